@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/stackgen-cli/dataclean/internal/config"
+	"github.com/stackgen-cli/dataclean/internal/docker"
+	"github.com/stackgen-cli/dataclean/internal/models"
+	"github.com/stackgen-cli/dataclean/internal/snapshot"
+)
+
+var (
+	adoptVolume      string
+	adoptType        string
+	adoptName        string
+	adoptTags        []string
+	adoptDescription string
+)
+
+var adoptCmd = &cobra.Command{
+	Use:   "adopt <tarball>",
+	Short: "Register an existing volume backup as a snapshot",
+	Long: `Register an externally created tar.gz of a volume (for example from an
+old backup script) as a dataclean snapshot, so it can be restored, browsed,
+and diffed like any other snapshot.
+
+The archive must contain the volume's contents at its root, as produced by
+'tar czf backup.tar.gz -C /path/to/volume .'.
+
+The volume can be given by its full Docker name or its compose name. When it
+matches a volume in the compose file, its service and datastore type are
+filled in automatically; --type overrides the detected type.
+
+Examples:
+  dataclean adopt ~/backups/pg-2023-11-02.tar.gz --volume pgdata --type postgres
+  dataclean adopt redis.tar.gz --volume myapp_redisdata --name redis-seed`,
+	Args: cobra.ExactArgs(1),
+	RunE: runAdopt,
+}
+
+func init() {
+	rootCmd.AddCommand(adoptCmd)
+	withSummary(adoptCmd)
+
+	adoptCmd.Flags().StringVar(&adoptVolume, "volume", "", "volume the archive belongs to (required)")
+	adoptCmd.Flags().StringVar(&adoptType, "type", "", "datastore type (postgres, mysql, redis, mongodb, neo4j, generic)")
+	adoptCmd.Flags().StringVar(&adoptName, "name", "", "snapshot name (default: adopted-<tarball name>)")
+	adoptCmd.Flags().StringSliceVarP(&adoptTags, "tag", "t", nil, "Tags to add to snapshot")
+	adoptCmd.Flags().StringVarP(&adoptDescription, "description", "d", "", "Description for snapshot")
+	adoptCmd.MarkFlagRequired("volume")
+}
+
+func runAdopt(cmd *cobra.Command, args []string) error {
+	tarball := args[0]
+
+	name := adoptName
+	if name == "" {
+		base := filepath.Base(tarball)
+		for _, ext := range []string{".tar.gz", ".tgz"} {
+			base = strings.TrimSuffix(base, ext)
+		}
+		name = "adopted-" + base
+	}
+
+	// Load config
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := guardReadOnly(cfg); err != nil {
+		return err
+	}
+
+	client, err := docker.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to connect to Docker: %w", err)
+	}
+	defer client.Close()
+
+	vol := adoptedVolume(client, cfg)
+	if adoptType != "" {
+		dt, err := parseDatastoreType(adoptType)
+		if err != nil {
+			return err
+		}
+		vol.DatastoreType = dt
+	}
+
+	if dryRun {
+		dryRunNote("would adopt %s as snapshot %s (volume %s, %s)", tarball, name, vol.Name, vol.DatastoreType)
+		return nil
+	}
+
+	mgr := snapshot.NewManager(client, cfg)
+	opts := snapshot.CreateOptions{
+		Tags:        adoptTags,
+		Description: adoptDescription,
+	}
+	result, err := mgr.Adopt(name, tarball, vol, opts)
+	if err != nil {
+		return fmt.Errorf("failed to adopt %s: %w", tarball, err)
+	}
+	summarize("name", result.Name)
+	summarize("size_bytes", result.SizeBytes)
+
+	if !quiet {
+		color.Green("✅ Adopted %s as snapshot: %s", tarball, result.Name)
+		fmt.Printf("   Volume: %s (%s)\n", vol.Name, vol.DatastoreType)
+		fmt.Printf("   Size: %s\n", result.SizeHuman)
+		fmt.Printf("   Restore with: dataclean restore %s\n", result.Name)
+	}
+
+	return nil
+}
+
+// adoptedVolume matches --volume against the compose volumes, falling back
+// to a bare volume of that name when there's no compose file or no match
+func adoptedVolume(client *docker.Client, cfg *models.Config) models.Volume {
+	if volumes, err := client.DetectComposeVolumes(cfg); err == nil {
+		for _, v := range volumes {
+			if v.Name == adoptVolume || v.ComposeName == adoptVolume {
+				return v
+			}
+		}
+	}
+	return models.Volume{Name: adoptVolume, DatastoreType: models.DatastoreGeneric}
+}
+
+// parseDatastoreType validates a datastore type given on the command line
+func parseDatastoreType(s string) (models.DatastoreType, error) {
+	var names []string
+	for _, dt := range models.AvailableDatastores() {
+		if string(dt) == strings.ToLower(s) {
+			return dt, nil
+		}
+		names = append(names, string(dt))
+	}
+	return "", fmt.Errorf("unknown datastore type %q (expected one of: %s)", s, strings.Join(names, ", "))
+}
@@ -0,0 +1,247 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/stackgen-cli/dataclean/internal/config"
+	"github.com/stackgen-cli/dataclean/internal/docker"
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+var aliasCmd = &cobra.Command{
+	Use:   "alias",
+	Short: "Adopt anonymous volumes under stable names",
+	Long: `Some images declare VOLUME paths that compose doesn't name, so Docker mounts
+an anonymous volume with a hash for a name, and a new one each time the
+container is recreated. dataclean skips those, since the name it would record
+means nothing after the next 'docker compose up'.
+
+An alias adopts such a volume by its service and mount path. The alias is
+recorded in volume_aliases in the config, along with the volume's hash at the
+time, and from then on the volume is snapshotted and restored under the alias:
+whichever anonymous volume is mounted there when dataclean runs is the one it
+reads or replaces.
+
+Examples:
+  dataclean alias list
+  dataclean alias add search-data search:/usr/share/elasticsearch/data
+  dataclean alias add cache-data cache
+  dataclean alias remove cache-data`,
+}
+
+var aliasListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Show aliases and the anonymous volumes that could be adopted",
+	Args:  cobra.NoArgs,
+	RunE:  runAliasList,
+}
+
+var aliasAddCmd = &cobra.Command{
+	Use:   "add <alias> <service>[:<path>]",
+	Short: "Adopt a service's anonymous volume under an alias",
+	Long: `Adopt the anonymous volume a service's container mounts at path under a
+stable alias. The path can be left out when the service has only one
+anonymous volume. The service needs a container (running or not); create one
+with 'docker compose up --no-start <service>'.`,
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true,
+	RunE:         runAliasAdd,
+}
+
+var aliasRemoveCmd = &cobra.Command{
+	Use:          "remove <alias>",
+	Short:        "Stop adopting an anonymous volume",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE:         runAliasRemove,
+}
+
+func init() {
+	rootCmd.AddCommand(aliasCmd)
+	aliasCmd.AddCommand(aliasListCmd)
+	aliasCmd.AddCommand(aliasAddCmd)
+	aliasCmd.AddCommand(aliasRemoveCmd)
+	withSummary(aliasAddCmd)
+	withSummary(aliasRemoveCmd)
+}
+
+// anonymousMounts lists the project's anonymous volumes through a fresh client
+func anonymousMounts() ([]docker.AnonymousMount, error) {
+	client, err := docker.NewClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Docker client: %w", err)
+	}
+	defer client.Close()
+	return client.AnonymousMounts()
+}
+
+func runAliasList(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	mounts, err := anonymousMounts()
+	if err != nil {
+		return err
+	}
+
+	if len(cfg.VolumeAliases) == 0 {
+		fmt.Println("No volume aliases configured.")
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ALIAS\tSERVICE\tPATH\tVOLUME")
+		for _, a := range cfg.VolumeAliases {
+			volume := "(no container)"
+			if m, ok := docker.FindAnonymous(mounts, a.Service, a.Target); ok {
+				volume = shortHash(m.Volume)
+				if a.Volume != "" && a.Volume != m.Volume {
+					volume += " (recreated since adopted)"
+				}
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", a.Alias, a.Service, a.Target, volume)
+		}
+		w.Flush()
+	}
+
+	var unaliased []docker.AnonymousMount
+	for _, m := range mounts {
+		if aliasFor(cfg, m.Service, m.Target) == nil {
+			unaliased = append(unaliased, m)
+		}
+	}
+	if len(unaliased) > 0 {
+		fmt.Println()
+		color.New(color.FgYellow).Println("Anonymous volumes without an alias (skipped by snapshots):")
+		for _, m := range unaliased {
+			fmt.Printf("  %s:%s (%s)\n", m.Service, m.Target, shortHash(m.Volume))
+		}
+		fmt.Println("Adopt one with: dataclean alias add <alias> <service>:<path>")
+	}
+	return nil
+}
+
+func runAliasAdd(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := guardReadOnly(cfg); err != nil {
+		return err
+	}
+	name := args[0]
+	service, target, _ := strings.Cut(args[1], ":")
+	if slices.ContainsFunc(cfg.VolumeAliases, func(a models.VolumeAlias) bool { return a.Alias == name }) {
+		return fmt.Errorf("alias '%s' already exists", name)
+	}
+	if target != "" {
+		if a := aliasFor(cfg, service, target); a != nil {
+			return fmt.Errorf("%s:%s already has the alias '%s'", service, target, a.Alias)
+		}
+	}
+
+	mounts, err := anonymousMounts()
+	if err != nil {
+		return err
+	}
+	var candidates []docker.AnonymousMount
+	for _, m := range mounts {
+		if m.Service == service && (target == "" || m.Target == target) {
+			candidates = append(candidates, m)
+		}
+	}
+	switch {
+	case len(candidates) == 0 && target == "":
+		return fmt.Errorf("service '%s' has no container with an anonymous volume (create one with 'docker compose up --no-start %s')", service, service)
+	case len(candidates) == 0:
+		return fmt.Errorf("no anonymous volume is mounted at %s in service '%s' (create its container with 'docker compose up --no-start %s')", target, service, service)
+	case len(candidates) > 1:
+		var paths []string
+		for _, m := range candidates {
+			paths = append(paths, m.Target)
+		}
+		return fmt.Errorf("service '%s' has %d anonymous volumes (%s); name the path as %s:<path>", service, len(candidates), strings.Join(paths, ", "), service)
+	}
+	mount := candidates[0]
+	if a := aliasFor(cfg, mount.Service, mount.Target); a != nil {
+		return fmt.Errorf("%s:%s already has the alias '%s'", mount.Service, mount.Target, a.Alias)
+	}
+
+	alias := models.VolumeAlias{Alias: name, Service: mount.Service, Target: mount.Target, Volume: mount.Volume}
+	aliases := append(slices.Clone(cfg.VolumeAliases), alias)
+	file := config.Path(cfgFile)
+	summarize("alias", name)
+	summarize("volume", mount.Volume)
+
+	if dryRun {
+		dryRunNote("would adopt %s:%s (%s) as '%s' in %s", mount.Service, mount.Target, shortHash(mount.Volume), name, file)
+		return nil
+	}
+	if err := config.SetKey(file, "volume_aliases", aliases); err != nil {
+		return fmt.Errorf("failed to update %s: %w", file, err)
+	}
+
+	if !quiet {
+		color.Green("✅ Adopted %s:%s as '%s'", mount.Service, mount.Target, name)
+		fmt.Printf("   Recorded in %s; it is snapshotted and restored from now on\n", file)
+	}
+	return nil
+}
+
+func runAliasRemove(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := guardReadOnly(cfg); err != nil {
+		return err
+	}
+	i := slices.IndexFunc(cfg.VolumeAliases, func(a models.VolumeAlias) bool { return a.Alias == args[0] })
+	if i < 0 {
+		return fmt.Errorf("no alias named '%s'", args[0])
+	}
+	file := config.Path(cfgFile)
+	summarize("alias", args[0])
+
+	if dryRun {
+		dryRunNote("would remove alias '%s' from %s", args[0], file)
+		return nil
+	}
+	var value any
+	if aliases := slices.Delete(slices.Clone(cfg.VolumeAliases), i, i+1); len(aliases) > 0 {
+		value = aliases
+	}
+	if err := config.SetKey(file, "volume_aliases", value); err != nil {
+		return fmt.Errorf("failed to update %s: %w", file, err)
+	}
+
+	if !quiet {
+		color.Green("✅ Removed alias '%s'", args[0])
+		fmt.Println("   Its volume is skipped by snapshots and restores again")
+	}
+	return nil
+}
+
+// aliasFor returns the alias configured for a service mount, if any
+func aliasFor(cfg *models.Config, service, target string) *models.VolumeAlias {
+	for i, a := range cfg.VolumeAliases {
+		if a.Service == service && a.Target == target {
+			return &cfg.VolumeAliases[i]
+		}
+	}
+	return nil
+}
+
+// shortHash abbreviates an anonymous volume's name the way docker does ids
+func shortHash(name string) string {
+	if len(name) > 12 {
+		return name[:12]
+	}
+	return name
+}
@@ -0,0 +1,335 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/stackgen-cli/dataclean/internal/config"
+	"github.com/stackgen-cli/dataclean/internal/docker"
+	"github.com/stackgen-cli/dataclean/internal/models"
+	"github.com/stackgen-cli/dataclean/internal/snapshot"
+)
+
+var applyCmd = &cobra.Command{
+	Use:   "apply <plan.yaml>",
+	Short: "Run a plan file of snapshot, reset, and restore steps",
+	Long: `Run the steps of a plan file in order, for environment refreshes that are
+checked into the repo and repeated the same way every time:
+
+  description: Refresh dev data from the seeded baseline
+  steps:
+    - snapshot: before-refresh      # volumes, tags, and description optional
+      tags: [auto]
+    - reset: [redisdata]            # Docker or compose names
+    - restore: seeded
+      into:
+        pgdata: pgdata_copy         # restore the snapshot's pgdata into pgdata_copy
+
+Every step is checked before anything runs: snapshots to restore must exist
+(or be taken by an earlier step), volumes must be detected, and protected
+volumes and the recorded Docker daemon are guarded as in restore and reset.
+One confirmation covers the whole plan, and --dry-run shows the steps of every
+operation together. Steps run one at a time and the first failure stops the
+rest. A restore step with into writes those snapshot volumes into the named
+volumes, created if missing, and leaves their own volumes alone.
+
+Examples:
+  dataclean apply refresh.yaml --dry-run
+  dataclean apply refresh.yaml
+  dataclean apply refresh.yaml --force`,
+	Args: cobra.ExactArgs(1),
+	RunE: runApply,
+}
+
+func init() {
+	rootCmd.AddCommand(applyCmd)
+	withSummary(applyCmd)
+	withSafetyOverrides(applyCmd)
+}
+
+// applyStep is a plan file step resolved against the detected volumes
+type applyStep struct {
+	models.RecipeStep
+	volumes []models.Volume          // snapshot and reset: the volumes the step works on
+	into    map[string]models.Volume // restore: targets by snapshot volume
+	pending int                      // restore: the earlier step taking the snapshot, 0 if it exists
+}
+
+func runApply(cmd *cobra.Command, args []string) error {
+	recipe, err := config.LoadRecipe(args[0])
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := guardReadOnly(cfg); err != nil {
+		return err
+	}
+
+	client, err := docker.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to connect to Docker: %w", err)
+	}
+	defer client.Close()
+	detected, err := client.DetectComposeVolumes(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to detect volumes: %w", err)
+	}
+	if err := guardDaemon(cfg, client); err != nil {
+		return err
+	}
+
+	mgr := snapshot.NewManager(client, cfg)
+	steps, overwritten, err := resolveApplySteps(mgr, recipe, detected)
+	if err != nil {
+		return err
+	}
+	if err := guardProtected(cfg, overwritten); err != nil {
+		return err
+	}
+
+	if !quiet && !jsonOutput {
+		color.Yellow("⚠️  APPLY will run %d step(s) from %s:", len(steps), args[0])
+		if recipe.Description != "" {
+			fmt.Printf("   %s\n", recipe.Description)
+		}
+		fmt.Println()
+		for i, s := range steps {
+			fmt.Printf("  %d. %s\n", i+1, s)
+		}
+		fmt.Println()
+	}
+
+	if !force && !dryRun && len(overwritten) > 0 {
+		color.Red("⚠️  This will DELETE existing data in: %s", strings.Join(volumeNames(overwritten), ", "))
+		fmt.Print("Type 'yes' to confirm: ")
+		reader := bufio.NewReader(os.Stdin)
+		response, _ := reader.ReadString('\n')
+		response = strings.TrimSpace(strings.ToLower(response))
+		if response != "yes" {
+			warn("Aborted.")
+			summarize("aborted", true)
+			return nil
+		}
+	}
+
+	if dryRun {
+		plan, err := planApply(mgr, args[0], steps)
+		if err != nil {
+			return err
+		}
+		return printPlan(plan)
+	}
+
+	ensureGitignored(cfg)
+	for i, s := range steps {
+		if !quiet && !jsonOutput {
+			color.Cyan("▶️  Step %d: %s", i+1, s)
+		}
+		if err := runApplyStep(cfg, mgr, s); err != nil {
+			summarize("steps", i)
+			return fmt.Errorf("step %d (%s) failed: %w", i+1, s, err)
+		}
+	}
+	summarize("steps", len(steps))
+
+	if !quiet && !jsonOutput {
+		color.Green("✅ Applied %s (%d step(s))", args[0], len(steps))
+	}
+	return nil
+}
+
+// resolveApplySteps checks every step of a plan file before any runs. It
+// returns the steps with their volumes and every volume the plan overwrites.
+func resolveApplySteps(mgr *snapshot.Manager, recipe *models.Recipe, detected []models.Volume) ([]applyStep, []models.Volume, error) {
+	steps := make([]applyStep, len(recipe.Steps))
+	taken := map[string]int{} // snapshot name → step taking it
+	var overwritten []models.Volume
+	overwrite := func(vols ...models.Volume) {
+		for _, v := range vols {
+			if !slices.ContainsFunc(overwritten, func(o models.Volume) bool { return o.Name == v.Name }) {
+				overwritten = append(overwritten, v)
+			}
+		}
+	}
+
+	for i, rs := range recipe.Steps {
+		s := applyStep{RecipeStep: rs}
+		fail := func(err error) ([]applyStep, []models.Volume, error) {
+			return nil, nil, fmt.Errorf("step %d (%s): %w", i+1, rs, err)
+		}
+		switch rs.Op() {
+		case "snapshot":
+			if err := mgr.ValidateName(rs.Snapshot); err != nil {
+				return fail(err)
+			}
+			s.volumes = detected
+			if len(rs.Volumes) > 0 {
+				vols, err := findVolumes(detected, rs.Volumes)
+				if err != nil {
+					return fail(err)
+				}
+				s.volumes = vols
+			}
+			if len(s.volumes) == 0 {
+				return fail(fmt.Errorf("no Docker Compose volumes detected in current directory"))
+			}
+			taken[rs.Snapshot] = i + 1
+
+		case "reset":
+			vols, err := findVolumes(detected, rs.Reset)
+			if err != nil {
+				return fail(err)
+			}
+			s.volumes = vols
+			overwrite(vols...)
+
+		case "restore":
+			var snapVolumes []models.Volume
+			if step, ok := taken[rs.Restore]; ok {
+				s.pending = step
+				snapVolumes = steps[step-1].volumes
+			} else {
+				snap, err := mgr.Get(rs.Restore)
+				if err != nil {
+					return fail(fmt.Errorf("snapshot not found: %s", rs.Restore))
+				}
+				snapVolumes = snap.Volumes
+			}
+			s.into = make(map[string]models.Volume, len(rs.Into))
+			for from, to := range rs.Into {
+				if _, err := findVolumes(snapVolumes, []string{from}); err != nil {
+					return fail(fmt.Errorf("snapshot %s has no volume %s to restore into %s", rs.Restore, from, to))
+				}
+				target := models.Volume{Name: to}
+				if i := slices.IndexFunc(detected, func(v models.Volume) bool { return v.Name == to || v.ComposeName == to }); i >= 0 {
+					target = detected[i]
+				}
+				s.into[from] = target
+			}
+			for _, v := range snapVolumes {
+				target, ok := s.into[v.Name]
+				if !ok && v.ComposeName != "" {
+					target, ok = s.into[v.ComposeName]
+				}
+				if ok {
+					v = target
+				}
+				overwrite(v)
+			}
+		}
+		steps[i] = s
+	}
+	return steps, overwritten, nil
+}
+
+// findVolumes picks volumes by Docker or compose name
+func findVolumes(volumes []models.Volume, names []string) ([]models.Volume, error) {
+	var found []models.Volume
+	for _, name := range names {
+		i := slices.IndexFunc(volumes, func(v models.Volume) bool { return v.Name == name || v.ComposeName == name })
+		if i < 0 {
+			return nil, fmt.Errorf("volume %s not detected", name)
+		}
+		found = append(found, volumes[i])
+	}
+	return found, nil
+}
+
+func volumeNames(volumes []models.Volume) []string {
+	names := make([]string, len(volumes))
+	for i, v := range volumes {
+		names[i] = v.Name
+	}
+	return names
+}
+
+// planApply combines the dry-run plans of every step into one
+func planApply(mgr *snapshot.Manager, file string, steps []applyStep) (*models.Plan, error) {
+	combined := &models.Plan{Operation: "apply " + file}
+	for i, s := range steps {
+		var plan *models.Plan
+		var err error
+		switch {
+		case s.Op() == "snapshot":
+			plan, err = mgr.PlanCreate(s.Snapshot, s.volumes)
+		case s.Op() == "reset":
+			plan, err = mgr.PlanReset(s.volumes)
+		case s.pending > 0:
+			// The snapshot doesn't exist yet, so its archives can't be planned
+			plan = &models.Plan{}
+			plan.Add(models.PlanAction{
+				Kind:   models.ActionImportVolume,
+				Target: s.Restore,
+				Note:   fmt.Sprintf("restore the snapshot taken by step %d", s.pending),
+			})
+		default:
+			plan, err = mgr.PlanRestoreWithOptions(s.Restore, snapshot.RestoreOptions{Into: s.into})
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to plan step %d (%s): %w", i+1, s, err)
+		}
+		combined.Steps = append(combined.Steps, s.String())
+		for _, a := range plan.Actions {
+			a.Step = i + 1
+			combined.Add(a)
+		}
+	}
+	return combined, nil
+}
+
+// runApplyStep runs one step, reporting it like the matching command does
+func runApplyStep(cfg *models.Config, mgr *snapshot.Manager, s applyStep) error {
+	start := time.Now()
+	switch s.Op() {
+	case "snapshot":
+		opts := snapshot.CreateOptions{Tags: s.Tags, Description: s.Description}
+		result, err := mgr.CreateWithOptions(s.Snapshot, s.volumes, opts)
+		var written int64
+		if result != nil {
+			written = result.SizeBytes
+		}
+		reportCompletion(cfg, "snapshot", s.Snapshot, start, written, err)
+		if err != nil {
+			return err
+		}
+		reportSnapshotEvent(cfg, models.SnapshotCreated, result)
+		mirrorSnapshot(os.Stdout, cfg, result)
+		if !quiet && !jsonOutput {
+			fmt.Printf("   Snapshot created: %s (%s)\n", result.Name, result.SizeHuman)
+		}
+
+	case "reset":
+		if err := mgr.Reset(s.volumes); err != nil {
+			return err
+		}
+		if !quiet && !jsonOutput {
+			fmt.Printf("   Reset %s\n", strings.Join(volumeNames(s.volumes), ", "))
+		}
+
+	case "restore":
+		snap, err := mgr.Get(s.Restore)
+		if err != nil {
+			return fmt.Errorf("snapshot not found: %s", s.Restore)
+		}
+		result, err := mgr.RestoreWithOptions(s.Restore, snapshot.RestoreOptions{Into: s.into})
+		reportCompletion(cfg, "restore", s.Restore, start, snap.SizeBytes, err)
+		if !quiet && !jsonOutput {
+			printRestoreResult(result)
+		}
+		if err != nil {
+			return err
+		}
+		reportSnapshotEvent(cfg, models.SnapshotRestored, snap)
+	}
+	return nil
+}
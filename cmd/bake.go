@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/stackgen-cli/dataclean/internal/config"
+	"github.com/stackgen-cli/dataclean/internal/docker"
+	"github.com/stackgen-cli/dataclean/internal/snapshot"
+)
+
+var (
+	bakeImage    string
+	bakeBase     string
+	bakeDataPath string
+)
+
+var bakeCmd = &cobra.Command{
+	Use:   "bake <snapshot> [volume]",
+	Short: "Build a pre-seeded Docker image from a snapshot",
+	Long: `Build a Docker image with a snapshot volume's data pre-loaded at the
+datastore's data path, so CI can start a seeded container without restoring
+volumes at all.
+
+The image is based on the volume's service image unless --base is given.
+The volume argument is required when the snapshot holds more than one volume.
+
+Examples:
+  dataclean bake seeded --image myorg/devdb:seeded
+  dataclean bake seeded pgdata --image myorg/devdb:seeded
+  dataclean bake legacy pgdata --image devdb:legacy --base postgres:15`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runBake,
+}
+
+func init() {
+	rootCmd.AddCommand(bakeCmd)
+	withSummary(bakeCmd)
+
+	bakeCmd.Flags().StringVar(&bakeImage, "image", "", "tag for the built image (required)")
+	bakeCmd.Flags().StringVar(&bakeBase, "base", "", "base image (default: the volume's service image)")
+	bakeCmd.Flags().StringVar(&bakeDataPath, "data-path", "", "data directory in the image (default: the volume's mount path)")
+	bakeCmd.MarkFlagRequired("image")
+}
+
+func runBake(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	// Load config
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	client, err := docker.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to connect to Docker: %w", err)
+	}
+	defer client.Close()
+
+	mgr := snapshot.NewManager(client, cfg)
+	snap, err := mgr.Get(name)
+	if err != nil {
+		return fmt.Errorf("snapshot not found: %s", name)
+	}
+
+	var volume string
+	switch {
+	case len(args) > 1:
+		volume = args[1]
+	case len(snap.Volumes) == 1:
+		volume = snap.Volumes[0].Name
+	default:
+		var names []string
+		for _, v := range snap.Volumes {
+			names = append(names, v.Name)
+		}
+		return fmt.Errorf("snapshot %s has %d volumes; choose one of: %s", name, len(names), strings.Join(names, ", "))
+	}
+
+	if dryRun {
+		dryRunNote("would build %s from %s/%s", bakeImage, name, volume)
+		return nil
+	}
+
+	if !quiet {
+		color.Cyan("🍞 Baking %s/%s into %s", name, volume, bakeImage)
+	}
+
+	opts := snapshot.BakeOptions{
+		Image:    bakeImage,
+		Base:     bakeBase,
+		DataPath: bakeDataPath,
+	}
+	if err := mgr.Bake(name, volume, opts); err != nil {
+		return fmt.Errorf("failed to bake image: %w", err)
+	}
+	summarize("image", bakeImage)
+
+	if !quiet {
+		color.Green("✅ Built image: %s", bakeImage)
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stackgen-cli/dataclean/internal/config"
+	"github.com/stackgen-cli/dataclean/internal/models"
+	"github.com/stackgen-cli/dataclean/internal/snapshot"
+	"github.com/stackgen-cli/dataclean/internal/tui"
+)
+
+var browseList bool
+
+var browseCmd = &cobra.Command{
+	Use:   "browse <snapshot> [volume]",
+	Short: "Browse files inside a snapshot without extracting it",
+	Long: `Show the file listing stored in a snapshot's volume archives, including
+file sizes and modification times, without restoring or extracting anything.
+
+By default an interactive tree view is shown. Use --list for plain output.
+
+Examples:
+  dataclean browse before-migration
+  dataclean browse before-migration pgdata
+  dataclean browse before-migration pgdata --list
+  dataclean browse before-migration pgdata --json`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runBrowse,
+}
+
+func init() {
+	rootCmd.AddCommand(browseCmd)
+
+	browseCmd.Flags().BoolVar(&browseList, "list", false, "print a plain listing instead of the interactive tree")
+}
+
+func runBrowse(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	// Load config
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	mgr := snapshot.NewManager(nil, cfg)
+	snap, err := mgr.Get(name)
+	if err != nil {
+		return fmt.Errorf("snapshot not found: %s", name)
+	}
+
+	// Browse one volume or all of them
+	volumes := snap.Volumes
+	if len(args) > 1 {
+		vol, err := snapshot.FindVolume(snap, args[1])
+		if err != nil {
+			return err
+		}
+		volumes = []models.Volume{*vol}
+	}
+
+	listings := make(map[string][]models.ArchiveEntry)
+	for _, v := range volumes {
+		entries, err := mgr.ArchiveEntries(name, v.Name)
+		if err != nil {
+			return fmt.Errorf("failed to read volume %s: %w", v.Name, err)
+		}
+		listings[v.Name] = entries
+	}
+
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(listings)
+	}
+
+	if browseList {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, v := range volumes {
+			fmt.Fprintf(w, "%s:\n", v.Name)
+			for _, e := range listings[v.Name] {
+				fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n",
+					e.Mode,
+					models.FormatSize(e.SizeBytes),
+					e.ModTime.Format("2006-01-02 15:04"),
+					e.Path,
+				)
+			}
+		}
+		return w.Flush()
+	}
+
+	keys, err := setupTUI(cfg)
+	if err != nil {
+		return err
+	}
+
+	var roots []*tui.TreeNode
+	for _, v := range volumes {
+		roots = append(roots, tui.BuildTree(v.Name, listings[v.Name]))
+	}
+	return tui.RunTreeBrowser(fmt.Sprintf("Snapshot: %s", name), roots, keys)
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stackgen-cli/dataclean/internal/catalog"
+	"github.com/stackgen-cli/dataclean/internal/config"
+	"github.com/stackgen-cli/dataclean/internal/docker"
+	"github.com/stackgen-cli/dataclean/internal/snapshot"
+)
+
+var (
+	reportFormat      string
+	reportByDatastore bool
+	reportOutput      string
+)
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Write a Markdown or CSV catalogue of all snapshots",
+	Long: `Write a catalogue of every snapshot (name, date, size, tags, volumes,
+description) followed by storage totals per datastore, ready to paste into a
+wiki page or open in a spreadsheet.
+
+Live volume sizes are included when Docker is reachable.
+
+With --format csv, the output is one row per snapshot; add --by-datastore to
+get the per-datastore totals instead.
+
+Examples:
+  dataclean report > SNAPSHOTS.md
+  dataclean report --format csv -o snapshots.csv
+  dataclean report --format csv --by-datastore`,
+	Args: cobra.NoArgs,
+	RunE: runReport,
+}
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+
+	reportCmd.Flags().StringVar(&reportFormat, "format", "markdown", "output format: markdown or csv")
+	reportCmd.Flags().BoolVar(&reportByDatastore, "by-datastore", false, "CSV only: write per-datastore totals instead of snapshots")
+	reportCmd.Flags().StringVarP(&reportOutput, "output", "o", "", "write to this file instead of stdout")
+}
+
+func runReport(cmd *cobra.Command, args []string) error {
+	var write func(io.Writer, *catalog.Catalog) error
+	switch {
+	case reportFormat == "markdown" || reportFormat == "md":
+		if reportByDatastore {
+			return fmt.Errorf("--by-datastore only applies to --format csv (Markdown always includes the totals)")
+		}
+		write = catalog.WriteMarkdown
+	case reportFormat == "csv" && reportByDatastore:
+		write = catalog.WriteRollupsCSV
+	case reportFormat == "csv":
+		write = catalog.WriteSnapshotsCSV
+	default:
+		return fmt.Errorf("unknown format %q (use markdown or csv)", reportFormat)
+	}
+
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Live sizes are a bonus; the catalogue itself only needs the snapshot directory
+	client, clientErr := docker.NewClient()
+	if clientErr == nil {
+		defer client.Close()
+	}
+	mgr := snapshot.NewManager(client, cfg)
+
+	snapshots, err := mgr.List()
+	if err != nil {
+		return fmt.Errorf("failed to list snapshots: %w", err)
+	}
+	c := &catalog.Catalog{
+		Project:   projectName(),
+		Generated: time.Now(),
+		Snapshots: snapshots,
+	}
+	if clientErr == nil {
+		if volumes, err := client.DetectComposeVolumes(cfg); err == nil && len(volumes) > 0 {
+			c.Sizes, _ = mgr.GetSizeReport(volumes)
+		}
+	}
+
+	out := io.Writer(os.Stdout)
+	if reportOutput != "" {
+		f, err := os.Create(reportOutput)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", reportOutput, err)
+		}
+		defer f.Close()
+		out = f
+	}
+	if err := write(out, c); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	if reportOutput != "" && !quiet {
+		fmt.Printf("Wrote %d snapshot(s) to %s\n", len(snapshots), reportOutput)
+	}
+	return nil
+}
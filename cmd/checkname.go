@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/stackgen-cli/dataclean/internal/config"
+	"github.com/stackgen-cli/dataclean/internal/snapshot"
+)
+
+var checkNameCmd = &cobra.Command{
+	Use:   "check-name [name...]",
+	Short: "Check snapshot names against the naming rules, or print the rules",
+	Long: `Check names before using them for snapshots, or, without names, print the
+rules new snapshot names must follow.
+
+Names may use letters, digits, '.', '_' and '-', must start with a letter or
+digit, and are at most 100 characters long. Names starting with '_' are kept
+for dataclean's own backups. A name_pattern in the config adds a regular
+expression every new name must match as well.
+
+Exits non-zero if any name is invalid.
+
+Examples:
+  dataclean check-name                  # print the rules
+  dataclean check-name --json           # the rules, for scripts
+  dataclean check-name feature-login "before migration"`,
+	RunE:         runCheckName,
+	SilenceUsage: true,
+}
+
+func init() {
+	rootCmd.AddCommand(checkNameCmd)
+	withSummary(checkNameCmd)
+}
+
+// nameCheck is one name's result in --json output
+type nameCheck struct {
+	Name  string `json:"name"`
+	Valid bool   `json:"valid"`
+	Error string `json:"error,omitempty"`
+}
+
+func runCheckName(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	mgr := snapshot.NewManager(nil, cfg)
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if len(args) == 0 {
+		rules := mgr.NameRules()
+		if jsonOutput {
+			return enc.Encode(rules)
+		}
+		fmt.Printf("Characters:        %s\n", rules.Charset)
+		fmt.Printf("First character:   %s\n", rules.FirstChar)
+		fmt.Printf("Max length:        %d\n", rules.MaxLength)
+		fmt.Printf("Reserved prefixes: %s\n", strings.Join(rules.ReservedPrefixes, " "))
+		if rules.Pattern != "" {
+			fmt.Printf("name_pattern:      %s\n", rules.Pattern)
+		}
+		return nil
+	}
+
+	checks := make([]nameCheck, len(args))
+	invalid := 0
+	for i, name := range args {
+		checks[i] = nameCheck{Name: name, Valid: true}
+		if err := mgr.ValidateName(name); err != nil {
+			checks[i] = nameCheck{Name: name, Error: err.Error()}
+			invalid++
+		}
+	}
+	summarize("names", len(args))
+	summarize("invalid", invalid)
+
+	if jsonOutput {
+		if err := enc.Encode(checks); err != nil {
+			return err
+		}
+	} else if !quiet {
+		for _, c := range checks {
+			if c.Valid {
+				color.Green("  ✓ %s", c.Name)
+			} else {
+				color.Red("  ✗ %s", c.Error)
+			}
+		}
+	}
+	if invalid > 0 {
+		return fmt.Errorf("%d invalid snapshot name(s)", invalid)
+	}
+	return nil
+}
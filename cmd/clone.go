@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/stackgen-cli/dataclean/internal/config"
+	"github.com/stackgen-cli/dataclean/internal/docker"
+	"github.com/stackgen-cli/dataclean/internal/models"
+	"github.com/stackgen-cli/dataclean/internal/snapshot"
+)
+
+var (
+	cloneProject  string
+	cloneOverride string
+)
+
+// composeProjectName is what docker compose accepts as a project name
+var composeProjectName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+var cloneCmd = &cobra.Command{
+	Use:   "clone <snapshot>",
+	Short: "Restore a snapshot into a copy of the volumes for a parallel environment",
+	Long: `Restore a snapshot into new volumes next to the project's own, which are left
+untouched, so a second environment can run on the snapshot's data.
+
+The copies are named the way compose names the volumes of another project,
+<project>_<volume>, with the project given by --project (default: this
+project's name plus -clone), and labelled for it. Start the second stack with
+
+  docker compose -p <project> up -d
+
+and it runs on the copies. With --override, a compose override file
+(docker-compose.override.yaml unless a name is given) is written that points
+this project's services at the copies instead, for the next 'docker compose
+up -d'. An existing override file is only replaced with --force.
+
+Copies that already exist are replaced, after confirmation unless --force is
+given. No pre-restore backup is taken: the project's own volumes aren't
+touched.
+
+Examples:
+  dataclean clone seeded
+  dataclean clone seeded --project shop-review
+  dataclean clone seeded --override
+  dataclean clone seeded --dry-run`,
+	Args: cobra.ExactArgs(1),
+	RunE: runClone,
+}
+
+func init() {
+	rootCmd.AddCommand(cloneCmd)
+	withSummary(cloneCmd)
+	withSafetyOverrides(cloneCmd)
+
+	cloneCmd.Flags().StringVar(&cloneProject, "project", "", "compose project the copies belong to (default: <project>-clone)")
+	cloneCmd.Flags().StringVar(&cloneOverride, "override", "", "write a compose override file pointing the services at the copies")
+	cloneCmd.Flags().Lookup("override").NoOptDefVal = "docker-compose.override.yaml"
+}
+
+func runClone(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := guardReadOnly(cfg); err != nil {
+		return err
+	}
+
+	project := cloneProject
+	if project == "" {
+		project = strings.ToLower(projectName()) + "-clone"
+	}
+	if !composeProjectName.MatchString(project) {
+		return fmt.Errorf("invalid project name %q (lowercase letters, digits, '_' and '-')", project)
+	}
+	if cloneOverride != "" && !force {
+		if _, err := os.Stat(cloneOverride); err == nil {
+			return fmt.Errorf("%s already exists (pass --force to replace it)", cloneOverride)
+		}
+	}
+
+	client, err := docker.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to connect to Docker: %w", err)
+	}
+	defer client.Close()
+
+	mgr := snapshot.NewManager(client, cfg)
+	snap, err := mgr.Get(name)
+	if err != nil {
+		return fmt.Errorf("snapshot not found: %s", name)
+	}
+	if err := guardDaemon(cfg, client); err != nil {
+		return err
+	}
+
+	// Copies are named as compose would name the volumes in the clone's project
+	into := make(map[string]models.Volume, len(snap.Volumes))
+	override := make(map[string]string)
+	var copies, existing []models.Volume
+	for _, v := range snap.Volumes {
+		composeName := v.ComposeName
+		if composeName == "" {
+			composeName = v.Name
+		}
+		target := models.Volume{Name: project + "_" + composeName, ComposeName: v.ComposeName}
+		if target.Name == v.Name {
+			return fmt.Errorf("volume %s would be cloned onto itself; pick another --project", v.Name)
+		}
+		into[v.Name] = target
+		copies = append(copies, target)
+		if v.ComposeName != "" {
+			override[v.ComposeName] = target.Name
+		}
+		if _, exists, err := client.VolumeLabels(target.Name); err != nil {
+			return err
+		} else if exists {
+			existing = append(existing, target)
+		}
+	}
+	if err := guardProtected(cfg, copies); err != nil {
+		return err
+	}
+	if cloneOverride != "" && len(override) == 0 {
+		return fmt.Errorf("snapshot %s records no compose volume names, so no override can point at the copies", name)
+	}
+
+	if !quiet && !jsonOutput {
+		color.Cyan("🧬 Cloning snapshot %s into project %s:", name, project)
+		fmt.Println()
+		for _, v := range snap.Volumes {
+			fmt.Printf("  • %s → %s (%s)\n", v.Name, into[v.Name].Name, v.DatastoreType)
+		}
+		fmt.Println()
+	}
+
+	if !force && !dryRun && len(existing) > 0 {
+		color.Red("⚠️  This will DELETE the data in the existing copies: %s", strings.Join(volumeNames(existing), ", "))
+		fmt.Print("Type 'yes' to confirm: ")
+		reader := bufio.NewReader(os.Stdin)
+		response, _ := reader.ReadString('\n')
+		response = strings.TrimSpace(strings.ToLower(response))
+		if response != "yes" {
+			warn("Aborted.")
+			summarize("aborted", true)
+			return nil
+		}
+	}
+
+	opts := snapshot.RestoreOptions{Into: into, SkipBackup: true}
+	if dryRun {
+		plan, err := mgr.PlanRestoreWithOptions(name, opts)
+		if err != nil {
+			return fmt.Errorf("failed to plan clone: %w", err)
+		}
+		plan.Operation = "clone"
+		if err := printPlan(plan); err != nil {
+			return err
+		}
+		if cloneOverride != "" {
+			dryRunNote("would write %s pointing %d volume(s) at the copies", cloneOverride, len(override))
+		}
+		return nil
+	}
+
+	start := time.Now()
+	result, err := mgr.RestoreWithOptions(name, opts)
+	reportCompletion(cfg, "clone", name, start, snap.SizeBytes, err)
+	summarizeRestore(result)
+	if !quiet && !jsonOutput {
+		printRestoreResult(result)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to clone snapshot: %w", err)
+	}
+	summarize("project", project)
+
+	if cloneOverride != "" {
+		data, err := docker.VolumeOverride(fmt.Sprintf("Generated by 'dataclean clone %s': runs the services on the copies in project %s", name, project), override)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(cloneOverride, data, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", cloneOverride, err)
+		}
+		summarize("override", cloneOverride)
+	}
+
+	if !quiet && !jsonOutput {
+		color.Green("✅ Cloned snapshot %s into project %s", name, project)
+		fmt.Printf("   Start it with: docker compose -p %s up -d\n", project)
+		if cloneOverride != "" {
+			fmt.Printf("   Or run this project on the copies: %s is written, so 'docker compose up -d' uses them\n", cloneOverride)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/stackgen-cli/dataclean/internal/config"
+	"github.com/stackgen-cli/dataclean/internal/models"
+	"github.com/stackgen-cli/dataclean/internal/snapshot"
+)
+
+var compactPrune bool
+
+var compactCmd = &cobra.Command{
+	Use:   "compact <base>",
+	Short: "Merge incremental snapshots into full snapshots",
+	Long: `Merge every incremental chain built on <base> into full snapshots.
+
+Incremental snapshots (created with 'snapshot --parent') only store the
+volumes that changed and read the rest from their parents. Compacting copies
+the inherited archives into the newest snapshot of each chain, so restores no
+longer walk the chain and retention can delete old parents safely.
+
+With --prune, the snapshots between <base> and the compacted ones are deleted
+afterwards. <base> itself is always kept.
+
+Examples:
+  dataclean compact baseline
+  dataclean compact baseline --prune`,
+	Args: cobra.ExactArgs(1),
+	RunE: runCompact,
+}
+
+func init() {
+	rootCmd.AddCommand(compactCmd)
+	withSummary(compactCmd)
+
+	compactCmd.Flags().BoolVar(&compactPrune, "prune", false, "delete intermediate snapshots after compacting")
+}
+
+func runCompact(cmd *cobra.Command, args []string) error {
+	base := args[0]
+
+	// Load config
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := guardReadOnly(cfg); err != nil {
+		return err
+	}
+
+	mgr := snapshot.NewManager(nil, cfg)
+
+	if dryRun {
+		dependents, err := mgr.Dependents(base)
+		if err != nil {
+			return fmt.Errorf("failed to list snapshots: %w", err)
+		}
+		dryRunNote("would compact incremental snapshots based on %s: %v", base, dependents)
+		return nil
+	}
+
+	var before map[string]models.Snapshot
+	if compactPrune {
+		before = snapshotsBefore(cfg, mgr)
+	}
+	result, err := mgr.Compact(base, compactPrune)
+	if result != nil {
+		reportPruned(cfg, before, result.Deleted)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to compact: %w", err)
+	}
+	summarize("compacted", len(result.Compacted))
+	summarize("deleted", len(result.Deleted))
+
+	if !quiet {
+		color.Green("✅ Compacted %d snapshot(s) into full snapshots", len(result.Compacted))
+		for _, name := range result.Compacted {
+			fmt.Printf("  • %s\n", name)
+		}
+		if len(result.Deleted) > 0 {
+			fmt.Printf("   Removed %d intermediate snapshot(s): %v\n", len(result.Deleted), result.Deleted)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,297 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/stackgen-cli/dataclean/internal/config"
+	"github.com/stackgen-cli/dataclean/internal/models"
+	"github.com/stackgen-cli/dataclean/internal/remote"
+	"github.com/stackgen-cli/dataclean/internal/snapshot"
+)
+
+var (
+	copyFrom string
+	copyTo   string
+	copyAs   string
+	copyAll  bool
+)
+
+var copyCmd = &cobra.Command{
+	Use:   "copy <snapshot>... --from <store> --to <store>",
+	Short: "Copy snapshots between snapshot stores and remotes",
+	Long: `Copy snapshots from one store to another. Either side is a snapshot
+directory (a path, such as a teammate's exported .dataclean or another
+project's global store), a remote (a file:// URL, or 'remote' for remote.url
+in the config), or, when left out, this project's own store.
+
+Snapshots are carried as bundles, the way push and pull move them: incremental
+snapshots arrive flattened, archives go where the destination's storage rules
+put them, and every archive is checked against its checksum before the copy
+is kept. Snapshots on a remote are named by key or share token. --all copies
+every snapshot but dataclean's own pre-restore and pre-reset backups.
+
+Snapshots the destination already has (the same name and creation time) are
+skipped; another snapshot of the same name is an error, unless --as gives the
+copy a new name.
+
+Examples:
+  dataclean copy seeded --from ../alex/.dataclean
+  dataclean copy --all --from .dataclean --to ~/.local/share/dataclean/shop
+  dataclean copy seeded --to remote
+  dataclean copy seeded@20240507T101500Z --from file:///mnt/team --as from-team`,
+	SilenceUsage: true,
+	RunE:         runCopy,
+}
+
+func init() {
+	rootCmd.AddCommand(copyCmd)
+	withSummary(copyCmd)
+
+	copyCmd.Flags().StringVar(&copyFrom, "from", "", "store to copy from (default: this project's)")
+	copyCmd.Flags().StringVar(&copyTo, "to", "", "store to copy to (default: this project's)")
+	copyCmd.Flags().StringVar(&copyAs, "as", "", "name the copy differently (one snapshot only)")
+	copyCmd.Flags().BoolVar(&copyAll, "all", false, "copy every snapshot in the source store")
+}
+
+// copyStore is one side of a copy: a snapshot directory or a remote
+type copyStore struct {
+	label  string
+	mgr    *snapshot.Manager // set for a snapshot directory
+	dir    string
+	remote *remote.Remote // set for a remote
+
+	shareKey string // checks share tokens given for a remote
+}
+
+// openCopyStore resolves --from or --to
+func openCopyStore(cfg *models.Config, location string) (*copyStore, error) {
+	switch {
+	case location == "":
+		return &copyStore{label: cfg.SnapshotDir, mgr: snapshot.NewManager(nil, cfg), dir: cfg.SnapshotDir}, nil
+	case location == "remote" || strings.Contains(location, "://"):
+		settings, err := config.Remote(cfg)
+		if err != nil {
+			return nil, err
+		}
+		if location == "remote" {
+			location = settings.URL
+		}
+		r, err := remote.Open(location)
+		if err != nil {
+			return nil, err
+		}
+		return &copyStore{label: location, remote: r, shareKey: settings.ShareKey}, nil
+	}
+	if info, err := os.Stat(location); err == nil && !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", location)
+	}
+	// Archives in another store stay inside it, whatever this project's storage rules say
+	store := *cfg
+	store.SnapshotDir = location
+	store.StorageRules = nil
+	return &copyStore{label: location, mgr: snapshot.NewManager(nil, &store), dir: location}, nil
+}
+
+// copyItem is one snapshot to copy: its name in a directory, or key on a remote
+type copyItem struct {
+	ref  string
+	key  string
+	size string
+}
+
+func runCopy(cmd *cobra.Command, args []string) error {
+	if copyAll == (len(args) > 0) {
+		return fmt.Errorf("name the snapshots to copy, or give --all")
+	}
+	if copyAs != "" && len(args) != 1 {
+		return fmt.Errorf("--as needs exactly one snapshot")
+	}
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	from, err := openCopyStore(cfg, copyFrom)
+	if err != nil {
+		return err
+	}
+	to, err := openCopyStore(cfg, copyTo)
+	if err != nil {
+		return err
+	}
+	if from.label == to.label || from.dir != "" && sameDir(from.dir, to.dir) {
+		return fmt.Errorf("--from and --to are the same store (%s)", from.label)
+	}
+	if to.mgr != nil {
+		if err := guardReadOnly(cfg); err != nil {
+			return err
+		}
+	}
+
+	items, err := from.items(args)
+	if err != nil {
+		return err
+	}
+	summarize("from", from.label)
+	summarize("to", to.label)
+
+	copied, skipped := 0, 0
+	for _, item := range items {
+		name := copyAs
+		if name == "" {
+			name, _, _ = strings.Cut(item.ref, "@")
+		}
+		done, err := to.has(name, item.key)
+		if err != nil {
+			return err
+		}
+		if done {
+			skipped++
+			if !quiet {
+				fmt.Printf("  = %s is already in %s\n", item.ref, to.label)
+			}
+			continue
+		}
+		if dryRun {
+			dryRunNote("would copy %s (%s) to %s", item.ref, item.size, to.label)
+			continue
+		}
+		if !quiet {
+			fmt.Printf("Copying %s (%s) to %s...\n", item.ref, item.size, to.label)
+		}
+		if err := copySnapshot(from, to, item, name); err != nil {
+			summarize("copied", copied)
+			return fmt.Errorf("failed to copy %s: %w", item.ref, err)
+		}
+		copied++
+	}
+	summarize("copied", copied)
+	summarize("skipped", skipped)
+
+	if !quiet && !dryRun {
+		color.Green("✅ Copied %d snapshot(s) to %s", copied, to.label)
+		if skipped > 0 {
+			fmt.Printf("   %d already there\n", skipped)
+		}
+	}
+	return nil
+}
+
+// items lists the snapshots to copy: those named, or all of them
+func (s *copyStore) items(refs []string) ([]copyItem, error) {
+	var items []copyItem
+	if s.remote != nil {
+		if len(refs) == 0 {
+			bundles, err := s.remote.Bundles()
+			if err != nil {
+				return nil, err
+			}
+			for _, b := range bundles {
+				items = append(items, copyItem{ref: b.Key, key: b.Key, size: models.FormatSize(b.SizeBytes)})
+			}
+			return items, nil
+		}
+		for _, ref := range refs {
+			key, err := s.remote.Resolve(ref, s.shareKey)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, copyItem{ref: key, key: key, size: "bundle"})
+		}
+		return items, nil
+	}
+
+	var snapshots []models.Snapshot
+	if len(refs) == 0 {
+		all, err := s.mgr.List()
+		if err != nil {
+			return nil, err
+		}
+		for _, snap := range all {
+			if !strings.HasPrefix(snap.Name, "_") { // pre-restore and pre-reset backups stay behind
+				snapshots = append(snapshots, snap)
+			}
+		}
+	}
+	for _, ref := range refs {
+		snap, err := s.mgr.Get(ref)
+		if err != nil {
+			return nil, fmt.Errorf("snapshot '%s' not found in %s", ref, s.label)
+		}
+		snapshots = append(snapshots, *snap)
+	}
+	for i := range snapshots {
+		items = append(items, copyItem{ref: snapshots[i].Name, key: remote.Key(&snapshots[i]), size: snapshots[i].SizeHuman})
+	}
+	return items, nil
+}
+
+// has reports whether the store already holds the snapshot with this key,
+// and refuses to replace another snapshot of the same name
+func (s *copyStore) has(name, key string) (bool, error) {
+	if s.remote != nil {
+		return s.remote.Has(key), nil
+	}
+	existing, err := s.mgr.Get(name)
+	if err != nil {
+		return false, nil
+	}
+	if remote.Key(existing) == key {
+		return true, nil
+	}
+	return false, fmt.Errorf("%s already has another snapshot named '%s' (pick a name with --as)", s.label, name)
+}
+
+// copySnapshot streams one snapshot as a bundle from one store to the other
+func copySnapshot(from, to *copyStore, item copyItem, name string) error {
+	bundle := func(w io.Writer) error {
+		if from.mgr != nil {
+			return from.mgr.Bundle(item.ref, w)
+		}
+		rc, err := from.remote.Download(item.key)
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		_, err = io.Copy(w, rc)
+		return err
+	}
+
+	if to.remote != nil {
+		return to.remote.Upload(item.key, bundle)
+	}
+
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		err := bundle(pw)
+		pw.CloseWithError(err)
+		done <- err
+	}()
+	_, err := to.mgr.Unbundle(pr, name)
+	pr.Close() // stops the writer when unbundling failed
+	if bundleErr := <-done; err == nil && bundleErr != nil {
+		return bundleErr
+	}
+	if err != nil || from.remote == nil {
+		return err
+	}
+	// Pulled snapshots remember the key they came from, so they match it later
+	return to.mgr.UpdateMetadata(name, map[string]string{remote.KeyMetadata: item.key})
+}
+
+// sameDir reports whether two paths name the same directory
+func sameDir(a, b string) bool {
+	if b == "" {
+		return false
+	}
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
@@ -6,28 +6,45 @@ import (
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 	"github.com/stackgen-cli/dataclean/internal/config"
-	"github.com/stackgen-cli/dataclean/internal/docker"
+	"github.com/stackgen-cli/dataclean/internal/models"
 	"github.com/stackgen-cli/dataclean/internal/snapshot"
 	"github.com/stackgen-cli/dataclean/internal/tui"
 )
 
+var (
+	deleteTags     []string
+	deleteKeepLast int
+)
+
 var deleteCmd = &cobra.Command{
-	Use:   "delete [snapshot-name]",
-	Short: "Delete a saved snapshot",
-	Long: `Delete a previously saved snapshot to free up disk space.
+	Use:   "delete [snapshot|pattern...]",
+	Short: "Delete saved snapshots",
+	Long: `Delete previously saved snapshots to free up disk space.
+
+Snapshots are chosen by name, by glob pattern (quote it so the shell leaves
+it alone), and with --tag by tag; a snapshot must match both a pattern and a
+tag when both are given. --keep-last spares the newest matches. All matches
+are listed with the space they take up before a single confirmation.
 
-If no snapshot name is provided, an interactive selector will be shown.
+If no snapshot, pattern, or tag is given, an interactive selector is shown.
 
 Examples:
   dataclean delete my-snapshot
-  dataclean delete                    # Interactive selection
-  dataclean delete my-snapshot -f     # Skip confirmation`,
-	Args: cobra.MaximumNArgs(1),
+  dataclean delete old-1 old-2
+  dataclean delete 'tmp-*'
+  dataclean delete --tag scratch
+  dataclean delete 'nightly-*' --keep-last 3   # all but the newest three
+  dataclean delete                             # interactive selection
+  dataclean delete my-snapshot -f              # skip confirmation`,
 	RunE: runDelete,
 }
 
 func init() {
 	rootCmd.AddCommand(deleteCmd)
+	withSummary(deleteCmd)
+
+	deleteCmd.Flags().StringSliceVar(&deleteTags, "tag", nil, "Delete snapshots with this tag (repeatable)")
+	deleteCmd.Flags().IntVar(&deleteKeepLast, "keep-last", 0, "Keep the newest N matching snapshots")
 }
 
 func runDelete(cmd *cobra.Command, args []string) error {
@@ -36,21 +53,27 @@ func runDelete(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
-
-	// Create Docker client
-	client, err := docker.NewClient()
-	if err != nil {
-		return fmt.Errorf("failed to create Docker client: %w", err)
+	if err := guardReadOnly(cfg); err != nil {
+		return err
 	}
-	defer client.Close()
 
 	// Create snapshot manager
-	mgr := snapshot.NewManager(client, cfg)
+	mgr := snapshot.NewManager(nil, cfg)
 
-	// Get snapshot name
-	var snapshotName string
-	if len(args) > 0 {
-		snapshotName = args[0]
+	// Pick the snapshots
+	var targets []models.Snapshot
+	if len(args) > 0 || len(deleteTags) > 0 {
+		targets, err = mgr.Select(snapshot.Selector{Patterns: args, Tags: deleteTags, KeepLast: deleteKeepLast})
+		if err != nil {
+			return err
+		}
+		if len(targets) == 0 {
+			summarize("deleted", 0)
+			if !quiet {
+				fmt.Println("No snapshots to delete.")
+			}
+			return nil
+		}
 	} else {
 		// Interactive selection
 		snapshots, err := mgr.List()
@@ -62,60 +85,97 @@ func runDelete(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("no snapshots found")
 		}
 
-		selected, err := tui.RunSnapshotSelector(snapshots)
+		keys, err := setupTUI(cfg)
 		if err != nil {
 			return err
 		}
-		snapshotName = selected.Name
-	}
-
-	// Verify snapshot exists
-	snap, err := mgr.Get(snapshotName)
-	if err != nil {
-		return fmt.Errorf("snapshot '%s' not found", snapshotName)
+		selected, err := tui.RunSnapshotSelector(snapshots, keys)
+		if err != nil {
+			return err
+		}
+		targets = []models.Snapshot{*selected}
 	}
 
 	// Show what will be deleted
+	deleting := make(map[string]bool, len(targets))
+	var total int64
+	for _, snap := range targets {
+		deleting[snap.Name] = true
+		total += snap.SizeBytes
+	}
 	if !quiet {
-		color.Cyan("Snapshot to delete:\n")
-		fmt.Printf("  Name:      %s\n", snap.Name)
-		fmt.Printf("  Created:   %s\n", snap.Timestamp.Format("2006-01-02 15:04:05"))
-		fmt.Printf("  Size:      %s\n", snap.SizeHuman)
-		fmt.Printf("  Volumes:   %d\n", len(snap.Volumes))
+		color.Cyan("Snapshots to delete:\n")
+		for _, snap := range targets {
+			fmt.Printf("  %-30s %s  %10s  %d volume(s)\n", snap.Name, snap.Timestamp.Format("2006-01-02 15:04:05"), snap.SizeHuman, len(snap.Volumes))
+		}
+		fmt.Printf("\n  Reclaims up to %s in %d snapshot(s)\n", models.FormatSize(total), len(targets))
 		fmt.Println()
 	}
 
+	// Deleting a parent would break incremental snapshots that are staying
+	for _, snap := range targets {
+		dependents, err := mgr.Dependents(snap.Name)
+		if err != nil {
+			return fmt.Errorf("failed to list snapshots: %w", err)
+		}
+		var kept []string
+		for _, d := range dependents {
+			if !deleting[d] {
+				kept = append(kept, d)
+			}
+		}
+		if len(kept) > 0 && !force {
+			return fmt.Errorf("incremental snapshots depend on '%s': %v (run 'dataclean compact %s' first, or use --force)",
+				snap.Name, kept, snap.Name)
+		}
+	}
+
 	// Dry run check
 	if dryRun {
-		color.Yellow("Dry run: would delete snapshot '%s'", snapshotName)
+		for _, snap := range targets {
+			dryRunNote("would delete snapshot '%s'", snap.Name)
+		}
 		return nil
 	}
 
 	// Confirmation
 	if !force {
-		color.Yellow("⚠️  This action cannot be undone!")
+		warn("⚠️  This action cannot be undone!")
 		fmt.Println()
-		confirmed, err := tui.ConfirmDestructive(fmt.Sprintf("Delete snapshot '%s'?", snapshotName))
+		question := fmt.Sprintf("Delete snapshot '%s'?", targets[0].Name)
+		if len(targets) > 1 {
+			question = fmt.Sprintf("Delete these %d snapshots (%s)?", len(targets), models.FormatSize(total))
+		}
+		confirmed, err := tui.ConfirmDestructive(question)
 		if err != nil {
 			return err
 		}
 		if !confirmed {
-			fmt.Println("Cancelled.")
+			warn("Cancelled.")
+			summarize("aborted", true)
 			return nil
 		}
 	}
 
-	// Delete the snapshot
-	if !quiet {
-		fmt.Printf("Deleting snapshot '%s'...\n", snapshotName)
-	}
-
-	if err := mgr.Delete(snapshotName); err != nil {
-		return fmt.Errorf("failed to delete snapshot: %w", err)
+	// Delete the snapshots
+	for _, snap := range targets {
+		if !quiet {
+			fmt.Printf("Deleting snapshot '%s'...\n", snap.Name)
+		}
+		if err := mgr.Delete(snap.Name); err != nil {
+			return fmt.Errorf("failed to delete snapshot %s: %w", snap.Name, err)
+		}
+		reportSnapshotEvent(cfg, models.SnapshotDeleted, &snap)
+		summarize("name", snap.Name)
 	}
+	summarize("deleted", len(targets))
 
 	if !quiet {
-		color.Green("✅ Snapshot '%s' deleted successfully", snapshotName)
+		if len(targets) == 1 {
+			color.Green("✅ Snapshot '%s' deleted successfully", targets[0].Name)
+		} else {
+			color.Green("✅ Deleted %d snapshots", len(targets))
+		}
 	}
 
 	return nil
@@ -10,6 +10,7 @@ import (
 	"github.com/stackgen-cli/dataclean/internal/config"
 	"github.com/stackgen-cli/dataclean/internal/docker"
 	"github.com/stackgen-cli/dataclean/internal/models"
+	"github.com/stackgen-cli/dataclean/internal/snapshot"
 )
 
 var detectCmd = &cobra.Command{
@@ -21,13 +22,23 @@ var detectCmd = &cobra.Command{
   • Volumes attached to services
   • Datastore types (inferred or configured)
   • Which volumes are snapshot-capable
+  • Each service's container: missing, stopped, running, and its health
+  • When each volume was last snapshotted, if ever
+  • Containers outside the compose project using a volume
 
-This is a read-only operation that helps you understand what dataclean will operate on.`,
+This is a read-only operation that helps you understand what dataclean will operate on.
+
+Use --verbose to list every mount encountered (bind mounts, tmpfs, anonymous
+volumes, excluded volumes) and the reason it was included or skipped.`,
 	RunE: runDetect,
 }
 
+var detectVerbose bool
+
 func init() {
 	rootCmd.AddCommand(detectCmd)
+
+	detectCmd.Flags().BoolVarP(&detectVerbose, "verbose", "v", false, "explain why each mount was included or skipped")
 }
 
 func runDetect(cmd *cobra.Command, args []string) error {
@@ -45,20 +56,52 @@ func runDetect(cmd *cobra.Command, args []string) error {
 	defer client.Close()
 
 	// Detect volumes
-	volumes, err := client.DetectComposeVolumes(cfg)
+	reports, err := client.DetectComposeMounts(cfg)
 	if err != nil {
 		return fmt.Errorf("failed to detect volumes: %w", err)
 	}
+	var volumes []models.Volume
+	for _, r := range reports {
+		if r.Included {
+			volumes = append(volumes, *r.Volume)
+		}
+	}
+
+	// Container states and snapshot history are extras; detection stands without them
+	services, _ := client.ServiceStates(cfg)
+	for i := range volumes {
+		_, volumes[i].Foreign, _ = client.VolumeUsers(volumes[i])
+	}
+	lastSnapshots, _ := snapshot.NewManager(client, cfg).LastSnapshots(volumes)
 
 	// Print results
 	if !quiet {
-		printDetectionResults(cfg, volumes)
+		printDetectionResults(cfg, volumes, services, lastSnapshots, volumeProvenance(client, volumes))
+		hintAnonymousVolumes(reports)
+		if detectVerbose {
+			printMountReports(reports)
+		}
+		warnSnapshotDirConflicts(client, cfg)
 	}
 
 	return nil
 }
 
-func printDetectionResults(cfg *models.Config, volumes []models.Volume) {
+// volumeProvenance reads the labels of volumes a restore created, which say
+// which snapshot their data came from
+func volumeProvenance(client *docker.Client, volumes []models.Volume) map[string]*models.RestoreRecord {
+	provenance := make(map[string]*models.RestoreRecord)
+	for _, v := range volumes {
+		if labels, _, err := client.VolumeLabels(v.Name); err == nil {
+			if rec := models.ProvenanceFromLabels(labels); rec != nil {
+				provenance[v.Name] = rec
+			}
+		}
+	}
+	return provenance
+}
+
+func printDetectionResults(cfg *models.Config, volumes []models.Volume, services []docker.ServiceState, lastSnapshots map[string]models.Snapshot, provenance map[string]*models.RestoreRecord) {
 	cyan := color.New(color.FgCyan, color.Bold)
 	green := color.New(color.FgGreen)
 	yellow := color.New(color.FgYellow)
@@ -72,17 +115,17 @@ func printDetectionResults(cfg *models.Config, volumes []models.Volume) {
 
 	// Compose file
 	white.Print("Compose file: ")
-	composeFile := cfg.ComposeFile
-	if composeFile == "" {
-		composeFile = findComposeFile()
-	}
-	if composeFile != "" {
+	if composeFile, err := docker.FindComposeFile(cfg); err == nil {
 		green.Println(composeFile)
 	} else {
 		yellow.Println("not found")
 	}
 	fmt.Println()
 
+	if len(services) > 0 {
+		printServiceStates(services)
+	}
+
 	// Volumes summary
 	if len(volumes) == 0 {
 		yellow.Println("No snapshot-capable volumes detected.")
@@ -118,6 +161,27 @@ func printDetectionResults(cfg *models.Config, volumes []models.Volume) {
 			if v.ContainerName != "" {
 				white.Printf(" (container: %s)", v.ContainerName)
 			}
+			if len(v.SharedWith) > 0 {
+				yellow.Printf(" [also mounted by %s]", strings.Join(v.SharedWith, ", "))
+			}
+			if len(v.Foreign) > 0 {
+				yellow.Printf(" [in use outside the project by %s]", strings.Join(v.Foreign, ", "))
+			}
+			if rec := provenance[v.Name]; rec != nil {
+				white.Printf(" [restored from %s", rec.Snapshot)
+				if !rec.RestoredAt.IsZero() {
+					white.Printf(" on %s", rec.RestoredAt.Local().Format("2006-01-02 15:04"))
+				}
+				white.Print("]")
+			}
+			if snap, ok := lastSnapshots[v.Name]; ok {
+				white.Printf(" · last snapshot %s", snap.Name)
+				if !snap.Timestamp.IsZero() {
+					white.Printf(" (%s)", snap.Timestamp.Local().Format("2006-01-02 15:04"))
+				}
+			} else {
+				yellow.Print(" · never snapshotted")
+			}
 			fmt.Println()
 		}
 		fmt.Println()
@@ -153,14 +217,90 @@ func printDetectionResults(cfg *models.Config, volumes []models.Volume) {
 	white.Println("  dataclean list             Show available snapshots")
 }
 
-func findComposeFile() string {
-	candidates := []string{"compose.yaml", "compose.yml", "docker-compose.yaml", "docker-compose.yml"}
-	for _, name := range candidates {
-		if _, err := os.Stat(name); err == nil {
-			return name
+// printServiceStates shows whether each service has a container, whether it
+// runs, and its health when it has a healthcheck
+func printServiceStates(services []docker.ServiceState) {
+	cyan := color.New(color.FgCyan, color.Bold)
+	green := color.New(color.FgGreen)
+	yellow := color.New(color.FgYellow)
+	red := color.New(color.FgRed)
+
+	cyan.Println("Services:")
+	for _, s := range services {
+		state, c := s.State, yellow
+		switch {
+		case s.Container == "":
+			state = "no container"
+		case s.State == "running" && s.Health == "unhealthy":
+			c = red
+		case s.State == "running" && s.Health != "starting":
+			c = green
+		}
+		if s.Health != "" {
+			state += " (" + s.Health + ")"
+		}
+		fmt.Printf("    • %-16s ", s.Service)
+		if s.Container == "" {
+			c.Println(state)
+			continue
 		}
+		c.Printf("%-22s", state)
+		fmt.Printf(" %s\n", s.Container)
+	}
+	fmt.Println()
+}
+
+// printMountReports lists every mount with the reason it was included or skipped
+func printMountReports(reports []docker.MountReport) {
+	cyan := color.New(color.FgCyan, color.Bold)
+	green := color.New(color.FgGreen)
+	yellow := color.New(color.FgYellow)
+
+	fmt.Println()
+	cyan.Println("Mounts encountered:")
+	if len(reports) == 0 {
+		fmt.Println("  (none)")
+	}
+	for _, r := range reports {
+		mark, c := "✗", yellow
+		if r.Included {
+			mark, c = "✓", green
+		}
+		c.Printf("  %s ", mark)
+		fmt.Printf("%-12s %-9s %-40s %s\n", r.Service, r.Mount.Type, r.Mount.String(), r.Reason)
+	}
+	fmt.Println()
+}
+
+// hintAnonymousVolumes points out the anonymous volumes detection skipped,
+// which an alias would let dataclean snapshot
+func hintAnonymousVolumes(reports []docker.MountReport) {
+	var mounts []string
+	for _, r := range reports {
+		if r.Reason == docker.ReasonAnonymous {
+			mounts = append(mounts, r.Service+":"+r.Mount.Target)
+		}
+	}
+	if len(mounts) == 0 {
+		return
+	}
+	fmt.Println()
+	color.New(color.FgYellow).Printf("Skipped %d anonymous volume(s): %s\n", len(mounts), strings.Join(mounts, ", "))
+	fmt.Println("  Adopt one under a stable name with: dataclean alias add <alias> <service>:<path>")
+}
+
+// warnSnapshotDirConflicts prints compose mounts that overlap the snapshot directory
+func warnSnapshotDirConflicts(client *docker.Client, cfg *models.Config) {
+	warnings, err := client.SnapshotDirConflicts(cfg)
+	if err != nil {
+		return
+	}
+	for _, w := range warnings {
+		warn("⚠️  %s", w)
+	}
+	if len(warnings) > 0 {
+		fmt.Println()
 	}
-	return ""
 }
 
 // Helper to check if slice contains string
@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/stackgen-cli/dataclean/internal/config"
+	"github.com/stackgen-cli/dataclean/internal/models"
+	"github.com/stackgen-cli/dataclean/internal/snapshot"
+	"github.com/stackgen-cli/dataclean/internal/sqldiff"
+)
+
+var (
+	diffSQL     bool
+	diffData    bool
+	diffMaxRows int
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <snapshot-a> <snapshot-b>",
+	Short: "Compare two snapshots",
+	Long: `Compare two snapshots volume by volume (presence and archive size).
+
+With --sql, compare the logical dumps of Postgres/MySQL volumes (snapshots
+taken with 'snapshot --logical'): table schemas and row counts per table,
+plus row-level differences for small tables with --data.
+
+Examples:
+  dataclean diff before-migration after-migration
+  dataclean diff --sql before-migration after-migration
+  dataclean diff --sql --data --max-rows 500 seed-v1 seed-v2`,
+	Args: cobra.ExactArgs(2),
+	RunE: runDiff,
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+
+	diffCmd.Flags().BoolVar(&diffSQL, "sql", false, "compare logical SQL dumps table by table")
+	diffCmd.Flags().BoolVar(&diffData, "data", false, "with --sql, show row-level differences for small tables")
+	diffCmd.Flags().IntVar(&diffMaxRows, "max-rows", 1000, "largest table (in rows) compared row by row")
+}
+
+// volumeSQLDiff is the table comparison for one volume
+type volumeSQLDiff struct {
+	Volume string              `json:"volume"`
+	Tables []sqldiff.TableDiff `json:"tables"`
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	// Load config
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	mgr := snapshot.NewManager(nil, cfg)
+	a, err := mgr.Get(args[0])
+	if err != nil {
+		return fmt.Errorf("snapshot not found: %s", args[0])
+	}
+	b, err := mgr.Get(args[1])
+	if err != nil {
+		return fmt.Errorf("snapshot not found: %s", args[1])
+	}
+
+	if !diffSQL {
+		printVolumeDiff(a, b)
+		return nil
+	}
+
+	var results []volumeSQLDiff
+	for _, va := range a.Volumes {
+		vb, err := snapshot.FindVolume(b, va.Name)
+		if err != nil || va.LogicalDump == "" || vb.LogicalDump == "" {
+			continue
+		}
+
+		before, err := sqldiff.ParseFile(filepath.Join(a.Path, va.LogicalDump), diffMaxRows)
+		if err != nil {
+			return fmt.Errorf("failed to read dump for %s in %s: %w", va.Name, a.Name, err)
+		}
+		after, err := sqldiff.ParseFile(filepath.Join(b.Path, vb.LogicalDump), diffMaxRows)
+		if err != nil {
+			return fmt.Errorf("failed to read dump for %s in %s: %w", vb.Name, b.Name, err)
+		}
+
+		results = append(results, volumeSQLDiff{
+			Volume: va.Name,
+			Tables: sqldiff.Compare(before, after, diffData),
+		})
+	}
+
+	if len(results) == 0 {
+		return fmt.Errorf("no logical dumps to compare; create snapshots with 'dataclean snapshot --logical'")
+	}
+
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	}
+
+	for _, r := range results {
+		printSQLDiff(r)
+	}
+	return nil
+}
+
+// printVolumeDiff shows which volumes were added, removed, or changed size
+func printVolumeDiff(a, b *models.Snapshot) {
+	green := color.New(color.FgGreen)
+	red := color.New(color.FgRed)
+	yellow := color.New(color.FgYellow)
+
+	color.Cyan("Comparing %s → %s", a.Name, b.Name)
+	fmt.Println()
+
+	for _, va := range a.Volumes {
+		vb, err := snapshot.FindVolume(b, va.Name)
+		if err != nil {
+			red.Printf("  - %s (only in %s)\n", va.Name, a.Name)
+			continue
+		}
+		if va.SizeBytes == vb.SizeBytes {
+			fmt.Printf("    %s (%s, unchanged size)\n", va.Name, va.SizeHuman)
+			continue
+		}
+		yellow.Printf("  ~ %s (%s → %s)\n", va.Name, va.SizeHuman, vb.SizeHuman)
+	}
+	for _, vb := range b.Volumes {
+		if _, err := snapshot.FindVolume(a, vb.Name); err != nil {
+			green.Printf("  + %s (only in %s)\n", vb.Name, b.Name)
+		}
+	}
+}
+
+// printSQLDiff shows table-level changes for one volume
+func printSQLDiff(r volumeSQLDiff) {
+	green := color.New(color.FgGreen)
+	red := color.New(color.FgRed)
+	yellow := color.New(color.FgYellow)
+
+	color.Cyan("📊 %s", r.Volume)
+
+	unchanged := 0
+	for _, t := range r.Tables {
+		switch t.Status {
+		case sqldiff.StatusAdded:
+			green.Printf("  + %-30s added (%d rows)\n", t.Name, t.RowsAfter)
+		case sqldiff.StatusRemoved:
+			red.Printf("  - %-30s removed (%d rows)\n", t.Name, t.RowsBefore)
+		case sqldiff.StatusChanged:
+			yellow.Printf("  ~ %-30s %d → %d rows (%+d)\n", t.Name, t.RowsBefore, t.RowsAfter, t.RowsAfter-t.RowsBefore)
+			for _, c := range t.AddedColumns {
+				green.Printf("      + column: %s\n", c)
+			}
+			for _, c := range t.RemovedColumns {
+				red.Printf("      - column: %s\n", c)
+			}
+			for _, row := range t.AddedRows {
+				green.Printf("      + row: %s\n", row)
+			}
+			for _, row := range t.RemovedRows {
+				red.Printf("      - row: %s\n", row)
+			}
+		default:
+			unchanged++
+		}
+	}
+
+	if unchanged > 0 {
+		fmt.Printf("    %d table(s) unchanged\n", unchanged)
+	}
+	fmt.Println()
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/stackgen-cli/dataclean/internal/config"
+	"github.com/stackgen-cli/dataclean/internal/snapshot"
+)
+
+var extractOutput string
+
+var extractCmd = &cobra.Command{
+	Use:   "extract <snapshot> <volume> <path-in-volume>",
+	Short: "Extract individual files from a snapshot",
+	Long: `Copy a single file or directory out of a snapshot's volume archive
+without restoring the whole volume.
+
+The volume can be given by its full Docker name or its compose name.
+
+Examples:
+  dataclean extract before-migration pgdata postgresql.conf
+  dataclean extract before-migration uploads images/logo.png -o ./out
+  dataclean extract before-migration mongodata diagnostic.data -o /tmp/mongo`,
+	Args: cobra.ExactArgs(3),
+	RunE: runExtract,
+}
+
+func init() {
+	rootCmd.AddCommand(extractCmd)
+	withSummary(extractCmd)
+
+	extractCmd.Flags().StringVarP(&extractOutput, "output", "o", ".", "directory to extract into")
+}
+
+func runExtract(cmd *cobra.Command, args []string) error {
+	name, volume, target := args[0], args[1], args[2]
+
+	// Load config
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	mgr := snapshot.NewManager(nil, cfg)
+	if _, err := mgr.Get(name); err != nil {
+		return fmt.Errorf("snapshot not found: %s", name)
+	}
+
+	if dryRun {
+		dryRunNote("would extract %s from %s/%s into %s", target, name, volume, extractOutput)
+		return nil
+	}
+
+	extracted, err := mgr.Extract(name, volume, target, extractOutput)
+	if err != nil {
+		return fmt.Errorf("failed to extract: %w", err)
+	}
+	summarize("items", len(extracted))
+
+	if !quiet {
+		color.Green("✅ Extracted %d item(s) into %s", len(extracted), extractOutput)
+		for _, p := range extracted {
+			fmt.Printf("  • %s\n", p)
+		}
+	}
+
+	return nil
+}
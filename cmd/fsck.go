@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/stackgen-cli/dataclean/internal/config"
+	"github.com/stackgen-cli/dataclean/internal/models"
+	"github.com/stackgen-cli/dataclean/internal/snapshot"
+)
+
+var fsckRepair bool
+
+var fsckCmd = &cobra.Command{
+	Use:   "fsck",
+	Short: "Check the whole snapshot store for damage and leftovers",
+	Long: `Check every snapshot in the store:
+
+  • metadata.yaml parses
+  • every volume's archive exists, through the parent chain for incremental
+    snapshots, and reads back in full with its recorded checksum
+  • parents of incremental snapshots exist
+  • nothing is left over from interrupted operations: directories without
+    metadata, archives no snapshot refers to (also in storage_rules
+    directories), and temporary files
+
+Reading back every archive takes a while for a large store.
+
+--repair removes the leftovers; those written in the last hour are left alone
+in case a running dataclean owns them. Damaged or missing data can't be
+repaired without the original volumes and is only reported: delete the
+snapshot, or restore what you can from its parents. fsck exits non-zero while
+any problem remains.
+
+Examples:
+  dataclean fsck
+  dataclean fsck --repair`,
+	Args:         cobra.NoArgs,
+	RunE:         runFsck,
+	SilenceUsage: true,
+}
+
+func init() {
+	rootCmd.AddCommand(fsckCmd)
+	withSummary(fsckCmd)
+
+	fsckCmd.Flags().BoolVar(&fsckRepair, "repair", false, "remove leftovers from interrupted operations")
+}
+
+func runFsck(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	repair := fsckRepair && !dryRun
+	if repair {
+		if err := guardReadOnly(cfg); err != nil {
+			return err
+		}
+	}
+
+	report, err := snapshot.NewManager(nil, cfg).Fsck(repair, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to check the snapshot store: %w", err)
+	}
+	unresolved := report.Unresolved()
+	summarize("snapshots", report.Snapshots)
+	summarize("archives", report.Archives)
+	summarize("problems", unresolved)
+	summarize("repaired", len(report.Problems)-unresolved)
+
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+	} else if !quiet {
+		printFsck(report, repair)
+	}
+
+	if unresolved > 0 {
+		return fmt.Errorf("%d problem(s) in the snapshot store", unresolved)
+	}
+	return nil
+}
+
+// printFsck lists the problems found and what was, or would be, done about them
+func printFsck(report *models.FsckReport, repair bool) {
+	for _, p := range report.Problems {
+		where := p.Snapshot
+		if where == "" {
+			where = p.Path
+		}
+		switch {
+		case p.Repaired:
+			color.Green("  ✓ %s: %s (repaired: %s)", where, p.Problem, p.Repair)
+		case p.Error != "":
+			color.Red("  ✗ %s: %s (repair failed: %s)", where, p.Problem, p.Error)
+		case p.Repair != "":
+			color.Yellow("  • %s: %s (--repair will %s)", where, p.Problem, p.Repair)
+		default:
+			color.Red("  ✗ %s: %s", where, p.Problem)
+		}
+		if p.Path != "" && p.Path != where {
+			fmt.Printf("      %s\n", p.Path)
+		}
+	}
+	if len(report.Problems) > 0 {
+		fmt.Println()
+	}
+
+	unresolved := report.Unresolved()
+	switch {
+	case unresolved == 0 && len(report.Problems) > 0:
+		color.Green("✅ Repaired %d problem(s) in %d snapshot(s)", len(report.Problems), report.Snapshots)
+	case unresolved == 0:
+		color.Green("✅ %d snapshot(s) and %d archive(s) checked, no problems", report.Snapshots, report.Archives)
+	case !repair && unresolved > report.Unrepairable():
+		fmt.Println("Leftovers marked • are removed by 'dataclean fsck --repair'")
+	}
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/stackgen-cli/dataclean/internal/config"
+	"github.com/stackgen-cli/dataclean/internal/docker"
+	"github.com/stackgen-cli/dataclean/internal/snapshot"
+	"github.com/stackgen-cli/dataclean/internal/tasks"
+)
+
+var (
+	generateFormat   string
+	generateOutput   string
+	generateBaseline string
+)
+
+var generateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generate files that wire dataclean into a project",
+}
+
+var generateTasksCmd = &cobra.Command{
+	Use:   "tasks",
+	Short: "Generate make, Task, or just targets for common dataclean flows",
+	Long: `Print task runner targets for the common flows, with the flags this project
+needs, so the whole team runs them the same way:
+
+  snapshot-baseline  save the current data as the baseline snapshot
+                     (with --logical when there are Postgres or MySQL volumes)
+  refresh-dev        restore the baseline snapshot
+  reset-cache        empty the Redis volumes, when there are any
+
+--format picks the runner: make (a Makefile), task (a Taskfile.yml), or just
+(a justfile). Commands pass --config when one was given. Paste the output into
+the project's own file, or write it out with --output.
+
+Examples:
+  dataclean generate tasks >> Makefile
+  dataclean generate tasks --format task --output Taskfile.dataclean.yml
+  dataclean generate tasks --format just --baseline seeded`,
+	Args: cobra.NoArgs,
+	RunE: runGenerateTasks,
+}
+
+func init() {
+	rootCmd.AddCommand(generateCmd)
+	generateCmd.AddCommand(generateTasksCmd)
+
+	generateTasksCmd.Flags().StringVar(&generateFormat, "format", "make", "task runner: "+strings.Join(tasks.Formats, ", "))
+	generateTasksCmd.Flags().StringVarP(&generateOutput, "output", "o", "", "write to this file instead of stdout")
+	generateTasksCmd.Flags().StringVar(&generateBaseline, "baseline", "baseline", "name of the baseline snapshot")
+}
+
+func runGenerateTasks(cmd *cobra.Command, args []string) error {
+	if !slices.Contains(tasks.Formats, generateFormat) {
+		return fmt.Errorf("unknown format %q (one of %s)", generateFormat, strings.Join(tasks.Formats, ", "))
+	}
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := snapshot.NewManager(nil, cfg).ValidateName(generateBaseline); err != nil {
+		return err
+	}
+
+	client, err := docker.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to connect to Docker: %w", err)
+	}
+	defer client.Close()
+	volumes, err := client.DetectComposeVolumes(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to detect volumes: %w", err)
+	}
+	if len(volumes) == 0 {
+		warn("⚠️  No Docker Compose volumes detected in current directory")
+	}
+
+	out, err := tasks.Render(generateFormat, tasks.Workflows(volumes, generateBaseline, cfgFile))
+	if err != nil {
+		return err
+	}
+	if generateOutput == "" {
+		fmt.Print(out)
+		return nil
+	}
+	if err := os.WriteFile(generateOutput, []byte(out), 0644); err != nil {
+		return err
+	}
+	if !quiet {
+		color.Green("✅ Wrote %s targets to %s", generateFormat, generateOutput)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+// ensureGitignored runs before the first snapshot (while the snapshot
+// directory doesn't exist yet): if it would land inside a git work tree
+// without being ignored, it is added to .gitignore with auto_gitignore, after
+// asking otherwise, so multi-GB archives aren't committed by accident.
+// Scripted runs (--quiet, --json, --force) only get a warning.
+func ensureGitignored(cfg *models.Config) {
+	if _, err := os.Stat(cfg.SnapshotDir); err == nil {
+		return
+	}
+	ignored, inRepo := gitIgnored(cfg.SnapshotDir)
+	if ignored || !inRepo {
+		return
+	}
+
+	// .gitignore here only covers paths below the working directory
+	abs, err := filepath.Abs(cfg.SnapshotDir)
+	if err != nil {
+		return
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return
+	}
+	rel, err := filepath.Rel(cwd, abs)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		warn("⚠️  %s is inside a git repository but not ignored; add it to .gitignore so snapshots aren't committed", cfg.SnapshotDir)
+		return
+	}
+	entry := filepath.ToSlash(rel) + "/"
+
+	switch {
+	case cfg.AutoGitignore:
+	case quiet || jsonOutput || force || dryRun:
+		warn("⚠️  %s is not in .gitignore; snapshots there could be committed (set auto_gitignore: true to add it)", entry)
+		return
+	default:
+		if !promptYesNo(bufio.NewReader(os.Stdin), entry+" is not ignored by git. Add it to .gitignore?", true) {
+			return
+		}
+	}
+	if err := appendGitignore(entry); err != nil {
+		warn("⚠️  Failed to update .gitignore: %v", err)
+		return
+	}
+	if !quiet && !jsonOutput {
+		color.Green("Added %s to .gitignore", entry)
+	}
+}
+
+// gitIgnored asks git whether a path is ignored. inRepo is false when git is
+// missing or the path isn't in a work tree.
+func gitIgnored(path string) (ignored, inRepo bool) {
+	err := exec.Command("git", "check-ignore", "-q", path).Run()
+	if err == nil {
+		return true, true
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return false, true
+	}
+	return false, false
+}
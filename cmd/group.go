@@ -0,0 +1,378 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/stackgen-cli/dataclean/internal/config"
+	"github.com/stackgen-cli/dataclean/internal/docker"
+	"github.com/stackgen-cli/dataclean/internal/models"
+	"github.com/stackgen-cli/dataclean/internal/snapshot"
+)
+
+var groupCmd = &cobra.Command{
+	Use:   "group",
+	Short: "Tie snapshots of several stacks into one restorable group",
+	Long: `A group ties existing snapshots, of this project or of stacks listed under
+stacks in the config, into one logical snapshot: a release that spans an API's
+database and a worker's queue, say. Restoring the group restores every
+member, or none of them.
+
+Members are named snapshot (this project's) or stack:snapshot. Each stack
+appears at most once in a group. The group is recorded in .dataclean/_groups/
+next to the snapshots; the snapshots themselves stay where they are, and
+deleting a group keeps them.
+
+Examples:
+  dataclean group create release-12 seeded api:v12 worker:v12
+  dataclean group list
+  dataclean group restore release-12
+  dataclean group delete release-12`,
+}
+
+var groupCreateCmd = &cobra.Command{
+	Use:          "create <group> <member>...",
+	Short:        "Group snapshots (snapshot or stack:snapshot) under a name",
+	Args:         cobra.MinimumNArgs(2),
+	SilenceUsage: true,
+	RunE:         runGroupCreate,
+}
+
+var groupListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List snapshot groups and their members",
+	Args:  cobra.NoArgs,
+	RunE:  runGroupList,
+}
+
+var groupRestoreCmd = &cobra.Command{
+	Use:   "restore <group>",
+	Short: "Restore every snapshot of a group, or none of them",
+	Long: `Restore each member of a group in turn, after one confirmation for all of
+them. Every member's snapshot is checked before anything is touched, and
+backup_before_restore must be on wherever one is restored: when a member's
+restore fails, the members restored before it are put back from their
+pre-restore backups, so the stacks never hold part of the group.`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE:         runGroupRestore,
+}
+
+var groupDeleteCmd = &cobra.Command{
+	Use:          "delete <group>",
+	Short:        "Delete a group, keeping its snapshots",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE:         runGroupDelete,
+}
+
+func init() {
+	rootCmd.AddCommand(groupCmd)
+	groupCmd.AddCommand(groupCreateCmd, groupListCmd, groupRestoreCmd, groupDeleteCmd)
+	withSummary(groupCreateCmd)
+	withSummary(groupRestoreCmd)
+	withSummary(groupDeleteCmd)
+}
+
+// parseGroupMember reads a member as given to 'group create'
+func parseGroupMember(cfg *models.Config, arg string) (models.GroupMember, error) {
+	stack, name, ok := strings.Cut(arg, ":")
+	if !ok {
+		return models.GroupMember{Snapshot: arg}, nil
+	}
+	if !slices.ContainsFunc(cfg.Stacks, func(s models.Stack) bool { return s.Name == stack }) {
+		return models.GroupMember{}, fmt.Errorf("unknown stack %q in %s", stack, arg)
+	}
+	if name == "" {
+		return models.GroupMember{}, fmt.Errorf("%s names no snapshot", arg)
+	}
+	return models.GroupMember{Stack: stack, Snapshot: name}, nil
+}
+
+// inMember runs fn where the member's snapshot lives: in its stack, or here
+// for this project's own
+func inMember(cfg *models.Config, member models.GroupMember, fn func() error) error {
+	if member.Stack == "" {
+		return fn()
+	}
+	i := slices.IndexFunc(cfg.Stacks, func(s models.Stack) bool { return s.Name == member.Stack })
+	if i < 0 {
+		return fmt.Errorf("unknown stack %q", member.Stack)
+	}
+	return inStack(cfg.Stacks[i], fn)
+}
+
+// memberSnapshot returns a member's snapshot, checking it's the one grouped.
+// Call it through inMember.
+func memberSnapshot(member models.GroupMember) (*models.Snapshot, *models.Config, error) {
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	snap, err := snapshot.NewManager(nil, cfg).Get(member.Snapshot)
+	if err != nil {
+		return nil, nil, fmt.Errorf("snapshot not found: %s", member)
+	}
+	if !member.Taken.IsZero() && !snap.Timestamp.Equal(member.Taken) {
+		return nil, nil, fmt.Errorf("snapshot %s was taken again (%s) since it was grouped (%s)", member, snap.Timestamp.Format("2006-01-02 15:04:05"), member.Taken.Format("2006-01-02 15:04:05"))
+	}
+	return snap, cfg, nil
+}
+
+func runGroupCreate(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := guardReadOnly(cfg); err != nil {
+		return err
+	}
+	mgr := snapshot.NewManager(nil, cfg)
+	name := args[0]
+	if err := mgr.ValidateName(name); err != nil {
+		return err
+	}
+	if existing, err := mgr.Group(name); err != nil {
+		return err
+	} else if existing != nil {
+		return fmt.Errorf("group '%s' already exists (delete it first)", name)
+	}
+
+	group := &models.SnapshotGroup{Name: name, Timestamp: time.Now()}
+	for _, arg := range args[1:] {
+		member, err := parseGroupMember(cfg, arg)
+		if err != nil {
+			return err
+		}
+		if slices.ContainsFunc(group.Members, func(m models.GroupMember) bool { return m.Stack == member.Stack }) {
+			if member.Stack == "" {
+				return fmt.Errorf("a group holds one snapshot of this project, not %s and another", arg)
+			}
+			return fmt.Errorf("a group holds one snapshot of each stack, not %s and another of %s", arg, member.Stack)
+		}
+		err = inMember(cfg, member, func() error {
+			snap, _, err := memberSnapshot(member)
+			if err != nil {
+				return err
+			}
+			member.Taken = snap.Timestamp
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		group.Members = append(group.Members, member)
+	}
+	summarize("group", name)
+	summarize("members", len(group.Members))
+
+	if dryRun {
+		dryRunNote("would group %s as '%s'", groupMemberNames(group), name)
+		return nil
+	}
+	if err := mgr.SaveGroup(group); err != nil {
+		return fmt.Errorf("failed to record the group: %w", err)
+	}
+	if !quiet {
+		color.Green("✅ Grouped %d snapshot(s) as '%s': %s", len(group.Members), name, groupMemberNames(group))
+	}
+	return nil
+}
+
+func runGroupList(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	groups, err := snapshot.NewManager(nil, cfg).Groups()
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if groups == nil {
+			groups = []*models.SnapshotGroup{}
+		}
+		return enc.Encode(groups)
+	}
+	if len(groups) == 0 {
+		color.Yellow("No snapshot groups.")
+		fmt.Println()
+		fmt.Println("Create one with: dataclean group create <group> <snapshot> <stack>:<snapshot>...")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tCREATED\tMEMBERS")
+	fmt.Fprintln(w, "----\t-------\t-------")
+	for _, g := range groups {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", g.Name, g.Timestamp.Local().Format("2006-01-02 15:04"), groupMemberNames(g))
+	}
+	w.Flush()
+	return nil
+}
+
+func runGroupRestore(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := guardReadOnly(cfg); err != nil {
+		return err
+	}
+	group, err := snapshot.NewManager(nil, cfg).Group(args[0])
+	if err != nil {
+		return err
+	}
+	if group == nil {
+		return fmt.Errorf("no group named '%s'", args[0])
+	}
+
+	// Check every member before touching any
+	for _, member := range group.Members {
+		err := inMember(cfg, member, func() error {
+			_, memberCfg, err := memberSnapshot(member)
+			if err != nil {
+				return err
+			}
+			if err := guardReadOnly(memberCfg); err != nil {
+				return err
+			}
+			if !memberCfg.BackupBeforeRestore && !dryRun {
+				return fmt.Errorf("backup_before_restore is off, so a failed group restore couldn't put this member back")
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("%s: %w", member, err)
+		}
+	}
+
+	if !force && !dryRun {
+		color.Red("⚠️  This will DELETE existing data and replace it with group %s (%s)!", group.Name, groupMemberNames(group))
+		fmt.Print("Type 'yes' to confirm: ")
+		reader := bufio.NewReader(os.Stdin)
+		response, _ := reader.ReadString('\n')
+		response = strings.TrimSpace(strings.ToLower(response))
+		if response != "yes" {
+			warn("Aborted.")
+			summarize("aborted", true)
+			return nil
+		}
+	}
+
+	// One confirmation covers every member
+	prevForce := force
+	force = true
+	defer func() { force = prevForce }()
+	summarize("group", group.Name)
+
+	var backups []string // Pre-restore backup of each member restored so far
+	for i, member := range group.Members {
+		if !quiet && !jsonOutput {
+			color.New(color.Bold).Printf("━━ %s\n", member)
+		}
+		lastRestore = nil
+		err := inMember(cfg, member, func() error { return runRestore(cmd, []string{member.Snapshot}) })
+		if err == nil && !dryRun && (lastRestore == nil || lastRestore.Backup == "") {
+			err = fmt.Errorf("restored without a pre-restore backup")
+		}
+		if err != nil {
+			if i == 0 {
+				return fmt.Errorf("%s: %w", member, err)
+			}
+			return fmt.Errorf("%s: %w; %s", member, err, rollBackGroup(cfg, group.Members[:i], backups))
+		}
+		if !dryRun {
+			backups = append(backups, lastRestore.Backup)
+		}
+	}
+	summarize("members", len(group.Members))
+	if !quiet && !dryRun {
+		color.Green("✅ Restored group %s: %s", group.Name, groupMemberNames(group))
+	}
+	return nil
+}
+
+// rollBackGroup puts the members a failed group restore had already restored
+// back from their pre-restore backups, last first, and describes the outcome
+func rollBackGroup(cfg *models.Config, members []models.GroupMember, backups []string) string {
+	var rolledBack, failed []string
+	for i := len(members) - 1; i >= 0; i-- {
+		member, backup := members[i], backups[i]
+		if !quiet && !jsonOutput {
+			color.Cyan("↩ Rolling %s back from %s...", member, backup)
+		}
+		err := inMember(cfg, member, func() error {
+			memberCfg, err := config.Load(cfgFile)
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+			client, err := docker.NewClient()
+			if err != nil {
+				return fmt.Errorf("failed to connect to Docker: %w", err)
+			}
+			defer client.Close()
+			_, err = snapshot.NewManager(client, memberCfg).RestoreWithOptions(backup, snapshot.RestoreOptions{SkipBackup: true})
+			return err
+		})
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s (%v; its data is in %s)", member, err, backup))
+			continue
+		}
+		rolledBack = append(rolledBack, member.String())
+	}
+	summarize("rolled_back", len(rolledBack))
+	if len(failed) > 0 {
+		return fmt.Sprintf("rolling back failed for %s", strings.Join(failed, ", "))
+	}
+	return fmt.Sprintf("rolled back %s", strings.Join(rolledBack, ", "))
+}
+
+func runGroupDelete(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := guardReadOnly(cfg); err != nil {
+		return err
+	}
+	mgr := snapshot.NewManager(nil, cfg)
+	if group, err := mgr.Group(args[0]); err != nil {
+		return err
+	} else if group == nil {
+		return fmt.Errorf("no group named '%s'", args[0])
+	}
+	summarize("group", args[0])
+
+	if dryRun {
+		dryRunNote("would delete group '%s'", args[0])
+		return nil
+	}
+	if err := mgr.DeleteGroup(args[0]); err != nil {
+		return err
+	}
+	if !quiet {
+		color.Green("✅ Deleted group '%s'", args[0])
+		fmt.Println("   Its snapshots are kept")
+	}
+	return nil
+}
+
+func groupMemberNames(group *models.SnapshotGroup) string {
+	names := make([]string, len(group.Members))
+	for i, m := range group.Members {
+		names[i] = m.String()
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/stackgen-cli/dataclean/internal/config"
+	"github.com/stackgen-cli/dataclean/internal/docker"
+	"github.com/stackgen-cli/dataclean/internal/models"
+	"github.com/stackgen-cli/dataclean/internal/tui"
+)
+
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Interactively create a .dataclean.yaml config",
+	Long: `Walk through a first-run setup for the current project:
+  • Detect the compose file and its data volumes
+  • Choose which volumes to include (space to toggle in the selector)
+  • Set retention, backup-before-restore, and where snapshots are kept (in
+    the repository, or in the global store outside it)
+  • Record the Docker daemon, so restore and reset refuse to run against
+    another one (say, after a forgotten DOCKER_HOST=prod)
+  • Write .dataclean.yaml and optionally update .gitignore
+
+Examples:
+  dataclean init
+  dataclean init --dry-run    # print the config instead of writing it`,
+	Args: cobra.NoArgs,
+	RunE: runInit,
+}
+
+func init() {
+	rootCmd.AddCommand(initCmd)
+}
+
+func runInit(cmd *cobra.Command, args []string) error {
+	configPath := cfgFile
+	if configPath == "" {
+		configPath = ".dataclean.yaml"
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+
+	if _, err := os.Stat(configPath); err == nil && !force {
+		if !promptYesNo(reader, fmt.Sprintf("%s already exists. Overwrite?", configPath), false) {
+			fmt.Println("Cancelled.")
+			return nil
+		}
+	}
+
+	cfg := models.DefaultConfig()
+
+	// Compose file
+	composeFile, err := docker.FindComposeFile(cfg)
+	if err != nil {
+		return err
+	}
+	cfg.ComposeFile = composeFile
+	color.Cyan("Found compose file: %s", composeFile)
+	fmt.Println()
+
+	// Volumes (detected without filters so every volume is offered)
+	client, err := docker.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to connect to Docker: %w", err)
+	}
+	defer client.Close()
+
+	volumes, err := client.DetectComposeVolumes(models.DefaultConfig())
+	if err != nil {
+		return fmt.Errorf("failed to detect volumes: %w", err)
+	}
+
+	if daemon, err := client.DaemonIdentity(); err == nil && daemon.ID != "" {
+		cfg.Daemon = &daemon
+		color.Cyan("Docker daemon: %s", daemon)
+		fmt.Println()
+	}
+
+	if len(volumes) > 0 {
+		selected, err := tui.RunVolumeSelector(volumes, true, tui.DefaultKeyMap())
+		if err != nil {
+			return err
+		}
+		if len(selected) == 0 {
+			return fmt.Errorf("no volumes selected, so snapshots would be empty: select at least one (space toggles), or exclude volumes later with exclude_volumes")
+		}
+		cfg.ExcludeVolumes = excludedVolumes(volumes, selected)
+		fmt.Printf("Including %d of %d volume(s)\n", len(volumes)-len(cfg.ExcludeVolumes), len(volumes))
+		fmt.Println()
+	} else {
+		warn("⚠️  No named volumes detected; all volumes will be auto-detected later")
+		fmt.Println()
+	}
+
+	// Settings
+	if promptYesNo(reader, "Keep snapshots outside the repository, in the global store (survives git clean and re-clones)?", false) {
+		cfg.Store = models.StoreGlobal
+	} else {
+		cfg.SnapshotDir = prompt(reader, "Snapshot directory", cfg.SnapshotDir)
+	}
+	cfg.BackupBeforeRestore = promptYesNo(reader, "Create a backup before restore/reset?", true)
+	for {
+		answer := prompt(reader, "Retention in days (0 = keep forever)", "0")
+		days, err := strconv.Atoi(answer)
+		if err == nil && days >= 0 {
+			cfg.RetentionDays = days
+			break
+		}
+		color.Yellow("Please enter a whole number of days")
+	}
+
+	if dryRun {
+		data, err := yaml.Marshal(cfg)
+		if err != nil {
+			return err
+		}
+		color.Yellow("🔍 Dry run - would write %s:", configPath)
+		fmt.Println()
+		fmt.Print(string(data))
+		return nil
+	}
+
+	if err := config.Save(cfg, configPath); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	color.Green("✅ Wrote %s", configPath)
+
+	// .gitignore
+	if cfg.Store != models.StoreGlobal && promptYesNo(reader, fmt.Sprintf("Add %s/ to .gitignore?", strings.TrimSuffix(cfg.SnapshotDir, "/")), true) {
+		if err := appendGitignore(strings.TrimSuffix(cfg.SnapshotDir, "/") + "/"); err != nil {
+			return fmt.Errorf("failed to update .gitignore: %w", err)
+		}
+	}
+	if promptYesNo(reader, fmt.Sprintf("Add %s to .gitignore?", configPath), false) {
+		if err := appendGitignore(configPath); err != nil {
+			return fmt.Errorf("failed to update .gitignore: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// excludedVolumes returns compose names of detected volumes that weren't selected
+func excludedVolumes(all, selected []models.Volume) []string {
+	keep := make(map[string]bool)
+	for _, v := range selected {
+		keep[v.Name] = true
+	}
+
+	var excluded []string
+	for _, v := range all {
+		if !keep[v.Name] && !containsString(excluded, v.ComposeName) {
+			excluded = append(excluded, v.ComposeName)
+		}
+	}
+	return excluded
+}
+
+// prompt asks a question and returns the answer or the default
+func prompt(reader *bufio.Reader, question, def string) string {
+	fmt.Printf("%s [%s]: ", question, def)
+	answer, _ := reader.ReadString('\n')
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return def
+	}
+	return answer
+}
+
+// promptYesNo asks a yes/no question and returns the answer or the default
+func promptYesNo(reader *bufio.Reader, question string, def bool) bool {
+	hint := "y/N"
+	if def {
+		hint = "Y/n"
+	}
+	fmt.Printf("%s [%s]: ", question, hint)
+	answer, _ := reader.ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	case "n", "no":
+		return false
+	default:
+		return def
+	}
+}
+
+// appendGitignore adds an entry to .gitignore unless it's already listed
+func appendGitignore(entry string) error {
+	data, err := os.ReadFile(".gitignore")
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	normalize := func(s string) string { return strings.Trim(strings.TrimSpace(s), "/") }
+	for _, line := range strings.Split(string(data), "\n") {
+		if normalize(line) == normalize(entry) {
+			return nil
+		}
+	}
+
+	f, err := os.OpenFile(".gitignore", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if len(data) > 0 && !strings.HasSuffix(string(data), "\n") {
+		entry = "\n" + entry
+	}
+	_, err = fmt.Fprintln(f, entry)
+	return err
+}
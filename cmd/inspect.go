@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/stackgen-cli/dataclean/internal/config"
+	"github.com/stackgen-cli/dataclean/internal/models"
+	"github.com/stackgen-cli/dataclean/internal/snapshot"
+)
+
+var (
+	inspectContents bool
+	inspectEntries  int
+)
+
+var inspectCmd = &cobra.Command{
+	Use:   "inspect <snapshot>",
+	Short: "Show detailed information about a snapshot",
+	Long: `Show a snapshot's metadata: creation time, size, tags, description,
+volumes, and (for snapshots taken with --tables) the tables or collections
+with their row counts at snapshot time.
+
+With --contents, each volume archive is read (headers only, so it is quick
+even for large archives) and its entry counts, first and last --entries
+entries, and the size of each top-level directory are shown: the place to
+start when a snapshot is bigger than expected.
+
+Examples:
+  dataclean inspect before-migration
+  dataclean inspect before-migration --json
+  dataclean inspect before-migration --contents
+  dataclean inspect before-migration --contents --entries 25`,
+	Args: cobra.ExactArgs(1),
+	RunE: runInspect,
+}
+
+func init() {
+	rootCmd.AddCommand(inspectCmd)
+
+	inspectCmd.Flags().BoolVar(&inspectContents, "contents", false, "List what is in each volume archive and what takes up the space")
+	inspectCmd.Flags().IntVar(&inspectEntries, "entries", 10, "With --contents, how many entries to show from the start and end of each archive")
+}
+
+func runInspect(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	// Load config
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	mgr := snapshot.NewManager(nil, cfg)
+	snap, err := mgr.Get(name)
+	if err != nil {
+		return fmt.Errorf("snapshot not found: %s", name)
+	}
+
+	var contents []models.ArchiveContents
+	if inspectContents {
+		for _, v := range snap.Volumes {
+			c, err := mgr.ArchiveContents(name, v.Name, inspectEntries)
+			if err != nil {
+				return fmt.Errorf("failed to list %s: %w", v.Name, err)
+			}
+			contents = append(contents, *c)
+		}
+	}
+
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if inspectContents {
+			return enc.Encode(struct {
+				*models.Snapshot
+				Contents []models.ArchiveContents `json:"contents"`
+			}{snap, contents})
+		}
+		return enc.Encode(snap)
+	}
+
+	printSnapshotDetails(snap)
+	for _, c := range contents {
+		fmt.Println()
+		printArchiveContents(c)
+	}
+	return nil
+}
+
+// printArchiveContents shows a volume archive's entries and top-level directory sizes
+func printArchiveContents(c models.ArchiveContents) {
+	cyan := color.New(color.FgCyan, color.Bold)
+	cyan.Printf("Contents of %s: %d files, %d directories, %s\n", c.Volume, c.Files, c.Dirs, models.FormatSize(c.Bytes))
+
+	fmt.Println("  By top-level directory:")
+	for _, u := range c.TopLevel {
+		fmt.Printf("    %10s  %6d entries  %s\n", models.FormatSize(u.Bytes), u.Entries, u.Path)
+	}
+
+	fmt.Printf("  First %d entries:\n", len(c.First))
+	printEntries(c.First)
+	if len(c.Last) > 0 {
+		if skipped := c.Files + c.Dirs - len(c.First) - len(c.Last); skipped > 0 {
+			fmt.Printf("    ... %d more ...\n", skipped)
+		}
+		fmt.Printf("  Last %d entries:\n", len(c.Last))
+		printEntries(c.Last)
+	}
+}
+
+func printEntries(entries []models.ArchiveEntry) {
+	for _, e := range entries {
+		name := e.Path
+		if e.IsDir {
+			name += "/"
+		}
+		fmt.Printf("    %s %10s  %s\n", e.Mode, models.FormatSize(e.SizeBytes), name)
+	}
+}
+
+func printSnapshotDetails(snap *models.Snapshot) {
+	cyan := color.New(color.FgCyan, color.Bold)
+	white := color.New(color.FgWhite)
+
+	cyan.Printf("Snapshot: %s\n", snap.Name)
+	fmt.Printf("  Created:     %s\n", snap.Timestamp.Format("2006-01-02 15:04:05"))
+	fmt.Printf("  Size:        %s\n", snap.SizeHuman)
+	fmt.Printf("  Path:        %s\n", snap.Path)
+	if snap.Duration > 0 {
+		fmt.Printf("  Took:        %s\n", snap.Duration)
+	}
+	if snap.WrittenBy != "" {
+		fmt.Printf("  Written by:  dataclean %s\n", snap.WrittenBy)
+	}
+	if snap.MinReader != "" {
+		fmt.Printf("  Needs:       dataclean %s or later to restore\n", snap.MinReader)
+	}
+	if snap.SchemaVersion > models.SnapshotSchemaVersion {
+		color.Yellow("  Metadata schema %d is newer than this release's (%d): fields it doesn't know are kept but not shown",
+			snap.SchemaVersion, models.SnapshotSchemaVersion)
+	}
+	if snap.Description != "" {
+		fmt.Printf("  Description: %s\n", snap.Description)
+	}
+	if len(snap.Tags) > 0 {
+		fmt.Printf("  Tags:        %s\n", strings.Join(snap.Tags, ", "))
+	}
+	if snap.ParentName != "" {
+		fmt.Printf("  Parent:      %s\n", snap.ParentName)
+	}
+	if snap.ExpiresAt != nil {
+		fmt.Printf("  Expires:     %s\n", snap.ExpiresAt.Format("2006-01-02 15:04:05"))
+	}
+	if snap.ComposeFile != "" {
+		fmt.Printf("  Compose:     %s\n", filepath.Join(snap.Path, snap.ComposeFile))
+	}
+	if len(snap.Metadata) > 0 {
+		keys := make([]string, 0, len(snap.Metadata))
+		for k := range snap.Metadata {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		fmt.Println("  Metadata:")
+		for _, k := range keys {
+			fmt.Printf("    %s = %s\n", k, snap.Metadata[k])
+		}
+	}
+	fmt.Println()
+
+	cyan.Printf("Volumes (%d):\n", len(snap.Volumes))
+	for _, v := range snap.Volumes {
+		_, icon := models.GetDatastoreInfo(v.DatastoreType)
+		fmt.Printf("  %s %s (%s, %s, %s)\n", icon, v.Name, v.DatastoreType, v.SizeHuman, v.ArchiveFormat)
+		if v.LinkedFrom != "" {
+			white.Printf("      unchanged, linked from: %s\n", v.LinkedFrom)
+		}
+		if v.ExportTime > 0 {
+			white.Printf("      exported in %s (%s/s)\n", v.ExportTime, models.FormatSize(v.ExportRate))
+		}
+		if v.HotCopy != "" {
+			color.Yellow("      copied while being written to: %s", v.HotCopy)
+		}
+		if v.Killed {
+			color.Yellow("      container was killed on stop: may be inconsistent")
+		}
+		if len(v.Foreign) > 0 {
+			white.Printf("      also mounted outside the project by: %s\n", strings.Join(v.Foreign, ", "))
+		}
+		if v.ArchiveDir != "" {
+			white.Printf("      stored in: %s\n", v.ArchiveDir)
+		}
+		if v.ImageDigest != "" {
+			white.Printf("      image: %s (%s)\n", v.ImageName, v.ImageDigest)
+		}
+		if v.LogicalDump != "" {
+			white.Printf("      logical dump: %s\n", v.LogicalDump)
+		}
+		for _, t := range v.Tables {
+			white.Printf("      %-40s %d rows\n", t.Name, t.Rows)
+		}
+	}
+}
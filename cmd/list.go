@@ -1,31 +1,51 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
+	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 
 	"github.com/stackgen-cli/dataclean/internal/config"
-	"github.com/stackgen-cli/dataclean/internal/docker"
+	"github.com/stackgen-cli/dataclean/internal/models"
+	"github.com/stackgen-cli/dataclean/internal/remote"
 	"github.com/stackgen-cli/dataclean/internal/snapshot"
 )
 
+var (
+	listRemote bool
+	listAll    bool
+)
+
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "Show available snapshots",
 	Long: `List all available snapshots for the current project.
 
+--remote lists the snapshots stored on the remote (remote.url in the config)
+instead, with when each was uploaded. --all combines both: every snapshot
+that is local, remote, or both, with the time it was last synced, so those
+already pushed can be deleted locally without losing them.
+
 Examples:
-  dataclean list`,
+  dataclean list
+  dataclean list --remote
+  dataclean list --all`,
 	Aliases: []string{"ls"},
 	RunE:    runList,
 }
 
 func init() {
 	rootCmd.AddCommand(listCmd)
+
+	listCmd.Flags().BoolVar(&listRemote, "remote", false, "list the snapshots on the remote")
+	listCmd.Flags().BoolVar(&listAll, "all", false, "list local and remote snapshots with where each is stored")
 }
 
 func runList(cmd *cobra.Command, args []string) error {
@@ -34,16 +54,12 @@ func runList(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
-
-	// Connect to Docker (needed for manager)
-	client, err := docker.NewClient()
-	if err != nil {
-		return fmt.Errorf("failed to connect to Docker: %w", err)
+	if listRemote || listAll {
+		return runListSync(cfg)
 	}
-	defer client.Close()
 
 	// List snapshots
-	mgr := snapshot.NewManager(client, cfg)
+	mgr := snapshot.NewManager(nil, cfg)
 	snapshots, err := mgr.List()
 	if err != nil {
 		return fmt.Errorf("failed to list snapshots: %w", err)
@@ -56,20 +72,129 @@ func runList(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	// Mark the snapshots the volumes were last restored from
+	restored, err := mgr.RestoredVolumes()
+	if err != nil {
+		return fmt.Errorf("failed to read state: %w", err)
+	}
+
 	// Print table
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "NAME\tCREATED\tSIZE\tVOLUMES")
-	fmt.Fprintln(w, "----\t-------\t----\t-------")
+	fmt.Fprintln(w, "NAME\tCREATED\tSIZE\tVOLUMES\tRESTORED INTO")
+	fmt.Fprintln(w, "----\t-------\t----\t-------\t-------------")
 
 	for _, snap := range snapshots {
-		fmt.Fprintf(w, "%s\t%s\t%s\t%d\n",
+		into := "-"
+		if vols := restored[snap.Name]; len(vols) > 0 {
+			sort.Strings(vols)
+			into = "* " + strings.Join(vols, ", ")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n",
 			snap.Name,
 			snap.Timestamp.Format("2006-01-02 15:04"),
 			snap.SizeHuman,
 			len(snap.Volumes),
+			into,
 		)
 	}
 	w.Flush()
 
+	if len(restored) > 0 && !quiet {
+		fmt.Println()
+		fmt.Println("* current data was last restored from this snapshot (see 'dataclean volumes' for drift)")
+	}
+
+	return nil
+}
+
+// syncEntry is a snapshot in the combined local and remote listing
+type syncEntry struct {
+	Name      string    `json:"name"`
+	Key       string    `json:"key"`
+	Created   time.Time `json:"created,omitzero"`
+	SizeBytes int64     `json:"size_bytes"`
+
+	// Where is local, remote, or both
+	Where string `json:"where"`
+
+	// Synced is when the remote copy was last uploaded
+	Synced time.Time `json:"synced,omitzero"`
+}
+
+// runListSync lists the remote's snapshots (--remote) or every snapshot
+// with where it is stored (--all), matching local snapshots to bundles by key
+func runListSync(cfg *models.Config) error {
+	r, err := openRemote(cfg)
+	if err != nil {
+		return err
+	}
+	bundles, err := r.Bundles()
+	if err != nil {
+		return fmt.Errorf("failed to list the remote: %w", err)
+	}
+	snapshots, err := snapshot.NewManager(nil, cfg).List()
+	if err != nil {
+		return fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	local := make(map[string]*models.Snapshot, len(snapshots))
+	for i := range snapshots {
+		local[remote.Key(&snapshots[i])] = &snapshots[i]
+	}
+	var entries []syncEntry
+	onRemote := make(map[string]bool, len(bundles))
+	for _, b := range bundles {
+		onRemote[b.Key] = true
+		e := syncEntry{Name: b.Name, Key: b.Key, Created: b.Created, SizeBytes: b.SizeBytes, Where: "remote", Synced: b.Uploaded}
+		if snap := local[b.Key]; snap != nil {
+			// Pulled snapshots may be saved under another name
+			e.Name, e.Created, e.Where = snap.Name, snap.Timestamp, "both"
+		}
+		entries = append(entries, e)
+	}
+	if listAll {
+		for _, snap := range snapshots {
+			if key := remote.Key(&snap); !onRemote[key] {
+				entries = append(entries, syncEntry{Name: snap.Name, Key: key, Created: snap.Timestamp, SizeBytes: snap.SizeBytes, Where: "local"})
+			}
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Created.Before(entries[j].Created) })
+
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	}
+	if len(entries) == 0 {
+		color.Yellow("No snapshots found.")
+		fmt.Println()
+		fmt.Println("Upload one with: dataclean push <snapshot>")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tCREATED\tSIZE\tWHERE\tLAST SYNC\tKEY")
+	fmt.Fprintln(w, "----\t-------\t----\t-----\t---------\t---")
+	pushed := 0
+	for _, e := range entries {
+		created, synced := "-", "-"
+		if !e.Created.IsZero() {
+			created = e.Created.Local().Format("2006-01-02 15:04")
+		}
+		if !e.Synced.IsZero() {
+			synced = e.Synced.Local().Format("2006-01-02 15:04")
+		}
+		if e.Where == "both" {
+			pushed++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", e.Name, created, models.FormatSize(e.SizeBytes), e.Where, synced, e.Key)
+	}
+	w.Flush()
+
+	if pushed > 0 && !quiet {
+		fmt.Println()
+		fmt.Printf("%d snapshot(s) marked both are on the remote too: deleting them locally keeps the remote copy ('dataclean pull' gets it back)\n", pushed)
+	}
 	return nil
 }
@@ -0,0 +1,291 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stackgen-cli/dataclean/internal/config"
+	"github.com/stackgen-cli/dataclean/internal/docker"
+	"github.com/stackgen-cli/dataclean/internal/models"
+	"github.com/stackgen-cli/dataclean/internal/rpc"
+	"github.com/stackgen-cli/dataclean/internal/snapshot"
+)
+
+var mcpCmd = &cobra.Command{
+	Use:   "mcp",
+	Short: "Run an MCP server on stdio for AI coding agents",
+	Long: `Serve the Model Context Protocol on stdin/stdout, so AI coding agents can
+list and take snapshots, and reset dev data between tasks.
+
+Tools:
+  list_snapshots     list saved snapshots
+  create_snapshot    snapshot the compose project's volumes
+  restore_snapshot   restore a snapshot over the live volumes (destructive)
+  delete_snapshot    delete a saved snapshot (destructive)
+
+Destructive tools are off until allowed in the config:
+
+  mcp:
+    allow_tools: [restore_snapshot]
+
+In read-only mode (readonly in the config) only list_snapshots is offered.
+
+Even when allowed, restores refuse protected volumes and a Docker daemon
+other than the one recorded in the config, and deletes refuse snapshots that
+incremental snapshots depend on.
+
+Register it with an agent as a stdio server, e.g.:
+  {"command": "dataclean", "args": ["mcp"]}`,
+	Args: cobra.NoArgs,
+	RunE: runMCP,
+}
+
+func init() {
+	rootCmd.AddCommand(mcpCmd)
+}
+
+// mcpProtocolVersions are the MCP revisions this server speaks, newest first
+var mcpProtocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// mcpTool is a tool as described by tools/list
+type mcpTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	InputSchema map[string]any `json:"inputSchema"`
+
+	run      func(args json.RawMessage) (any, error)
+	readOnly bool // Offered in read-only mode
+}
+
+// mcpContent is a tool result; failures are reported as results with
+// IsError set, so the agent can see what went wrong
+type mcpContent struct {
+	Content []mcpText `json:"content"`
+	IsError bool      `json:"isError,omitempty"`
+}
+
+type mcpText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+func runMCP(cmd *cobra.Command, args []string) error {
+	// Fail early on a broken config rather than on the first tool call
+	if _, err := config.Load(cfgFile); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	client, err := docker.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to connect to Docker: %w", err)
+	}
+	defer client.Close()
+
+	tools := mcpTools(client)
+	server := rpc.NewServer()
+
+	server.Handle("initialize", func(params json.RawMessage) (any, error) {
+		var p struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		if err := rpc.DecodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		protocol := mcpProtocolVersions[0]
+		if slices.Contains(mcpProtocolVersions, p.ProtocolVersion) {
+			protocol = p.ProtocolVersion
+		}
+		return map[string]any{
+			"protocolVersion": protocol,
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]string{"name": "dataclean", "version": version},
+			"instructions": "Snapshots of this project's Docker Compose data volumes. Take a snapshot " +
+				"before changing data you may want back, and restore it to reset between tasks.",
+		}, nil
+	})
+	server.Handle("notifications/initialized", func(params json.RawMessage) (any, error) {
+		return nil, nil
+	})
+	server.Handle("ping", func(params json.RawMessage) (any, error) {
+		return nil, nil
+	})
+
+	server.Handle("tools/list", func(params json.RawMessage) (any, error) {
+		cfg, err := config.Load(cfgFile)
+		if err != nil {
+			return nil, err
+		}
+		offered := []mcpTool{}
+		for _, t := range tools {
+			if cfg.ReadOnly && !t.readOnly {
+				continue
+			}
+			if !slices.Contains(models.MCPDestructiveTools, t.Name) || cfg.MCP.Allows(t.Name) {
+				offered = append(offered, t)
+			}
+		}
+		return map[string]any{"tools": offered}, nil
+	})
+
+	server.Handle("tools/call", func(params json.RawMessage) (any, error) {
+		var p struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := rpc.DecodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		i := slices.IndexFunc(tools, func(t mcpTool) bool { return t.Name == p.Name })
+		if i < 0 {
+			return nil, &rpc.Error{Code: rpc.CodeInvalidParams, Message: fmt.Sprintf("unknown tool: %s", p.Name)}
+		}
+
+		result, err := callMCPTool(tools[i], p.Arguments)
+		if err != nil {
+			return mcpContent{Content: []mcpText{{Type: "text", Text: err.Error()}}, IsError: true}, nil
+		}
+		text, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return mcpContent{Content: []mcpText{{Type: "text", Text: string(text)}}}, nil
+	})
+
+	if !quiet {
+		server.OnCall = func(method string, err error) {
+			if err != nil {
+				warn("%s: %v", method, err)
+			}
+		}
+	}
+	// stdout carries the protocol; everything else goes to stderr
+	return server.ServeConn(os.Stdin, os.Stdout)
+}
+
+// callMCPTool runs a tool, refusing destructive ones the config doesn't allow.
+// The allowlist is checked on every call, so it can't go stale.
+func callMCPTool(tool mcpTool, args json.RawMessage) (any, error) {
+	if slices.Contains(models.MCPDestructiveTools, tool.Name) {
+		cfg, err := config.Load(cfgFile)
+		if err != nil {
+			return nil, err
+		}
+		if !cfg.MCP.Allows(tool.Name) {
+			return nil, fmt.Errorf("%s is not allowed: add it to mcp.allow_tools in the dataclean config", tool.Name)
+		}
+	}
+	return tool.run(args)
+}
+
+// nameArgs are the arguments of tools that act on one snapshot
+type nameArgs struct {
+	Name string `json:"name"`
+}
+
+func decodeNameArgs(args json.RawMessage) (string, error) {
+	var a nameArgs
+	if err := rpc.DecodeParams(args, &a); err != nil {
+		return "", err
+	}
+	if a.Name == "" {
+		return "", fmt.Errorf("name is required")
+	}
+	return a.Name, nil
+}
+
+var mcpNameSchema = map[string]any{
+	"type":       "object",
+	"properties": map[string]any{"name": map[string]any{"type": "string", "description": "Snapshot name"}},
+	"required":   []string{"name"},
+}
+
+func mcpTools(client *docker.Client) []mcpTool {
+	return []mcpTool{
+		{
+			Name:        "list_snapshots",
+			Description: "List saved snapshots of the project's data volumes, newest first.",
+			InputSchema: map[string]any{"type": "object", "properties": map[string]any{}},
+			run: func(args json.RawMessage) (any, error) {
+				snapshots, err := listSnapshots(client)
+				return map[string]any{"snapshots": snapshots}, err
+			},
+			readOnly: true,
+		},
+		{
+			Name:        "create_snapshot",
+			Description: "Snapshot the project's Docker Compose data volumes. Names default to snapshot-<time>.",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"name":        map[string]any{"type": "string", "description": "Snapshot name"},
+					"description": map[string]any{"type": "string"},
+					"tags":        map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+					"volumes": map[string]any{"type": "array", "items": map[string]any{"type": "string"},
+						"description": "Only these volumes (Docker or compose names); default all"},
+				},
+			},
+			run: func(args json.RawMessage) (any, error) {
+				var p snapshotParams
+				if err := rpc.DecodeParams(args, &p); err != nil {
+					return nil, err
+				}
+				return takeSnapshot(client, p)
+			},
+		},
+		{
+			Name:        models.MCPRestoreTool,
+			Description: "Restore a snapshot over the live data volumes, replacing their current data.",
+			InputSchema: mcpNameSchema,
+			run: func(args json.RawMessage) (any, error) {
+				name, err := decodeNameArgs(args)
+				if err != nil {
+					return nil, err
+				}
+				return restoreSnapshot(client, name)
+			},
+		},
+		{
+			Name:        models.MCPDeleteTool,
+			Description: "Delete a saved snapshot. Snapshots that others are based on can't be deleted.",
+			InputSchema: mcpNameSchema,
+			run: func(args json.RawMessage) (any, error) {
+				name, err := decodeNameArgs(args)
+				if err != nil {
+					return nil, err
+				}
+				if err := deleteSnapshot(client, name); err != nil {
+					return nil, err
+				}
+				return map[string]string{"deleted": name}, nil
+			},
+		},
+	}
+}
+
+// deleteSnapshot deletes a snapshot no incremental snapshot depends on
+func deleteSnapshot(client *docker.Client, name string) error {
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return err
+	}
+	mgr := snapshot.NewManager(client, cfg)
+	snap, err := mgr.Get(name)
+	if err != nil {
+		return fmt.Errorf("snapshot not found: %s", name)
+	}
+	dependents, err := mgr.Dependents(name)
+	if err != nil {
+		return err
+	}
+	if len(dependents) > 0 {
+		return fmt.Errorf("incremental snapshots depend on '%s': %v", name, dependents)
+	}
+	if err := mgr.Delete(name); err != nil {
+		return err
+	}
+	reportSnapshotEvent(cfg, models.SnapshotDeleted, snap)
+	return nil
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/stackgen-cli/dataclean/internal/config"
+	"github.com/stackgen-cli/dataclean/internal/models"
+	"github.com/stackgen-cli/dataclean/internal/snapshot"
+)
+
+var migrateStoreCmd = &cobra.Command{
+	Use:   "migrate-store",
+	Short: "Upgrade snapshots written by older releases to the current format",
+	Long: fmt.Sprintf(`Upgrade every snapshot in the snapshot directory to format version %d, in
+place. Metadata records the format version each snapshot was written in; this
+release reads older ones, but some checks need what they lack:
+
+  • Directories holding volume archives but no metadata.yaml (from before
+    metadata was written) get generated metadata, so list and restore see them
+  • Archives without a checksum are read back in full and checksummed, so
+    restore can verify them
+
+Archives in the legacy tar format can't be upgraded without the original
+volumes, and are listed as notes. Snapshots written by a newer release are
+left alone and refused by every command.
+
+Examples:
+  dataclean migrate-store --dry-run   # list what would change
+  dataclean migrate-store`, models.SnapshotFormatVersion),
+	Args: cobra.NoArgs,
+	RunE: runMigrateStore,
+}
+
+func init() {
+	rootCmd.AddCommand(migrateStoreCmd)
+	withSummary(migrateStoreCmd)
+}
+
+func runMigrateStore(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := guardReadOnly(cfg); err != nil {
+		return err
+	}
+
+	mgr := snapshot.NewManager(nil, cfg)
+	migrations, err := mgr.MigrateStore(!dryRun)
+	if err != nil {
+		return fmt.Errorf("failed to read snapshot directory: %w", err)
+	}
+
+	failed := 0
+	for _, mig := range migrations {
+		if mig.Error != "" {
+			failed++
+		}
+	}
+	summarize("snapshots", len(migrations)-failed)
+	summarize("failed", failed)
+
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(migrations); err != nil {
+			return err
+		}
+	} else if !quiet {
+		printMigrations(migrations)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d snapshot(s) could not be migrated", failed)
+	}
+	return nil
+}
+
+// printMigrations shows what was, or would be, done to each snapshot
+func printMigrations(migrations []models.StoreMigration) {
+	if len(migrations) == 0 {
+		color.Green("✅ Every snapshot is at format version %d", models.SnapshotFormatVersion)
+		return
+	}
+
+	for _, mig := range migrations {
+		switch {
+		case mig.Error != "":
+			color.Red("  ✗ %s: %s", mig.Snapshot, mig.Error)
+		case dryRun:
+			fmt.Printf("  • %s (format %d): would\n", mig.Snapshot, mig.From)
+		default:
+			color.Green("  ✓ %s (format %d → %d)", mig.Snapshot, mig.From, models.SnapshotFormatVersion)
+		}
+		if mig.Error == "" {
+			for _, step := range mig.Steps {
+				fmt.Printf("      %s\n", step)
+			}
+		}
+		for _, note := range mig.Notes {
+			color.Yellow("      note: %s", note)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/stackgen-cli/dataclean/internal/config"
+	"github.com/stackgen-cli/dataclean/internal/models"
+	"github.com/stackgen-cli/dataclean/internal/remote"
+	"github.com/stackgen-cli/dataclean/internal/snapshot"
+)
+
+var mirrorCmd = &cobra.Command{
+	Use:   "mirror",
+	Short: "Copy snapshots to the mirror target and prune old copies",
+	Long: `Copy every local snapshot the mirror policy (mirror in the config) covers to
+its target, unless it is there already, then prune the copies past the
+policy's keep and retention_days.
+
+New snapshots are mirrored as soon as they are taken; run this to catch up
+after the target was unreachable, or after changing the policy. Mirrored
+copies are listed on a channel of the target (mirror-<project> unless
+mirror.channel is set), and only copies on that channel are pruned. Copies
+another channel still lists stay stored. Local retention doesn't apply to the
+mirror: a snapshot pruned locally keeps its copy until the mirror's own
+retention drops it.
+
+Pull a mirrored copy back with 'dataclean pull --channel mirror-<project>', or
+restore it directly with 'restore --from <key>'.
+
+Examples:
+  dataclean mirror --dry-run
+  dataclean mirror`,
+	Args: cobra.NoArgs,
+	RunE: runMirror,
+}
+
+func init() {
+	rootCmd.AddCommand(mirrorCmd)
+	withSummary(mirrorCmd)
+}
+
+func runMirror(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if cfg.Mirror.Target == "" {
+		return fmt.Errorf("no mirror configured (set mirror.target in the config)")
+	}
+	if cfg.Mirror, err = config.Mirror(cfg); err != nil {
+		return err
+	}
+
+	mgr := snapshot.NewManager(nil, cfg)
+	snapshots, err := mgr.List()
+	if err != nil {
+		return fmt.Errorf("failed to list snapshots: %w", err)
+	}
+	var wanted []models.Snapshot
+	for _, s := range snapshots {
+		if cfg.Mirror.Wants(&s) {
+			wanted = append(wanted, s)
+		}
+	}
+	sort.Slice(wanted, func(i, j int) bool { return wanted[i].Timestamp.Before(wanted[j].Timestamp) })
+	// Older ones would only be pruned again
+	if keep := cfg.Mirror.Keep; keep > 0 && len(wanted) > keep {
+		wanted = wanted[len(wanted)-keep:]
+	}
+
+	if dryRun {
+		r, err := remote.Open(cfg.Mirror.Target)
+		if err != nil {
+			return err
+		}
+		for _, s := range wanted {
+			if !r.Has(remote.Key(&s)) && !mirrorExpired(cfg, &s) {
+				dryRunNote("would mirror snapshot '%s' (%s) to %s", s.Name, s.SizeHuman, cfg.Mirror.Target)
+			}
+		}
+		expired, err := r.Expired(cfg.Mirror.Channel, cfg.Mirror.Keep, mirrorMaxAge(cfg), time.Now())
+		if err != nil {
+			return err
+		}
+		for _, key := range expired {
+			dryRunNote("would prune mirrored copy %s", key)
+		}
+		return nil
+	}
+
+	uploaded, pruned, err := mirrorSnapshots(cfg, mgr, wanted)
+	summarize("uploaded", uploaded)
+	summarize("pruned", len(pruned))
+	if err != nil {
+		return err
+	}
+
+	if !quiet {
+		color.Green("✅ Mirrored %d snapshot(s) to %s (%d already there)", uploaded, cfg.Mirror.Target, len(wanted)-uploaded)
+		for _, key := range pruned {
+			fmt.Printf("   Pruned %s\n", key)
+		}
+	}
+	return nil
+}
+
+// mirrorSnapshot runs the mirror policy for a snapshot just taken, noting an
+// upload on out. Failures are warnings: the snapshot is safe locally either
+// way, and 'dataclean mirror' catches up later.
+func mirrorSnapshot(out io.Writer, cfg *models.Config, snap *models.Snapshot) {
+	if snap == nil || !cfg.Mirror.Wants(snap) {
+		return
+	}
+	mirror, err := config.Mirror(cfg)
+	uploaded := 0
+	if err == nil {
+		// cfg may be shared (serve), so the resolved target goes in a copy
+		resolved := *cfg
+		resolved.Mirror = mirror
+		uploaded, _, err = mirrorSnapshots(&resolved, snapshot.NewManager(nil, cfg), []models.Snapshot{*snap})
+	}
+	if err != nil {
+		warn("⚠️  Snapshot not mirrored: %v (retry with 'dataclean mirror')", err)
+		return
+	}
+	if uploaded > 0 && !quiet && !jsonOutput {
+		fmt.Fprintf(out, "   Mirrored to %s\n", mirror.Target)
+	}
+}
+
+// mirrorSnapshots uploads the snapshots missing from the mirror target, lists
+// them on the mirror channel, and retires the copies past the policy's
+// retention. It returns how many were uploaded and which copies were pruned.
+func mirrorSnapshots(cfg *models.Config, mgr *snapshot.Manager, snaps []models.Snapshot) (int, []string, error) {
+	r, err := remote.Open(cfg.Mirror.Target)
+	if err != nil {
+		return 0, nil, err
+	}
+	channel := cfg.Mirror.Channel
+	history, err := r.History(channel)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	uploaded := 0
+	for _, s := range snaps {
+		if mirrorExpired(cfg, &s) {
+			continue // would be pruned right away
+		}
+		key := remote.Key(&s)
+		if !r.Has(key) {
+			name := s.Name
+			if err := r.Upload(key, func(w io.Writer) error { return mgr.Bundle(name, w) }); err != nil {
+				return uploaded, nil, fmt.Errorf("failed to mirror snapshot '%s': %w", s.Name, err)
+			}
+			uploaded++
+		}
+		if !slices.Contains(history, key) {
+			if err := r.Publish(channel, key); err != nil {
+				return uploaded, nil, err
+			}
+			history = append(history, key)
+		}
+	}
+
+	expired, err := r.Expired(channel, cfg.Mirror.Keep, mirrorMaxAge(cfg), time.Now())
+	if err != nil || len(expired) == 0 {
+		return uploaded, nil, err
+	}
+	if err := r.Retire(channel, expired); err != nil {
+		return uploaded, nil, fmt.Errorf("failed to prune mirrored copies: %w", err)
+	}
+	return uploaded, expired, nil
+}
+
+// mirrorMaxAge is the mirror's retention_days as a duration, 0 for forever
+func mirrorMaxAge(cfg *models.Config) time.Duration {
+	return time.Duration(cfg.Mirror.RetentionDays) * 24 * time.Hour
+}
+
+// mirrorExpired reports whether a snapshot is already older than the mirror keeps copies
+func mirrorExpired(cfg *models.Config, s *models.Snapshot) bool {
+	maxAge := mirrorMaxAge(cfg)
+	return maxAge > 0 && time.Since(s.Timestamp) > maxAge
+}
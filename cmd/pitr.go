@@ -0,0 +1,251 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/stackgen-cli/dataclean/internal/config"
+	"github.com/stackgen-cli/dataclean/internal/docker"
+	"github.com/stackgen-cli/dataclean/internal/models"
+	"github.com/stackgen-cli/dataclean/internal/snapshot"
+)
+
+var pitrCmd = &cobra.Command{
+	Use:   "pitr",
+	Short: "Point-in-time recovery for Postgres volumes",
+	Long: `Continuously archive Postgres WAL so a restore can roll forward from a
+snapshot to any moment after it.
+
+Once enabled, Postgres archives each finished WAL segment (at least once a
+minute while there are writes). 'pitr sync' moves archived segments into the
+snapshot directory; snapshots and point-in-time restores also sync.
+
+Take a snapshot after enabling: it is the earliest point restores can start
+from. Then restore with:
+
+  dataclean restore --to "2024-05-01 14:30"
+
+Redis volumes listed under aof_capture in the config are covered by 'pitr
+sync' too: each sync saves a copy of their append-only files, and restore --to
+loads the newest copy taken before the target time.
+
+Volumes default to every Postgres volume in the compose project (and, for
+sync, every Redis volume with AOF capture).
+
+Examples:
+  dataclean pitr enable
+  dataclean pitr enable pgdata
+  dataclean pitr sync
+  dataclean pitr status
+  dataclean pitr disable`,
+}
+
+var pitrEnableCmd = &cobra.Command{
+	Use:   "enable [volume...]",
+	Short: "Turn on WAL archiving (restarts Postgres)",
+	RunE:  runPITR("enable"),
+}
+
+var pitrDisableCmd = &cobra.Command{
+	Use:   "disable [volume...]",
+	Short: "Turn off WAL archiving (restarts Postgres)",
+	RunE:  runPITR("disable"),
+}
+
+var pitrSyncCmd = &cobra.Command{
+	Use:   "sync [volume...]",
+	Short: "Move archived WAL and capture Redis AOF into the snapshot directory",
+	RunE:  runPITR("sync"),
+}
+
+var pitrStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show which volumes archive WAL or capture AOF, and how much is stored",
+	Args:  cobra.NoArgs,
+	RunE:  runPITRStatus,
+}
+
+func init() {
+	rootCmd.AddCommand(pitrCmd)
+	pitrCmd.AddCommand(pitrEnableCmd, pitrDisableCmd, pitrSyncCmd, pitrStatusCmd)
+	withSummary(pitrEnableCmd)
+	withSummary(pitrDisableCmd)
+	withSummary(pitrSyncCmd)
+}
+
+// runPITR runs one of the per-volume pitr actions
+func runPITR(action string) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load(cfgFile)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		if err := guardReadOnly(cfg); err != nil {
+			return err
+		}
+
+		client, err := docker.NewClient()
+		if err != nil {
+			return fmt.Errorf("failed to connect to Docker: %w", err)
+		}
+		defer client.Close()
+
+		volumes, err := pitrTargets(client, cfg, args, action == "sync")
+		if err != nil {
+			return err
+		}
+		summarize("volumes", len(volumes))
+
+		if dryRun {
+			for _, v := range volumes {
+				dryRunNote("would %s point-in-time recovery for %s", action, v.Name)
+			}
+			return nil
+		}
+
+		mgr := snapshot.NewManager(client, cfg)
+		for _, v := range volumes {
+			switch action {
+			case "enable":
+				if err := mgr.EnablePITR(v); err != nil {
+					return err
+				}
+				if !quiet {
+					color.Green("✅ WAL archiving enabled for %s", v.Name)
+				}
+			case "disable":
+				if err := mgr.DisablePITR(v); err != nil {
+					return err
+				}
+				if !quiet {
+					color.Green("✅ WAL archiving disabled for %s (archived WAL kept in %s)", v.Name, mgr.WALDir(v))
+				}
+			case "sync":
+				if cfg.AOFCapture.Enabled(v) {
+					path, err := mgr.CaptureAOF(v)
+					if err != nil {
+						return err
+					}
+					summarize("aof_captures", 1)
+					if !quiet {
+						fmt.Printf("  • %s: AOF captured to %s\n", v.Name, path)
+					}
+					continue
+				}
+				n, err := mgr.SyncWAL(v)
+				if err != nil {
+					return err
+				}
+				summarize("segments", n)
+				if !quiet {
+					fmt.Printf("  • %s: %d segment(s) moved to %s\n", v.Name, n, mgr.WALDir(v))
+				}
+			}
+		}
+
+		if action == "enable" && !quiet {
+			fmt.Println("   Take a snapshot now: restores roll forward from snapshots taken after this point.")
+		}
+		return nil
+	}
+}
+
+// pitrTargets returns the Postgres volumes named in args, or all of them.
+// withAOF adds Redis volumes that have AOF capture on.
+func pitrTargets(client *docker.Client, cfg *models.Config, args []string, withAOF bool) ([]models.Volume, error) {
+	detected, err := client.DetectComposeVolumes(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect volumes: %w", err)
+	}
+
+	var volumes []models.Volume
+	for _, v := range detected {
+		named := slices.Contains(args, v.Name) || (v.ComposeName != "" && slices.Contains(args, v.ComposeName))
+		if len(args) > 0 && !named {
+			continue
+		}
+		if v.DatastoreType != models.DatastorePostgres && !(withAOF && cfg.AOFCapture.Enabled(v)) {
+			if named {
+				return nil, fmt.Errorf("point-in-time recovery needs a Postgres volume, %s is %s", v.Name, v.DatastoreType)
+			}
+			continue
+		}
+		volumes = append(volumes, v)
+	}
+	if len(volumes) == 0 {
+		return nil, fmt.Errorf("no matching volumes found")
+	}
+	return volumes, nil
+}
+
+func runPITRStatus(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	mgr := snapshot.NewManager(nil, cfg)
+	state, err := mgr.LoadState()
+	if err != nil {
+		return fmt.Errorf("failed to read state: %w", err)
+	}
+	aofVolumes := mgr.AOFCapturedVolumes()
+	if len(state.PITR) == 0 && len(aofVolumes) == 0 {
+		fmt.Println("Point-in-time recovery is not enabled. Turn it on with: dataclean pitr enable")
+		return nil
+	}
+
+	names := make([]string, 0, len(state.PITR))
+	for name := range state.PITR {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VOLUME\tKIND\tSINCE\tFILES\tSIZE\tNEWEST")
+	for _, name := range names {
+		count, size, newest := walStats(mgr.WALDir(models.Volume{Name: name}))
+		fmt.Fprintf(w, "%s\tpostgres wal\t%s\t%d\t%s\t%s\n", name, state.PITR[name].Format("2006-01-02 15:04:05"),
+			count, models.FormatSize(size), formatNewest(newest))
+	}
+	for _, name := range aofVolumes {
+		count, size, newest := walStats(mgr.AOFDir(models.Volume{Name: name}))
+		fmt.Fprintf(w, "%s\tredis aof\t-\t%d\t%s\t%s\n", name, count, models.FormatSize(size), formatNewest(newest))
+	}
+	return w.Flush()
+}
+
+// formatNewest shows when the newest file arrived, or - for none
+func formatNewest(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
+
+// walStats counts the files in a WAL or AOF directory, their total size, and
+// when the newest one arrived
+func walStats(dir string) (count int, size int64, newest time.Time) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, 0, time.Time{}
+	}
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil || e.IsDir() {
+			continue
+		}
+		count++
+		size += info.Size()
+		if info.ModTime().After(newest) {
+			newest = info.ModTime()
+		}
+	}
+	return count, size, newest
+}
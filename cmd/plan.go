@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+// printPlan renders a dry-run plan as JSON or a human-readable step list
+func printPlan(plan *models.Plan) error {
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(plan)
+	}
+
+	summarize("dry_run", true)
+	summarize("actions", len(plan.Actions))
+	summarize("estimated_bytes", plan.EstimatedBytes)
+	if quiet {
+		return nil
+	}
+
+	color.Yellow("🔍 Dry run - no changes made. Planned steps for %s:", plan.Operation)
+	fmt.Println()
+	step := 0
+	for i, a := range plan.Actions {
+		if a.Step != step && a.Step <= len(plan.Steps) {
+			step = a.Step
+			fmt.Printf("  Step %d: %s\n", step, plan.Steps[step-1])
+		}
+		fmt.Printf("  %2d. %-16s %s", i+1, a.Kind, a.Target)
+		if a.Path != "" {
+			fmt.Printf(" → %s", a.Path)
+		}
+		if a.SizeHuman != "" {
+			fmt.Printf(" [%s]", a.SizeHuman)
+		}
+		if a.Note != "" {
+			fmt.Printf(" (%s)", a.Note)
+		}
+		fmt.Println()
+	}
+	fmt.Println()
+	fmt.Printf("   Estimated data: %s\n", models.FormatSize(plan.EstimatedBytes))
+	return nil
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"text/tabwriter"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/stackgen-cli/dataclean/internal/config"
+	"github.com/stackgen-cli/dataclean/internal/plugin"
+)
+
+var pluginsCmd = &cobra.Command{
+	Use:   "plugins",
+	Short: "List exporter plugins and the volumes assigned to them",
+	Long: `List the exporter plugins found in the plugins directory (plugins_dir in the
+config, default ~/.config/dataclean/plugins).
+
+An exporter plugin is an executable named dataclean-exporter-<name> that takes
+over exporting and importing volumes, for backup tooling dataclean doesn't
+know. exporters rules in the config assign volumes to plugins:
+
+  exporters:
+    - volume: "*_pgdata"
+      plugin: vault
+
+dataclean runs the plugin once per call, writes a JSON request to its stdin
+and reads a JSON response from its stdout:
+
+  {"protocol": 1, "method": "describe"}
+  {"protocol": 1, "method": "export", "volume": {...}, "archive": "/abs/path/pgdata.tar.gz"}
+  {"protocol": 1, "method": "import", "volume": {...}, "archive": "/abs/path/pgdata.tar.gz"}
+
+Describe answers {"name": ..., "description": ...}; export and import answer
+{} or {"error": "..."}. Exports write the volume's contents as a gzipped tar,
+which dataclean verifies and checksums like its own; imports fill the cleared
+volume from one. Snapshots record the plugin per volume, and restores use it
+even if the rules have changed since.
+
+Examples:
+  dataclean plugins
+  dataclean plugins --json`,
+	Args:         cobra.NoArgs,
+	RunE:         runPlugins,
+	SilenceUsage: true,
+}
+
+func init() {
+	rootCmd.AddCommand(pluginsCmd)
+}
+
+// pluginInfo is one plugin in --json output
+type pluginInfo struct {
+	plugin.Plugin
+	Description string `json:"description,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+func runPlugins(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	plugins, err := plugin.Discover(cfg.PluginsDir)
+	if err != nil {
+		return fmt.Errorf("failed to read plugins directory: %w", err)
+	}
+
+	infos := make([]pluginInfo, len(plugins))
+	for i, p := range plugins {
+		infos[i] = pluginInfo{Plugin: p}
+		if resp, err := p.Describe(); err != nil {
+			infos[i].Error = err.Error()
+		} else {
+			infos[i].Description = resp.Description
+		}
+	}
+	var missing []string
+	for _, r := range cfg.Exporters {
+		found := slices.ContainsFunc(plugins, func(p plugin.Plugin) bool { return p.Name == r.Plugin })
+		if !found && !slices.Contains(missing, r.Plugin) {
+			missing = append(missing, r.Plugin)
+		}
+	}
+
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(infos); err != nil {
+			return err
+		}
+	} else {
+		if len(infos) == 0 {
+			fmt.Printf("No exporter plugins in %s\n", cfg.PluginsDir)
+		} else {
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tDESCRIPTION\tPATH")
+			for _, info := range infos {
+				desc := info.Description
+				if info.Error != "" {
+					desc = "error: " + info.Error
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\n", info.Name, desc, info.Path)
+			}
+			w.Flush()
+		}
+		for _, name := range missing {
+			color.Yellow("⚠️  exporters assign volumes to %s, which isn't installed (%s%s)", name, plugin.Prefix, name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%d exporter plugin(s) missing", len(missing))
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stackgen-cli/dataclean/internal/docker"
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+var (
+	// allowProtected lists the protected volumes the user explicitly allowed
+	// this run to overwrite
+	allowProtected []string
+
+	// anyDaemon skips the check that Docker is the daemon recorded by init
+	anyDaemon bool
+)
+
+// withSafetyOverrides adds the flags that get past guardProtected and
+// guardDaemon to a command that overwrites volumes
+func withSafetyOverrides(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVar(&allowProtected, "i-know-what-im-doing", nil,
+		"overwrite this protected volume anyway (repeat per volume)")
+	cmd.Flags().BoolVar(&anyDaemon, "any-daemon", false,
+		"run even if Docker isn't the daemon recorded in the config")
+}
+
+// guardReadOnly refuses commands that change snapshots or volumes in
+// read-only mode. --dry-run still shows what they would do.
+func guardReadOnly(cfg *models.Config) error {
+	if dryRun {
+		return nil
+	}
+	return cfg.Writable()
+}
+
+// guardProtected refuses to overwrite volumes protected by a policy unless
+// each one is named with --i-know-what-im-doing. --force doesn't count: it is
+// exactly what a script pointed at the wrong Docker daemon would pass.
+func guardProtected(cfg *models.Config, volumes []models.Volume) error {
+	var blocked []string
+	for _, v := range volumes {
+		if !cfg.Protected(v) {
+			continue
+		}
+		if slices.Contains(allowProtected, v.Name) || (v.ComposeName != "" && slices.Contains(allowProtected, v.ComposeName)) {
+			continue
+		}
+		blocked = append(blocked, v.Name)
+	}
+	if len(blocked) == 0 {
+		return nil
+	}
+	return fmt.Errorf("refusing to overwrite protected volume(s): %s\n"+
+		"Check that DOCKER_HOST points where you think it does. To proceed anyway, pass --i-know-what-im-doing=<volume> for each",
+		strings.Join(blocked, ", "))
+}
+
+// guardDaemon refuses to overwrite volumes when Docker isn't the daemon
+// recorded in the config, such as after a forgotten DOCKER_HOST=prod
+func guardDaemon(cfg *models.Config, client *docker.Client) error {
+	if cfg.Daemon == nil || cfg.Daemon.ID == "" || anyDaemon {
+		return nil
+	}
+	current, err := client.DaemonIdentity()
+	if err != nil {
+		return fmt.Errorf("%w (can't check it is %s; pass --any-daemon to skip the check)", err, cfg.Daemon)
+	}
+	if current.ID != cfg.Daemon.ID {
+		return fmt.Errorf("connected to Docker daemon %s, but this project belongs to %s\n"+
+			"Check DOCKER_HOST and 'docker context'. To proceed anyway, pass --any-daemon", current, cfg.Daemon)
+	}
+	return nil
+}
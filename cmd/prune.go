@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/stackgen-cli/dataclean/internal/config"
+	"github.com/stackgen-cli/dataclean/internal/models"
+	"github.com/stackgen-cli/dataclean/internal/remote"
+	"github.com/stackgen-cli/dataclean/internal/snapshot"
+)
+
+var pruneRemote bool
+
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete the snapshots retention no longer keeps",
+	Long: `Delete the local snapshots past their --expires date or older than
+retention_days.
+
+With --remote, prune the remote (remote.url in the config) instead, by its
+own retention: remote.keep keeps that many of the newest snapshots and
+remote.retention_days removes those older than that many days. Snapshots
+matching remote.pin (names or keys, globs allowed) are never removed, nor are
+those a channel lists: channels are pruned by their own retention (see
+'dataclean mirror'), and pinned or listed snapshots don't count towards keep.
+The space reclaimed on the remote is reported.
+
+Examples:
+  dataclean prune
+  dataclean prune --remote --dry-run
+  dataclean prune --remote`,
+	Args: cobra.NoArgs,
+	RunE: runPrune,
+}
+
+func init() {
+	rootCmd.AddCommand(pruneCmd)
+	withSummary(pruneCmd)
+
+	pruneCmd.Flags().BoolVar(&pruneRemote, "remote", false, "prune the remote by remote.keep and remote.retention_days")
+}
+
+func runPrune(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := guardReadOnly(cfg); err != nil {
+		return err
+	}
+	if pruneRemote {
+		return pruneRemoteSnapshots(cfg)
+	}
+
+	mgr := snapshot.NewManager(nil, cfg)
+	plan, err := mgr.PlanPrune()
+	if err != nil {
+		return fmt.Errorf("failed to apply retention: %w", err)
+	}
+	if dryRun {
+		return printPlan(plan)
+	}
+
+	before := snapshotsBefore(cfg, mgr)
+	deleted, err := mgr.CleanupOldSnapshots()
+	if err != nil {
+		return fmt.Errorf("failed to apply retention: %w", err)
+	}
+	reportPruned(cfg, before, deleted)
+	var reclaimed int64
+	for _, a := range plan.Actions {
+		reclaimed += a.SizeBytes
+	}
+	summarize("pruned", len(deleted))
+	summarize("reclaimed_bytes", reclaimed)
+
+	if !quiet {
+		if len(deleted) == 0 {
+			fmt.Println("No snapshots to prune.")
+			return nil
+		}
+		color.Green("✅ Pruned %d snapshot(s), reclaiming up to %s: %v", len(deleted), models.FormatSize(reclaimed), deleted)
+	}
+	return nil
+}
+
+// pruneRemoteSnapshots removes the bundles past the remote's retention
+func pruneRemoteSnapshots(cfg *models.Config) error {
+	policy, err := config.Remote(cfg)
+	if err != nil {
+		return err
+	}
+	if policy.Keep == 0 && policy.RetentionDays == 0 {
+		return fmt.Errorf("no remote retention configured (set remote.keep or remote.retention_days in the config)")
+	}
+	r, err := remote.Open(policy.URL)
+	if err != nil {
+		return err
+	}
+	maxAge := time.Duration(policy.RetentionDays) * 24 * time.Hour
+	now := time.Now()
+	prunable, err := r.Prunable(policy.Keep, maxAge, func(b remote.Bundle) bool { return policy.Pinned(b.Name, b.Key) }, now)
+	if err != nil {
+		return fmt.Errorf("failed to list the remote: %w", err)
+	}
+
+	if dryRun {
+		plan := &models.Plan{Operation: "prune --remote"}
+		for _, b := range prunable {
+			note := fmt.Sprintf("beyond the newest %d", policy.Keep)
+			if maxAge > 0 && now.Sub(b.Created) > maxAge {
+				note = fmt.Sprintf("older than remote retention (%d days)", policy.RetentionDays)
+			}
+			plan.Add(models.PlanAction{Kind: models.ActionDeleteSnapshot, Target: b.Key, SizeBytes: b.SizeBytes, Note: note})
+		}
+		return printPlan(plan)
+	}
+
+	var removed []string
+	var reclaimed int64
+	for _, b := range prunable {
+		if err := r.Remove(b.Key); err != nil {
+			summarize("pruned", len(removed))
+			summarize("reclaimed_bytes", reclaimed)
+			return fmt.Errorf("failed to remove %s from the remote: %w", b.Key, err)
+		}
+		removed = append(removed, b.Key)
+		reclaimed += b.SizeBytes
+	}
+	summarize("pruned", len(removed))
+	summarize("reclaimed_bytes", reclaimed)
+
+	if !quiet {
+		if len(removed) == 0 {
+			fmt.Println("No remote snapshots to prune.")
+			return nil
+		}
+		color.Green("✅ Pruned %d snapshot(s) from %s, reclaiming %s", len(removed), policy.URL, models.FormatSize(reclaimed))
+		for _, key := range removed {
+			fmt.Printf("   Removed %s\n", key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/stackgen-cli/dataclean/internal/config"
+	"github.com/stackgen-cli/dataclean/internal/remote"
+	"github.com/stackgen-cli/dataclean/internal/snapshot"
+)
+
+var (
+	pullAs      string
+	pullChannel string
+)
+
+var pullCmd = &cobra.Command{
+	Use:   "pull <token> | --channel <name>",
+	Short: "Fetch a shared snapshot from the remote",
+	Long: `Fetch a snapshot from the team remote (remote.url in the config) and save it
+locally: either the one a teammate shared with 'dataclean share', or the
+latest one published to a channel with 'dataclean push --channel'.
+
+The snapshot keeps its original name unless --as is given. Every archive is
+checked against its recorded checksum before the snapshot is kept. Pulling a
+channel again when nothing new was published does nothing.
+
+Examples:
+  dataclean pull dc1.c2VlZGVk...
+  dataclean pull dc1.c2VlZGVk... --as from-alex
+  dataclean pull --channel nightly
+  dataclean restore from-alex`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runPull,
+}
+
+func init() {
+	rootCmd.AddCommand(pullCmd)
+	withSummary(pullCmd)
+
+	pullCmd.Flags().StringVar(&pullAs, "as", "", "save the snapshot under this name")
+	pullCmd.Flags().StringVar(&pullChannel, "channel", "", "fetch the latest snapshot published to this channel")
+}
+
+func runPull(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := guardReadOnly(cfg); err != nil {
+		return err
+	}
+	r, err := openRemote(cfg)
+	if err != nil {
+		return err
+	}
+
+	var key string
+	switch {
+	case pullChannel != "" && len(args) > 0:
+		return fmt.Errorf("give a token or --channel, not both")
+	case pullChannel != "":
+		if key, err = r.Latest(pullChannel); err != nil {
+			return err
+		}
+		summarize("channel", pullChannel)
+	case len(args) > 0:
+		if key, err = r.Redeem(args[0], cfg.Remote.ShareKey); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("give a share token or --channel")
+	}
+	name := pullAs
+	if name == "" {
+		name, _, _ = strings.Cut(key, "@")
+	}
+	summarize("key", key)
+	summarize("name", name)
+
+	mgr := snapshot.NewManager(nil, cfg)
+	if existing, err := mgr.Get(name); err == nil && remote.Key(existing) == key {
+		summarize("up_to_date", true)
+		if !quiet {
+			color.Green("✅ Already have %s as snapshot '%s'", key, name)
+		}
+		return nil
+	}
+
+	if dryRun {
+		dryRunNote("would fetch %s as snapshot '%s'", key, name)
+		return nil
+	}
+	return pullSnapshot(mgr, r, key, name)
+}
+
+// pullSnapshot downloads a bundle from the remote and saves it as the named snapshot
+func pullSnapshot(mgr *snapshot.Manager, r *remote.Remote, key, name string) error {
+	if !quiet {
+		fmt.Printf("Fetching %s...\n", key)
+	}
+	bundle, err := r.Download(key)
+	if err != nil {
+		return err
+	}
+	defer bundle.Close()
+
+	snap, err := mgr.Unbundle(bundle, name)
+	if err != nil {
+		return fmt.Errorf("failed to fetch snapshot: %w", err)
+	}
+	if err := mgr.UpdateMetadata(name, map[string]string{remote.KeyMetadata: key}); err != nil {
+		return err
+	}
+	summarize("size_bytes", snap.SizeBytes)
+
+	if !quiet {
+		color.Green("✅ Fetched snapshot: %s (%s)", snap.Name, snap.SizeHuman)
+		fmt.Printf("   Restore it with: dataclean restore %s\n", snap.Name)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/stackgen-cli/dataclean/internal/config"
+	"github.com/stackgen-cli/dataclean/internal/remote"
+	"github.com/stackgen-cli/dataclean/internal/snapshot"
+)
+
+var pushChannels []string
+
+var pushCmd = &cobra.Command{
+	Use:   "push <snapshot>",
+	Short: "Upload a snapshot to the remote and publish it to channels",
+	Long: `Upload a snapshot to the team remote (remote.url in the config) unless it is
+already there, and make it the latest snapshot of each --channel.
+
+Channels are named baselines such as nightly, stable, or sprint-42. CI
+publishes to them; everyone else runs 'dataclean pull --channel <name>' and
+never needs to know exact snapshot names.
+
+Examples:
+  dataclean push nightly-2024-05-07 --channel nightly
+  dataclean push release-seed --channel stable --channel sprint-42`,
+	Args: cobra.ExactArgs(1),
+	RunE: runPush,
+}
+
+func init() {
+	rootCmd.AddCommand(pushCmd)
+	withSummary(pushCmd)
+
+	pushCmd.Flags().StringArrayVar(&pushChannels, "channel", nil, "publish to this channel (repeatable)")
+}
+
+func runPush(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	r, err := openRemote(cfg)
+	if err != nil {
+		return err
+	}
+
+	mgr := snapshot.NewManager(nil, cfg)
+	snap, err := mgr.Get(args[0])
+	if err != nil {
+		return fmt.Errorf("snapshot '%s' not found", args[0])
+	}
+	key := remote.Key(snap)
+	summarize("key", key)
+
+	if dryRun {
+		if !r.Has(key) {
+			dryRunNote("would upload snapshot '%s' (%s) to %s", snap.Name, snap.SizeHuman, cfg.Remote.URL)
+		}
+		for _, channel := range pushChannels {
+			dryRunNote("would publish it to channel %s", channel)
+		}
+		return nil
+	}
+
+	uploaded, err := uploadSnapshot(mgr, r, snap)
+	if err != nil {
+		return err
+	}
+	summarize("uploaded", uploaded)
+
+	for _, channel := range pushChannels {
+		if err := r.Publish(channel, key); err != nil {
+			return fmt.Errorf("failed to publish to channel %s: %w", channel, err)
+		}
+	}
+	summarize("channels", len(pushChannels))
+
+	if !quiet {
+		if uploaded {
+			color.Green("✅ Pushed snapshot '%s'", snap.Name)
+		} else {
+			color.Green("✅ Snapshot '%s' is already on the remote", snap.Name)
+		}
+		for _, channel := range pushChannels {
+			fmt.Printf("   Published to %s (dataclean pull --channel %s)\n", channel, channel)
+		}
+	}
+	return nil
+}
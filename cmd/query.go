@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/stackgen-cli/dataclean/internal/config"
+	"github.com/stackgen-cli/dataclean/internal/datastore"
+	"github.com/stackgen-cli/dataclean/internal/docker"
+	"github.com/stackgen-cli/dataclean/internal/snapshot"
+)
+
+var (
+	querySQL   string
+	queryImage string
+)
+
+var queryCmd = &cobra.Command{
+	Use:   "query <snapshot> [volume] --sql <query>",
+	Short: "Run one query against a snapshot's data and print the result",
+	Long: `Run a single query against a snapshot without restoring it: the volume's data
+is unpacked into a scratch volume, the datastore's image is started on it, the
+query runs through the datastore's client, and the container and scratch volume
+are removed again. Handy for checking a snapshot before sharing it.
+
+--sql takes what the datastore's client does: SQL for Postgres, MySQL, and
+ClickHouse, a command for Redis (as typed into redis-cli) and MongoDB (a
+mongosh expression). For anything more, use 'dataclean shell'.
+
+The volume argument is required when the snapshot holds more than one volume.
+
+Examples:
+  dataclean query seeded --sql "select count(*) from users"
+  dataclean query before-migration pgdata --sql "select max(id) from orders" --image postgres:15
+  dataclean query cache-warm --sql "dbsize"`,
+	Args:         cobra.RangeArgs(1, 2),
+	SilenceUsage: true,
+	RunE:         runQuery,
+}
+
+func init() {
+	rootCmd.AddCommand(queryCmd)
+
+	queryCmd.Flags().StringVar(&querySQL, "sql", "", "query to run (required)")
+	queryCmd.Flags().StringVar(&queryImage, "image", "", "image to run (default: the volume's service image)")
+	queryCmd.MarkFlagRequired("sql")
+}
+
+func runQuery(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	client, err := docker.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to connect to Docker: %w", err)
+	}
+	defer client.Close()
+
+	mgr := snapshot.NewManager(client, cfg)
+	snap, err := mgr.Get(name)
+	if err != nil {
+		return fmt.Errorf("snapshot not found: %s", name)
+	}
+	volume, err := chooseVolume(snap, args[1:])
+	if err != nil {
+		return err
+	}
+	vol, err := snapshot.FindVolume(snap, volume)
+	if err != nil {
+		return err
+	}
+	if datastore.For(vol.DatastoreType).Query == "" {
+		return fmt.Errorf("queries aren't supported for %s volumes; use 'dataclean shell %s %s' instead", vol.DatastoreType, name, volume)
+	}
+
+	if dryRun {
+		dryRunNote("would run the query against a copy of %s/%s", name, volume)
+		return nil
+	}
+	if !quiet && !jsonOutput {
+		color.New(color.FgCyan).Fprintf(os.Stderr, "🔎 Starting %s/%s in a temporary container...\n", name, volume)
+	}
+
+	// Ctrl+C still removes the container and its scratch volume
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	output, err := mgr.Query(ctx, name, volume, querySQL, queryImage)
+	if err != nil {
+		return fmt.Errorf("query failed: %w", err)
+	}
+
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(map[string]string{"snapshot": name, "volume": vol.Name, "query": querySQL, "output": output})
+	}
+	fmt.Print(output)
+	return nil
+}
@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"time"
+
+	"github.com/stackgen-cli/dataclean/internal/config"
+	"github.com/stackgen-cli/dataclean/internal/events"
+	"github.com/stackgen-cli/dataclean/internal/metrics"
+	"github.com/stackgen-cli/dataclean/internal/models"
+	"github.com/stackgen-cli/dataclean/internal/notify"
+	"github.com/stackgen-cli/dataclean/internal/snapshot"
+)
+
+// snapshotActions maps snapshot events to the actions snapshot webhooks subscribe to
+var snapshotActions = map[events.Kind]string{
+	events.SnapshotCreated:  models.SnapshotCreated,
+	events.SnapshotRestored: models.SnapshotRestored,
+	events.SnapshotDeleted:  models.SnapshotDeleted,
+	events.SnapshotPruned:   models.SnapshotPruned,
+}
+
+// The integrations configured in a project's config subscribe to the event bus
+func init() {
+	events.Subscribe("notifications", notifyOperation, events.OperationFinished)
+	events.Subscribe("metrics", recordMetrics, events.OperationFinished)
+	events.Subscribe("snapshot webhooks", notifySnapshotChanged,
+		events.SnapshotCreated, events.SnapshotRestored, events.SnapshotDeleted, events.SnapshotPruned)
+	events.Subscribe("event log", logEvent)
+	events.Default.OnError(func(_ string, err error) {
+		if quiet {
+			return
+		}
+		errs := []error{err}
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			errs = joined.Unwrap()
+		}
+		for _, err := range errs {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
+	})
+}
+
+// reportCompletion announces a finished operation, for notifications and metrics
+func reportCompletion(cfg *models.Config, operation, target string, start time.Time, bytes int64, opErr error) {
+	events.Publish(events.Event{
+		Kind:      events.OperationFinished,
+		Config:    cfg,
+		Operation: operation,
+		Target:    target,
+		Duration:  time.Since(start),
+		Bytes:     bytes,
+		Err:       opErr,
+	})
+}
+
+// reportSnapshotEvent announces a snapshot being created, restored, deleted,
+// or pruned (one of models.SnapshotActions), for snapshot webhooks
+func reportSnapshotEvent(cfg *models.Config, action string, snap *models.Snapshot) {
+	if snap == nil {
+		return
+	}
+	for kind, a := range snapshotActions {
+		if a == action {
+			events.Publish(events.Event{Kind: kind, Config: cfg, Target: snap.Name, Snapshot: snap})
+		}
+	}
+}
+
+func notifyOperation(e events.Event) error {
+	settings, err := config.Notifications(e.Config)
+	if err != nil {
+		return err
+	}
+	return errors.Join(notify.New(settings).Notify(notify.Event{
+		Operation: e.Operation,
+		Target:    e.Target,
+		Project:   projectName(),
+		Duration:  e.Duration,
+		Err:       e.Err,
+	})...)
+}
+
+func recordMetrics(e events.Event) error {
+	if e.Config.Metrics.Textfile == "" {
+		return nil
+	}
+	err := metrics.WriteTextfile(e.Config.Metrics.Textfile, metrics.Record{
+		Project:   projectName(),
+		Operation: e.Operation,
+		Duration:  e.Duration,
+		Bytes:     e.Bytes,
+		Err:       e.Err,
+	})
+	if err != nil {
+		return fmt.Errorf("metrics textfile: %w", err)
+	}
+	return nil
+}
+
+func notifySnapshotChanged(e events.Event) error {
+	if len(e.Config.Notifications.SnapshotWebhooks) == 0 {
+		return nil
+	}
+	settings, err := config.Notifications(e.Config)
+	if err != nil {
+		return err
+	}
+	host, _ := os.Hostname()
+	return errors.Join(notify.New(settings).SnapshotChanged(notify.SnapshotEvent{
+		Action:   snapshotActions[e.Kind],
+		Project:  projectName(),
+		User:     userName(),
+		Host:     host,
+		Time:     e.Time,
+		Snapshot: *e.Snapshot,
+	})...)
+}
+
+func logEvent(e events.Event) error {
+	if e.Config.EventLog == "" {
+		return nil
+	}
+	return events.AppendLog(e.Config.EventLog, projectName(), e)
+}
+
+// snapshotsBefore indexes the snapshots ahead of an operation that may
+// delete some, so webhooks can still describe them afterwards. It skips the
+// listing when there are no snapshot webhooks.
+func snapshotsBefore(cfg *models.Config, mgr *snapshot.Manager) map[string]models.Snapshot {
+	if len(cfg.Notifications.SnapshotWebhooks) == 0 {
+		return nil
+	}
+	snapshots, err := mgr.List()
+	if err != nil {
+		return nil
+	}
+	index := make(map[string]models.Snapshot, len(snapshots))
+	for _, s := range snapshots {
+		index[s.Name] = s
+	}
+	return index
+}
+
+// reportPruned sends a prune event for each deleted snapshot
+func reportPruned(cfg *models.Config, before map[string]models.Snapshot, deleted []string) {
+	for _, name := range deleted {
+		snap, ok := before[name]
+		if !ok {
+			snap = models.Snapshot{Name: name}
+		}
+		reportSnapshotEvent(cfg, models.SnapshotPruned, &snap)
+	}
+}
+
+// userName returns who is running dataclean, for team-facing events
+func userName() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// projectName returns the compose project name (COMPOSE_PROJECT_NAME or the
+// current directory name)
+func projectName() string {
+	if name := os.Getenv("COMPOSE_PROJECT_NAME"); name != "" {
+		return name
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	return filepath.Base(cwd)
+}
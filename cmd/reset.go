@@ -14,6 +14,12 @@ import (
 	"github.com/stackgen-cli/dataclean/internal/snapshot"
 )
 
+var (
+	resetInclude  []string
+	resetExclude  []string
+	resetServices []string
+)
+
 var resetCmd = &cobra.Command{
 	Use:   "reset",
 	Short: "Wipe all data volumes to empty state",
@@ -24,15 +30,28 @@ Requires --force flag or interactive confirmation.
 
 A backup of current state is automatically created before reset.
 
+Volumes protected by a policy in the config are refused even with --force;
+name each one with --i-know-what-im-doing=<volume> to reset it anyway.
+When the config records a Docker daemon (see 'dataclean init'), resetting
+against any other daemon is refused unless --any-daemon is given.
+
 Examples:
   dataclean reset          # interactive confirmation
   dataclean reset --force  # skip confirmation
-  dataclean reset --dry-run`,
+  dataclean reset --dry-run
+  dataclean reset --include redisdata  # only this volume
+  dataclean reset --exclude 're:^keep_'
+  dataclean reset --service cache      # only the volumes of this service`,
 	RunE: runReset,
 }
 
 func init() {
 	rootCmd.AddCommand(resetCmd)
+	withSummary(resetCmd)
+	withSafetyOverrides(resetCmd)
+	resetCmd.Flags().StringSliceVar(&resetInclude, "include", nil, "Only reset these volumes (names, globs, or re:<regexp>)")
+	resetCmd.Flags().StringSliceVar(&resetExclude, "exclude", nil, "Leave these volumes alone (names, globs, or re:<regexp>)")
+	resetCmd.Flags().StringSliceVar(&resetServices, "service", nil, "Only reset the volumes of these compose services")
 }
 
 func runReset(cmd *cobra.Command, args []string) error {
@@ -41,6 +60,12 @@ func runReset(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
+	if err := guardReadOnly(cfg); err != nil {
+		return err
+	}
+	if err := applyVolumeFilters(cfg, resetInclude, resetExclude, resetServices); err != nil {
+		return err
+	}
 
 	// Connect to Docker
 	client, err := docker.NewClient()
@@ -56,12 +81,19 @@ func runReset(cmd *cobra.Command, args []string) error {
 	}
 
 	if len(volumes) == 0 {
-		color.Yellow("⚠️  No Docker Compose volumes detected in current directory")
+		warn("⚠️  No Docker Compose volumes detected in current directory")
+		summarize("volumes", 0)
 		return nil
 	}
+	if err := guardDaemon(cfg, client); err != nil {
+		return err
+	}
+	if err := guardProtected(cfg, volumes); err != nil {
+		return err
+	}
 
 	// Show what will be reset
-	if !quiet {
+	if !quiet && !jsonOutput {
 		color.Red("🗑️  RESET will DELETE all data in the following volumes:")
 		fmt.Println()
 		for _, v := range volumes {
@@ -78,24 +110,29 @@ func runReset(cmd *cobra.Command, args []string) error {
 		response, _ := reader.ReadString('\n')
 		response = strings.TrimSpace(strings.ToLower(response))
 		if response != "yes" {
-			color.Yellow("Aborted.")
+			warn("Aborted.")
+			summarize("aborted", true)
 			return nil
 		}
 	}
 
+	mgr := snapshot.NewManager(client, cfg)
+
 	// Dry run stops here
 	if dryRun {
-		color.Yellow("🔍 Dry run - no changes made")
-		return nil
+		plan, err := mgr.PlanReset(volumes)
+		if err != nil {
+			return fmt.Errorf("failed to plan reset: %w", err)
+		}
+		return printPlan(plan)
 	}
 
 	// Create backup before reset
-	if !quiet {
+	if !quiet && cfg.BackupBeforeRestore {
 		color.Cyan("📦 Creating backup of current state...")
 	}
 
 	// Perform reset
-	mgr := snapshot.NewManager(client, cfg)
 
 	if !quiet {
 		color.Cyan("🗑️  Resetting volumes...")
@@ -105,6 +142,7 @@ func runReset(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to reset volumes: %w", err)
 	}
+	summarize("volumes", len(volumes))
 
 	if !quiet {
 		color.Green("✅ All volumes reset to empty state")
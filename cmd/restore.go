@@ -2,20 +2,41 @@ package cmd
 
 import (
 	"bufio"
+	"context"
+	"encoding/json"
 	"fmt"
 	"os"
+	"os/signal"
+	"slices"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 
 	"github.com/stackgen-cli/dataclean/internal/config"
 	"github.com/stackgen-cli/dataclean/internal/docker"
+	"github.com/stackgen-cli/dataclean/internal/models"
 	"github.com/stackgen-cli/dataclean/internal/snapshot"
 )
 
+var (
+	restoreWithImages   bool
+	restoreTo           string
+	restoreLeaveStopped bool
+	restoreFrom         string
+	restoreInclude      []string
+	restoreExclude      []string
+	restoreServices     []string
+
+	// lastRestore is the result of the last restore runRestore ran, for
+	// commands that restore through it
+	lastRestore *models.RestoreResult
+)
+
 var restoreCmd = &cobra.Command{
-	Use:   "restore <name>",
+	Use:   "restore [name]",
 	Short: "Restore a previously saved snapshot",
 	Long: `Restore data volumes from a named snapshot.
 
@@ -24,26 +45,121 @@ Requires --force flag or interactive confirmation.
 
 A backup of current state is automatically created before restore.
 
+Volumes protected by a policy in the config are refused even with --force;
+name each one with --i-know-what-im-doing=<volume> to restore over it anyway.
+When the config records a Docker daemon (see 'dataclean init'), restoring
+against any other daemon is refused unless --any-daemon is given.
+
+With --with-images, the exact images recorded in the snapshot are pulled and
+re-tagged and the services recreated, so the data runs on the server version
+that wrote it.
+
+With --to, Postgres volumes are rolled forward to a point in time by replaying
+archived WAL, and Redis volumes with aof_capture load the newest AOF captured
+before it (see 'dataclean pitr'). Without a name, the newest snapshot taken
+before that time is restored first. Other volumes in that snapshot are restored
+as they were when it was taken.
+
+Before asking for confirmation (and with --dry-run), each volume's current
+data is compared with the snapshot: both sizes, whether it changed since it was
+last snapshotted or restored, and, when the snapshot has table counts
+(--tables) or a logical dump (--logical), the tables whose row counts differ
+from the running database's.
+
+Containers that were already stopped before the restore stay stopped. With
+--leave-stopped, the containers stopped for the restore aren't started again
+either, so a migration or seed step can run first; health checks, restore
+hooks, and validation queries are skipped.
+
+When an import fails, or the restore is interrupted with Ctrl+C, no further
+volumes are imported and the ones already cleared or imported are put back
+from the pre-restore backup, so the project is never left with part of the
+snapshot next to old data. The volumes rolled back are listed.
+
+With --from, a snapshot on the team remote (a share token or its key, see
+'dataclean push') is restored straight from the remote without being saved
+locally first, so the local disk needs no room for it. Each archive is checked
+against its recorded checksum as it is imported rather than before anything is
+touched, so a damaged archive fails the restore with its volume cleared (the
+pre-restore backup is still taken). Volumes are imported one at a time.
+
+Only the snapshot's volumes that include_volumes and exclude_volumes in the
+config let through are restored; --include replaces include_volumes for this
+restore and --exclude adds to exclude_volumes. Each takes names, globs
+(*_cache), or regular expressions (re:^tmp_), matched against the volume's
+Docker, compose, and alias names. --service (or include_services) restores
+only the volumes the named compose services mount.
+
+With --stack (repeatable) or --all-stacks, the snapshot of that name is
+restored in each stack listed under stacks in the config, after one
+confirmation for all of them. --all-stacks picks the stacks a snapshot taken
+with 'snapshot --all-stacks' or --stack covered, when there is one.
+
 Examples:
   dataclean restore before-migration          # interactive confirmation
   dataclean restore before-migration --force  # skip confirmation
-  dataclean restore before-migration --dry-run`,
-	Args: cobra.ExactArgs(1),
+  dataclean restore before-migration --dry-run
+  dataclean restore before-upgrade --with-images
+  dataclean restore --to "2024-05-01 14:30"
+  dataclean restore nightly --to "2024-05-01 14:30:15"
+  dataclean restore --to now                  # latest snapshot plus everything since
+  dataclean restore seeded --leave-stopped    # start services yourself afterwards
+  dataclean restore seeded --include 'pg*'    # only the Postgres volumes
+  dataclean restore seeded --service db       # only the db service's volumes
+  dataclean restore --from dc1.c2VlZGVk...    # stream from the remote
+  dataclean restore release-1 --all-stacks`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: runRestore,
 }
 
 func init() {
 	rootCmd.AddCommand(restoreCmd)
+	withSummary(restoreCmd)
+
+	restoreCmd.Flags().BoolVar(&restoreWithImages, "with-images", false, "Also restore the image versions recorded in the snapshot")
+	withSafetyOverrides(restoreCmd)
+	restoreCmd.Flags().BoolVar(&restoreLeaveStopped, "leave-stopped", false, "Don't start the containers stopped for the restore again")
+	restoreCmd.Flags().StringVar(&restoreFrom, "from", "", "Restore a snapshot on the remote (share token or key) without saving it locally")
+	withStackFlags(restoreCmd)
+	restoreCmd.Flags().StringSliceVar(&restoreInclude, "include", nil, "Only restore these volumes of the snapshot (names, globs, or re:<regexp>)")
+	restoreCmd.Flags().StringSliceVar(&restoreExclude, "exclude", nil, "Leave these volumes alone (names, globs, or re:<regexp>)")
+	restoreCmd.Flags().StringSliceVar(&restoreServices, "service", nil, "Only restore the volumes of these compose services")
+	restoreCmd.Flags().StringVar(&restoreTo, "to", "", "Roll Postgres WAL and Redis AOF forward to this local time (\"2006-01-02 15:04[:05]\", RFC 3339, or now)")
 }
 
 func runRestore(cmd *cobra.Command, args []string) error {
-	name := args[0]
+	if stacksSelected() {
+		if len(args) == 0 || restoreFrom != "" || restoreTo != "" {
+			return fmt.Errorf("restoring across stacks needs a snapshot name, and can't be combined with --from or --to")
+		}
+		return restoreStacks(cmd, args[0])
+	}
+	var target time.Time
+	if restoreFrom != "" {
+		if len(args) > 0 || restoreTo != "" {
+			return fmt.Errorf("--from can't be combined with a snapshot name or --to")
+		}
+	} else if restoreTo != "" {
+		t, err := parseRecoveryTime(restoreTo)
+		if err != nil {
+			return err
+		}
+		target = t
+	} else if len(args) == 0 {
+		return fmt.Errorf("a snapshot name is required unless --to is given")
+	}
 
 	// Load config
 	cfg, err := config.Load(cfgFile)
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
+	if err := guardReadOnly(cfg); err != nil {
+		return err
+	}
+	if err := applyVolumeFilters(cfg, restoreInclude, restoreExclude, restoreServices); err != nil {
+		return err
+	}
 
 	// Connect to Docker
 	client, err := docker.NewClient()
@@ -54,21 +170,67 @@ func runRestore(cmd *cobra.Command, args []string) error {
 
 	// Check snapshot exists
 	mgr := snapshot.NewManager(client, cfg)
+	if restoreFrom != "" {
+		return restoreFromRemote(cfg, client, mgr)
+	}
+	var name string
+	if len(args) > 0 {
+		name = args[0]
+	} else if name, err = mgr.PITRBase(target); err != nil {
+		return err
+	}
 	snap, err := mgr.Get(name)
 	if err != nil {
 		return fmt.Errorf("snapshot not found: %s", name)
 	}
+	// Only what the restore will touch is shown and guarded
+	selected := cfg.SelectVolumes(snap.Volumes)
+	if len(selected) == 0 && len(snap.Volumes) > 0 {
+		return fmt.Errorf("no volume of snapshot %s is included (see --include, --exclude, and --service)", name)
+	}
+	for _, service := range cfg.IncludeServices {
+		if !slices.ContainsFunc(snap.Volumes, func(v models.Volume) bool { return v.Service == service || slices.Contains(v.SharedWith, service) }) {
+			return fmt.Errorf("snapshot %s has no volume of service '%s'", name, service)
+		}
+	}
+	snap.Volumes = selected
+	if restoreWithImages && !slices.ContainsFunc(snap.Volumes, func(v models.Volume) bool { return v.ImageName != "" && v.ImageDigest != "" }) {
+		return fmt.Errorf("snapshot %s has no recorded image digests, so --with-images has nothing to restore", name)
+	}
+	if err := guardDaemon(cfg, client); err != nil {
+		return err
+	}
+	if err := guardProtected(cfg, snap.Volumes); err != nil {
+		return err
+	}
 
 	// Show what will be restored
-	if !quiet {
+	if !quiet && !jsonOutput {
 		color.Yellow("⚠️  RESTORE will replace current data with snapshot: %s", name)
 		fmt.Println()
 		fmt.Printf("   Created: %s\n", snap.Timestamp.Format("2006-01-02 15:04:05"))
 		fmt.Printf("   Size: %s\n", snap.SizeHuman)
 		fmt.Printf("   Volumes: %d\n", len(snap.Volumes))
+		if !target.IsZero() {
+			fmt.Printf("   Postgres rolled forward to: %s\n", target.Format("2006-01-02 15:04:05"))
+		}
 		fmt.Println()
-		for _, v := range snap.Volumes {
-			fmt.Printf("  • %s (%s)\n", v.Name, v.DatastoreType)
+		// Scripted restores skip the preview: counting rows can take a while
+		var previews []models.RestorePreview
+		if !force || dryRun {
+			if previews, err = mgr.PreviewRestore(snap); err != nil {
+				warn("⚠️  Could not compare with the current data: %v", err)
+			}
+		}
+		if previews != nil {
+			printRestorePreview(previews)
+			if cfg.BackupBeforeRestore {
+				fmt.Println("   The current data is backed up first (backup_before_restore).")
+			}
+		} else {
+			for _, v := range snap.Volumes {
+				fmt.Printf("  • %s (%s)\n", v.Name, v.DatastoreType)
+			}
 		}
 		fmt.Println()
 	}
@@ -81,35 +243,298 @@ func runRestore(cmd *cobra.Command, args []string) error {
 		response, _ := reader.ReadString('\n')
 		response = strings.TrimSpace(strings.ToLower(response))
 		if response != "yes" {
-			color.Yellow("Aborted.")
+			warn("Aborted.")
+			summarize("aborted", true)
 			return nil
 		}
 	}
 
 	// Dry run stops here
 	if dryRun {
-		color.Yellow("🔍 Dry run - no changes made")
-		return nil
+		plan, err := mgr.PlanRestoreWithOptions(name, snapshot.RestoreOptions{LeaveStopped: restoreLeaveStopped})
+		if err != nil {
+			return fmt.Errorf("failed to plan restore: %w", err)
+		}
+		return printPlan(plan)
 	}
 
 	// Create backup before restore
-	if !quiet {
+	if !quiet && !jsonOutput && cfg.BackupBeforeRestore {
 		color.Cyan("📦 Creating backup of current state...")
 	}
 
 	// Perform restore
-	if !quiet {
+	if !quiet && !jsonOutput {
 		color.Cyan("🔄 Restoring snapshot...")
 	}
 
-	err = mgr.Restore(name)
+	// Ctrl+C stops importing and rolls back the volumes already touched
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	start := time.Now()
+	result, err := mgr.RestoreWithOptions(name, snapshot.RestoreOptions{WithImages: restoreWithImages, RecoverTo: target, LeaveStopped: restoreLeaveStopped, Context: ctx})
+	reportCompletion(cfg, "restore", name, start, snap.SizeBytes, err)
+	summarizeRestore(result)
+	lastRestore = result
+
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if encErr := enc.Encode(result); encErr != nil {
+			return encErr
+		}
+	} else if !quiet {
+		printRestoreResult(result)
+	}
+
 	if err != nil {
 		return fmt.Errorf("failed to restore snapshot: %w", err)
 	}
+	reportSnapshotEvent(cfg, models.SnapshotRestored, snap)
 
-	if !quiet {
+	if !quiet && !jsonOutput {
 		color.Green("✅ Restored snapshot: %s", name)
 	}
 
 	return nil
 }
+
+// restoreFromRemote restores a snapshot read straight from the remote
+func restoreFromRemote(cfg *models.Config, client *docker.Client, mgr *snapshot.Manager) error {
+	r, err := openRemote(cfg)
+	if err != nil {
+		return err
+	}
+	key, err := r.Resolve(restoreFrom, cfg.Remote.ShareKey)
+	if err != nil {
+		return err
+	}
+	bundle, err := r.Download(key)
+	if err != nil {
+		return err
+	}
+	defer bundle.Close()
+	b, err := snapshot.ReadBundle(bundle)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	snap := b.Snapshot
+	if err := guardDaemon(cfg, client); err != nil {
+		return err
+	}
+	if err := guardProtected(cfg, snap.Volumes); err != nil {
+		return err
+	}
+
+	if !quiet && !jsonOutput {
+		color.Yellow("⚠️  RESTORE will replace current data with remote snapshot: %s", key)
+		fmt.Println()
+		fmt.Printf("   Created: %s\n", snap.Timestamp.Format("2006-01-02 15:04:05"))
+		fmt.Printf("   Size: %s\n", snap.SizeHuman)
+		fmt.Printf("   Volumes: %d\n", len(snap.Volumes))
+		fmt.Println()
+		for _, v := range snap.Volumes {
+			fmt.Printf("  • %s (%s)\n", v.Name, v.DatastoreType)
+		}
+		if cfg.BackupBeforeRestore {
+			fmt.Println("   The current data is backed up first (backup_before_restore).")
+		}
+		fmt.Println()
+	}
+
+	if !force && !dryRun {
+		color.Red("⚠️  This will DELETE existing data and replace with snapshot!")
+		fmt.Print("Type 'yes' to confirm: ")
+		reader := bufio.NewReader(os.Stdin)
+		response, _ := reader.ReadString('\n')
+		response = strings.TrimSpace(strings.ToLower(response))
+		if response != "yes" {
+			warn("Aborted.")
+			summarize("aborted", true)
+			return nil
+		}
+	}
+
+	if dryRun {
+		for _, v := range snap.Volumes {
+			dryRunNote("would clear %s and import its archive from %s", v.Name, key)
+		}
+		return nil
+	}
+
+	if !quiet && !jsonOutput {
+		if cfg.BackupBeforeRestore {
+			color.Cyan("📦 Creating backup of current state...")
+		}
+		color.Cyan("🔄 Restoring snapshot from the remote...")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	start := time.Now()
+	result, err := mgr.RestoreBundle(key, b, snapshot.RestoreOptions{WithImages: restoreWithImages, LeaveStopped: restoreLeaveStopped, Context: ctx})
+	reportCompletion(cfg, "restore", key, start, snap.SizeBytes, err)
+	summarizeRestore(result)
+
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if encErr := enc.Encode(result); encErr != nil {
+			return encErr
+		}
+	} else if !quiet {
+		printRestoreResult(result)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to restore snapshot: %w", err)
+	}
+	reportSnapshotEvent(cfg, models.SnapshotRestored, snap)
+
+	if !quiet && !jsonOutput {
+		color.Green("✅ Restored snapshot: %s", key)
+	}
+	return nil
+}
+
+// printRestorePreview shows, per volume, what the restore would replace
+func printRestorePreview(previews []models.RestorePreview) {
+	yellow := color.New(color.FgYellow)
+	for _, p := range previews {
+		st := p.Status
+		fmt.Printf("  • %s (%s)\n", st.Volume.Name, st.Volume.DatastoreType)
+		if st.SizeHuman != "" {
+			fmt.Printf("      now %s, snapshot %s (%s)\n", st.SizeHuman, models.FormatSize(p.SnapshotBytes),
+				formatSizeDelta(p.SnapshotBytes-st.SizeBytes))
+		}
+		switch {
+		case st.LastSnapshot == "":
+			yellow.Println("      never snapshotted: its current data is in no snapshot")
+		case st.Clean != nil && !*st.Clean:
+			yellow.Printf("      changed since its last snapshot (%s, %s)\n", st.LastSnapshot, st.SnapshotTime.Format("2006-01-02 15:04"))
+		}
+		if st.Restored != nil {
+			fmt.Printf("      last restored from %s\n", formatDrift(st))
+		}
+		for _, t := range p.Tables {
+			switch {
+			case t.Dropped:
+				yellow.Printf("      %-40s %d rows, not in snapshot (dropped)\n", t.Name, t.Live)
+			case t.Restored:
+				fmt.Printf("      %-40s not present, %d rows in snapshot\n", t.Name, t.Snapshot)
+			default:
+				fmt.Printf("      %-40s %d → %d rows (%+d)\n", t.Name, t.Live, t.Snapshot, t.Snapshot-t.Live)
+			}
+		}
+	}
+}
+
+// formatSizeDelta renders a signed size change
+func formatSizeDelta(delta int64) string {
+	if delta < 0 {
+		return "-" + models.FormatSize(-delta)
+	}
+	return "+" + models.FormatSize(delta)
+}
+
+// parseRecoveryTime reads a --to time in the local zone unless it carries its own
+func parseRecoveryTime(s string) (time.Time, error) {
+	if s == "now" {
+		return time.Now(), nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02T15:04:05", "2006-01-02T15:04"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid --to time %q (expected \"2006-01-02 15:04\" or RFC 3339)", s)
+}
+
+// summarizeRestore adds a restore's outcome to the --quiet summary line
+func summarizeRestore(result *models.RestoreResult) {
+	if result == nil {
+		return
+	}
+	summarize("name", result.Snapshot)
+	if result.RecoveredTo != nil {
+		summarize("recovered_to", result.RecoveredTo.Format(time.RFC3339))
+	}
+	imported, rolledBack := 0, 0
+	for _, v := range result.Volumes {
+		switch {
+		case v.RolledBack:
+			rolledBack++
+			if v.Error != "" {
+				summarize("failed_volume", v.Volume)
+			}
+		case v.Imported:
+			imported++
+		case v.Error != "":
+			summarize("failed_volume", v.Volume)
+		}
+	}
+	summarize("volumes", imported)
+	if rolledBack > 0 {
+		summarize("rolled_back", rolledBack)
+	}
+	if len(result.ConfigDrift) > 0 {
+		summarize("config_drift", len(result.ConfigDrift))
+	}
+	if result.Backup != "" {
+		summarize("backup", result.Backup)
+	}
+}
+
+// clearNote says how a volume was readied for its import
+func clearNote(v models.VolumeResult) string {
+	switch {
+	case v.Created:
+		return "created"
+	case v.Empty:
+		return "empty, not cleared"
+	}
+	return "cleared"
+}
+
+// printRestoreResult shows what happened to each volume
+func printRestoreResult(result *models.RestoreResult) {
+	if result == nil || len(result.Volumes) == 0 {
+		return
+	}
+
+	fmt.Println()
+	for _, v := range result.Volumes {
+		switch {
+		case v.RolledBack && v.Error != "":
+			color.Yellow("  ↩ %s: import failed (%s), rolled back to %s", v.Volume, v.Error, result.Backup)
+		case v.RolledBack:
+			color.Yellow("  ↩ %s: rolled back to %s", v.Volume, result.Backup)
+		case v.Imported:
+			color.Green("  ✓ %s: %s, imported", v.Volume, clearNote(v))
+		case v.Error != "" && (v.Cleared || v.Created || v.Empty):
+			color.Red("  ✗ %s: %s, import failed: %s", v.Volume, clearNote(v), v.Error)
+		case v.Error != "":
+			color.Red("  ✗ %s: clear failed: %s", v.Volume, v.Error)
+		default:
+			fmt.Printf("  - %s: untouched\n", v.Volume)
+		}
+	}
+	if len(result.ConfigDrift) > 0 {
+		color.Yellow("\n⚠️  Settings changed since the snapshot was taken:")
+		for _, d := range result.ConfigDrift {
+			color.Yellow("  • %s", d)
+		}
+	}
+	if result.RecoveredTo != nil {
+		fmt.Printf("\n   Postgres WAL replayed to: %s\n", result.RecoveredTo.Format("2006-01-02 15:04:05"))
+	}
+	if result.Backup != "" {
+		fmt.Printf("\n   Previous state saved as: %s\n", result.Backup)
+	}
+	fmt.Println()
+}
@@ -6,14 +6,24 @@ import (
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
+
+	"github.com/stackgen-cli/dataclean/internal/docker"
+	"github.com/stackgen-cli/dataclean/internal/models"
+	"github.com/stackgen-cli/dataclean/internal/snapshot"
+	"github.com/stackgen-cli/dataclean/internal/tui"
 )
 
 var (
-	version   = "1.0.0"
-	cfgFile   string
-	dryRun    bool
-	force     bool
-	quiet     bool
+	version    = "1.0.0"
+	cfgFile    string
+	dryRun     bool
+	force      bool
+	quiet      bool
+	jsonOutput bool
+	noColor    bool
+	silent     bool
+
+	skipDockerCheck bool
 )
 
 var rootCmd = &cobra.Command{
@@ -34,12 +44,41 @@ Commands:
 `) + color.New(color.FgYellow).Sprint("For local development and testing only.") + `
 Destructive operations require --force or interactive confirmation.`,
 	Version: version,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		if silent {
+			quiet = true
+		}
+		if quiet {
+			// The summary line carries the error; don't repeat it with usage
+			cmd.SilenceUsage = true
+		}
+		if skipDockerCheck {
+			docker.SkipDaemonCheck()
+		}
+		retries := 0
+		docker.OnRetry(func(r docker.Retry) {
+			retries++
+			summarize("docker_retries", retries)
+			warn("🔁 %s", r)
+		})
+		snapshot.Version = version
+		// fatih/color already honors NO_COLOR and TERM=dumb; make the TUI and --no-color match
+		if noColor || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+			color.NoColor = true
+			tui.DisableColor()
+		}
+	},
 }
 
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	cmd, err := rootCmd.ExecuteC()
+	snapshot.EndRun()
+	if quiet && !(silent && err == nil) {
+		printSummary(os.Stdout, cmd, err)
+	}
+	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
 }
 
@@ -48,4 +87,20 @@ func init() {
 	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "preview changes without executing")
 	rootCmd.PersistentFlags().BoolVarP(&force, "force", "f", false, "skip confirmation prompts for destructive operations")
 	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "minimal output (for CI/scripts)")
+	rootCmd.PersistentFlags().BoolVar(&jsonOutput, "json", false, "machine-readable JSON output where supported")
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "disable colored output")
+	rootCmd.PersistentFlags().BoolVar(&silent, "silent", false, "like --quiet, but print nothing on success")
+	rootCmd.PersistentFlags().BoolVar(&skipDockerCheck, "skip-docker-check", false, "don't check that the Docker daemon is running (for commands that only read snapshots)")
+}
+
+// setupTUI applies the config's theme and returns its key bindings
+func setupTUI(cfg *models.Config) (tui.KeyMap, error) {
+	if err := tui.SetTheme(cfg.Theme); err != nil {
+		return tui.KeyMap{}, fmt.Errorf("invalid theme in config: %w", err)
+	}
+	keys, err := tui.NewKeyMap(cfg.Keymap)
+	if err != nil {
+		return keys, fmt.Errorf("invalid keymap in config: %w", err)
+	}
+	return keys, nil
 }
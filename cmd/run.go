@@ -0,0 +1,203 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/stackgen-cli/dataclean/internal/config"
+	"github.com/stackgen-cli/dataclean/internal/docker"
+	"github.com/stackgen-cli/dataclean/internal/models"
+	"github.com/stackgen-cli/dataclean/internal/snapshot"
+	"github.com/stackgen-cli/dataclean/internal/tui"
+)
+
+var (
+	runName             string
+	runKeep             bool
+	runRestoreOnFailure bool
+)
+
+var runCmd = &cobra.Command{
+	Use:   "run [flags] -- <command> [args...]",
+	Short: "Snapshot, run a command, and offer to restore if it fails",
+	Long: `Take a snapshot, run a risky command, and roll back if it fails.
+
+The snapshot is deleted when the command succeeds (keep it with --keep).
+When the command fails you are asked whether to restore it; --restore-on-failure
+or --force restores without asking. The command's exit code is passed through.
+
+Examples:
+  dataclean run -- ./migrate up
+  dataclean run --restore-on-failure -- go test ./e2e/...
+  dataclean run --name before-seed --keep -- make seed`,
+	Args:         cobra.MinimumNArgs(1),
+	RunE:         runRun,
+	SilenceUsage: true,
+}
+
+func init() {
+	rootCmd.AddCommand(runCmd)
+	withSummary(runCmd)
+
+	runCmd.Flags().StringVar(&runName, "name", "", "Snapshot name (default: run-<timestamp>)")
+	runCmd.Flags().BoolVar(&runKeep, "keep", false, "Keep the snapshot when the command succeeds")
+	runCmd.Flags().BoolVar(&runRestoreOnFailure, "restore-on-failure", false, "Restore without asking if the command fails")
+	withSafetyOverrides(runCmd)
+	// Everything after the command name belongs to the command, not to dataclean
+	runCmd.Flags().SetInterspersed(false)
+}
+
+func runRun(cmd *cobra.Command, args []string) error {
+	name := runName
+	if name == "" {
+		name = fmt.Sprintf("run-%s", time.Now().Format("2006-01-02-150405"))
+	}
+
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := guardReadOnly(cfg); err != nil {
+		return err
+	}
+
+	client, err := docker.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to connect to Docker: %w", err)
+	}
+	defer client.Close()
+
+	volumes, err := client.DetectComposeVolumes(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to detect volumes: %w", err)
+	}
+	if len(volumes) == 0 {
+		return fmt.Errorf("no Docker Compose volumes detected in current directory")
+	}
+
+	mgr := snapshot.NewManager(client, cfg)
+
+	if dryRun {
+		dryRunNote("would snapshot %d volume(s) as '%s', then run: %s",
+			len(volumes), name, strings.Join(args, " "))
+		return nil
+	}
+
+	ensureGitignored(cfg)
+
+	// Snapshot first; never run the command without a way back
+	if !quiet {
+		color.Cyan("📸 Creating snapshot: %s", name)
+	}
+	start := time.Now()
+	snap, err := mgr.Create(name, volumes)
+	var written int64
+	if snap != nil {
+		written = snap.SizeBytes
+	}
+	reportCompletion(cfg, "snapshot", name, start, written, err)
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot, command not run: %w", err)
+	}
+	reportSnapshotEvent(cfg, models.SnapshotCreated, snap)
+	mirrorSnapshot(os.Stdout, cfg, snap)
+
+	if !quiet {
+		color.Cyan("▶️  Running: %s", strings.Join(args, " "))
+		fmt.Println()
+	}
+	runErr := runWrapped(args)
+	summarize("name", name)
+	summarize("exit_code", 0)
+	if runErr != nil {
+		summarize("exit_code", exitCode(runErr))
+	}
+
+	if runErr == nil {
+		if !quiet {
+			fmt.Println()
+			color.Green("✅ Command succeeded")
+		}
+		if runKeep {
+			if !quiet {
+				fmt.Printf("   Snapshot kept: %s\n", name)
+			}
+			return nil
+		}
+		if err := mgr.Delete(name); err != nil {
+			return fmt.Errorf("failed to delete snapshot %s: %w", name, err)
+		}
+		reportSnapshotEvent(cfg, models.SnapshotDeleted, snap)
+		return nil
+	}
+
+	if !quiet {
+		fmt.Println()
+	}
+	color.New(color.FgRed).Fprintf(os.Stderr, "❌ Command failed: %v\n", runErr)
+
+	restore := runRestoreOnFailure || force
+	if !restore {
+		restore, err = tui.ConfirmDestructive(fmt.Sprintf("Restore snapshot '%s'?", name))
+		if err != nil {
+			return err
+		}
+	}
+	if !restore {
+		warn("   Data left as-is. Restore later with: dataclean restore %s", name)
+		return runErr
+	}
+	guardErr := guardDaemon(cfg, client)
+	if guardErr == nil {
+		guardErr = guardProtected(cfg, snap.Volumes)
+	}
+	if guardErr != nil {
+		warn("   Data left as-is.")
+		return guardErr
+	}
+
+	if !quiet {
+		color.Cyan("🔄 Restoring snapshot...")
+	}
+	start = time.Now()
+	result, err := mgr.Restore(name)
+	reportCompletion(cfg, "restore", name, start, snap.SizeBytes, err)
+	summarize("restored", err == nil)
+	if !quiet {
+		printRestoreResult(result)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to restore snapshot: %w", err)
+	}
+	reportSnapshotEvent(cfg, models.SnapshotRestored, snap)
+	if !quiet {
+		color.Green("✅ Restored snapshot: %s", name)
+	}
+
+	return runErr
+}
+
+// runWrapped runs the user's command attached to the terminal
+func runWrapped(args []string) error {
+	c := exec.Command(args[0], args[1:]...)
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	return c.Run()
+}
+
+// exitCode returns the exit code to report for err
+func exitCode(err error) int {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+		return exitErr.ExitCode()
+	}
+	return 1
+}
@@ -0,0 +1,265 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/stackgen-cli/dataclean/internal/config"
+	"github.com/stackgen-cli/dataclean/internal/docker"
+	"github.com/stackgen-cli/dataclean/internal/models"
+	"github.com/stackgen-cli/dataclean/internal/rpc"
+	"github.com/stackgen-cli/dataclean/internal/snapshot"
+)
+
+var (
+	serveLocal  bool
+	serveSocket string
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve --local",
+	Short: "Serve a local control socket for editor integrations",
+	Long: `Listen on a Unix socket for JSON-RPC 2.0 calls, so editor plugins (a VS Code
+task, a JetBrains plugin) can list, take, and restore snapshots without
+shelling out and parsing text output.
+
+Messages are one JSON object per line, and calls run one at a time. The
+socket defaults to control.sock in the snapshot directory and only the
+current user can connect.
+
+Methods:
+  list                                             snapshots, newest first
+  snapshot {name, description, tags, volumes}      the new snapshot (all optional)
+  restore  {name}                                  the restore result
+
+restore doesn't ask for confirmation; the calling editor is expected to. It
+still refuses protected volumes and a Docker daemon other than the one
+recorded in the config.
+
+Example:
+  dataclean serve --local
+  echo '{"jsonrpc":"2.0","id":1,"method":"list"}' | nc -U .dataclean/control.sock`,
+	Args: cobra.NoArgs,
+	RunE: runServe,
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+
+	serveCmd.Flags().BoolVar(&serveLocal, "local", false, "serve JSON-RPC on a local Unix socket")
+	serveCmd.Flags().StringVar(&serveSocket, "socket", "", "socket path (default: <snapshot_dir>/control.sock)")
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	if !serveLocal {
+		return fmt.Errorf("only the local control socket is available: run 'dataclean serve --local'")
+	}
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	client, err := docker.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to connect to Docker: %w", err)
+	}
+	defer client.Close()
+
+	path := serveSocket
+	if path == "" {
+		path = filepath.Join(cfg.SnapshotDir, "control.sock")
+	}
+	l, err := listenUnix(path)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(path)
+
+	server := controlServer(client)
+	server.OnCall = func(method string, err error) {
+		if quiet {
+			return
+		}
+		if err != nil {
+			color.New(color.FgRed).Fprintf(os.Stderr, "%s %s: %v\n", time.Now().Format("15:04:05"), method, err)
+			return
+		}
+		fmt.Fprintf(os.Stderr, "%s %s: ok\n", time.Now().Format("15:04:05"), method)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		l.Close()
+	}()
+
+	if !quiet {
+		color.Cyan("🔌 Listening on %s (Ctrl+C to stop)", path)
+	}
+	return server.Serve(l)
+}
+
+// listenUnix listens on a socket only the current user can use, replacing a
+// stale socket left by a server that didn't shut down cleanly
+func listenUnix(path string) (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(path); err == nil {
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("another server is already listening on %s", path)
+		}
+		os.Remove(path)
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// snapshotParams are the parameters of the snapshot method
+type snapshotParams struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags"`
+	Volumes     []string `json:"volumes"` // only these volumes (Docker or compose names)
+}
+
+// restoreParams are the parameters of the restore method
+type restoreParams struct {
+	Name string `json:"name"`
+}
+
+// controlServer registers the list, snapshot, and restore methods
+func controlServer(client *docker.Client) *rpc.Server {
+	server := rpc.NewServer()
+
+	server.Handle("list", func(params json.RawMessage) (any, error) {
+		return listSnapshots(client)
+	})
+
+	server.Handle("snapshot", func(params json.RawMessage) (any, error) {
+		var p snapshotParams
+		if err := rpc.DecodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return takeSnapshot(client, p)
+	})
+
+	server.Handle("restore", func(params json.RawMessage) (any, error) {
+		var p restoreParams
+		if err := rpc.DecodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		if p.Name == "" {
+			return nil, &rpc.Error{Code: rpc.CodeInvalidParams, Message: "name is required"}
+		}
+		return restoreSnapshot(client, p.Name)
+	})
+
+	return server
+}
+
+// The operations below back both the control socket and the MCP server. They
+// reload the config on every call, so edits apply without a restart, and never
+// prompt: callers are expected to confirm.
+
+// listSnapshots returns every snapshot, newest first
+func listSnapshots(client *docker.Client) ([]models.Snapshot, error) {
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return nil, err
+	}
+	snapshots, err := snapshot.NewManager(client, cfg).List()
+	if snapshots == nil {
+		snapshots = []models.Snapshot{}
+	}
+	return snapshots, err
+}
+
+// takeSnapshot snapshots the compose project's volumes, or only p.Volumes
+func takeSnapshot(client *docker.Client, p snapshotParams) (*models.Snapshot, error) {
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return nil, err
+	}
+	if len(p.Volumes) > 0 {
+		cfg.IncludeVolumes = p.Volumes
+	}
+	if p.Name == "" {
+		p.Name = fmt.Sprintf("snapshot-%s", time.Now().Format("2006-01-02-150405"))
+	}
+
+	volumes, err := client.DetectComposeVolumes(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect volumes: %w", err)
+	}
+	if len(volumes) == 0 {
+		return nil, fmt.Errorf("no Docker Compose volumes detected")
+	}
+
+	start := time.Now()
+	result, err := snapshot.NewManager(client, cfg).CreateWithOptions(p.Name, volumes, snapshot.CreateOptions{
+		Tags:        p.Tags,
+		Description: p.Description,
+	})
+	var written int64
+	if result != nil {
+		written = result.SizeBytes
+	}
+	reportCompletion(cfg, "snapshot", p.Name, start, written, err)
+	if err != nil {
+		return nil, err
+	}
+	reportSnapshotEvent(cfg, models.SnapshotCreated, result)
+	// Finished before returning, so a delete or restore that follows can't
+	// race the upload. stdout may be the MCP stream, so notes go to stderr.
+	mirrorSnapshot(os.Stderr, cfg, result)
+	return result, nil
+}
+
+// restoreSnapshot restores a snapshot over the live volumes. Protected
+// volumes and a mismatched Docker daemon are refused, with no override.
+func restoreSnapshot(client *docker.Client, name string) (*models.RestoreResult, error) {
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return nil, err
+	}
+	mgr := snapshot.NewManager(client, cfg)
+	snap, err := mgr.Get(name)
+	if err != nil {
+		return nil, fmt.Errorf("snapshot not found: %s", name)
+	}
+	if err := guardDaemon(cfg, client); err != nil {
+		return nil, err
+	}
+	if err := guardProtected(cfg, snap.Volumes); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	result, err := mgr.Restore(name)
+	reportCompletion(cfg, "restore", name, start, snap.SizeBytes, err)
+	if err != nil {
+		return nil, err
+	}
+	reportSnapshotEvent(cfg, models.SnapshotRestored, snap)
+	return result, nil
+}
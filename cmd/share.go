@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/stackgen-cli/dataclean/internal/config"
+	"github.com/stackgen-cli/dataclean/internal/models"
+	"github.com/stackgen-cli/dataclean/internal/remote"
+	"github.com/stackgen-cli/dataclean/internal/snapshot"
+)
+
+var shareExpires time.Duration
+
+var shareCmd = &cobra.Command{
+	Use:   "share <snapshot>",
+	Short: "Upload a snapshot to the remote and print a command to fetch it",
+	Long: `Upload a snapshot to the team remote (unless it is already there) and
+print a 'dataclean pull <token>' one-liner a teammate can run to fetch exactly
+that snapshot.
+
+The remote is a directory everyone can reach, set with remote.url in the
+config. Incremental snapshots are uploaded with their inherited volumes, so
+the copy stands on its own. Stored passwords are never uploaded.
+
+Tokens stop working after --expires. They are signed with remote.share_key,
+which is kept out of the remote (usually as a !env or !secret reference), so
+only teammates holding it can issue or redeem them. They are not access
+control: anyone who can read the remote can read every snapshot on it.
+
+Examples:
+  dataclean share seeded
+  dataclean share seeded --expires 2h`,
+	Args: cobra.ExactArgs(1),
+	RunE: runShare,
+}
+
+func init() {
+	rootCmd.AddCommand(shareCmd)
+	withSummary(shareCmd)
+
+	shareCmd.Flags().DurationVar(&shareExpires, "expires", 72*time.Hour, "how long the token works")
+}
+
+func runShare(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	r, err := openRemote(cfg)
+	if err != nil {
+		return err
+	}
+	if err := remote.CheckShareKey(cfg.Remote.ShareKey); err != nil {
+		return err
+	}
+
+	mgr := snapshot.NewManager(nil, cfg)
+	snap, err := mgr.Get(args[0])
+	if err != nil {
+		return fmt.Errorf("snapshot '%s' not found", args[0])
+	}
+	key := remote.Key(snap)
+	summarize("key", key)
+
+	if dryRun {
+		if !r.Has(key) {
+			dryRunNote("would upload snapshot '%s' (%s) to %s", snap.Name, snap.SizeHuman, cfg.Remote.URL)
+		}
+		dryRunNote("would print a pull token valid for %s", shareExpires)
+		return nil
+	}
+
+	uploaded, err := uploadSnapshot(mgr, r, snap)
+	if err != nil {
+		return err
+	}
+	summarize("uploaded", uploaded)
+
+	token, expires, err := r.Share(key, shareExpires, cfg.Remote.ShareKey)
+	if err != nil {
+		return err
+	}
+	summarize("token", token)
+	summarize("expires", expires.Format(time.RFC3339))
+
+	if !quiet {
+		color.Green("✅ Shared snapshot '%s' (expires %s)", snap.Name, expires.Format("2006-01-02 15:04"))
+		fmt.Println("   Fetch it with:")
+		fmt.Printf("   dataclean pull %s\n", token)
+	}
+	return nil
+}
+
+// openRemote opens the team remote, resolving the references in its settings,
+// which are left until a command uses the remote
+func openRemote(cfg *models.Config) (*remote.Remote, error) {
+	resolved, err := config.Remote(cfg)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Remote = resolved
+	return remote.Open(cfg.Remote.URL)
+}
+
+// uploadSnapshot bundles a snapshot onto the remote unless it is already
+// there, reporting whether it was uploaded
+func uploadSnapshot(mgr *snapshot.Manager, r *remote.Remote, snap *models.Snapshot) (bool, error) {
+	key := remote.Key(snap)
+	if r.Has(key) {
+		return false, nil
+	}
+	if !quiet {
+		fmt.Printf("Uploading snapshot '%s' (%s)...\n", snap.Name, snap.SizeHuman)
+	}
+	err := r.Upload(key, func(w io.Writer) error {
+		return mgr.Bundle(snap.Name, w)
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to upload snapshot: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/stackgen-cli/dataclean/internal/config"
+	"github.com/stackgen-cli/dataclean/internal/docker"
+	"github.com/stackgen-cli/dataclean/internal/models"
+	"github.com/stackgen-cli/dataclean/internal/snapshot"
+)
+
+var (
+	shellImage   string
+	shellCommand string
+)
+
+var shellCmd = &cobra.Command{
+	Use:   "shell <snapshot> [volume]",
+	Short: "Query a snapshot's data in a temporary container",
+	Long: `Start a temporary container on a copy of a snapshot volume's data and open
+the datastore's client (psql, mysql, redis-cli, mongosh, ...), so old data can
+be queried without touching the live volumes.
+
+The data is unpacked into a scratch volume; the container and the scratch
+volume are removed when the client exits, along with any changes made. The
+container runs the volume's service image unless --image is given; datastores
+without a known client get a shell.
+
+The volume argument is required when the snapshot holds more than one volume.
+
+Examples:
+  dataclean shell before-migration pgdata
+  dataclean shell seeded --image postgres:15
+  dataclean shell seeded pgdata --command bash`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runShell,
+}
+
+func init() {
+	rootCmd.AddCommand(shellCmd)
+
+	shellCmd.Flags().StringVar(&shellImage, "image", "", "image to run (default: the volume's service image)")
+	shellCmd.Flags().StringVar(&shellCommand, "command", "", "command to run instead of the datastore's client")
+}
+
+func runShell(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	client, err := docker.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to connect to Docker: %w", err)
+	}
+	defer client.Close()
+
+	mgr := snapshot.NewManager(client, cfg)
+	snap, err := mgr.Get(name)
+	if err != nil {
+		return fmt.Errorf("snapshot not found: %s", name)
+	}
+
+	volume, err := chooseVolume(snap, args[1:])
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		dryRunNote("would open a shell on a copy of %s/%s", name, volume)
+		return nil
+	}
+
+	if !quiet {
+		color.Cyan("🐚 Starting %s/%s in a temporary container (changes are discarded on exit)...", name, volume)
+	}
+
+	// Ctrl+C while the container starts still cleans up; once the client is
+	// attached, the terminal passes it to the client instead
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err = mgr.Shell(ctx, name, volume, snapshot.ShellOptions{
+		Image:   shellImage,
+		Command: shellCommand,
+	})
+	if err != nil && ctx.Err() == nil {
+		return fmt.Errorf("shell failed: %w", err)
+	}
+	return nil
+}
+
+// chooseVolume returns the volume named in args, or the snapshot's only one
+func chooseVolume(snap *models.Snapshot, args []string) (string, error) {
+	switch {
+	case len(args) > 0:
+		return args[0], nil
+	case len(snap.Volumes) == 1:
+		return snap.Volumes[0].Name, nil
+	}
+	var names []string
+	for _, v := range snap.Volumes {
+		names = append(names, v.Name)
+	}
+	return "", fmt.Errorf("snapshot %s has %d volumes; choose one of: %s", snap.Name, len(names), strings.Join(names, ", "))
+}
@@ -2,6 +2,10 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/fatih/color"
@@ -9,6 +13,7 @@ import (
 
 	"github.com/stackgen-cli/dataclean/internal/config"
 	"github.com/stackgen-cli/dataclean/internal/docker"
+	"github.com/stackgen-cli/dataclean/internal/models"
 	"github.com/stackgen-cli/dataclean/internal/snapshot"
 )
 
@@ -18,6 +23,15 @@ var (
 	snapshotMetadata    map[string]string
 	snapshotInclude     []string
 	snapshotExclude     []string
+	snapshotServices    []string
+	snapshotLogical     bool
+	snapshotTables      bool
+	snapshotParent      string
+	snapshotExportAll   bool
+	snapshotRuntime     bool
+	snapshotWaitQuiet   time.Duration
+	snapshotExpires     string
+	snapshotConsistent  bool
 )
 
 var snapshotCmd = &cobra.Command{
@@ -34,18 +48,73 @@ Examples:
   dataclean snapshot --tag release --tag v1.0
   dataclean snapshot --description "Pre-release snapshot"
   dataclean snapshot --include db_data --include cache_data
-  dataclean snapshot --exclude temp_data`,
+  dataclean snapshot --exclude temp_data
+  dataclean snapshot --exclude '*_cache' --exclude 're:^tmp_'
+  dataclean snapshot --service db --service search  # the volumes these services mount
+  dataclean snapshot --logical          # also store SQL dumps (enables diff --sql)
+  dataclean snapshot --tables           # record table row counts (see inspect)
+  dataclean snapshot --runtime          # record container and server settings
+  dataclean snapshot --parent baseline  # incremental: only store volumes that changed
+  dataclean snapshot --export-all       # re-export volumes even if they look unchanged
+  dataclean snapshot --wait-quiet 30s   # wait for writes to running databases to finish
+  dataclean snapshot --expires 7d       # delete on the first cleanup after a week
+  dataclean snapshot release-1 --stack api --stack worker
+
+Volumes whose file listing (names, sizes, modification times) matches their
+most recent snapshot are not exported again; that snapshot's archive is
+hard-linked instead.
+
+Volumes whose containers keep running while they are copied (no
+container_name to stop them by, say) are checked for writes in flight: running
+queries, a Redis background save, or files changing. With --wait-quiet (or
+quiet_wait in the config) the snapshot waits up to that long for them to
+finish; volumes still busy are copied anyway and flagged, because a hot copy
+of a busy database may not restore.
+
+With --runtime, each volume's 'docker inspect' output and server settings
+(Postgres settings changed from their defaults, MySQL global variables, Redis
+CONFIG GET) are stored under runtime/ in the snapshot. Restoring it then lists
+every setting that differs from the running containers'.
+
+With --expires (a number of days like 7d, a duration like 12h, or a date
+like 2024-06-30) the snapshot is deleted by the first 'dataclean prune' after
+that time, whatever retention_days says. Snapshots other snapshots are built
+on are kept until those are gone.
+
+With --stack (repeatable) or --all-stacks, a snapshot of the same name is
+taken in each stack listed under stacks in the config, one after another, as
+if dataclean were run in the stack's directory with its own .dataclean.yaml
+and compose project. Every stack's name is checked before any is touched, and
+the set is recorded in the top-level snapshot directory, so 'restore
+--all-stacks' brings back the same stacks. Stacks are normally stopped one at
+a time, each only while its own volumes are copied; with --consistent, every
+stack's containers are flushed and stopped first, all volumes exported, and
+only then are the stacks started again, so stores that refer to each other
+(an API database and an event store, say) hold one logical point in time.
+--consistent can't be combined with --logical, --tables, or --runtime, which
+need the databases running.`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runSnapshot,
 }
 
 func init() {
 	rootCmd.AddCommand(snapshotCmd)
+	withSummary(snapshotCmd)
 
 	snapshotCmd.Flags().StringSliceVarP(&snapshotTags, "tag", "t", nil, "Tags to add to snapshot")
 	snapshotCmd.Flags().StringVarP(&snapshotDescription, "description", "d", "", "Description for snapshot")
-	snapshotCmd.Flags().StringSliceVar(&snapshotInclude, "include", nil, "Only include these volumes")
-	snapshotCmd.Flags().StringSliceVar(&snapshotExclude, "exclude", nil, "Exclude these volumes")
+	snapshotCmd.Flags().StringSliceVar(&snapshotInclude, "include", nil, "Only include these volumes (names, globs, or re:<regexp>)")
+	snapshotCmd.Flags().StringSliceVar(&snapshotExclude, "exclude", nil, "Exclude these volumes (names, globs, or re:<regexp>)")
+	snapshotCmd.Flags().StringSliceVar(&snapshotServices, "service", nil, "Only include the volumes of these compose services")
+	snapshotCmd.Flags().BoolVar(&snapshotLogical, "logical", false, "Also store SQL dumps of Postgres/MySQL volumes")
+	snapshotCmd.Flags().BoolVar(&snapshotTables, "tables", false, "Record table/collection row counts for Postgres/MySQL/MongoDB")
+	snapshotCmd.Flags().BoolVar(&snapshotRuntime, "runtime", false, "Record docker inspect output and server settings, to flag drift on restore")
+	snapshotCmd.Flags().DurationVar(&snapshotWaitQuiet, "wait-quiet", 0, "Wait up to this long for writes to volumes that stay running to finish (default: quiet_wait from the config)")
+	snapshotCmd.Flags().StringVar(&snapshotExpires, "expires", "", "Delete after this long (7d, 12h) or on this date (2024-06-30), instead of by retention_days")
+	snapshotCmd.Flags().StringVar(&snapshotParent, "parent", "", "Create an incremental snapshot on top of this one")
+	withStackFlags(snapshotCmd)
+	snapshotCmd.Flags().BoolVar(&snapshotConsistent, "consistent", false, "With stacks: stop every stack before exporting any, so the snapshots share one point in time")
+	snapshotCmd.Flags().BoolVar(&snapshotExportAll, "export-all", false, "Export every volume, even ones unchanged since their last snapshot")
 }
 
 func runSnapshot(cmd *cobra.Command, args []string) error {
@@ -54,19 +123,34 @@ func runSnapshot(cmd *cobra.Command, args []string) error {
 	if len(args) > 0 {
 		name = args[0]
 	}
+	if stacksSelected() {
+		return snapshotStacks(cmd, name)
+	}
+
+	var expiresAt time.Time
+	if snapshotExpires != "" {
+		t, err := parseExpiry(snapshotExpires, time.Now())
+		if err != nil {
+			return err
+		}
+		expiresAt = t
+	}
 
 	// Load config (auto-detect or from file)
 	cfg, err := config.Load(cfgFile)
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
+	if err := guardReadOnly(cfg); err != nil {
+		return err
+	}
 
-	// Apply include/exclude flags to config
-	if len(snapshotInclude) > 0 {
-		cfg.IncludeVolumes = snapshotInclude
+	if err := snapshot.NewManager(nil, cfg).ValidateName(name); err != nil {
+		return err
 	}
-	if len(snapshotExclude) > 0 {
-		cfg.ExcludeVolumes = append(cfg.ExcludeVolumes, snapshotExclude...)
+
+	if err := applyVolumeFilters(cfg, snapshotInclude, snapshotExclude, snapshotServices); err != nil {
+		return err
 	}
 
 	// Detect volumes
@@ -82,12 +166,18 @@ func runSnapshot(cmd *cobra.Command, args []string) error {
 	}
 
 	if len(volumes) == 0 {
-		color.Yellow("⚠️  No Docker Compose volumes detected in current directory")
+		warn("⚠️  No Docker Compose volumes detected in current directory")
+		summarize("volumes", 0)
 		return nil
 	}
 
+	if !dryRun {
+		ensureGitignored(cfg)
+	}
+
 	// Show what will be snapshotted
-	if !quiet {
+	if !quiet && !jsonOutput {
+		warnSnapshotDirConflicts(client, cfg)
 		color.Cyan("📸 Creating snapshot: %s", name)
 		if snapshotDescription != "" {
 			fmt.Printf("   Description: %s\n", snapshotDescription)
@@ -95,6 +185,9 @@ func runSnapshot(cmd *cobra.Command, args []string) error {
 		if len(snapshotTags) > 0 {
 			fmt.Printf("   Tags: %v\n", snapshotTags)
 		}
+		if !expiresAt.IsZero() {
+			fmt.Printf("   Expires: %s\n", expiresAt.Format("2006-01-02 15:04"))
+		}
 		fmt.Println()
 		for _, v := range volumes {
 			fmt.Printf("  • %s (%s)\n", v.Name, v.DatastoreType)
@@ -102,22 +195,65 @@ func runSnapshot(cmd *cobra.Command, args []string) error {
 		fmt.Println()
 	}
 
+	mgr := snapshot.NewManager(client, cfg)
+
 	// Dry run stops here
 	if dryRun {
-		color.Yellow("🔍 Dry run - no changes made")
-		return nil
+		plan, err := mgr.PlanCreate(name, volumes)
+		if err != nil {
+			return fmt.Errorf("failed to plan snapshot: %w", err)
+		}
+		return printPlan(plan)
 	}
 
 	// Create snapshot with options
-	mgr := snapshot.NewManager(client, cfg)
 	opts := snapshot.CreateOptions{
 		Tags:        snapshotTags,
 		Description: snapshotDescription,
+		Logical:     snapshotLogical,
+		Tables:      snapshotTables,
+		Runtime:     snapshotRuntime,
+		ParentName:  snapshotParent,
+		ExportAll:   snapshotExportAll,
+		QuietWait:   snapshotWaitQuiet,
+		ExpiresAt:   expiresAt,
 	}
+	start := time.Now()
 	result, err := mgr.CreateWithOptions(name, volumes, opts)
+	var written int64
+	if result != nil {
+		written = result.SizeBytes
+	}
+	reportCompletion(cfg, "snapshot", name, start, written, err)
 	if err != nil {
 		return fmt.Errorf("failed to create snapshot: %w", err)
 	}
+	reportSnapshotEvent(cfg, models.SnapshotCreated, result)
+	mirrorSnapshot(os.Stdout, cfg, result)
+	summarize("name", result.Name)
+	summarize("volumes", len(result.Volumes))
+	summarize("size_bytes", result.SizeBytes)
+	linked := 0
+	for _, v := range result.Volumes {
+		if v.LinkedFrom != "" {
+			linked++
+		}
+	}
+	summarize("linked", linked)
+	for _, v := range result.Volumes {
+		if v.HotCopy != "" {
+			warn("⚠️  %s was copied while being written to (%s); it may not restore cleanly", v.Name, v.HotCopy)
+			summarize("hot_copy", v.Name)
+		}
+		if v.Killed {
+			warn("⚠️  %s's container was killed after its stop timeout; the snapshot may be inconsistent (raise stop_timeouts.%s)", v.Name, v.DatastoreType)
+			summarize("killed", v.Name)
+		}
+		if len(v.Foreign) > 0 {
+			warn("⚠️  %s was also mounted by %s, outside the compose project; stopped for the snapshot and started again", v.Name, strings.Join(v.Foreign, ", "))
+			summarize("foreign", v.Name)
+		}
+	}
 
 	if !quiet {
 		color.Green("✅ Snapshot created: %s", result.Name)
@@ -126,7 +262,54 @@ func runSnapshot(cmd *cobra.Command, args []string) error {
 		if len(result.Tags) > 0 {
 			fmt.Printf("   Tags: %v\n", result.Tags)
 		}
+		for _, v := range result.Volumes {
+			if v.LinkedFrom != "" {
+				fmt.Printf("   %s: unchanged, linked from %s\n", v.Name, v.LinkedFrom)
+			}
+		}
 	}
 
 	return nil
 }
+
+// applyVolumeFilters applies --include, --exclude, and --service flags to the
+// config: --include replaces include_volumes, --exclude adds to
+// exclude_volumes, and --service replaces include_services. The volume flags
+// take the config's names, globs, and re: regular expressions.
+func applyVolumeFilters(cfg *models.Config, include, exclude, services []string) error {
+	for _, pattern := range append(slices.Clone(include), exclude...) {
+		if err := models.CheckVolumePattern(pattern); err != nil {
+			return err
+		}
+	}
+	if len(include) > 0 {
+		cfg.IncludeVolumes = include
+	}
+	if len(exclude) > 0 {
+		cfg.ExcludeVolumes = append(slices.Clone(cfg.ExcludeVolumes), exclude...)
+	}
+	if len(services) > 0 {
+		cfg.IncludeServices = services
+	}
+	return nil
+}
+
+// parseExpiry turns an --expires value into a time: a number of days (7d), a
+// Go duration (12h), or a date (2024-06-30, meaning the start of that day)
+func parseExpiry(value string, now time.Time) (time.Time, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n > 0 {
+			return now.AddDate(0, 0, n), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d > 0 {
+		return now.Add(d), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		if !t.After(now) {
+			return time.Time{}, fmt.Errorf("--expires %s is in the past", value)
+		}
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --expires %q: use a number of days (7d), a duration (12h), or a date (2024-06-30)", value)
+}
@@ -0,0 +1,326 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/stackgen-cli/dataclean/internal/config"
+	"github.com/stackgen-cli/dataclean/internal/docker"
+	"github.com/stackgen-cli/dataclean/internal/models"
+	"github.com/stackgen-cli/dataclean/internal/snapshot"
+)
+
+var (
+	stackNames []string
+	allStacks  bool
+
+	// activeStack is the stack a command is running for, nil at the top level
+	activeStack *models.Stack
+)
+
+// withStackFlags adds --stack and --all-stacks to a command
+func withStackFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVar(&stackNames, "stack", nil, "run for this stack from stacks in the config (repeat for several)")
+	cmd.Flags().BoolVar(&allStacks, "all-stacks", false, "run for every stack in the config")
+}
+
+// stacksSelected reports whether the command should fan out over stacks
+func stacksSelected() bool {
+	return activeStack == nil && (len(stackNames) > 0 || allStacks)
+}
+
+// selectStacks returns the stacks --stack and --all-stacks pick from the config
+func selectStacks(cfg *models.Config) ([]models.Stack, error) {
+	if len(cfg.Stacks) == 0 {
+		return nil, fmt.Errorf("no stacks in the config (list them under stacks)")
+	}
+	if allStacks {
+		if len(stackNames) > 0 {
+			return nil, fmt.Errorf("--stack and --all-stacks can't be combined")
+		}
+		return cfg.Stacks, nil
+	}
+	var stacks []models.Stack
+	for _, name := range stackNames {
+		i := slices.IndexFunc(cfg.Stacks, func(s models.Stack) bool { return s.Name == name })
+		if i < 0 {
+			return nil, fmt.Errorf("unknown stack %q", name)
+		}
+		if !slices.ContainsFunc(stacks, func(s models.Stack) bool { return s.Name == name }) {
+			stacks = append(stacks, cfg.Stacks[i])
+		}
+	}
+	return stacks, nil
+}
+
+// inStack runs fn as if dataclean had been started in the stack's directory,
+// with its own config and COMPOSE_PROJECT_NAME set to its project
+func inStack(stack models.Stack, fn func() error) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	dir, err := filepath.Abs(stack.Path)
+	if err != nil {
+		return err
+	}
+	if err := os.Chdir(dir); err != nil {
+		return fmt.Errorf("stack %s: %w", stack.Name, err)
+	}
+	prevProject, hadProject := os.LookupEnv("COMPOSE_PROJECT_NAME")
+	if stack.Project != "" {
+		os.Setenv("COMPOSE_PROJECT_NAME", stack.Project)
+	}
+	prevCfgFile := cfgFile
+	cfgFile = ""
+	activeStack = &stack
+
+	defer func() {
+		activeStack = nil
+		cfgFile = prevCfgFile
+		if hadProject {
+			os.Setenv("COMPOSE_PROJECT_NAME", prevProject)
+		} else {
+			os.Unsetenv("COMPOSE_PROJECT_NAME")
+		}
+		os.Chdir(cwd)
+	}()
+	return fn()
+}
+
+// stackHeader introduces a stack's part of the output
+func stackHeader(stack models.Stack) {
+	if !quiet && !jsonOutput {
+		color.New(color.Bold).Printf("━━ Stack %s (%s)\n", stack.Name, stack.Path)
+	}
+}
+
+// snapshotStacks takes a snapshot of the same name in every selected stack,
+// then records the set in the top-level snapshot directory
+func snapshotStacks(cmd *cobra.Command, name string) error {
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := guardReadOnly(cfg); err != nil {
+		return err
+	}
+	stacks, err := selectStacks(cfg)
+	if err != nil {
+		return err
+	}
+
+	if snapshotConsistent && (snapshotLogical || snapshotTables || snapshotRuntime) {
+		return fmt.Errorf("--consistent can't be combined with --logical, --tables, or --runtime: they need the databases running")
+	}
+
+	// Check every stack before touching any
+	for _, s := range stacks {
+		err := inStack(s, func() error {
+			stackCfg, err := config.Load("")
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+			return snapshot.NewManager(nil, stackCfg).ValidateName(name)
+		})
+		if err != nil {
+			return fmt.Errorf("stack %s: %w", s.Name, err)
+		}
+	}
+
+	restart := func() {}
+	if snapshotConsistent && !dryRun {
+		if restart, err = holdStacks(stacks); err != nil {
+			return err
+		}
+	}
+
+	set := &models.StackSet{Name: name, Timestamp: time.Now(), Consistent: snapshotConsistent}
+	for _, s := range stacks {
+		stackHeader(s)
+		err := inStack(s, func() error {
+			if err := runSnapshot(cmd, []string{name}); err != nil {
+				return err
+			}
+			if dryRun {
+				return nil
+			}
+			stackCfg, err := config.Load("")
+			if err != nil {
+				return err
+			}
+			snap, err := snapshot.NewManager(nil, stackCfg).Get(name)
+			if err != nil {
+				return err
+			}
+			member := models.StackMember{Stack: s, SizeBytes: snap.SizeBytes}
+			for _, v := range snap.Volumes {
+				member.Volumes = append(member.Volumes, v.Name)
+			}
+			set.Stacks = append(set.Stacks, member)
+			return nil
+		})
+		if err != nil {
+			restart()
+			return fmt.Errorf("stack %s: %w (snapshotted before it: %s)", s.Name, err, memberNames(set))
+		}
+	}
+	restart()
+	summarize("stacks", len(stacks))
+	if dryRun {
+		return nil
+	}
+
+	if err := snapshot.NewManager(nil, cfg).SaveStackSet(set); err != nil {
+		return fmt.Errorf("failed to record the stack snapshot: %w", err)
+	}
+	if !quiet {
+		color.Green("✅ Snapshot %s taken across %d stack(s): %s", name, len(set.Stacks), memberNames(set))
+	}
+	return nil
+}
+
+// holdStacks flushes and stops the containers of every stack before any is
+// snapshotted, and returns a func that starts them all again
+func holdStacks(stacks []models.Stack) (func(), error) {
+	client, err := docker.NewClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Docker: %w", err)
+	}
+	var held []models.Stack
+	var starts []func()
+	restart := func() {
+		if !quiet && !jsonOutput && len(held) > 0 {
+			color.Cyan("▶️  Starting the stacks again...")
+		}
+		for i, s := range held {
+			inStack(s, func() error { starts[i](); return nil })
+		}
+		client.Close()
+	}
+
+	if !quiet && !jsonOutput {
+		color.Cyan("⏸️  Stopping %d stack(s) for a consistent snapshot...", len(stacks))
+	}
+	for _, s := range stacks {
+		err := inStack(s, func() error {
+			cfg, err := config.Load("")
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+			if err := applyVolumeFilters(cfg, snapshotInclude, snapshotExclude, snapshotServices); err != nil {
+				return err
+			}
+			volumes, err := client.DetectComposeVolumes(cfg)
+			if err != nil {
+				return fmt.Errorf("failed to detect volumes: %w", err)
+			}
+			start, err := snapshot.NewManager(client, cfg).Hold(volumes)
+			if err != nil {
+				return err
+			}
+			held = append(held, s)
+			starts = append(starts, start)
+			return nil
+		})
+		if err != nil {
+			restart()
+			return nil, fmt.Errorf("stack %s: %w", s.Name, err)
+		}
+	}
+	return restart, nil
+}
+
+// restoreStacks restores the snapshot of the same name in every selected
+// stack after one confirmation. With --all-stacks, a recorded stack
+// snapshot picks the stacks it was taken across.
+func restoreStacks(cmd *cobra.Command, name string) error {
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := guardReadOnly(cfg); err != nil {
+		return err
+	}
+	var stacks []models.Stack
+	set, err := snapshot.NewManager(nil, cfg).StackSet(name)
+	if err != nil {
+		return err
+	}
+	if allStacks && set != nil {
+		for _, m := range set.Stacks {
+			stacks = append(stacks, m.Stack)
+		}
+	} else if stacks, err = selectStacks(cfg); err != nil {
+		return err
+	}
+
+	// Check every stack has the snapshot before touching any
+	for _, s := range stacks {
+		err := inStack(s, func() error {
+			stackCfg, err := config.Load("")
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+			if _, err := snapshot.NewManager(nil, stackCfg).Get(name); err != nil {
+				return fmt.Errorf("snapshot not found: %s", name)
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("stack %s: %w", s.Name, err)
+		}
+	}
+
+	if !force && !dryRun {
+		names := make([]string, len(stacks))
+		for i, s := range stacks {
+			names[i] = s.Name
+		}
+		color.Red("⚠️  This will DELETE existing data in stacks %s and replace it with snapshot %s!", strings.Join(names, ", "), name)
+		fmt.Print("Type 'yes' to confirm: ")
+		reader := bufio.NewReader(os.Stdin)
+		response, _ := reader.ReadString('\n')
+		response = strings.TrimSpace(strings.ToLower(response))
+		if response != "yes" {
+			warn("Aborted.")
+			summarize("aborted", true)
+			return nil
+		}
+	}
+
+	// One confirmation covers every stack
+	prevForce := force
+	force = true
+	defer func() { force = prevForce }()
+	for i, s := range stacks {
+		stackHeader(s)
+		if err := inStack(s, func() error { return runRestore(cmd, []string{name}) }); err != nil {
+			return fmt.Errorf("stack %s: %w (%d of %d stacks restored)", s.Name, err, i, len(stacks))
+		}
+	}
+	summarize("stacks", len(stacks))
+	if !quiet && !dryRun {
+		color.Green("✅ Restored snapshot %s across %d stack(s)", name, len(stacks))
+	}
+	return nil
+}
+
+func memberNames(set *models.StackSet) string {
+	if len(set.Stacks) == 0 {
+		return "none"
+	}
+	names := make([]string, len(set.Stacks))
+	for i, m := range set.Stacks {
+		names[i] = m.Name
+	}
+	return strings.Join(names, ", ")
+}
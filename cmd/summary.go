@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// Commands that change state print exactly one summary line in --quiet mode,
+// in logfmt so scripts can parse it:
+//
+//	dataclean snapshot status=ok name=before-migration volumes=3 size_bytes=47395635
+//
+// Commands whose output is the data itself (list, inspect, diff, ...) don't.
+const summaryAnnotation = "dataclean.summary"
+
+// summaryField is one key=value pair of the summary line
+type summaryField struct {
+	key   string
+	value any
+}
+
+var summaryFields []summaryField
+
+// withSummary marks a command as printing a summary line in --quiet mode
+func withSummary(cmd *cobra.Command) {
+	if cmd.Annotations == nil {
+		cmd.Annotations = map[string]string{}
+	}
+	cmd.Annotations[summaryAnnotation] = "true"
+}
+
+// summarize adds a field to the summary line, replacing an earlier value
+func summarize(key string, value any) {
+	for i, f := range summaryFields {
+		if f.key == key {
+			summaryFields[i].value = value
+			return
+		}
+	}
+	summaryFields = append(summaryFields, summaryField{key, value})
+}
+
+// printSummary writes the summary line for a finished command
+func printSummary(w io.Writer, cmd *cobra.Command, err error) {
+	if cmd == nil || cmd.Annotations[summaryAnnotation] == "" {
+		return
+	}
+
+	status := "ok"
+	if err != nil {
+		status = "error"
+	}
+	parts := []string{"dataclean", cmd.Name(), "status=" + status}
+	for _, f := range summaryFields {
+		parts = append(parts, f.key+"="+logfmtValue(fmt.Sprint(f.value)))
+	}
+	if err != nil {
+		parts = append(parts, "error="+logfmtValue(err.Error()))
+	}
+	fmt.Fprintln(w, strings.Join(parts, " "))
+}
+
+// logfmtValue quotes values that contain spaces, quotes, or '='
+func logfmtValue(v string) string {
+	if v == "" || strings.ContainsAny(v, " \t\n\"=") {
+		return fmt.Sprintf("%q", v)
+	}
+	return v
+}
+
+// warn prints a warning to stderr, so it never mixes with parseable output
+func warn(format string, args ...any) {
+	color.New(color.FgYellow).Fprintf(os.Stderr, format+"\n", args...)
+}
+
+// dryRunNote reports what a dry run would have done
+func dryRunNote(format string, args ...any) {
+	summarize("dry_run", true)
+	if !quiet {
+		color.Yellow("🔍 Dry run - "+format, args...)
+	}
+}
@@ -0,0 +1,263 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/stackgen-cli/dataclean/internal/config"
+	"github.com/stackgen-cli/dataclean/internal/docker"
+	"github.com/stackgen-cli/dataclean/internal/remote"
+	"github.com/stackgen-cli/dataclean/internal/snapshot"
+)
+
+var (
+	templateDescription string
+	templateApplyAs     string
+)
+
+var templateCmd = &cobra.Command{
+	Use:   "template",
+	Short: "Publish and apply snapshot templates for new projects",
+	Long: `Templates are snapshots (schema plus seed data) published on the team remote
+(remote.url in the config) under a name such as acme/orders-service, for
+bootstrapping a service's dev data in one command.
+
+'template apply' starts the compose project in the current directory, fetches
+the template, and restores it into the project's volumes. Volumes are matched
+by their name in the compose file, so the project directory (and with it the
+Docker volume names) doesn't have to match the one the template came from.
+
+Examples:
+  dataclean template publish seeded acme/orders-service --description "Orders schema with demo data"
+  dataclean template list
+  dataclean template apply acme/orders-service`,
+}
+
+var templatePublishCmd = &cobra.Command{
+	Use:   "publish <snapshot> <template>",
+	Short: "Upload a snapshot and publish it as a template",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runTemplatePublish,
+}
+
+var templateListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the templates on the remote",
+	Args:  cobra.NoArgs,
+	RunE:  runTemplateList,
+}
+
+var templateApplyCmd = &cobra.Command{
+	Use:   "apply <template>",
+	Short: "Start the compose project and restore a template into it",
+	Long: `Start the compose project in the current directory with 'docker compose up -d',
+fetch the template from the remote, and restore it into the project's volumes.
+
+Applying over volumes that already exist replaces their data, so it asks for
+confirmation unless --force is given. The fetched snapshot is kept (named
+after the template unless --as is given), so 'dataclean restore' can go back
+to it later.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTemplateApply,
+}
+
+func init() {
+	rootCmd.AddCommand(templateCmd)
+	templateCmd.AddCommand(templatePublishCmd, templateListCmd, templateApplyCmd)
+	withSummary(templatePublishCmd)
+	withSummary(templateApplyCmd)
+
+	templatePublishCmd.Flags().StringVarP(&templateDescription, "description", "d", "", "what the template holds")
+	templateApplyCmd.Flags().StringVar(&templateApplyAs, "as", "", "save the fetched snapshot under this name")
+}
+
+func runTemplatePublish(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	r, err := openRemote(cfg)
+	if err != nil {
+		return err
+	}
+
+	mgr := snapshot.NewManager(nil, cfg)
+	snap, err := mgr.Get(args[0])
+	if err != nil {
+		return fmt.Errorf("snapshot '%s' not found", args[0])
+	}
+	key := remote.Key(snap)
+	summarize("key", key)
+	summarize("template", args[1])
+
+	if dryRun {
+		if !r.Has(key) {
+			dryRunNote("would upload snapshot '%s' (%s) to %s", snap.Name, snap.SizeHuman, cfg.Remote.URL)
+		}
+		dryRunNote("would publish it as template %s", args[1])
+		return nil
+	}
+
+	if _, err := uploadSnapshot(mgr, r, snap); err != nil {
+		return err
+	}
+	t := remote.Template{Name: args[1], Key: key, Description: templateDescription, Published: time.Now()}
+	if err := r.PublishTemplate(t); err != nil {
+		return fmt.Errorf("failed to publish template %s: %w", args[1], err)
+	}
+
+	if !quiet {
+		color.Green("✅ Published snapshot '%s' as template %s", snap.Name, args[1])
+		fmt.Printf("   Apply it with: dataclean template apply %s\n", args[1])
+	}
+	return nil
+}
+
+func runTemplateList(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	r, err := openRemote(cfg)
+	if err != nil {
+		return err
+	}
+	templates, err := r.Templates()
+	if err != nil {
+		return err
+	}
+	if len(templates) == 0 {
+		fmt.Println("No templates on the remote. Publish one with: dataclean template publish <snapshot> <name>")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TEMPLATE\tPUBLISHED\tDESCRIPTION")
+	for _, t := range templates {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", t.Name, t.Published.Format("2006-01-02 15:04"), t.Description)
+	}
+	return w.Flush()
+}
+
+func runTemplateApply(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := guardReadOnly(cfg); err != nil {
+		return err
+	}
+	r, err := openRemote(cfg)
+	if err != nil {
+		return err
+	}
+	t, err := r.Template(args[0])
+	if err != nil {
+		return err
+	}
+	name := templateApplyAs
+	if name == "" {
+		name = strings.ReplaceAll(t.Name, "/", "-")
+	}
+	summarize("key", t.Key)
+	summarize("name", name)
+
+	client, err := docker.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to connect to Docker: %w", err)
+	}
+	defer client.Close()
+	if err := guardDaemon(cfg, client); err != nil {
+		return err
+	}
+
+	volumes, err := client.DetectComposeVolumes(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to detect volumes: %w", err)
+	}
+	if err := guardProtected(cfg, volumes); err != nil {
+		return err
+	}
+
+	mgr := snapshot.NewManager(client, cfg)
+	fetched := false
+	if existing, err := mgr.Get(name); err == nil {
+		if remote.Key(existing) != t.Key {
+			return fmt.Errorf("snapshot '%s' already exists; pick another name with --as", name)
+		}
+		fetched = true
+	}
+
+	// Only a project that already has data needs confirming
+	var existing []string
+	for _, v := range volumes {
+		if _, ok, _ := client.VolumeLabels(v.Name); ok {
+			existing = append(existing, v.Name)
+		}
+	}
+	if len(existing) > 0 && !force && !dryRun {
+		color.Red("⚠️  Applying %s will DELETE the data in: %s", t.Name, strings.Join(existing, ", "))
+		fmt.Print("Type 'yes' to confirm: ")
+		response, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if strings.TrimSpace(strings.ToLower(response)) != "yes" {
+			warn("Aborted.")
+			summarize("aborted", true)
+			return nil
+		}
+	}
+
+	if dryRun {
+		if !fetched {
+			dryRunNote("would fetch template %s (%s) as snapshot '%s'", t.Name, t.Key, name)
+		}
+		dryRunNote("would start the compose project and restore '%s' into %d volume(s)", name, len(volumes))
+		return nil
+	}
+
+	// Fetch first, so a template that doesn't fit this project starts nothing
+	if !fetched {
+		if !quiet {
+			fmt.Printf("Fetching template %s...\n", t.Name)
+		}
+		bundle, err := r.Download(t.Key)
+		if err != nil {
+			return err
+		}
+		_, err = mgr.UnbundleFor(bundle, name, volumes)
+		bundle.Close()
+		if err != nil {
+			return fmt.Errorf("failed to fetch template: %w", err)
+		}
+		if err := mgr.UpdateMetadata(name, map[string]string{remote.KeyMetadata: t.Key}); err != nil {
+			return err
+		}
+	}
+
+	if !quiet {
+		color.Cyan("🚀 Starting compose project...")
+	}
+	if err := client.ComposeUp(cfg); err != nil {
+		return err
+	}
+	if !quiet {
+		color.Cyan("🔄 Restoring template...")
+	}
+	result, err := restoreSnapshot(client, name)
+	summarizeRestore(result)
+	if err != nil {
+		return fmt.Errorf("failed to apply template: %w", err)
+	}
+
+	if !quiet {
+		printRestoreResult(result)
+		color.Green("✅ Applied template %s", t.Name)
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/stackgen-cli/dataclean/internal/config"
+	"github.com/stackgen-cli/dataclean/internal/docker"
+	"github.com/stackgen-cli/dataclean/internal/models"
+	"github.com/stackgen-cli/dataclean/internal/snapshot"
+)
+
+var (
+	upService string
+	upImage   string
+	upPort    int
+	upDetach  bool
+)
+
+var upCmd = &cobra.Command{
+	Use:   "up <snapshot> [volume]",
+	Short: "Start a throwaway database from a snapshot on a random port",
+	Long: `Start a throwaway container of the right image with a snapshot volume's
+data in a fresh anonymous volume, publish its port on a random localhost port,
+and print a connection string. The live volumes are never touched.
+
+The container is removed, data and all, on Ctrl+C. With --detach it keeps
+running until 'dataclean down'.
+
+Pick the volume by compose service with --service, or by name; either is
+required when the snapshot holds more than one volume.
+
+Examples:
+  dataclean up before-migration --service db
+  dataclean up seeded pgdata --detach
+  dataclean up seeded --service search --port 9200`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runUp,
+}
+
+var downCmd = &cobra.Command{
+	Use:   "down [container...]",
+	Short: "Remove throwaway databases started by up",
+	Long: `Remove the containers started by 'dataclean up' for this project, along with
+their data, or only the named ones.
+
+Examples:
+  dataclean down
+  dataclean down dataclean-up-lq3x9k2f`,
+	RunE: runDown,
+}
+
+func init() {
+	rootCmd.AddCommand(upCmd, downCmd)
+	withSummary(upCmd)
+	withSummary(downCmd)
+
+	upCmd.Flags().StringVar(&upService, "service", "", "compose service whose volume to start")
+	upCmd.Flags().StringVar(&upImage, "image", "", "image to run (default: the volume's service image)")
+	upCmd.Flags().IntVar(&upPort, "port", 0, "container port to publish (default: the datastore's)")
+	upCmd.Flags().BoolVarP(&upDetach, "detach", "d", false, "leave it running until 'dataclean down'")
+}
+
+func runUp(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	client, err := docker.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to connect to Docker: %w", err)
+	}
+	defer client.Close()
+
+	mgr := snapshot.NewManager(client, cfg)
+	snap, err := mgr.Get(name)
+	if err != nil {
+		return fmt.Errorf("snapshot not found: %s", name)
+	}
+	vol, err := upVolume(snap, args[1:])
+	if err != nil {
+		return err
+	}
+	summarize("volume", vol.Name)
+
+	if dryRun {
+		dryRunNote("would start %s/%s on a random port", name, vol.Name)
+		return nil
+	}
+
+	if !quiet {
+		color.Cyan("🚀 Starting %s/%s...", name, vol.Name)
+	}
+
+	// Ctrl+C while starting or running tears everything down
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	e, err := mgr.Up(ctx, name, vol.Name, snapshot.UpOptions{
+		Project: projectName(),
+		Image:   upImage,
+		Port:    upPort,
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("cancelled")
+		}
+		return fmt.Errorf("failed to start %s: %w", vol.Name, err)
+	}
+	summarize("container", e.Container)
+	summarize("address", e.Address)
+
+	if !quiet {
+		color.Green("✅ %s/%s is up as %s", name, vol.Name, e.Container)
+		fmt.Printf("   %s\n", e.URL)
+	}
+	if upDetach {
+		if !quiet {
+			fmt.Println("   Remove it with: dataclean down")
+		}
+		return nil
+	}
+	if !quiet {
+		fmt.Println("   Press Ctrl+C to stop and remove it")
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		client.WaitContainer(e.Container)
+		close(stopped)
+	}()
+	select {
+	case <-ctx.Done():
+	case <-stopped:
+		if ctx.Err() == nil {
+			warn("%s stopped", e.Container)
+		}
+	}
+	if err := mgr.RemoveEphemeral(e.Container); err != nil && ctx.Err() != nil {
+		return err
+	}
+	if !quiet {
+		fmt.Printf("Removed %s\n", e.Container)
+	}
+	return nil
+}
+
+// upVolume picks the snapshot volume to start: by --service, by name, or the only one
+func upVolume(snap *models.Snapshot, args []string) (*models.Volume, error) {
+	switch {
+	case upService != "":
+		return snapshot.FindServiceVolume(snap, upService)
+	case len(args) > 0:
+		return snapshot.FindVolume(snap, args[0])
+	case len(snap.Volumes) == 1:
+		return &snap.Volumes[0], nil
+	}
+	var services []string
+	for _, v := range snap.Volumes {
+		if v.Service != "" && !slices.Contains(services, v.Service) {
+			services = append(services, v.Service)
+		}
+	}
+	return nil, fmt.Errorf("snapshot %s has %d volumes; choose a service with --service (%s) or a volume by name",
+		snap.Name, len(snap.Volumes), strings.Join(services, ", "))
+}
+
+func runDown(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	client, err := docker.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to connect to Docker: %w", err)
+	}
+	defer client.Close()
+
+	mgr := snapshot.NewManager(client, cfg)
+	running, err := mgr.EphemeralContainers(projectName())
+	if err != nil {
+		return err
+	}
+
+	targets := running
+	if len(args) > 0 {
+		for _, name := range args {
+			if !slices.Contains(running, name) {
+				return fmt.Errorf("%s was not started by 'dataclean up' in this project", name)
+			}
+		}
+		targets = args
+	}
+	summarize("removed", 0)
+	if len(targets) == 0 {
+		if !quiet {
+			fmt.Println("Nothing to remove.")
+		}
+		return nil
+	}
+
+	if dryRun {
+		for _, name := range targets {
+			dryRunNote("would remove %s", name)
+		}
+		return nil
+	}
+
+	for i, name := range targets {
+		if err := mgr.RemoveEphemeral(name); err != nil {
+			return err
+		}
+		summarize("removed", i+1)
+		if !quiet {
+			fmt.Printf("  • removed %s\n", name)
+		}
+	}
+	return nil
+}
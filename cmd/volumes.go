@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stackgen-cli/dataclean/internal/config"
+	"github.com/stackgen-cli/dataclean/internal/docker"
+	"github.com/stackgen-cli/dataclean/internal/models"
+	"github.com/stackgen-cli/dataclean/internal/snapshot"
+)
+
+var volumesCmd = &cobra.Command{
+	Use:   "volumes",
+	Short: "Show detected volumes with live size and container status",
+	Long: `List detected volumes with their current size, the containers that mount
+them, and their most recent snapshot.
+
+The CLEAN column is "yes" when nothing in the volume was modified after its
+last snapshot was taken (a restore would change nothing). RESTORED FROM shows
+the snapshot the volume was last restored from and whether it has drifted
+since: its size change and whether anything was modified after the restore.
+Volumes a restore had to create also carry dataclean.snapshot and
+dataclean.restored_at labels, which are shown when the state file has no
+record (drift is then unknown).
+Both are modification time heuristics: writes that preserve mtimes aren't
+detected, and datastores that write in the background drift on their own.
+
+Examples:
+  dataclean volumes
+  dataclean volumes --json`,
+	Args: cobra.NoArgs,
+	RunE: runVolumes,
+}
+
+func init() {
+	rootCmd.AddCommand(volumesCmd)
+}
+
+func runVolumes(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	client, err := docker.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to connect to Docker: %w", err)
+	}
+	defer client.Close()
+
+	volumes, err := client.DetectComposeVolumes(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to detect volumes: %w", err)
+	}
+
+	mgr := snapshot.NewManager(client, cfg)
+	statuses, err := mgr.VolumeStatus(volumes)
+	if err != nil {
+		return fmt.Errorf("failed to read volume status: %w", err)
+	}
+
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(statuses)
+	}
+
+	if len(statuses) == 0 {
+		warn("⚠️  No Docker Compose volumes detected in current directory")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VOLUME\tTYPE\tSIZE\tCONTAINERS\tLAST SNAPSHOT\tCLEAN\tRESTORED FROM")
+	fmt.Fprintln(w, "------\t----\t----\t----------\t-------------\t-----\t-------------")
+	for _, st := range statuses {
+		size := st.SizeHuman
+		if size == "" {
+			size = "?"
+		}
+		last := "-"
+		if st.LastSnapshot != "" {
+			last = fmt.Sprintf("%s (%s)", st.LastSnapshot, st.SnapshotTime.Format("2006-01-02 15:04"))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			st.Volume.Name, st.Volume.DatastoreType, size, formatContainers(st.Containers), last,
+			formatClean(st.Clean), formatDrift(st))
+	}
+	return w.Flush()
+}
+
+// formatContainers renders "name (state)" pairs, or "-" if none mount the volume
+func formatContainers(containers []models.ContainerStatus) string {
+	if len(containers) == 0 {
+		return "-"
+	}
+	parts := make([]string, len(containers))
+	for i, c := range containers {
+		parts[i] = fmt.Sprintf("%s (%s)", c.Name, c.State)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatDrift renders the last restore and how far the volume has moved from it
+func formatDrift(st models.VolumeStatus) string {
+	if st.Restored == nil {
+		return "-"
+	}
+	switch {
+	case st.Drifted == nil:
+		return st.Restored.Snapshot
+	case !*st.Drifted:
+		return st.Restored.Snapshot + " (unchanged)"
+	}
+
+	sign := "+"
+	delta := st.SizeDelta
+	if delta < 0 {
+		sign, delta = "-", -delta
+	}
+	return fmt.Sprintf("%s (drifted, %s%s)", st.Restored.Snapshot, sign, models.FormatSize(delta))
+}
+
+// formatClean renders the clean heuristic for the table
+func formatClean(clean *bool) string {
+	switch {
+	case clean == nil:
+		return "-"
+	case *clean:
+		return "yes"
+	default:
+		return "modified"
+	}
+}
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/stackgen-cli/dataclean/internal/config"
+	"github.com/stackgen-cli/dataclean/internal/docker"
+	"github.com/stackgen-cli/dataclean/internal/models"
+	"github.com/stackgen-cli/dataclean/internal/snapshot"
+	"github.com/stackgen-cli/dataclean/internal/watch"
+)
+
+var (
+	watchPaths    []string
+	watchTags     []string
+	watchDebounce time.Duration
+	watchInterval time.Duration
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch --on-change <path>",
+	Short: "Snapshot automatically when watched files change",
+	Long: `Watch files and take a tagged snapshot whenever they change.
+
+Changes are debounced: a burst of edits produces one snapshot once the files
+have been quiet for --debounce. Snapshots are named watch-<timestamp>, tagged
+"watch" (plus any --tag), and subject to the retention policy.
+
+Examples:
+  dataclean watch --on-change ./migrations
+  dataclean watch --on-change ./migrations --on-change ./seeds --debounce 5s`,
+	Args: cobra.NoArgs,
+	RunE: runWatch,
+}
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+
+	watchCmd.Flags().StringSliceVar(&watchPaths, "on-change", nil, "Files or directories to watch")
+	watchCmd.Flags().StringSliceVarP(&watchTags, "tag", "t", nil, "Extra tags for automatic snapshots")
+	watchCmd.Flags().DurationVar(&watchDebounce, "debounce", 2*time.Second, "Quiet period before snapshotting")
+	watchCmd.Flags().DurationVar(&watchInterval, "interval", 500*time.Millisecond, "How often to check for changes")
+	watchCmd.MarkFlagRequired("on-change")
+}
+
+func runWatch(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := guardReadOnly(cfg); err != nil {
+		return err
+	}
+
+	client, err := docker.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to connect to Docker: %w", err)
+	}
+	defer client.Close()
+
+	volumes, err := client.DetectComposeVolumes(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to detect volumes: %w", err)
+	}
+	if len(volumes) == 0 {
+		return fmt.Errorf("no Docker Compose volumes detected in current directory")
+	}
+
+	if dryRun {
+		dryRunNote("would snapshot %d volume(s) when %s change", len(volumes), strings.Join(watchPaths, ", "))
+		return nil
+	}
+
+	ensureGitignored(cfg)
+	mgr := snapshot.NewManager(client, cfg)
+	tags := append([]string{"watch"}, watchTags...)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if !quiet {
+		color.Cyan("👀 Watching %s (Ctrl+C to stop)", strings.Join(watchPaths, ", "))
+	}
+
+	w := watch.New(watchPaths, watchInterval, watchDebounce)
+	return w.Run(ctx, func(changed []string) {
+		name := fmt.Sprintf("watch-%s", time.Now().Format("2006-01-02-150405"))
+		if !quiet {
+			fmt.Println()
+			color.Cyan("📸 %d file(s) changed, creating snapshot: %s", len(changed), name)
+			for _, p := range changed {
+				fmt.Printf("  • %s\n", p)
+			}
+		}
+
+		opts := snapshot.CreateOptions{
+			Tags:        tags,
+			Description: fmt.Sprintf("Automatic snapshot after changes to: %s", strings.Join(changed, ", ")),
+		}
+		start := time.Now()
+		result, err := mgr.CreateWithOptions(name, volumes, opts)
+		var written int64
+		if result != nil {
+			written = result.SizeBytes
+		}
+		reportCompletion(cfg, "snapshot", name, start, written, err)
+		if err != nil {
+			// Keep watching; the next change gets another attempt
+			color.New(color.FgRed).Fprintf(os.Stderr, "❌ Failed to create snapshot: %v\n", err)
+			return
+		}
+		reportSnapshotEvent(cfg, models.SnapshotCreated, result)
+		mirrorSnapshot(os.Stdout, cfg, result)
+		if !quiet {
+			color.Green("✅ Snapshot created: %s (%s)", result.Name, result.SizeHuman)
+		}
+	})
+}
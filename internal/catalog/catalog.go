@@ -0,0 +1,170 @@
+// Package catalog renders the snapshot catalogue as Markdown or CSV, for
+// pasting into a wiki page or a spreadsheet.
+package catalog
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+// Catalog is everything a report covers
+type Catalog struct {
+	Project   string
+	Generated time.Time
+	Snapshots []models.Snapshot  // in the order they are listed
+	Sizes     *models.SizeReport // live volume sizes; nil when Docker isn't reachable
+}
+
+// Rollup totals one datastore type across snapshots and live volumes
+type Rollup struct {
+	Type          models.DatastoreType
+	Archives      int   // volume archives across all snapshots
+	ArchivedBytes int64 // their total size
+	LiveVolumes   int
+	LiveBytes     int64
+}
+
+// Rollups returns per-datastore totals, sorted by datastore type
+func (c *Catalog) Rollups() []Rollup {
+	byType := make(map[models.DatastoreType]*Rollup)
+	get := func(t models.DatastoreType) *Rollup {
+		if byType[t] == nil {
+			byType[t] = &Rollup{Type: t}
+		}
+		return byType[t]
+	}
+
+	for _, s := range c.Snapshots {
+		for _, v := range s.Volumes {
+			r := get(v.DatastoreType)
+			r.Archives++
+			r.ArchivedBytes += v.SizeBytes
+		}
+	}
+	if c.Sizes != nil {
+		for _, info := range c.Sizes.ByDatastore {
+			r := get(info.Type)
+			r.LiveVolumes += info.Count
+			r.LiveBytes += info.TotalSize
+		}
+	}
+
+	rollups := make([]Rollup, 0, len(byType))
+	for _, r := range byType {
+		rollups = append(rollups, *r)
+	}
+	sort.Slice(rollups, func(i, j int) bool { return rollups[i].Type < rollups[j].Type })
+	return rollups
+}
+
+// totalSize adds up the size of every snapshot
+func (c *Catalog) totalSize() int64 {
+	var total int64
+	for _, s := range c.Snapshots {
+		total += s.SizeBytes
+	}
+	return total
+}
+
+// WriteMarkdown writes the snapshot table and the per-datastore rollup as Markdown
+func WriteMarkdown(w io.Writer, c *Catalog) error {
+	title := "Snapshots"
+	if c.Project != "" {
+		title = "Snapshots: " + c.Project
+	}
+	fmt.Fprintf(w, "# %s\n\n", mdEscape(title))
+	fmt.Fprintf(w, "Generated %s by dataclean. %d snapshot(s), %s in total.\n\n",
+		c.Generated.Format("2006-01-02 15:04"), len(c.Snapshots), models.FormatSize(c.totalSize()))
+
+	if len(c.Snapshots) > 0 {
+		fmt.Fprintln(w, "| Name | Created | Size | Tags | Volumes | Description |")
+		fmt.Fprintln(w, "|------|---------|------|------|---------|-------------|")
+		for _, s := range c.Snapshots {
+			fmt.Fprintf(w, "| %s | %s | %s | %s | %s | %s |\n",
+				mdEscape(s.Name), s.Timestamp.Format("2006-01-02 15:04"), models.FormatSize(s.SizeBytes),
+				mdEscape(strings.Join(s.Tags, ", ")), mdEscape(strings.Join(volumeNames(s), ", ")),
+				mdEscape(s.Description))
+		}
+		fmt.Fprintln(w)
+	}
+
+	rollups := c.Rollups()
+	if len(rollups) == 0 {
+		return nil
+	}
+	fmt.Fprintln(w, "## Storage by datastore")
+	fmt.Fprintln(w)
+	if c.Sizes != nil {
+		fmt.Fprintln(w, "| Datastore | Archives | Archived size | Live volumes | Live size |")
+		fmt.Fprintln(w, "|-----------|----------|---------------|--------------|-----------|")
+	} else {
+		fmt.Fprintln(w, "| Datastore | Archives | Archived size |")
+		fmt.Fprintln(w, "|-----------|----------|---------------|")
+	}
+	for _, r := range rollups {
+		name, _ := models.GetDatastoreInfo(r.Type)
+		row := fmt.Sprintf("| %s | %d | %s |", name, r.Archives, models.FormatSize(r.ArchivedBytes))
+		if c.Sizes != nil {
+			row += fmt.Sprintf(" %d | %s |", r.LiveVolumes, models.FormatSize(r.LiveBytes))
+		}
+		fmt.Fprintln(w, row)
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+// WriteSnapshotsCSV writes one row per snapshot, with sizes in bytes and
+// lists separated by semicolons
+func WriteSnapshotsCSV(w io.Writer, c *Catalog) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"name", "created", "size_bytes", "tags", "volumes", "description"})
+	for _, s := range c.Snapshots {
+		cw.Write([]string{
+			s.Name,
+			s.Timestamp.Format(time.RFC3339),
+			strconv.FormatInt(s.SizeBytes, 10),
+			strings.Join(s.Tags, ";"),
+			strings.Join(volumeNames(s), ";"),
+			s.Description,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteRollupsCSV writes one row per datastore type. Live columns are empty
+// when Docker wasn't reachable.
+func WriteRollupsCSV(w io.Writer, c *Catalog) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"datastore", "archives", "archived_bytes", "live_volumes", "live_bytes"})
+	for _, r := range c.Rollups() {
+		live := []string{"", ""}
+		if c.Sizes != nil {
+			live = []string{strconv.Itoa(r.LiveVolumes), strconv.FormatInt(r.LiveBytes, 10)}
+		}
+		cw.Write(append([]string{string(r.Type), strconv.Itoa(r.Archives), strconv.FormatInt(r.ArchivedBytes, 10)}, live...))
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func volumeNames(s models.Snapshot) []string {
+	names := make([]string, len(s.Volumes))
+	for i, v := range s.Volumes {
+		names[i] = v.Name
+	}
+	return names
+}
+
+// mdEscape keeps text from breaking out of a Markdown table cell
+func mdEscape(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,103 @@
+package catalog
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+func testCatalog() *Catalog {
+	return &Catalog{
+		Project:   "shop",
+		Generated: time.Date(2024, 5, 1, 14, 30, 0, 0, time.UTC),
+		Snapshots: []models.Snapshot{
+			{
+				Name:        "seeded",
+				Timestamp:   time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC),
+				SizeBytes:   3 << 20,
+				Tags:        []string{"shared", "seed"},
+				Description: "Seed data | v2\nwith orders",
+				Volumes: []models.Volume{
+					{Name: "shop_pgdata", DatastoreType: models.DatastorePostgres, SizeBytes: 2 << 20},
+					{Name: "shop_redis", DatastoreType: models.DatastoreRedis, SizeBytes: 1 << 20},
+				},
+			},
+			{
+				Name:      "empty",
+				Timestamp: time.Date(2024, 4, 30, 9, 0, 0, 0, time.UTC),
+				SizeBytes: 1 << 10,
+				Volumes:   []models.Volume{{Name: "shop_pgdata", DatastoreType: models.DatastorePostgres, SizeBytes: 1 << 10}},
+			},
+		},
+	}
+}
+
+func TestRollups(t *testing.T) {
+	c := testCatalog()
+	c.Sizes = &models.SizeReport{ByDatastore: map[string]models.DatastoreSizeInfo{
+		"postgres": {Type: models.DatastorePostgres, TotalSize: 50 << 20, Count: 1},
+	}}
+
+	rollups := c.Rollups()
+	if len(rollups) != 2 || rollups[0].Type != models.DatastorePostgres || rollups[1].Type != models.DatastoreRedis {
+		t.Fatalf("Rollups() = %+v, want postgres then redis", rollups)
+	}
+	pg := rollups[0]
+	if pg.Archives != 2 || pg.ArchivedBytes != 2<<20+1<<10 || pg.LiveVolumes != 1 || pg.LiveBytes != 50<<20 {
+		t.Errorf("postgres rollup = %+v", pg)
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteMarkdown(&buf, testCatalog()); err != nil {
+		t.Fatalf("WriteMarkdown() failed: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"# Snapshots: shop",
+		"2 snapshot(s), 3.0 MB in total",
+		"| seeded | 2024-05-01 09:00 | 3.0 MB | shared, seed | shop_pgdata, shop_redis | Seed data \\| v2 with orders |",
+		"| PostgreSQL | 2 | 2.0 MB |",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+	// No live columns without Docker
+	if strings.Contains(out, "Live size") {
+		t.Errorf("live sizes shown without a size report:\n%s", out)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteSnapshotsCSV(&buf, testCatalog()); err != nil {
+		t.Fatalf("WriteSnapshotsCSV() failed: %v", err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("output is not valid CSV: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("got %d rows, want header + 2", len(rows))
+	}
+	want := []string{"seeded", "2024-05-01T09:00:00Z", "3145728", "shared;seed", "shop_pgdata;shop_redis", "Seed data | v2\nwith orders"}
+	for i := range want {
+		if rows[1][i] != want[i] {
+			t.Errorf("column %s = %q, want %q", rows[0][i], rows[1][i], want[i])
+		}
+	}
+
+	buf.Reset()
+	WriteRollupsCSV(&buf, testCatalog())
+	rows, _ = csv.NewReader(&buf).ReadAll()
+	if len(rows) != 3 || rows[1][0] != "postgres" || rows[1][1] != "2" || rows[1][3] != "" {
+		t.Errorf("rollup rows = %v", rows)
+	}
+}
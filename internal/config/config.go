@@ -1,15 +1,36 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 
 	"github.com/stackgen-cli/dataclean/internal/models"
+	"github.com/stackgen-cli/dataclean/internal/notify"
 )
 
 // Load loads configuration from file or returns defaults with auto-detection
 func Load(cfgFile string) (*models.Config, error) {
+	cfg, err := load(cfgFile)
+	if err != nil {
+		return nil, err
+	}
+	if readOnlyEnv() {
+		cfg.ReadOnly = true
+	}
+	return cfg, nil
+}
+
+// defaultFiles are the config files looked for in the current directory
+var defaultFiles = []string{".dataclean.yaml", ".dataclean.yml"}
+
+func load(cfgFile string) (*models.Config, error) {
 	cfg := models.DefaultConfig()
 
 	// Try to load from specified file
@@ -18,20 +39,19 @@ func Load(cfgFile string) (*models.Config, error) {
 		if err != nil {
 			return nil, err
 		}
-		if err := yaml.Unmarshal(data, cfg); err != nil {
+		if err := decodeConfig(data, cfg); err != nil {
 			return nil, err
 		}
-		return cfg, nil
+		return cfg, finishLoad(cfg)
 	}
 
 	// Try default config file
-	defaultFiles := []string{".dataclean.yaml", ".dataclean.yml"}
 	for _, file := range defaultFiles {
 		if data, err := os.ReadFile(file); err == nil {
-			if err := yaml.Unmarshal(data, cfg); err != nil {
+			if err := decodeConfig(data, cfg); err != nil {
 				return nil, err
 			}
-			return cfg, nil
+			return cfg, finishLoad(cfg)
 		}
 	}
 
@@ -39,6 +59,384 @@ func Load(cfgFile string) (*models.Config, error) {
 	return cfg, nil
 }
 
+// readOnlyEnv reports whether DATACLEAN_READONLY asks for read-only mode
+func readOnlyEnv() bool {
+	switch strings.ToLower(os.Getenv("DATACLEAN_READONLY")) {
+	case "", "0", "false", "no":
+		return false
+	}
+	return true
+}
+
+// finishLoad registers custom datastore types from config and checks the
+// settings that can't be validated by unmarshalling alone
+func finishLoad(cfg *models.Config) error {
+	if err := models.RegisterDatastores(cfg.DatastoreTypes); err != nil {
+		return fmt.Errorf("invalid datastore_types: %w", err)
+	}
+	if err := validateStorageRules(cfg.StorageRules); err != nil {
+		return err
+	}
+	if err := validateExporterRules(cfg.Exporters); err != nil {
+		return err
+	}
+	if cfg.PluginsDir == "" {
+		cfg.PluginsDir = filepath.Join(userConfigDir(), "plugins")
+	} else {
+		cfg.PluginsDir = expandHome(cfg.PluginsDir)
+	}
+	cfg.EventLog = expandHome(cfg.EventLog)
+	for _, pattern := range cfg.AOFCapture.Volumes {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("aof_capture: invalid volume pattern %q: %w", pattern, err)
+		}
+	}
+	for i, p := range cfg.Policies {
+		if p.Volume == "" {
+			return fmt.Errorf("policies[%d]: volume pattern is required", i)
+		}
+		if _, err := path.Match(p.Volume, ""); err != nil {
+			return fmt.Errorf("policies[%d]: invalid volume pattern %q: %w", i, p.Volume, err)
+		}
+	}
+	for pattern, query := range cfg.Validate {
+		if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+			return fmt.Errorf("validate: invalid volume pattern %q", pattern)
+		}
+		if strings.TrimSpace(query) == "" {
+			return fmt.Errorf("validate: empty query for %s", pattern)
+		}
+	}
+	switch cfg.Quiesce {
+	case "", models.QuiesceContainer, models.QuiesceProject:
+	default:
+		return fmt.Errorf("quiesce: unknown mode %q (valid: %s, %s)", cfg.Quiesce, models.QuiesceContainer, models.QuiesceProject)
+	}
+	for service, command := range cfg.PostRestoreExec {
+		if service == "" || strings.TrimSpace(command) == "" {
+			return fmt.Errorf("post_restore_exec: a service and a command are required (got %q: %q)", service, command)
+		}
+	}
+	for _, pattern := range cfg.IncludeVolumes {
+		if err := models.CheckVolumePattern(pattern); err != nil {
+			return fmt.Errorf("include_volumes: %w", err)
+		}
+	}
+	for _, pattern := range cfg.ExcludeVolumes {
+		if err := models.CheckVolumePattern(pattern); err != nil {
+			return fmt.Errorf("exclude_volumes: %w", err)
+		}
+	}
+	if slices.Contains(cfg.IncludeServices, "") {
+		return fmt.Errorf("include_services: empty service name")
+	}
+	if cfg.DockerRetry.Attempts < 0 || cfg.DockerRetry.Delay < 0 {
+		return fmt.Errorf("docker_retry: attempts and delay can't be negative")
+	}
+	switch cfg.ForeignContainers {
+	case "", models.ForeignStop, models.ForeignRefuse:
+	default:
+		return fmt.Errorf("foreign_containers: unknown mode %q (valid: %s, %s)", cfg.ForeignContainers, models.ForeignStop, models.ForeignRefuse)
+	}
+	if err := resolveStore(cfg); err != nil {
+		return err
+	}
+	if cfg.NamePattern != "" {
+		if _, err := regexp.Compile(cfg.NamePattern); err != nil {
+			return fmt.Errorf("name_pattern: %w", err)
+		}
+	}
+	if cfg.RestoreParallelism < 0 {
+		return fmt.Errorf("restore_parallelism: must not be negative")
+	}
+	if err := validateRestoreAfter(cfg.RestoreAfter); err != nil {
+		return err
+	}
+	known := models.AvailableDatastores()
+	for dt, timeout := range cfg.StopTimeouts {
+		if !slices.Contains(known, dt) {
+			return fmt.Errorf("stop_timeouts: unknown datastore type %q", dt)
+		}
+		if timeout < 0 {
+			return fmt.Errorf("stop_timeouts: %s must not be negative", dt)
+		}
+	}
+	if err := resolveMirror(cfg); err != nil {
+		return err
+	}
+	if err := validateRemote(cfg.Remote); err != nil {
+		return err
+	}
+	if err := validateStacks(cfg.Stacks); err != nil {
+		return err
+	}
+	if err := validateVolumeAliases(cfg.VolumeAliases); err != nil {
+		return err
+	}
+	for _, tool := range cfg.MCP.AllowTools {
+		if !slices.Contains(models.MCPDestructiveTools, tool) {
+			return fmt.Errorf("mcp.allow_tools: unknown tool %q (valid: %s)", tool, strings.Join(models.MCPDestructiveTools, ", "))
+		}
+	}
+	return validateSnapshotWebhooks(cfg.Notifications.SnapshotWebhooks)
+}
+
+// projectName keeps global store namespaces usable as directory names
+var projectName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// resolveStore points SnapshotDir at the project's namespace in the global
+// store when store is global
+func resolveStore(cfg *models.Config) error {
+	switch cfg.Store {
+	case "", models.StoreLocal:
+		return nil
+	case models.StoreGlobal:
+	default:
+		return fmt.Errorf("store: unknown store %q (valid: %s, %s)", cfg.Store, models.StoreLocal, models.StoreGlobal)
+	}
+	if cfg.SnapshotDir != models.DefaultConfig().SnapshotDir {
+		return fmt.Errorf("store: snapshot_dir can't be set with the global store (use store_dir)")
+	}
+
+	project := cfg.Project
+	if project == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("store: can't tell the project name: %w", err)
+		}
+		project = filepath.Base(cwd)
+	}
+	if !projectName.MatchString(project) {
+		return fmt.Errorf("store: invalid project name %q (letters, digits, '.', '_' and '-'; set project in the config)", project)
+	}
+
+	root := cfg.StoreDir
+	if root == "" {
+		root = defaultStoreDir()
+	} else {
+		root = expandHome(root)
+	}
+	if root == "" {
+		return fmt.Errorf("store: no home directory for the global store (set store_dir)")
+	}
+	cfg.Project = project
+	cfg.SnapshotDir = filepath.Join(root, project)
+	return nil
+}
+
+// validateRemote checks the remote's retention
+func validateRemote(r models.RemoteConfig) error {
+	if r.Keep < 0 || r.RetentionDays < 0 {
+		return fmt.Errorf("remote: keep and retention_days must not be negative")
+	}
+	for _, pattern := range r.Pin {
+		if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+			return fmt.Errorf("remote.pin: invalid pattern %q", pattern)
+		}
+	}
+	return nil
+}
+
+// resolveMirror checks the mirror policy and names its channel after the
+// project unless one is set, so projects mirroring to the same target don't
+// prune each other's copies
+func resolveMirror(cfg *models.Config) error {
+	m := &cfg.Mirror
+	if m.Keep < 0 || m.RetentionDays < 0 {
+		return fmt.Errorf("mirror: keep and retention_days must not be negative")
+	}
+	if m.Target == "" || m.Channel != "" {
+		if m.Channel != "" && !projectName.MatchString(m.Channel) {
+			return fmt.Errorf("mirror.channel: invalid channel name %q (letters, digits, '.', '_' and '-')", m.Channel)
+		}
+		return nil
+	}
+
+	project := cfg.Project
+	if project == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("mirror: can't tell the project name: %w", err)
+		}
+		project = filepath.Base(cwd)
+	}
+	if !projectName.MatchString(project) {
+		return fmt.Errorf("mirror: invalid project name %q for the channel (set mirror.channel)", project)
+	}
+	m.Channel = "mirror-" + project
+	return nil
+}
+
+// composeProject is what docker compose accepts as a project name
+var composeProject = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+func validateStacks(stacks []models.Stack) error {
+	seen := make(map[string]bool)
+	for i := range stacks {
+		s := &stacks[i]
+		switch {
+		case !projectName.MatchString(s.Name):
+			return fmt.Errorf("stacks[%d]: invalid name %q (letters, digits, '.', '_' and '-')", i, s.Name)
+		case seen[s.Name]:
+			return fmt.Errorf("stacks[%d]: duplicate name %q", i, s.Name)
+		case s.Path == "":
+			return fmt.Errorf("stacks[%d]: path is required", i)
+		case s.Project != "" && !composeProject.MatchString(s.Project):
+			return fmt.Errorf("stacks[%d]: invalid project name %q (lowercase letters, digits, '_' and '-')", i, s.Project)
+		}
+		seen[s.Name] = true
+		s.Path = expandHome(s.Path)
+	}
+	return nil
+}
+
+// validateVolumeAliases checks that every alias is a usable name and that no
+// mount has two
+func validateVolumeAliases(aliases []models.VolumeAlias) error {
+	seen := make(map[string]bool)
+	mounts := make(map[string]bool)
+	for i, a := range aliases {
+		mount := a.Service + ":" + a.Target
+		switch {
+		case !composeProject.MatchString(a.Alias):
+			return fmt.Errorf("volume_aliases[%d]: invalid alias %q (lowercase letters, digits, '_' and '-')", i, a.Alias)
+		case seen[a.Alias]:
+			return fmt.Errorf("volume_aliases[%d]: duplicate alias %q", i, a.Alias)
+		case a.Service == "" || !path.IsAbs(a.Target):
+			return fmt.Errorf("volume_aliases[%d]: service and an absolute target path are required", i)
+		case mounts[mount]:
+			return fmt.Errorf("volume_aliases[%d]: %s already has an alias", i, mount)
+		}
+		seen[a.Alias] = true
+		mounts[mount] = true
+	}
+	return nil
+}
+
+// defaultStoreDir follows the XDG base directory spec
+func defaultStoreDir() string {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, "dataclean")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".local", "share", "dataclean")
+}
+
+// validateSnapshotWebhooks catches template and event typos at load time
+// rather than when the first event is silently not sent
+func validateSnapshotWebhooks(hooks []models.SnapshotWebhook) error {
+	for i, h := range hooks {
+		if h.URL == "" {
+			return fmt.Errorf("notifications.snapshot_webhooks[%d]: url is required", i)
+		}
+		for _, e := range h.Events {
+			if !slices.Contains(models.SnapshotActions, e) {
+				return fmt.Errorf("notifications.snapshot_webhooks[%d]: unknown event %q (valid: %s)",
+					i, e, strings.Join(models.SnapshotActions, ", "))
+			}
+		}
+		if _, err := notify.ParseTemplate(h.Template); err != nil {
+			return fmt.Errorf("notifications.snapshot_webhooks[%d]: invalid template: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// validateRestoreAfter rejects unknown datastore types and orderings that
+// can never be satisfied
+func validateRestoreAfter(after map[models.DatastoreType][]models.DatastoreType) error {
+	known := models.AvailableDatastores()
+	for dt, deps := range after {
+		for _, t := range append([]models.DatastoreType{dt}, deps...) {
+			if !slices.Contains(known, t) {
+				return fmt.Errorf("restore_after: unknown datastore type %q", t)
+			}
+		}
+	}
+
+	// Depth-first search for a type that is (indirectly) ordered after itself
+	const visiting, visited = 1, 2
+	state := make(map[models.DatastoreType]int)
+	var visit func(dt models.DatastoreType, path []models.DatastoreType) error
+	visit = func(dt models.DatastoreType, path []models.DatastoreType) error {
+		path = append(path, dt)
+		switch state[dt] {
+		case visiting:
+			names := make([]string, len(path))
+			for i, t := range path {
+				names[i] = string(t)
+			}
+			return fmt.Errorf("restore_after: cycle %s", strings.Join(names, " -> "))
+		case visited:
+			return nil
+		}
+		state[dt] = visiting
+		for _, dep := range after[dt] {
+			if err := visit(dep, path); err != nil {
+				return err
+			}
+		}
+		state[dt] = visited
+		return nil
+	}
+	types := make([]models.DatastoreType, 0, len(after))
+	for dt := range after {
+		types = append(types, dt)
+	}
+	slices.Sort(types)
+	for _, dt := range types {
+		if err := visit(dt, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateStorageRules rejects rules that would match nothing or everything by accident
+func validateStorageRules(rules []models.StorageRule) error {
+	for i, r := range rules {
+		switch {
+		case r.Dir == "":
+			return fmt.Errorf("storage_rules[%d]: dir is required", i)
+		case r.Volume == "" && r.Type == "":
+			return fmt.Errorf("storage_rules[%d]: needs a volume pattern or a type", i)
+		}
+		if _, err := path.Match(r.Volume, ""); err != nil {
+			return fmt.Errorf("storage_rules[%d]: invalid volume pattern %q: %w", i, r.Volume, err)
+		}
+	}
+	return nil
+}
+
+func validateExporterRules(rules []models.ExporterRule) error {
+	for i, r := range rules {
+		switch {
+		case r.Plugin == "":
+			return fmt.Errorf("exporters[%d]: plugin is required", i)
+		case !projectName.MatchString(r.Plugin):
+			return fmt.Errorf("exporters[%d]: invalid plugin name %q", i, r.Plugin)
+		case r.Volume == "" && r.Type == "":
+			return fmt.Errorf("exporters[%d]: needs a volume pattern or a type", i)
+		}
+		if _, err := path.Match(r.Volume, ""); err != nil {
+			return fmt.Errorf("exporters[%d]: invalid volume pattern %q: %w", i, r.Volume, err)
+		}
+	}
+	return nil
+}
+
+// userConfigDir is dataclean's directory under $XDG_CONFIG_HOME (default ~/.config)
+func userConfigDir() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		dir = expandHome("~/.config")
+	}
+	return filepath.Join(dir, "dataclean")
+}
+
 // Save writes configuration to a file
 func Save(cfg *models.Config, path string) error {
 	data, err := yaml.Marshal(cfg)
@@ -47,3 +445,69 @@ func Save(cfg *models.Config, path string) error {
 	}
 	return os.WriteFile(path, data, 0644)
 }
+
+// Path returns the config file Load reads: cfgFile when given, else the
+// first default file that exists, else the one init would write
+func Path(cfgFile string) string {
+	if cfgFile != "" {
+		return cfgFile
+	}
+	for _, file := range defaultFiles {
+		if _, err := os.Stat(file); err == nil {
+			return file
+		}
+	}
+	return defaultFiles[0]
+}
+
+// SetKey replaces one top-level key of a config file with value, or removes
+// it when value is nil, leaving the rest of the file, comments included, as
+// it is. A missing file is created.
+func SetKey(file, key string, value any) error {
+	data, err := os.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s: not a YAML mapping", file)
+	}
+
+	var node *yaml.Node
+	if value != nil {
+		node = &yaml.Node{}
+		if err := node.Encode(value); err != nil {
+			return err
+		}
+	}
+	at := -1
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == key {
+			at = i
+			break
+		}
+	}
+	switch {
+	case at >= 0 && node != nil:
+		root.Content[at+1] = node
+	case at >= 0:
+		root.Content = slices.Delete(root.Content, at, at+2)
+	case node != nil:
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, node)
+	}
+
+	var out strings.Builder
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+	return os.WriteFile(file, []byte(out.String()), 0644)
+}
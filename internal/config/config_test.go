@@ -4,7 +4,9 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stackgen-cli/dataclean/internal/models"
 )
@@ -157,3 +159,841 @@ func TestLoadConfig_InvalidYAML(t *testing.T) {
 		t.Error("expected error for invalid YAML, got nil")
 	}
 }
+
+func TestSaveConfig_RoundTrip(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "dataclean-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	cfg := models.DefaultConfig()
+	cfg.BackupBeforeRestore = false
+	cfg.RetentionDays = 14
+	cfg.ExcludeVolumes = []string{"cache"}
+	cfg.Daemon = &models.DaemonIdentity{ID: "7TRN:IPZB:QYBB", Name: "dev-laptop"}
+
+	configPath := filepath.Join(tmpDir, ".dataclean.yaml")
+	if err := Save(cfg, configPath); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+
+	loaded, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	if loaded.BackupBeforeRestore {
+		t.Error("BackupBeforeRestore = true after round-trip, want false")
+	}
+	if loaded.RetentionDays != 14 {
+		t.Errorf("RetentionDays = %d, want 14", loaded.RetentionDays)
+	}
+	if len(loaded.ExcludeVolumes) != 1 || loaded.ExcludeVolumes[0] != "cache" {
+		t.Errorf("ExcludeVolumes = %v, want [cache]", loaded.ExcludeVolumes)
+	}
+	if loaded.Daemon == nil || *loaded.Daemon != *cfg.Daemon {
+		t.Errorf("Daemon = %v, want %v", loaded.Daemon, cfg.Daemon)
+	}
+}
+
+func TestLoadConfig_Notifications(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "dataclean-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	configContent := `
+notifications:
+  desktop: true
+  min_duration: 45s
+  slack:
+    - https://hooks.slack.com/services/T000/B000/XXX
+`
+	configPath := filepath.Join(tmpDir, "notify.yaml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	if !cfg.Notifications.Desktop {
+		t.Error("Notifications.Desktop should be true")
+	}
+	if cfg.Notifications.MinDuration != 45*time.Second {
+		t.Errorf("MinDuration = %v, want 45s", cfg.Notifications.MinDuration)
+	}
+	if len(cfg.Notifications.Slack) != 1 {
+		t.Errorf("Slack len = %d, want 1", len(cfg.Notifications.Slack))
+	}
+}
+
+func TestLoadConfig_DatastoreTypes(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "dataclean-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	configContent := `
+health_timeout: 2m
+datastore_types:
+  - name: elastic-internal
+    display_name: Internal Search
+    images: [mycorp/search]
+    health: curl -fs localhost:9200/_cluster/health
+`
+	configPath := filepath.Join(tmpDir, "types.yaml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if cfg.HealthTimeout != 2*time.Minute {
+		t.Errorf("HealthTimeout = %v, want 2m", cfg.HealthTimeout)
+	}
+	if len(cfg.DatastoreTypes) != 1 || cfg.DatastoreTypes[0].Health == "" {
+		t.Fatalf("DatastoreTypes = %+v", cfg.DatastoreTypes)
+	}
+	if name, _ := models.GetDatastoreInfo("elastic-internal"); name != "Internal Search" {
+		t.Errorf("custom type not registered, got display name %q", name)
+	}
+
+	// Built-in names can't be redefined
+	os.WriteFile(configPath, []byte("datastore_types:\n  - name: postgres\n    images: [pg]\n"), 0644)
+	if _, err := Load(configPath); err == nil {
+		t.Error("expected error redefining a built-in type")
+	}
+}
+
+func TestLoadConfig_StorageRules(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "storage.yaml")
+
+	configContent := `
+storage_rules:
+  - type: elasticsearch
+    dir: /mnt/external/dataclean
+  - volume: "*_uploads"
+    dir: /srv/bulk
+`
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	tests := []struct {
+		vol  models.Volume
+		want string
+	}{
+		{models.Volume{Name: "app_esdata", DatastoreType: models.DatastoreElastic}, "/mnt/external/dataclean"},
+		{models.Volume{Name: "app_uploads", DatastoreType: models.DatastoreGeneric}, "/srv/bulk"},
+		{models.Volume{Name: "app_pgdata", DatastoreType: models.DatastorePostgres}, ""},
+	}
+	for _, tt := range tests {
+		if got := cfg.StorageDir(tt.vol); got != tt.want {
+			t.Errorf("StorageDir(%s) = %q, want %q", tt.vol.Name, got, tt.want)
+		}
+	}
+
+	// A rule without a match condition would swallow every volume
+	os.WriteFile(configPath, []byte("storage_rules:\n  - dir: /mnt/external\n"), 0644)
+	if _, err := Load(configPath); err == nil {
+		t.Error("expected error for rule without volume or type")
+	}
+}
+
+func TestLoadConfig_AOFCapture(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "aof.yaml")
+
+	os.WriteFile(configPath, []byte("aof_capture:\n  volumes: [cache]\n  keep: 6\n"), 0644)
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if cfg.AOFCapture.Keep != 6 {
+		t.Errorf("Keep = %d, want 6", cfg.AOFCapture.Keep)
+	}
+	if !cfg.AOFCapture.Enabled(models.Volume{Name: "app_cache", ComposeName: "cache", DatastoreType: models.DatastoreRedis}) {
+		t.Error("expected capture for the Redis volume matched by compose name")
+	}
+	if cfg.AOFCapture.Enabled(models.Volume{Name: "app_cache", ComposeName: "cache", DatastoreType: models.DatastoreGeneric}) {
+		t.Error("expected no capture for a volume that isn't Redis")
+	}
+
+	os.WriteFile(configPath, []byte("aof_capture:\n  volumes: [\"[\"]\n"), 0644)
+	if _, err := Load(configPath); err == nil {
+		t.Error("expected error for invalid volume pattern")
+	}
+}
+
+func TestLoadConfig_SnapshotWebhooks(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "hooks.yaml")
+
+	tests := []struct {
+		yaml    string
+		wantErr bool
+	}{
+		{"notifications:\n  snapshot_webhooks:\n    - url: http://example.test\n      events: [create, prune]\n      template: '{{.Snapshot.Name}}'\n", false},
+		{"notifications:\n  snapshot_webhooks:\n    - events: [create]\n", true},
+		{"notifications:\n  snapshot_webhooks:\n    - url: http://example.test\n      events: [created]\n", true},
+		{"notifications:\n  snapshot_webhooks:\n    - url: http://example.test\n      template: '{{.Snapshot.Name'\n", true},
+	}
+	for _, tt := range tests {
+		os.WriteFile(configPath, []byte(tt.yaml), 0644)
+		_, err := Load(configPath)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Load(%q) error = %v, wantErr %v", tt.yaml, err, tt.wantErr)
+		}
+	}
+}
+
+func TestLoadConfig_Policies(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "policies.yaml")
+
+	os.WriteFile(configPath, []byte("policies:\n  - volume: \"*prod*\"\n    protected: true\n  - volume: scratch\n"), 0644)
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	tests := []struct {
+		vol  models.Volume
+		want bool
+	}{
+		{models.Volume{Name: "shop_prod_pgdata"}, true},
+		{models.Volume{Name: "app_db", ComposeName: "prod-db"}, true},
+		{models.Volume{Name: "app_scratch", ComposeName: "scratch"}, false}, // matched, not protected
+		{models.Volume{Name: "app_pgdata"}, false},
+	}
+	for _, tt := range tests {
+		if got := cfg.Protected(tt.vol); got != tt.want {
+			t.Errorf("Protected(%s) = %v, want %v", tt.vol.Name, got, tt.want)
+		}
+	}
+
+	os.WriteFile(configPath, []byte("policies:\n  - protected: true\n"), 0644)
+	if _, err := Load(configPath); err == nil {
+		t.Error("expected error for policy without volume pattern")
+	}
+}
+
+func TestLoadConfig_MCPAllowTools(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "mcp.yaml")
+
+	os.WriteFile(configPath, []byte("mcp:\n  allow_tools: [restore_snapshot]\n"), 0644)
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if !cfg.MCP.Allows(models.MCPRestoreTool) || cfg.MCP.Allows(models.MCPDeleteTool) {
+		t.Errorf("MCP.AllowTools = %v, want only %s allowed", cfg.MCP.AllowTools, models.MCPRestoreTool)
+	}
+
+	os.WriteFile(configPath, []byte("mcp:\n  allow_tools: [drop_database]\n"), 0644)
+	if _, err := Load(configPath); err == nil {
+		t.Error("expected error for unknown MCP tool")
+	}
+}
+
+func TestLoadConfig_Validate(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "validate.yaml")
+
+	os.WriteFile(configPath, []byte("validate:\n  pgdata: SELECT count(*) FROM users\n  \"*\": SELECT 1\n"), 0644)
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	got := cfg.ValidationQueries(models.Volume{Name: "app_pgdata", ComposeName: "pgdata"})
+	if len(got) != 2 || got[0] != "SELECT 1" || got[1] != "SELECT count(*) FROM users" {
+		t.Errorf("ValidationQueries(pgdata) = %v, want both queries in pattern order", got)
+	}
+	if got := cfg.ValidationQueries(models.Volume{Name: "app_redis"}); len(got) != 1 {
+		t.Errorf("ValidationQueries(redis) = %v, want only the wildcard query", got)
+	}
+
+	os.WriteFile(configPath, []byte("validate:\n  pgdata: \"\"\n"), 0644)
+	if _, err := Load(configPath); err == nil {
+		t.Error("expected error for empty validation query")
+	}
+}
+
+func TestLoadConfig_Quiesce(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "quiesce.yaml")
+
+	os.WriteFile(configPath, []byte("quiesce: project\n"), 0644)
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if cfg.Quiesce != models.QuiesceProject {
+		t.Errorf("Quiesce = %q, want %q", cfg.Quiesce, models.QuiesceProject)
+	}
+
+	os.WriteFile(configPath, []byte("quiesce: everything\n"), 0644)
+	if _, err := Load(configPath); err == nil {
+		t.Error("expected error for unknown quiesce mode")
+	}
+}
+
+func TestLoadConfig_ForeignContainers(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "foreign.yaml")
+
+	os.WriteFile(configPath, []byte("foreign_containers: refuse\n"), 0644)
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if cfg.ForeignContainers != models.ForeignRefuse {
+		t.Errorf("ForeignContainers = %q, want %q", cfg.ForeignContainers, models.ForeignRefuse)
+	}
+
+	os.WriteFile(configPath, []byte("foreign_containers: ignore\n"), 0644)
+	if _, err := Load(configPath); err == nil {
+		t.Error("expected error for unknown foreign_containers mode")
+	}
+}
+
+func TestLoadConfig_VolumePatterns(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "patterns.yaml")
+
+	os.WriteFile(configPath, []byte("exclude_volumes:\n  - '*_cache'\n  - 're:^tmp_'\n"), 0644)
+	if _, err := Load(configPath); err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	os.WriteFile(configPath, []byte("include_volumes:\n  - 're:(unclosed'\n"), 0644)
+	if _, err := Load(configPath); err == nil {
+		t.Error("expected error for an invalid regular expression in include_volumes")
+	}
+}
+
+func TestLoadConfig_DockerRetry(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "retry.yaml")
+
+	os.WriteFile(configPath, []byte("docker_retry:\n  attempts: 5\n  delay: 2s\n"), 0644)
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if cfg.DockerRetry.Attempts != 5 || cfg.DockerRetry.Delay != 2*time.Second {
+		t.Errorf("DockerRetry = %+v, want 5 attempts 2s apart", cfg.DockerRetry)
+	}
+
+	os.WriteFile(configPath, []byte("docker_retry:\n  attempts: -1\n"), 0644)
+	if _, err := Load(configPath); err == nil {
+		t.Error("expected error for negative docker_retry attempts")
+	}
+}
+
+func TestLoadConfig_PostRestoreExec(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "exec.yaml")
+
+	os.WriteFile(configPath, []byte("post_restore_exec:\n  api: php artisan cache:clear\n"), 0644)
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if cfg.PostRestoreExec["api"] != "php artisan cache:clear" {
+		t.Errorf("PostRestoreExec = %v", cfg.PostRestoreExec)
+	}
+
+	os.WriteFile(configPath, []byte("post_restore_exec:\n  api: ''\n"), 0644)
+	if _, err := Load(configPath); err == nil {
+		t.Error("expected error for an empty post_restore_exec command")
+	}
+}
+
+func TestLoadConfig_NamePattern(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "names.yaml")
+
+	os.WriteFile(configPath, []byte("name_pattern: '^(feature|release)-'\n"), 0644)
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if cfg.NamePattern != "^(feature|release)-" {
+		t.Errorf("NamePattern = %q", cfg.NamePattern)
+	}
+
+	os.WriteFile(configPath, []byte("name_pattern: '^(feature'\n"), 0644)
+	if _, err := Load(configPath); err == nil {
+		t.Error("expected error for an invalid name_pattern")
+	}
+}
+
+func TestLoadConfig_GlobalStore(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "store.yaml")
+	t.Setenv("XDG_DATA_HOME", filepath.Join(tmpDir, "data"))
+
+	os.WriteFile(configPath, []byte("store: global\nproject: shop\n"), 0644)
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if want := filepath.Join(tmpDir, "data", "dataclean", "shop"); cfg.SnapshotDir != want {
+		t.Errorf("SnapshotDir = %q, want %q", cfg.SnapshotDir, want)
+	}
+
+	os.WriteFile(configPath, []byte("store: global\nstore_dir: /srv/snapshots\nproject: shop\n"), 0644)
+	cfg, err = Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if want := filepath.Join("/srv/snapshots", "shop"); cfg.SnapshotDir != want {
+		t.Errorf("SnapshotDir = %q, want %q", cfg.SnapshotDir, want)
+	}
+
+	for _, bad := range []string{
+		"store: shared\n",
+		"store: global\nsnapshot_dir: /tmp/snaps\n",
+		"store: global\nproject: ../other\n",
+	} {
+		os.WriteFile(configPath, []byte(bad), 0644)
+		if _, err := Load(configPath); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestLoadConfig_ReadOnly(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "readonly.yaml")
+	os.WriteFile(configPath, []byte("retention_days: 7\n"), 0644)
+
+	t.Setenv("DATACLEAN_READONLY", "")
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if cfg.Writable() != nil {
+		t.Error("expected a writable config by default")
+	}
+
+	t.Setenv("DATACLEAN_READONLY", "1")
+	cfg, err = Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if cfg.Writable() == nil {
+		t.Error("expected DATACLEAN_READONLY to make the config read-only")
+	}
+
+	t.Setenv("DATACLEAN_READONLY", "false")
+	os.WriteFile(configPath, []byte("readonly: true\n"), 0644)
+	cfg, err = Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if !cfg.ReadOnly {
+		t.Error("expected readonly: true to be read")
+	}
+}
+
+func TestLoadConfig_References(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "refs.yaml")
+	os.WriteFile(filepath.Join(tmpDir, "slack-url"), []byte("https://hooks.slack.test/file\n"), 0644)
+	t.Setenv("TEST_WEBHOOK", "https://hooks.test/env")
+
+	// A stand-in for the age CLI that "decrypts" by printing a fixed map
+	bin := filepath.Join(tmpDir, "bin")
+	os.MkdirAll(bin, 0755)
+	os.WriteFile(filepath.Join(bin, "age"), []byte("#!/bin/sh\ncat >/dev/null\necho 'remote: /srv/team-snapshots'\n"), 0755)
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	os.WriteFile(configPath, []byte(`notifications:
+  webhooks:
+    - !env TEST_WEBHOOK
+    - !cmd echo https://hooks.test/cmd
+  slack:
+    - !file `+filepath.Join(tmpDir, "slack-url")+`
+remote:
+  url: !secret remote
+secrets: |
+  -----BEGIN AGE ENCRYPTED FILE-----
+  -----END AGE ENCRYPTED FILE-----
+`), 0644)
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	notifications, err := Notifications(cfg)
+	if err != nil {
+		t.Fatalf("Notifications() failed: %v", err)
+	}
+	if got := notifications.Webhooks; len(got) != 2 || got[0] != "https://hooks.test/env" || got[1] != "https://hooks.test/cmd" {
+		t.Errorf("Webhooks = %v", got)
+	}
+	if got := notifications.Slack; len(got) != 1 || got[0] != "https://hooks.slack.test/file" {
+		t.Errorf("Slack = %v", got)
+	}
+	remote, err := Remote(cfg)
+	if err != nil {
+		t.Fatalf("Remote() failed: %v", err)
+	}
+	if remote.URL != "/srv/team-snapshots" {
+		t.Errorf("Remote.URL = %q", remote.URL)
+	}
+
+	// Other settings are resolved as the config loads
+	os.WriteFile(configPath, []byte("event_log: !env TEST_WEBHOOK\n"), 0644)
+	if cfg, err := Load(configPath); err != nil || cfg.EventLog != "https://hooks.test/env" {
+		t.Errorf("Load() = %q, %v, want event_log resolved", cfg.EventLog, err)
+	}
+
+	// A broken reference in a lazy setting only fails where it is used
+	for _, bad := range []string{
+		"remote:\n  url: !env TEST_UNSET_VARIABLE\n",
+		"remote:\n  url: !cmd exit 3\n",
+		"remote:\n  url: !secret remote\n", // no secrets block
+	} {
+		os.WriteFile(configPath, []byte(bad), 0644)
+		cfg, err := Load(configPath)
+		if err != nil {
+			t.Errorf("Load(%q) failed: %v", bad, err)
+			continue
+		}
+		if _, err := Remote(cfg); err == nil || !strings.Contains(err.Error(), "remote.url") {
+			t.Errorf("Remote() error = %v for %q", err, bad)
+		}
+	}
+	os.WriteFile(configPath, []byte("event_log: !env TEST_UNSET_VARIABLE\n"), 0644)
+	if _, err := Load(configPath); err == nil {
+		t.Error("expected error for an unset variable outside the lazy settings")
+	}
+}
+
+func TestLoadConfig_LazyReferences(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "lazy.yaml")
+	marker := filepath.Join(tmpDir, "ran")
+
+	os.WriteFile(configPath, []byte(`remote:
+  url: !cmd touch `+marker+` && echo /srv/team
+  share_key: !env TEST_UNSET_SHARE_KEY
+mirror:
+  target: !cmd touch `+marker+` && echo /srv/mirror
+notifications:
+  snapshot_webhooks:
+    - url: !cmd touch `+marker+` && echo https://hooks.test/snapshots
+`), 0644)
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Fatal("loading the config ran a !cmd reference nothing used")
+	}
+
+	mirror, err := Mirror(cfg)
+	if err != nil || mirror.Target != "/srv/mirror" {
+		t.Errorf("Mirror() = %q, %v, want /srv/mirror", mirror.Target, err)
+	}
+	if _, err := os.Stat(marker); err != nil {
+		t.Error("Mirror() didn't run the !cmd reference")
+	}
+	if cfg.Mirror.Channel == "" {
+		t.Error("mirror channel wasn't derived for a referenced target")
+	}
+
+	if _, err := Remote(cfg); err == nil || !strings.Contains(err.Error(), "remote.share_key") {
+		t.Errorf("Remote() error = %v, want the unset share key", err)
+	}
+	t.Setenv("TEST_UNSET_SHARE_KEY", "correct horse battery staple")
+	if remote, err := Remote(cfg); err != nil || remote.URL != "/srv/team" || remote.ShareKey != "correct horse battery staple" {
+		t.Errorf("Remote() = %+v, %v", remote, err)
+	}
+
+	notifications, err := Notifications(cfg)
+	if err != nil {
+		t.Fatalf("Notifications() failed: %v", err)
+	}
+	if got := notifications.SnapshotWebhooks[0].URL; got != "https://hooks.test/snapshots" {
+		t.Errorf("SnapshotWebhooks[0].URL = %q", got)
+	}
+	if cfg.Notifications.SnapshotWebhooks[0].URL == notifications.SnapshotWebhooks[0].URL {
+		t.Error("Notifications() changed the loaded config")
+	}
+}
+
+func TestLoadConfig_RestoreAfter(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "order.yaml")
+
+	os.WriteFile(configPath, []byte("restore_after:\n  elasticsearch: [postgres]\n  redis: [elasticsearch, mysql]\nrestore_parallelism: 2\n"), 0644)
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if deps := cfg.RestoreAfter[models.DatastoreElastic]; len(deps) != 1 || deps[0] != models.DatastorePostgres {
+		t.Errorf("RestoreAfter[elasticsearch] = %v, want [postgres]", deps)
+	}
+
+	for _, bad := range []string{
+		"restore_after:\n  postgres: [cockroach]\n",
+		"restore_after:\n  postgres: [redis]\n  redis: [mysql]\n  mysql: [postgres]\n",
+		"restore_after:\n  postgres: [postgres]\n",
+		"restore_parallelism: -1\n",
+	} {
+		os.WriteFile(configPath, []byte(bad), 0644)
+		if _, err := Load(configPath); err == nil {
+			t.Errorf("expected error for config:\n%s", bad)
+		}
+	}
+}
+
+func TestLoadConfig_StopTimeouts(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "stop.yaml")
+
+	os.WriteFile(configPath, []byte("stop_timeouts:\n  postgres: 2m\n  redis: 5s\n"), 0644)
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if got := cfg.StopTimeouts[models.DatastorePostgres]; got != 2*time.Minute {
+		t.Errorf("StopTimeouts[postgres] = %v, want 2m", got)
+	}
+
+	for _, bad := range []string{
+		"stop_timeouts:\n  cockroach: 30s\n",
+		"stop_timeouts:\n  postgres: -1s\n",
+	} {
+		os.WriteFile(configPath, []byte(bad), 0644)
+		if _, err := Load(configPath); err == nil {
+			t.Errorf("expected error for config:\n%s", bad)
+		}
+	}
+}
+
+func TestLoadConfig_VolumeAliases(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "aliases.yaml")
+
+	os.WriteFile(configPath, []byte("volume_aliases:\n  - alias: db-extra\n    service: db\n    target: /var/lib/extra\n"), 0644)
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if len(cfg.VolumeAliases) != 1 || cfg.VolumeAliases[0].Alias != "db-extra" {
+		t.Errorf("VolumeAliases = %+v, want db-extra", cfg.VolumeAliases)
+	}
+
+	for _, bad := range []string{
+		"volume_aliases:\n  - alias: Bad Name\n    service: db\n    target: /data\n",
+		"volume_aliases:\n  - alias: a\n    service: db\n    target: data\n",
+		"volume_aliases:\n  - alias: a\n    service: db\n    target: /data\n  - alias: a\n    service: app\n    target: /data\n",
+		"volume_aliases:\n  - alias: a\n    service: db\n    target: /data\n  - alias: b\n    service: db\n    target: /data\n",
+	} {
+		os.WriteFile(configPath, []byte(bad), 0644)
+		if _, err := Load(configPath); err == nil {
+			t.Errorf("expected error for config:\n%s", bad)
+		}
+	}
+}
+
+func TestSetKey(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "edit.yaml")
+	os.WriteFile(configPath, []byte("# team settings\nretention_days: 7 # a week\nexclude_volumes:\n  - cache\n"), 0644)
+
+	aliases := []models.VolumeAlias{{Alias: "db-extra", Service: "db", Target: "/var/lib/extra"}}
+	if err := SetKey(configPath, "volume_aliases", aliases); err != nil {
+		t.Fatalf("SetKey() failed: %v", err)
+	}
+	data, _ := os.ReadFile(configPath)
+	for _, want := range []string{"# team settings", "retention_days: 7 # a week", "  - cache", "volume_aliases:", "alias: db-extra"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("config lost %q:\n%s", want, data)
+		}
+	}
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if len(cfg.VolumeAliases) != 1 || cfg.RetentionDays != 7 {
+		t.Errorf("reloaded config = %+v", cfg)
+	}
+
+	if err := SetKey(configPath, "volume_aliases", nil); err != nil {
+		t.Fatalf("SetKey(nil) failed: %v", err)
+	}
+	if data, _ := os.ReadFile(configPath); strings.Contains(string(data), "volume_aliases") {
+		t.Errorf("volume_aliases not removed:\n%s", data)
+	}
+
+	created := filepath.Join(t.TempDir(), "new.yaml")
+	if err := SetKey(created, "volume_aliases", aliases); err != nil {
+		t.Fatalf("SetKey() on a missing file failed: %v", err)
+	}
+	if cfg, err := Load(created); err != nil || len(cfg.VolumeAliases) != 1 {
+		t.Errorf("Load(new file) = %+v, %v", cfg, err)
+	}
+}
+
+func TestLoadConfig_Mirror(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "mirror.yaml")
+
+	os.WriteFile(configPath, []byte("project: shop\nmirror:\n  target: /mnt/backup\n  tags: [golden]\n  keep: 5\n"), 0644)
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if cfg.Mirror.Channel != "mirror-shop" || cfg.Mirror.Keep != 5 {
+		t.Errorf("Mirror = %+v, want channel mirror-shop and keep 5", cfg.Mirror)
+	}
+
+	os.WriteFile(configPath, []byte("mirror:\n  target: /mnt/backup\n  channel: ../escape\n"), 0644)
+	if _, err := Load(configPath); err == nil {
+		t.Error("expected error for an invalid mirror.channel")
+	}
+	os.WriteFile(configPath, []byte("mirror:\n  target: /mnt/backup\n  retention_days: -1\n"), 0644)
+	if _, err := Load(configPath); err == nil {
+		t.Error("expected error for negative retention_days")
+	}
+}
+
+func TestLoadConfig_RemoteRetention(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "remote.yaml")
+
+	os.WriteFile(configPath, []byte("remote:\n  url: /mnt/team\n  keep: 20\n  pin: [seeded, 'release-*', 'nightly@20240501T*']\n"), 0644)
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if !cfg.Remote.Pinned("release-2.1", "release-2.1@20240501T090000Z") {
+		t.Error("Pinned() = false for a pinned name or key")
+	}
+	if !cfg.Remote.Pinned("nightly", "nightly@20240501T020000Z") {
+		t.Error("Pinned() = false for a pinned key")
+	}
+	if cfg.Remote.Pinned("nightly", "nightly@20240502T020000Z") {
+		t.Error("Pinned() = true for an unpinned nightly")
+	}
+
+	os.WriteFile(configPath, []byte("remote:\n  keep: -1\n"), 0644)
+	if _, err := Load(configPath); err == nil {
+		t.Error("expected error for negative keep")
+	}
+	os.WriteFile(configPath, []byte("remote:\n  pin: ['[']\n"), 0644)
+	if _, err := Load(configPath); err == nil {
+		t.Error("expected error for an invalid pin pattern")
+	}
+}
+
+func TestLoadConfig_Exporters(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "exporters.yaml")
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tmpDir, "config"))
+
+	os.WriteFile(configPath, []byte("exporters:\n  - volume: '*_pgdata'\n    plugin: vault\n"), 0644)
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if got := cfg.ExporterFor(models.Volume{Name: "shop_pgdata"}); got != "vault" {
+		t.Errorf("ExporterFor(shop_pgdata) = %q, want vault", got)
+	}
+	if got := cfg.ExporterFor(models.Volume{Name: "shop_redis"}); got != "" {
+		t.Errorf("ExporterFor(shop_redis) = %q, want none", got)
+	}
+	if want := filepath.Join(tmpDir, "config", "dataclean", "plugins"); cfg.PluginsDir != want {
+		t.Errorf("PluginsDir = %q, want %q", cfg.PluginsDir, want)
+	}
+
+	for _, bad := range []string{
+		"exporters:\n  - volume: '*_pgdata'\n",
+		"exporters:\n  - plugin: vault\n",
+		"exporters:\n  - type: postgres\n    plugin: ../vault\n",
+	} {
+		os.WriteFile(configPath, []byte(bad), 0644)
+		if _, err := Load(configPath); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestLoadRecipe(t *testing.T) {
+	tmpDir := t.TempDir()
+	planPath := filepath.Join(tmpDir, "refresh.yaml")
+
+	os.WriteFile(planPath, []byte(`description: Refresh dev data
+steps:
+  - snapshot: before-refresh
+    tags: [auto]
+  - reset: [redisdata]
+  - restore: seeded
+    into:
+      pgdata: pgdata_copy
+`), 0644)
+	recipe, err := LoadRecipe(planPath)
+	if err != nil {
+		t.Fatalf("LoadRecipe() failed: %v", err)
+	}
+	if len(recipe.Steps) != 3 || recipe.Steps[1].Op() != "reset" || recipe.Steps[2].Into["pgdata"] != "pgdata_copy" {
+		t.Errorf("unexpected steps: %+v", recipe.Steps)
+	}
+
+	for _, bad := range []string{
+		"steps: []\n",
+		"steps:\n  - snapshot: a\n    restore: b\n",
+		"steps:\n  - tags: [auto]\n",
+		"steps:\n  - reset: [redisdata]\n    tags: [auto]\n",
+		"steps:\n  - snapshot: a\n    into: {pgdata: copy}\n",
+		"steps:\n  - restore: seeded\n    volume: pgdata\n",
+	} {
+		os.WriteFile(planPath, []byte(bad), 0644)
+		if _, err := LoadRecipe(planPath); err == nil {
+			t.Errorf("expected error for plan:\n%s", bad)
+		}
+	}
+}
+
+func TestLoadConfig_Stacks(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "stacks.yaml")
+
+	os.WriteFile(configPath, []byte("stacks:\n  - name: api\n    path: services/api\n    project: shop-api\n  - name: worker\n    path: services/worker\n"), 0644)
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if len(cfg.Stacks) != 2 || cfg.Stacks[0].Project != "shop-api" || cfg.Stacks[1].Path != "services/worker" {
+		t.Errorf("Stacks = %+v", cfg.Stacks)
+	}
+
+	for _, bad := range []string{
+		"stacks:\n  - name: api\n",
+		"stacks:\n  - path: services/api\n",
+		"stacks:\n  - name: api\n    path: a\n  - name: api\n    path: b\n",
+		"stacks:\n  - name: api\n    path: a\n    project: Shop API\n",
+	} {
+		os.WriteFile(configPath, []byte(bad), 0644)
+		if _, err := Load(configPath); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+// LoadRecipe reads and checks a plan file for 'dataclean apply'. Unknown
+// fields are errors, so a misspelt option can't silently change what runs.
+func LoadRecipe(path string) (*models.Recipe, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var recipe models.Recipe
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&recipe); err != nil {
+		return nil, fmt.Errorf("invalid plan %s: %w", path, err)
+	}
+	if len(recipe.Steps) == 0 {
+		return nil, fmt.Errorf("plan %s has no steps", path)
+	}
+	for i, step := range recipe.Steps {
+		if err := validateRecipeStep(step); err != nil {
+			return nil, fmt.Errorf("plan %s: steps[%d]: %w", path, i, err)
+		}
+	}
+	return &recipe, nil
+}
+
+func validateRecipeStep(s models.RecipeStep) error {
+	ops := 0
+	for _, set := range []bool{s.Snapshot != "", len(s.Reset) > 0, s.Restore != ""} {
+		if set {
+			ops++
+		}
+	}
+	if ops != 1 {
+		return fmt.Errorf("needs exactly one of snapshot, reset, or restore")
+	}
+	op := s.Op()
+	switch {
+	case op != "snapshot" && (len(s.Volumes) > 0 || len(s.Tags) > 0 || s.Description != ""):
+		return fmt.Errorf("volumes, tags, and description only apply to snapshot")
+	case op != "restore" && len(s.Into) > 0:
+		return fmt.Errorf("into only applies to restore")
+	}
+	for from, to := range s.Into {
+		if from == "" || to == "" {
+			return fmt.Errorf("into: %q: %q needs a volume on both sides", from, to)
+		}
+	}
+	return nil
+}
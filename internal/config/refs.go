@@ -0,0 +1,291 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+// Any config value can be kept out of the (often committed) config file by
+// tagging it with where to find it instead:
+//
+//	url: !env SLACK_WEBHOOK              # an environment variable
+//	url: !file ~/.config/dataclean/url   # a file's contents
+//	url: !cmd pass show dataclean/slack  # a command's output (sh -c)
+//	url: !secret slack                   # the age-encrypted secrets block
+//
+// References are resolved when the config is loaded, except in the settings
+// listed in lazyRefs: those are only resolved by the code that uses them
+// (Remote, Mirror, and Notifications), so a command that never touches the
+// remote doesn't run its !cmd or need its variables set.
+const (
+	tagEnv    = "!env"
+	tagFile   = "!file"
+	tagCmd    = "!cmd"
+	tagSecret = "!secret"
+)
+
+// lazyRefs are the settings whose references wait until they are used, by
+// path ("[]" stands for any list item)
+var lazyRefs = map[string]bool{
+	"remote.url":                            true,
+	"remote.share_key":                      true,
+	"mirror.target":                         true,
+	"notifications.webhooks[]":              true,
+	"notifications.slack[]":                 true,
+	"notifications.snapshot_webhooks[].url": true,
+}
+
+// deferredRef marks a reference left in a lazy setting as "<tag> <value>".
+// No real value starts with the NUL byte.
+const deferredRef = "\x00"
+
+// decodeConfig unmarshals a config file, resolving tagged references
+func decodeConfig(data []byte, cfg *models.Config) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	if doc.Kind == 0 {
+		return nil // empty file
+	}
+	r := &refResolver{}
+	if root := doc.Content[0]; root.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			switch root.Content[i].Value {
+			case "secrets":
+				r.block = root.Content[i+1].Value
+			case "secrets_identity":
+				r.identity = root.Content[i+1].Value
+			}
+		}
+	}
+	if err := r.resolve(doc.Content[0], ""); err != nil {
+		return err
+	}
+	return doc.Decode(cfg)
+}
+
+// Remote returns the remote settings with their references resolved
+func Remote(cfg *models.Config) (models.RemoteConfig, error) {
+	remote := cfg.Remote
+	err := resolveLazy(cfg, []lazyRef{
+		{"remote.url", &remote.URL},
+		{"remote.share_key", &remote.ShareKey},
+	})
+	return remote, err
+}
+
+// Mirror returns the mirror policy with its target's reference resolved
+func Mirror(cfg *models.Config) (models.MirrorConfig, error) {
+	mirror := cfg.Mirror
+	err := resolveLazy(cfg, []lazyRef{{"mirror.target", &mirror.Target}})
+	return mirror, err
+}
+
+// Notifications returns the notification settings with the references in
+// their URLs resolved
+func Notifications(cfg *models.Config) (models.NotifyConfig, error) {
+	n := cfg.Notifications
+	n.Webhooks = slices.Clone(n.Webhooks)
+	n.Slack = slices.Clone(n.Slack)
+	n.SnapshotWebhooks = slices.Clone(n.SnapshotWebhooks)
+
+	var refs []lazyRef
+	for i := range n.Webhooks {
+		refs = append(refs, lazyRef{fmt.Sprintf("notifications.webhooks[%d]", i), &n.Webhooks[i]})
+	}
+	for i := range n.Slack {
+		refs = append(refs, lazyRef{fmt.Sprintf("notifications.slack[%d]", i), &n.Slack[i]})
+	}
+	for i := range n.SnapshotWebhooks {
+		refs = append(refs, lazyRef{fmt.Sprintf("notifications.snapshot_webhooks[%d].url", i), &n.SnapshotWebhooks[i].URL})
+	}
+	return n, resolveLazy(cfg, refs)
+}
+
+// lazyRef is a setting that may hold a deferred reference
+type lazyRef struct {
+	name  string
+	value *string
+}
+
+// resolveLazy replaces the deferred references among refs with their values
+func resolveLazy(cfg *models.Config, refs []lazyRef) error {
+	r := &refResolver{block: cfg.Secrets, identity: cfg.SecretsIdentity}
+	for _, ref := range refs {
+		deferred, ok := strings.CutPrefix(*ref.value, deferredRef)
+		if !ok {
+			continue
+		}
+		tag, name, _ := strings.Cut(deferred, " ")
+		value, err := r.lookup(tag, name)
+		if err != nil {
+			return fmt.Errorf("%s: %s %s: %w", ref.name, tag, name, err)
+		}
+		*ref.value = value
+	}
+	return nil
+}
+
+// refResolver replaces tagged scalars with the values they refer to
+type refResolver struct {
+	block    string            // age-armored secrets block
+	identity string            // secrets_identity from the config
+	secrets  map[string]string // decrypted on first use
+}
+
+// resolve replaces the references under n, found at path, with their values,
+// or defers them in the lazyRefs settings
+func (r *refResolver) resolve(n *yaml.Node, path string) error {
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key := n.Content[i].Value
+			if path != "" {
+				key = path + "." + key
+			}
+			if err := r.resolve(n.Content[i+1], key); err != nil {
+				return err
+			}
+		}
+		return nil
+	case yaml.SequenceNode:
+		for _, c := range n.Content {
+			if err := r.resolve(c, path+"[]"); err != nil {
+				return err
+			}
+		}
+		return nil
+	case yaml.ScalarNode:
+	default:
+		return nil
+	}
+
+	switch n.Tag {
+	case tagEnv, tagFile, tagCmd, tagSecret:
+	default:
+		return nil
+	}
+	value := deferredRef + n.Tag + " " + n.Value
+	if !lazyRefs[path] {
+		var err error
+		if value, err = r.lookup(n.Tag, n.Value); err != nil {
+			return fmt.Errorf("line %d: %s %s: %w", n.Line, n.Tag, n.Value, err)
+		}
+	}
+	n.Tag = "!!str"
+	n.Value = value
+	n.Style = 0
+	return nil
+}
+
+// lookup returns the value a reference tagged tag refers to
+func (r *refResolver) lookup(tag, ref string) (string, error) {
+	switch tag {
+	case tagEnv:
+		value, ok := os.LookupEnv(ref)
+		if !ok {
+			return "", fmt.Errorf("%s is not set", ref)
+		}
+		return value, nil
+	case tagFile:
+		data, err := os.ReadFile(expandHome(ref))
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	case tagCmd:
+		return runRefCommand(ref)
+	case tagSecret:
+		return r.secret(ref)
+	}
+	return "", fmt.Errorf("unknown reference")
+}
+
+// runRefCommand runs a !cmd reference and returns its output without the trailing newline
+func runRefCommand(command string) (string, error) {
+	var stderr strings.Builder
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimRight(string(out), "\r\n"), nil
+}
+
+// secret looks a name up in the secrets block, decrypting it on first use
+func (r *refResolver) secret(name string) (string, error) {
+	if r.secrets == nil {
+		if r.block == "" {
+			return "", fmt.Errorf("the config has no secrets block")
+		}
+		secrets, err := decryptSecrets(r.block, r.identityFile())
+		if err != nil {
+			return "", err
+		}
+		r.secrets = secrets
+	}
+	value, ok := r.secrets[name]
+	if !ok {
+		return "", fmt.Errorf("not in the secrets block")
+	}
+	return value, nil
+}
+
+// identityFile is the age identity that decrypts the secrets block:
+// DATACLEAN_AGE_IDENTITY, then secrets_identity, then the default location
+func (r *refResolver) identityFile() string {
+	if path := os.Getenv("DATACLEAN_AGE_IDENTITY"); path != "" {
+		return expandHome(path)
+	}
+	if r.identity != "" {
+		return expandHome(r.identity)
+	}
+	return filepath.Join(userConfigDir(), "age.key")
+}
+
+// decryptSecrets decrypts an age-armored YAML map of names to values with the age CLI
+func decryptSecrets(block, identity string) (map[string]string, error) {
+	var stderr strings.Builder
+	cmd := exec.Command("age", "--decrypt", "-i", identity)
+	cmd.Stdin = strings.NewReader(block)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if _, ok := err.(*exec.Error); ok {
+			return nil, fmt.Errorf("decrypting the secrets block needs the age CLI: %w", err)
+		}
+		return nil, fmt.Errorf("failed to decrypt the secrets block with %s: %s", identity, strings.TrimSpace(stderr.String()))
+	}
+
+	var secrets map[string]string
+	if err := yaml.Unmarshal(out, &secrets); err != nil {
+		return nil, fmt.Errorf("the secrets block isn't a YAML map of names to values: %w", err)
+	}
+	if secrets == nil {
+		secrets = map[string]string{}
+	}
+	return secrets, nil
+}
+
+// expandHome expands a leading ~ to the home directory
+func expandHome(path string) string {
+	rest, ok := strings.CutPrefix(path, "~")
+	if !ok {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, rest)
+}
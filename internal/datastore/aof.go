@@ -0,0 +1,32 @@
+package datastore
+
+import "fmt"
+
+// Redis append-only file capture. A capture is a tar of the server's AOF
+// (the appendonlydir of Redis 7+, or appendonly.aof before that); restoring
+// one in place of the snapshot's makes Redis load the later state on start.
+const (
+	redisLogin = `P="${DATACLEAN_DB_PASSWORD:-$REDIS_PASSWORD}"; [ -n "$P" ] && export REDISCLI_AUTH="$P"; ` +
+		`cfg() { redis-cli CONFIG GET "$1" | sed -n 2p; }; `
+
+	// RedisCaptureAOF writes a tar of the append-only files to stdout. A
+	// rewrite swaps the files out mid-copy, so it refuses to run during one;
+	// a half-written last command is dropped on load (aof-load-truncated).
+	RedisCaptureAOF = redisLogin +
+		`[ "$(cfg appendonly)" = yes ] || { echo "appendonly is off" >&2; exit 1; }; ` +
+		`redis-cli INFO persistence | grep -q '^aof_rewrite_in_progress:0' || { echo "AOF rewrite in progress, try again" >&2; exit 1; }; ` +
+		`cd "$(cfg dir)" || exit 1; n="$(cfg appenddirname)"; ` +
+		`if [ -n "$n" ] && [ -d "$n" ]; then tar -cf - "$n"; else tar -cf - "$(cfg appendfilename)"; fi`
+)
+
+// RedisStageAOF returns the script that swaps a captured AOF into a restored
+// volume. It runs in a helper container with the volume at /data and the
+// capture directory at /host, while Redis is stopped.
+func RedisStageAOF(capture string) string {
+	return fmt.Sprintf(`set -e
+owner="$(stat -c %%u:%%g /data)"
+tar -tf "/host/%[1]s" | cut -d/ -f1 | sort -u | while read -r top; do rm -rf "/data/$top"; done
+tar -xf "/host/%[1]s" -C /data
+chown -R "$owner" /data
+`, capture)
+}
@@ -0,0 +1,42 @@
+package datastore
+
+import (
+	"net/url"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+// defaultUsers are the users official images log in as when none is configured
+var defaultUsers = map[models.DatastoreType]string{
+	models.DatastorePostgres: "postgres",
+	models.DatastoreMySQL:    "root",
+}
+
+// ConnectionURL returns a connection string for a datastore listening on addr
+// ("host:port"), or addr itself for datastores without a URL scheme
+func ConnectionURL(dt models.DatastoreType, addr string, creds *models.Credentials) string {
+	scheme := For(dt).Scheme
+	if scheme == "" {
+		return addr
+	}
+
+	u := url.URL{Scheme: scheme, Host: addr}
+	user := defaultUsers[dt]
+	var password string
+	if creds != nil {
+		if creds.User != "" {
+			user = creds.User
+		}
+		password = revealPassword(creds)
+		if creds.Database != "" {
+			u.Path = "/" + creds.Database
+		}
+	}
+	switch {
+	case password != "":
+		u.User = url.UserPassword(user, password) // redis://:secret@... when there's no user
+	case user != "":
+		u.User = url.User(user)
+	}
+	return u.String()
+}
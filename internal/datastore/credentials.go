@@ -0,0 +1,108 @@
+package datastore
+
+import (
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+// Environment variables the dump and summary scripts read before falling back
+// to the official images' own variables
+const (
+	EnvUser     = "DATACLEAN_DB_USER"
+	EnvPassword = "DATACLEAN_DB_PASSWORD"
+	EnvDatabase = "DATACLEAN_DB_NAME"
+
+	// EnvQuery hands a validation query to the Query scripts
+	EnvQuery = "DATACLEAN_QUERY"
+)
+
+// DiscoverCredentials extracts login details from a service's environment
+// using the variables understood by the official images. It returns nil when
+// the environment holds nothing relevant.
+func DiscoverCredentials(dt models.DatastoreType, env map[string]string) *models.Credentials {
+	var creds models.Credentials
+	var password string
+
+	switch dt {
+	case models.DatastorePostgres:
+		creds.User = first(env, "POSTGRES_USER")
+		password = first(env, "POSTGRES_PASSWORD")
+		creds.Database = first(env, "POSTGRES_DB")
+		if creds.User == "" && password != "" {
+			creds.User = "postgres"
+		}
+
+	case models.DatastoreMySQL:
+		// Prefer root so dumps see every table
+		if root := first(env, "MYSQL_ROOT_PASSWORD", "MARIADB_ROOT_PASSWORD"); root != "" {
+			creds.User, password = "root", root
+		} else {
+			creds.User = first(env, "MYSQL_USER", "MARIADB_USER")
+			password = first(env, "MYSQL_PASSWORD", "MARIADB_PASSWORD")
+		}
+		creds.Database = first(env, "MYSQL_DATABASE", "MARIADB_DATABASE")
+
+	case models.DatastoreMongoDB:
+		creds.User = first(env, "MONGO_INITDB_ROOT_USERNAME")
+		password = first(env, "MONGO_INITDB_ROOT_PASSWORD")
+		creds.Database = first(env, "MONGO_INITDB_DATABASE")
+
+	case models.DatastoreClickHouse:
+		creds.User = first(env, "CLICKHOUSE_USER")
+		password = first(env, "CLICKHOUSE_PASSWORD")
+		creds.Database = first(env, "CLICKHOUSE_DB")
+
+	case models.DatastoreElastic:
+		if pw := first(env, "ELASTIC_PASSWORD"); pw != "" {
+			creds.User, password = "elastic", pw
+		} else if pw := first(env, "OPENSEARCH_INITIAL_ADMIN_PASSWORD"); pw != "" {
+			creds.User, password = "admin", pw
+		}
+
+	default:
+		return nil
+	}
+
+	if creds.User == "" && password == "" && creds.Database == "" {
+		return nil
+	}
+	creds.Password = models.NewSecret(password)
+	return &creds
+}
+
+// CredentialEnv returns the variables that hand credentials to the in-container scripts
+func CredentialEnv(creds *models.Credentials) []string {
+	if creds == nil {
+		return nil
+	}
+
+	var env []string
+	if creds.User != "" {
+		env = append(env, EnvUser+"="+creds.User)
+	}
+	if password := revealPassword(creds); password != "" {
+		env = append(env, EnvPassword+"="+password)
+	}
+	if creds.Database != "" {
+		env = append(env, EnvDatabase+"="+creds.Database)
+	}
+	return env
+}
+
+// revealPassword returns the clear text password, if one is known. Snapshots
+// taken with store_credentials keep it in StoredPassword.
+func revealPassword(creds *models.Credentials) string {
+	if password := creds.Password.Reveal(); password != "" {
+		return password
+	}
+	return creds.StoredPassword
+}
+
+// first returns the first non-empty value among keys
+func first(env map[string]string, keys ...string) string {
+	for _, k := range keys {
+		if v := env[k]; v != "" {
+			return v
+		}
+	}
+	return ""
+}
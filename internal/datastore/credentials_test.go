@@ -0,0 +1,103 @@
+package datastore
+
+import (
+	"testing"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+func TestDiscoverCredentials(t *testing.T) {
+	tests := []struct {
+		name     string
+		dt       models.DatastoreType
+		env      map[string]string
+		expected *models.Credentials
+	}{
+		{
+			name:     "postgres",
+			dt:       models.DatastorePostgres,
+			env:      map[string]string{"POSTGRES_USER": "app", "POSTGRES_PASSWORD": "secret", "POSTGRES_DB": "appdb"},
+			expected: &models.Credentials{User: "app", Password: models.NewSecret("secret"), Database: "appdb"},
+		},
+		{
+			name:     "postgres default user",
+			dt:       models.DatastorePostgres,
+			env:      map[string]string{"POSTGRES_PASSWORD": "secret"},
+			expected: &models.Credentials{User: "postgres", Password: models.NewSecret("secret")},
+		},
+		{
+			name:     "mysql prefers root",
+			dt:       models.DatastoreMySQL,
+			env:      map[string]string{"MYSQL_ROOT_PASSWORD": "rootpw", "MYSQL_USER": "app", "MYSQL_PASSWORD": "apppw", "MYSQL_DATABASE": "shop"},
+			expected: &models.Credentials{User: "root", Password: models.NewSecret("rootpw"), Database: "shop"},
+		},
+		{
+			name:     "mariadb user",
+			dt:       models.DatastoreMySQL,
+			env:      map[string]string{"MARIADB_USER": "app", "MARIADB_PASSWORD": "apppw"},
+			expected: &models.Credentials{User: "app", Password: models.NewSecret("apppw")},
+		},
+		{
+			name:     "mongodb",
+			dt:       models.DatastoreMongoDB,
+			env:      map[string]string{"MONGO_INITDB_ROOT_USERNAME": "admin", "MONGO_INITDB_ROOT_PASSWORD": "pw"},
+			expected: &models.Credentials{User: "admin", Password: models.NewSecret("pw")},
+		},
+		{
+			name:     "elasticsearch",
+			dt:       models.DatastoreElastic,
+			env:      map[string]string{"ELASTIC_PASSWORD": "changeme", "discovery.type": "single-node"},
+			expected: &models.Credentials{User: "elastic", Password: models.NewSecret("changeme")},
+		},
+		{
+			name:     "opensearch",
+			dt:       models.DatastoreElastic,
+			env:      map[string]string{"OPENSEARCH_INITIAL_ADMIN_PASSWORD": "Str0ng!pw"},
+			expected: &models.Credentials{User: "admin", Password: models.NewSecret("Str0ng!pw")},
+		},
+		{
+			name:     "clickhouse",
+			dt:       models.DatastoreClickHouse,
+			env:      map[string]string{"CLICKHOUSE_USER": "analytics", "CLICKHOUSE_PASSWORD": "pw", "CLICKHOUSE_DB": "events"},
+			expected: &models.Credentials{User: "analytics", Password: models.NewSecret("pw"), Database: "events"},
+		},
+		{
+			name: "nothing relevant",
+			dt:   models.DatastorePostgres,
+			env:  map[string]string{"TZ": "UTC"},
+		},
+		{
+			name: "unsupported type",
+			dt:   models.DatastoreRedis,
+			env:  map[string]string{"POSTGRES_PASSWORD": "secret"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DiscoverCredentials(tt.dt, tt.env)
+			if (got == nil) != (tt.expected == nil) || (got != nil && *got != *tt.expected) {
+				t.Errorf("DiscoverCredentials() = %+v, want %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCredentialEnv(t *testing.T) {
+	creds := &models.Credentials{User: "app", Password: models.NewSecret("secret"), Database: "appdb"}
+	env := CredentialEnv(creds)
+	if len(env) != 3 || env[1] != "DATACLEAN_DB_PASSWORD=secret" {
+		t.Errorf("CredentialEnv() = %v", env)
+	}
+
+	// Redacted passwords must never be handed to the container
+	for _, kv := range CredentialEnv(creds.Redacted()) {
+		if kv == "DATACLEAN_DB_PASSWORD="+models.RedactedPassword {
+			t.Error("redacted password passed through")
+		}
+	}
+
+	if CredentialEnv(nil) != nil {
+		t.Error("expected no variables without credentials")
+	}
+}
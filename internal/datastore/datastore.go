@@ -0,0 +1,97 @@
+package datastore
+
+import (
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+// Shell snippets run inside the datastore container. Credentials come from the
+// DATACLEAN_DB_* variables when discovered (see CredentialEnv), falling back
+// to the container's own environment (set by the official images' env vars).
+const (
+	postgresLogin = `U="${DATACLEAN_DB_USER:-${POSTGRES_USER:-postgres}}"; D="${DATACLEAN_DB_NAME:-${POSTGRES_DB:-$U}}"; ` +
+		`export PGPASSWORD="${DATACLEAN_DB_PASSWORD:-$POSTGRES_PASSWORD}"; `
+	mysqlLogin = `U="${DATACLEAN_DB_USER:-root}"; D="${DATACLEAN_DB_NAME:-${MYSQL_DATABASE:-$MARIADB_DATABASE}}"; ` +
+		`export MYSQL_PWD="${DATACLEAN_DB_PASSWORD:-${MYSQL_ROOT_PASSWORD:-$MARIADB_ROOT_PASSWORD}}"; `
+	mongoLogin = `U="${DATACLEAN_DB_USER:-$MONGO_INITDB_ROOT_USERNAME}"; P="${DATACLEAN_DB_PASSWORD:-$MONGO_INITDB_ROOT_PASSWORD}"; `
+
+	// es PATH [curl args] calls the local REST API over http, then https
+	// (security-enabled 8.x nodes), with basic auth when a password is known
+	elasticLogin = `P="${DATACLEAN_DB_PASSWORD:-${ELASTIC_PASSWORD:-$OPENSEARCH_INITIAL_ADMIN_PASSWORD}}"; ` +
+		`U="${DATACLEAN_DB_USER:-elastic}"; ` +
+		`[ -z "$DATACLEAN_DB_USER" ] && [ -n "$OPENSEARCH_INITIAL_ADMIN_PASSWORD" ] && U=admin; ` +
+		`es() { p="$1"; shift; for base in http://localhost:9200 https://localhost:9200; do ` +
+		`if [ -n "$P" ]; then curl -fsSk -u "$U:$P" "$@" "$base$p" && return 0; ` +
+		`else curl -fsSk "$@" "$base$p" && return 0; fi; done; return 1; }; `
+
+	postgresDump = postgresLogin + `pg_dump -U "$U" "$D"`
+	mysqlDump    = mysqlLogin + `$(command -v mysqldump || command -v mariadb-dump) --single-transaction --skip-extended-insert ` +
+		`-u"$U" "$D"`
+
+	postgresHealth = postgresLogin + `pg_isready -q -U "$U" -d "$D"`
+	mysqlHealth    = mysqlLogin + `$(command -v mysqladmin || command -v mariadb-admin) ping --silent -u"$U"`
+	redisHealth    = `redis-cli ping | grep -q PONG`
+	mongoHealth    = `$(command -v mongosh || command -v mongo) --quiet --eval 'db.adminCommand("ping").ok' | grep -q 1`
+
+	postgresClient = postgresLogin + `exec psql -U "$U" -d "$D"`
+	mysqlClient    = mysqlLogin + `exec $(command -v mysql || command -v mariadb) -u"$U" ${D:+"$D"}`
+	redisClient    = `exec redis-cli`
+	mongoClient    = mongoLogin + `MONGO=$(command -v mongosh || command -v mongo); ` +
+		`if [ -n "$U" ]; then exec $MONGO -u "$U" -p "$P" --authenticationDatabase admin; else exec $MONGO; fi`
+
+	postgresQuery = postgresLogin + `psql -U "$U" -d "$D" -v ON_ERROR_STOP=1 -Atc "$DATACLEAN_QUERY"`
+	mysqlQuery    = mysqlLogin + `$(command -v mysql || command -v mariadb) -N -B -u"$U" ${D:+"$D"} -e "$DATACLEAN_QUERY"`
+	redisQuery    = `redis-cli $DATACLEAN_QUERY` // split into words, as typed into redis-cli
+	mongoQuery    = mongoLogin + `MONGO=$(command -v mongosh || command -v mongo); ` +
+		`if [ -n "$U" ]; then $MONGO --quiet -u "$U" -p "$P" --authenticationDatabase admin --eval "$DATACLEAN_QUERY"; ` +
+		`else $MONGO --quiet --eval "$DATACLEAN_QUERY"; fi`
+
+	// Activity scripts look for writes in flight: client queries still
+	// running, or a background save or rewrite
+	postgresActivity = postgresLogin + `psql -U "$U" -d "$D" -At -c "SELECT count(*) || ' active queries' FROM pg_stat_activity ` +
+		`WHERE state = 'active' AND backend_type = 'client backend' AND pid <> pg_backend_pid() HAVING count(*) > 0"`
+	mysqlActivity = mysqlLogin + `$(command -v mysql || command -v mariadb) -N -B -u"$U" -e "SELECT CONCAT(COUNT(*), ' active queries') ` +
+		`FROM information_schema.PROCESSLIST WHERE COMMAND = 'Query' AND ID <> CONNECTION_ID() HAVING COUNT(*) > 0"`
+	redisActivity = `redis-cli INFO persistence | grep -E '^(rdb_bgsave|aof_rewrite)_in_progress:1' | cut -d: -f1`
+
+	// ShellFallback opens a shell for datastores without an interactive client
+	ShellFallback = `command -v bash >/dev/null && exec bash; exec sh`
+
+	// Flush writes segments and clears the translog so the data directory copy is consistent
+	elasticQuiesce = elasticLogin + `es /_flush -XPOST >/dev/null`
+	// Yellow is as good as it gets on a single-node dev cluster
+	elasticHealth = elasticLogin + `es "/_cluster/health?wait_for_status=yellow&timeout=5s" >/dev/null`
+
+	clickhouseLogin = `CH="clickhouse-client --user ${DATACLEAN_DB_USER:-${CLICKHOUSE_USER:-default}}"; ` +
+		`export CLICKHOUSE_PASSWORD="${DATACLEAN_DB_PASSWORD:-$CLICKHOUSE_PASSWORD}"; `
+	// Push buffered async inserts and system logs into their parts before the copy
+	clickhouseQuiesce = clickhouseLogin + `$CH -q "SYSTEM FLUSH ASYNC INSERT QUEUE" 2>/dev/null; $CH -q "SYSTEM FLUSH LOGS"`
+	clickhouseHealth  = clickhouseLogin + `$CH -q "SELECT 1" >/dev/null`
+	clickhouseClient  = clickhouseLogin + `exec $CH`
+	clickhouseQuery   = clickhouseLogin + `$CH -q "$DATACLEAN_QUERY"`
+
+	// Write memtables to SSTables so the commit log isn't needed on restore
+	cassandraQuiesce = `nodetool flush`
+	cassandraHealth  = `nodetool status 2>/dev/null | grep -q '^UN'`
+	cassandraClient  = `exec cqlsh`
+
+	// RabbitMQ persists durable queues on a clean container stop, so it needs no quiesce step
+	rabbitmqHealth = `rabbitmq-diagnostics -q check_running`
+)
+
+// DumpCommand returns the shell command that writes a logical dump to stdout
+func DumpCommand(dt models.DatastoreType) (string, bool) {
+	dump := For(dt).Dump
+	return dump, dump != ""
+}
+
+// SupportsLogicalDump reports whether a datastore type can produce logical dumps
+func SupportsLogicalDump(dt models.DatastoreType) bool {
+	_, ok := DumpCommand(dt)
+	return ok
+}
+
+// DataPath returns where the official image for a datastore type keeps its data
+func DataPath(dt models.DatastoreType) (string, bool) {
+	path := For(dt).DataPath
+	return path, path != ""
+}
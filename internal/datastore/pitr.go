@@ -0,0 +1,100 @@
+package datastore
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// Postgres point-in-time recovery. The server archives finished WAL segments
+// into a dataclean_wal directory inside its data directory; dataclean moves
+// them from there into the snapshot directory (see WALDir in the snapshot
+// package), since restoring a volume wipes everything inside it.
+const (
+	// postgresDataDir sets d to the server's data directory
+	postgresDataDir = postgresLogin + `d="$(psql -U "$U" -d "$D" -Atc 'SHOW data_directory')" || exit 1; `
+
+	// PostgresEnableWALArchive turns on archiving (after a restart).
+	// archive_command runs in the data directory; copy-then-rename means a
+	// sync never picks up a half-written segment.
+	PostgresEnableWALArchive = postgresLogin + `psql -U "$U" -d "$D" -v ON_ERROR_STOP=1 -q <<'SQL'
+ALTER SYSTEM SET wal_level = 'replica';
+ALTER SYSTEM SET archive_mode = 'on';
+ALTER SYSTEM SET archive_timeout = '60s';
+ALTER SYSTEM SET archive_command = 'mkdir -p dataclean_wal && { test -f dataclean_wal/%f || { cp %p dataclean_wal/%f.tmp && mv dataclean_wal/%f.tmp dataclean_wal/%f; }; }';
+SQL`
+
+	// PostgresDisableWALArchive turns archiving back off (after a restart)
+	PostgresDisableWALArchive = postgresLogin + `psql -U "$U" -d "$D" -v ON_ERROR_STOP=1 -q <<'SQL'
+ALTER SYSTEM RESET archive_mode;
+ALTER SYSTEM RESET archive_timeout;
+ALTER SYSTEM RESET archive_command;
+SQL`
+
+	// PostgresSwitchWAL closes the current segment so it gets archived, and
+	// prints its file name
+	PostgresSwitchWAL = postgresLogin + `psql -U "$U" -d "$D" -Atc 'SELECT pg_walfile_name(pg_switch_wal())'`
+
+	// PostgresListWAL prints the archived segments waiting to be moved out
+	PostgresListWAL = postgresDataDir + `[ -d "$d/dataclean_wal" ] && ls "$d/dataclean_wal"; true`
+
+	// PostgresInRecovery prints t while WAL is still being replayed
+	PostgresInRecovery = postgresLogin + `psql -U "$U" -d "$D" -Atc 'SELECT pg_is_in_recovery()'`
+
+	// PostgresFinishRecovery drops the recovery settings and staged WAL once
+	// the server has been promoted
+	PostgresFinishRecovery = postgresDataDir + `psql -U "$U" -d "$D" -v ON_ERROR_STOP=1 -q ` +
+		`-c 'ALTER SYSTEM RESET restore_command' -c 'ALTER SYSTEM RESET recovery_target_time' ` +
+		`-c 'ALTER SYSTEM RESET recovery_target_action' -c 'SELECT pg_reload_conf()' >/dev/null && ` +
+		`rm -rf "$d/dataclean_restore_wal"`
+)
+
+// walName matches WAL segment, timeline history, and backup label file names
+var walName = regexp.MustCompile(`^([0-9A-F]{24}(\.partial|\.[0-9A-F]{8}\.backup)?|[0-9A-F]{8}\.history)$`)
+
+// ValidWALName reports whether name is a file Postgres archives. Names are
+// checked before they go into shell commands.
+func ValidWALName(name string) bool {
+	return walName.MatchString(name)
+}
+
+// PostgresFetchWAL returns the command that writes an archived segment to stdout
+func PostgresFetchWAL(name string) string {
+	return postgresDataDir + fmt.Sprintf(`cat "$d/dataclean_wal/%s"`, name)
+}
+
+// PostgresRemoveWAL returns the command that deletes segments once they're copied out
+func PostgresRemoveWAL(names []string) string {
+	script := postgresDataDir + `cd "$d/dataclean_wal" && rm -f`
+	for _, name := range names {
+		script += " " + name
+	}
+	return script
+}
+
+// PostgresStageRecovery returns the script that prepares a restored data
+// directory to replay WAL up to target. It runs in a helper container with
+// the volume at /data and the archived WAL at /host, while Postgres is stopped.
+func PostgresStageRecovery(target time.Time) string {
+	return `set -e
+pgv="$(find /data -maxdepth 3 -name PG_VERSION -not -path '*/base/*' | head -1)"
+[ -n "$pgv" ] || { echo "no Postgres data directory in volume" >&2; exit 1; }
+d="$(dirname "$pgv")"
+owner="$(stat -c %u:%g "$pgv")"
+rm -rf "$d/dataclean_restore_wal"
+mkdir "$d/dataclean_restore_wal"
+cp /host/* "$d/dataclean_restore_wal/"
+touch "$d/recovery.signal"
+cat >> "$d/postgresql.auto.conf" <<'CONF'
+` + RecoveryConfig(target) + `CONF
+chown -R "$owner" "$d/dataclean_restore_wal" "$d/recovery.signal" "$d/postgresql.auto.conf"
+`
+}
+
+// RecoveryConfig returns the postgresql.conf settings that replay staged WAL
+// up to target and then open the database for writes
+func RecoveryConfig(target time.Time) string {
+	return "restore_command = 'cp dataclean_restore_wal/%f \"%p\"'\n" +
+		fmt.Sprintf("recovery_target_time = '%s'\n", target.Format("2006-01-02 15:04:05.999999-07:00")) +
+		"recovery_target_action = 'promote'\n"
+}
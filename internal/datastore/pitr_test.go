@@ -0,0 +1,55 @@
+package datastore
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidWALName(t *testing.T) {
+	tests := map[string]bool{
+		"000000010000000000000003":                 true,
+		"00000002.history":                         true,
+		"000000010000000000000003.00000028.backup": true,
+		"000000010000000000000003.partial":         true,
+		"000000010000000000000003.tmp":             false,
+		"000000010000000000000003; rm -rf /":       false,
+		"../000000010000000000000003":              false,
+		"":                                         false,
+	}
+	for name, want := range tests {
+		if got := ValidWALName(name); got != want {
+			t.Errorf("ValidWALName(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestRecoveryConfig(t *testing.T) {
+	target := time.Date(2024, 5, 1, 14, 30, 0, 0, time.FixedZone("CEST", 2*60*60))
+	conf := RecoveryConfig(target)
+
+	for _, want := range []string{
+		"recovery_target_time = '2024-05-01 14:30:00+02:00'",
+		"recovery_target_action = 'promote'",
+		"restore_command = 'cp dataclean_restore_wal/%f \"%p\"'",
+	} {
+		if !strings.Contains(conf, want) {
+			t.Errorf("missing %q in:\n%s", want, conf)
+		}
+	}
+
+	// The settings go through a quoted heredoc, so nothing in them is expanded
+	if script := PostgresStageRecovery(target); !strings.Contains(script, "<<'CONF'\n"+conf+"CONF\n") {
+		t.Errorf("recovery settings not embedded verbatim:\n%s", script)
+	}
+}
+
+func TestRedisStageAOF(t *testing.T) {
+	script := RedisStageAOF("20240501T130000Z.tar")
+	if !strings.Contains(script, `tar -xf "/host/20240501T130000Z.tar" -C /data`) {
+		t.Errorf("capture not extracted into the volume:\n%s", script)
+	}
+	if strings.Contains(script, "%!") {
+		t.Errorf("bad format verb in:\n%s", script)
+	}
+}
@@ -0,0 +1,33 @@
+package datastore
+
+import (
+	"bufio"
+	"strings"
+)
+
+// Settings scripts print one "name=value" line per server setting. Postgres
+// lists only settings changed from their defaults; MySQL and Redis have no
+// such filter and list everything.
+const (
+	postgresSettings = postgresLogin + `psql -U "$U" -d "$D" -At -c "` +
+		`SELECT name || '=' || setting FROM pg_settings WHERE source NOT IN ('default', 'override') ORDER BY 1"`
+	mysqlSettings = mysqlLogin + `$(command -v mysql || command -v mariadb) -N -B -u"$U" -e "SHOW GLOBAL VARIABLES" | ` +
+		`sed 's/\t/=/'`
+	// CONFIG GET prints names and values on alternate lines
+	redisSettings = redisLogin + `redis-cli CONFIG GET '*' | sed 'N;s/\n/=/'`
+)
+
+// ParseSettings parses "name=value" lines produced by a settings command
+func ParseSettings(output string) map[string]string {
+	settings := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		name, value, ok := strings.Cut(scanner.Text(), "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || strings.ContainsAny(name, " \t") {
+			continue // warnings and banners from the client tools
+		}
+		settings[name] = value
+	}
+	return settings
+}
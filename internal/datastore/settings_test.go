@@ -0,0 +1,27 @@
+package datastore
+
+import "testing"
+
+func TestParseSettings(t *testing.T) {
+	output := "mysql: [Warning] Using a password on the command line interface can be insecure.\n" +
+		"shared_buffers=16384\n" +
+		"search_path=\"$user\", public\n" +
+		"maxmemory-policy=\n" +
+		"\n" +
+		"=orphan\n"
+
+	settings := ParseSettings(output)
+	want := map[string]string{
+		"shared_buffers":   "16384",
+		"search_path":      "\"$user\", public",
+		"maxmemory-policy": "",
+	}
+	if len(settings) != len(want) {
+		t.Fatalf("expected %d settings, got %d: %v", len(want), len(settings), settings)
+	}
+	for k, v := range want {
+		if got, ok := settings[k]; !ok || got != v {
+			t.Errorf("settings[%s] = %q, want %q", k, got, v)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package datastore
+
+import (
+	"time"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+// Strategy holds the in-container shell commands dataclean runs for a
+// datastore type. An empty command means that step is skipped.
+type Strategy struct {
+	Dump     string // Writes a logical dump to stdout
+	Summary  string // Prints "name<TAB>rows" per table or collection
+	Quiesce  string // Runs before containers stop for a snapshot
+	Health   string // Exits 0 once the datastore accepts connections
+	Restore  string // Runs after a restore, once healthy
+	Client   string // Opens the datastore's interactive client
+	Query    string // Runs the query in $DATACLEAN_QUERY and prints the result
+	Settings string // Prints "name=value" per server setting worth comparing
+	Activity string // Prints what is writing right now, nothing when idle
+	DataPath string // Where the official image keeps its data
+	Port     int    // Port the official image listens on
+	Scheme   string // URL scheme of connection strings ("" for plain host:port)
+
+	// StopTimeout is how long 'docker stop' waits for a clean shutdown
+	// before killing the container (0: Docker's default of 10s)
+	StopTimeout time.Duration
+}
+
+// slowStop gives datastores that checkpoint on shutdown time to finish
+const slowStop = 60 * time.Second
+
+// builtins are the strategies for the datastore types dataclean knows natively
+var builtins = map[models.DatastoreType]Strategy{
+	models.DatastorePostgres: {
+		Dump:        postgresDump,
+		Summary:     postgresSummary,
+		Health:      postgresHealth,
+		Client:      postgresClient,
+		Query:       postgresQuery,
+		Settings:    postgresSettings,
+		Activity:    postgresActivity,
+		DataPath:    "/var/lib/postgresql/data",
+		Port:        5432,
+		Scheme:      "postgres",
+		StopTimeout: slowStop,
+	},
+	models.DatastoreMySQL: {
+		Dump:        mysqlDump,
+		Summary:     mysqlSummary,
+		Health:      mysqlHealth,
+		Client:      mysqlClient,
+		Query:       mysqlQuery,
+		Settings:    mysqlSettings,
+		Activity:    mysqlActivity,
+		DataPath:    "/var/lib/mysql",
+		Port:        3306,
+		Scheme:      "mysql",
+		StopTimeout: slowStop,
+	},
+	models.DatastoreRedis: {
+		Health:   redisHealth,
+		Client:   redisClient,
+		Query:    redisQuery,
+		Settings: redisSettings,
+		Activity: redisActivity,
+		DataPath: "/data",
+		Port:     6379,
+		Scheme:   "redis",
+	},
+	models.DatastoreMongoDB: {
+		Summary:     mongoSummary,
+		Health:      mongoHealth,
+		Client:      mongoClient,
+		Query:       mongoQuery,
+		DataPath:    "/data/db",
+		Port:        27017,
+		Scheme:      "mongodb",
+		StopTimeout: slowStop,
+	},
+	models.DatastoreNeo4j: {
+		DataPath: "/data",
+		Port:     7687,
+		Scheme:   "bolt",
+	},
+	models.DatastoreElastic: {
+		Summary:  elasticSummary,
+		Quiesce:  elasticQuiesce,
+		Health:   elasticHealth,
+		DataPath: "/usr/share/elasticsearch/data",
+		Port:     9200,
+		Scheme:   "http",
+	},
+	models.DatastoreClickHouse: {
+		Summary:  clickhouseSummary,
+		Quiesce:  clickhouseQuiesce,
+		Health:   clickhouseHealth,
+		Client:   clickhouseClient,
+		Query:    clickhouseQuery,
+		DataPath: "/var/lib/clickhouse",
+		Port:     9000,
+		Scheme:   "clickhouse",
+	},
+	models.DatastoreCassandra: {
+		Quiesce:     cassandraQuiesce,
+		Health:      cassandraHealth,
+		Client:      cassandraClient,
+		DataPath:    "/var/lib/cassandra",
+		Port:        9042,
+		StopTimeout: slowStop,
+	},
+	models.DatastoreRabbitMQ: {
+		Summary:  rabbitmqSummary,
+		Health:   rabbitmqHealth,
+		DataPath: "/var/lib/rabbitmq",
+		Port:     5672,
+		Scheme:   "amqp",
+	},
+}
+
+// For returns the strategy for a datastore type: a built-in one, or the
+// commands of a custom type registered from config
+func For(dt models.DatastoreType) Strategy {
+	if s, ok := builtins[dt]; ok {
+		return s
+	}
+	if custom, ok := models.LookupDatastore(dt); ok {
+		return Strategy{
+			Dump:        custom.Dump,
+			Quiesce:     custom.Quiesce,
+			Health:      custom.Health,
+			Restore:     custom.Restore,
+			StopTimeout: custom.StopTimeout,
+		}
+	}
+	return Strategy{}
+}
@@ -0,0 +1,69 @@
+package datastore
+
+import (
+	"bufio"
+	"strconv"
+	"strings"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+// Summary scripts print one "name<TAB>rows" line per table or collection.
+// Counts are exact (COUNT(*) / countDocuments), not planner estimates.
+var (
+	postgresSummary = postgresLogin + `psql -U "$U" -d "$D" -At -F "$(printf '\t')" -c "` +
+		`SELECT table_schema || '.' || table_name, ` +
+		`(xpath('/row/c/text()', query_to_xml(format('SELECT count(*) AS c FROM %I.%I', table_schema, table_name), false, true, '')))[1]::text ` +
+		`FROM information_schema.tables ` +
+		`WHERE table_type = 'BASE TABLE' AND table_schema NOT IN ('pg_catalog', 'information_schema') ORDER BY 1"`
+
+	mysqlSummary = mysqlLogin + `MYSQL="$(command -v mysql || command -v mariadb) -N -B -u$U"; ` +
+		`for t in $($MYSQL -e 'SHOW TABLES' "$D"); do ` +
+		"printf '%s\\t' \"$t\"; $MYSQL -e \"SELECT COUNT(*) FROM \\`$t\\`\" \"$D\"; " +
+		`done`
+
+	mongoSummary = mongoLogin + `MONGO=$(command -v mongosh || command -v mongo); ` +
+		`if [ -n "$U" ]; then AUTH="-u $U -p $P --authenticationDatabase admin"; fi; ` +
+		`$MONGO --quiet $AUTH --eval '` +
+		`db.adminCommand({listDatabases: 1}).databases.forEach(function(d) {` +
+		` if (["admin", "config", "local"].indexOf(d.name) >= 0) return;` +
+		` var s = db.getSiblingDB(d.name);` +
+		` s.getCollectionNames().forEach(function(c) { print(d.name + "." + c + "\t" + s.getCollection(c).countDocuments({})); });` +
+		`})'`
+
+	clickhouseSummary = clickhouseLogin + `$CH -q "SELECT database || '.' || name, total_rows FROM system.tables ` +
+		`WHERE database NOT IN ('system', 'INFORMATION_SCHEMA', 'information_schema') AND total_rows IS NOT NULL ` +
+		`ORDER BY 1 FORMAT TSV"`
+
+	// Ready messages per queue in the default vhost
+	rabbitmqSummary = `rabbitmqctl -q list_queues name messages`
+
+	elasticSummary = elasticLogin + `es "/_cat/indices?h=index,docs.count&s=index" | ` +
+		`awk '$1 !~ /^\./ { print $1 "\t" $2 }'`
+)
+
+// SummaryCommand returns the shell command that lists tables/collections with row counts
+func SummaryCommand(dt models.DatastoreType) (string, bool) {
+	summary := For(dt).Summary
+	return summary, summary != ""
+}
+
+// ParseSummary parses "name<TAB>rows" lines produced by a summary command
+func ParseSummary(output string) []models.TableSummary {
+	var tables []models.TableSummary
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		parts := strings.Split(strings.TrimSpace(scanner.Text()), "\t")
+		if len(parts) != 2 {
+			continue // warnings and banners from the client tools
+		}
+		rows, err := strconv.ParseInt(strings.TrimSpace(parts[1]), 10, 64)
+		if err != nil {
+			continue
+		}
+		tables = append(tables, models.TableSummary{Name: parts[0], Rows: rows})
+	}
+
+	return tables
+}
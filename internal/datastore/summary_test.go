@@ -0,0 +1,35 @@
+package datastore
+
+import (
+	"testing"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+func TestParseSummary(t *testing.T) {
+	output := "mysql: [Warning] Using a password on the command line interface can be insecure.\n" +
+		"public.users\t42\n" +
+		"public.orders\t0\n" +
+		"\n" +
+		"broken line\n" +
+		"app.events\tnot-a-number\n"
+
+	tables := ParseSummary(output)
+	if len(tables) != 2 {
+		t.Fatalf("expected 2 tables, got %d: %+v", len(tables), tables)
+	}
+	if tables[0].Name != "public.users" || tables[0].Rows != 42 {
+		t.Errorf("first table = %+v, want public.users/42", tables[0])
+	}
+}
+
+func TestSummaryCommand(t *testing.T) {
+	for _, dt := range []models.DatastoreType{models.DatastorePostgres, models.DatastoreMySQL, models.DatastoreMongoDB} {
+		if _, ok := SummaryCommand(dt); !ok {
+			t.Errorf("expected summary support for %s", dt)
+		}
+	}
+	if _, ok := SummaryCommand(models.DatastoreRedis); ok {
+		t.Error("redis should not support table summaries")
+	}
+}
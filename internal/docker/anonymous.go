@@ -0,0 +1,134 @@
+package docker
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/stackgen-cli/dataclean/internal/datastore"
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+// anonymousName is how Docker names the volumes it creates for mounts
+// without a source, including VOLUME lines in an image
+var anonymousName = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// ReasonAliasNoContainer is reported for an aliased mount whose service has
+// no container, so there is no volume behind the alias yet
+const ReasonAliasNoContainer = "aliased anonymous volume without a container (run 'docker compose up --no-start')"
+
+// AnonymousMount is an anonymous volume mounted in one of the project's containers
+type AnonymousMount struct {
+	Service string
+	Target  string
+	Volume  string // Docker's hash name, which changes when the container is recreated
+}
+
+// AnonymousMounts lists the anonymous volumes mounted in the project's
+// containers, running or not, by service and mount path
+func (c *Client) AnonymousMounts() ([]AnonymousMount, error) {
+	cmd := exec.CommandContext(c.ctx, "docker", "ps", "-aq", "--filter", "label=com.docker.compose.project="+c.getProjectName())
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the project's containers: %w", err)
+	}
+	ids := strings.Fields(string(output))
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	format := `{{range .Mounts}}{{if eq .Type "volume"}}` +
+		`{{index $.Config.Labels "com.docker.compose.service"}}{{"\t"}}{{.Destination}}{{"\t"}}{{.Name}}{{"\n"}}` +
+		`{{end}}{{end}}`
+	args := append([]string{"inspect", "--format", format}, ids...)
+	output, err = exec.CommandContext(c.ctx, "docker", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect the project's containers: %w", err)
+	}
+	return parseAnonymousMounts(string(output)), nil
+}
+
+// parseAnonymousMounts reads "service<TAB>target<TAB>volume" lines, keeping
+// one anonymous volume per service mount (the first replica's)
+func parseAnonymousMounts(output string) []AnonymousMount {
+	var mounts []AnonymousMount
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 3 || fields[0] == "" || !anonymousName.MatchString(fields[2]) {
+			continue
+		}
+		m := AnonymousMount{Service: fields[0], Target: fields[1], Volume: fields[2]}
+		if !slices.ContainsFunc(mounts, func(o AnonymousMount) bool { return o.Service == m.Service && o.Target == m.Target }) {
+			mounts = append(mounts, m)
+		}
+	}
+	sort.Slice(mounts, func(i, j int) bool {
+		if mounts[i].Service != mounts[j].Service {
+			return mounts[i].Service < mounts[j].Service
+		}
+		return mounts[i].Target < mounts[j].Target
+	})
+	return mounts
+}
+
+// FindAnonymous returns the anonymous volume mounted at target in a service's container
+func FindAnonymous(mounts []AnonymousMount, service, target string) (AnonymousMount, bool) {
+	i := slices.IndexFunc(mounts, func(m AnonymousMount) bool { return m.Service == service && m.Target == target })
+	if i < 0 {
+		return AnonymousMount{}, false
+	}
+	return mounts[i], true
+}
+
+// aliasedMounts reports the mounts of a service that volume aliases adopt.
+// Compose's own anonymous mounts are replaced; aliases for mounts only the
+// image declares are added.
+func (c *Client) aliasedMounts(cfg *models.Config, serviceName string, service ComposeService, env map[string]string, anonymous []AnonymousMount, reports []MountReport) []MountReport {
+	for _, alias := range cfg.VolumeAliases {
+		if alias.Service != serviceName {
+			continue
+		}
+		report := MountReport{Service: serviceName, Mount: ServiceVolume{Type: MountTypeAnonymous, Target: alias.Target}}
+		i := slices.IndexFunc(reports, func(r MountReport) bool {
+			return r.Service == serviceName && r.Mount.Type == MountTypeAnonymous && r.Mount.Target == alias.Target
+		})
+
+		mount, found := FindAnonymous(anonymous, serviceName, alias.Target)
+		switch {
+		case !cfg.Included(models.Volume{Alias: alias.Alias}):
+			report.Reason = ReasonNotIncluded
+		case cfg.Excluded(models.Volume{Alias: alias.Alias}):
+			report.Reason = ReasonExcluded
+		case !found:
+			report.Reason = ReasonAliasNoContainer
+		default:
+			hint := cfg.DatastoreHints[alias.Alias]
+			if hint == "" {
+				hint = matchCustomDatastore(cfg.DatastoreTypes, service.Image, alias.Target)
+			}
+			datastoreType := c.inferDatastoreType(service.Image, alias.Target, env, hint)
+			report.Included = true
+			report.Reason = ReasonIncluded + " (alias " + alias.Alias + ")"
+			report.Volume = &models.Volume{
+				Name:          mount.Volume,
+				Alias:         alias.Alias,
+				DatastoreType: datastoreType,
+				Service:       serviceName,
+				ContainerName: service.ContainerName,
+				MountPath:     alias.Target,
+				ImageName:     service.Image,
+				Credentials:   datastore.DiscoverCredentials(datastoreType, env),
+			}
+		}
+
+		if i >= 0 {
+			reports[i] = report
+		} else {
+			reports = append(reports, report)
+		}
+	}
+	return reports
+}
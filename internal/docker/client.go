@@ -1,24 +1,49 @@
 package docker
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 
+	"github.com/stackgen-cli/dataclean/internal/datastore"
 	"github.com/stackgen-cli/dataclean/internal/models"
+	"github.com/stackgen-cli/dataclean/internal/pgzip"
 )
 
 // Client wraps Docker operations
 type Client struct {
-	ctx context.Context
+	ctx   context.Context
+	retry models.DockerRetryConfig // See SetRetryPolicy
 }
 
-// NewClient creates a new Docker client
+// daemonCheckTimeout bounds the check that the Docker daemon answers, so an
+// unreachable DOCKER_HOST fails fast instead of hanging
+const daemonCheckTimeout = 15 * time.Second
+
+// skipDaemonCheck is set by SkipDaemonCheck
+var skipDaemonCheck bool
+
+// SkipDaemonCheck makes NewClient skip checking that the Docker daemon is
+// running, for commands that may not need it
+func SkipDaemonCheck() {
+	skipDaemonCheck = true
+}
+
+// NewClient creates a new Docker client, checking that the daemon is running
+// so a stopped one is reported up front rather than as a failed export
 func NewClient() (*Client, error) {
 	// Verify docker is available
 	_, err := exec.LookPath("docker")
@@ -26,9 +51,66 @@ func NewClient() (*Client, error) {
 		return nil, fmt.Errorf("docker not found in PATH: %w", err)
 	}
 
-	return &Client{
+	c := &Client{
 		ctx: context.Background(),
-	}, nil
+	}
+	if !skipDaemonCheck {
+		if err := c.Ping(); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// Ping checks that the Docker daemon answers, and explains how to start it
+// when it doesn't
+func (c *Client) Ping() error {
+	ctx, cancel := context.WithTimeout(c.ctx, daemonCheckTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "docker", "version", "--format", "{{.Server.Version}}")
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("the Docker daemon didn't answer within %s%s", daemonCheckTimeout, daemonHint(runtime.GOOS, os.Getenv("DOCKER_HOST")))
+	}
+	if err != nil {
+		return daemonError(string(output), runtime.GOOS, os.Getenv("DOCKER_HOST"))
+	}
+	return nil
+}
+
+// daemonError turns the output of a failed 'docker version' into an error
+// saying what to do about it
+func daemonError(output, goos, dockerHost string) error {
+	output = strings.TrimSpace(output)
+	lower := strings.ToLower(output)
+	switch {
+	case strings.Contains(lower, "permission denied"):
+		return fmt.Errorf("permission denied talking to the Docker daemon: add your user to the docker group " +
+			"('sudo usermod -aG docker $USER', then log in again)")
+	case strings.Contains(lower, "cannot connect to the docker daemon"),
+		strings.Contains(lower, "is the docker daemon running"),
+		strings.Contains(lower, "error during connect"),
+		strings.Contains(lower, "connection refused"):
+		return fmt.Errorf("the Docker daemon isn't running%s", daemonHint(goos, dockerHost))
+	}
+	if output == "" {
+		output = "no output"
+	}
+	return fmt.Errorf("the Docker daemon can't be reached: %s", output)
+}
+
+// daemonHint says how to get a daemon running on this platform
+func daemonHint(goos, dockerHost string) string {
+	hint := "\n"
+	switch {
+	case dockerHost != "":
+		hint += fmt.Sprintf("DOCKER_HOST is %s: check that a daemon runs there, or unset it for the local one", dockerHost)
+	case goos == "darwin" || goos == "windows":
+		hint += "Start Docker Desktop and try again"
+	default:
+		hint += "Start it with 'sudo systemctl start docker' (or start Docker Desktop) and try again"
+	}
+	return hint + ". Commands that only read snapshots can run without it with --skip-docker-check"
 }
 
 // Close releases any resources
@@ -40,18 +122,37 @@ func (c *Client) Close() error {
 type ComposeConfig struct {
 	Services map[string]ComposeService `yaml:"services"`
 	Volumes  map[string]interface{}    `yaml:"volumes"`
+	Secrets  map[string]ComposeSecret  `yaml:"secrets"`
 }
 
 // ComposeService represents a service in docker-compose.yaml
 type ComposeService struct {
-	Image         string   `yaml:"image"`
-	ContainerName string   `yaml:"container_name"`
-	Volumes       []string `yaml:"volumes"`
+	Image         string          `yaml:"image"`
+	ContainerName string          `yaml:"container_name"`
+	Volumes       []ServiceVolume `yaml:"volumes"`
+	Tmpfs         stringList      `yaml:"tmpfs"`
+	Environment   envMap          `yaml:"environment"`
+	EnvFile       envFiles        `yaml:"env_file"`
+	Secrets       []serviceSecret `yaml:"secrets"`
 }
 
-// DetectComposeVolumes finds volumes defined in docker-compose.yaml
-func (c *Client) DetectComposeVolumes(cfg *models.Config) ([]models.Volume, error) {
-	// Find compose file
+// loadCompose finds and parses the compose file
+func (c *Client) loadCompose(cfg *models.Config) (*ComposeConfig, string, error) {
+	root, composeFile, err := c.readCompose(cfg)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var compose ComposeConfig
+	if err := root.Decode(&compose); err != nil {
+		return nil, "", fmt.Errorf("failed to parse %s: %w", composeFile, err)
+	}
+
+	return &compose, composeFile, nil
+}
+
+// FindComposeFile returns the configured compose file or the first standard name present
+func FindComposeFile(cfg *models.Config) (string, error) {
 	composeFile := cfg.ComposeFile
 	if composeFile == "" {
 		// Auto-detect
@@ -65,79 +166,417 @@ func (c *Client) DetectComposeVolumes(cfg *models.Config) ([]models.Volume, erro
 	}
 
 	if composeFile == "" {
-		return nil, fmt.Errorf("no compose file found in current directory")
+		return "", fmt.Errorf("no compose file found in current directory")
+	}
+	return composeFile, nil
+}
+
+// readCompose reads the compose file into a YAML tree with variables interpolated
+func (c *Client) readCompose(cfg *models.Config) (*yaml.Node, string, error) {
+	composeFile, err := FindComposeFile(cfg)
+	if err != nil {
+		return nil, "", err
 	}
 
 	// Parse compose file
 	data, err := os.ReadFile(composeFile)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read %s: %w", composeFile, err)
+		return nil, "", fmt.Errorf("failed to read %s: %w", composeFile, err)
 	}
 
-	var compose ComposeConfig
-	if err := yaml.Unmarshal(data, &compose); err != nil {
-		return nil, fmt.Errorf("failed to parse %s: %w", composeFile, err)
+	// Interpolate ${VAR} references (process env, then .env) before decoding
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, "", fmt.Errorf("failed to parse %s: %w", composeFile, err)
+	}
+	lookup, err := composeEnv(filepath.Dir(composeFile))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read .env: %w", err)
+	}
+	if err := interpolateNode(&root, lookup); err != nil {
+		return nil, "", fmt.Errorf("failed to interpolate %s: %w", composeFile, err)
 	}
 
-	// Extract volumes and infer datastore types
-	var volumes []models.Volume
-	projectName := c.getProjectName()
+	return &root, composeFile, nil
+}
 
-	for serviceName, service := range compose.Services {
-		for _, volumeMount := range service.Volumes {
-			// Parse volume mount (can be "volume:path" or "volume:path:mode")
-			parts := strings.Split(volumeMount, ":")
-			if len(parts) < 2 {
+// ResolvedCompose returns the compose file with variables interpolated, for
+// storing alongside a snapshot. Values of secret-looking environment variables
+// are always redacted.
+func (c *Client) ResolvedCompose(cfg *models.Config) ([]byte, error) {
+	root, _, err := c.readCompose(cfg)
+	if err != nil {
+		return nil, err
+	}
+	redactComposeSecrets(root)
+	return yaml.Marshal(root)
+}
+
+// redactComposeSecrets blanks environment values whose names suggest secrets
+// and passwords inside connection strings, in both the mapping (KEY: value)
+// and list (- KEY=value) forms
+func redactComposeSecrets(node *yaml.Node) {
+	switch node.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, n := range node.Content {
+			redactComposeSecrets(n)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Value == "environment" {
+				redactEnvironment(value)
 				continue
 			}
+			redactComposeSecrets(value)
+		}
+	}
+}
 
-			volumeName := parts[0]
-			mountPath := parts[1]
-
-			// Skip bind mounts (start with . or /)
-			if strings.HasPrefix(volumeName, ".") || strings.HasPrefix(volumeName, "/") {
+func redactEnvironment(env *yaml.Node) {
+	switch env.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(env.Content); i += 2 {
+			key, value := env.Content[i], env.Content[i+1]
+			if isSecretName(key.Value) && value.Value != "" {
+				value.Value = models.RedactedPassword
+				value.Tag = "!!str"
+			} else {
+				value.Value = models.RedactConnectionString(value.Value)
+			}
+		}
+	case yaml.SequenceNode:
+		for _, item := range env.Content {
+			k, v, ok := strings.Cut(item.Value, "=")
+			if !ok {
 				continue
 			}
+			if isSecretName(k) && v != "" {
+				v = models.RedactedPassword
+			}
+			item.Value = k + "=" + models.RedactConnectionString(v)
+		}
+	}
+}
+
+// isSecretName reports whether an environment variable name looks like it holds a secret.
+// *_FILE variables point at secret files and are kept.
+func isSecretName(name string) bool {
+	upper := strings.ToUpper(name)
+	if strings.HasSuffix(upper, "_FILE") {
+		return false
+	}
+	for _, marker := range []string{"PASSWORD", "PASSWD", "SECRET", "TOKEN", "API_KEY", "PRIVATE_KEY"} {
+		if strings.Contains(upper, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// ImageDigest returns a reference that pins an image exactly: the repo digest
+// (postgres@sha256:...) when the image came from a registry, else its local ID
+func (c *Client) ImageDigest(image string) (string, error) {
+	cmd := exec.CommandContext(c.ctx, "docker", "image", "inspect",
+		"--format", "{{if .RepoDigests}}{{index .RepoDigests 0}}{{else}}{{.Id}}{{end}}", image)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect image %s: %w", image, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// DaemonIdentity returns the ID and host name of the Docker daemon commands
+// go to, which tell one engine from another whatever DOCKER_HOST says
+func (c *Client) DaemonIdentity() (models.DaemonIdentity, error) {
+	cmd := exec.CommandContext(c.ctx, "docker", "info", "--format", "{{.ID}}\t{{.Name}}")
+	output, err := cmd.Output()
+	if err != nil {
+		return models.DaemonIdentity{}, fmt.Errorf("failed to query Docker daemon: %w", err)
+	}
+	id, name, _ := strings.Cut(strings.TrimSpace(string(output)), "\t")
+	return models.DaemonIdentity{ID: id, Name: name}, nil
+}
+
+// PinImage points an image tag back at a recorded digest, pulling it first
+// when it's a registry digest
+func (c *Client) PinImage(image, digest string) error {
+	if strings.Contains(digest, "@sha256:") {
+		cmd := exec.CommandContext(c.ctx, "docker", "pull", digest)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("pull %s failed: %s: %w", digest, string(output), err)
+		}
+	}
+
+	cmd := exec.CommandContext(c.ctx, "docker", "tag", digest, image)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("tag %s as %s failed: %s: %w", digest, image, string(output), err)
+	}
+	return nil
+}
+
+// RecreateServices recreates compose services so they run their (re-tagged) images
+func (c *Client) RecreateServices(cfg *models.Config, services []string) error {
+	composeFile, err := FindComposeFile(cfg)
+	if err != nil {
+		return err
+	}
+
+	args := append([]string{"compose", "-f", composeFile, "up", "-d", "--no-deps", "--force-recreate"}, services...)
+	cmd := exec.CommandContext(c.ctx, "docker", args...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("recreate %v failed: %s: %w", services, string(output), err)
+	}
+	return nil
+}
+
+// ComposeUp creates and starts every service of the compose project
+func (c *Client) ComposeUp(cfg *models.Config) error {
+	composeFile, err := FindComposeFile(cfg)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(c.ctx, "docker", "compose", "-f", composeFile, "up", "-d")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("compose up failed: %s: %w", string(output), err)
+	}
+	return nil
+}
+
+// DetectComposeVolumes finds volumes defined in docker-compose.yaml
+func (c *Client) DetectComposeVolumes(cfg *models.Config) ([]models.Volume, error) {
+	reports, err := c.DetectComposeMounts(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var volumes []models.Volume
+	for _, r := range reports {
+		if r.Included {
+			volumes = append(volumes, *r.Volume)
+		}
+	}
+	return volumes, nil
+}
+
+// DetectComposeMounts reports every service mount and whether it was included
+func (c *Client) DetectComposeMounts(cfg *models.Config) ([]MountReport, error) {
+	compose, composeFile, err := c.loadCompose(cfg)
+	if err != nil {
+		return nil, err
+	}
+	composeDir := filepath.Dir(composeFile)
+
+	// Extract volumes and infer datastore types
+	var reports []MountReport
+	projectName := c.getProjectName()
+
+	serviceNames := make([]string, 0, len(compose.Services))
+	for name := range compose.Services {
+		serviceNames = append(serviceNames, name)
+	}
+	sort.Strings(serviceNames)
+	for _, s := range cfg.IncludeServices {
+		if !slices.Contains(serviceNames, s) {
+			return nil, fmt.Errorf("no service named '%s' in %s (services: %s)", s, filepath.Base(composeFile), strings.Join(serviceNames, ", "))
+		}
+	}
+
+	// A volume mounted by several services is snapshotted once; this maps
+	// it to the report that includes it
+	included := make(map[string]int)
+
+	// Aliased anonymous volumes are found by mount in the containers
+	var anonymous []AnonymousMount
+	if len(cfg.VolumeAliases) > 0 {
+		if anonymous, err = c.AnonymousMounts(); err != nil {
+			return nil, err
+		}
+	}
 
-			// Check if volume is in compose volumes section
-			if compose.Volumes != nil {
-				if _, exists := compose.Volumes[volumeName]; !exists {
-					continue
+	for _, serviceName := range serviceNames {
+		service := compose.Services[serviceName]
+		env := compose.serviceEnv(service, composeDir)
+
+		for _, target := range service.Tmpfs {
+			reports = append(reports, MountReport{
+				Service: serviceName,
+				Mount:   ServiceVolume{Type: MountTypeTmpfs, Target: target},
+				Reason:  ReasonTmpfs,
+			})
+		}
+
+		for _, mount := range service.Volumes {
+			report := MountReport{Service: serviceName, Mount: mount}
+			volumeName := mount.Source
+			names := models.Volume{Name: fmt.Sprintf("%s_%s", projectName, volumeName), ComposeName: volumeName}
+
+			switch {
+			case mount.Type != MountTypeVolume:
+				report.Reason = reasonFor(mount.Type)
+			case compose.Volumes != nil && !hasKey(compose.Volumes, volumeName):
+				report.Reason = ReasonNotDeclared
+			case !cfg.Included(names):
+				report.Reason = ReasonNotIncluded
+			case cfg.Excluded(names):
+				report.Reason = ReasonExcluded
+			case volumeDevice(compose.Volumes[volumeName]) != "" &&
+				pathsOverlap(resolveHostPath(composeDir, volumeDevice(compose.Volumes[volumeName])), cfg.SnapshotDir):
+				// Snapshots would include themselves
+				report.Reason = ReasonSnapshotDir
+			default:
+				// Determine datastore type
+				hint := cfg.DatastoreHints[volumeName]
+				if hint == "" {
+					hint = matchCustomDatastore(cfg.DatastoreTypes, service.Image, mount.Target)
+				}
+				datastoreType := c.inferDatastoreType(service.Image, mount.Target, env, hint)
+
+				// Docker Compose prefixes volume names with project name
+				report.Included = true
+				report.Reason = ReasonIncluded
+				report.Volume = &models.Volume{
+					Name:          fmt.Sprintf("%s_%s", projectName, volumeName),
+					ComposeName:   volumeName,
+					DatastoreType: datastoreType,
+					Service:       serviceName,
+					ContainerName: service.ContainerName,
+					MountPath:     mount.Target,
+					ImageName:     service.Image,
+					Credentials:   datastore.DiscoverCredentials(datastoreType, env),
+				}
+				if i, ok := included[volumeName]; ok {
+					report = shareMount(&reports[i], report)
+				}
+				if report.Included {
+					included[volumeName] = len(reports)
 				}
 			}
 
-			// Apply include/exclude filters
-			if len(cfg.IncludeVolumes) > 0 && !contains(cfg.IncludeVolumes, volumeName) {
+			reports = append(reports, report)
+		}
+		reports = c.aliasedMounts(cfg, serviceName, service, env, anonymous, reports)
+	}
+
+	// Services are matched once shared volumes are settled, so a volume is
+	// selected through any service that mounts it
+	for i, r := range reports {
+		if r.Included && !cfg.ServiceSelected(*r.Volume) {
+			reports[i] = MountReport{Service: r.Service, Mount: r.Mount, Reason: ReasonNoService}
+		}
+	}
+	return reports, nil
+}
+
+// shareMount settles a volume mounted by a second service: the volume is
+// included once, through whichever service looks like its datastore (the
+// first, unless only the second is recognized), and the other mount is
+// reported as shared. It returns the second service's report.
+func shareMount(first *MountReport, report MountReport) MountReport {
+	owner, other := first, &report
+	if first.Volume.DatastoreType == models.DatastoreGeneric && report.Volume.DatastoreType != models.DatastoreGeneric {
+		owner, other = &report, first
+		owner.Volume.SharedWith = first.Volume.SharedWith
+	}
+	if other.Service != owner.Service && !contains(owner.Volume.SharedWith, other.Service) {
+		owner.Volume.SharedWith = append(owner.Volume.SharedWith, other.Service)
+	}
+	*other = MountReport{Service: other.Service, Mount: other.Mount, Reason: ReasonShared}
+	return report
+}
+
+// SnapshotDirConflicts reports compose mounts that overlap the snapshot directory
+func (c *Client) SnapshotDirConflicts(cfg *models.Config) ([]string, error) {
+	compose, composeFile, err := c.loadCompose(cfg)
+	if err != nil {
+		return nil, err
+	}
+	composeDir := filepath.Dir(composeFile)
+
+	var warnings []string
+
+	// Named volumes bound to a host path (driver_opts.device)
+	for name, def := range compose.Volumes {
+		device := volumeDevice(def)
+		if device != "" && pathsOverlap(resolveHostPath(composeDir, device), cfg.SnapshotDir) {
+			warnings = append(warnings, fmt.Sprintf(
+				"volume %s is backed by %s which overlaps snapshot dir %s; it is skipped", name, device, cfg.SnapshotDir))
+		}
+	}
+
+	// Bind mounts that expose the snapshot dir to a container
+	for serviceName, service := range compose.Services {
+		for _, mount := range service.Volumes {
+			if mount.Type != MountTypeBind {
 				continue
 			}
-			if contains(cfg.ExcludeVolumes, volumeName) {
-				continue
+			if pathsOverlap(resolveHostPath(composeDir, mount.Source), cfg.SnapshotDir) {
+				warnings = append(warnings, fmt.Sprintf(
+					"service %s bind-mounts %s which overlaps snapshot dir %s; the container can read or modify snapshots",
+					serviceName, mount.Source, cfg.SnapshotDir))
 			}
+		}
+	}
 
-			// Determine datastore type
-			datastoreType := c.inferDatastoreType(service.Image, mountPath, cfg.DatastoreHints[volumeName])
+	sort.Strings(warnings)
+	return warnings, nil
+}
 
-			// Docker Compose prefixes volume names with project name
-			fullVolumeName := fmt.Sprintf("%s_%s", projectName, volumeName)
+// volumeDevice returns the host path of a volume defined with driver_opts.device
+func volumeDevice(def interface{}) string {
+	spec, ok := def.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	opts, ok := spec["driver_opts"].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	device, _ := opts["device"].(string)
+	return device
+}
 
-			volumes = append(volumes, models.Volume{
-				Name:          fullVolumeName,
-				DatastoreType: datastoreType,
-				ContainerName: service.ContainerName,
-				MountPath:     mountPath,
-				ImageName:     service.Image,
-			})
+// isHostPath reports whether a mount source refers to a host path (bind mount)
+func isHostPath(source string) bool {
+	return strings.HasPrefix(source, ".") || strings.HasPrefix(source, "/") || strings.HasPrefix(source, "~")
+}
 
-			// Store reference to short name for display
-			_ = serviceName // used for context
+// resolveHostPath resolves a host path relative to the compose file directory
+func resolveHostPath(composeDir, path string) string {
+	if strings.HasPrefix(path, "~") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, strings.TrimPrefix(path, "~"))
 		}
 	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(composeDir, path)
+	}
+	return path
+}
 
-	return volumes, nil
+// pathsOverlap reports whether either path contains the other
+func pathsOverlap(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	return isWithin(absA, absB) || isWithin(absB, absA)
 }
 
-// inferDatastoreType determines the datastore type from image name or mount path
-func (c *Client) inferDatastoreType(image, mountPath string, hint models.DatastoreType) models.DatastoreType {
+// isWithin reports whether path is parent or lies beneath it
+func isWithin(path, parent string) bool {
+	rel, err := filepath.Rel(parent, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+// inferDatastoreType determines the datastore type from image name, service
+// environment, or mount path
+func (c *Client) inferDatastoreType(image, mountPath string, env map[string]string, hint models.DatastoreType) models.DatastoreType {
 	// Explicit hint takes precedence
 	if hint != "" {
 		return hint
@@ -157,6 +596,34 @@ func (c *Client) inferDatastoreType(image, mountPath string, hint models.Datasto
 		return models.DatastoreMongoDB
 	case strings.Contains(imageLower, "neo4j"):
 		return models.DatastoreNeo4j
+	case strings.Contains(imageLower, "elasticsearch"), strings.Contains(imageLower, "opensearch"):
+		return models.DatastoreElastic
+	case strings.Contains(imageLower, "clickhouse"):
+		return models.DatastoreClickHouse
+	case strings.Contains(imageLower, "cassandra"):
+		return models.DatastoreCassandra
+	case strings.Contains(imageLower, "rabbitmq"):
+		return models.DatastoreRabbitMQ
+	}
+
+	// Check for the official images' configuration variables
+	switch {
+	case hasEnv(env, "POSTGRES_PASSWORD", "POSTGRES_USER", "POSTGRES_DB"):
+		return models.DatastorePostgres
+	case hasEnv(env, "MYSQL_ROOT_PASSWORD", "MYSQL_DATABASE", "MARIADB_ROOT_PASSWORD", "MARIADB_DATABASE"):
+		return models.DatastoreMySQL
+	case hasEnv(env, "MONGO_INITDB_ROOT_USERNAME", "MONGO_INITDB_DATABASE"):
+		return models.DatastoreMongoDB
+	case hasEnv(env, "NEO4J_AUTH"):
+		return models.DatastoreNeo4j
+	case hasEnv(env, "ELASTIC_PASSWORD", "OPENSEARCH_INITIAL_ADMIN_PASSWORD", "discovery.type"):
+		return models.DatastoreElastic
+	case hasEnv(env, "CLICKHOUSE_USER", "CLICKHOUSE_PASSWORD", "CLICKHOUSE_DB"):
+		return models.DatastoreClickHouse
+	case hasEnv(env, "CASSANDRA_CLUSTER_NAME", "CASSANDRA_SEEDS"):
+		return models.DatastoreCassandra
+	case hasEnv(env, "RABBITMQ_DEFAULT_USER", "RABBITMQ_DEFAULT_PASS", "RABBITMQ_ERLANG_COOKIE"):
+		return models.DatastoreRabbitMQ
 	}
 
 	// Check mount path patterns
@@ -171,13 +638,35 @@ func (c *Client) inferDatastoreType(image, mountPath string, hint models.Datasto
 		return models.DatastoreMongoDB
 	case strings.Contains(mountPath, "neo4j"):
 		return models.DatastoreNeo4j
+	case strings.Contains(mountPath, "elasticsearch"), strings.Contains(mountPath, "opensearch"):
+		return models.DatastoreElastic
+	case strings.Contains(mountPath, "clickhouse"):
+		return models.DatastoreClickHouse
+	case strings.Contains(mountPath, "cassandra"):
+		return models.DatastoreCassandra
+	case strings.Contains(mountPath, "rabbitmq"):
+		return models.DatastoreRabbitMQ
 	}
 
 	return models.DatastoreGeneric
 }
 
-// getProjectName returns the Docker Compose project name (directory name by default)
+// matchCustomDatastore returns the first custom type whose rules match
+func matchCustomDatastore(types []models.CustomDatastore, image, mountPath string) models.DatastoreType {
+	for _, d := range types {
+		if d.Matches(image, mountPath) {
+			return d.Name
+		}
+	}
+	return ""
+}
+
+// getProjectName returns the Docker Compose project name: COMPOSE_PROJECT_NAME,
+// as for docker compose itself, or the directory name
 func (c *Client) getProjectName() string {
+	if name := os.Getenv("COMPOSE_PROJECT_NAME"); name != "" {
+		return name
+	}
 	cwd, err := os.Getwd()
 	if err != nil {
 		return "unknown"
@@ -185,75 +674,774 @@ func (c *Client) getProjectName() string {
 	return filepath.Base(cwd)
 }
 
-// StopContainers stops containers that use the specified volumes
-func (c *Client) StopContainers(volumes []models.Volume) error {
+// StopContainers stops the running containers that use the specified volumes
+// and returns the ones it stopped. Passing those to StartContainers leaves
+// containers that were already stopped as they were. Along with a volume's
+// container go the project's other containers mounting it, so a volume shared
+// by several services isn't written to during the copy, and any container
+// outside the project mounting it. Each container gets the longest timeout
+// of its volumes to shut down before Docker kills it; the volumes of the ones
+// that were killed are returned too.
+func (c *Client) StopContainers(volumes []models.Volume, timeout func(models.Volume) time.Duration) (stopped, killed []string) {
+	timeouts := make(map[string]time.Duration)
+	users := make(map[string][]string)
+	var running []string
 	for _, v := range volumes {
-		if v.ContainerName != "" {
-			cmd := exec.CommandContext(c.ctx, "docker", "stop", v.ContainerName)
-			cmd.Run() // Ignore errors - container might not be running
+		for _, name := range c.runningContainers(v) {
+			if _, seen := timeouts[name]; !seen {
+				running = append(running, name)
+			}
+			timeouts[name] = max(timeouts[name], timeout(v))
+			users[v.Name] = append(users[v.Name], name)
+		}
+	}
+	for _, name := range running {
+		args := append(append([]string{"stop"}, stopTimeoutArgs(timeouts[name])...), name)
+		err := c.withRetries("docker stop "+name, func() error {
+			if output, err := exec.CommandContext(c.ctx, "docker", args...).CombinedOutput(); err != nil {
+				return fmt.Errorf("%s: %w", output, err)
+			}
+			return nil
+		})
+		if err == nil {
+			stopped = append(stopped, name)
+		}
+	}
+
+	killedContainers := c.killedContainers(stopped)
+	for _, v := range volumes {
+		if slices.ContainsFunc(users[v.Name], func(name string) bool { return slices.Contains(killedContainers, name) }) {
+			killed = append(killed, v.Name)
+		}
+	}
+	return stopped, killed
+}
+
+// runningContainers returns the running containers to stop for a volume: its
+// own container and the project's others mounting it, and the containers
+// outside the project mounting it. Volumes without a container_name have no
+// project containers to stop; they are copied hot.
+func (c *Client) runningContainers(volume models.Volume) []string {
+	project, foreign, _ := c.VolumeUsers(volume)
+	if volume.ContainerName == "" {
+		return foreign
+	}
+	var names []string
+	if c.IsRunning(volume.ContainerName) {
+		names = append(names, volume.ContainerName)
+	}
+	for _, name := range append(project, foreign...) {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
 		}
 	}
+	return names
+}
+
+// VolumeUsers returns the running containers that mount a volume: those of
+// the compose project, and the rest (one-off debug containers, other projects)
+func (c *Client) VolumeUsers(volume models.Volume) (project, foreign []string, err error) {
+	cmd := exec.CommandContext(c.ctx, "docker", "ps",
+		"--filter", "volume="+volume.Name,
+		"--format", `{{.Names}}\t{{.Label "com.docker.compose.project"}}`)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list containers for volume %s: %w", volume.Name, err)
+	}
+	project, foreign = splitVolumeUsers(string(output), c.getProjectName())
+	return project, foreign, nil
+}
+
+// splitVolumeUsers reads "name<TAB>compose project" lines from docker ps
+func splitVolumeUsers(output, projectName string) (project, foreign []string) {
+	for _, line := range strings.Split(output, "\n") {
+		name, label, _ := strings.Cut(line, "\t")
+		switch {
+		case name == "":
+		case label == projectName:
+			project = append(project, name)
+		default:
+			foreign = append(foreign, name)
+		}
+	}
+	return project, foreign
+}
+
+// stopTimeoutArgs passes a stop timeout to 'docker stop' or 'docker compose
+// stop' in whole seconds, rounded up; 0 leaves Docker's default
+func stopTimeoutArgs(timeout time.Duration) []string {
+	if timeout <= 0 {
+		return nil
+	}
+	return []string{"--time", strconv.Itoa(int((timeout + time.Second - 1) / time.Second))}
+}
+
+// killedExitCode is what a container exits with when SIGKILL ends it,
+// as Docker does once the stop timeout runs out
+const killedExitCode = 137
+
+// killedContainers returns the containers, by name or ID, that exited on SIGKILL
+func (c *Client) killedContainers(containers []string) []string {
+	if len(containers) == 0 {
+		return nil
+	}
+	args := append([]string{"inspect", "--format", "{{.State.ExitCode}}"}, containers...)
+	output, err := exec.CommandContext(c.ctx, "docker", args...).Output()
+	if err != nil {
+		return nil
+	}
+	var killed []string
+	for i, code := range strings.Fields(string(output)) {
+		if i < len(containers) && code == strconv.Itoa(killedExitCode) {
+			killed = append(killed, containers[i])
+		}
+	}
+	return killed
+}
+
+// StartContainers starts containers by name
+func (c *Client) StartContainers(containers []string) error {
+	for _, name := range containers {
+		// Ignore errors - container might not exist
+		c.withRetries("docker start "+name, func() error {
+			if output, err := exec.CommandContext(c.ctx, "docker", "start", name).CombinedOutput(); err != nil {
+				return fmt.Errorf("%s: %w", output, err)
+			}
+			return nil
+		})
+	}
 	return nil
 }
 
-// StartContainers starts containers that use the specified volumes
-func (c *Client) StartContainers(volumes []models.Volume) error {
-	for _, v := range volumes {
-		if v.ContainerName != "" {
-			cmd := exec.CommandContext(c.ctx, "docker", "start", v.ContainerName)
-			cmd.Run() // Ignore errors - container might not exist
+// StopProject stops every running service of the compose project with
+// 'docker compose stop', which stops dependents before the services they
+// depend on, giving each timeout to shut down. It returns the services it
+// stopped, for StartServices, and those Docker had to kill.
+func (c *Client) StopProject(cfg *models.Config, timeout time.Duration) (stopped, killed []string, err error) {
+	composeFile, err := FindComposeFile(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cmd := exec.CommandContext(c.ctx, "docker", "compose", "-f", composeFile, "ps", "--services", "--status", "running")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list running services: %w", err)
+	}
+	services := strings.Fields(string(output))
+	if len(services) == 0 {
+		return nil, nil, nil
+	}
+
+	args := append(append([]string{"compose", "-f", composeFile, "stop"}, stopTimeoutArgs(timeout)...), services...)
+	err = c.withRetries("docker compose stop", func() error {
+		if output, err := exec.CommandContext(c.ctx, "docker", args...).CombinedOutput(); err != nil {
+			return fmt.Errorf("compose stop failed: %s: %w", string(output), err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Map the killed containers back to their services
+	args = append([]string{"compose", "-f", composeFile, "ps", "--all", "--quiet"}, services...)
+	if output, err := exec.CommandContext(c.ctx, "docker", args...).Output(); err == nil {
+		for _, id := range c.killedContainers(strings.Fields(string(output))) {
+			cmd := exec.CommandContext(c.ctx, "docker", "inspect", "--format", `{{index .Config.Labels "com.docker.compose.service"}}`, id)
+			if out, err := cmd.Output(); err == nil {
+				killed = append(killed, strings.TrimSpace(string(out)))
+			}
+		}
+	}
+	return services, killed, nil
+}
+
+// StartServices starts compose services with 'docker compose start', which
+// starts dependencies first
+func (c *Client) StartServices(cfg *models.Config, services []string) error {
+	if len(services) == 0 {
+		return nil
+	}
+	composeFile, err := FindComposeFile(cfg)
+	if err != nil {
+		return err
+	}
+
+	args := append([]string{"compose", "-f", composeFile, "start"}, services...)
+	return c.withRetries("docker compose start", func() error {
+		if output, err := exec.CommandContext(c.ctx, "docker", args...).CombinedOutput(); err != nil {
+			return fmt.Errorf("compose start failed: %s: %w", string(output), err)
+		}
+		return nil
+	})
+}
+
+// IsRunning reports whether a container exists and is running
+func (c *Client) IsRunning(container string) bool {
+	cmd := exec.CommandContext(c.ctx, "docker", "inspect", "--format", "{{.State.Running}}", container)
+	output, err := cmd.Output()
+	return err == nil && strings.TrimSpace(string(output)) == "true"
+}
+
+// ServiceState is the container of a compose service and how it's doing
+type ServiceState struct {
+	Service   string
+	Container string // empty when the service has no container
+	State     string // running, exited, created, ...
+	Health    string // healthy, unhealthy, or starting; empty without a healthcheck
+}
+
+// ServiceStates returns the state of every service in the compose file, in
+// name order, going by each service's first container
+func (c *Client) ServiceStates(cfg *models.Config) ([]ServiceState, error) {
+	compose, _, err := c.loadCompose(cfg)
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.CommandContext(c.ctx, "docker", "ps", "-a",
+		"--filter", "label=com.docker.compose.project="+c.getProjectName(),
+		"--format", `{{.Label "com.docker.compose.service"}}\t{{.Names}}\t{{.State}}\t{{.Status}}`)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the project's containers: %w", err)
+	}
+	containers := parseServiceStates(string(output))
+
+	states := make([]ServiceState, 0, len(compose.Services))
+	for name := range compose.Services {
+		st, ok := containers[name]
+		if !ok {
+			st = ServiceState{Service: name}
+		}
+		states = append(states, st)
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Service < states[j].Service })
+	return states, nil
+}
+
+// parseServiceStates reads "service<TAB>name<TAB>state<TAB>status" lines from
+// docker ps, keeping the first container listed for each service
+func parseServiceStates(output string) map[string]ServiceState {
+	states := make(map[string]ServiceState)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 4 || fields[0] == "" {
+			continue
+		}
+		if _, ok := states[fields[0]]; ok {
+			continue
+		}
+		st := ServiceState{Service: fields[0], Container: fields[1], State: fields[2]}
+		switch {
+		case strings.Contains(fields[3], "(healthy)"):
+			st.Health = "healthy"
+		case strings.Contains(fields[3], "(unhealthy)"):
+			st.Health = "unhealthy"
+		case strings.Contains(fields[3], "(health: starting)"):
+			st.Health = "starting"
+		}
+		states[fields[0]] = st
+	}
+	return states
+}
+
+// ContainerHealth returns a container's healthcheck status: healthy,
+// unhealthy, or starting, or "" when it has no healthcheck
+func (c *Client) ContainerHealth(container string) (string, error) {
+	cmd := exec.CommandContext(c.ctx, "docker", "inspect", "--format", "{{if .State.Health}}{{.State.Health.Status}}{{end}}", container)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect container %s: %w", container, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// InspectContainer returns a container's 'docker inspect' output with the
+// values of secret-looking environment variables redacted
+func (c *Client) InspectContainer(container string) ([]byte, error) {
+	cmd := exec.CommandContext(c.ctx, "docker", "inspect", "--type", "container", container)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect container %s: %w", container, err)
+	}
+	var inspect []map[string]interface{}
+	if err := json.Unmarshal(output, &inspect); err != nil || len(inspect) != 1 {
+		return nil, fmt.Errorf("unexpected inspect output for container %s", container)
+	}
+
+	if config, ok := inspect[0]["Config"].(map[string]interface{}); ok {
+		env, _ := config["Env"].([]interface{})
+		for i, item := range env {
+			k, v, _ := strings.Cut(fmt.Sprint(item), "=")
+			if isSecretName(k) && v != "" {
+				v = models.RedactedPassword
+			}
+			env[i] = k + "=" + models.RedactConnectionString(v)
+		}
+	}
+	return json.MarshalIndent(inspect[0], "", "  ")
+}
+
+// ContainerSettings picks the settings worth comparing between runs out of
+// InspectContainer output: image, command, environment, and resource limits
+func ContainerSettings(inspect []byte) (map[string]string, error) {
+	var container struct {
+		Config struct {
+			Image string
+			Cmd   []string
+			Env   []string
+		}
+		HostConfig struct {
+			Memory        int64
+			NanoCpus      int64
+			RestartPolicy struct{ Name string }
+		}
+	}
+	if err := json.Unmarshal(inspect, &container); err != nil {
+		return nil, err
+	}
+
+	settings := map[string]string{
+		"container.image":   container.Config.Image,
+		"container.command": strings.Join(container.Config.Cmd, " "),
+		"container.memory":  strconv.FormatInt(container.HostConfig.Memory, 10),
+		"container.cpus":    strconv.FormatFloat(float64(container.HostConfig.NanoCpus)/1e9, 'f', -1, 64),
+		"container.restart": container.HostConfig.RestartPolicy.Name,
+	}
+	for _, e := range container.Config.Env {
+		k, v, _ := strings.Cut(e, "=")
+		settings["container.env."+k] = v
+	}
+	return settings, nil
+}
+
+// RestartContainer restarts a container, e.g. to apply server settings
+func (c *Client) RestartContainer(container string) error {
+	return c.withRetries("docker restart "+container, func() error {
+		output, err := exec.CommandContext(c.ctx, "docker", "restart", container).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("restart failed: %s: %w", string(output), err)
+		}
+		return nil
+	})
+}
+
+// VolumeContainers returns every container (running or not) that mounts a volume
+func (c *Client) VolumeContainers(volume models.Volume) ([]models.ContainerStatus, error) {
+	cmd := exec.CommandContext(c.ctx, "docker", "ps", "-a",
+		"--filter", "volume="+volume.Name,
+		"--format", "{{.Names}}\t{{.State}}")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers for volume %s: %w", volume.Name, err)
+	}
+
+	var containers []models.ContainerStatus
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		name, state, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
 		}
+		containers = append(containers, models.ContainerStatus{Name: name, State: state})
+	}
+	return containers, nil
+}
+
+// LastModified returns the newest modification time of any file or directory
+// in a volume. Deletions show up through their parent directory's mtime.
+func (c *Client) LastModified(volume models.Volume) (time.Time, error) {
+	cmd := exec.CommandContext(c.ctx, "docker", "run", "--rm",
+		"-v", fmt.Sprintf("%s:/data:ro", volume.Name),
+		archiveImage,
+		"sh", "-c", `find /data -printf '%T@\n' | sort -n | tail -1`)
+
+	output, err := cmd.Output()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to read modification times of %s: %w", volume.Name, err)
+	}
+
+	secs, err := strconv.ParseFloat(strings.TrimSpace(string(output)), 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unexpected find output for %s: %q", volume.Name, output)
+	}
+	return time.Unix(0, int64(secs*float64(time.Second))), nil
+}
+
+// Fingerprint returns a quick hash of a volume's file listing: paths, types,
+// sizes, modification times, modes, owners, and link targets. File contents
+// are not read, so it costs a directory walk rather than an export.
+func (c *Client) Fingerprint(volume models.Volume) (string, error) {
+	cmd := exec.CommandContext(c.ctx, "docker", "run", "--rm",
+		"-v", fmt.Sprintf("%s:/data:ro", volume.Name),
+		archiveImage,
+		"sh", "-c", `cd /data && find . -printf '%P\t%y\t%s\t%T@\t%m\t%U:%G\t%l\n' | LC_ALL=C sort | sha256sum | cut -d' ' -f1`)
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to fingerprint %s: %w", volume.Name, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// ResolveContainer returns the running container that mounts a volume's service
+func (c *Client) ResolveContainer(volume models.Volume) (string, error) {
+	if volume.ContainerName != "" {
+		return volume.ContainerName, nil
+	}
+	if volume.Service == "" {
+		return "", fmt.Errorf("no container or service known for volume %s", volume.Name)
+	}
+
+	// Compose labels its containers with project and service names
+	cmd := exec.CommandContext(c.ctx, "docker", "ps", "-q",
+		"--filter", "label=com.docker.compose.project="+c.getProjectName(),
+		"--filter", "label=com.docker.compose.service="+volume.Service)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to find container for service %s: %w", volume.Service, err)
+	}
+
+	ids := strings.Fields(string(output))
+	if len(ids) == 0 {
+		return "", fmt.Errorf("no running container for service %s", volume.Service)
 	}
+	return ids[0], nil
+}
+
+// ExecOutput runs a shell command inside a container and returns its stdout.
+// env ("KEY=value") is passed into the container.
+func (c *Client) ExecOutput(container, script string, env ...string) (string, error) {
+	var stderr strings.Builder
+	cmd := c.execCommand(container, script, env)
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("exec in %s failed: %s: %w", container, stderr.String(), err)
+	}
+	return string(output), nil
+}
+
+// ExecToFile runs a shell command inside a container and writes its stdout to destPath
+func (c *Client) ExecToFile(container, script, destPath string, env ...string) error {
+	f, err := os.Create(destPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var stderr strings.Builder
+	cmd := c.execCommand(container, script, env)
+	cmd.Stdout = f
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("exec in %s failed: %s: %w", container, stderr.String(), err)
+	}
+
 	return nil
 }
 
-// ExportVolume exports a volume's contents to a tar file
+// ExecInteractive runs a shell command inside a container attached to the
+// terminal, for interactive clients such as psql
+func (c *Client) ExecInteractive(container, script string, env ...string) error {
+	cmd := c.execCommand(container, script, env, "-it")
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// execCommand builds a docker exec invocation. Values are handed over through
+// the docker CLI's environment ("-e KEY") so they don't show up in process lists.
+func (c *Client) execCommand(container, script string, env []string, flags ...string) *exec.Cmd {
+	args := append([]string{"exec"}, flags...)
+	for _, kv := range env {
+		key, _, _ := strings.Cut(kv, "=")
+		args = append(args, "-e", key)
+	}
+	args = append(args, container, "sh", "-c", script)
+
+	cmd := exec.CommandContext(c.ctx, "docker", args...)
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	return cmd
+}
+
+// archiveImage provides GNU tar for exports and imports. Busybox tar (alpine)
+// drops extended attributes and ACLs and stores sparse files at full size.
+const archiveImage = "debian:bookworm-slim"
+
+// Flags shared by export and import so attributes survive the round trip
+var tarAttrFlags = []string{"--xattrs", "--xattrs-include=*", "--acls", "--numeric-owner"}
+
+// ExportVolume exports a volume's contents to a tar file in
+// models.ArchiveFormatPAX, keeping sparse files, xattrs, and ACLs. tar runs
+// in the helper container; gzip runs here on every core, since a single
+// compression thread is what limits large exports.
 func (c *Client) ExportVolume(volume models.Volume, destPath string) error {
 	// Create a temporary container to access the volume
-	cmd := exec.CommandContext(c.ctx, "docker", "run", "--rm",
+	args := []string{"run", "--rm",
 		"-v", fmt.Sprintf("%s:/data:ro", volume.Name),
-		"-v", fmt.Sprintf("%s:/backup", filepath.Dir(destPath)),
-		"alpine",
-		"tar", "czf", fmt.Sprintf("/backup/%s", filepath.Base(destPath)), "-C", "/data", ".")
+		archiveImage,
+		"tar", "--format=posix", "--sparse"}
+	args = append(args, tarAttrFlags...)
+	args = append(args, "-cf", "-", "-C", "/data", ".")
+	return c.withRetries("export of "+volume.Name, func() error {
+		return c.exportTo(args, destPath)
+	})
+}
 
-	output, err := cmd.CombinedOutput()
+// exportTo runs the export's helper container, compressing its tar output
+// into destPath. The archive is written next to it and renamed into place, so
+// a destPath hard-linked from another snapshot's archive is replaced rather
+// than rewritten, and a failed export leaves no partial archive there.
+func (c *Client) exportTo(args []string, destPath string) error {
+	cmd := exec.CommandContext(c.ctx, "docker", args...)
+	out, err := os.CreateTemp(filepath.Dir(destPath), "."+filepath.Base(destPath)+".*.tmp")
 	if err != nil {
-		return fmt.Errorf("export failed: %s: %w", string(output), err)
+		return fmt.Errorf("export failed: %w", err)
 	}
+	defer os.Remove(out.Name())
+	defer out.Close()
 
+	z := pgzip.NewWriter(out, 0)
+	var stderr bytes.Buffer
+	cmd.Stdout = z
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		z.Close()
+		return fmt.Errorf("export failed: %s: %w", stderr.String(), err)
+	}
+	if err := z.Close(); err != nil {
+		return fmt.Errorf("export failed: %w", err)
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("export failed: %w", err)
+	}
+	if err := os.Rename(out.Name(), destPath); err != nil {
+		return fmt.Errorf("export failed: %w", err)
+	}
 	return nil
 }
 
-// ImportVolume imports a tar file into a volume
+// ImportVolume extracts a tar file into a volume. Existing files are
+// overwritten but not removed, so clear the volume first (see ClearVolume).
+// Archives in either format are accepted.
 func (c *Client) ImportVolume(srcPath string, volume models.Volume) error {
-	// Clear existing data
-	clearCmd := exec.CommandContext(c.ctx, "docker", "run", "--rm",
-		"-v", fmt.Sprintf("%s:/data", volume.Name),
-		"alpine",
-		"sh", "-c", "rm -rf /data/* /data/.*")
-	clearCmd.Run() // Ignore errors
+	return c.importArchive(srcPath, "/data", "-v", fmt.Sprintf("%s:/data", volume.Name))
+}
 
-	// Import from tar
-	cmd := exec.CommandContext(c.ctx, "docker", "run", "--rm",
+// ImportStream extracts an uncompressed tar stream into a volume, like
+// ImportVolume, without the archive ever touching the local disk
+func (c *Client) ImportStream(r io.Reader, volume models.Volume) error {
+	args := []string{"run", "--rm", "-i",
 		"-v", fmt.Sprintf("%s:/data", volume.Name),
-		"-v", fmt.Sprintf("%s:/backup:ro", filepath.Dir(srcPath)),
-		"alpine",
-		"tar", "xzf", fmt.Sprintf("/backup/%s", filepath.Base(srcPath)), "-C", "/data")
+		archiveImage,
+		"tar", "--same-permissions"}
+	args = append(args, tarAttrFlags...)
+	args = append(args, "-xf", "-", "-C", "/data")
+	cmd := exec.CommandContext(c.ctx, "docker", args...)
+	cmd.Stdin = r
 
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("import failed: %s: %w", string(output), err)
 	}
+	return nil
+}
+
+// ImportInto extracts a tar file into dataPath of a stopped container, which
+// must have a volume mounted there
+func (c *Client) ImportInto(srcPath, container, dataPath string) error {
+	return c.importArchive(srcPath, dataPath, "--volumes-from", container)
+}
+
+// importArchive extracts a tar file to dest in a helper container set up by mountArgs
+func (c *Client) importArchive(srcPath, dest string, mountArgs ...string) error {
+	args := append([]string{"run", "--rm"}, mountArgs...)
+	args = append(args,
+		"-v", fmt.Sprintf("%s:/backup:ro", filepath.Dir(srcPath)),
+		archiveImage,
+		"tar", "--same-permissions")
+	args = append(args, tarAttrFlags...)
+	args = append(args, "-xzf", fmt.Sprintf("/backup/%s", filepath.Base(srcPath)), "-C", dest)
+
+	// Extracting again overwrites whatever a failed attempt left
+	return c.withRetries("import of "+filepath.Base(srcPath), func() error {
+		output, err := exec.CommandContext(c.ctx, "docker", args...).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("import failed: %s: %w", string(output), err)
+		}
+		return nil
+	})
+}
+
+// CreateVolume creates an empty named volume with labels ("key=value")
+func (c *Client) CreateVolume(name string, labels ...string) error {
+	args := []string{"volume", "create"}
+	for _, l := range labels {
+		args = append(args, "--label", l)
+	}
+	args = append(args, name)
+	cmd := exec.CommandContext(c.ctx, "docker", args...)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to create volume %s: %s: %w", name, string(output), err)
+	}
+	return nil
+}
+
+// VolumeLabels returns a volume's labels. exists is false when there is no such volume.
+func (c *Client) VolumeLabels(name string) (labels map[string]string, exists bool, err error) {
+	var stderr strings.Builder
+	cmd := exec.CommandContext(c.ctx, "docker", "volume", "inspect", "--format", "{{json .Labels}}", name)
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if strings.Contains(strings.ToLower(stderr.String()), "no such volume") {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("failed to inspect volume %s: %s: %w", name, stderr.String(), err)
+	}
+	if err := json.Unmarshal(output, &labels); err != nil {
+		return nil, true, fmt.Errorf("failed to read labels of volume %s: %w", name, err)
+	}
+	return labels, true, nil
+}
+
+// EnsureVolume creates a missing volume with labels ("key=value"), reporting
+// whether it did. Compose volumes get the compose labels too, so 'docker
+// compose' adopts them instead of warning that compose didn't create them.
+// Existing volumes are left alone: Docker can't change their labels.
+func (c *Client) EnsureVolume(volume models.Volume, labels ...string) (bool, error) {
+	if _, exists, err := c.VolumeLabels(volume.Name); exists || err != nil {
+		return false, err
+	}
+	// Compose names volumes <project>_<volume> and refuses ones labelled
+	// with another project, so the labels are only added when that holds
+	if project, ok := strings.CutSuffix(volume.Name, "_"+volume.ComposeName); ok && volume.ComposeName != "" && project != "" {
+		labels = append(labels, "com.docker.compose.project="+project, "com.docker.compose.volume="+volume.ComposeName)
+	}
+	return true, c.CreateVolume(volume.Name, labels...)
+}
+
+// RemoveVolume deletes a volume and its data
+func (c *Client) RemoveVolume(name string) error {
+	cmd := exec.CommandContext(c.ctx, "docker", "volume", "rm", "-f", name)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to remove volume %s: %s: %w", name, string(output), err)
+	}
+	return nil
+}
+
+// RunDetached starts a named container from image in the background with a
+// volume mounted at mountPath
+func (c *Client) RunDetached(name, image, volume, mountPath string) error {
+	cmd := exec.CommandContext(c.ctx, "docker", "run", "-d", "--name", name,
+		"--label", "dataclean.temporary=true",
+		"-v", fmt.Sprintf("%s:%s", volume, mountPath),
+		image)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to start %s: %s: %w", image, string(output), err)
+	}
+	return nil
+}
+
+// CreateContainer creates a stopped container from image with an anonymous
+// volume at dataPath and port published on a random localhost port
+func (c *Client) CreateContainer(name, image, dataPath string, port int, labels ...string) error {
+	args := []string{"create", "--name", name}
+	for _, l := range labels {
+		args = append(args, "--label", l)
+	}
+	args = append(args, "-v", dataPath, "-p", fmt.Sprintf("127.0.0.1::%d", port), image)
+	cmd := exec.CommandContext(c.ctx, "docker", args...)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to create container from %s: %s: %w", image, string(output), err)
+	}
+	return nil
+}
+
+// StartContainer starts a stopped container
+func (c *Client) StartContainer(name string) error {
+	cmd := exec.CommandContext(c.ctx, "docker", "start", name)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to start container %s: %s: %w", name, string(output), err)
+	}
+	return nil
+}
+
+// HostAddress returns the host address ("127.0.0.1:49153") a container port is published on
+func (c *Client) HostAddress(container string, port int) (string, error) {
+	cmd := exec.CommandContext(c.ctx, "docker", "port", container, fmt.Sprintf("%d/tcp", port))
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to look up port %d of %s: %w", port, container, err)
+	}
+	// One line per binding; the first is enough
+	addr, _, _ := strings.Cut(strings.TrimSpace(string(output)), "\n")
+	if addr == "" {
+		return "", fmt.Errorf("port %d of %s is not published", port, container)
+	}
+	return addr, nil
+}
+
+// ContainersWithLabel returns the names of containers, running or not, with a label ("key=value")
+func (c *Client) ContainersWithLabel(label string) ([]string, error) {
+	cmd := exec.CommandContext(c.ctx, "docker", "ps", "-a",
+		"--filter", "label="+label,
+		"--format", "{{.Names}}")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+	return strings.Fields(string(output)), nil
+}
+
+// WaitContainer blocks until a container stops or is removed
+func (c *Client) WaitContainer(name string) error {
+	return exec.CommandContext(c.ctx, "docker", "wait", name).Run()
+}
+
+// RemoveContainer stops and deletes a container
+func (c *Client) RemoveContainer(name string) error {
+	cmd := exec.CommandContext(c.ctx, "docker", "rm", "-f", "-v", name)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to remove container %s: %s: %w", name, string(output), err)
+	}
+	return nil
+}
+
+// BuildImage builds and tags an image from a local build context
+func (c *Client) BuildImage(contextDir, tag string) error {
+	cmd := exec.CommandContext(c.ctx, "docker", "build", "-t", tag, contextDir)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("build failed: %s: %w", string(output), err)
+	}
 
 	return nil
 }
 
-// ClearVolume removes all data from a volume
+// ClearVolume removes all data from a volume, including hidden files
 func (c *Client) ClearVolume(volume models.Volume) error {
 	cmd := exec.CommandContext(c.ctx, "docker", "run", "--rm",
 		"-v", fmt.Sprintf("%s:/data", volume.Name),
 		"alpine",
-		"sh", "-c", "rm -rf /data/* /data/.*")
+		"find", "/data", "-mindepth", "1", "-delete")
 
 	output, err := cmd.CombinedOutput()
 	if err != nil {
@@ -263,6 +1451,44 @@ func (c *Client) ClearVolume(volume models.Volume) error {
 	return nil
 }
 
+// ClearNonEmptyVolume removes all data from a volume unless it is already
+// empty, reporting whether there was anything to remove
+func (c *Client) ClearNonEmptyVolume(volume models.Volume) (bool, error) {
+	cmd := exec.CommandContext(c.ctx, "docker", "run", "--rm",
+		"-v", fmt.Sprintf("%s:/data", volume.Name),
+		"alpine",
+		"sh", "-c", `[ -z "$(ls -A /data)" ] || { find /data -mindepth 1 -delete && echo cleared; }`)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return false, fmt.Errorf("clear failed: %s: %w", string(output), err)
+	}
+
+	return strings.Contains(string(output), "cleared"), nil
+}
+
+// VolumeScript runs a shell script in a helper container with the volume at
+// /data and a host directory at /host (read-only), for preparing a volume's
+// contents while its service is stopped
+func (c *Client) VolumeScript(volume models.Volume, hostDir, script string) error {
+	hostDir, err := filepath.Abs(hostDir)
+	if err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(c.ctx, "docker", "run", "--rm",
+		"-v", fmt.Sprintf("%s:/data", volume.Name),
+		"-v", fmt.Sprintf("%s:/host:ro", hostDir),
+		archiveImage,
+		"sh", "-c", script)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("script failed in volume %s: %s: %w", volume.Name, string(output), err)
+	}
+
+	return nil
+}
+
 // GetVolumeSize returns the size of a volume in bytes
 func (c *Client) GetVolumeSize(volume models.Volume) (int64, error) {
 	cmd := exec.CommandContext(c.ctx, "docker", "run", "--rm",
@@ -280,6 +1506,21 @@ func (c *Client) GetVolumeSize(volume models.Volume) (int64, error) {
 	return size, nil
 }
 
+// hasEnv reports whether any of keys is set in env
+func hasEnv(env map[string]string, keys ...string) bool {
+	for _, k := range keys {
+		if _, ok := env[k]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func hasKey(m map[string]interface{}, key string) bool {
+	_, ok := m[key]
+	return ok
+}
+
 func contains(slice []string, item string) bool {
 	for _, s := range slice {
 		if s == item {
@@ -0,0 +1,690 @@
+package docker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+func TestPathsOverlap(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected bool
+	}{
+		{"/project/.dataclean", "/project/.dataclean", true},
+		{"/project", "/project/.dataclean", true},
+		{"/project/.dataclean/snap", "/project/.dataclean", true},
+		{"/project/data", "/project/.dataclean", false},
+		{"/project/.dataclean-old", "/project/.dataclean", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"|"+tt.b, func(t *testing.T) {
+			if got := pathsOverlap(tt.a, tt.b); got != tt.expected {
+				t.Errorf("pathsOverlap(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSnapshotDirSelfReference(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "dataclean-docker-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	compose := `
+services:
+  db:
+    image: postgres:16
+    volumes:
+      - pgdata:/var/lib/postgresql/data
+      - snapshots:/snapshots
+  app:
+    image: node:20
+    volumes:
+      - .:/app
+volumes:
+  pgdata:
+  snapshots:
+    driver: local
+    driver_opts:
+      type: none
+      o: bind
+      device: ./.dataclean
+`
+	composePath := filepath.Join(tmpDir, "compose.yaml")
+	if err := os.WriteFile(composePath, []byte(compose), 0644); err != nil {
+		t.Fatalf("failed to write compose file: %v", err)
+	}
+
+	cfg := &models.Config{ComposeFile: composePath, SnapshotDir: filepath.Join(tmpDir, ".dataclean")}
+	c := &Client{}
+
+	volumes, err := c.DetectComposeVolumes(cfg)
+	if err != nil {
+		t.Fatalf("DetectComposeVolumes() failed: %v", err)
+	}
+	if len(volumes) != 1 || volumes[0].ComposeName != "pgdata" {
+		t.Errorf("expected only pgdata to be detected, got %+v", volumes)
+	}
+
+	warnings, err := c.SnapshotDirConflicts(cfg)
+	if err != nil {
+		t.Fatalf("SnapshotDirConflicts() failed: %v", err)
+	}
+	if len(warnings) != 2 {
+		t.Errorf("expected 2 warnings (backed volume + bind mount), got %d: %v", len(warnings), warnings)
+	}
+}
+
+func TestDetectComposeMounts_Reasons(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "dataclean-docker-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	compose := `
+services:
+  db:
+    image: postgres:16
+    volumes:
+      - pgdata:/var/lib/postgresql/data
+      - ./init:/docker-entrypoint-initdb.d:ro
+      - /var/lib/extra
+  cache:
+    image: redis:7
+    tmpfs: /tmp
+    volumes:
+      - type: volume
+        source: cachedata
+        target: /data
+      - type: tmpfs
+        target: /run
+      - undeclared:/other
+  search:
+    image: elasticsearch:8
+    volumes:
+      - esdata:/usr/share/elasticsearch/data
+volumes:
+  pgdata:
+  cachedata:
+  esdata:
+`
+	composePath := filepath.Join(tmpDir, "compose.yaml")
+	if err := os.WriteFile(composePath, []byte(compose), 0644); err != nil {
+		t.Fatalf("failed to write compose file: %v", err)
+	}
+
+	cfg := &models.Config{ComposeFile: composePath, SnapshotDir: filepath.Join(tmpDir, ".dataclean"), ExcludeVolumes: []string{"esdata"}}
+	c := &Client{}
+
+	reports, err := c.DetectComposeMounts(cfg)
+	if err != nil {
+		t.Fatalf("DetectComposeMounts() failed: %v", err)
+	}
+
+	reasons := make(map[string]string)
+	for _, r := range reports {
+		reasons[r.Service+" "+r.Mount.String()] = r.Reason
+	}
+
+	expected := map[string]string{
+		"db pgdata:/var/lib/postgresql/data":          ReasonIncluded,
+		"db ./init:/docker-entrypoint-initdb.d":       ReasonBindMount,
+		"db /var/lib/extra":                           ReasonAnonymous,
+		"cache /tmp":                                  ReasonTmpfs,
+		"cache cachedata:/data":                       ReasonIncluded,
+		"cache /run":                                  ReasonTmpfs,
+		"cache undeclared:/other":                     ReasonNotDeclared,
+		"search esdata:/usr/share/elasticsearch/data": ReasonExcluded,
+	}
+	for key, want := range expected {
+		if got, ok := reasons[key]; !ok || got != want {
+			t.Errorf("%s: reason = %q, want %q", key, got, want)
+		}
+	}
+	if len(reports) != len(expected) {
+		t.Errorf("expected %d reports, got %d", len(expected), len(reports))
+	}
+}
+
+func TestDetectComposeMounts_Shared(t *testing.T) {
+	tmpDir := t.TempDir()
+	compose := `
+services:
+  db:
+    image: postgres:16
+    volumes:
+      - pgdata:/var/lib/postgresql/data
+  backup:
+    image: alpine
+    volumes:
+      - pgdata:/backup/source:ro
+  worker:
+    image: alpine
+    volumes:
+      - pgdata:/data
+volumes:
+  pgdata:
+`
+	composePath := filepath.Join(tmpDir, "compose.yaml")
+	if err := os.WriteFile(composePath, []byte(compose), 0644); err != nil {
+		t.Fatalf("failed to write compose file: %v", err)
+	}
+
+	c := &Client{}
+	reports, err := c.DetectComposeMounts(&models.Config{ComposeFile: composePath, SnapshotDir: filepath.Join(tmpDir, ".dataclean")})
+	if err != nil {
+		t.Fatalf("DetectComposeMounts() failed: %v", err)
+	}
+
+	var included []models.Volume
+	for _, r := range reports {
+		if r.Included {
+			included = append(included, *r.Volume)
+		} else if r.Reason != ReasonShared {
+			t.Errorf("%s %s: reason = %q, want %q", r.Service, r.Mount.String(), r.Reason, ReasonShared)
+		}
+	}
+	if len(included) != 1 {
+		t.Fatalf("expected the shared volume once, got %d", len(included))
+	}
+	// The postgres service owns it, though backup comes first
+	if included[0].Service != "db" || included[0].DatastoreType != models.DatastorePostgres ||
+		!reflect.DeepEqual(included[0].SharedWith, []string{"backup", "worker"}) {
+		t.Errorf("service = %s (%s), shared with %v; want db (postgres), shared with [backup worker]",
+			included[0].Service, included[0].DatastoreType, included[0].SharedWith)
+	}
+}
+
+func TestDetectComposeVolumes_Services(t *testing.T) {
+	tmpDir := t.TempDir()
+	compose := `
+services:
+  db:
+    image: postgres:16
+    volumes:
+      - pgdata:/var/lib/postgresql/data
+  backup:
+    image: alpine
+    volumes:
+      - pgdata:/backup/source:ro
+  cache:
+    image: redis:7
+    volumes:
+      - redisdata:/data
+volumes:
+  pgdata:
+  redisdata:
+`
+	composePath := filepath.Join(tmpDir, "compose.yaml")
+	if err := os.WriteFile(composePath, []byte(compose), 0644); err != nil {
+		t.Fatalf("failed to write compose file: %v", err)
+	}
+	c := &Client{}
+	cfg := &models.Config{ComposeFile: composePath, SnapshotDir: filepath.Join(tmpDir, ".dataclean")}
+
+	// The shared volume is selected through either service mounting it
+	for _, service := range []string{"db", "backup"} {
+		cfg.IncludeServices = []string{service}
+		volumes, err := c.DetectComposeVolumes(cfg)
+		if err != nil {
+			t.Fatalf("DetectComposeVolumes(%s) failed: %v", service, err)
+		}
+		if len(volumes) != 1 || volumes[0].ComposeName != "pgdata" {
+			t.Errorf("DetectComposeVolumes(%s) = %v, want pgdata only", service, volumes)
+		}
+	}
+
+	cfg.IncludeServices = []string{"search"}
+	if _, err := c.DetectComposeVolumes(cfg); err == nil || !strings.Contains(err.Error(), "backup, cache, db") {
+		t.Errorf("DetectComposeVolumes(search) = %v, want an error listing the services", err)
+	}
+}
+
+func TestDetectComposeVolumes_Credentials(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "dataclean-docker-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	compose := `
+services:
+  db:
+    image: postgres:16
+    environment:
+      POSTGRES_USER: app
+      POSTGRES_PASSWORD: ${DB_PASSWORD:-devpass}
+      POSTGRES_DB: appdb
+    volumes:
+      - pgdata:/var/lib/postgresql/data
+  mysql:
+    image: mysql:8
+    environment:
+      - MYSQL_ROOT_PASSWORD=rootpw
+      - MYSQL_DATABASE=shop
+    volumes:
+      - mysqldata:/var/lib/mysql
+volumes:
+  pgdata:
+  mysqldata:
+`
+	composePath := filepath.Join(tmpDir, "compose.yaml")
+	if err := os.WriteFile(composePath, []byte(compose), 0644); err != nil {
+		t.Fatalf("failed to write compose file: %v", err)
+	}
+
+	cfg := &models.Config{ComposeFile: composePath, SnapshotDir: filepath.Join(tmpDir, ".dataclean")}
+	c := &Client{}
+
+	volumes, err := c.DetectComposeVolumes(cfg)
+	if err != nil {
+		t.Fatalf("DetectComposeVolumes() failed: %v", err)
+	}
+
+	creds := make(map[string]models.Credentials)
+	for _, v := range volumes {
+		if v.Credentials == nil {
+			t.Fatalf("expected credentials for %s", v.ComposeName)
+		}
+		creds[v.ComposeName] = *v.Credentials
+	}
+
+	if creds["pgdata"] != (models.Credentials{User: "app", Password: models.NewSecret("devpass"), Database: "appdb"}) {
+		t.Errorf("pgdata credentials = %+v", creds["pgdata"])
+	}
+	if creds["mysqldata"] != (models.Credentials{User: "root", Password: models.NewSecret("rootpw"), Database: "shop"}) {
+		t.Errorf("mysqldata credentials = %+v", creds["mysqldata"])
+	}
+}
+
+func TestDetectComposeVolumes_EnvFileAndSecrets(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "dataclean-docker-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	compose := `
+services:
+  db:
+    image: mycorp/db:2
+    env_file:
+      - db.env
+      - path: missing.env
+        required: false
+    environment:
+      POSTGRES_PASSWORD_FILE: /run/secrets/db_password
+    secrets:
+      - db_password
+    volumes:
+      - dbdata:/srv/data
+  store:
+    image: mycorp/store:1
+    env_file: store.env
+    volumes:
+      - storedata:/srv/data
+volumes:
+  dbdata:
+  storedata:
+secrets:
+  db_password:
+    file: ./secrets/db_password.txt
+`
+	files := map[string]string{
+		"compose.yaml":            compose,
+		"db.env":                  "POSTGRES_USER=app\nPOSTGRES_DB=appdb\n",
+		"store.env":               "MYSQL_DATABASE=shop\n",
+		"secrets/db_password.txt": "s3cret\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	cfg := &models.Config{ComposeFile: filepath.Join(tmpDir, "compose.yaml"), SnapshotDir: filepath.Join(tmpDir, ".dataclean")}
+	c := &Client{}
+
+	volumes, err := c.DetectComposeVolumes(cfg)
+	if err != nil {
+		t.Fatalf("DetectComposeVolumes() failed: %v", err)
+	}
+
+	byName := make(map[string]models.Volume)
+	for _, v := range volumes {
+		byName[v.ComposeName] = v
+	}
+
+	db := byName["dbdata"]
+	if db.DatastoreType != models.DatastorePostgres {
+		t.Errorf("dbdata type = %s, want postgres", db.DatastoreType)
+	}
+	if db.Credentials == nil || *db.Credentials != (models.Credentials{User: "app", Password: models.NewSecret("s3cret"), Database: "appdb"}) {
+		t.Errorf("dbdata credentials = %+v", db.Credentials)
+	}
+
+	if byName["storedata"].DatastoreType != models.DatastoreMySQL {
+		t.Errorf("storedata type = %s, want mysql", byName["storedata"].DatastoreType)
+	}
+}
+
+func TestDetectComposeVolumes_CustomType(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "dataclean-docker-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	compose := `
+services:
+  olap:
+    image: clickhouse/clickhouse-server:24
+    volumes:
+      - chdata:/var/lib/clickhouse
+volumes:
+  chdata:
+`
+	composePath := filepath.Join(tmpDir, "compose.yaml")
+	if err := os.WriteFile(composePath, []byte(compose), 0644); err != nil {
+		t.Fatalf("failed to write compose file: %v", err)
+	}
+
+	cfg := &models.Config{
+		ComposeFile:    composePath,
+		SnapshotDir:    filepath.Join(tmpDir, ".dataclean"),
+		DatastoreTypes: []models.CustomDatastore{{Name: "olap", MountPaths: []string{"/var/lib/clickhouse"}}},
+	}
+	c := &Client{}
+
+	volumes, err := c.DetectComposeVolumes(cfg)
+	if err != nil {
+		t.Fatalf("DetectComposeVolumes() failed: %v", err)
+	}
+	if len(volumes) != 1 || volumes[0].DatastoreType != "olap" {
+		t.Errorf("expected custom type olap, got %+v", volumes)
+	}
+}
+
+func TestInferDatastoreType(t *testing.T) {
+	tests := []struct {
+		image, mountPath string
+		env              map[string]string
+		expected         models.DatastoreType
+	}{
+		{"postgres:16", "/var/lib/postgresql/data", nil, models.DatastorePostgres},
+		{"mycorp/db", "/srv/data", map[string]string{"POSTGRES_PASSWORD": "x"}, models.DatastorePostgres},
+		{"docker.elastic.co/elasticsearch/elasticsearch:8.13.0", "/usr/share/elasticsearch/data", nil, models.DatastoreElastic},
+		{"opensearchproject/opensearch:2", "/usr/share/opensearch/data", nil, models.DatastoreElastic},
+		{"clickhouse/clickhouse-server:24", "/var/lib/clickhouse", nil, models.DatastoreClickHouse},
+		{"mycorp/olap", "/var/lib/clickhouse", nil, models.DatastoreClickHouse},
+		{"cassandra:4", "/var/lib/cassandra", nil, models.DatastoreCassandra},
+		{"mycorp/cluster", "/data", map[string]string{"CASSANDRA_CLUSTER_NAME": "dev"}, models.DatastoreCassandra},
+		{"rabbitmq:3-management", "/var/lib/rabbitmq", nil, models.DatastoreRabbitMQ},
+		{"busybox", "/data", nil, models.DatastoreGeneric},
+	}
+
+	c := &Client{}
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			if got := c.inferDatastoreType(tt.image, tt.mountPath, tt.env, ""); got != tt.expected {
+				t.Errorf("inferDatastoreType(%q, %q) = %s, want %s", tt.image, tt.mountPath, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRedactComposeSecrets(t *testing.T) {
+	compose := `services:
+  db:
+    image: postgres:16
+    environment:
+      POSTGRES_USER: app
+      POSTGRES_PASSWORD: hunter2
+      POSTGRES_PASSWORD_FILE: /run/secrets/pg
+      DATABASE_URL: postgres://app:urlpass@db:5432/app
+  cache:
+    image: redis
+    environment:
+      - REDIS_PASSWORD=s3cret
+      - REDIS_PORT=6379
+      - CACHE_URL=redis://:listpass@cache:6379/0
+`
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(compose), &root); err != nil {
+		t.Fatalf("failed to parse compose: %v", err)
+	}
+	redactComposeSecrets(&root)
+	out, err := yaml.Marshal(&root)
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+
+	for _, secret := range []string{"hunter2", "s3cret", "urlpass", "listpass"} {
+		if strings.Contains(string(out), secret) {
+			t.Errorf("secret %q survived redaction:\n%s", secret, out)
+		}
+	}
+	for _, kept := range []string{"POSTGRES_USER: app", "/run/secrets/pg", "REDIS_PORT=6379", "image: postgres:16", "postgres://app:********@db:5432/app"} {
+		if !strings.Contains(string(out), kept) {
+			t.Errorf("expected %q to be kept:\n%s", kept, out)
+		}
+	}
+}
+
+func TestContainerSettings(t *testing.T) {
+	inspect := `{
+  "Config": {"Image": "postgres:16", "Cmd": ["postgres", "-c", "fsync=off"], "Env": ["POSTGRES_USER=app", "PGDATA=/var/lib/postgresql/data"]},
+  "HostConfig": {"Memory": 536870912, "NanoCpus": 1500000000, "RestartPolicy": {"Name": "unless-stopped"}}
+}`
+	settings, err := ContainerSettings([]byte(inspect))
+	if err != nil {
+		t.Fatalf("ContainerSettings() failed: %v", err)
+	}
+	want := map[string]string{
+		"container.image":             "postgres:16",
+		"container.command":           "postgres -c fsync=off",
+		"container.memory":            "536870912",
+		"container.cpus":              "1.5",
+		"container.restart":           "unless-stopped",
+		"container.env.POSTGRES_USER": "app",
+		"container.env.PGDATA":        "/var/lib/postgresql/data",
+	}
+	for k, v := range want {
+		if settings[k] != v {
+			t.Errorf("settings[%s] = %q, want %q", k, settings[k], v)
+		}
+	}
+	if len(settings) != len(want) {
+		t.Errorf("got %d settings, want %d: %v", len(settings), len(want), settings)
+	}
+}
+
+func TestStopTimeoutArgs(t *testing.T) {
+	tests := []struct {
+		timeout time.Duration
+		want    string
+	}{
+		{0, ""},
+		{60 * time.Second, "--time 60"},
+		{1500 * time.Millisecond, "--time 2"},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(stopTimeoutArgs(tt.timeout), " "); got != tt.want {
+			t.Errorf("stopTimeoutArgs(%v) = %q, want %q", tt.timeout, got, tt.want)
+		}
+	}
+}
+
+func TestDaemonError(t *testing.T) {
+	tests := []struct {
+		output, goos, dockerHost string
+		want                     string
+	}{
+		{"Cannot connect to the Docker daemon at unix:///var/run/docker.sock. Is the docker daemon running?", "linux", "", "systemctl start docker"},
+		{"Cannot connect to the Docker daemon at unix:///Users/me/.docker/run/docker.sock. Is the docker daemon running?", "darwin", "", "Docker Desktop"},
+		{"error during connect: Get \"http://%2F%2F.%2Fpipe%2FdockerDesktopLinuxEngine/v1.45/version\"", "windows", "", "Docker Desktop"},
+		{"Cannot connect to the Docker daemon at tcp://prod:2376. Is the docker daemon running?", "linux", "tcp://prod:2376", "DOCKER_HOST is tcp://prod:2376"},
+		{"permission denied while trying to connect to the Docker daemon socket", "linux", "", "docker group"},
+		{"something else entirely", "linux", "", "something else entirely"},
+	}
+	for _, tt := range tests {
+		err := daemonError(tt.output, tt.goos, tt.dockerHost)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("daemonError(%q, %s) = %v, want it to mention %q", tt.output, tt.goos, err, tt.want)
+		}
+	}
+}
+
+func TestParseAnonymousMounts(t *testing.T) {
+	hashA := strings.Repeat("a", 64)
+	hashB := strings.Repeat("b", 64)
+	output := "search\t/usr/share/elasticsearch/data\t" + hashB + "\n" +
+		"db\t/var/lib/postgresql/data\tshop_pgdata\n" +
+		"search\t/usr/share/elasticsearch/data\t" + hashA + "\n" +
+		"db\t/var/lib/extra\t" + hashA + "\n" +
+		"\t/orphan\t" + hashA + "\n"
+
+	got := parseAnonymousMounts(output)
+	want := []AnonymousMount{
+		{Service: "db", Target: "/var/lib/extra", Volume: hashA},
+		{Service: "search", Target: "/usr/share/elasticsearch/data", Volume: hashB},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseAnonymousMounts() = %+v, want %+v", got, want)
+	}
+}
+
+func TestAliasedMounts(t *testing.T) {
+	hash := strings.Repeat("c", 64)
+	cfg := &models.Config{VolumeAliases: []models.VolumeAlias{
+		{Alias: "extra", Service: "db", Target: "/var/lib/extra"},
+		{Alias: "pgdata-anon", Service: "db", Target: "/var/lib/postgresql/data"},
+		{Alias: "other", Service: "cache", Target: "/data"},
+	}}
+	reports := []MountReport{
+		{Service: "db", Mount: ServiceVolume{Type: MountTypeAnonymous, Target: "/var/lib/extra"}, Reason: ReasonAnonymous},
+	}
+	anonymous := []AnonymousMount{{Service: "db", Target: "/var/lib/extra", Volume: hash}}
+
+	c := &Client{}
+	got := c.aliasedMounts(cfg, "db", ComposeService{Image: "postgres:16"}, nil, anonymous, reports)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 reports, got %d: %+v", len(got), got)
+	}
+	if !got[0].Included || got[0].Volume.Name != hash || got[0].Volume.Alias != "extra" {
+		t.Errorf("aliased mount not adopted: %+v", got[0])
+	}
+	if got[1].Included || got[1].Reason != ReasonAliasNoContainer {
+		t.Errorf("alias without a container: included = %v, reason = %q", got[1].Included, got[1].Reason)
+	}
+}
+
+func TestParseServiceStates(t *testing.T) {
+	output := "db\tshop-db-1\trunning\tUp 2 minutes (healthy)\n" +
+		"db\tshop-db-2\texited\tExited (0) 1 hour ago\n" +
+		"search\tshop-search-1\trunning\tUp 5 seconds (health: starting)\n" +
+		"cache\tshop-cache-1\texited\tExited (137) 3 days ago\n" +
+		"\tdebug\trunning\tUp 1 minute\n"
+
+	got := parseServiceStates(output)
+	want := map[string]ServiceState{
+		"db":     {Service: "db", Container: "shop-db-1", State: "running", Health: "healthy"},
+		"search": {Service: "search", Container: "shop-search-1", State: "running", Health: "starting"},
+		"cache":  {Service: "cache", Container: "shop-cache-1", State: "exited"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseServiceStates() = %+v, want %+v", got, want)
+	}
+}
+
+func TestSplitVolumeUsers(t *testing.T) {
+	output := "shop-db-1\tshop\nadminer-debug\t\nother-db-1\tother\n"
+	project, foreign := splitVolumeUsers(output, "shop")
+	if !reflect.DeepEqual(project, []string{"shop-db-1"}) {
+		t.Errorf("project = %v, want [shop-db-1]", project)
+	}
+	if !reflect.DeepEqual(foreign, []string{"adminer-debug", "other-db-1"}) {
+		t.Errorf("foreign = %v, want [adminer-debug other-db-1]", foreign)
+	}
+}
+
+func TestExportVolume_ReplacesLinkedArchive(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// A stand-in for docker whose helper container writes a fresh tar stream
+	bin := filepath.Join(tmpDir, "bin")
+	os.MkdirAll(bin, 0755)
+	os.WriteFile(filepath.Join(bin, "docker"), []byte("#!/bin/sh\nprintf 'fresh export'\n"), 0755)
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	// The snapshot being overwritten had its archive hard-linked from monday's
+	monday := filepath.Join(tmpDir, "monday", "project_pgdata.tar.gz")
+	tuesday := filepath.Join(tmpDir, "tuesday", "project_pgdata.tar.gz")
+	os.MkdirAll(filepath.Dir(monday), 0755)
+	os.MkdirAll(filepath.Dir(tuesday), 0755)
+	os.WriteFile(monday, []byte("monday's archive"), 0644)
+	if err := os.Link(monday, tuesday); err != nil {
+		t.Skipf("hard links unsupported: %v", err)
+	}
+
+	c := &Client{ctx: context.Background()}
+	if err := c.ExportVolume(models.Volume{Name: "project_pgdata"}, tuesday); err != nil {
+		t.Fatalf("ExportVolume() failed: %v", err)
+	}
+	if data, _ := os.ReadFile(monday); string(data) != "monday's archive" {
+		t.Errorf("the earlier snapshot's archive was rewritten: %q", data)
+	}
+	src, _ := os.Stat(monday)
+	dst, _ := os.Stat(tuesday)
+	if os.SameFile(src, dst) {
+		t.Error("the export still shares the earlier snapshot's archive")
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(tuesday)); len(entries) != 1 {
+		t.Errorf("export left %d files behind, want just the archive", len(entries))
+	}
+}
+
+func TestInspectContainer_RedactsSecrets(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	bin := filepath.Join(tmpDir, "bin")
+	os.MkdirAll(bin, 0755)
+	inspect := `[{"Config":{"Image":"postgres:16","Env":["POSTGRES_USER=app","POSTGRES_PASSWORD=hunter2","DATABASE_URL=postgres://app:hunter2@db/app"]}}]`
+	os.WriteFile(filepath.Join(bin, "docker"), []byte("#!/bin/sh\nprintf '%s' '"+inspect+"'\n"), 0755)
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	c := &Client{ctx: context.Background()}
+	output, err := c.InspectContainer("project-db-1")
+	if err != nil {
+		t.Fatalf("InspectContainer() failed: %v", err)
+	}
+	if strings.Contains(string(output), "hunter2") {
+		t.Errorf("inspect output kept a password:\n%s", output)
+	}
+
+	settings, err := ContainerSettings(output)
+	if err != nil {
+		t.Fatalf("ContainerSettings() failed: %v", err)
+	}
+	if settings["container.env.POSTGRES_USER"] != "app" {
+		t.Errorf("env.POSTGRES_USER = %q, want app", settings["container.env.POSTGRES_USER"])
+	}
+	if settings["container.env.POSTGRES_PASSWORD"] != models.RedactedPassword {
+		t.Errorf("env.POSTGRES_PASSWORD = %q, want it redacted", settings["container.env.POSTGRES_PASSWORD"])
+	}
+}
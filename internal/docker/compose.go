@@ -0,0 +1,319 @@
+package docker
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+// Mount types as used by the compose spec
+const (
+	MountTypeVolume    = "volume"
+	MountTypeBind      = "bind"
+	MountTypeTmpfs     = "tmpfs"
+	MountTypeAnonymous = "anonymous"
+)
+
+// ServiceVolume is a service mount in short ("src:dst:mode") or long (mapping) syntax
+type ServiceVolume struct {
+	Type     string `yaml:"type"`
+	Source   string `yaml:"source"`
+	Target   string `yaml:"target"`
+	ReadOnly bool   `yaml:"read_only"`
+}
+
+// UnmarshalYAML accepts both the short string form and the long mapping form
+func (v *ServiceVolume) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*v = parseShortVolume(node.Value)
+		return nil
+	}
+
+	type long ServiceVolume
+	var l long
+	if err := node.Decode(&l); err != nil {
+		return err
+	}
+	*v = ServiceVolume(l)
+	if v.Type == "" {
+		v.Type = MountTypeVolume
+	}
+	if v.Type == MountTypeVolume && v.Source == "" {
+		v.Type = MountTypeAnonymous
+	}
+	return nil
+}
+
+// parseShortVolume parses "volume:/path", "./dir:/path:ro", or "/path" (anonymous)
+func parseShortVolume(spec string) ServiceVolume {
+	parts := strings.Split(spec, ":")
+	if len(parts) < 2 {
+		return ServiceVolume{Type: MountTypeAnonymous, Target: spec}
+	}
+
+	v := ServiceVolume{Source: parts[0], Target: parts[1], Type: MountTypeVolume}
+	if isHostPath(v.Source) {
+		v.Type = MountTypeBind
+	}
+	if len(parts) > 2 && strings.Contains(parts[2], "ro") {
+		v.ReadOnly = true
+	}
+	return v
+}
+
+// String renders the mount in short syntax for display
+func (v ServiceVolume) String() string {
+	if v.Source == "" {
+		return v.Target
+	}
+	return v.Source + ":" + v.Target
+}
+
+// stringList accepts a YAML scalar or sequence of strings
+type stringList []string
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (s *stringList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*s = stringList{node.Value}
+		return nil
+	}
+	var list []string
+	if err := node.Decode(&list); err != nil {
+		return err
+	}
+	*s = list
+	return nil
+}
+
+// envMap accepts compose environment in list ("KEY=value") or mapping form
+type envMap map[string]string
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (e *envMap) UnmarshalYAML(node *yaml.Node) error {
+	env := make(envMap)
+
+	if node.Kind == yaml.SequenceNode {
+		var list []string
+		if err := node.Decode(&list); err != nil {
+			return err
+		}
+		for _, entry := range list {
+			key, value, _ := strings.Cut(entry, "=")
+			env[key] = value
+		}
+		*e = env
+		return nil
+	}
+
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: environment must be a list or mapping", node.Line)
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		// Values may be numbers or booleans; a null value means "unset"
+		key, value := node.Content[i], node.Content[i+1]
+		if value.Tag == "!!null" {
+			env[key.Value] = ""
+			continue
+		}
+		env[key.Value] = value.Value
+	}
+	*e = env
+	return nil
+}
+
+// envFile is an env_file entry, given as a path or a mapping with a path
+type envFile struct {
+	Path string `yaml:"path"`
+}
+
+// envFiles accepts env_file as a single path or a list of entries
+type envFiles []envFile
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (e *envFiles) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*e = envFiles{{Path: node.Value}}
+		return nil
+	}
+
+	var files []envFile
+	for _, item := range node.Content {
+		var f envFile
+		if item.Kind == yaml.ScalarNode {
+			f.Path = item.Value
+		} else if err := item.Decode(&f); err != nil {
+			return err
+		}
+		files = append(files, f)
+	}
+	*e = files
+	return nil
+}
+
+// serviceSecret grants a service access to a top-level secret
+type serviceSecret struct {
+	Source string `yaml:"source"`
+	Target string `yaml:"target"`
+}
+
+// UnmarshalYAML accepts the short (name) and long (mapping) syntax
+func (s *serviceSecret) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*s = serviceSecret{Source: node.Value}
+		return nil
+	}
+
+	type long serviceSecret
+	var l long
+	if err := node.Decode(&l); err != nil {
+		return err
+	}
+	*s = serviceSecret(l)
+	return nil
+}
+
+// path returns where the secret is mounted inside the container
+func (s serviceSecret) path() string {
+	switch {
+	case s.Target == "":
+		return "/run/secrets/" + s.Source
+	case strings.HasPrefix(s.Target, "/"):
+		return s.Target
+	default:
+		return "/run/secrets/" + s.Target
+	}
+}
+
+// ComposeSecret is a top-level secret definition
+type ComposeSecret struct {
+	File        string `yaml:"file"`
+	Environment string `yaml:"environment"`
+}
+
+// serviceEnv returns a service's effective environment: env_file entries,
+// then environment (which wins), then *_FILE variables pointing at a
+// file-backed secret resolved to the secret's value (as the official
+// database images do at startup). Unreadable env files and secrets are
+// skipped since the environment is only used for detection and credentials.
+func (c *ComposeConfig) serviceEnv(service ComposeService, composeDir string) map[string]string {
+	env := make(map[string]string)
+
+	for _, f := range service.EnvFile {
+		values, err := loadDotEnv(resolveHostPath(composeDir, f.Path))
+		if err != nil {
+			continue
+		}
+		for k, v := range values {
+			env[k] = v
+		}
+	}
+	for k, v := range service.Environment {
+		env[k] = v
+	}
+
+	secrets := make(map[string]string) // mount path -> value
+	for _, s := range service.Secrets {
+		if value, ok := c.secretValue(s.Source, composeDir); ok {
+			secrets[s.path()] = value
+		}
+	}
+	for k, v := range env {
+		base, ok := strings.CutSuffix(k, "_FILE")
+		if !ok || env[base] != "" {
+			continue
+		}
+		if value, ok := secrets[v]; ok {
+			env[base] = value
+		}
+	}
+
+	return env
+}
+
+// secretValue reads a top-level secret backed by a file or environment variable
+func (c *ComposeConfig) secretValue(name, composeDir string) (string, bool) {
+	secret, ok := c.Secrets[name]
+	if !ok {
+		return "", false
+	}
+
+	switch {
+	case secret.File != "":
+		data, err := os.ReadFile(resolveHostPath(composeDir, secret.File))
+		if err != nil {
+			return "", false
+		}
+		return strings.TrimRight(string(data), "\r\n"), true
+	case secret.Environment != "":
+		return os.LookupEnv(secret.Environment)
+	default:
+		return "", false // external secrets live in the swarm, not on disk
+	}
+}
+
+// MountReport explains how one service mount was treated during detection
+type MountReport struct {
+	Service  string
+	Mount    ServiceVolume
+	Included bool
+	Reason   string
+	Volume   *models.Volume // set when Included
+}
+
+// Skip reasons reported by DetectComposeMounts
+const (
+	ReasonIncluded    = "snapshot-capable named volume"
+	ReasonBindMount   = "bind mount (host path, not a Docker volume)"
+	ReasonAnonymous   = "anonymous volume (no stable name; adopt it with 'dataclean alias add')"
+	ReasonTmpfs       = "tmpfs mount (in-memory, nothing to snapshot)"
+	ReasonNotDeclared = "not declared in top-level volumes section"
+	ReasonNotIncluded = "not in include_volumes"
+	ReasonExcluded    = "listed in exclude_volumes"
+	ReasonNoService   = "no selected service mounts it (include_services or --service)"
+	ReasonSnapshotDir = "backed by the snapshot directory"
+	ReasonShared      = "also mounted by another service (snapshotted once, every container stopped)"
+	ReasonUnknownType = "unsupported mount type"
+)
+
+// reasonFor returns a human explanation for a mount type that is always skipped
+func reasonFor(mountType string) string {
+	switch mountType {
+	case MountTypeBind:
+		return ReasonBindMount
+	case MountTypeAnonymous:
+		return ReasonAnonymous
+	case MountTypeTmpfs:
+		return ReasonTmpfs
+	default:
+		return fmt.Sprintf("%s (%s)", ReasonUnknownType, mountType)
+	}
+}
+
+// externalVolume is a compose volume that refers to an existing Docker volume
+type externalVolume struct {
+	External bool   `yaml:"external"`
+	Name     string `yaml:"name"`
+}
+
+// VolumeOverride renders a compose override file that swaps the Docker
+// volumes behind compose volumes (compose name → Docker name), so the
+// services run on other data, such as a restored clone, without changing
+// their mounts. header becomes a comment at the top.
+func VolumeOverride(header string, volumes map[string]string) ([]byte, error) {
+	doc := struct {
+		Volumes map[string]externalVolume `yaml:"volumes"`
+	}{Volumes: make(map[string]externalVolume, len(volumes))}
+	for composeName, name := range volumes {
+		doc.Volumes[composeName] = externalVolume{External: true, Name: name}
+	}
+	out, err := yaml.Marshal(&doc)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte("# "+header+"\n"), out...), nil
+}
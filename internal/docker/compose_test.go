@@ -0,0 +1,28 @@
+package docker
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestVolumeOverride(t *testing.T) {
+	out, err := VolumeOverride("generated", map[string]string{"pgdata": "shop-clone_pgdata", "uploads": "shop-clone_uploads"})
+	if err != nil {
+		t.Fatalf("VolumeOverride() failed: %v", err)
+	}
+	var compose ComposeConfig
+	if err := yaml.Unmarshal(out, &compose); err != nil {
+		t.Fatalf("override isn't valid compose yaml: %v\n%s", err, out)
+	}
+	var doc struct {
+		Volumes map[string]externalVolume `yaml:"volumes"`
+	}
+	yaml.Unmarshal(out, &doc)
+	if v := doc.Volumes["pgdata"]; !v.External || v.Name != "shop-clone_pgdata" {
+		t.Errorf("pgdata = %+v, want external shop-clone_pgdata\n%s", v, out)
+	}
+	if len(doc.Volumes) != 2 {
+		t.Errorf("got %d volumes, want 2:\n%s", len(doc.Volumes), out)
+	}
+}
@@ -0,0 +1,211 @@
+package docker
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// lookupFunc resolves a variable name, reporting whether it is set
+type lookupFunc func(name string) (string, bool)
+
+// composeEnv returns a lookup over the process environment with .env (next to
+// the compose file) as fallback, matching docker compose precedence.
+func composeEnv(composeDir string) (lookupFunc, error) {
+	dotenv, err := loadDotEnv(filepath.Join(composeDir, ".env"))
+	if err != nil {
+		return nil, err
+	}
+
+	return func(name string) (string, bool) {
+		if v, ok := os.LookupEnv(name); ok {
+			return v, true
+		}
+		v, ok := dotenv[name]
+		return v, ok
+	}, nil
+}
+
+// loadDotEnv parses KEY=VALUE lines from a .env file (missing file is not an error)
+func loadDotEnv(path string) (map[string]string, error) {
+	env := make(map[string]string)
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return env, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		env[strings.TrimSpace(key)] = unquote(strings.TrimSpace(value))
+	}
+
+	return env, scanner.Err()
+}
+
+// unquote strips matching single or double quotes from a .env value
+func unquote(value string) string {
+	if len(value) >= 2 {
+		first, last := value[0], value[len(value)-1]
+		if (first == '"' || first == '\'') && first == last {
+			return value[1 : len(value)-1]
+		}
+	}
+	return value
+}
+
+// interpolateNode replaces variables in every scalar value of a YAML tree
+func interpolateNode(node *yaml.Node, lookup lookupFunc) error {
+	if node.Kind == yaml.ScalarNode && strings.Contains(node.Value, "$") {
+		value, err := interpolate(node.Value, lookup)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", node.Line, err)
+		}
+		node.Value = value
+	}
+	for _, child := range node.Content {
+		if err := interpolateNode(child, lookup); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// interpolate expands $VAR, ${VAR}, ${VAR:-default}, ${VAR-default},
+// ${VAR:?error}, ${VAR?error}, ${VAR:+alt}, ${VAR+alt}, and $$ (literal $)
+func interpolate(s string, lookup lookupFunc) (string, error) {
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 >= len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		switch next := s[i+1]; {
+		case next == '$':
+			b.WriteByte('$')
+			i++
+
+		case next == '{':
+			end := matchingBrace(s, i+1)
+			if end < 0 {
+				return "", fmt.Errorf("unterminated variable in %q", s)
+			}
+			value, err := expandBraced(s[i+2:end], lookup)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(value)
+			i = end
+
+		case isNameStart(next):
+			j := i + 1
+			for j < len(s) && isNameChar(s[j]) {
+				j++
+			}
+			value, _ := lookup(s[i+1 : j])
+			b.WriteString(value)
+			i = j - 1
+
+		default:
+			b.WriteByte('$')
+		}
+	}
+
+	return b.String(), nil
+}
+
+// expandBraced resolves the inside of ${...}
+func expandBraced(expr string, lookup lookupFunc) (string, error) {
+	j := 0
+	for j < len(expr) && isNameChar(expr[j]) {
+		j++
+	}
+	name, op := expr[:j], expr[j:]
+	if name == "" {
+		return "", fmt.Errorf("invalid variable ${%s}", expr)
+	}
+	value, set := lookup(name)
+
+	for _, candidate := range []string{":-", ":?", ":+", "-", "?", "+"} {
+		if !strings.HasPrefix(op, candidate) {
+			continue
+		}
+		arg, err := interpolate(op[len(candidate):], lookup)
+		if err != nil {
+			return "", err
+		}
+
+		// The ":" forms also treat an empty value as unset
+		missing := !set || (candidate[0] == ':' && value == "")
+		switch candidate[len(candidate)-1] {
+		case '-':
+			if missing {
+				return arg, nil
+			}
+			return value, nil
+		case '?':
+			if missing {
+				if arg == "" {
+					arg = "required variable is not set"
+				}
+				return "", fmt.Errorf("%s: %s", name, arg)
+			}
+			return value, nil
+		case '+':
+			if missing {
+				return "", nil
+			}
+			return arg, nil
+		}
+	}
+
+	if op != "" {
+		return "", fmt.Errorf("invalid variable ${%s}", expr)
+	}
+	return value, nil
+}
+
+// matchingBrace returns the index of the "}" closing the "{" at open
+func matchingBrace(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNameChar(c byte) bool {
+	return isNameStart(c) || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,104 @@
+package docker
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+func TestInterpolate(t *testing.T) {
+	env := map[string]string{"NAME": "pgdata", "EMPTY": ""}
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"$NAME:/data", "pgdata:/data"},
+		{"${NAME}:/data", "pgdata:/data"},
+		{"${MISSING:-fallback}", "fallback"},
+		{"${EMPTY:-fallback}", "fallback"},
+		{"${EMPTY-fallback}", ""},
+		{"${NAME:+set}", "set"},
+		{"${MISSING:+set}", ""},
+		{"${MISSING:-${NAME}_v2}", "pgdata_v2"},
+		{"$$NAME", "$NAME"},
+		{"cost: 5$", "cost: 5$"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := interpolate(tt.input, lookup)
+			if err != nil {
+				t.Fatalf("interpolate(%q) failed: %v", tt.input, err)
+			}
+			if got != tt.expected {
+				t.Errorf("interpolate(%q) = %q, want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+
+	if _, err := interpolate("${MISSING:?must be set}", lookup); err == nil {
+		t.Error("expected error for required variable")
+	}
+	if _, err := interpolate("${NAME", lookup); err == nil {
+		t.Error("expected error for unterminated variable")
+	}
+}
+
+func TestDetectComposeVolumes_Interpolation(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "dataclean-docker-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	compose := `
+services:
+  db:
+    image: postgres:${PG_VERSION:-16}
+    volumes:
+      - ${DATA_VOLUME:-pgdata}:/var/lib/postgresql/data
+volumes:
+  ${DATA_VOLUME:-pgdata}:
+`
+	composePath := filepath.Join(tmpDir, "compose.yaml")
+	if err := os.WriteFile(composePath, []byte(compose), 0644); err != nil {
+		t.Fatalf("failed to write compose file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, ".env"), []byte("# local\nDATA_VOLUME=\"appdata\"\n"), 0644); err != nil {
+		t.Fatalf("failed to write .env: %v", err)
+	}
+
+	cfg := &models.Config{ComposeFile: composePath, SnapshotDir: filepath.Join(tmpDir, ".dataclean")}
+	c := &Client{}
+
+	volumes, err := c.DetectComposeVolumes(cfg)
+	if err != nil {
+		t.Fatalf("DetectComposeVolumes() failed: %v", err)
+	}
+	if len(volumes) != 1 {
+		t.Fatalf("expected 1 volume, got %d", len(volumes))
+	}
+	if volumes[0].ComposeName != "appdata" {
+		t.Errorf("ComposeName = %q, want appdata", volumes[0].ComposeName)
+	}
+	if volumes[0].ImageName != "postgres:16" {
+		t.Errorf("ImageName = %q, want postgres:16", volumes[0].ImageName)
+	}
+
+	// Process environment takes precedence over .env
+	t.Setenv("DATA_VOLUME", "fromenv")
+	volumes, err = c.DetectComposeVolumes(cfg)
+	if err != nil {
+		t.Fatalf("DetectComposeVolumes() failed: %v", err)
+	}
+	if len(volumes) != 1 || volumes[0].ComposeName != "fromenv" {
+		t.Errorf("expected volume fromenv, got %+v", volumes)
+	}
+}
@@ -0,0 +1,126 @@
+package docker
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+const (
+	defaultRetryAttempts = 3
+	defaultRetryDelay    = time.Second
+	maxRetryDelay        = 30 * time.Second // Caps the backoff, however many attempts are configured
+)
+
+// Retry describes a docker command that failed transiently and is about to be
+// tried again
+type Retry struct {
+	Step     string        // What was being done, e.g. "docker stop db"
+	Attempt  int           // The attempt that failed, from 1
+	Attempts int           // Tries in all
+	Delay    time.Duration // Wait before the next attempt
+	Err      error
+}
+
+var (
+	retryMu       sync.Mutex // Parallel restores retry from several goroutines
+	retryReporter func(Retry)
+)
+
+// OnRetry has every client call report before it retries a docker command.
+// Calls to report don't overlap.
+func OnRetry(report func(Retry)) {
+	retryReporter = report
+}
+
+// SetRetryPolicy sets how this client retries docker commands that fail
+// transiently; zero values take the defaults
+func (c *Client) SetRetryPolicy(policy models.DockerRetryConfig) {
+	c.retry = policy
+}
+
+// withRetries runs op, a docker command that is safe to repeat, trying it
+// again with exponential backoff while it fails on the way to the daemon.
+// Failures of the command itself are returned at once.
+func (c *Client) withRetries(step string, op func() error) error {
+	attempts := c.retry.Attempts
+	if attempts <= 0 {
+		attempts = defaultRetryAttempts
+	}
+	delay := c.retry.Delay
+	if delay <= 0 {
+		delay = defaultRetryDelay
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := op()
+		if err == nil || attempt >= attempts || !transientError(err) || c.ctx.Err() != nil {
+			return err
+		}
+		retryMu.Lock()
+		if retryReporter != nil {
+			retryReporter(Retry{Step: step, Attempt: attempt, Attempts: attempts, Delay: delay, Err: err})
+		}
+		retryMu.Unlock()
+		select {
+		case <-time.After(delay):
+		case <-c.ctx.Done():
+			return err
+		}
+		delay = min(2*delay, maxRetryDelay)
+	}
+}
+
+// transientMessages are what the docker CLI says when it loses the daemon
+// rather than when the command fails. Errors that tar and gzip also report
+// for a truncated or corrupt archive ("unexpected EOF", "broken pipe") are
+// left out, so a bad export or import fails at once instead of being retried.
+var transientMessages = []string{
+	"cannot connect to the docker daemon",
+	"is the docker daemon running",
+	"error during connect",
+	"connection refused",
+	"connection reset by peer",
+	"i/o timeout",
+	"tls handshake timeout",
+	"the server is currently unable to handle the request",
+	"503 service unavailable",
+}
+
+// transientError reports whether a docker command failed for want of a
+// daemon. Errors from docker are expected to carry its output, as the
+// client's own do; the stderr captured by exec.Cmd.Output is read too.
+func transientError(err error) bool {
+	msg := err.Error()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		msg += "\n" + string(exitErr.Stderr)
+	}
+	msg = strings.ToLower(msg)
+	for _, m := range transientMessages {
+		if strings.Contains(msg, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// String describes the retry for a warning
+func (r Retry) String() string {
+	return fmt.Sprintf("%s failed (attempt %d of %d), retrying in %s: %s", r.Step, r.Attempt, r.Attempts, r.Delay, firstLine(r.Err.Error()))
+}
+
+// firstLine returns the first non-empty line of s
+func firstLine(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return s
+}
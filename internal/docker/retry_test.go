@@ -0,0 +1,63 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+func TestTransientError(t *testing.T) {
+	tests := []struct {
+		err  string
+		want bool
+	}{
+		{"stop failed: Cannot connect to the Docker daemon at unix:///var/run/docker.sock. Is the docker daemon running?: exit status 1", true},
+		{"import failed: error during connect: Post \"https://10.0.0.5:2376/v1.43/containers/create\": read: connection reset by peer: exit status 1", true},
+		{"export failed: tar: ./pg_wal: Cannot open: Permission denied: exit status 2", false},
+		{"import failed: gzip: stdin: unexpected end of file\ntar: Unexpected EOF in archive\ntar: Error is not recoverable: exiting now: exit status 2", false},
+		{"export failed: tar: -: Cannot write: Broken pipe: exit status 2", false},
+		{"Error response from daemon: No such container: db: exit status 1", false},
+	}
+	for _, tt := range tests {
+		if got := transientError(errors.New(tt.err)); got != tt.want {
+			t.Errorf("transientError(%q) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestWithRetries(t *testing.T) {
+	var reported []Retry
+	OnRetry(func(r Retry) { reported = append(reported, r) })
+	defer OnRetry(nil)
+	c := &Client{ctx: context.Background()}
+	c.SetRetryPolicy(models.DockerRetryConfig{Attempts: 3, Delay: time.Millisecond})
+
+	daemonDown := errors.New("Cannot connect to the Docker daemon")
+	calls := 0
+	err := c.withRetries("docker stop db", func() error {
+		if calls++; calls < 3 {
+			return daemonDown
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Errorf("withRetries() = %v after %d calls, want success on the 3rd", err, calls)
+	}
+	if len(reported) != 2 || reported[0].Delay != time.Millisecond || reported[1].Delay != 2*time.Millisecond {
+		t.Errorf("reported retries = %+v, want 2 with the delay doubling", reported)
+	}
+
+	calls = 0
+	if err := c.withRetries("docker stop db", func() error { calls++; return daemonDown }); err != daemonDown || calls != 3 {
+		t.Errorf("withRetries() = %v after %d calls, want the error after 3", err, calls)
+	}
+
+	calls = 0
+	failed := errors.New("No such container: db")
+	if err := c.withRetries("docker stop db", func() error { calls++; return failed }); err != failed || calls != 1 {
+		t.Errorf("withRetries() = %v after %d calls, want a failure of the command itself returned at once", err, calls)
+	}
+}
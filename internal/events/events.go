@@ -0,0 +1,164 @@
+// Package events carries what dataclean does to the integrations that want
+// to know: notifications, snapshot webhooks, metrics, and the event log all
+// subscribe to one bus instead of each command calling them.
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+// Kind names what happened
+type Kind string
+
+const (
+	OperationFinished Kind = "operation.finished" // A command's operation (snapshot, restore, reset, ...) succeeded or failed
+	SnapshotCreated   Kind = "snapshot.created"
+	SnapshotRestored  Kind = "snapshot.restored"
+	SnapshotDeleted   Kind = "snapshot.deleted"
+	SnapshotPruned    Kind = "snapshot.pruned" // Removed by retention or compact --prune
+	RestoreStarted    Kind = "restore.started" // Containers are stopped; volumes are about to be replaced
+	ResetStarted      Kind = "reset.started"
+	VolumeCleared     Kind = "volume.cleared"
+	VolumeRestored    Kind = "volume.restored" // A snapshot's archive was imported into the volume
+)
+
+// Kinds lists every kind of event
+var Kinds = []Kind{OperationFinished, SnapshotCreated, SnapshotRestored, SnapshotDeleted, SnapshotPruned,
+	RestoreStarted, ResetStarted, VolumeCleared, VolumeRestored}
+
+// Event is something dataclean did. Fields that don't apply to its kind are empty.
+type Event struct {
+	Kind      Kind
+	Time      time.Time
+	Config    *models.Config // The config of the project it happened in, for subscribers' settings
+	Operation string         // snapshot, restore, ...
+	Target    string         // Usually a snapshot name
+	Volume    string
+	Snapshot  *models.Snapshot
+	Duration  time.Duration
+	Bytes     int64
+	Err       error
+}
+
+// Handler receives events. An error is reported but never fails the operation.
+type Handler func(Event) error
+
+type subscription struct {
+	name   string
+	kinds  []Kind
+	handle Handler
+}
+
+// Bus delivers each published event to the handlers subscribed to its kind,
+// one event at a time, in the order they subscribed
+type Bus struct {
+	mu      sync.Mutex
+	subs    []subscription
+	onError func(subscriber string, err error)
+}
+
+// Subscribe has handle receive events of the given kinds, or of every kind
+// when none are given. name identifies the subscriber in errors.
+func (b *Bus) Subscribe(name string, handle Handler, kinds ...Kind) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subs = append(b.subs, subscription{name: name, kinds: kinds, handle: handle})
+}
+
+// OnError sets what is done with a handler's error (default: nothing)
+func (b *Bus) OnError(report func(subscriber string, err error)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onError = report
+}
+
+// Publish delivers an event, stamping its time if unset
+func (b *Bus) Publish(e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, s := range b.subs {
+		if len(s.kinds) > 0 && !slices.Contains(s.kinds, e.Kind) {
+			continue
+		}
+		if err := s.handle(e); err != nil && b.onError != nil {
+			b.onError(s.name, err)
+		}
+	}
+}
+
+// Default is the bus dataclean's commands and the snapshot manager publish to
+var Default = &Bus{}
+
+// Subscribe subscribes to the default bus
+func Subscribe(name string, handle Handler, kinds ...Kind) {
+	Default.Subscribe(name, handle, kinds...)
+}
+
+// Publish publishes to the default bus
+func Publish(e Event) {
+	Default.Publish(e)
+}
+
+// record is an event's line in the event log
+type record struct {
+	Time            time.Time `json:"time"`
+	Kind            Kind      `json:"kind"`
+	Project         string    `json:"project,omitempty"`
+	Operation       string    `json:"operation,omitempty"`
+	Target          string    `json:"target,omitempty"`
+	Volume          string    `json:"volume,omitempty"`
+	Snapshot        string    `json:"snapshot,omitempty"`
+	DurationSeconds float64   `json:"duration_seconds,omitempty"`
+	Bytes           int64     `json:"bytes,omitempty"`
+	Error           string    `json:"error,omitempty"`
+}
+
+// AppendLog appends an event to a JSON-lines log file, creating it and its
+// directory as needed
+func AppendLog(path, project string, e Event) error {
+	r := record{
+		Time:            e.Time,
+		Kind:            e.Kind,
+		Project:         project,
+		Operation:       e.Operation,
+		Target:          e.Target,
+		Volume:          e.Volume,
+		DurationSeconds: e.Duration.Seconds(),
+		Bytes:           e.Bytes,
+	}
+	if e.Snapshot != nil {
+		r.Snapshot = e.Snapshot.Name
+	}
+	if e.Err != nil {
+		r.Error = e.Err.Error()
+	}
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("event log: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("event log: %w", err)
+	}
+	_, werr := f.Write(append(line, '\n'))
+	if err := errors.Join(werr, f.Close()); err != nil {
+		return fmt.Errorf("event log: %w", err)
+	}
+	return nil
+}
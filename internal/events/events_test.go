@@ -0,0 +1,67 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+func TestBus(t *testing.T) {
+	b := &Bus{}
+	var all, cleared []Kind
+	b.Subscribe("all", func(e Event) error { all = append(all, e.Kind); return nil })
+	b.Subscribe("cleared", func(e Event) error {
+		cleared = append(cleared, e.Kind)
+		return errors.New("webhook down")
+	}, VolumeCleared)
+	var failed []string
+	b.OnError(func(subscriber string, err error) { failed = append(failed, subscriber+": "+err.Error()) })
+
+	b.Publish(Event{Kind: RestoreStarted})
+	b.Publish(Event{Kind: VolumeCleared, Volume: "shop_pgdata"})
+
+	if !slices.Equal(all, []Kind{RestoreStarted, VolumeCleared}) {
+		t.Errorf("subscriber to every kind got %v", all)
+	}
+	if !slices.Equal(cleared, []Kind{VolumeCleared}) {
+		t.Errorf("subscriber to volume.cleared got %v", cleared)
+	}
+	if !slices.Equal(failed, []string{"cleared: webhook down"}) {
+		t.Errorf("reported errors = %v", failed)
+	}
+}
+
+func TestAppendLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "events.jsonl")
+	at := time.Date(2024, 5, 7, 10, 15, 0, 0, time.UTC)
+	AppendLog(path, "shop", Event{Kind: RestoreStarted, Time: at, Operation: "restore", Target: "seeded", Snapshot: &models.Snapshot{Name: "seeded"}})
+	if err := AppendLog(path, "shop", Event{Kind: OperationFinished, Time: at, Operation: "restore", Duration: 1500 * time.Millisecond, Err: errors.New("boom")}); err != nil {
+		t.Fatalf("AppendLog() failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("log has %d lines, want 2", len(lines))
+	}
+	var r record
+	if err := json.Unmarshal([]byte(lines[1]), &r); err != nil {
+		t.Fatalf("line is not JSON: %v", err)
+	}
+	if r.Kind != OperationFinished || r.Project != "shop" || r.DurationSeconds != 1.5 || r.Error != "boom" {
+		t.Errorf("logged %+v", r)
+	}
+	if !strings.Contains(lines[0], `"snapshot":"seeded"`) {
+		t.Errorf("first line = %s, want the snapshot's name", lines[0])
+	}
+}
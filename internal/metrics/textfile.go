@@ -0,0 +1,122 @@
+// Package metrics records operation metrics in Prometheus textfile format
+// (for node_exporter's textfile collector).
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Record describes one finished operation
+type Record struct {
+	Project   string
+	Operation string
+	Duration  time.Duration
+	Bytes     int64
+	Err       error
+}
+
+// metric families written to the textfile
+var families = []struct {
+	name, kind, help string
+}{
+	{"dataclean_operations_total", "counter", "Operations run, by outcome."},
+	{"dataclean_operation_duration_seconds_total", "counter", "Total time spent in operations."},
+	{"dataclean_operation_bytes_total", "counter", "Total archive bytes written or restored."},
+	{"dataclean_last_operation_duration_seconds", "gauge", "Duration of the most recent operation."},
+	{"dataclean_last_operation_timestamp_seconds", "gauge", "Unix time the most recent operation finished."},
+}
+
+// WriteTextfile merges a record into the textfile at path. Counters from
+// earlier runs are read back from the file so they accumulate across runs.
+func WriteTextfile(path string, r Record) error {
+	series, err := readSeries(path)
+	if err != nil {
+		return err
+	}
+
+	status := "success"
+	if r.Err != nil {
+		status = "failure"
+	}
+	labels := fmt.Sprintf(`project=%q,operation=%q`, r.Project, r.Operation)
+
+	series[fmt.Sprintf(`dataclean_operations_total{%s,status=%q}`, labels, status)]++
+	series[fmt.Sprintf(`dataclean_operation_duration_seconds_total{%s}`, labels)] += r.Duration.Seconds()
+	series[fmt.Sprintf(`dataclean_operation_bytes_total{%s}`, labels)] += float64(r.Bytes)
+	series[fmt.Sprintf(`dataclean_last_operation_duration_seconds{%s}`, labels)] = r.Duration.Seconds()
+	series[fmt.Sprintf(`dataclean_last_operation_timestamp_seconds{%s}`, labels)] = float64(time.Now().Unix())
+
+	return writeSeries(path, series)
+}
+
+// readSeries parses sample lines ("name{labels} value") from an existing textfile
+func readSeries(path string) (map[string]float64, error) {
+	series := make(map[string]float64)
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return series, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		idx := strings.LastIndex(line, " ")
+		if idx < 0 {
+			continue
+		}
+		value, err := strconv.ParseFloat(line[idx+1:], 64)
+		if err != nil {
+			continue
+		}
+		series[line[:idx]] = value
+	}
+
+	return series, scanner.Err()
+}
+
+// writeSeries writes all series atomically (node_exporter may read at any time)
+func writeSeries(path string, series map[string]float64) error {
+	var b strings.Builder
+	for _, fam := range families {
+		var keys []string
+		for k := range series {
+			if strings.HasPrefix(k, fam.name+"{") {
+				keys = append(keys, k)
+			}
+		}
+		if len(keys) == 0 {
+			continue
+		}
+		sort.Strings(keys)
+
+		fmt.Fprintf(&b, "# HELP %s %s\n", fam.name, fam.help)
+		fmt.Fprintf(&b, "# TYPE %s %s\n", fam.name, fam.kind)
+		for _, k := range keys {
+			fmt.Fprintf(&b, "%s %s\n", k, strconv.FormatFloat(series[k], 'f', -1, 64))
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(b.String()), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
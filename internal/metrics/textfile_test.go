@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteTextfile_Accumulates(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "dataclean-metrics-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	path := filepath.Join(tmpDir, "dataclean.prom")
+
+	records := []Record{
+		{Project: "api", Operation: "restore", Duration: 10 * time.Second, Bytes: 1000},
+		{Project: "api", Operation: "restore", Duration: 20 * time.Second, Bytes: 500},
+		{Project: "api", Operation: "restore", Duration: 5 * time.Second, Err: errors.New("boom")},
+	}
+	for _, r := range records {
+		if err := WriteTextfile(path, r); err != nil {
+			t.Fatalf("WriteTextfile() failed: %v", err)
+		}
+	}
+
+	series, err := readSeries(path)
+	if err != nil {
+		t.Fatalf("readSeries() failed: %v", err)
+	}
+
+	labels := `project="api",operation="restore"`
+	checks := map[string]float64{
+		`dataclean_operations_total{` + labels + `,status="success"}`: 2,
+		`dataclean_operations_total{` + labels + `,status="failure"}`: 1,
+		`dataclean_operation_duration_seconds_total{` + labels + `}`:  35,
+		`dataclean_operation_bytes_total{` + labels + `}`:             1500,
+		`dataclean_last_operation_duration_seconds{` + labels + `}`:   5,
+	}
+	for key, want := range checks {
+		if got := series[key]; got != want {
+			t.Errorf("%s = %v, want %v", key, got, want)
+		}
+	}
+
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "# TYPE dataclean_operations_total counter") {
+		t.Error("textfile should include TYPE comments")
+	}
+}
@@ -2,6 +2,10 @@ package models
 
 import (
 	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
 	"time"
 )
 
@@ -9,49 +13,276 @@ import (
 type DatastoreType string
 
 const (
-	DatastorePostgres DatastoreType = "postgres"
-	DatastoreMySQL    DatastoreType = "mysql"
-	DatastoreRedis    DatastoreType = "redis"
-	DatastoreMongoDB  DatastoreType = "mongodb"
-	DatastoreNeo4j    DatastoreType = "neo4j"
-	DatastoreGeneric  DatastoreType = "generic"
+	DatastorePostgres   DatastoreType = "postgres"
+	DatastoreMySQL      DatastoreType = "mysql"
+	DatastoreRedis      DatastoreType = "redis"
+	DatastoreMongoDB    DatastoreType = "mongodb"
+	DatastoreNeo4j      DatastoreType = "neo4j"
+	DatastoreElastic    DatastoreType = "elasticsearch" // Elasticsearch and OpenSearch
+	DatastoreClickHouse DatastoreType = "clickhouse"
+	DatastoreCassandra  DatastoreType = "cassandra"
+	DatastoreRabbitMQ   DatastoreType = "rabbitmq"
+	DatastoreGeneric    DatastoreType = "generic"
 )
 
 // Volume represents a Docker volume with datastore metadata
 type Volume struct {
-	Name          string        `yaml:"name" json:"name"`
-	DatastoreType DatastoreType `yaml:"datastore_type" json:"datastore_type"`
-	ContainerName string        `yaml:"container_name,omitempty" json:"container_name,omitempty"`
-	MountPath     string        `yaml:"mount_path,omitempty" json:"mount_path,omitempty"`
-	ImageName     string        `yaml:"image_name,omitempty" json:"image_name,omitempty"`
-	SizeBytes     int64         `yaml:"size_bytes,omitempty" json:"size_bytes,omitempty"`
-	SizeHuman     string        `yaml:"size_human,omitempty" json:"size_human,omitempty"`
+	Name          string         `yaml:"name" json:"name"`
+	ComposeName   string         `yaml:"compose_name,omitempty" json:"compose_name,omitempty"` // Key in the compose volumes section
+	DatastoreType DatastoreType  `yaml:"datastore_type" json:"datastore_type"`
+	Service       string         `yaml:"service,omitempty" json:"service,omitempty"`
+	ContainerName string         `yaml:"container_name,omitempty" json:"container_name,omitempty"`
+	MountPath     string         `yaml:"mount_path,omitempty" json:"mount_path,omitempty"`
+	ImageName     string         `yaml:"image_name,omitempty" json:"image_name,omitempty"`
+	ImageDigest   string         `yaml:"image_digest,omitempty" json:"image_digest,omitempty"` // Exact image at snapshot time (repo digest or local ID)
+	SizeBytes     int64          `yaml:"size_bytes,omitempty" json:"size_bytes,omitempty"`
+	SizeHuman     string         `yaml:"size_human,omitempty" json:"size_human,omitempty"`
+	LogicalDump   string         `yaml:"logical_dump,omitempty" json:"logical_dump,omitempty"` // SQL dump file stored next to the archive
+	Tables        []TableSummary `yaml:"tables,omitempty" json:"tables,omitempty"`
+	Runtime       string         `yaml:"runtime,omitempty" json:"runtime,omitempty"`         // Settings file under runtime/, from snapshot --runtime
+	Credentials   *Credentials   `yaml:"credentials,omitempty" json:"credentials,omitempty"` // Discovered from the service environment
+	Checksum      string         `yaml:"checksum,omitempty" json:"checksum,omitempty"`       // SHA-256 of the uncompressed archive
+	ArchiveFormat ArchiveFormat  `yaml:"archive_format,omitempty" json:"archive_format,omitempty"`
+	Exporter      string         `yaml:"exporter,omitempty" json:"exporter,omitempty"`       // Exporter plugin that wrote the archive and restores it
+	ArchiveDir    string         `yaml:"archive_dir,omitempty" json:"archive_dir,omitempty"` // Set when a storage rule put the archive outside the snapshot directory
+	Fingerprint   string         `yaml:"fingerprint,omitempty" json:"fingerprint,omitempty"` // Hash of the file listing (names, sizes, mtimes, modes) at snapshot time
+	LinkedFrom    string         `yaml:"linked_from,omitempty" json:"linked_from,omitempty"` // Snapshot whose unchanged archive was hard-linked instead of exported
+	HotCopy       string         `yaml:"hot_copy,omitempty" json:"hot_copy,omitempty"`       // Write activity seen while copying with the container running
+	Killed        bool           `yaml:"killed,omitempty" json:"killed,omitempty"`           // Container was killed after its stop timeout instead of shutting down cleanly
+	ExportTime    time.Duration  `yaml:"export_time,omitempty" json:"export_time,omitempty"` // How long exporting the archive took; 0 when it was linked or inherited
+	ExportRate    int64          `yaml:"export_rate,omitempty" json:"export_rate,omitempty"` // Archive bytes written per second
+	Alias         string         `yaml:"alias,omitempty" json:"alias,omitempty"`             // Stable name of an anonymous volume, whose Docker name changes
+	SharedWith    []string       `yaml:"shared_with,omitempty" json:"shared_with,omitempty"` // Other services mounting the volume, stopped along with Service
+	Foreign       []string       `yaml:"foreign,omitempty" json:"foreign,omitempty"`         // Containers outside the compose project that mounted the volume and were stopped
+
+	// Extra holds fields a newer dataclean wrote that this release doesn't know,
+	// so saving the metadata again keeps them
+	Extra map[string]any `yaml:",inline" json:"-"`
+}
+
+// VolumeAlias names the anonymous volume mounted at Target in a service's
+// container. Docker names anonymous volumes by a random hash, and a new one
+// comes with every recreated container, so the alias is looked up by mount.
+type VolumeAlias struct {
+	Alias   string `yaml:"alias"`
+	Service string `yaml:"service"`
+	Target  string `yaml:"target"`
+	Volume  string `yaml:"volume,omitempty"` // Docker's name for it when adopted
+}
+
+// ArchiveFormat records how a volume archive was written
+type ArchiveFormat string
+
+const (
+	// ArchiveFormatLegacy is busybox tar from older releases and adopted
+	// tarballs: no sparse files, extended attributes, or ACLs
+	ArchiveFormatLegacy ArchiveFormat = ""
+	// ArchiveFormatPAX is GNU tar in POSIX format with sparse files,
+	// extended attributes, and ACLs preserved
+	ArchiveFormatPAX ArchiveFormat = "gnu-pax"
+)
+
+// String returns a human-readable format name
+func (f ArchiveFormat) String() string {
+	if f == ArchiveFormatLegacy {
+		return "legacy tar"
+	}
+	return string(f)
+}
+
+// Credentials are database login details discovered from a service's environment
+type Credentials struct {
+	User     string `yaml:"user,omitempty" json:"user,omitempty"`
+	Password Secret `yaml:"password,omitempty" json:"password,omitzero"` // Always written redacted
+	Database string `yaml:"database,omitempty" json:"database,omitempty"`
+
+	// StoredPassword is the password in clear text, set only when
+	// store_credentials is enabled. It is never serialized: the snapshot
+	// keeps it in a credentials file only its owner can read.
+	StoredPassword string `yaml:"-" json:"-"`
+}
+
+// Redacted returns a copy that keeps no clear text password
+func (c *Credentials) Redacted() *Credentials {
+	if c == nil {
+		return nil
+	}
+	redacted := *c
+	redacted.StoredPassword = ""
+	if !c.Password.IsZero() {
+		redacted.Password = Secret{redacted: true}
+	}
+	return &redacted
+}
+
+// Stored returns a copy that keeps the clear text password, for the
+// snapshot's credentials file
+func (c *Credentials) Stored() *Credentials {
+	if c == nil {
+		return nil
+	}
+	stored := *c
+	stored.StoredPassword = c.Password.Reveal()
+	return &stored
+}
+
+// TableSummary records a table or collection and its row count at snapshot time
+type TableSummary struct {
+	Name string `yaml:"name" json:"name"`
+	Rows int64  `yaml:"rows" json:"rows"`
 }
 
 // Snapshot represents a saved state of one or more volumes
 type Snapshot struct {
-	Name        string            `yaml:"name" json:"name"`
-	Timestamp   time.Time         `yaml:"timestamp" json:"timestamp"`
-	Volumes     []Volume          `yaml:"volumes" json:"volumes"`
-	SizeBytes   int64             `yaml:"size_bytes" json:"size_bytes"`
-	SizeHuman   string            `yaml:"size_human" json:"size_human"`
-	Path        string            `yaml:"path" json:"path"`
-	Checksum    string            `yaml:"checksum,omitempty" json:"checksum,omitempty"`
-	Tags        []string          `yaml:"tags,omitempty" json:"tags,omitempty"`
-	Description string            `yaml:"description,omitempty" json:"description,omitempty"`
-	Metadata    map[string]string `yaml:"metadata,omitempty" json:"metadata,omitempty"`
-	ParentName  string            `yaml:"parent_name,omitempty" json:"parent_name,omitempty"` // For incremental
-	Incremental bool              `yaml:"incremental,omitempty" json:"incremental,omitempty"`
+	Name          string            `yaml:"name" json:"name"`
+	Timestamp     time.Time         `yaml:"timestamp" json:"timestamp"`
+	Volumes       []Volume          `yaml:"volumes" json:"volumes"`
+	SizeBytes     int64             `yaml:"size_bytes" json:"size_bytes"`
+	SizeHuman     string            `yaml:"size_human" json:"size_human"`
+	Path          string            `yaml:"path" json:"path"`
+	Checksum      string            `yaml:"checksum,omitempty" json:"checksum,omitempty"`
+	Tags          []string          `yaml:"tags,omitempty" json:"tags,omitempty"`
+	Description   string            `yaml:"description,omitempty" json:"description,omitempty"`
+	Metadata      map[string]string `yaml:"metadata,omitempty" json:"metadata,omitempty"`
+	ParentName    string            `yaml:"parent_name,omitempty" json:"parent_name,omitempty"` // For incremental
+	Incremental   bool              `yaml:"incremental,omitempty" json:"incremental,omitempty"`
+	ComposeFile   string            `yaml:"compose_file,omitempty" json:"compose_file,omitempty"`     // Resolved compose config stored with the snapshot
+	ExpiresAt     *time.Time        `yaml:"expires_at,omitempty" json:"expires_at,omitempty"`         // Pruned after this, instead of by retention_days
+	FormatVersion int               `yaml:"format_version,omitempty" json:"format_version,omitempty"` // Layout written in; 0 predates versioning (see migrate-store)
+	Duration      time.Duration     `yaml:"duration,omitempty" json:"duration,omitempty"`             // How long taking the snapshot took, containers stopped included
+	WrittenBy     string            `yaml:"written_by,omitempty" json:"written_by,omitempty"`         // dataclean version that took the snapshot
+	MinReader     string            `yaml:"min_reader,omitempty" json:"min_reader,omitempty"`         // Oldest dataclean version that can restore it
+	SchemaVersion int               `yaml:"schema_version,omitempty" json:"schema_version,omitempty"` // Metadata fields written in; 0 predates versioning
+
+	// Extra holds fields a newer dataclean wrote that this release doesn't know,
+	// so saving the metadata again keeps them
+	Extra map[string]any `yaml:",inline" json:"-"`
+}
+
+// SnapshotFormatVersion is the snapshot layout this release writes. Version 1
+// records a checksum for every volume archive.
+const SnapshotFormatVersion = 1
+
+// SnapshotSchemaVersion is the metadata schema this release writes. Unlike
+// the format version, newer schemas only add fields: they are read, and the
+// fields kept, by older releases.
+const SnapshotSchemaVersion = 1
+
+// SnapshotMinReader is the oldest release that restores what this release
+// writes. Raise it with any change older releases would misread, such as a
+// new compression or encryption format.
+const SnapshotMinReader = "1.0.0"
+
+// Validate checks the fields every snapshot's metadata needs
+func (s *Snapshot) Validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("missing name")
+	}
+	if s.SizeBytes < 0 {
+		return fmt.Errorf("negative size_bytes")
+	}
+	seen := make(map[string]bool, len(s.Volumes))
+	for i, v := range s.Volumes {
+		if v.Name == "" {
+			return fmt.Errorf("volumes[%d]: missing name", i)
+		}
+		if seen[v.Name] {
+			return fmt.Errorf("volumes[%d]: duplicate volume %s", i, v.Name)
+		}
+		seen[v.Name] = true
+		if v.SizeBytes < 0 {
+			return fmt.Errorf("volume %s: negative size_bytes", v.Name)
+		}
+	}
+	return nil
+}
+
+// StoreMigration is what migrate-store did, or would do, to one snapshot
+type StoreMigration struct {
+	Snapshot string   `json:"snapshot"`
+	From     int      `json:"from"` // Format version before migrating
+	Steps    []string `json:"steps,omitempty"`
+	Notes    []string `json:"notes,omitempty"` // Left as is: can't be upgraded without the original data
+	Error    string   `json:"error,omitempty"`
+}
+
+// StoreProblem is something fsck found wrong in the snapshot store
+type StoreProblem struct {
+	Snapshot string `json:"snapshot,omitempty"`
+	Path     string `json:"path,omitempty"`
+	Problem  string `json:"problem"`
+	Repair   string `json:"repair,omitempty"` // What --repair does about it; empty when it needs a person
+	Repaired bool   `json:"repaired,omitempty"`
+	Error    string `json:"error,omitempty"` // Why the repair failed
+}
+
+// FsckReport is what fsck checked and found
+type FsckReport struct {
+	Snapshots int            `json:"snapshots"`
+	Archives  int            `json:"archives"` // Read back and checked against their checksums
+	Problems  []StoreProblem `json:"problems"`
+}
+
+// Unresolved counts the problems still left after any repairs
+func (r *FsckReport) Unresolved() int {
+	n := 0
+	for _, p := range r.Problems {
+		if !p.Repaired {
+			n++
+		}
+	}
+	return n
+}
+
+// Unrepairable counts the problems --repair can't fix
+func (r *FsckReport) Unrepairable() int {
+	n := 0
+	for _, p := range r.Problems {
+		if !p.Repaired && p.Repair == "" {
+			n++
+		}
+	}
+	return n
+}
+
+// ArchiveEntry is a file or directory stored in a volume archive
+type ArchiveEntry struct {
+	Path       string    `json:"path"`
+	SizeBytes  int64     `json:"size_bytes"`
+	Mode       string    `json:"mode"`
+	ModTime    time.Time `json:"mod_time"`
+	IsDir      bool      `json:"is_dir"`
+	LinkTarget string    `json:"link_target,omitempty"`
+}
+
+// ArchiveContents summarizes a volume archive: what is in it and what takes
+// up the space
+type ArchiveContents struct {
+	Volume   string         `json:"volume"`
+	Files    int            `json:"files"`
+	Dirs     int            `json:"dirs"`
+	Bytes    int64          `json:"bytes"`
+	First    []ArchiveEntry `json:"first"`
+	Last     []ArchiveEntry `json:"last,omitempty"`
+	TopLevel []DirUsage     `json:"top_level"` // Largest first
+}
+
+// DirUsage is how much of an archive sits under one top-level directory.
+// Files at the archive root are counted under ".".
+type DirUsage struct {
+	Path    string `json:"path"`
+	Entries int    `json:"entries"`
+	Bytes   int64  `json:"bytes"`
 }
 
 // SizeReport contains detailed size information
 type SizeReport struct {
-	TotalSize      int64         `json:"total_size"`
-	TotalSizeHuman string        `json:"total_size_human"`
+	TotalSize      int64                        `json:"total_size"`
+	TotalSizeHuman string                       `json:"total_size_human"`
 	ByDatastore    map[string]DatastoreSizeInfo `json:"by_datastore"`
-	ByVolume       map[string]int64 `json:"by_volume"`
-	SnapshotCount  int           `json:"snapshot_count"`
-	SnapshotSize   int64         `json:"snapshot_size"`
+	ByVolume       map[string]int64             `json:"by_volume"`
+	SnapshotCount  int                          `json:"snapshot_count"`
+	SnapshotSize   int64                        `json:"snapshot_size"`
 }
 
 // DatastoreSizeInfo holds size info for a datastore type
@@ -67,26 +298,700 @@ type Config struct {
 	// ComposeFile is the path to docker-compose.yaml (auto-detected if empty)
 	ComposeFile string `yaml:"compose_file,omitempty"`
 
-	// Volumes to explicitly include (if empty, auto-detect all)
+	// Volumes to explicitly include (if empty, auto-detect all). Each entry
+	// is a name, a glob (*_cache), or a regular expression after "re:"
+	// (re:^tmp_), matched against the compose, Docker, and alias names.
 	IncludeVolumes []string `yaml:"include_volumes,omitempty"`
 
-	// Volumes to exclude from operations
+	// Volumes to exclude from operations, matched like IncludeVolumes
 	ExcludeVolumes []string `yaml:"exclude_volumes,omitempty"`
 
+	// IncludeServices narrows operations to the volumes of these compose
+	// services (if empty, every service's), on top of the volume lists
+	IncludeServices []string `yaml:"include_services,omitempty"`
+
 	// DatastoreHints maps volume names to datastore types (overrides auto-detection)
 	DatastoreHints map[string]DatastoreType `yaml:"datastore_hints,omitempty"`
 
+	// DatastoreTypes registers additional datastore types with their own match rules and hooks
+	DatastoreTypes []CustomDatastore `yaml:"datastore_types,omitempty"`
+
+	// VolumeAliases adopt anonymous volumes under stable names (see 'dataclean alias')
+	VolumeAliases []VolumeAlias `yaml:"volume_aliases,omitempty"`
+
+	// Quiesce is how containers are stopped around snapshot, restore, and
+	// reset: "container" (default) stops those using the volumes, "project"
+	// runs 'docker compose stop' and 'start' for the whole project
+	Quiesce string `yaml:"quiesce,omitempty"`
+
+	// ForeignContainers is what to do about running containers outside the
+	// compose project that mount a volume (a one-off debug container, say):
+	// "stop" (default) stops them with the project's and starts them again,
+	// "refuse" fails the snapshot, restore, or reset instead
+	ForeignContainers string `yaml:"foreign_containers,omitempty"`
+
+	// QuietWait is how long a snapshot waits for writes to volumes whose
+	// containers keep running to stop before copying them anyway (default 0:
+	// copy at once and flag the volume)
+	QuietWait time.Duration `yaml:"quiet_wait,omitempty"`
+
+	// HealthTimeout bounds the wait for datastores to become healthy after restore/reset (default 60s)
+	HealthTimeout time.Duration `yaml:"health_timeout,omitempty"`
+
+	// PostRestoreExec maps compose services to a shell command run in their
+	// container after a restore, once Docker reports it healthy (e.g. api:
+	// "php artisan cache:clear"), for caches built from the old data
+	PostRestoreExec map[string]string `yaml:"post_restore_exec,omitempty"`
+
+	// StopTimeouts is how long containers get to shut down per datastore type
+	// before Docker kills them (e.g. postgres: 2m). Types not listed use the
+	// datastore's default: 60s for Postgres, MySQL, MongoDB, and Cassandra,
+	// Docker's 10s otherwise.
+	StopTimeouts map[DatastoreType]time.Duration `yaml:"stop_timeouts,omitempty"`
+
+	// DockerRetry retries docker commands that fail on the way to the daemon
+	DockerRetry DockerRetryConfig `yaml:"docker_retry,omitempty"`
+
 	// SnapshotDir is where snapshots are stored (default: .dataclean/)
 	SnapshotDir string `yaml:"snapshot_dir,omitempty"`
 
+	// AutoGitignore adds SnapshotDir to .gitignore without asking when the
+	// first snapshot is taken in a git repository that doesn't ignore it
+	AutoGitignore bool `yaml:"auto_gitignore,omitempty"`
+
+	// Store is "local" (default) to keep snapshots in SnapshotDir, or
+	// "global" to keep them under StoreDir/<project>, outside the repository,
+	// so they survive 'git clean -fdx' and re-clones
+	Store string `yaml:"store,omitempty"`
+
+	// StoreDir is the global store (default: $XDG_DATA_HOME/dataclean or ~/.local/share/dataclean)
+	StoreDir string `yaml:"store_dir,omitempty"`
+
+	// Project names this project's namespace in the global store (default:
+	// the compose project name, i.e. the directory name)
+	Project string `yaml:"project,omitempty"`
+
+	// NamePattern is a regular expression new snapshot names must match on
+	// top of the built-in rules, for team naming conventions (e.g.
+	// ^(feature|release|snapshot|watch)-). Generated names are checked too.
+	NamePattern string `yaml:"name_pattern,omitempty"`
+
+	// StorageRules send matching volume archives somewhere other than SnapshotDir
+	StorageRules []StorageRule `yaml:"storage_rules,omitempty"`
+
+	// Exporters hand export and import of matching volumes to exporter plugins
+	Exporters []ExporterRule `yaml:"exporters,omitempty"`
+
+	// PluginsDir is where exporter plugins are found (default: ~/.config/dataclean/plugins)
+	PluginsDir string `yaml:"plugins_dir,omitempty"`
+
+	// Daemon is the Docker daemon this project belongs to, recorded by init.
+	// Restore and reset refuse to run against any other.
+	Daemon *DaemonIdentity `yaml:"docker_daemon,omitempty"`
+
+	// ReadOnly refuses everything that changes snapshots or volumes, so a
+	// shared machine can use dataclean to look without risk. The
+	// DATACLEAN_READONLY environment variable turns it on too.
+	ReadOnly bool `yaml:"readonly,omitempty"`
+
+	// Policies put guards on matching volumes
+	Policies []VolumePolicy `yaml:"policies,omitempty"`
+
+	// Validate maps volume globs (Docker or compose name) to a query run after
+	// every restore. A restore whose query fails, or prints nothing, 0, or
+	// false, is logged as suspect and fails.
+	Validate map[string]string `yaml:"validate,omitempty"`
+
+	// RestoreAfter orders restores between datastore types: a type's volumes
+	// are imported only once every volume of the types it lists is done (e.g.
+	// elasticsearch: [postgres]). Other volumes are imported in parallel.
+	RestoreAfter map[DatastoreType][]DatastoreType `yaml:"restore_after,omitempty"`
+
+	// RestoreParallelism caps how many volumes a restore imports at once (default 4; 1 imports one at a time)
+	RestoreParallelism int `yaml:"restore_parallelism,omitempty"`
+
+	// AOFCapture keeps copies of Redis append-only files between snapshots
+	AOFCapture AOFCaptureConfig `yaml:"aof_capture,omitempty"`
+
+	// Secrets is an age-encrypted (armored) YAML map of names to values,
+	// which values anywhere in the config refer to with !secret <name>
+	Secrets string `yaml:"secrets,omitempty"`
+
+	// SecretsIdentity is the age identity file that decrypts Secrets
+	// (default: ~/.config/dataclean/age.key; DATACLEAN_AGE_IDENTITY overrides)
+	SecretsIdentity string `yaml:"secrets_identity,omitempty"`
+
+	// Remote is a snapshot store shared with teammates, for share and pull
+	Remote RemoteConfig `yaml:"remote,omitempty"`
+
+	// Mirror copies new snapshots to an off-machine remote as they are taken
+	Mirror MirrorConfig `yaml:"mirror,omitempty"`
+
+	// MCP controls which tools 'dataclean mcp' offers to AI agents
+	MCP MCPConfig `yaml:"mcp,omitempty"`
+
+	// Stacks lists the compose stacks of a monorepo, for snapshotting and
+	// restoring several at once with --stack and --all-stacks
+	Stacks []Stack `yaml:"stacks,omitempty"`
+
 	// BackupBeforeRestore creates automatic backup before restore/reset
-	BackupBeforeRestore bool `yaml:"backup_before_restore,omitempty"`
+	BackupBeforeRestore bool `yaml:"backup_before_restore"`
 
 	// DefaultTags are added to all snapshots
 	DefaultTags []string `yaml:"default_tags,omitempty"`
 
 	// RetentionDays is how long to keep snapshots (0 = forever)
 	RetentionDays int `yaml:"retention_days,omitempty"`
+
+	// StoreCredentials keeps discovered database passwords with each snapshot,
+	// in a credentials file only its owner can read (redacted by default)
+	StoreCredentials bool `yaml:"store_credentials,omitempty"`
+
+	// Notifications are sent when long snapshot/restore operations finish
+	Notifications NotifyConfig `yaml:"notifications,omitempty"`
+
+	// Metrics records operation durations, sizes, and failures
+	Metrics MetricsConfig `yaml:"metrics,omitempty"`
+
+	// EventLog is a file every event (snapshot created, volume cleared,
+	// operation finished, ...) is appended to as a JSON line
+	EventLog string `yaml:"event_log,omitempty"`
+
+	// Keymap remaps TUI actions (up, down, toggle, confirm, expand, collapse, filter, sort, tag, help, quit) to keys
+	Keymap map[string][]string `yaml:"keymap,omitempty"`
+
+	// Theme sets the TUI colors
+	Theme Theme `yaml:"theme,omitempty"`
+}
+
+// StorageRule routes archives of matching volumes to another directory, such
+// as an external disk. Volume is a glob matched against the Docker or compose
+// volume name; when both Volume and Type are set, both must match.
+type StorageRule struct {
+	Volume string        `yaml:"volume,omitempty"`
+	Type   DatastoreType `yaml:"type,omitempty"`
+	Dir    string        `yaml:"dir"`
+}
+
+// Matches reports whether the rule applies to a volume
+func (r StorageRule) Matches(vol Volume) bool {
+	if r.Type != "" && r.Type != vol.DatastoreType {
+		return false
+	}
+	return r.Volume == "" || matchVolumeName(r.Volume, vol)
+}
+
+// ExporterRule hands export and import of matching volumes to an exporter
+// plugin instead of copying their files. Volume and Type match as in
+// StorageRule.
+type ExporterRule struct {
+	Volume string        `yaml:"volume,omitempty"`
+	Type   DatastoreType `yaml:"type,omitempty"`
+	Plugin string        `yaml:"plugin"`
+}
+
+// Matches reports whether the rule applies to a volume
+func (r ExporterRule) Matches(vol Volume) bool {
+	return StorageRule{Volume: r.Volume, Type: r.Type}.Matches(vol)
+}
+
+// ExporterFor returns the plugin the first matching exporter rule assigns a
+// volume, or "" to export it as files
+func (c *Config) ExporterFor(vol Volume) string {
+	for _, r := range c.Exporters {
+		if r.Matches(vol) {
+			return r.Plugin
+		}
+	}
+	return ""
+}
+
+// matchVolumeName matches a glob against a volume's Docker or compose name
+func matchVolumeName(pattern string, vol Volume) bool {
+	if ok, _ := path.Match(pattern, vol.Name); ok {
+		return true
+	}
+	ok, _ := path.Match(pattern, vol.ComposeName)
+	return ok && vol.ComposeName != ""
+}
+
+// StorageDir returns the directory the first matching storage rule sends a
+// volume's archives to, or "" to keep them in the snapshot directory
+func (c *Config) StorageDir(vol Volume) string {
+	for _, r := range c.StorageRules {
+		if r.Matches(vol) {
+			return r.Dir
+		}
+	}
+	return ""
+}
+
+// DaemonIdentity tells Docker daemons apart
+type DaemonIdentity struct {
+	ID   string `yaml:"id"`
+	Name string `yaml:"name,omitempty"` // host name, for messages
+}
+
+func (d DaemonIdentity) String() string {
+	if d.Name == "" {
+		return d.ID
+	}
+	return fmt.Sprintf("%s (%s)", d.Name, d.ID)
+}
+
+// VolumePolicy guards volumes whose Docker or compose name matches a glob
+type VolumePolicy struct {
+	Volume string `yaml:"volume"`
+
+	// Protected volumes are never restored over or reset, even with --force,
+	// unless named with --i-know-what-im-doing
+	Protected bool `yaml:"protected,omitempty"`
+}
+
+// Writable returns an error in read-only mode
+func (c *Config) Writable() error {
+	if c.ReadOnly {
+		return fmt.Errorf("dataclean is read-only here (readonly in the config or DATACLEAN_READONLY): snapshots and volumes can't be changed")
+	}
+	return nil
+}
+
+// Included reports whether include_volumes lets a volume through: it is
+// empty, or a pattern matches one of the volume's names
+func (c *Config) Included(vol Volume) bool {
+	return len(c.IncludeVolumes) == 0 || matchVolumePatterns(c.IncludeVolumes, vol)
+}
+
+// Excluded reports whether a pattern in exclude_volumes matches one of a volume's names
+func (c *Config) Excluded(vol Volume) bool {
+	return matchVolumePatterns(c.ExcludeVolumes, vol)
+}
+
+// ServiceSelected reports whether include_services lets a volume through: it
+// is empty, or names a service mounting the volume
+func (c *Config) ServiceSelected(vol Volume) bool {
+	if len(c.IncludeServices) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(c.IncludeServices, func(s string) bool {
+		return s == vol.Service || slices.Contains(vol.SharedWith, s)
+	})
+}
+
+// SelectVolumes returns the volumes that are included, not excluded, and
+// mounted by a selected service
+func (c *Config) SelectVolumes(vols []Volume) []Volume {
+	var selected []Volume
+	for _, v := range vols {
+		if c.Included(v) && !c.Excluded(v) && c.ServiceSelected(v) {
+			selected = append(selected, v)
+		}
+	}
+	return selected
+}
+
+// volumePatternRegexp marks an include or exclude pattern as a regular expression
+const volumePatternRegexp = "re:"
+
+// CheckVolumePattern reports a malformed include or exclude pattern
+func CheckVolumePattern(pattern string) error {
+	if expr, ok := strings.CutPrefix(pattern, volumePatternRegexp); ok {
+		if _, err := regexp.Compile(expr); err != nil {
+			return fmt.Errorf("invalid regular expression in %q: %w", pattern, err)
+		}
+		return nil
+	}
+	if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+		return fmt.Errorf("invalid volume pattern %q", pattern)
+	}
+	return nil
+}
+
+// MatchVolumePattern matches an include or exclude pattern against a name:
+// a regular expression after "re:", a glob otherwise (which a plain name is)
+func MatchVolumePattern(pattern, name string) bool {
+	if expr, ok := strings.CutPrefix(pattern, volumePatternRegexp); ok {
+		re, err := regexp.Compile(expr)
+		return err == nil && re.MatchString(name)
+	}
+	ok, _ := path.Match(pattern, name)
+	return ok
+}
+
+// matchVolumePatterns reports whether any pattern matches a volume's Docker,
+// compose, or alias name
+func matchVolumePatterns(patterns []string, vol Volume) bool {
+	for _, pattern := range patterns {
+		for _, name := range []string{vol.Name, vol.ComposeName, vol.Alias} {
+			if name != "" && MatchVolumePattern(pattern, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Protected reports whether any policy protects a volume
+func (c *Config) Protected(vol Volume) bool {
+	for _, p := range c.Policies {
+		if p.Protected && matchVolumeName(p.Volume, vol) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidationQueries returns the validation queries that apply to a volume, in a stable order
+func (c *Config) ValidationQueries(vol Volume) []string {
+	var patterns []string
+	for pattern := range c.Validate {
+		if matchVolumeName(pattern, vol) {
+			patterns = append(patterns, pattern)
+		}
+	}
+	slices.Sort(patterns)
+
+	queries := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		queries = append(queries, c.Validate[pattern])
+	}
+	return queries
+}
+
+// RemoteConfig points at a snapshot store shared with teammates
+type RemoteConfig struct {
+	// URL is a directory everyone can reach (a network share or synced
+	// folder), as a path or file:// URL
+	URL string `yaml:"url,omitempty"`
+
+	// ShareKey signs and checks share tokens. It belongs outside the remote,
+	// usually as a !env or !secret reference, since whoever holds it can
+	// issue tokens.
+	ShareKey string `yaml:"share_key,omitempty"`
+
+	// Keep is how many snapshots 'prune --remote' keeps, newest first (0 = no limit)
+	Keep int `yaml:"keep,omitempty"`
+
+	// RetentionDays has 'prune --remote' remove snapshots older than this (0 = forever)
+	RetentionDays int `yaml:"retention_days,omitempty"`
+
+	// Pin lists snapshot names or keys (globs allowed) that 'prune --remote'
+	// never removes
+	Pin []string `yaml:"pin,omitempty"`
+}
+
+// Pinned reports whether a remote snapshot, by name and key, is pinned
+func (c RemoteConfig) Pinned(name, key string) bool {
+	for _, pattern := range c.Pin {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// MirrorConfig copies snapshots to a remote as they are taken and prunes the
+// copies by its own retention, independent of local retention
+type MirrorConfig struct {
+	// Target is the remote directory (path or file:// URL); empty turns mirroring off
+	Target string `yaml:"target,omitempty"`
+
+	// Tags limits mirroring to snapshots with one of these tags (default: all)
+	Tags []string `yaml:"tags,omitempty"`
+
+	// Channel lists the mirrored copies on the target (default: mirror-<project>).
+	// Only copies listed there are pruned.
+	Channel string `yaml:"channel,omitempty"`
+
+	// Keep is how many mirrored copies to keep, newest first (0 = no limit)
+	Keep int `yaml:"keep,omitempty"`
+
+	// RetentionDays drops mirrored copies older than this (0 = forever)
+	RetentionDays int `yaml:"retention_days,omitempty"`
+}
+
+// Wants reports whether the mirror policy copies a snapshot. Internal
+// snapshots (pre-restore backups and the like) are never mirrored.
+func (m MirrorConfig) Wants(s *Snapshot) bool {
+	if m.Target == "" || strings.HasPrefix(s.Name, "_") {
+		return false
+	}
+	if len(m.Tags) == 0 {
+		return true
+	}
+	for _, tag := range s.Tags {
+		if slices.Contains(m.Tags, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// Snapshot stores
+const (
+	StoreLocal  = "local"
+	StoreGlobal = "global"
+)
+
+// Quiesce modes
+const (
+	QuiesceContainer = "container"
+	QuiesceProject   = "project"
+)
+
+// What to do about containers outside the compose project using a volume
+const (
+	ForeignStop   = "stop"
+	ForeignRefuse = "refuse"
+)
+
+// Destructive MCP tools are only offered when listed in mcp.allow_tools
+const (
+	MCPRestoreTool = "restore_snapshot"
+	MCPDeleteTool  = "delete_snapshot"
+)
+
+// MCPDestructiveTools lists every MCP tool that needs allowing
+var MCPDestructiveTools = []string{MCPRestoreTool, MCPDeleteTool}
+
+// Stack is one compose stack of a monorepo. Commands run for a stack as if
+// started in its directory, with that directory's own .dataclean.yaml.
+type Stack struct {
+	Name    string `yaml:"name" json:"name"`
+	Path    string `yaml:"path" json:"path"`                           // Directory holding the stack's compose file
+	Project string `yaml:"project,omitempty" json:"project,omitempty"` // Compose project name (default: the directory's name)
+}
+
+// StackSet records a snapshot taken across several stacks at once: each
+// stack holds a snapshot of the set's name in its own snapshot directory
+type StackSet struct {
+	Name      string        `yaml:"name" json:"name"`
+	Timestamp time.Time     `yaml:"timestamp" json:"timestamp"`
+	Stacks    []StackMember `yaml:"stacks" json:"stacks"`
+
+	// Consistent sets were taken with every stack stopped at once, so the
+	// snapshots hold one point in time
+	Consistent bool `yaml:"consistent,omitempty" json:"consistent,omitempty"`
+}
+
+// StackMember is one stack's part of a StackSet
+type StackMember struct {
+	Stack     `yaml:",inline"`
+	Volumes   []string `yaml:"volumes" json:"volumes"`
+	SizeBytes int64    `yaml:"size_bytes" json:"size_bytes"`
+}
+
+// SnapshotGroup ties existing snapshots, possibly of different stacks, into
+// one logical snapshot that is restored as a whole
+type SnapshotGroup struct {
+	Name      string        `yaml:"name" json:"name"`
+	Timestamp time.Time     `yaml:"timestamp" json:"timestamp"`
+	Members   []GroupMember `yaml:"members" json:"members"`
+}
+
+// GroupMember is one snapshot of a SnapshotGroup
+type GroupMember struct {
+	Stack    string `yaml:"stack,omitempty" json:"stack,omitempty"` // Name under stacks in the config; empty for the project itself
+	Snapshot string `yaml:"snapshot" json:"snapshot"`
+
+	// Taken is the snapshot's timestamp, so a snapshot deleted and taken
+	// again under the same name isn't mistaken for the member
+	Taken time.Time `yaml:"taken" json:"taken"`
+}
+
+// String names the member the way 'group create' takes it: snapshot or stack:snapshot
+func (m GroupMember) String() string {
+	if m.Stack == "" {
+		return m.Snapshot
+	}
+	return m.Stack + ":" + m.Snapshot
+}
+
+// MCPConfig controls the tools offered by the MCP server
+type MCPConfig struct {
+	// AllowTools turns on destructive tools, which are off by default
+	AllowTools []string `yaml:"allow_tools,omitempty"`
+}
+
+// Allows reports whether a destructive tool is turned on
+func (c MCPConfig) Allows(tool string) bool {
+	return slices.Contains(c.AllowTools, tool)
+}
+
+// DockerRetryConfig is how the docker commands that are safe to repeat
+// (stopping and starting containers, and the helper containers that read and
+// import volumes) are tried again when the daemon can't be reached, e.g. while
+// it restarts or over a flaky connection to a remote DOCKER_HOST
+type DockerRetryConfig struct {
+	Attempts int           `yaml:"attempts,omitempty"` // Tries in all (default 3; 1 turns retries off)
+	Delay    time.Duration `yaml:"delay,omitempty"`    // Wait before the first retry, doubling for each after it (default 1s)
+}
+
+// AOFCaptureConfig selects the Redis volumes whose append-only files are
+// captured by 'pitr sync', for restore --to
+type AOFCaptureConfig struct {
+	Volumes []string `yaml:"volumes,omitempty"` // Globs matched against the Docker or compose volume name
+	Keep    int      `yaml:"keep,omitempty"`    // Captures kept per volume (default 24)
+}
+
+// Enabled reports whether a volume's append-only files are captured
+func (c AOFCaptureConfig) Enabled(vol Volume) bool {
+	if vol.DatastoreType != DatastoreRedis {
+		return false
+	}
+	for _, pattern := range c.Volumes {
+		if matchVolumeName(pattern, vol) {
+			return true
+		}
+	}
+	return false
+}
+
+// Theme selects a color preset for the TUI and overrides individual colors.
+// Colors are ANSI 256 numbers ("39") or hex ("#0087ff").
+type Theme struct {
+	Preset    string `yaml:"preset,omitempty"` // auto (default), dark, or light
+	Accent    string `yaml:"accent,omitempty"`
+	Highlight string `yaml:"highlight,omitempty"`
+	Muted     string `yaml:"muted,omitempty"`
+	Warning   string `yaml:"warning,omitempty"`
+	Error     string `yaml:"error,omitempty"`
+	Success   string `yaml:"success,omitempty"`
+}
+
+// CustomDatastore is a user-defined datastore type from .dataclean.yaml.
+// Commands run with sh inside the service's container.
+type CustomDatastore struct {
+	Name        DatastoreType `yaml:"name"`
+	DisplayName string        `yaml:"display_name,omitempty"`
+	Icon        string        `yaml:"icon,omitempty"`
+
+	// Images and MountPaths are substrings matched against the service image and mount target
+	Images     []string `yaml:"images,omitempty"`
+	MountPaths []string `yaml:"mount_paths,omitempty"`
+
+	// Dump writes a logical dump to stdout (used by snapshot --logical)
+	Dump string `yaml:"dump,omitempty"`
+
+	// Quiesce runs before containers are stopped for a snapshot (e.g. flush to disk)
+	Quiesce string `yaml:"quiesce,omitempty"`
+
+	// Health exits 0 once the datastore is ready after restore/reset
+	Health string `yaml:"health,omitempty"`
+
+	// Restore runs after a restore, once the datastore is healthy
+	Restore string `yaml:"restore,omitempty"`
+
+	// StopTimeout is how long containers get to shut down before Docker kills them
+	StopTimeout time.Duration `yaml:"stop_timeout,omitempty"`
+}
+
+// Matches reports whether a service image or mount path matches the type's rules
+func (d CustomDatastore) Matches(image, mountPath string) bool {
+	image = strings.ToLower(image)
+	for _, pattern := range d.Images {
+		if strings.Contains(image, strings.ToLower(pattern)) {
+			return true
+		}
+	}
+	for _, pattern := range d.MountPaths {
+		if strings.Contains(mountPath, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// customDatastores holds the types registered from config
+var customDatastores = map[DatastoreType]CustomDatastore{}
+
+// RegisterDatastores makes custom datastore types known for display and hooks
+func RegisterDatastores(types []CustomDatastore) error {
+	for i, d := range types {
+		switch {
+		case d.Name == "":
+			return fmt.Errorf("datastore_types[%d]: name is required", i)
+		case slices.Contains(builtinDatastores, d.Name):
+			return fmt.Errorf("datastore type %s is built in; use datastore_hints to assign it", d.Name)
+		case len(d.Images) == 0 && len(d.MountPaths) == 0:
+			return fmt.Errorf("datastore type %s: needs at least one of images or mount_paths", d.Name)
+		}
+	}
+
+	for _, d := range types {
+		customDatastores[d.Name] = d
+	}
+	return nil
+}
+
+// LookupDatastore returns a registered custom datastore type
+func LookupDatastore(dt DatastoreType) (CustomDatastore, bool) {
+	d, ok := customDatastores[dt]
+	return d, ok
+}
+
+// MetricsConfig controls operation metrics export
+type MetricsConfig struct {
+	// Textfile is a Prometheus textfile path (for node_exporter's textfile collector)
+	Textfile string `yaml:"textfile,omitempty"`
+}
+
+// NotifyConfig controls completion notifications for long operations
+type NotifyConfig struct {
+	// Desktop shows a native notification (notify-send / osascript)
+	Desktop bool `yaml:"desktop,omitempty"`
+
+	// Webhooks receive a JSON payload describing the operation
+	Webhooks []string `yaml:"webhooks,omitempty"`
+
+	// Slack incoming-webhook URLs receive a formatted message
+	Slack []string `yaml:"slack,omitempty"`
+
+	// MinDuration skips notifications for operations faster than this (e.g. "30s")
+	MinDuration time.Duration `yaml:"min_duration,omitempty"`
+
+	// SnapshotWebhooks are told whenever a snapshot is created, restored,
+	// deleted, or pruned, however long it took
+	SnapshotWebhooks []SnapshotWebhook `yaml:"snapshot_webhooks,omitempty"`
+}
+
+// Snapshot lifecycle actions sent to snapshot webhooks
+const (
+	SnapshotCreated  = "create"
+	SnapshotRestored = "restore"
+	SnapshotDeleted  = "delete"
+	SnapshotPruned   = "prune" // removed by retention or compact --prune
+)
+
+// SnapshotActions lists every snapshot lifecycle action
+var SnapshotActions = []string{SnapshotCreated, SnapshotRestored, SnapshotDeleted, SnapshotPruned}
+
+// SnapshotWebhook posts a message about snapshot lifecycle events to a URL
+type SnapshotWebhook struct {
+	URL string `yaml:"url"`
+
+	// Events limits which actions are sent (default: all)
+	Events []string `yaml:"events,omitempty"`
+
+	// Template is a Go text/template for the request body, executed with the
+	// event; without one the event is sent as JSON
+	Template string `yaml:"template,omitempty"`
+
+	// ContentType of the request body (default: application/json)
+	ContentType string `yaml:"content_type,omitempty"`
+}
+
+// Wants reports whether the webhook subscribes to an action
+func (w SnapshotWebhook) Wants(action string) bool {
+	return len(w.Events) == 0 || slices.Contains(w.Events, action)
+}
+
+// Enabled reports whether any notification channel is configured
+func (n NotifyConfig) Enabled() bool {
+	return n.Desktop || len(n.Webhooks) > 0 || len(n.Slack) > 0
 }
 
 // DefaultConfig returns a Config with sensible defaults
@@ -110,21 +1015,54 @@ func GetDatastoreInfo(dt DatastoreType) (name string, icon string) {
 		return "MongoDB", "🍃"
 	case DatastoreNeo4j:
 		return "Neo4j", "🔵"
-	default:
-		return "Generic Volume", "📦"
+	case DatastoreElastic:
+		return "Elasticsearch/OpenSearch", "🔍"
+	case DatastoreClickHouse:
+		return "ClickHouse", "🟨"
+	case DatastoreCassandra:
+		return "Cassandra", "🪐"
+	case DatastoreRabbitMQ:
+		return "RabbitMQ", "🐇"
 	}
+
+	if custom, ok := customDatastores[dt]; ok {
+		name, icon = custom.DisplayName, custom.Icon
+		if name == "" {
+			name = string(custom.Name)
+		}
+		if icon == "" {
+			icon = "📦"
+		}
+		return name, icon
+	}
+	return "Generic Volume", "📦"
+}
+
+// builtinDatastores are the types dataclean knows natively
+var builtinDatastores = []DatastoreType{
+	DatastorePostgres,
+	DatastoreMySQL,
+	DatastoreRedis,
+	DatastoreMongoDB,
+	DatastoreNeo4j,
+	DatastoreElastic,
+	DatastoreClickHouse,
+	DatastoreCassandra,
+	DatastoreRabbitMQ,
+	DatastoreGeneric,
 }
 
 // AvailableDatastores returns all supported datastore types
 func AvailableDatastores() []DatastoreType {
-	return []DatastoreType{
-		DatastorePostgres,
-		DatastoreMySQL,
-		DatastoreRedis,
-		DatastoreMongoDB,
-		DatastoreNeo4j,
-		DatastoreGeneric,
+	types := slices.Clone(builtinDatastores)
+
+	// Custom types follow the built-ins in name order
+	var custom []DatastoreType
+	for name := range customDatastores {
+		custom = append(custom, name)
 	}
+	slices.Sort(custom)
+	return append(types, custom...)
 }
 
 // FormatSize converts bytes to human-readable format
@@ -140,3 +1078,225 @@ func FormatSize(bytes int64) string {
 	}
 	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
 }
+
+// ContainerStatus is a container and its state (running, exited, ...)
+type ContainerStatus struct {
+	Name  string `json:"name"`
+	State string `json:"state"`
+}
+
+// VolumeStatus is the live state of a volume compared to its last snapshot
+type VolumeStatus struct {
+	Volume       Volume            `json:"volume"`
+	SizeBytes    int64             `json:"size_bytes"`
+	SizeHuman    string            `json:"size_human"`
+	Containers   []ContainerStatus `json:"containers"`
+	LastSnapshot string            `json:"last_snapshot,omitempty"`
+	SnapshotTime time.Time         `json:"snapshot_time,omitzero"`
+	LastModified time.Time         `json:"last_modified,omitzero"`
+	Clean        *bool             `json:"clean,omitempty"` // No writes since the last snapshot (nil if unknown)
+	Restored     *RestoreRecord    `json:"restored,omitempty"`
+	SizeDelta    int64             `json:"size_delta"`        // Growth since the last restore
+	Drifted      *bool             `json:"drifted,omitempty"` // Written to since the last restore (nil if unknown)
+}
+
+// RestorePreview is what restoring a snapshot would replace in one volume
+type RestorePreview struct {
+	Status        VolumeStatus `json:"status"`         // The volume's live data
+	SnapshotBytes int64        `json:"snapshot_bytes"` // What the snapshot puts in its place
+	Tables        []TableDelta `json:"tables,omitempty"`
+}
+
+// TableDelta is a table whose live row count differs from the snapshot's
+type TableDelta struct {
+	Name     string `json:"name"`
+	Live     int64  `json:"live"`
+	Snapshot int64  `json:"snapshot"`
+	Dropped  bool   `json:"dropped,omitempty"`  // Exists only live, so the restore removes it
+	Restored bool   `json:"restored,omitempty"` // Exists only in the snapshot
+}
+
+// RestoreRecord remembers which snapshot a volume was last restored from
+type RestoreRecord struct {
+	Snapshot   string    `yaml:"snapshot" json:"snapshot"`
+	RestoredAt time.Time `yaml:"restored_at" json:"restored_at"`
+	SizeBytes  int64     `yaml:"size_bytes" json:"size_bytes"` // Volume size right after the restore
+}
+
+// Outcomes of a restore in the history log
+const (
+	RestoreOK      = "ok"
+	RestoreSuspect = "suspect" // Restored, but validation queries failed
+	RestoreFailed  = "failed"
+)
+
+// HistoryEntry is one restore in the history log
+type HistoryEntry struct {
+	Time     time.Time `json:"time"`
+	Snapshot string    `json:"snapshot"`
+	Volumes  []string  `json:"volumes,omitempty"`
+	Status   string    `json:"status"`
+	Problems []string  `json:"problems,omitempty"`
+}
+
+// Docker labels on volumes created by a restore, recording where their data
+// came from. Docker can't relabel existing volumes, so for those the state
+// file is the only record.
+const (
+	LabelSnapshot   = "dataclean.snapshot"
+	LabelRestoredAt = "dataclean.restored_at"
+)
+
+// ProvenanceLabels returns the labels ("key=value") for a restore
+func ProvenanceLabels(snapshot string, at time.Time) []string {
+	return []string{LabelSnapshot + "=" + snapshot, LabelRestoredAt + "=" + at.UTC().Format(time.RFC3339)}
+}
+
+// ProvenanceFromLabels reads a restore back from a volume's labels, or
+// returns nil when they don't record one
+func ProvenanceFromLabels(labels map[string]string) *RestoreRecord {
+	name := labels[LabelSnapshot]
+	if name == "" {
+		return nil
+	}
+	at, _ := time.Parse(time.RFC3339, labels[LabelRestoredAt])
+	return &RestoreRecord{Snapshot: name, RestoredAt: at}
+}
+
+// State is dataclean's record of the project's volumes, kept in the snapshot directory
+type State struct {
+	Restored map[string]RestoreRecord `yaml:"restored,omitempty"` // Keyed by volume name
+	PITR     map[string]time.Time     `yaml:"pitr,omitempty"`     // Volumes archiving WAL, and since when
+}
+
+// VolumeResult is the outcome of restoring one volume
+type VolumeResult struct {
+	Volume   string `json:"volume"`
+	Cleared  bool   `json:"cleared"`
+	Imported bool   `json:"imported"`
+	Error    string `json:"error,omitempty"`
+
+	// Created and Empty record why a volume wasn't cleared: the restore
+	// created it, or it held no data
+	Created bool `json:"created,omitempty"`
+	Empty   bool `json:"empty,omitempty"`
+
+	// RolledBack is set when the restore failed or was cancelled and the
+	// volume was put back from the pre-restore backup
+	RolledBack bool `json:"rolled_back,omitempty"`
+}
+
+// RestoreResult summarizes what a restore did to each volume
+type RestoreResult struct {
+	Snapshot    string         `json:"snapshot"`
+	Backup      string         `json:"backup,omitempty"`       // Pre-restore backup snapshot
+	RecoveredTo *time.Time     `json:"recovered_to,omitempty"` // Point in time WAL was replayed to
+	Volumes     []VolumeResult `json:"volumes"`
+	Suspect     []string       `json:"suspect,omitempty"`      // Failed validation queries
+	ConfigDrift []string       `json:"config_drift,omitempty"` // Settings that changed since snapshot --runtime captured them
+}
+
+// PlanActionKind identifies a single step an operation would perform
+type PlanActionKind string
+
+const (
+	ActionStopContainer  PlanActionKind = "stop_container"
+	ActionStartContainer PlanActionKind = "start_container"
+	ActionVerifyArchive  PlanActionKind = "verify_archive"
+	ActionCreateBackup   PlanActionKind = "create_backup"
+	ActionExportVolume   PlanActionKind = "export_volume"
+	ActionClearVolume    PlanActionKind = "clear_volume"
+	ActionImportVolume   PlanActionKind = "import_volume"
+	ActionDeleteSnapshot PlanActionKind = "delete_snapshot"
+	ActionExec           PlanActionKind = "exec"
+)
+
+// PlanAction is one simulated step of an operation
+type PlanAction struct {
+	Kind      PlanActionKind `json:"kind"`
+	Target    string         `json:"target"`
+	Path      string         `json:"path,omitempty"`
+	SizeBytes int64          `json:"size_bytes,omitempty"`
+	SizeHuman string         `json:"size_human,omitempty"`
+	Note      string         `json:"note,omitempty"`
+	Step      int            `json:"step,omitempty"` // Recipe step the action belongs to (apply)
+}
+
+// Plan describes what an operation would do without executing it (used by --dry-run)
+type Plan struct {
+	Operation      string       `json:"operation"`
+	Snapshot       string       `json:"snapshot,omitempty"`
+	Steps          []string     `json:"steps,omitempty"` // Recipe steps, by PlanAction.Step - 1 (apply)
+	Actions        []PlanAction `json:"actions"`
+	EstimatedBytes int64        `json:"estimated_bytes"`
+	EstimatedHuman string       `json:"estimated_human"`
+}
+
+// Add appends an action to the plan and accumulates its size
+func (p *Plan) Add(action PlanAction) {
+	if action.SizeBytes > 0 && action.SizeHuman == "" {
+		action.SizeHuman = FormatSize(action.SizeBytes)
+	}
+	p.Actions = append(p.Actions, action)
+	if action.Kind == ActionExportVolume || action.Kind == ActionImportVolume {
+		p.EstimatedBytes += action.SizeBytes
+	}
+	p.EstimatedHuman = FormatSize(p.EstimatedBytes)
+}
+
+// Recipe is a plan file for 'dataclean apply': operations run in order, so an
+// environment refresh can be checked into the repo and repeated
+type Recipe struct {
+	Description string       `yaml:"description,omitempty"`
+	Steps       []RecipeStep `yaml:"steps"`
+}
+
+// RecipeStep is one operation of a recipe. Exactly one of Snapshot, Reset,
+// and Restore is set; the other fields refine it.
+type RecipeStep struct {
+	Snapshot string   `yaml:"snapshot,omitempty"` // Take a snapshot with this name
+	Reset    []string `yaml:"reset,omitempty"`    // Empty these volumes (Docker or compose names)
+	Restore  string   `yaml:"restore,omitempty"`  // Restore this snapshot
+
+	Volumes     []string `yaml:"volumes,omitempty"`     // Snapshot only these volumes
+	Tags        []string `yaml:"tags,omitempty"`        // Tags for the snapshot
+	Description string   `yaml:"description,omitempty"` // Description for the snapshot
+
+	// Into restores snapshot volumes (keys, Docker or compose names) into
+	// other volumes (values) instead of their own
+	Into map[string]string `yaml:"into,omitempty"`
+}
+
+// Op names the step's operation
+func (s RecipeStep) Op() string {
+	switch {
+	case s.Snapshot != "":
+		return "snapshot"
+	case len(s.Reset) > 0:
+		return "reset"
+	case s.Restore != "":
+		return "restore"
+	}
+	return ""
+}
+
+// String describes the step, e.g. "restore seeded"
+func (s RecipeStep) String() string {
+	switch s.Op() {
+	case "snapshot":
+		return "snapshot " + s.Snapshot
+	case "reset":
+		return "reset " + strings.Join(s.Reset, ", ")
+	case "restore":
+		var into []string
+		for from, to := range s.Into {
+			into = append(into, from+" into "+to)
+		}
+		if len(into) == 0 {
+			return "restore " + s.Restore
+		}
+		slices.Sort(into)
+		return fmt.Sprintf("restore %s (%s)", s.Restore, strings.Join(into, ", "))
+	}
+	return "empty step"
+}
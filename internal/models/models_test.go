@@ -2,8 +2,14 @@
 package models
 
 import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
 	"testing"
 	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 func TestFormatSize(t *testing.T) {
@@ -42,6 +48,10 @@ func TestGetDatastoreInfo(t *testing.T) {
 		{DatastoreRedis, "Redis", true},
 		{DatastoreMongoDB, "MongoDB", true},
 		{DatastoreNeo4j, "Neo4j", true},
+		{DatastoreElastic, "Elasticsearch/OpenSearch", true},
+		{DatastoreClickHouse, "ClickHouse", true},
+		{DatastoreCassandra, "Cassandra", true},
+		{DatastoreRabbitMQ, "RabbitMQ", true},
 		{DatastoreGeneric, "Generic Volume", true},
 		{"unknown", "Generic Volume", true},
 	}
@@ -69,6 +79,10 @@ func TestAvailableDatastores(t *testing.T) {
 		DatastoreRedis,
 		DatastoreMongoDB,
 		DatastoreNeo4j,
+		DatastoreElastic,
+		DatastoreClickHouse,
+		DatastoreCassandra,
+		DatastoreRabbitMQ,
 		DatastoreGeneric,
 	}
 
@@ -164,3 +178,193 @@ func TestConfigWithIncludes(t *testing.T) {
 		t.Error("datastore hint mismatch")
 	}
 }
+
+func TestSelectVolumes(t *testing.T) {
+	vols := []Volume{
+		{Name: "shop_pgdata", ComposeName: "pgdata"},
+		{Name: "shop_redis_cache", ComposeName: "redis_cache"},
+		{Name: "shop_tmp_uploads", ComposeName: "tmp_uploads"},
+		{Name: "0f3a9c", Alias: "search-data"},
+	}
+	names := func(vols []Volume) []string {
+		var out []string
+		for _, v := range vols {
+			out = append(out, v.Name)
+		}
+		return out
+	}
+
+	tests := []struct {
+		include, exclude []string
+		want             []string
+	}{
+		{nil, nil, []string{"shop_pgdata", "shop_redis_cache", "shop_tmp_uploads", "0f3a9c"}},
+		{[]string{"pgdata"}, nil, []string{"shop_pgdata"}},
+		{[]string{"shop_pgdata", "search-*"}, nil, []string{"shop_pgdata", "0f3a9c"}},
+		{nil, []string{"*_cache", "re:^tmp_"}, []string{"shop_pgdata", "0f3a9c"}},
+		{[]string{"re:^shop_"}, []string{"tmp_*"}, []string{"shop_pgdata", "shop_redis_cache"}},
+	}
+	for _, tt := range tests {
+		cfg := &Config{IncludeVolumes: tt.include, ExcludeVolumes: tt.exclude}
+		if got := names(cfg.SelectVolumes(vols)); !slices.Equal(got, tt.want) {
+			t.Errorf("SelectVolumes(include %v, exclude %v) = %v, want %v", tt.include, tt.exclude, got, tt.want)
+		}
+	}
+
+	for _, pattern := range []string{"re:(", "[", ""} {
+		if CheckVolumePattern(pattern) == nil {
+			t.Errorf("CheckVolumePattern(%q) = nil, want an error", pattern)
+		}
+	}
+}
+
+func TestRegisterDatastores(t *testing.T) {
+	defer func() { customDatastores = map[DatastoreType]CustomDatastore{} }()
+
+	questdb := CustomDatastore{
+		Name:        "questdb",
+		DisplayName: "QuestDB",
+		Icon:        "🟣",
+		Images:      []string{"questdb"},
+		MountPaths:  []string{"/var/lib/questdb"},
+	}
+	if err := RegisterDatastores([]CustomDatastore{questdb}); err != nil {
+		t.Fatalf("RegisterDatastores() failed: %v", err)
+	}
+
+	if name, icon := GetDatastoreInfo("questdb"); name != "QuestDB" || icon != "🟣" {
+		t.Errorf("GetDatastoreInfo(questdb) = %s, %s", name, icon)
+	}
+	types := AvailableDatastores()
+	if types[len(types)-1] != "questdb" {
+		t.Errorf("custom type should follow built-ins, got %v", types)
+	}
+
+	if !questdb.Matches("QuestDB/questdb:8", "/data") {
+		t.Error("expected image match")
+	}
+	if !questdb.Matches("mycorp/tsdb", "/var/lib/questdb") {
+		t.Error("expected mount path match")
+	}
+	if questdb.Matches("postgres:16", "/var/lib/postgresql/data") {
+		t.Error("unexpected match")
+	}
+
+	invalid := [][]CustomDatastore{
+		{{Images: []string{"x"}}},
+		{{Name: DatastorePostgres, Images: []string{"x"}}},
+		{{Name: "noRules"}},
+	}
+	for _, types := range invalid {
+		if err := RegisterDatastores(types); err == nil {
+			t.Errorf("expected error registering %+v", types)
+		}
+	}
+}
+
+func TestSecret_NeverSerialized(t *testing.T) {
+	creds := Credentials{User: "app", Password: NewSecret("hunter2"), Database: "appdb"}
+
+	yamlOut, err := yaml.Marshal(creds)
+	if err != nil {
+		t.Fatalf("yaml.Marshal() failed: %v", err)
+	}
+	jsonOut, err := json.Marshal(creds)
+	if err != nil {
+		t.Fatalf("json.Marshal() failed: %v", err)
+	}
+
+	outputs := map[string]string{
+		"yaml": string(yamlOut),
+		"json": string(jsonOut),
+		"%v":   fmt.Sprintf("%v", creds),
+		"%+v":  fmt.Sprintf("%+v", creds),
+		"%#v":  fmt.Sprintf("%#v", creds),
+		"%s":   fmt.Sprintf("%s", creds.Password),
+	}
+	for name, out := range outputs {
+		if strings.Contains(out, "hunter2") {
+			t.Errorf("%s output leaks the password: %s", name, out)
+		}
+	}
+	if !strings.Contains(string(yamlOut), RedactedPassword) {
+		t.Errorf("expected redacted placeholder in yaml:\n%s", yamlOut)
+	}
+
+	if got := creds.Password.Reveal(); got != "hunter2" {
+		t.Errorf("Reveal() = %q, want hunter2", got)
+	}
+}
+
+func TestCredentials_StoredAndRedacted(t *testing.T) {
+	creds := &Credentials{User: "app", Password: NewSecret("hunter2")}
+
+	out, _ := yaml.Marshal(creds.Redacted())
+	if strings.Contains(string(out), "hunter2") {
+		t.Errorf("redacted credentials leak the password:\n%s", out)
+	}
+
+	// Even the clear text kept for store_credentials never serializes
+	out, _ = yaml.Marshal(creds.Stored())
+	if strings.Contains(string(out), "hunter2") {
+		t.Errorf("stored credentials leak the password:\n%s", out)
+	}
+
+	// Reading metadata back keeps a redacted password redacted
+	var loaded Credentials
+	if err := yaml.Unmarshal([]byte("user: app\npassword: \"********\"\n"), &loaded); err != nil {
+		t.Fatalf("yaml.Unmarshal() failed: %v", err)
+	}
+	if loaded.Password.Reveal() != "" || loaded.Password.IsZero() {
+		t.Errorf("loaded password = %#v, want redacted", loaded.Password)
+	}
+}
+
+func TestRedactConnectionString(t *testing.T) {
+	tests := map[string]string{
+		"postgres://app:hunter2@db:5432/app?sslmode=disable": "postgres://app:********@db:5432/app?sslmode=disable",
+		"redis://:hunter2@cache:6379/0":                      "redis://:********@cache:6379/0",
+		"postgres://app@db/app":                              "postgres://app@db/app",
+		"not a url":                                          "not a url",
+	}
+	for in, want := range tests {
+		if got := RedactConnectionString(in); got != want {
+			t.Errorf("RedactConnectionString(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestProvenanceLabels(t *testing.T) {
+	at := time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC)
+	labels := map[string]string{}
+	for _, l := range ProvenanceLabels("seeded", at) {
+		key, value, _ := strings.Cut(l, "=")
+		labels[key] = value
+	}
+
+	rec := ProvenanceFromLabels(labels)
+	if rec == nil || rec.Snapshot != "seeded" || !rec.RestoredAt.Equal(at) {
+		t.Errorf("ProvenanceFromLabels() = %+v, want seeded at %s", rec, at)
+	}
+	if rec := ProvenanceFromLabels(map[string]string{"com.docker.compose.volume": "pgdata"}); rec != nil {
+		t.Errorf("ProvenanceFromLabels() = %+v for a volume no restore created, want nil", rec)
+	}
+}
+
+func TestMirrorConfig_Wants(t *testing.T) {
+	golden := &Snapshot{Name: "seeded", Tags: []string{"golden"}}
+	plain := &Snapshot{Name: "scratch"}
+	backup := &Snapshot{Name: "_pre-restore-20240501-093000"}
+
+	all := MirrorConfig{Target: "/mnt/backup"}
+	if !all.Wants(golden) || !all.Wants(plain) || all.Wants(backup) {
+		t.Error("a policy without tags should mirror every snapshot but internal ones")
+	}
+	tagged := MirrorConfig{Target: "/mnt/backup", Tags: []string{"golden"}}
+	if !tagged.Wants(golden) || tagged.Wants(plain) {
+		t.Error("a policy with tags should mirror only matching snapshots")
+	}
+	if (MirrorConfig{}).Wants(golden) {
+		t.Error("a policy without a target mirrors nothing")
+	}
+}
@@ -0,0 +1,166 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"runtime"
+	"time"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+// Event describes a finished operation
+type Event struct {
+	Operation string        // snapshot, restore, ...
+	Target    string        // snapshot name
+	Project   string        // compose project directory
+	Duration  time.Duration // how long the operation took
+	Err       error         // nil on success
+}
+
+// Notifier delivers completion notifications
+type Notifier struct {
+	cfg  models.NotifyConfig
+	http *http.Client
+}
+
+// webhookPayload is the JSON body sent to generic webhooks
+type webhookPayload struct {
+	Operation       string  `json:"operation"`
+	Target          string  `json:"target"`
+	Project         string  `json:"project,omitempty"`
+	Status          string  `json:"status"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	Error           string  `json:"error,omitempty"`
+}
+
+// New creates a notifier for the configured channels
+func New(cfg models.NotifyConfig) *Notifier {
+	return &Notifier{
+		cfg:  cfg,
+		http: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Notify sends the event to every configured channel. Events shorter than
+// MinDuration are skipped. Delivery errors are returned but never fatal.
+func (n *Notifier) Notify(e Event) []error {
+	if !n.cfg.Enabled() || e.Duration < n.cfg.MinDuration {
+		return nil
+	}
+
+	var errs []error
+	if n.cfg.Desktop {
+		if err := desktop(e.title(), e.message()); err != nil {
+			errs = append(errs, fmt.Errorf("desktop notification: %w", err))
+		}
+	}
+	for _, hook := range n.cfg.Webhooks {
+		if err := n.post(hook, e.payload()); err != nil {
+			errs = append(errs, fmt.Errorf("webhook %s: %w", RedactURL(hook), err))
+		}
+	}
+	for _, hook := range n.cfg.Slack {
+		if err := n.post(hook, map[string]string{"text": e.title() + ": " + e.message()}); err != nil {
+			errs = append(errs, fmt.Errorf("slack %s: %w", RedactURL(hook), err))
+		}
+	}
+	return errs
+}
+
+func (e Event) status() string {
+	if e.Err != nil {
+		return "failed"
+	}
+	return "succeeded"
+}
+
+func (e Event) title() string {
+	return fmt.Sprintf("dataclean %s %s", e.Operation, e.status())
+}
+
+func (e Event) message() string {
+	msg := fmt.Sprintf("%s %s in %s", e.Operation, e.Target, e.Duration.Round(time.Second))
+	if e.Project != "" {
+		msg = fmt.Sprintf("[%s] %s", e.Project, msg)
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e Event) payload() webhookPayload {
+	p := webhookPayload{
+		Operation:       e.Operation,
+		Target:          e.Target,
+		Project:         e.Project,
+		Status:          e.status(),
+		DurationSeconds: e.Duration.Seconds(),
+	}
+	if e.Err != nil {
+		p.Error = e.Err.Error()
+	}
+	return p
+}
+
+// post sends a JSON body and treats non-2xx responses as errors
+func (n *Notifier) post(hook string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	return n.postBody(hook, "application/json", data)
+}
+
+// postBody sends a prepared body and treats non-2xx responses as errors.
+// Errors leave out the URL, whose path often holds the hook's token.
+func (n *Notifier) postBody(hook, contentType string, data []byte) error {
+	resp, err := n.http.Post(hook, contentType, bytes.NewReader(data))
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return urlErr.Err
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// RedactURL shortens a webhook URL to its scheme and host for messages and
+// logs: Slack and most webhook services put the secret in the path or query
+func RedactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "(webhook URL redacted)"
+	}
+	redacted := u.Scheme + "://" + u.Host
+	if (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" {
+		redacted += "/…"
+	}
+	return redacted
+}
+
+// desktop shows a native notification on macOS and Linux
+func desktop(title, message string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %q with title %q", message, title)
+		cmd = exec.Command("osascript", "-e", script)
+	case "linux":
+		cmd = exec.Command("notify-send", title, message)
+	default:
+		return fmt.Errorf("desktop notifications not supported on %s", runtime.GOOS)
+	}
+	return cmd.Run()
+}
@@ -0,0 +1,76 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+// SnapshotEvent describes a snapshot being created, restored, deleted, or
+// pruned. It is the JSON body sent to snapshot webhooks without a template,
+// and the data templates are executed with.
+type SnapshotEvent struct {
+	Action   string          `json:"action"` // one of models.SnapshotActions
+	Project  string          `json:"project,omitempty"`
+	User     string          `json:"user,omitempty"`
+	Host     string          `json:"host,omitempty"`
+	Time     time.Time       `json:"time"`
+	Snapshot models.Snapshot `json:"snapshot"`
+}
+
+// templateFuncs are available in webhook templates
+var templateFuncs = template.FuncMap{
+	// json quotes a value for use inside a JSON body
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"join": strings.Join,
+	"size": models.FormatSize,
+}
+
+// ParseTemplate parses a snapshot webhook body template
+func ParseTemplate(text string) (*template.Template, error) {
+	return template.New("webhook").Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+}
+
+// SnapshotChanged sends the event to every snapshot webhook that subscribes
+// to its action. Unlike Notify, MinDuration doesn't apply. Delivery errors are
+// returned but never fatal.
+func (n *Notifier) SnapshotChanged(e SnapshotEvent) []error {
+	var errs []error
+	for _, hook := range n.cfg.SnapshotWebhooks {
+		if !hook.Wants(e.Action) {
+			continue
+		}
+		if err := n.sendSnapshotEvent(hook, e); err != nil {
+			errs = append(errs, fmt.Errorf("snapshot webhook %s: %w", RedactURL(hook.URL), err))
+		}
+	}
+	return errs
+}
+
+func (n *Notifier) sendSnapshotEvent(hook models.SnapshotWebhook, e SnapshotEvent) error {
+	if hook.Template == "" {
+		return n.post(hook.URL, e)
+	}
+
+	tmpl, err := ParseTemplate(hook.Template)
+	if err != nil {
+		return err
+	}
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, e); err != nil {
+		return err
+	}
+	contentType := hook.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	return n.postBody(hook.URL, contentType, body.Bytes())
+}
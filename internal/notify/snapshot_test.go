@@ -0,0 +1,94 @@
+package notify
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+func testSnapshot() models.Snapshot {
+	return models.Snapshot{
+		Name:      "baseline",
+		Timestamp: time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC),
+		SizeBytes: 3 << 20,
+		Tags:      []string{"shared"},
+		Volumes: []models.Volume{{
+			Name:          "app_pgdata",
+			DatastoreType: models.DatastorePostgres,
+			Credentials:   (&models.Credentials{User: "app", Password: models.NewSecret("hunter2")}).Stored(),
+		}},
+	}
+}
+
+func TestSnapshotChanged_DefaultPayload(t *testing.T) {
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	n := New(models.NotifyConfig{SnapshotWebhooks: []models.SnapshotWebhook{{URL: server.URL}}})
+	errs := n.SnapshotChanged(SnapshotEvent{Action: models.SnapshotRestored, User: "sam", Snapshot: testSnapshot()})
+	if len(errs) != 0 {
+		t.Fatalf("SnapshotChanged() errors: %v", errs)
+	}
+
+	var got SnapshotEvent
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatalf("payload is not JSON: %v\n%s", err, body)
+	}
+	if got.Action != "restore" || got.User != "sam" || got.Snapshot.Name != "baseline" {
+		t.Errorf("payload = %+v", got)
+	}
+	if strings.Contains(string(body), "hunter2") {
+		t.Errorf("stored password sent to webhook:\n%s", body)
+	}
+}
+
+func TestSnapshotChanged_Template(t *testing.T) {
+	var body, contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body, contentType = string(data), r.Header.Get("Content-Type")
+	}))
+	defer server.Close()
+
+	n := New(models.NotifyConfig{SnapshotWebhooks: []models.SnapshotWebhook{{
+		URL:         server.URL,
+		Template:    `{{.User}} {{.Action}}d {{.Snapshot.Name}} ({{size .Snapshot.SizeBytes}}, {{join .Snapshot.Tags ","}}) {{json .Project}}`,
+		ContentType: "text/plain",
+	}}})
+	n.SnapshotChanged(SnapshotEvent{Action: models.SnapshotCreated, User: "sam", Project: `my "app"`, Snapshot: testSnapshot()})
+
+	if want := `sam created baseline (3.0 MB, shared) "my \"app\""`; body != want {
+		t.Errorf("body = %q, want %q", body, want)
+	}
+	if contentType != "text/plain" {
+		t.Errorf("Content-Type = %q, want text/plain", contentType)
+	}
+}
+
+func TestSnapshotChanged_FiltersEvents(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+	defer server.Close()
+
+	n := New(models.NotifyConfig{
+		SnapshotWebhooks: []models.SnapshotWebhook{{URL: server.URL, Events: []string{models.SnapshotRestored}}},
+		MinDuration:      time.Hour, // doesn't apply to snapshot webhooks
+	})
+	n.SnapshotChanged(SnapshotEvent{Action: models.SnapshotCreated, Snapshot: testSnapshot()})
+	n.SnapshotChanged(SnapshotEvent{Action: models.SnapshotRestored, Snapshot: testSnapshot()})
+
+	if calls != 1 {
+		t.Errorf("webhook called %d times, want 1 (restore only)", calls)
+	}
+}
@@ -0,0 +1,120 @@
+// Package pgzip compresses a stream on all cores. Input is cut into blocks
+// that are compressed concurrently and written in order as separate gzip
+// members; any gzip reader (gunzip, GNU tar, compress/gzip) reads the result
+// as one stream.
+package pgzip
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"runtime"
+	"sync"
+)
+
+// BlockSize is the amount of input compressed per gzip member. Larger blocks
+// compress slightly better; smaller ones spread more evenly across cores.
+const BlockSize = 1 << 20
+
+// Writer compresses everything written to it with one goroutine per core
+type Writer struct {
+	w       io.Writer
+	buf     []byte
+	queue   chan chan []byte // Blocks in input order, each delivered once compressed
+	done    chan struct{}
+	started bool
+
+	mu  sync.Mutex
+	err error
+}
+
+// NewWriter returns a Writer that compresses into w using up to workers
+// goroutines (0 means one per CPU)
+func NewWriter(w io.Writer, workers int) *Writer {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	z := &Writer{
+		w:     w,
+		buf:   make([]byte, 0, BlockSize),
+		queue: make(chan chan []byte, workers),
+		done:  make(chan struct{}),
+	}
+	go z.drain()
+	return z
+}
+
+// Write buffers p and hands off every full block for compression
+func (z *Writer) Write(p []byte) (int, error) {
+	if err := z.failed(); err != nil {
+		return 0, err
+	}
+
+	n := len(p)
+	for len(p) > 0 {
+		take := min(BlockSize-len(z.buf), len(p))
+		z.buf = append(z.buf, p[:take]...)
+		p = p[take:]
+		if len(z.buf) == BlockSize {
+			z.flushBlock()
+		}
+	}
+	return n, nil
+}
+
+// Close compresses what's left, waits for every block to be written, and
+// returns the first error. It doesn't close the underlying writer.
+func (z *Writer) Close() error {
+	// An empty input still has to be a valid gzip stream
+	if len(z.buf) > 0 || !z.started {
+		z.flushBlock()
+	}
+	close(z.queue)
+	<-z.done
+	return z.failed()
+}
+
+// flushBlock starts compressing the buffered block. It blocks while as many
+// blocks as there are workers are still in flight, which bounds memory use.
+func (z *Writer) flushBlock() {
+	block := z.buf
+	z.buf = make([]byte, 0, BlockSize)
+	z.started = true
+
+	result := make(chan []byte, 1)
+	z.queue <- result
+	go func() {
+		result <- compress(block)
+	}()
+}
+
+// drain writes compressed blocks in order as they become ready
+func (z *Writer) drain() {
+	defer close(z.done)
+	for result := range z.queue {
+		data := <-result
+		if z.failed() != nil {
+			continue // keep draining so senders don't block
+		}
+		if _, err := z.w.Write(data); err != nil {
+			z.mu.Lock()
+			z.err = err
+			z.mu.Unlock()
+		}
+	}
+}
+
+func (z *Writer) failed() error {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	return z.err
+}
+
+// compress turns a block into a complete gzip member
+func compress(block []byte) []byte {
+	var out bytes.Buffer
+	gz := gzip.NewWriter(&out)
+	gz.Write(block) // writes to a bytes.Buffer don't fail
+	gz.Close()
+	return out.Bytes()
+}
@@ -0,0 +1,69 @@
+package pgzip
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+func TestWriter_RoundTrip(t *testing.T) {
+	// Several blocks plus a partial one, so ordering across workers matters
+	input := make([]byte, 3*BlockSize+12345)
+	rand.New(rand.NewSource(1)).Read(input[:len(input)/2]) // half incompressible, half zeros
+
+	var out bytes.Buffer
+	z := NewWriter(&out, 4)
+	for chunk := input; len(chunk) > 0; {
+		n := min(len(chunk), 70000)
+		if _, err := z.Write(chunk[:n]); err != nil {
+			t.Fatalf("Write() failed: %v", err)
+		}
+		chunk = chunk[n:]
+	}
+	if err := z.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+
+	gz, err := gzip.NewReader(&out)
+	if err != nil {
+		t.Fatalf("gzip.NewReader() failed: %v", err)
+	}
+	got, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatalf("decompress failed: %v", err)
+	}
+	if !bytes.Equal(got, input) {
+		t.Errorf("round trip mismatch: got %d bytes, want %d", len(got), len(input))
+	}
+}
+
+func TestWriter_Empty(t *testing.T) {
+	var out bytes.Buffer
+	z := NewWriter(&out, 0)
+	if err := z.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+
+	gz, err := gzip.NewReader(&out)
+	if err != nil {
+		t.Fatalf("empty input should still be valid gzip: %v", err)
+	}
+	if got, _ := io.ReadAll(gz); len(got) != 0 {
+		t.Errorf("got %d bytes, want 0", len(got))
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestWriter_PropagatesWriteError(t *testing.T) {
+	z := NewWriter(failingWriter{}, 2)
+	z.Write(make([]byte, 2*BlockSize))
+	if err := z.Close(); err == nil || err.Error() != "disk full" {
+		t.Errorf("Close() = %v, want disk full", err)
+	}
+}
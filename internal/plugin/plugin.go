@@ -0,0 +1,164 @@
+// Package plugin runs exporter plugins: executables that take over exporting
+// and importing volumes, so tooling dataclean doesn't know (a company's own
+// backup system, say) can be used without forking it.
+//
+// A plugin is an executable named dataclean-exporter-<name> in the plugins
+// directory. dataclean runs it once per call, writes one JSON request to its
+// stdin and reads one JSON response from its stdout; its stderr is passed
+// through. Every request carries the protocol version:
+//
+//	{"protocol": 1, "method": "describe"}
+//	→ {"name": "vault", "description": "Company backup vault"}
+//
+//	{"protocol": 1, "method": "export", "volume": {...}, "archive": "/abs/path/pgdata.tar.gz"}
+//	{"protocol": 1, "method": "import", "volume": {...}, "archive": "/abs/path/pgdata.tar.gz"}
+//	→ {}  or  {"error": "what went wrong"}
+//
+// An export writes the volume's contents to archive as a gzipped tar with the
+// files at its root, the format every other command (verify, browse, diff,
+// extract) reads. An import fills the volume from such an archive; it has
+// been cleared and its containers stopped beforehand. Volumes are described
+// as in snapshot metadata, without passwords. A non-zero exit fails the call
+// like an error response does.
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+const (
+	// Protocol is the version of the request and response format
+	Protocol = 1
+
+	// Prefix starts the file name of every exporter plugin
+	Prefix = "dataclean-exporter-"
+)
+
+// Request is what dataclean writes to a plugin's stdin
+type Request struct {
+	Protocol int            `json:"protocol"`
+	Method   string         `json:"method"` // describe, export, or import
+	Volume   *models.Volume `json:"volume,omitempty"`
+	Archive  string         `json:"archive,omitempty"`
+}
+
+// Response is what a plugin writes to its stdout
+type Response struct {
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// Plugin is an exporter plugin found in the plugins directory
+type Plugin struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+// Discover lists the exporter plugins in dir, by name. A missing directory
+// has none.
+func Discover(dir string) ([]Plugin, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var plugins []Plugin
+	for _, e := range entries {
+		name, ok := strings.CutPrefix(e.Name(), Prefix)
+		if !ok || name == "" || e.IsDir() {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || info.Mode()&0111 == 0 {
+			continue // not executable
+		}
+		plugins = append(plugins, Plugin{Name: name, Path: filepath.Join(dir, e.Name())})
+	}
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].Name < plugins[j].Name })
+	return plugins, nil
+}
+
+// Find returns the named exporter plugin in dir
+func Find(dir, name string) (Plugin, error) {
+	plugins, err := Discover(dir)
+	if err != nil {
+		return Plugin{}, err
+	}
+	for _, p := range plugins {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+	return Plugin{}, fmt.Errorf("exporter plugin %s not found (expected an executable %s%s in %s)", name, Prefix, name, dir)
+}
+
+// Describe asks the plugin for its name and description
+func (p Plugin) Describe() (*Response, error) {
+	return p.call(Request{Method: "describe"})
+}
+
+// Export writes a volume's contents to archive
+func (p Plugin) Export(vol models.Volume, archive string) error {
+	_, err := p.call(Request{Method: "export", Volume: &vol, Archive: archive})
+	return err
+}
+
+// Import fills a cleared volume from archive
+func (p Plugin) Import(archive string, vol models.Volume) error {
+	_, err := p.call(Request{Method: "import", Volume: &vol, Archive: archive})
+	return err
+}
+
+func (p Plugin) call(req Request) (*Response, error) {
+	req.Protocol = Protocol
+	if req.Archive != "" {
+		abs, err := filepath.Abs(req.Archive)
+		if err != nil {
+			return nil, err
+		}
+		req.Archive = abs
+	}
+	if req.Volume != nil {
+		vol := *req.Volume
+		vol.Credentials = vol.Credentials.Redacted()
+		req.Volume = &vol
+	}
+	input, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var stdout bytes.Buffer
+	cmd := exec.Command(p.Path)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	runErr := cmd.Run()
+
+	var resp Response
+	if err := json.Unmarshal(bytes.TrimSpace(stdout.Bytes()), &resp); err != nil {
+		if runErr != nil {
+			return nil, fmt.Errorf("plugin %s: %s failed: %w", p.Name, req.Method, runErr)
+		}
+		return nil, fmt.Errorf("plugin %s: invalid response to %s: %w", p.Name, req.Method, err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("plugin %s: %s", p.Name, resp.Error)
+	}
+	if runErr != nil {
+		return nil, fmt.Errorf("plugin %s: %s failed: %w", p.Name, req.Method, runErr)
+	}
+	return &resp, nil
+}
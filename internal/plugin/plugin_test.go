@@ -0,0 +1,90 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+// writePlugin installs a shell script as an exporter plugin
+func writePlugin(t *testing.T, dir, name, script string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, Prefix+name), []byte("#!/bin/sh\n"+script), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	dir := t.TempDir()
+	writePlugin(t, dir, "vault", "")
+	writePlugin(t, dir, "archive", "")
+	os.WriteFile(filepath.Join(dir, Prefix+"readme"), []byte("not executable"), 0644)
+	os.WriteFile(filepath.Join(dir, "other-tool"), []byte("#!/bin/sh\n"), 0755)
+
+	plugins, err := Discover(dir)
+	if err != nil {
+		t.Fatalf("Discover() failed: %v", err)
+	}
+	if len(plugins) != 2 || plugins[0].Name != "archive" || plugins[1].Name != "vault" {
+		t.Errorf("Discover() = %+v, want archive and vault", plugins)
+	}
+	if _, err := Find(dir, "readme"); err == nil {
+		t.Error("expected error finding a plugin that isn't executable")
+	}
+	if plugins, err := Discover(filepath.Join(dir, "missing")); err != nil || len(plugins) != 0 {
+		t.Errorf("Discover(missing dir) = %v, %v, want none", plugins, err)
+	}
+}
+
+func TestCall(t *testing.T) {
+	dir := t.TempDir()
+	requests := filepath.Join(dir, "requests")
+	// Records each request and answers by method
+	writePlugin(t, dir, "vault", `req="$(cat)"
+echo "$req" >> `+requests+`
+case "$req" in
+  *'"describe"'*) echo '{"name": "vault", "description": "Company backup vault"}' ;;
+  *'"export"'*) echo '{}' ;;
+  *) echo '{"error": "vault is read-only"}' ;;
+esac
+`)
+	writePlugin(t, dir, "broken", "echo oops >&2; exit 3\n")
+
+	p, err := Find(dir, "vault")
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+	resp, err := p.Describe()
+	if err != nil || resp.Description != "Company backup vault" {
+		t.Errorf("Describe() = %+v, %v", resp, err)
+	}
+
+	vol := models.Volume{Name: "shop_pgdata", DatastoreType: models.DatastorePostgres,
+		Credentials: &models.Credentials{User: "app", Password: models.NewSecret("hunter2")}}
+	if err := p.Export(vol, "pgdata.tar.gz"); err != nil {
+		t.Errorf("Export() failed: %v", err)
+	}
+	if err := p.Import("pgdata.tar.gz", vol); err == nil || !strings.Contains(err.Error(), "vault is read-only") {
+		t.Errorf("Import() error = %v, want the plugin's error", err)
+	}
+
+	data, _ := os.ReadFile(requests)
+	if strings.Contains(string(data), "hunter2") {
+		t.Error("password was sent to the plugin")
+	}
+	cwd, _ := os.Getwd()
+	if !strings.Contains(string(data), `"archive":"`+filepath.Join(cwd, "pgdata.tar.gz")+`"`) {
+		t.Errorf("archive path not made absolute: %s", data)
+	}
+	if !strings.Contains(string(data), `"protocol":1`) {
+		t.Errorf("requests carry no protocol version: %s", data)
+	}
+
+	broken, _ := Find(dir, "broken")
+	if err := broken.Export(vol, "pgdata.tar.gz"); err == nil {
+		t.Error("expected error from a plugin that exits non-zero")
+	}
+}
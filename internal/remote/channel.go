@@ -0,0 +1,215 @@
+package remote
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// channelDir holds one file per channel listing the snapshot keys published
+// to it, oldest first
+const channelDir = "channels"
+
+// channelName keeps channel names usable as file names
+var channelName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+func (r *Remote) channelPath(channel string) (string, error) {
+	if !channelName.MatchString(channel) {
+		return "", fmt.Errorf("invalid channel name %q (letters, digits, '.', '_' and '-')", channel)
+	}
+	return filepath.Join(r.root, channelDir, channel), nil
+}
+
+// Publish makes an uploaded snapshot the latest of a channel
+func (r *Remote) Publish(channel, key string) error {
+	if !r.Has(key) {
+		return fmt.Errorf("snapshot %s is not on the remote", key)
+	}
+	path, err := r.channelPath(channel)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	history, err := r.History(channel)
+	if err != nil {
+		return err
+	}
+	return writeHistory(path, append(history, key))
+}
+
+func writeHistory(path string, history []string) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strings.Join(history, "\n")+"\n"), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// History returns the keys published to a channel, oldest first
+func (r *Remote) History(channel string) ([]string, error) {
+	path, err := r.channelPath(channel)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(string(data)), nil
+}
+
+// Latest returns the key most recently published to a channel
+func (r *Remote) Latest(channel string) (string, error) {
+	history, err := r.History(channel)
+	if err != nil {
+		return "", err
+	}
+	if len(history) == 0 {
+		return "", fmt.Errorf("nothing has been published to channel %s", channel)
+	}
+	return history[len(history)-1], nil
+}
+
+// Channels lists the channels anything was published to
+func (r *Remote) Channels() ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(r.root, channelDir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var channels []string
+	for _, e := range entries {
+		if e.Type().IsRegular() && channelName.MatchString(e.Name()) && !strings.HasSuffix(e.Name(), ".tmp") {
+			channels = append(channels, e.Name())
+		}
+	}
+	return channels, nil
+}
+
+// Expired returns the keys of a channel beyond its newest keep, or taken
+// longer than maxAge before now, judged by the time in each key. Zero keep or
+// maxAge means no limit. Keys without a time are never expired.
+func (r *Remote) Expired(channel string, keep int, maxAge time.Duration, now time.Time) ([]string, error) {
+	history, err := r.History(channel)
+	if err != nil {
+		return nil, err
+	}
+	type entry struct {
+		key string
+		at  time.Time
+	}
+	// Newest first; of two taken in the same second, the later published
+	var dated []entry
+	for i := len(history) - 1; i >= 0; i-- {
+		if at, ok := keyTime(history[i]); ok {
+			dated = append(dated, entry{history[i], at})
+		}
+	}
+	sort.SliceStable(dated, func(i, j int) bool { return dated[i].at.After(dated[j].at) })
+
+	var expired []string
+	for i, e := range dated {
+		if (keep > 0 && i >= keep) || (maxAge > 0 && now.Sub(e.at) > maxAge) {
+			expired = append(expired, e.key)
+		}
+	}
+	return expired, nil
+}
+
+// Retire takes keys off a channel and deletes their bundles, except those
+// another channel still lists
+func (r *Remote) Retire(channel string, keys []string) error {
+	path, err := r.channelPath(channel)
+	if err != nil {
+		return err
+	}
+	history, err := r.History(channel)
+	if err != nil {
+		return err
+	}
+	history = slices.DeleteFunc(history, func(key string) bool { return slices.Contains(keys, key) })
+	if err := writeHistory(path, history); err != nil {
+		return err
+	}
+
+	listed, err := r.listed(channel)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if listed[key] {
+			continue
+		}
+		if err := r.Remove(key); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// listed returns the keys any channel but except lists
+func (r *Remote) listed(except string) (map[string]bool, error) {
+	channels, err := r.Channels()
+	if err != nil {
+		return nil, err
+	}
+	listed := make(map[string]bool)
+	for _, c := range channels {
+		if c == except {
+			continue
+		}
+		history, err := r.History(c)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range history {
+			listed[key] = true
+		}
+	}
+	return listed, nil
+}
+
+// Prunable returns the bundles past the newest keep, or taken longer than
+// maxAge before now, judged by the time in each key. Bundles a channel lists
+// are left to that channel's retention, and neither they nor those pinned
+// count towards keep. Zero keep or maxAge means no limit. Keys without a
+// time are never pruned.
+func (r *Remote) Prunable(keep int, maxAge time.Duration, pinned func(Bundle) bool, now time.Time) ([]Bundle, error) {
+	bundles, err := r.Bundles()
+	if err != nil {
+		return nil, err
+	}
+	listed, err := r.listed("")
+	if err != nil {
+		return nil, err
+	}
+
+	// Newest first
+	var candidates []Bundle
+	for i := len(bundles) - 1; i >= 0; i-- {
+		b := bundles[i]
+		if !b.Created.IsZero() && !listed[b.Key] && !pinned(b) {
+			candidates = append(candidates, b)
+		}
+	}
+	var prunable []Bundle
+	for i, b := range candidates {
+		if (keep > 0 && i >= keep) || (maxAge > 0 && now.Sub(b.Created) > maxAge) {
+			prunable = append(prunable, b)
+		}
+	}
+	return prunable, nil
+}
@@ -0,0 +1,293 @@
+// Package remote keeps snapshot bundles in a directory shared by a team, so
+// snapshots can be handed to teammates and CI.
+package remote
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+const (
+	// bundleDir holds one <key>.tar bundle per uploaded snapshot
+	bundleDir = "snapshots"
+
+	// minShareKey is the shortest remote.share_key tokens are signed with
+	minShareKey = 16
+
+	tokenPrefix = "dc1."
+
+	// keyTimeFormat is how keys record when a snapshot was taken
+	keyTimeFormat = "20060102T150405Z"
+
+	// KeyMetadata records, on pulled snapshots, the key they were pulled from
+	KeyMetadata = "remote_key"
+)
+
+// Remote is a directory of snapshot bundles: a network share, a mounted
+// bucket, or a synced folder
+type Remote struct {
+	root string
+}
+
+// Open returns the remote at a path or file:// URL
+func Open(location string) (*Remote, error) {
+	if location == "" {
+		return nil, fmt.Errorf("no remote configured (set remote.url in the config)")
+	}
+	root := location
+	if strings.Contains(location, "://") {
+		u, err := url.Parse(location)
+		if err != nil {
+			return nil, fmt.Errorf("invalid remote URL %s: %w", location, err)
+		}
+		if u.Scheme != "file" {
+			return nil, fmt.Errorf("unsupported remote %s: only directories (a path or file:// URL) are supported", location)
+		}
+		root = u.Path
+	}
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("remote %s is not a reachable directory", location)
+	}
+	return &Remote{root: root}, nil
+}
+
+// Key identifies a snapshot on the remote. Teammates' snapshots often share
+// a name, so the creation time is part of it. Pulled snapshots keep the key
+// they came from, even when saved under another name.
+func Key(s *models.Snapshot) string {
+	if key := s.Metadata[KeyMetadata]; key != "" {
+		return key
+	}
+	return s.Name + "@" + s.Timestamp.UTC().Format(keyTimeFormat)
+}
+
+// keyTime returns the creation time recorded in a key
+func keyTime(key string) (time.Time, bool) {
+	i := strings.LastIndex(key, "@")
+	if i < 0 {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(keyTimeFormat, key[i+1:])
+	return t, err == nil
+}
+
+// bundlePath returns where a key's bundle is stored
+func (r *Remote) bundlePath(key string) (string, error) {
+	if key == "" || key != filepath.Base(key) || strings.HasPrefix(key, ".") {
+		return "", fmt.Errorf("invalid snapshot key %q", key)
+	}
+	return filepath.Join(r.root, bundleDir, key+".tar"), nil
+}
+
+// Has reports whether a snapshot is already on the remote
+func (r *Remote) Has(key string) bool {
+	path, err := r.bundlePath(key)
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
+
+// Upload stores the bundle written by write under key. An interrupted upload
+// never replaces a complete one.
+func (r *Remote) Upload(key string, write func(io.Writer) error) error {
+	path, err := r.bundlePath(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Download opens a stored bundle
+func (r *Remote) Download(key string) (io.ReadCloser, error) {
+	path, err := r.bundlePath(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("snapshot %s is not on the remote", key)
+	}
+	return f, err
+}
+
+// Remove deletes a stored bundle. Channels listing it aren't changed.
+func (r *Remote) Remove(key string) error {
+	path, err := r.bundlePath(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Share returns a token for fetching key until ttl has passed. Tokens are
+// signed with shareKey, which is kept off the remote, so only those holding
+// it can issue or check them; anyone who can read the remote can read every
+// bundle anyway.
+func (r *Remote) Share(key string, ttl time.Duration, shareKey string) (string, time.Time, error) {
+	if !r.Has(key) {
+		return "", time.Time{}, fmt.Errorf("snapshot %s is not on the remote", key)
+	}
+	secret, err := signingSecret(shareKey)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	expires := time.Now().Add(ttl).Truncate(time.Second)
+	claim := key + "\n" + strconv.FormatInt(expires.Unix(), 10)
+	token := tokenPrefix + base64.RawURLEncoding.EncodeToString([]byte(claim)) + "." +
+		base64.RawURLEncoding.EncodeToString(sign(secret, claim))
+	return token, expires, nil
+}
+
+// Redeem checks a share token against shareKey and returns the key it grants
+func (r *Remote) Redeem(token, shareKey string) (string, error) {
+	body, sig, ok := strings.Cut(strings.TrimPrefix(token, tokenPrefix), ".")
+	if !ok || !strings.HasPrefix(token, tokenPrefix) {
+		return "", fmt.Errorf("not a share token")
+	}
+	claim, err := base64.RawURLEncoding.DecodeString(body)
+	if err != nil {
+		return "", fmt.Errorf("not a share token")
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil {
+		return "", fmt.Errorf("not a share token")
+	}
+
+	secret, err := signingSecret(shareKey)
+	if err != nil {
+		return "", err
+	}
+	if !hmac.Equal(mac, sign(secret, string(claim))) {
+		return "", fmt.Errorf("share token was not signed with this remote.share_key")
+	}
+
+	key, unix, _ := strings.Cut(string(claim), "\n")
+	seconds, err := strconv.ParseInt(unix, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("not a share token")
+	}
+	if expires := time.Unix(seconds, 0); time.Now().After(expires) {
+		return "", fmt.Errorf("share token expired at %s", expires.Format("2006-01-02 15:04"))
+	}
+	return key, nil
+}
+
+// Resolve returns the key a share token grants, or ref itself when it is
+// the key of a snapshot on the remote
+func (r *Remote) Resolve(ref, shareKey string) (string, error) {
+	if strings.HasPrefix(ref, tokenPrefix) {
+		return r.Redeem(ref, shareKey)
+	}
+	if !r.Has(ref) {
+		return "", fmt.Errorf("snapshot %s is not on the remote", ref)
+	}
+	return ref, nil
+}
+
+// signingSecret checks the configured remote.share_key. It is never stored
+// on the remote, where anyone who can read bundles could issue tokens too.
+func signingSecret(shareKey string) ([]byte, error) {
+	if shareKey == "" {
+		return nil, fmt.Errorf("share tokens need remote.share_key in the config (e.g. '!env DATACLEAN_SHARE_KEY')")
+	}
+	if len(shareKey) < minShareKey {
+		return nil, fmt.Errorf("remote.share_key must be at least %d characters", minShareKey)
+	}
+	return []byte(shareKey), nil
+}
+
+// CheckShareKey reports why shareKey can't sign tokens, if it can't, so
+// share can fail before uploading anything
+func CheckShareKey(shareKey string) error {
+	_, err := signingSecret(shareKey)
+	return err
+}
+
+func sign(secret []byte, claim string) []byte {
+	h := hmac.New(sha256.New, secret)
+	h.Write([]byte(claim))
+	return h.Sum(nil)
+}
+
+// Bundle describes a snapshot stored on the remote
+type Bundle struct {
+	Key string `json:"key"`
+
+	// Name and Created are read from the key; Created is zero for keys
+	// without a time
+	Name    string    `json:"name"`
+	Created time.Time `json:"created,omitzero"`
+
+	SizeBytes int64 `json:"size_bytes"`
+
+	// Uploaded is when the bundle was last written to the remote
+	Uploaded time.Time `json:"uploaded"`
+}
+
+// Bundles lists every snapshot stored on the remote, oldest first
+func (r *Remote) Bundles() ([]Bundle, error) {
+	entries, err := os.ReadDir(filepath.Join(r.root, bundleDir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var bundles []Bundle
+	for _, e := range entries {
+		key, ok := strings.CutSuffix(e.Name(), ".tar")
+		if !ok || !e.Type().IsRegular() || strings.HasPrefix(key, ".") {
+			continue // uploads in progress and strays
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue // removed while listing
+		}
+		b := Bundle{Key: key, Name: key, SizeBytes: info.Size(), Uploaded: info.ModTime()}
+		if i := strings.LastIndex(key, "@"); i >= 0 {
+			b.Name = key[:i]
+		}
+		b.Created, _ = keyTime(key)
+		bundles = append(bundles, b)
+	}
+	sort.SliceStable(bundles, func(i, j int) bool {
+		if !bundles[i].Created.Equal(bundles[j].Created) {
+			return bundles[i].Created.Before(bundles[j].Created)
+		}
+		return bundles[i].Key < bundles[j].Key
+	})
+	return bundles, nil
+}
@@ -0,0 +1,340 @@
+package remote
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+func TestUploadAndDownload(t *testing.T) {
+	r, err := Open("file://" + t.TempDir())
+	if err != nil {
+		t.Fatalf("Open() failed: %v", err)
+	}
+	key := "seeded@20240501T090000Z"
+
+	if r.Has(key) {
+		t.Fatal("Has() = true before upload")
+	}
+	// A failed upload leaves nothing behind
+	r.Upload(key, func(w io.Writer) error {
+		io.WriteString(w, "partial")
+		return io.ErrUnexpectedEOF
+	})
+	if r.Has(key) {
+		t.Fatal("Has() = true after a failed upload")
+	}
+
+	if err := r.Upload(key, func(w io.Writer) error {
+		_, err := io.WriteString(w, "bundle")
+		return err
+	}); err != nil {
+		t.Fatalf("Upload() failed: %v", err)
+	}
+	rc, err := r.Download(key)
+	if err != nil {
+		t.Fatalf("Download() failed: %v", err)
+	}
+	data, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(data) != "bundle" {
+		t.Errorf("Download() = %q, want bundle", data)
+	}
+
+	entries, _ := os.ReadDir(filepath.Join(r.root, bundleDir))
+	if len(entries) != 1 {
+		t.Errorf("remote holds %d files, want only the bundle", len(entries))
+	}
+	if _, err := r.bundlePath("../escape"); err == nil {
+		t.Error("expected error for a key outside the remote")
+	}
+}
+
+func TestShareAndRedeem(t *testing.T) {
+	root := t.TempDir()
+	r, _ := Open(root)
+	key := "seeded@20240501T090000Z"
+	shareKey := "correct horse battery staple"
+
+	if _, _, err := r.Share(key, time.Hour, shareKey); err == nil {
+		t.Error("expected error sharing a snapshot that isn't uploaded")
+	}
+	r.Upload(key, func(w io.Writer) error { return nil })
+
+	for _, bad := range []string{"", "short"} {
+		if _, _, err := r.Share(key, time.Hour, bad); err == nil {
+			t.Errorf("expected error sharing with share key %q", bad)
+		}
+	}
+
+	token, expires, err := r.Share(key, time.Hour, shareKey)
+	if err != nil {
+		t.Fatalf("Share() failed: %v", err)
+	}
+	if time.Until(expires) > time.Hour || time.Until(expires) < 59*time.Minute {
+		t.Errorf("expires = %s, want about an hour from now", expires)
+	}
+	got, err := r.Redeem(token, shareKey)
+	if err != nil || got != key {
+		t.Errorf("Redeem() = %q, %v, want %q", got, err, key)
+	}
+
+	for _, ref := range []string{token, key} {
+		if got, err := r.Resolve(ref, shareKey); err != nil || got != key {
+			t.Errorf("Resolve(%q) = %q, %v, want %q", ref, got, err, key)
+		}
+	}
+	if _, err := r.Resolve("other@20240501T090000Z", shareKey); err == nil {
+		t.Error("expected error resolving a key that isn't uploaded")
+	}
+
+	// Tampering with the claim breaks the signature
+	body, sig, _ := strings.Cut(strings.TrimPrefix(token, tokenPrefix), ".")
+	other, _, _ := strings.Cut(strings.TrimPrefix(mustShare(t, r, key, 2*time.Hour, shareKey), tokenPrefix), ".")
+	if _, err := r.Redeem(tokenPrefix+other+"."+sig, shareKey); err == nil {
+		t.Error("expected error for a token with a swapped claim")
+	}
+	if _, err := r.Redeem(tokenPrefix+body, shareKey); err == nil {
+		t.Error("expected error for a token without a signature")
+	}
+
+	expired := mustShare(t, r, key, -time.Minute, shareKey)
+	if _, err := r.Redeem(expired, shareKey); err == nil || !strings.Contains(err.Error(), "expired") {
+		t.Errorf("Redeem(expired) error = %v", err)
+	}
+
+	// Tokens only check out with the key they were signed with
+	if _, err := r.Redeem(token, "another team's share key"); err == nil {
+		t.Error("expected error redeeming with a different share key")
+	}
+	if _, err := r.Redeem(token, ""); err == nil {
+		t.Error("expected error redeeming without a share key")
+	}
+
+	// Nothing on the remote lets its readers sign tokens
+	entries, _ := os.ReadDir(root)
+	for _, e := range entries {
+		if e.Name() != bundleDir {
+			t.Errorf("remote holds %s besides the bundles", e.Name())
+		}
+	}
+}
+
+func mustShare(t *testing.T, r *Remote, key string, ttl time.Duration, shareKey string) string {
+	t.Helper()
+	token, _, err := r.Share(key, ttl, shareKey)
+	if err != nil {
+		t.Fatalf("Share() failed: %v", err)
+	}
+	return token
+}
+
+func TestOpen_RejectsOtherSchemes(t *testing.T) {
+	if _, err := Open("s3://bucket/snapshots"); err == nil {
+		t.Error("expected error for an s3 remote")
+	}
+	if _, err := Open(""); err == nil {
+		t.Error("expected error without a remote")
+	}
+}
+
+func TestChannels(t *testing.T) {
+	r, _ := Open(t.TempDir())
+
+	if _, err := r.Latest("nightly"); err == nil {
+		t.Error("expected error for an empty channel")
+	}
+	if err := r.Publish("nightly", "missing@20240501T090000Z"); err == nil {
+		t.Error("expected error publishing a snapshot that isn't uploaded")
+	}
+
+	for _, key := range []string{"mon@20240506T020000Z", "tue@20240507T020000Z"} {
+		r.Upload(key, func(w io.Writer) error { return nil })
+		if err := r.Publish("nightly", key); err != nil {
+			t.Fatalf("Publish() failed: %v", err)
+		}
+	}
+	r.Publish("stable", "mon@20240506T020000Z")
+
+	if got, _ := r.Latest("nightly"); got != "tue@20240507T020000Z" {
+		t.Errorf("Latest(nightly) = %s, want tue", got)
+	}
+	if got, _ := r.Latest("stable"); got != "mon@20240506T020000Z" {
+		t.Errorf("Latest(stable) = %s, want mon", got)
+	}
+	if history, _ := r.History("nightly"); len(history) != 2 {
+		t.Errorf("History(nightly) = %v, want 2 entries", history)
+	}
+
+	if err := r.Publish("../escape", "mon@20240506T020000Z"); err == nil {
+		t.Error("expected error for an invalid channel name")
+	}
+}
+
+func TestExpiredAndRetire(t *testing.T) {
+	r, _ := Open(t.TempDir())
+	keys := []string{"mon@20240506T020000Z", "tue@20240507T020000Z", "wed@20240508T020000Z"}
+	for _, key := range keys {
+		r.Upload(key, func(w io.Writer) error { return nil })
+		r.Publish("mirror-shop", key)
+	}
+	r.Publish("stable", "mon@20240506T020000Z")
+	now := time.Date(2024, 5, 8, 12, 0, 0, 0, time.UTC)
+
+	if got, _ := r.Expired("mirror-shop", 2, 0, now); !slices.Equal(got, []string{"mon@20240506T020000Z"}) {
+		t.Errorf("Expired(keep 2) = %v, want mon", got)
+	}
+	if got, _ := r.Expired("mirror-shop", 0, 36*time.Hour, now); !slices.Equal(got, []string{"mon@20240506T020000Z"}) {
+		t.Errorf("Expired(36h) = %v, want mon", got)
+	}
+	if got, _ := r.Expired("mirror-shop", 1, 0, now); len(got) != 2 {
+		t.Errorf("Expired(keep 1) = %v, want mon and tue", got)
+	}
+	if got, _ := r.Expired("mirror-shop", 0, 0, now); len(got) != 0 {
+		t.Errorf("Expired(no limits) = %v, want none", got)
+	}
+
+	// mon stays stored because stable still lists it
+	if err := r.Retire("mirror-shop", []string{"mon@20240506T020000Z", "tue@20240507T020000Z"}); err != nil {
+		t.Fatalf("Retire() failed: %v", err)
+	}
+	if history, _ := r.History("mirror-shop"); !slices.Equal(history, []string{"wed@20240508T020000Z"}) {
+		t.Errorf("History(mirror-shop) = %v, want wed", history)
+	}
+	if !r.Has("mon@20240506T020000Z") || r.Has("tue@20240507T020000Z") {
+		t.Error("Retire() should keep bundles other channels list and remove the rest")
+	}
+	if channels, _ := r.Channels(); !slices.Equal(channels, []string{"mirror-shop", "stable"}) {
+		t.Errorf("Channels() = %v", channels)
+	}
+}
+
+func TestBundles(t *testing.T) {
+	r, _ := Open(t.TempDir())
+	if bundles, err := r.Bundles(); err != nil || len(bundles) != 0 {
+		t.Fatalf("Bundles() on an empty remote = %v, %v", bundles, err)
+	}
+
+	for _, key := range []string{"tue@20240507T020000Z", "mon@20240506T020000Z"} {
+		r.Upload(key, func(w io.Writer) error {
+			_, err := io.WriteString(w, key)
+			return err
+		})
+	}
+	// An interrupted upload isn't listed
+	os.WriteFile(filepath.Join(r.root, bundleDir, ".wed@20240508T020000Z.123.tmp"), nil, 0644)
+
+	bundles, err := r.Bundles()
+	if err != nil {
+		t.Fatalf("Bundles() failed: %v", err)
+	}
+	if len(bundles) != 2 {
+		t.Fatalf("Bundles() = %v, want mon and tue", bundles)
+	}
+	mon := bundles[0]
+	if mon.Key != "mon@20240506T020000Z" || mon.Name != "mon" || mon.SizeBytes != int64(len(mon.Key)) {
+		t.Errorf("Bundles()[0] = %+v, want mon first", mon)
+	}
+	if want := time.Date(2024, 5, 6, 2, 0, 0, 0, time.UTC); !mon.Created.Equal(want) {
+		t.Errorf("Created = %v, want %v", mon.Created, want)
+	}
+	if mon.Uploaded.IsZero() {
+		t.Error("Uploaded is zero")
+	}
+}
+
+func TestPrunable(t *testing.T) {
+	r, _ := Open(t.TempDir())
+	keys := []string{"mon@20240506T020000Z", "tue@20240507T020000Z", "wed@20240508T020000Z", "thu@20240509T020000Z", "undated"}
+	for _, key := range keys {
+		r.Upload(key, func(w io.Writer) error { return nil })
+	}
+	r.Publish("stable", "mon@20240506T020000Z")
+	now := time.Date(2024, 5, 9, 12, 0, 0, 0, time.UTC)
+	none := func(Bundle) bool { return false }
+	prunable := func(keep int, maxAge time.Duration, pinned func(Bundle) bool) []string {
+		t.Helper()
+		bundles, err := r.Prunable(keep, maxAge, pinned, now)
+		if err != nil {
+			t.Fatalf("Prunable() failed: %v", err)
+		}
+		var got []string
+		for _, b := range bundles {
+			got = append(got, b.Key)
+		}
+		return got
+	}
+
+	// mon is listed on stable, so only tue is past the newest two
+	if got := prunable(2, 0, none); !slices.Equal(got, []string{"tue@20240507T020000Z"}) {
+		t.Errorf("Prunable(keep 2) = %v, want tue", got)
+	}
+	if got := prunable(0, 36*time.Hour, none); !slices.Equal(got, []string{"tue@20240507T020000Z"}) {
+		t.Errorf("Prunable(36h) = %v, want tue", got)
+	}
+	// A pinned bundle doesn't take up one of the kept places
+	pinWed := func(b Bundle) bool { return b.Name == "wed" }
+	if got := prunable(1, 0, pinWed); !slices.Equal(got, []string{"tue@20240507T020000Z"}) {
+		t.Errorf("Prunable(keep 1, wed pinned) = %v, want tue", got)
+	}
+	if got := prunable(0, 0, none); len(got) != 0 {
+		t.Errorf("Prunable(no limits) = %v, want none", got)
+	}
+}
+
+func TestKey(t *testing.T) {
+	snap := &models.Snapshot{Name: "seeded", Timestamp: time.Date(2024, 5, 1, 11, 0, 0, 0, time.FixedZone("CEST", 2*60*60))}
+	if got := Key(snap); got != "seeded@20240501T090000Z" {
+		t.Errorf("Key() = %s", got)
+	}
+
+	// Pulled under another name, it still maps to the bundle it came from
+	snap.Name = "from-alex"
+	snap.Metadata = map[string]string{KeyMetadata: "seeded@20240501T090000Z"}
+	if got := Key(snap); got != "seeded@20240501T090000Z" {
+		t.Errorf("Key() of pulled snapshot = %s", got)
+	}
+}
+
+func TestTemplates(t *testing.T) {
+	r, _ := Open(t.TempDir())
+
+	if templates, err := r.Templates(); err != nil || len(templates) != 0 {
+		t.Errorf("Templates() = %v, %v on an empty remote", templates, err)
+	}
+	if err := r.PublishTemplate(Template{Name: "acme/orders", Key: "missing@20240501T090000Z"}); err == nil {
+		t.Error("expected error publishing a snapshot that isn't uploaded")
+	}
+
+	key := "orders-seed@20240501T090000Z"
+	r.Upload(key, func(w io.Writer) error { return nil })
+	for _, name := range []string{"acme/orders", "billing"} {
+		if err := r.PublishTemplate(Template{Name: name, Key: key, Description: "seed data"}); err != nil {
+			t.Fatalf("PublishTemplate(%s) failed: %v", name, err)
+		}
+	}
+
+	got, err := r.Template("acme/orders")
+	if err != nil {
+		t.Fatalf("Template() failed: %v", err)
+	}
+	if got.Key != key || got.Description != "seed data" || got.Name != "acme/orders" {
+		t.Errorf("Template() = %+v", got)
+	}
+	templates, _ := r.Templates()
+	if len(templates) != 2 || templates[0].Name != "acme/orders" || templates[1].Name != "billing" {
+		t.Errorf("Templates() = %+v, want acme/orders and billing", templates)
+	}
+
+	for _, name := range []string{"../escape", "acme/../escape", "a/b/c"} {
+		if _, err := r.Template(name); err == nil || !strings.Contains(err.Error(), "invalid") {
+			t.Errorf("Template(%q) = %v, want invalid name error", name, err)
+		}
+	}
+}
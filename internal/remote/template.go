@@ -0,0 +1,105 @@
+package remote
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// templateDir holds one <org>/<name>.yaml per template
+const templateDir = "templates"
+
+// templateName is an optional org and a name, each usable as a file name
+var templateName = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*/)?[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Template is a snapshot published for starting new projects from: schema
+// and seed data that 'template apply' restores into a fresh compose project
+type Template struct {
+	Name        string    `yaml:"-"`
+	Key         string    `yaml:"key"`
+	Description string    `yaml:"description,omitempty"`
+	Published   time.Time `yaml:"published"`
+}
+
+func (r *Remote) templatePath(name string) (string, error) {
+	if !templateName.MatchString(name) {
+		return "", fmt.Errorf("invalid template name %q (name or org/name; letters, digits, '.', '_' and '-')", name)
+	}
+	return filepath.Join(r.root, templateDir, filepath.FromSlash(name)+".yaml"), nil
+}
+
+// PublishTemplate makes an uploaded snapshot the template of that name,
+// replacing any earlier version
+func (r *Remote) PublishTemplate(t Template) error {
+	if !r.Has(t.Key) {
+		return fmt.Errorf("snapshot %s is not on the remote", t.Key)
+	}
+	path, err := r.templatePath(t.Name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(&t)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Template returns a published template
+func (r *Remote) Template(name string) (*Template, error) {
+	path, err := r.templatePath(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no template %s on the remote", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var t Template
+	if err := yaml.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("invalid template %s: %w", name, err)
+	}
+	t.Name = name
+	return &t, nil
+}
+
+// Templates lists every published template by name
+func (r *Remote) Templates() ([]Template, error) {
+	root := filepath.Join(r.root, templateDir)
+	var templates []Template
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if os.IsNotExist(err) {
+			return fs.SkipAll
+		}
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".yaml") {
+			return err
+		}
+		rel, _ := filepath.Rel(root, path)
+		t, err := r.Template(filepath.ToSlash(strings.TrimSuffix(rel, ".yaml")))
+		if err != nil {
+			return nil // strays and half-written files
+		}
+		templates = append(templates, *t)
+		return nil
+	})
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+	return templates, err
+}
@@ -0,0 +1,172 @@
+// Package rpc is a small JSON-RPC 2.0 server. Messages are newline-delimited
+// JSON objects, over a socket or any reader/writer pair such as stdio.
+package rpc
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+)
+
+// Standard JSON-RPC error codes, plus CodeServerError for failed operations
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeServerError    = -32000
+)
+
+// maxMessage bounds a single request line
+const maxMessage = 1 << 20
+
+// Handler runs one method. Returning an *Error picks the error code; any
+// other error is reported as CodeServerError.
+type Handler func(params json.RawMessage) (any, error)
+
+// Error is a JSON-RPC error object
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// request is an incoming call; without an ID it is a notification and gets no response
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Server dispatches calls to registered handlers, one at a time across all
+// connections: operations on the same volumes must never overlap.
+type Server struct {
+	mu       sync.Mutex
+	handlers map[string]Handler
+
+	// OnCall, when set, is told about every finished call
+	OnCall func(method string, err error)
+}
+
+// NewServer returns a server with no methods
+func NewServer() *Server {
+	return &Server{handlers: make(map[string]Handler)}
+}
+
+// Handle registers a method
+func (s *Server) Handle(method string, h Handler) {
+	s.handlers[method] = h
+}
+
+// DecodeParams unmarshals params into v, reporting problems as CodeInvalidParams.
+// Missing params leave v untouched.
+func DecodeParams(params json.RawMessage, v any) error {
+	if len(params) == 0 || string(params) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return &Error{Code: CodeInvalidParams, Message: fmt.Sprintf("invalid params: %v", err)}
+	}
+	return nil
+}
+
+// Serve accepts connections until the listener is closed
+func (s *Server) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		go func() {
+			defer conn.Close()
+			s.ServeConn(conn, conn)
+		}()
+	}
+}
+
+// ServeConn answers requests read from r on w until r is exhausted
+func (s *Server) ServeConn(r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxMessage)
+	enc := json.NewEncoder(w)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		if resp := s.handle(line); resp != nil {
+			if err := enc.Encode(resp); err != nil {
+				return err
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+// handle runs one request and returns its response, or nil for notifications
+func (s *Server) handle(line []byte) *response {
+	var req request
+	if err := json.Unmarshal(line, &req); err != nil {
+		return &response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &Error{Code: CodeParseError, Message: "parse error"}}
+	}
+	resp := &response{JSONRPC: "2.0", ID: req.ID}
+	if len(req.ID) == 0 {
+		resp = nil
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		if resp != nil {
+			resp.Error = &Error{Code: CodeInvalidRequest, Message: "invalid request"}
+		}
+		return resp
+	}
+
+	h, ok := s.handlers[req.Method]
+	if !ok {
+		if resp != nil {
+			resp.Error = &Error{Code: CodeMethodNotFound, Message: fmt.Sprintf("method not found: %s", req.Method)}
+		}
+		return resp
+	}
+
+	s.mu.Lock()
+	result, err := h(req.Params)
+	s.mu.Unlock()
+	if s.OnCall != nil {
+		s.OnCall(req.Method, err)
+	}
+
+	if resp == nil {
+		return nil
+	}
+	if err != nil {
+		var rpcErr *Error
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &Error{Code: CodeServerError, Message: err.Error()}
+		}
+		resp.Error = rpcErr
+		return resp
+	}
+	if result == nil {
+		result = struct{}{} // a successful call always carries a result
+	}
+	resp.Result = result
+	return resp
+}
@@ -0,0 +1,96 @@
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func serve(t *testing.T, s *Server, input string) []map[string]any {
+	t.Helper()
+	var out strings.Builder
+	if err := s.ServeConn(strings.NewReader(input), &out); err != nil {
+		t.Fatalf("ServeConn() failed: %v", err)
+	}
+
+	var responses []map[string]any
+	dec := json.NewDecoder(strings.NewReader(out.String()))
+	for dec.More() {
+		var resp map[string]any
+		if err := dec.Decode(&resp); err != nil {
+			t.Fatalf("invalid response %q: %v", out.String(), err)
+		}
+		responses = append(responses, resp)
+	}
+	return responses
+}
+
+func errorCode(resp map[string]any) int {
+	e, ok := resp["error"].(map[string]any)
+	if !ok {
+		return 0
+	}
+	return int(e["code"].(float64))
+}
+
+func TestServeConn(t *testing.T) {
+	s := NewServer()
+	s.Handle("add", func(params json.RawMessage) (any, error) {
+		var p struct{ A, B int }
+		if err := DecodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return p.A + p.B, nil
+	})
+	s.Handle("fail", func(params json.RawMessage) (any, error) {
+		return nil, fmt.Errorf("volume is in use")
+	})
+	s.Handle("reset", func(params json.RawMessage) (any, error) {
+		return nil, nil
+	})
+	var calls []string
+	s.OnCall = func(method string, err error) { calls = append(calls, method) }
+
+	responses := serve(t, s, strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"add","params":{"a":2,"b":3}}`,
+		`{"jsonrpc":"2.0","method":"reset"}`, // notification: no response
+		``,
+		`{"jsonrpc":"2.0","id":"x","method":"nope"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"add","params":[1]}`,
+		`{"jsonrpc":"2.0","id":4,"method":"fail"}`,
+		`{"jsonrpc":"2.0","id":5,"method":"reset"}`,
+		`{"id":6,"method":"add"}`,
+		`not json`,
+	}, "\n"))
+
+	if len(responses) != 7 {
+		t.Fatalf("got %d responses, want 7: %v", len(responses), responses)
+	}
+	if responses[0]["id"] != 1.0 || responses[0]["result"] != 5.0 {
+		t.Errorf("add: got %v", responses[0])
+	}
+	if responses[1]["id"] != "x" || errorCode(responses[1]) != CodeMethodNotFound {
+		t.Errorf("unknown method: got %v", responses[1])
+	}
+	if errorCode(responses[2]) != CodeInvalidParams {
+		t.Errorf("bad params: got %v", responses[2])
+	}
+	if errorCode(responses[3]) != CodeServerError || responses[3]["error"].(map[string]any)["message"] != "volume is in use" {
+		t.Errorf("failed call: got %v", responses[3])
+	}
+	if _, ok := responses[4]["result"]; !ok || errorCode(responses[4]) != 0 {
+		t.Errorf("nil result: got %v, want an empty result", responses[4])
+	}
+	if errorCode(responses[5]) != CodeInvalidRequest {
+		t.Errorf("missing jsonrpc: got %v", responses[5])
+	}
+	if errorCode(responses[6]) != CodeParseError || responses[6]["id"] != nil {
+		t.Errorf("parse error: got %v", responses[6])
+	}
+
+	want := []string{"add", "reset", "add", "fail", "reset"}
+	if strings.Join(calls, ",") != strings.Join(want, ",") {
+		t.Errorf("OnCall saw %v, want %v", calls, want)
+	}
+}
@@ -0,0 +1,111 @@
+package snapshot
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+// Adopt registers an externally created tar.gz of a volume as a snapshot.
+// The archive is validated, copied into the snapshot directory, and given
+// generated metadata so it can be restored like any other snapshot.
+func (m *Manager) Adopt(name, tarball string, vol models.Volume, opts CreateOptions) (*models.Snapshot, error) {
+	if err := m.cfg.Writable(); err != nil {
+		return nil, err
+	}
+	if err := m.ValidateName(name); err != nil {
+		return nil, err
+	}
+	snapshotDir := filepath.Join(m.cfg.SnapshotDir, name)
+	if _, err := os.Stat(filepath.Join(snapshotDir, "metadata.yaml")); err == nil {
+		return nil, fmt.Errorf("snapshot %s already exists", name)
+	}
+
+	// Make sure it's a readable gzipped tar before registering it
+	checksum, err := verifyArchive(tarball, "")
+	if err != nil {
+		return nil, err
+	}
+	vol.Checksum = checksum
+
+	if err := os.MkdirAll(snapshotDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+
+	vol.ArchiveDir = m.archiveDir(name, vol)
+	dest := archivePath(snapshotDir, vol)
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		os.RemoveAll(snapshotDir)
+		return nil, fmt.Errorf("failed to create archive directory: %w", err)
+	}
+	size, err := copyFile(tarball, dest)
+	if err != nil {
+		os.Remove(dest)
+		os.RemoveAll(snapshotDir)
+		return nil, fmt.Errorf("failed to copy archive: %w", err)
+	}
+	vol.SizeBytes = size
+	vol.SizeHuman = models.FormatSize(size)
+
+	source, err := filepath.Abs(tarball)
+	if err != nil {
+		source = tarball
+	}
+	metadata := map[string]string{"adopted_from": source}
+	for k, v := range opts.Metadata {
+		metadata[k] = v
+	}
+
+	timestamp := time.Now()
+	if info, err := os.Stat(tarball); err == nil {
+		timestamp = info.ModTime() // when the backup was actually taken
+	}
+
+	snapshot := &models.Snapshot{
+		Name:          name,
+		Timestamp:     timestamp,
+		Volumes:       []models.Volume{vol},
+		SizeBytes:     size,
+		SizeHuman:     models.FormatSize(size),
+		Path:          snapshotDir,
+		Tags:          append(m.cfg.DefaultTags, opts.Tags...),
+		Description:   opts.Description,
+		Metadata:      metadata,
+		FormatVersion: models.SnapshotFormatVersion,
+		SchemaVersion: models.SnapshotSchemaVersion,
+		WrittenBy:     Version,
+		MinReader:     models.SnapshotMinReader,
+	}
+
+	if err := m.saveMetadata(snapshot); err != nil {
+		os.Remove(dest)
+		os.RemoveAll(snapshotDir)
+		return nil, fmt.Errorf("failed to write metadata: %w", err)
+	}
+
+	return snapshot, nil
+}
+
+// copyFile copies src to dst, returning the number of bytes written
+func copyFile(src, dst string) (int64, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := io.Copy(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return n, err
+}
@@ -0,0 +1,101 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+func TestAdopt(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "dataclean-adopt-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	tarball := filepath.Join(tmpDir, "old-backup.tar.gz")
+	writeTestArchive(t, tarball, map[string]string{"PG_VERSION": "15\n"})
+
+	cfg := &models.Config{SnapshotDir: filepath.Join(tmpDir, ".dataclean")}
+	m := NewManager(nil, cfg)
+
+	vol := models.Volume{Name: "project_pgdata", DatastoreType: models.DatastorePostgres}
+	snap, err := m.Adopt("legacy", tarball, vol, CreateOptions{Tags: []string{"adopted"}})
+	if err != nil {
+		t.Fatalf("Adopt() failed: %v", err)
+	}
+	if snap.SizeBytes == 0 || snap.Metadata["adopted_from"] != tarball {
+		t.Errorf("unexpected snapshot: %+v", snap)
+	}
+
+	loaded, err := m.Get("legacy")
+	if err != nil {
+		t.Fatalf("Get() failed: %v", err)
+	}
+	if len(loaded.Volumes) != 1 || loaded.Volumes[0].DatastoreType != models.DatastorePostgres {
+		t.Errorf("unexpected volumes: %+v", loaded.Volumes)
+	}
+
+	entries, err := m.ArchiveEntries("legacy", "project_pgdata")
+	if err != nil {
+		t.Fatalf("ArchiveEntries() failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Path != "PG_VERSION" {
+		t.Errorf("unexpected entries: %+v", entries)
+	}
+
+	if _, err := m.Adopt("legacy", tarball, vol, CreateOptions{}); err == nil {
+		t.Error("expected error adopting over an existing snapshot")
+	}
+
+	notArchive := filepath.Join(tmpDir, "notes.txt")
+	os.WriteFile(notArchive, []byte("not a tarball"), 0644)
+	if _, err := m.Adopt("bogus", notArchive, vol, CreateOptions{}); err == nil {
+		t.Error("expected error for a non-archive file")
+	}
+	if _, err := os.Stat(filepath.Join(cfg.SnapshotDir, "bogus")); !os.IsNotExist(err) {
+		t.Error("failed adoption should not leave a snapshot directory")
+	}
+}
+
+func TestAdopt_StorageRule(t *testing.T) {
+	tmpDir := t.TempDir()
+	external := filepath.Join(tmpDir, "external")
+
+	tarball := filepath.Join(tmpDir, "es.tar.gz")
+	writeTestArchive(t, tarball, map[string]string{"nodes/0/_state": "x"})
+
+	cfg := &models.Config{
+		SnapshotDir:  filepath.Join(tmpDir, ".dataclean"),
+		StorageRules: []models.StorageRule{{Type: models.DatastoreElastic, Dir: external}},
+	}
+	m := NewManager(nil, cfg)
+
+	vol := models.Volume{Name: "project_esdata", DatastoreType: models.DatastoreElastic}
+	if _, err := m.Adopt("search", tarball, vol, CreateOptions{}); err != nil {
+		t.Fatalf("Adopt() failed: %v", err)
+	}
+
+	routed := filepath.Join(external, "search", "project_esdata.tar.gz")
+	if _, err := os.Stat(routed); err != nil {
+		t.Fatalf("archive not stored under the rule's dir: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.SnapshotDir, "search", "project_esdata.tar.gz")); !os.IsNotExist(err) {
+		t.Error("archive should not also be in the snapshot directory")
+	}
+
+	// Reads follow the recorded location, even after the rule is removed
+	cfg.StorageRules = nil
+	if _, err := m.ArchiveEntries("search", "project_esdata"); err != nil {
+		t.Errorf("ArchiveEntries() failed: %v", err)
+	}
+
+	if err := m.Delete("search"); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(external, "search")); !os.IsNotExist(err) {
+		t.Error("Delete should remove the routed archive directory")
+	}
+}
@@ -0,0 +1,114 @@
+package snapshot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/stackgen-cli/dataclean/internal/datastore"
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+const (
+	// aofDirName holds Redis AOF captures, one directory per volume. List
+	// skips it because it has no metadata.
+	aofDirName = "_aof"
+
+	// aofTimeFormat names captures by when they were taken
+	aofTimeFormat = "20060102T150405Z"
+
+	defaultAOFKeep = 24
+)
+
+// aofCapture is one saved copy of a volume's append-only files
+type aofCapture struct {
+	At   time.Time
+	Path string
+}
+
+// AOFDir returns where a volume's AOF captures are kept
+func (m *Manager) AOFDir(vol models.Volume) string {
+	return filepath.Join(m.cfg.SnapshotDir, aofDirName, sanitizeName(vol.Name))
+}
+
+// AOFCapturedVolumes returns the names of volumes that have AOF captures
+func (m *Manager) AOFCapturedVolumes() []string {
+	entries, err := os.ReadDir(filepath.Join(m.cfg.SnapshotDir, aofDirName))
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() {
+			names = append(names, e.Name())
+		}
+	}
+	return names
+}
+
+// CaptureAOF copies a Redis volume's append-only files from the running
+// server into AOFDir and drops the oldest captures beyond the keep limit
+func (m *Manager) CaptureAOF(vol models.Volume) (string, error) {
+	container, err := m.client.ResolveContainer(vol)
+	if err != nil {
+		return "", err
+	}
+	dir := m.AOFDir(vol)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, time.Now().UTC().Format(aofTimeFormat)+".tar")
+	tmp := tempPath(path)
+	if err := m.client.ExecToFile(container, datastore.RedisCaptureAOF, tmp, datastore.CredentialEnv(vol.Credentials)...); err != nil {
+		os.Remove(tmp)
+		return "", fmt.Errorf("failed to capture AOF of %s: %w", vol.Name, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return "", err
+	}
+
+	keep := m.cfg.AOFCapture.Keep
+	if keep <= 0 {
+		keep = defaultAOFKeep
+	}
+	captures := m.aofCaptures(vol)
+	for len(captures) > keep {
+		os.Remove(captures[0].Path)
+		captures = captures[1:]
+	}
+	return path, nil
+}
+
+// aofCaptures lists a volume's captures, oldest first
+func (m *Manager) aofCaptures(vol models.Volume) []aofCapture {
+	entries, err := os.ReadDir(m.AOFDir(vol))
+	if err != nil {
+		return nil
+	}
+	var captures []aofCapture
+	for _, e := range entries {
+		at, err := time.Parse(aofTimeFormat, strings.TrimSuffix(e.Name(), ".tar"))
+		if err != nil || !strings.HasSuffix(e.Name(), ".tar") {
+			continue // in-progress captures and strays
+		}
+		captures = append(captures, aofCapture{At: at, Path: filepath.Join(m.AOFDir(vol), e.Name())})
+	}
+	sort.Slice(captures, func(i, j int) bool { return captures[i].At.Before(captures[j].At) })
+	return captures
+}
+
+// aofCaptureBetween returns the newest capture taken after since and no later than until
+func (m *Manager) aofCaptureBetween(vol models.Volume, since, until time.Time) (aofCapture, bool) {
+	captures := m.aofCaptures(vol)
+	for i := len(captures) - 1; i >= 0; i-- {
+		c := captures[i]
+		if c.At.After(since) && !c.At.After(until) {
+			return c, true
+		}
+	}
+	return aofCapture{}, false
+}
@@ -0,0 +1,63 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+func TestAOFCaptureBetween(t *testing.T) {
+	tmpDir := t.TempDir()
+	m := &Manager{cfg: &models.Config{
+		SnapshotDir: tmpDir,
+		AOFCapture:  models.AOFCaptureConfig{Volumes: []string{"*_redis"}},
+	}}
+	redis := models.Volume{Name: "project_redis", DatastoreType: models.DatastoreRedis}
+
+	taken := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	snap := &models.Snapshot{
+		Name:      "noon",
+		Timestamp: taken,
+		Path:      filepath.Join(tmpDir, "noon"),
+		Volumes:   []models.Volume{redis, {Name: "project_uploads", DatastoreType: models.DatastoreGeneric}},
+	}
+	os.MkdirAll(snap.Path, 0755)
+	if err := m.saveMetadata(snap); err != nil {
+		t.Fatalf("failed to save metadata: %v", err)
+	}
+
+	dir := m.AOFDir(redis)
+	os.MkdirAll(dir, 0755)
+	for _, at := range []time.Time{taken.Add(-time.Hour), taken.Add(time.Hour), taken.Add(2 * time.Hour)} {
+		os.WriteFile(filepath.Join(dir, at.Format(aofTimeFormat)+".tar"), nil, 0644)
+	}
+	// An interrupted capture is never used
+	os.WriteFile(filepath.Join(dir, taken.Add(90*time.Minute).Format(aofTimeFormat)+".tar.tmp"), nil, 0644)
+
+	if got := m.aofCaptures(redis); len(got) != 3 || !got[0].At.Equal(taken.Add(-time.Hour)) {
+		t.Errorf("aofCaptures() = %+v, want 3 captures oldest first", got)
+	}
+
+	c, ok := m.aofCaptureBetween(redis, taken, taken.Add(100*time.Minute))
+	if !ok || !c.At.Equal(taken.Add(time.Hour)) {
+		t.Errorf("aofCaptureBetween() = %+v, %v, want the 13:00 capture", c, ok)
+	}
+	if _, ok := m.aofCaptureBetween(redis, taken, taken.Add(30*time.Minute)); ok {
+		t.Error("expected no capture between the snapshot and 12:30")
+	}
+
+	// With only AOF capture configured, the snapshot still serves as a base
+	base, err := m.PITRBase(taken.Add(3 * time.Hour))
+	if err != nil || base != "noon" {
+		t.Errorf("PITRBase() = %s, %v, want noon", base, err)
+	}
+
+	state, _ := m.LoadState()
+	r := m.rollForward(snap, state, taken.Add(3*time.Hour))
+	if len(r.wal) != 0 || len(r.aof) != 1 || !r.aof[0].capture.At.Equal(taken.Add(2*time.Hour)) {
+		t.Errorf("rollForward() = %+v, want the newest Redis capture only", r)
+	}
+}
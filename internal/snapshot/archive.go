@@ -0,0 +1,220 @@
+package snapshot
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+// FindVolume returns the snapshot volume matching a full or compose volume name
+func FindVolume(snapshot *models.Snapshot, name string) (*models.Volume, error) {
+	for i, v := range snapshot.Volumes {
+		if v.Name == name || (v.ComposeName != "" && v.ComposeName == name) {
+			return &snapshot.Volumes[i], nil
+		}
+	}
+	return nil, fmt.Errorf("volume %s not found in snapshot %s", name, snapshot.Name)
+}
+
+// ArchiveEntries lists the files stored in a volume's archive without extracting it
+func (m *Manager) ArchiveEntries(name, volume string) ([]models.ArchiveEntry, error) {
+	var entries []models.ArchiveEntry
+	err := m.ListArchiveContents(name, volume, func(e models.ArchiveEntry) error {
+		entries = append(entries, e)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// ListArchiveContents calls fn for every entry of a volume's archive in
+// archive order. Only headers are kept, so archives of any size list in
+// constant memory. An error from fn stops the listing and is returned.
+func (m *Manager) ListArchiveContents(name, volume string, fn func(models.ArchiveEntry) error) error {
+	snapshot, err := m.Get(name)
+	if err != nil {
+		return err
+	}
+	vol, err := FindVolume(snapshot, volume)
+	if err != nil {
+		return err
+	}
+
+	archive, err := m.resolveArchive(snapshot, *vol)
+	if err != nil {
+		return err
+	}
+	return walkArchive(archive, func(hdr *tar.Header, _ io.Reader) error {
+		return fn(archiveEntry(hdr))
+	})
+}
+
+// walkArchive calls fn for every entry in a gzipped tar archive
+func walkArchive(archive string, fn func(hdr *tar.Header, r io.Reader) error) error {
+	f, err := os.Open(archive)
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("failed to read archive %s: %w", archive, err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read archive %s: %w", archive, err)
+		}
+		if entryPath(hdr.Name) == "" {
+			continue // archive root
+		}
+		if err := fn(hdr, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// archiveEntry converts a tar header to an ArchiveEntry
+func archiveEntry(hdr *tar.Header) models.ArchiveEntry {
+	return models.ArchiveEntry{
+		Path:       entryPath(hdr.Name),
+		SizeBytes:  hdr.Size,
+		Mode:       hdr.FileInfo().Mode().String(),
+		ModTime:    hdr.ModTime,
+		IsDir:      hdr.Typeflag == tar.TypeDir,
+		LinkTarget: hdr.Linkname,
+	}
+}
+
+// entryPath normalizes a tar entry name ("./base/1" -> "base/1")
+func entryPath(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+name), "/")
+}
+
+// Extract copies a file or directory from a volume's archive into outDir.
+// The extracted item keeps its base name (extracting "base/1" creates outDir/1/...).
+// Symlinks are extracted as they are, but nothing is written through one
+// that leads out of outDir, so an archive can't place files elsewhere.
+func (m *Manager) Extract(name, volume, target, outDir string) ([]string, error) {
+	snapshot, err := m.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	vol, err := FindVolume(snapshot, volume)
+	if err != nil {
+		return nil, err
+	}
+
+	target = entryPath(target)
+	if target == "" {
+		return nil, fmt.Errorf("path inside volume is required")
+	}
+	parent := path.Dir(target)
+
+	archive, err := m.resolveArchive(snapshot, *vol)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return nil, err
+	}
+	root, err := os.OpenRoot(outDir)
+	if err != nil {
+		return nil, err
+	}
+	defer root.Close()
+
+	var extracted []string
+	err = walkArchive(archive, func(hdr *tar.Header, r io.Reader) error {
+		p := entryPath(hdr.Name)
+		if p != target && !strings.HasPrefix(p, target+"/") {
+			return nil
+		}
+
+		rel := strings.TrimPrefix(p, parent+"/")
+		if parent == "." {
+			rel = p
+		}
+		rel = filepath.FromSlash(rel)
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := root.MkdirAll(rel, 0755); err != nil {
+				return fmt.Errorf("can't extract %s: %w", p, err)
+			}
+		case tar.TypeReg:
+			if err := writeFileIn(root, rel, r, hdr.FileInfo().Mode().Perm()); err != nil {
+				return fmt.Errorf("can't extract %s: %w", p, err)
+			}
+		case tar.TypeSymlink:
+			if err := root.MkdirAll(filepath.Dir(rel), 0755); err != nil {
+				return fmt.Errorf("can't extract %s: %w", p, err)
+			}
+			root.Remove(rel)
+			if err := root.Symlink(hdr.Linkname, rel); err != nil {
+				return fmt.Errorf("can't extract %s: %w", p, err)
+			}
+		default:
+			return nil // devices, fifos etc. aren't useful outside the volume
+		}
+
+		extracted = append(extracted, filepath.Join(outDir, rel))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(extracted) == 0 {
+		return nil, fmt.Errorf("%s not found in volume %s", target, vol.Name)
+	}
+	return extracted, nil
+}
+
+// writeFileIn writes a reader to name inside root, creating parent
+// directories; like every access through root, it can't leave it
+func writeFileIn(root *os.Root, name string, r io.Reader, perm os.FileMode) error {
+	if err := root.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+	f, err := root.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(f, r)
+	return err
+}
+
+// writeFile writes a reader to dest, creating parent directories
+func writeFile(dest string, r io.Reader, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(f, r)
+	return err
+}
@@ -0,0 +1,194 @@
+package snapshot
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+// writeTestArchive creates a gzipped tar with the given files (name -> content)
+func writeTestArchive(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("failed to create archive: %v", err)
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	tw.WriteHeader(&tar.Header{Name: "./", Typeflag: tar.TypeDir, Mode: 0755})
+	for name, content := range files {
+		tw.WriteHeader(&tar.Header{
+			Name:    "./" + name,
+			Mode:    0644,
+			Size:    int64(len(content)),
+			ModTime: time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC),
+		})
+		tw.Write([]byte(content))
+	}
+	tw.Close()
+	gz.Close()
+}
+
+func TestArchiveEntries(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "dataclean-archive-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	snapshotDir := filepath.Join(tmpDir, "baseline")
+	os.MkdirAll(snapshotDir, 0755)
+	os.WriteFile(filepath.Join(snapshotDir, "metadata.yaml"), []byte(`
+name: baseline
+volumes:
+  - name: project_pgdata
+    compose_name: pgdata
+    datastore_type: postgres
+`), 0644)
+	writeTestArchive(t, filepath.Join(snapshotDir, "project_pgdata.tar.gz"), map[string]string{
+		"PG_VERSION":      "16\n",
+		"postgresql.conf": "max_connections = 100\n",
+		"base/1/1259":     "table data",
+	})
+
+	cfg := &models.Config{SnapshotDir: tmpDir}
+	m := &Manager{cfg: cfg}
+
+	// Lookup by compose name
+	entries, err := m.ArchiveEntries("baseline", "pgdata")
+	if err != nil {
+		t.Fatalf("ArchiveEntries() failed: %v", err)
+	}
+
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries (root skipped), got %d: %+v", len(entries), entries)
+	}
+	found := false
+	for _, e := range entries {
+		if e.Path == "PG_VERSION" && e.SizeBytes == 3 {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected PG_VERSION entry with size 3")
+	}
+}
+
+func TestArchiveEntries_UnknownVolume(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "dataclean-archive-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	snapshotDir := filepath.Join(tmpDir, "baseline")
+	os.MkdirAll(snapshotDir, 0755)
+	os.WriteFile(filepath.Join(snapshotDir, "metadata.yaml"), []byte(`name: baseline`), 0644)
+
+	cfg := &models.Config{SnapshotDir: tmpDir}
+	m := &Manager{cfg: cfg}
+
+	if _, err := m.ArchiveEntries("baseline", "missing"); err == nil {
+		t.Error("expected error for unknown volume, got nil")
+	}
+}
+
+func TestExtract(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "dataclean-archive-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	snapshotDir := filepath.Join(tmpDir, "baseline")
+	os.MkdirAll(snapshotDir, 0755)
+	os.WriteFile(filepath.Join(snapshotDir, "metadata.yaml"), []byte(`
+name: baseline
+volumes:
+  - name: project_pgdata
+    compose_name: pgdata
+`), 0644)
+	writeTestArchive(t, filepath.Join(snapshotDir, "project_pgdata.tar.gz"), map[string]string{
+		"postgresql.conf": "max_connections = 100\n",
+		"base/1/1259":     "table data",
+		"base/1/1260":     "more data",
+		"base/2/1259":     "other db",
+	})
+
+	cfg := &models.Config{SnapshotDir: tmpDir}
+	m := &Manager{cfg: cfg}
+	outDir := filepath.Join(tmpDir, "out")
+
+	// Single file
+	if _, err := m.Extract("baseline", "pgdata", "postgresql.conf", outDir); err != nil {
+		t.Fatalf("Extract() failed: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(outDir, "postgresql.conf"))
+	if err != nil || string(data) != "max_connections = 100\n" {
+		t.Errorf("postgresql.conf = %q, %v", data, err)
+	}
+
+	// Directory keeps its base name
+	extracted, err := m.Extract("baseline", "pgdata", "base/1", outDir)
+	if err != nil {
+		t.Fatalf("Extract() failed: %v", err)
+	}
+	if len(extracted) != 2 {
+		t.Errorf("expected 2 files extracted, got %d: %v", len(extracted), extracted)
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "1", "1260")); err != nil {
+		t.Errorf("expected out/1/1260 to exist: %v", err)
+	}
+
+	// Missing path
+	if _, err := m.Extract("baseline", "pgdata", "nope", outDir); err == nil {
+		t.Error("expected error for missing path, got nil")
+	}
+}
+
+func TestExtract_SymlinkEscape(t *testing.T) {
+	tmpDir := t.TempDir()
+	snapshotDir := filepath.Join(tmpDir, "baseline")
+	os.MkdirAll(snapshotDir, 0755)
+	os.WriteFile(filepath.Join(snapshotDir, "metadata.yaml"), []byte(`
+name: baseline
+volumes:
+  - name: project_pgdata
+    compose_name: pgdata
+`), 0644)
+	outside := filepath.Join(tmpDir, "outside")
+	os.MkdirAll(outside, 0755)
+
+	// conf/escape points out of the extraction directory, and the next
+	// entry writes through it
+	f, err := os.Create(filepath.Join(snapshotDir, "project_pgdata.tar.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	tw.WriteHeader(&tar.Header{Name: "./conf/", Typeflag: tar.TypeDir, Mode: 0755})
+	tw.WriteHeader(&tar.Header{Name: "./conf/escape", Typeflag: tar.TypeSymlink, Linkname: outside})
+	payload := "owned"
+	tw.WriteHeader(&tar.Header{Name: "./conf/escape/passwd", Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(payload))})
+	tw.Write([]byte(payload))
+	tw.Close()
+	gz.Close()
+	f.Close()
+
+	m := &Manager{cfg: &models.Config{SnapshotDir: tmpDir}}
+	if _, err := m.Extract("baseline", "pgdata", "conf", filepath.Join(tmpDir, "out")); err == nil {
+		t.Error("expected Extract() to refuse writing through a symlink out of the output directory")
+	}
+	if _, err := os.Stat(filepath.Join(outside, "passwd")); !os.IsNotExist(err) {
+		t.Errorf("Extract() wrote outside the output directory (%v)", err)
+	}
+}
@@ -0,0 +1,89 @@
+package snapshot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/stackgen-cli/dataclean/internal/datastore"
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+// BakeOptions controls building a fixture image from a snapshot volume
+type BakeOptions struct {
+	Image    string // Tag for the built image
+	Base     string // Base image (default: the volume's service image)
+	DataPath string // Where the data goes in the image (default: the volume's mount path)
+}
+
+// Bake builds a Docker image with a snapshot volume's data pre-loaded at the
+// datastore's data path, so a container can start already seeded
+func (m *Manager) Bake(name, volume string, opts BakeOptions) error {
+	snapshot, err := m.Get(name)
+	if err != nil {
+		return err
+	}
+	vol, err := FindVolume(snapshot, volume)
+	if err != nil {
+		return err
+	}
+	base, dataPath, err := bakeTarget(*vol, opts)
+	if err != nil {
+		return err
+	}
+
+	// Build context holds just the archive and the Dockerfile
+	contextDir, err := m.tempDir("bake-")
+	if err != nil {
+		return fmt.Errorf("failed to create build context: %w", err)
+	}
+	defer os.RemoveAll(contextDir)
+
+	archive, err := m.resolveArchive(snapshot, *vol)
+	if err != nil {
+		return err
+	}
+	if _, err := copyFile(archive, filepath.Join(contextDir, "data.tar.gz")); err != nil {
+		return fmt.Errorf("failed to copy archive: %w", err)
+	}
+	dockerfile := bakeDockerfile(base, dataPath, snapshot.Name, vol.Name)
+	if err := os.WriteFile(filepath.Join(contextDir, "Dockerfile"), []byte(dockerfile), 0644); err != nil {
+		return fmt.Errorf("failed to write Dockerfile: %w", err)
+	}
+
+	return m.client.BuildImage(contextDir, opts.Image)
+}
+
+// bakeTarget resolves the base image and data path for a baked volume
+func bakeTarget(vol models.Volume, opts BakeOptions) (base, dataPath string, err error) {
+	base = opts.Base
+	if base == "" {
+		base = vol.ImageName
+	}
+	if base == "" {
+		return "", "", fmt.Errorf("volume %s has no known image; pass a base image", vol.Name)
+	}
+
+	dataPath = opts.DataPath
+	if dataPath == "" {
+		dataPath = vol.MountPath
+	}
+	if dataPath == "" {
+		dataPath, _ = datastore.DataPath(vol.DatastoreType)
+	}
+	if dataPath == "" {
+		return "", "", fmt.Errorf("volume %s has no known data path; pass one explicitly", vol.Name)
+	}
+
+	return base, dataPath, nil
+}
+
+// bakeDockerfile returns a Dockerfile that unpacks the archive into dataPath.
+// ADD extracts local tarballs and keeps file ownership, which datastores
+// like Postgres require for their data directory.
+func bakeDockerfile(base, dataPath, snapshotName, volumeName string) string {
+	return fmt.Sprintf(`FROM %s
+LABEL dataclean.snapshot=%q dataclean.volume=%q
+ADD data.tar.gz %s/
+`, base, snapshotName, volumeName, filepath.ToSlash(filepath.Clean(dataPath)))
+}
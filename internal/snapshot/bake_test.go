@@ -0,0 +1,49 @@
+package snapshot
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+func TestBakeTarget(t *testing.T) {
+	vol := models.Volume{Name: "project_pgdata", DatastoreType: models.DatastorePostgres, ImageName: "postgres:16", MountPath: "/pgdata"}
+
+	base, dataPath, err := bakeTarget(vol, BakeOptions{})
+	if err != nil {
+		t.Fatalf("bakeTarget() failed: %v", err)
+	}
+	if base != "postgres:16" || dataPath != "/pgdata" {
+		t.Errorf("bakeTarget() = %s, %s", base, dataPath)
+	}
+
+	// Adopted volumes have no image or mount path recorded
+	adopted := models.Volume{Name: "pgdata", DatastoreType: models.DatastorePostgres}
+	if _, _, err := bakeTarget(adopted, BakeOptions{}); err == nil {
+		t.Error("expected error without a base image")
+	}
+	_, dataPath, err = bakeTarget(adopted, BakeOptions{Base: "postgres:15"})
+	if err != nil || dataPath != "/var/lib/postgresql/data" {
+		t.Errorf("bakeTarget() data path = %s, %v", dataPath, err)
+	}
+
+	generic := models.Volume{Name: "uploads", DatastoreType: models.DatastoreGeneric}
+	if _, _, err := bakeTarget(generic, BakeOptions{Base: "alpine"}); err == nil {
+		t.Error("expected error without a data path")
+	}
+}
+
+func TestBakeDockerfile(t *testing.T) {
+	got := bakeDockerfile("postgres:16", "/var/lib/postgresql/data/", "seeded", "project_pgdata")
+
+	for _, want := range []string{
+		"FROM postgres:16\n",
+		`LABEL dataclean.snapshot="seeded" dataclean.volume="project_pgdata"`,
+		"ADD data.tar.gz /var/lib/postgresql/data/\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Dockerfile missing %q:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package snapshot
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+// A bundle carries one snapshot as a single uncompressed tar (the archives
+// inside are compressed already): metadata.yaml first, then every volume
+// archive and the snapshot's other files. Incremental snapshots are
+// flattened, so a bundle stands on its own.
+const bundleMetadata = "metadata.yaml"
+
+// bundleFile is a file that goes into a bundle under a flat name
+type bundleFile struct {
+	name string
+	path string
+}
+
+// Bundle writes a snapshot to w as a self-contained bundle. Stored passwords
+// are left out.
+func (m *Manager) Bundle(name string, w io.Writer) error {
+	snapshot, err := m.Get(name)
+	if err != nil {
+		return err
+	}
+
+	flat := *snapshot
+	flat.Path = ""
+	flat.ParentName = ""
+	flat.Incremental = false
+	flat.Volumes = make([]models.Volume, len(snapshot.Volumes))
+
+	var files []bundleFile
+	included := map[string]bool{bundleMetadata: true, credentialsFileName: true}
+	for i, vol := range snapshot.Volumes {
+		src, err := m.resolveArchive(snapshot, vol)
+		if err != nil {
+			return err
+		}
+		vol.ArchiveDir = ""
+		vol.LinkedFrom = ""
+		vol.Credentials = vol.Credentials.Redacted()
+		flat.Volumes[i] = vol
+
+		entry := filepath.Base(archivePath("", vol))
+		files = append(files, bundleFile{name: entry, path: src})
+		included[entry] = true
+	}
+
+	// Logical dumps and the compose config
+	entries, err := os.ReadDir(snapshot.Path)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.Type().IsRegular() && !included[e.Name()] {
+			files = append(files, bundleFile{name: e.Name(), path: filepath.Join(snapshot.Path, e.Name())})
+		}
+	}
+
+	metadata, err := yaml.Marshal(&flat)
+	if err != nil {
+		return err
+	}
+	tw := tar.NewWriter(w)
+	hdr := &tar.Header{Name: bundleMetadata, Mode: 0644, Size: int64(len(metadata)), ModTime: time.Now()}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	if _, err := tw.Write(metadata); err != nil {
+		return err
+	}
+	for _, f := range files {
+		if err := addBundleFile(tw, f); err != nil {
+			return fmt.Errorf("failed to bundle %s: %w", f.name, err)
+		}
+	}
+	return tw.Close()
+}
+
+func addBundleFile(tw *tar.Writer, f bundleFile) error {
+	src, err := os.Open(f.path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	hdr := &tar.Header{Name: f.name, Mode: 0644, Size: info.Size(), ModTime: info.ModTime()}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, src)
+	return err
+}
+
+// Unbundle saves a bundle read from r as the named snapshot, applying
+// storage rules and verifying every archive against its recorded checksum.
+// Nothing is left behind when it fails.
+func (m *Manager) Unbundle(r io.Reader, name string) (*models.Snapshot, error) {
+	return m.UnbundleFor(r, name, nil)
+}
+
+// UnbundleFor is Unbundle for another compose project: each bundled volume
+// takes the name, service, and container of the local volume with the same
+// compose name, so restoring the snapshot fills this project's volumes. A
+// nil local keeps the bundled names.
+func (m *Manager) UnbundleFor(r io.Reader, name string, local []models.Volume) (*models.Snapshot, error) {
+	if err := m.cfg.Writable(); err != nil {
+		return nil, err
+	}
+	if err := m.ValidateName(name); err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(m.cfg.SnapshotDir, name)
+	if _, err := os.Stat(dir); err == nil {
+		return nil, fmt.Errorf("snapshot '%s' already exists", name)
+	}
+
+	tr := tar.NewReader(r)
+	snapshot, err := readBundleMetadata(tr)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.Name = name
+	snapshot.Path = dir
+
+	archives := make(map[string]models.Volume)
+	for i := range snapshot.Volumes {
+		entry := filepath.Base(archivePath("", snapshot.Volumes[i]))
+		if local != nil {
+			if err := rebindVolume(&snapshot.Volumes[i], local); err != nil {
+				return nil, err
+			}
+		}
+		snapshot.Volumes[i].ArchiveDir = m.archiveDir(name, snapshot.Volumes[i])
+		archives[entry] = snapshot.Volumes[i]
+	}
+
+	// Metadata goes first so Delete can clean up after a failure
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	if err := m.saveMetadata(snapshot); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	if err := m.unbundleFiles(tr, snapshot, archives); err != nil {
+		m.Delete(name)
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// readBundleMetadata reads the metadata that starts every bundle
+func readBundleMetadata(tr *tar.Reader) (*models.Snapshot, error) {
+	hdr, err := tr.Next()
+	if err != nil || hdr.Name != bundleMetadata {
+		return nil, fmt.Errorf("not a snapshot bundle")
+	}
+	data, err := io.ReadAll(tr)
+	if err != nil {
+		return nil, err
+	}
+	var snapshot models.Snapshot
+	if err := yaml.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("invalid snapshot metadata in bundle: %w", err)
+	}
+	if err := snapshot.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid snapshot metadata in bundle: %w", err)
+	}
+	return &snapshot, nil
+}
+
+// unbundleFiles writes the bundle's remaining entries into place and verifies them
+func (m *Manager) unbundleFiles(tr *tar.Reader, snapshot *models.Snapshot, archives map[string]models.Volume) error {
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("bundle is corrupt: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg || hdr.Name != filepath.Base(hdr.Name) || hdr.Name == bundleMetadata {
+			return fmt.Errorf("unexpected entry %q in bundle", hdr.Name)
+		}
+
+		dest := filepath.Join(snapshot.Path, hdr.Name)
+		if vol, ok := archives[hdr.Name]; ok {
+			dest = archivePath(snapshot.Path, vol)
+		}
+		if err := writeFile(dest, tr, 0644); err != nil {
+			return err
+		}
+	}
+	return m.VerifySnapshot(snapshot)
+}
+
+// rebindVolume points a bundled volume at the local volume with the same compose name
+func rebindVolume(vol *models.Volume, local []models.Volume) error {
+	for _, l := range local {
+		if l.ComposeName == "" || l.ComposeName != vol.ComposeName {
+			continue
+		}
+		vol.Name = l.Name
+		vol.Service = l.Service
+		vol.ContainerName = l.ContainerName
+		vol.MountPath = l.MountPath
+		vol.Credentials = l.Credentials.Redacted()
+		return nil
+	}
+	return fmt.Errorf("volume %s has no counterpart in this compose project (no volume named %q)", vol.Name, vol.ComposeName)
+}
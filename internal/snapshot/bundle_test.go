@@ -0,0 +1,149 @@
+package snapshot
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stackgen-cli/dataclean/internal/docker"
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+func TestBundleRoundTrip(t *testing.T) {
+	src := NewManager(nil, &models.Config{SnapshotDir: t.TempDir()})
+	now := time.Now()
+	writeChainSnapshot(t, src, "base", "", now.Add(-time.Hour), map[string]string{"project_pgdata": "pg v1", "project_uploads": "files v1"})
+	writeChainSnapshot(t, src, "inc", "base", now, map[string]string{"project_pgdata": "pg v2"})
+	os.WriteFile(filepath.Join(src.cfg.SnapshotDir, "inc", composeFileName), []byte("services: {}\n"), 0644)
+
+	var bundle bytes.Buffer
+	if err := src.Bundle("inc", &bundle); err != nil {
+		t.Fatalf("Bundle() failed: %v", err)
+	}
+
+	// The incremental snapshot arrives flattened, with uploads inherited from base
+	dst := NewManager(nil, &models.Config{SnapshotDir: t.TempDir()})
+	snap, err := dst.Unbundle(bytes.NewReader(bundle.Bytes()), "from-alex")
+	if err != nil {
+		t.Fatalf("Unbundle() failed: %v", err)
+	}
+	if snap.Incremental || snap.ParentName != "" {
+		t.Errorf("unbundled snapshot still incremental: %+v", snap)
+	}
+	for vol, want := range map[string]string{"project_pgdata": "pg v2", "project_uploads": "files v1"} {
+		archive := filepath.Join(dst.cfg.SnapshotDir, "from-alex", vol+".tar.gz")
+		if got := archiveFileContent(t, archive, "data"); got != want {
+			t.Errorf("%s = %q, want %q", vol, got, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dst.cfg.SnapshotDir, "from-alex", composeFileName)); err != nil {
+		t.Errorf("compose config not carried over: %v", err)
+	}
+
+	if _, err := dst.Unbundle(bytes.NewReader(bundle.Bytes()), "from-alex"); err == nil {
+		t.Error("expected error unbundling over an existing snapshot")
+	}
+
+	// A truncated bundle leaves nothing behind
+	if _, err := dst.Unbundle(bytes.NewReader(bundle.Bytes()[:bundle.Len()/2]), "broken"); err == nil {
+		t.Error("expected error for a truncated bundle")
+	}
+	if _, err := os.Stat(filepath.Join(dst.cfg.SnapshotDir, "broken")); !os.IsNotExist(err) {
+		t.Error("truncated bundle left a snapshot directory behind")
+	}
+}
+
+// archiveFileContent returns one file's content from a volume archive
+func archiveFileContent(t *testing.T, archive, name string) string {
+	t.Helper()
+	var content string
+	err := walkArchive(archive, func(hdr *tar.Header, r io.Reader) error {
+		if entryPath(hdr.Name) == name {
+			data, err := io.ReadAll(r)
+			content = string(data)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", archive, err)
+	}
+	return content
+}
+
+func TestUnbundleFor(t *testing.T) {
+	src := NewManager(nil, &models.Config{SnapshotDir: t.TempDir()})
+	writeChainSnapshot(t, src, "seed", "", time.Now(), map[string]string{"project_pgdata": "pg seed", "project_uploads": "files seed"})
+	var bundle bytes.Buffer
+	if err := src.Bundle("seed", &bundle); err != nil {
+		t.Fatalf("Bundle() failed: %v", err)
+	}
+
+	// Another checkout names its compose project differently
+	dst := NewManager(nil, &models.Config{SnapshotDir: t.TempDir()})
+	local := []models.Volume{
+		{Name: "orders_pgdata", ComposeName: "pgdata", Service: "db"},
+		{Name: "orders_uploads", ComposeName: "uploads", Service: "app"},
+	}
+	snap, err := dst.UnbundleFor(bytes.NewReader(bundle.Bytes()), "seed", local)
+	if err != nil {
+		t.Fatalf("UnbundleFor() failed: %v", err)
+	}
+	for i, want := range []string{"orders_pgdata", "orders_uploads"} {
+		if snap.Volumes[i].Name != want || snap.Volumes[i].Service != local[i].Service {
+			t.Errorf("volume %d = %s (service %s), want %s", i, snap.Volumes[i].Name, snap.Volumes[i].Service, want)
+		}
+	}
+	if got := archiveFileContent(t, filepath.Join(dst.cfg.SnapshotDir, "seed", "orders_pgdata.tar.gz"), "data"); got != "pg seed" {
+		t.Errorf("orders_pgdata = %q, want pg seed", got)
+	}
+
+	// A project without one of the volumes can't take the snapshot
+	if _, err := dst.UnbundleFor(bytes.NewReader(bundle.Bytes()), "partial", local[:1]); err == nil {
+		t.Error("expected error for a volume with no local counterpart")
+	}
+	if _, err := os.Stat(filepath.Join(dst.cfg.SnapshotDir, "partial")); !os.IsNotExist(err) {
+		t.Error("failed UnbundleFor left a snapshot directory behind")
+	}
+}
+
+func TestStoredPasswordsStayOutOfComposeAndBundles(t *testing.T) {
+	dir := t.TempDir()
+	composePath := filepath.Join(dir, "compose.yaml")
+	os.WriteFile(composePath, []byte(`services:
+  db:
+    image: postgres:16
+    environment:
+      POSTGRES_USER: app
+      POSTGRES_PASSWORD: hunter2
+`), 0644)
+
+	m := NewManager(&docker.Client{}, &models.Config{SnapshotDir: filepath.Join(dir, ".dataclean"), ComposeFile: composePath, StoreCredentials: true})
+	writeChainSnapshot(t, m, "seeded", "", time.Now(), map[string]string{"project_pgdata": "pg v1", "project_uploads": "files v1"})
+	snapshotDir := filepath.Join(m.cfg.SnapshotDir, "seeded")
+
+	creds := (&models.Credentials{User: "app", Password: models.NewSecret("hunter2")}).Stored()
+	if err := writeStoredCredentials(snapshotDir, []models.Volume{{Name: "project_pgdata", Credentials: creds}}); err != nil {
+		t.Fatalf("writeStoredCredentials() failed: %v", err)
+	}
+	name, err := m.writeCompose(snapshotDir)
+	if err != nil || name != composeFileName {
+		t.Fatalf("writeCompose() = %q, %v", name, err)
+	}
+	compose, _ := os.ReadFile(filepath.Join(snapshotDir, composeFileName))
+	if bytes.Contains(compose, []byte("hunter2")) || !bytes.Contains(compose, []byte("POSTGRES_USER: app")) {
+		t.Errorf("compose.yaml should keep the user and redact the password:\n%s", compose)
+	}
+
+	var bundle bytes.Buffer
+	if err := m.Bundle("seeded", &bundle); err != nil {
+		t.Fatalf("Bundle() failed: %v", err)
+	}
+	if bytes.Contains(bundle.Bytes(), []byte("hunter2")) {
+		t.Error("bundle carries the stored password")
+	}
+}
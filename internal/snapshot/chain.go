@@ -0,0 +1,170 @@
+package snapshot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+// CompactResult reports what Compact changed
+type CompactResult struct {
+	Compacted []string // Incremental snapshots turned into full snapshots
+	Deleted   []string // Intermediate snapshots removed afterwards
+}
+
+// resolveArchive returns the archive holding a volume's data. Incremental
+// snapshots only store volumes that changed; the rest come from the parent chain.
+func (m *Manager) resolveArchive(snapshot *models.Snapshot, vol models.Volume) (string, error) {
+	seen := make(map[string]bool)
+	for s := snapshot; ; {
+		// Each snapshot in the chain records where it put its own archive
+		if own, err := FindVolume(s, vol.Name); err == nil {
+			vol = *own
+		}
+		p := archivePath(s.Path, vol)
+		if _, err := os.Stat(p); err == nil {
+			return p, nil
+		}
+		if s.ParentName == "" || seen[s.ParentName] {
+			return "", fmt.Errorf("archive for volume %s not found in snapshot %s or its parents", vol.Name, snapshot.Name)
+		}
+		seen[s.Name] = true
+
+		parent, err := m.Get(s.ParentName)
+		if err != nil {
+			return "", fmt.Errorf("parent %s of snapshot %s is missing: %w", s.ParentName, s.Name, err)
+		}
+		s = parent
+	}
+}
+
+// children maps each snapshot name to the snapshots built on top of it
+func children(snapshots []models.Snapshot) map[string][]string {
+	kids := make(map[string][]string)
+	for _, s := range snapshots {
+		if s.ParentName != "" {
+			kids[s.ParentName] = append(kids[s.ParentName], s.Name)
+		}
+	}
+	return kids
+}
+
+// Dependents returns the snapshots built directly on top of name
+func (m *Manager) Dependents(name string) ([]string, error) {
+	all, err := m.List()
+	if err != nil {
+		return nil, err
+	}
+	return children(all)[name], nil
+}
+
+// Compact turns every incremental snapshot built on base into a full
+// snapshot, so base and the snapshots in between are no longer needed for
+// restores. With prune, the intermediate snapshots are deleted afterwards
+// (base itself is kept).
+func (m *Manager) Compact(base string, prune bool) (*CompactResult, error) {
+	if err := m.cfg.Writable(); err != nil {
+		return nil, err
+	}
+	if _, err := m.Get(base); err != nil {
+		return nil, err
+	}
+	all, err := m.List()
+	if err != nil {
+		return nil, err
+	}
+	kids := children(all)
+
+	// Walk the chain: snapshots with children are intermediates, the rest are tips
+	var tips, intermediates []string
+	queue := append([]string(nil), kids[base]...)
+	seen := map[string]bool{base: true}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		if len(kids[name]) == 0 {
+			tips = append(tips, name)
+			continue
+		}
+		intermediates = append(intermediates, name)
+		queue = append(queue, kids[name]...)
+	}
+	if len(tips) == 0 {
+		return nil, fmt.Errorf("no incremental snapshots are based on %s", base)
+	}
+	sort.Strings(tips)
+	sort.Strings(intermediates)
+
+	// Materialize every tip before anything in the chain is deleted
+	result := &CompactResult{}
+	for _, name := range tips {
+		if err := m.materialize(name); err != nil {
+			return result, fmt.Errorf("failed to compact %s: %w", name, err)
+		}
+		result.Compacted = append(result.Compacted, name)
+	}
+
+	if prune {
+		for _, name := range intermediates {
+			if err := m.Delete(name); err != nil {
+				return result, fmt.Errorf("failed to delete %s: %w", name, err)
+			}
+			result.Deleted = append(result.Deleted, name)
+		}
+	}
+
+	return result, nil
+}
+
+// materialize copies inherited archives into an incremental snapshot and
+// marks it as a full snapshot
+func (m *Manager) materialize(name string) error {
+	snapshot, err := m.Get(name)
+	if err != nil {
+		return err
+	}
+
+	var total int64
+	for i, vol := range snapshot.Volumes {
+		own := archivePath(snapshot.Path, vol)
+		if _, err := os.Stat(own); err != nil {
+			src, err := m.resolveArchive(snapshot, vol)
+			if err != nil {
+				return err
+			}
+			if err := os.MkdirAll(filepath.Dir(own), 0755); err != nil {
+				return err
+			}
+			if _, err := copyFile(src, own); err != nil {
+				return fmt.Errorf("failed to copy archive for %s: %w", vol.Name, err)
+			}
+		}
+
+		info, err := os.Stat(own)
+		if err != nil {
+			return err
+		}
+		total += info.Size()
+		snapshot.Volumes[i].SizeBytes = info.Size()
+		snapshot.Volumes[i].SizeHuman = models.FormatSize(info.Size())
+	}
+
+	if snapshot.Metadata == nil {
+		snapshot.Metadata = make(map[string]string)
+	}
+	snapshot.Metadata["compacted_from"] = snapshot.ParentName
+	snapshot.ParentName = ""
+	snapshot.Incremental = false
+	snapshot.SizeBytes = total
+	snapshot.SizeHuman = models.FormatSize(total)
+
+	return m.saveMetadata(snapshot)
+}
@@ -0,0 +1,197 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+// writeChainSnapshot saves metadata for a snapshot with archives for only some volumes
+func writeChainSnapshot(t *testing.T, m *Manager, name, parent string, timestamp time.Time, stored map[string]string) {
+	t.Helper()
+
+	snap := &models.Snapshot{
+		Name:        name,
+		Timestamp:   timestamp,
+		Path:        filepath.Join(m.cfg.SnapshotDir, name),
+		ParentName:  parent,
+		Incremental: parent != "",
+		Volumes: []models.Volume{
+			{Name: "project_pgdata", ComposeName: "pgdata", DatastoreType: models.DatastorePostgres},
+			{Name: "project_uploads", ComposeName: "uploads", DatastoreType: models.DatastoreGeneric},
+		},
+	}
+	os.MkdirAll(snap.Path, 0755)
+	for vol, content := range stored {
+		writeTestArchive(t, filepath.Join(snap.Path, vol+".tar.gz"), map[string]string{"data": content})
+	}
+	if err := m.saveMetadata(snap); err != nil {
+		t.Fatalf("failed to save metadata: %v", err)
+	}
+}
+
+func TestCompact(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "dataclean-chain-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	m := NewManager(nil, &models.Config{SnapshotDir: tmpDir})
+	now := time.Now()
+	writeChainSnapshot(t, m, "base", "", now.Add(-3*time.Hour), map[string]string{"project_pgdata": "pg v1", "project_uploads": "files v1"})
+	writeChainSnapshot(t, m, "inc1", "base", now.Add(-2*time.Hour), map[string]string{"project_pgdata": "pg v2"})
+	writeChainSnapshot(t, m, "inc2", "inc1", now.Add(-time.Hour), map[string]string{"project_uploads": "files v2"})
+
+	// Archives resolve through the chain before compaction
+	inc2, _ := m.Get("inc2")
+	archive, err := m.resolveArchive(inc2, inc2.Volumes[0])
+	if err != nil || archive != filepath.Join(tmpDir, "inc1", "project_pgdata.tar.gz") {
+		t.Errorf("resolveArchive() = %s, %v", archive, err)
+	}
+
+	result, err := m.Compact("base", true)
+	if err != nil {
+		t.Fatalf("Compact() failed: %v", err)
+	}
+	if len(result.Compacted) != 1 || result.Compacted[0] != "inc2" {
+		t.Errorf("Compacted = %v, want [inc2]", result.Compacted)
+	}
+	if len(result.Deleted) != 1 || result.Deleted[0] != "inc1" {
+		t.Errorf("Deleted = %v, want [inc1]", result.Deleted)
+	}
+
+	inc2, err = m.Get("inc2")
+	if err != nil {
+		t.Fatalf("Get() failed: %v", err)
+	}
+	if inc2.Incremental || inc2.ParentName != "" || inc2.Metadata["compacted_from"] != "inc1" {
+		t.Errorf("inc2 should be a full snapshot, got %+v", inc2)
+	}
+
+	// The pgdata archive now lives in inc2 itself, with inc1's contents
+	entries, err := m.ArchiveEntries("inc2", "project_pgdata")
+	if err != nil {
+		t.Fatalf("ArchiveEntries() failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].SizeBytes != int64(len("pg v2")) {
+		t.Errorf("unexpected pgdata entries: %+v", entries)
+	}
+	if _, err := m.Get("base"); err != nil {
+		t.Error("base snapshot should be kept")
+	}
+
+	if _, err := m.Compact("base", false); err == nil {
+		t.Error("expected error when nothing is based on base")
+	}
+}
+
+func TestExpiredSnapshots_KeepsParents(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "dataclean-chain-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	m := NewManager(nil, &models.Config{SnapshotDir: tmpDir, RetentionDays: 7})
+	old := time.Now().AddDate(0, 0, -30)
+	writeChainSnapshot(t, m, "old-base", "", old, map[string]string{"project_pgdata": "pg", "project_uploads": "files"})
+	writeChainSnapshot(t, m, "old-alone", "", old, map[string]string{"project_pgdata": "pg", "project_uploads": "files"})
+	writeChainSnapshot(t, m, "recent", "old-base", time.Now(), map[string]string{"project_pgdata": "pg v2"})
+
+	expired, err := m.expiredSnapshots()
+	if err != nil {
+		t.Fatalf("expiredSnapshots() failed: %v", err)
+	}
+	if len(expired) != 1 || expired[0].Name != "old-alone" {
+		t.Errorf("expired = %v, want only old-alone", expired)
+	}
+}
+
+func TestExpiredSnapshots_ExpiresAt(t *testing.T) {
+	tmpDir := t.TempDir()
+	m := NewManager(nil, &models.Config{SnapshotDir: tmpDir})
+	for name, offset := range map[string]time.Duration{"past": -time.Hour, "future": time.Hour, "old": 0} {
+		writeChainSnapshot(t, m, name, "", time.Now().AddDate(0, 0, -30), map[string]string{"project_pgdata": "pg"})
+		if offset == 0 {
+			continue
+		}
+		snap, _ := m.Get(name)
+		expires := time.Now().Add(offset)
+		snap.ExpiresAt = &expires
+		if err := m.saveMetadata(snap); err != nil {
+			t.Fatalf("failed to save metadata: %v", err)
+		}
+	}
+
+	// No retention: only the snapshot past its own expiry goes
+	expired, err := m.expiredSnapshots()
+	if err != nil {
+		t.Fatalf("expiredSnapshots() failed: %v", err)
+	}
+	if len(expired) != 1 || expired[0].Name != "past" {
+		t.Errorf("expired = %v, want only past", expired)
+	}
+
+	// An expiry date outlives retention
+	m.cfg.RetentionDays = 7
+	expired, _ = m.expiredSnapshots()
+	var names []string
+	for _, s := range expired {
+		names = append(names, s.Name)
+	}
+	sort.Strings(names)
+	if strings.Join(names, ",") != "old,past" {
+		t.Errorf("expired = %v, want old and past", names)
+	}
+}
+
+func TestLinkUnchanged(t *testing.T) {
+	tmpDir := t.TempDir()
+	m := &Manager{cfg: &models.Config{SnapshotDir: tmpDir}}
+
+	writeChainSnapshot(t, m, "monday", "", time.Now(), map[string]string{"project_pgdata": "v1"})
+	monday, _ := m.Get("monday")
+	prevVol := monday.Volumes[0]
+	prevVol.Fingerprint = "abc"
+	prevVol.Checksum = "sha256:1"
+	prev := previousArchive{monday, prevVol}
+
+	os.MkdirAll(filepath.Join(tmpDir, "tuesday"), 0755)
+	tarPath := filepath.Join(tmpDir, "tuesday", "project_pgdata.tar.gz")
+
+	changed := models.Volume{Name: "project_pgdata", Fingerprint: "def"}
+	if m.linkUnchanged(prev, &changed, tarPath) {
+		t.Fatal("linked a volume whose fingerprint changed")
+	}
+	if _, err := os.Stat(tarPath); !os.IsNotExist(err) {
+		t.Error("a refused link should leave nothing behind")
+	}
+
+	vol := models.Volume{Name: "project_pgdata", Fingerprint: "abc"}
+	if !m.linkUnchanged(prev, &vol, tarPath) {
+		t.Fatal("expected unchanged volume to be linked")
+	}
+	if vol.LinkedFrom != "monday" || vol.Checksum != "sha256:1" {
+		t.Errorf("linked volume = %+v", vol)
+	}
+
+	src, _ := os.Stat(filepath.Join(tmpDir, "monday", "project_pgdata.tar.gz"))
+	dst, _ := os.Stat(tarPath)
+	if !os.SameFile(src, dst) {
+		t.Error("archive was copied, not hard-linked")
+	}
+
+	// The link keeps the data after the earlier snapshot is gone
+	if err := m.Delete("monday"); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+	if _, err := os.Stat(tarPath); err != nil {
+		t.Errorf("linked archive lost with its source snapshot: %v", err)
+	}
+}
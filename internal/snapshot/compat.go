@@ -0,0 +1,69 @@
+package snapshot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+// checkReadable refuses a snapshot whose metadata says it needs a newer
+// dataclean than this one, before anything is touched
+func checkReadable(snapshot *models.Snapshot) error {
+	if snapshot.MinReader == "" || compareVersions(snapshot.MinReader, Version) <= 0 {
+		return nil
+	}
+	writer := snapshot.WrittenBy
+	if writer == "" {
+		writer = "a newer release"
+	}
+	return fmt.Errorf("snapshot %s was written by dataclean %s and needs %s or later to restore; this is %s. "+
+		"Upgrade dataclean, or take the snapshot again with this release",
+		snapshot.Name, writer, snapshot.MinReader, Version)
+}
+
+// compareVersions compares two release versions such as 1.4.0 or v1.4.0-rc1
+// by their numeric parts, returning -1, 0, or 1. Versions that don't start
+// with a number, like dev builds, compare equal to everything.
+func compareVersions(a, b string) int {
+	pa, okA := versionParts(a)
+	pb, okB := versionParts(b)
+	if !okA || !okB {
+		return 0
+	}
+	for i := 0; i < max(len(pa), len(pb)); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// versionParts returns the dot-separated numbers of a version, ignoring a
+// leading v and anything after a '-' or '+'
+func versionParts(v string) ([]int, bool) {
+	v = strings.TrimPrefix(v, "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	var parts []int
+	for _, field := range strings.Split(v, ".") {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, false
+		}
+		parts = append(parts, n)
+	}
+	return parts, true
+}
@@ -0,0 +1,43 @@
+package snapshot
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.0.0", "1.0.0", 0},
+		{"1.2.0", "1.10.0", -1},
+		{"v2.0.0", "1.9.9", 1},
+		{"1.4", "1.4.0", 0},
+		{"1.5.0-rc1", "1.4.2", 1},
+		{"1.5.0", "dev", 0},
+	}
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestCheckReadable(t *testing.T) {
+	prev := Version
+	Version = "1.2.0"
+	defer func() { Version = prev }()
+
+	for _, minReader := range []string{"", "1.0.0", "1.2.0"} {
+		if err := checkReadable(&models.Snapshot{Name: "s", MinReader: minReader}); err != nil {
+			t.Errorf("min_reader %q: unexpected error %v", minReader, err)
+		}
+	}
+	err := checkReadable(&models.Snapshot{Name: "s", WrittenBy: "1.4.1", MinReader: "1.4.0"})
+	if err == nil || !strings.Contains(err.Error(), "needs 1.4.0 or later") {
+		t.Errorf("checkReadable() = %v, want an upgrade error", err)
+	}
+}
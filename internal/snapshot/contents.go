@@ -0,0 +1,72 @@
+package snapshot
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+// ArchiveContents summarizes a volume's archive: entry counts, the first and
+// last n entries, and the size of each top-level directory
+func (m *Manager) ArchiveContents(name, volume string, n int) (*models.ArchiveContents, error) {
+	contents := &models.ArchiveContents{Volume: volume}
+	usage := make(map[string]*models.DirUsage)
+	var last []models.ArchiveEntry // ring of the newest n past the first n
+	seen := 0
+
+	err := m.ListArchiveContents(name, volume, func(e models.ArchiveEntry) error {
+		if e.IsDir {
+			contents.Dirs++
+		} else {
+			contents.Files++
+			contents.Bytes += e.SizeBytes
+		}
+
+		top, _, nested := strings.Cut(e.Path, "/")
+		if !nested && !e.IsDir {
+			top = "."
+		}
+		u, ok := usage[top]
+		if !ok {
+			u = &models.DirUsage{Path: top}
+			usage[top] = u
+		}
+		u.Entries++
+		u.Bytes += e.SizeBytes
+
+		switch {
+		case n <= 0:
+		case seen < n:
+			contents.First = append(contents.First, e)
+		case len(last) < n:
+			last = append(last, e)
+		default:
+			last[(seen-n)%n] = e
+		}
+		seen++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Unroll the ring so Last is in archive order
+	if extra := seen - n; n > 0 && extra > n {
+		start := extra % n
+		last = append(last[start:], last[:start]...)
+	}
+	contents.Last = last
+
+	for _, u := range usage {
+		contents.TopLevel = append(contents.TopLevel, *u)
+	}
+	sort.Slice(contents.TopLevel, func(i, j int) bool {
+		a, b := contents.TopLevel[i], contents.TopLevel[j]
+		if a.Bytes != b.Bytes {
+			return a.Bytes > b.Bytes
+		}
+		return a.Path < b.Path
+	})
+	return contents, nil
+}
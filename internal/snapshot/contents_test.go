@@ -0,0 +1,77 @@
+package snapshot
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+func TestArchiveContents(t *testing.T) {
+	tmpDir := t.TempDir()
+	snapshotDir := filepath.Join(tmpDir, "big")
+	os.MkdirAll(snapshotDir, 0755)
+	os.WriteFile(filepath.Join(snapshotDir, "metadata.yaml"), []byte("name: big\nvolumes:\n  - name: project_pgdata\n    datastore_type: postgres\n"), 0644)
+
+	// PG_VERSION, base/, 7 files under base/, pg_wal/ with one large segment
+	f, _ := os.Create(filepath.Join(snapshotDir, "project_pgdata.tar.gz"))
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	add := func(name string, size int64, typ byte) {
+		tw.WriteHeader(&tar.Header{Name: "./" + name, Size: size, Typeflag: typ, Mode: 0644})
+		tw.Write(make([]byte, size))
+	}
+	add("", 0, tar.TypeDir)
+	add("PG_VERSION", 3, tar.TypeReg)
+	add("base", 0, tar.TypeDir)
+	for i := 0; i < 7; i++ {
+		add(fmt.Sprintf("base/%d", i), 10, tar.TypeReg)
+	}
+	add("pg_wal", 0, tar.TypeDir)
+	add("pg_wal/000000010000000000000001", 1000, tar.TypeReg)
+	tw.Close()
+	gz.Close()
+	f.Close()
+
+	m := &Manager{cfg: &models.Config{SnapshotDir: tmpDir}}
+	contents, err := m.ArchiveContents("big", "project_pgdata", 3)
+	if err != nil {
+		t.Fatalf("ArchiveContents() failed: %v", err)
+	}
+
+	if contents.Files != 9 || contents.Dirs != 2 || contents.Bytes != 1073 {
+		t.Errorf("counts = %d files, %d dirs, %d bytes; want 9, 2, 1073", contents.Files, contents.Dirs, contents.Bytes)
+	}
+	paths := func(entries []models.ArchiveEntry) []string {
+		var p []string
+		for _, e := range entries {
+			p = append(p, e.Path)
+		}
+		return p
+	}
+	if got := fmt.Sprint(paths(contents.First)); got != "[PG_VERSION base base/0]" {
+		t.Errorf("First = %s", got)
+	}
+	if got := fmt.Sprint(paths(contents.Last)); got != "[base/6 pg_wal pg_wal/000000010000000000000001]" {
+		t.Errorf("Last = %s", got)
+	}
+
+	want := []models.DirUsage{
+		{Path: "pg_wal", Entries: 2, Bytes: 1000},
+		{Path: "base", Entries: 8, Bytes: 70},
+		{Path: ".", Entries: 1, Bytes: 3},
+	}
+	if fmt.Sprint(contents.TopLevel) != fmt.Sprint(want) {
+		t.Errorf("TopLevel = %v, want %v", contents.TopLevel, want)
+	}
+
+	// Small archives don't repeat entries in Last
+	small, _ := m.ArchiveContents("big", "project_pgdata", 20)
+	if len(small.First) != 11 || len(small.Last) != 0 {
+		t.Errorf("n=20: %d first, %d last; want 11 and 0", len(small.First), len(small.Last))
+	}
+}
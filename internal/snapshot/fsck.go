@@ -0,0 +1,240 @@
+package snapshot
+
+import (
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+// fsckGrace is how recently a leftover may have been written for fsck to
+// leave it be: a dataclean still running may be writing it
+const fsckGrace = time.Hour
+
+// fsck collects the problems found in one run, repairing them as they are
+// found when asked to
+type fsck struct {
+	report *models.FsckReport
+	repair bool
+	now    time.Time
+}
+
+// add records a problem. fix, when set, is how repair resolves it.
+func (f *fsck) add(p models.StoreProblem, fix func() error) {
+	if fix != nil && f.repair {
+		if err := fix(); err != nil {
+			p.Error = err.Error()
+		} else {
+			p.Repaired = true
+		}
+	}
+	f.report.Problems = append(f.report.Problems, p)
+}
+
+// leftover records a problem repaired by removing p.Path, unless it was
+// written too recently to be sure nothing is using it
+func (f *fsck) leftover(p models.StoreProblem, modTime time.Time) {
+	if f.now.Sub(modTime) < fsckGrace {
+		p.Problem += " (written in the last hour, so left alone: it may still be in use)"
+		f.add(p, nil)
+		return
+	}
+	p.Repair = "remove " + filepath.Base(p.Path)
+	f.add(p, func() error { return os.RemoveAll(p.Path) })
+}
+
+// Fsck checks the whole snapshot store: every metadata.yaml parses, every
+// volume's archive exists (through the parent chain for incremental
+// snapshots) and reads back with its recorded checksum, parents exist, and
+// nothing is left over from interrupted operations: directories without
+// metadata, archives no snapshot refers to, and temporary files. With repair
+// set, leftovers older than an hour are removed; everything else is only
+// reported, since fixing it needs the original data.
+func (m *Manager) Fsck(repair bool, now time.Time) (*models.FsckReport, error) {
+	if repair {
+		if err := m.cfg.Writable(); err != nil {
+			return nil, err
+		}
+	}
+	f := &fsck{report: &models.FsckReport{}, repair: repair, now: now}
+	entries, err := os.ReadDir(m.cfg.SnapshotDir)
+	if os.IsNotExist(err) {
+		return f.report, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := make(map[string]*models.Snapshot)
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		name, dir := e.Name(), filepath.Join(m.cfg.SnapshotDir, e.Name())
+		if !m.hasMetadata(name) {
+			// _wal, _aof, and other internal directories hold no snapshots
+			if !strings.HasPrefix(name, "_") {
+				m.fsckStray(f, name, dir)
+			}
+			continue
+		}
+		f.report.Snapshots++
+		s, err := m.loadMetadata(dir)
+		if err != nil {
+			f.add(models.StoreProblem{Snapshot: name, Path: filepath.Join(dir, "metadata.yaml"), Problem: err.Error()}, nil)
+			continue
+		}
+		snapshots[name] = s
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Archives the snapshots refer to, by absolute path
+	referenced := make(map[string]bool)
+	for _, s := range snapshots {
+		for _, vol := range s.Volumes {
+			referenced[absPath(archivePath(s.Path, vol))] = true
+		}
+	}
+
+	verified := make(map[string]error)
+	for _, name := range names {
+		s := snapshots[name]
+		if s.ParentName != "" && snapshots[s.ParentName] == nil {
+			f.add(models.StoreProblem{Snapshot: name, Problem: fmt.Sprintf("parent snapshot %s is missing", s.ParentName)}, nil)
+			continue // every inherited archive is missing with it
+		}
+		for _, vol := range s.Volumes {
+			archive, err := m.resolveArchive(s, vol)
+			if err != nil {
+				f.add(models.StoreProblem{Snapshot: name, Problem: err.Error()}, nil)
+				continue
+			}
+			// Archives inherited or linked by several snapshots are read once
+			key := archive + "@" + vol.Checksum
+			err, done := verified[key]
+			if !done {
+				_, err = verifyArchive(archive, vol.Checksum)
+				verified[key] = err
+				f.report.Archives++
+			}
+			if err != nil {
+				f.add(models.StoreProblem{Snapshot: name, Path: archive, Problem: fmt.Sprintf("volume %s: %v", vol.Name, err)}, nil)
+			} else if vol.Checksum == "" {
+				f.add(models.StoreProblem{Snapshot: name, Path: archive, Problem: fmt.Sprintf("volume %s has no checksum to verify (run 'dataclean migrate-store')", vol.Name)}, nil)
+			}
+		}
+		m.fsckOrphans(f, name, s.Path, referenced)
+	}
+
+	// Routed archives live in <dir>/<snapshot> under each storage rule's dir
+	dirs := make(map[string]bool)
+	for _, rule := range m.cfg.StorageRules {
+		if dirs[rule.Dir] {
+			continue
+		}
+		dirs[rule.Dir] = true
+		entries, err := os.ReadDir(rule.Dir)
+		if err != nil {
+			continue // an external disk that isn't mounted
+		}
+		for _, e := range entries {
+			path := filepath.Join(rule.Dir, e.Name())
+			if !e.IsDir() {
+				continue
+			}
+			if snapshots[e.Name()] == nil {
+				// Unreadable metadata is reported already
+				if info, err := e.Info(); err == nil && !m.hasMetadata(e.Name()) {
+					f.leftover(models.StoreProblem{Snapshot: e.Name(), Path: path, Problem: "archive directory of a snapshot that doesn't exist"}, info.ModTime())
+				}
+				continue
+			}
+			m.fsckOrphans(f, e.Name(), path, referenced)
+		}
+	}
+
+	for _, root := range append([]string{m.cfg.SnapshotDir}, slices.Sorted(maps.Keys(dirs))...) {
+		m.fsckTemp(f, root)
+	}
+	m.fsckRuns(f)
+	return f.report, nil
+}
+
+// fsckRuns reports the workspaces of dataclean runs that ended without
+// removing them. Their process is known to be gone, so repair needs no grace.
+func (m *Manager) fsckRuns(f *fsck) {
+	runsMu.Lock()
+	stale := m.staleRuns(f.now)
+	runsMu.Unlock()
+	for _, dir := range stale {
+		p := models.StoreProblem{Path: dir, Problem: "workspace of a dataclean run that has ended", Repair: "remove " + filepath.Base(dir)}
+		f.add(p, func() error { return os.RemoveAll(dir) })
+	}
+}
+
+// fsckStray reports a directory in the store without metadata
+func (m *Manager) fsckStray(f *fsck, name, dir string) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return
+	}
+	if flat, err := flatSnapshot(name, dir); err == nil && flat != nil {
+		f.add(models.StoreProblem{Snapshot: name, Path: dir,
+			Problem: fmt.Sprintf("no metadata.yaml, but holds %d archive(s): left by an interrupted snapshot or an old release ('dataclean migrate-store' adopts it)", len(flat.Volumes))}, nil)
+		return
+	}
+	f.leftover(models.StoreProblem{Snapshot: name, Path: dir, Problem: "no metadata.yaml: left by an interrupted snapshot"}, info.ModTime())
+}
+
+// fsckOrphans reports archives in dir that no snapshot refers to
+func (m *Manager) fsckOrphans(f *fsck, name, dir string, referenced map[string]bool) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		path := filepath.Join(dir, e.Name())
+		if !e.Type().IsRegular() || !strings.HasSuffix(e.Name(), ".tar.gz") || referenced[absPath(path)] {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		f.leftover(models.StoreProblem{Snapshot: name, Path: path, Problem: "archive not referenced by the snapshot's metadata"}, info.ModTime())
+	}
+}
+
+// fsckTemp reports temporary files left by interrupted writes under root
+func (m *Manager) fsckTemp(f *fsck, root string) {
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() && path == filepath.Join(m.cfg.SnapshotDir, runsDirName) {
+			return filepath.SkipDir // see fsckRuns
+		}
+		if err != nil || d.IsDir() || !strings.HasSuffix(d.Name(), ".tmp") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		f.leftover(models.StoreProblem{Path: path, Problem: "temporary file left by an interrupted write"}, info.ModTime())
+		return nil
+	})
+}
+
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
@@ -0,0 +1,78 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+func TestFsck(t *testing.T) {
+	tmpDir := t.TempDir()
+	m := NewManager(nil, &models.Config{SnapshotDir: tmpDir})
+	now := time.Now()
+	writeChainSnapshot(t, m, "base", "", now.Add(-3*time.Hour), map[string]string{"project_pgdata": "pg v1", "project_uploads": "files v1"})
+	writeChainSnapshot(t, m, "inc", "base", now.Add(-2*time.Hour), map[string]string{"project_pgdata": "pg v2"})
+
+	report, err := m.Fsck(false, now)
+	if err != nil {
+		t.Fatalf("Fsck() failed: %v", err)
+	}
+	if report.Snapshots != 2 || report.Archives != 3 {
+		t.Errorf("Fsck() checked %d snapshots and %d archives, want 2 and 3", report.Snapshots, report.Archives)
+	}
+	// The test snapshots have no checksums, which is all fsck finds
+	for _, p := range report.Problems {
+		if !strings.Contains(p.Problem, "no checksum") {
+			t.Errorf("unexpected problem in a healthy store: %+v", p)
+		}
+	}
+
+	// Break the store in every way fsck looks for
+	writeChainSnapshot(t, m, "orphaned", "gone", now.Add(-time.Hour), nil)
+	os.WriteFile(filepath.Join(tmpDir, "base", "project_pgdata.tar.gz"), []byte("not gzip"), 0644)
+	writeTestArchive(t, filepath.Join(tmpDir, "base", "stray.tar.gz"), map[string]string{"data": "x"})
+	os.MkdirAll(filepath.Join(tmpDir, "interrupted"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, "state.yaml.tmp"), nil, 0644)
+	os.WriteFile(filepath.Join(tmpDir, "broken.tmp"), nil, 0644)
+	os.MkdirAll(filepath.Join(tmpDir, "unreadable"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, "unreadable", "metadata.yaml"), []byte("volumes: {"), 0644)
+	old := now.Add(-2 * fsckGrace)
+	for _, path := range []string{"base/stray.tar.gz", "interrupted", "state.yaml.tmp"} {
+		os.Chtimes(filepath.Join(tmpDir, path), old, old)
+	}
+
+	report, err = m.Fsck(true, now)
+	if err != nil {
+		t.Fatalf("Fsck(repair) failed: %v", err)
+	}
+	found := func(substr string) *models.StoreProblem {
+		for i, p := range report.Problems {
+			if strings.Contains(p.Problem, substr) || strings.HasSuffix(p.Path, substr) {
+				return &report.Problems[i]
+			}
+		}
+		t.Errorf("no problem matching %q in %+v", substr, report.Problems)
+		return &models.StoreProblem{}
+	}
+	for _, substr := range []string{"parent snapshot gone is missing", "not readable", "unreadable/metadata.yaml"} {
+		if p := found(substr); p.Repaired || p.Repair != "" {
+			t.Errorf("%q should be reported, not repaired: %+v", substr, p)
+		}
+	}
+	for _, path := range []string{"base/stray.tar.gz", "interrupted", "state.yaml.tmp"} {
+		if p := found(filepath.Base(path)); !p.Repaired {
+			t.Errorf("%s should be repaired: %+v", path, p)
+		}
+		if _, err := os.Stat(filepath.Join(tmpDir, path)); !os.IsNotExist(err) {
+			t.Errorf("%s still exists after repair", path)
+		}
+	}
+	// Written just now, so possibly still in use
+	if p := found("broken.tmp"); p.Repaired {
+		t.Errorf("a fresh temporary file was removed: %+v", p)
+	}
+}
@@ -0,0 +1,94 @@
+package snapshot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+// groupsDirName holds one <name>.yaml per snapshot group; the reserved prefix
+// keeps it apart from snapshots
+const groupsDirName = "_groups"
+
+// SaveGroup records a snapshot group, replacing any of the same name
+func (m *Manager) SaveGroup(group *models.SnapshotGroup) error {
+	if err := checkName(group.Name); err != nil {
+		return err
+	}
+	dir := filepath.Join(m.cfg.SnapshotDir, groupsDirName)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(group)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dir, group.Name+".yaml")
+	tmp := tempPath(path)
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Group returns a snapshot group, or nil if there is none by that name
+func (m *Manager) Group(name string) (*models.SnapshotGroup, error) {
+	if err := checkName(name); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(m.cfg.SnapshotDir, groupsDirName, name+".yaml"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var group models.SnapshotGroup
+	if err := yaml.Unmarshal(data, &group); err != nil {
+		return nil, fmt.Errorf("invalid group record %s: %w", name, err)
+	}
+	return &group, nil
+}
+
+// Groups returns every snapshot group, by name
+func (m *Manager) Groups() ([]*models.SnapshotGroup, error) {
+	entries, err := os.ReadDir(filepath.Join(m.cfg.SnapshotDir, groupsDirName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var groups []*models.SnapshotGroup
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".yaml")
+		if e.IsDir() || !ok {
+			continue
+		}
+		group, err := m.Group(name)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, group)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Name < groups[j].Name })
+	return groups, nil
+}
+
+// DeleteGroup removes a snapshot group's record; its snapshots are kept
+func (m *Manager) DeleteGroup(name string) error {
+	if err := checkName(name); err != nil {
+		return err
+	}
+	err := os.Remove(filepath.Join(m.cfg.SnapshotDir, groupsDirName, name+".yaml"))
+	if os.IsNotExist(err) {
+		return fmt.Errorf("no group named '%s'", name)
+	}
+	return err
+}
@@ -0,0 +1,49 @@
+package snapshot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+func TestGroups(t *testing.T) {
+	m := NewManager(nil, &models.Config{SnapshotDir: t.TempDir()})
+	taken := time.Now().UTC().Truncate(time.Second)
+
+	for _, g := range []*models.SnapshotGroup{
+		{Name: "release-12", Timestamp: taken, Members: []models.GroupMember{{Snapshot: "seeded", Taken: taken}, {Stack: "api", Snapshot: "v12", Taken: taken}}},
+		{Name: "demo", Timestamp: taken, Members: []models.GroupMember{{Snapshot: "demo"}}},
+	} {
+		if err := m.SaveGroup(g); err != nil {
+			t.Fatalf("SaveGroup(%s) failed: %v", g.Name, err)
+		}
+	}
+
+	got, err := m.Group("release-12")
+	if err != nil || got == nil {
+		t.Fatalf("Group() = %v, %v", got, err)
+	}
+	if len(got.Members) != 2 || got.Members[1].String() != "api:v12" || got.Members[0].String() != "seeded" || !got.Members[1].Taken.Equal(taken) {
+		t.Errorf("Group() members = %+v", got.Members)
+	}
+
+	groups, err := m.Groups()
+	if err != nil || len(groups) != 2 || groups[0].Name != "demo" || groups[1].Name != "release-12" {
+		t.Fatalf("Groups() = %v, %v; want demo, release-12", groups, err)
+	}
+	// The records aren't snapshots
+	if snapshots, err := m.List(); err != nil || len(snapshots) != 0 {
+		t.Errorf("List() = %v, %v; want no snapshots", snapshots, err)
+	}
+
+	if err := m.DeleteGroup("demo"); err != nil {
+		t.Fatalf("DeleteGroup() failed: %v", err)
+	}
+	if got, err := m.Group("demo"); got != nil || err != nil {
+		t.Errorf("Group(demo) after delete = %v, %v; want nil", got, err)
+	}
+	if err := m.DeleteGroup("demo"); err == nil {
+		t.Error("DeleteGroup() of a missing group should fail")
+	}
+}
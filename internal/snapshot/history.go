@@ -0,0 +1,73 @@
+package snapshot
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+// historyFile is an append-only log of restores, one JSON object per line.
+// Like the state file, List skips it.
+const historyFile = "history.log"
+
+// History returns every logged restore, oldest first
+func (m *Manager) History() ([]models.HistoryEntry, error) {
+	f, err := os.Open(filepath.Join(m.cfg.SnapshotDir, historyFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []models.HistoryEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e models.HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue // a line cut short by a crash
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// logRestore appends the outcome of a restore to the history log
+func (m *Manager) logRestore(result *models.RestoreResult, restoreErr error) error {
+	entry := models.HistoryEntry{Time: time.Now(), Snapshot: result.Snapshot, Status: models.RestoreOK}
+	for _, v := range result.Volumes {
+		entry.Volumes = append(entry.Volumes, v.Volume)
+	}
+	var invalid *ValidationError
+	switch {
+	case errors.As(restoreErr, &invalid):
+		entry.Status = models.RestoreSuspect
+		entry.Problems = invalid.Problems
+	case restoreErr != nil:
+		entry.Status = models.RestoreFailed
+		entry.Problems = []string{restoreErr.Error()}
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(m.cfg.SnapshotDir, 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filepath.Join(m.cfg.SnapshotDir, historyFile), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,51 @@
+package snapshot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+func TestRestoreHistory(t *testing.T) {
+	tmpDir := t.TempDir()
+	m := &Manager{cfg: &models.Config{SnapshotDir: tmpDir}}
+
+	if entries, err := m.History(); err != nil || len(entries) != 0 {
+		t.Fatalf("History() = %v, %v, want empty", entries, err)
+	}
+
+	result := &models.RestoreResult{Snapshot: "seeded", Volumes: []models.VolumeResult{{Volume: "app_pgdata"}}}
+	m.logRestore(result, nil)
+	m.logRestore(result, &ValidationError{Problems: []string{`app_pgdata: "SELECT count(*) FROM users" returned 0`}})
+	m.logRestore(&models.RestoreResult{Snapshot: "broken"}, fmt.Errorf("snapshot broken failed verification"))
+
+	// A line cut short by a crash doesn't hide the rest
+	f, _ := os.OpenFile(filepath.Join(tmpDir, historyFile), os.O_WRONLY|os.O_APPEND, 0644)
+	f.WriteString(`{"time":"2024-05`)
+	f.Close()
+
+	entries, err := m.History()
+	if err != nil {
+		t.Fatalf("History() failed: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("History() returned %d entries, want 3", len(entries))
+	}
+	want := []string{models.RestoreOK, models.RestoreSuspect, models.RestoreFailed}
+	for i, e := range entries {
+		if e.Status != want[i] {
+			t.Errorf("entry %d status = %s, want %s", i, e.Status, want[i])
+		}
+	}
+	if len(entries[1].Problems) != 1 || entries[1].Volumes[0] != "app_pgdata" {
+		t.Errorf("suspect entry = %+v, want its volume and problem", entries[1])
+	}
+
+	// The log never shows up as a snapshot
+	if snapshots, _ := m.List(); len(snapshots) != 0 {
+		t.Errorf("List() = %v, want no snapshots", snapshots)
+	}
+}
@@ -0,0 +1,194 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/stackgen-cli/dataclean/internal/datastore"
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+const (
+	defaultHealthTimeout = 60 * time.Second // Bounds the wait for a datastore to come back up
+	healthPollInterval   = time.Second      // Delay between health command attempts
+)
+
+// quiesce runs each volume's quiesce command (e.g. a flush) before its container stops
+func (m *Manager) quiesce(volumes []models.Volume) error {
+	for _, vol := range volumes {
+		script := datastore.For(vol.DatastoreType).Quiesce
+		if script == "" {
+			continue
+		}
+		container, err := m.client.ResolveContainer(vol)
+		if err != nil || !m.client.IsRunning(container) {
+			continue // not running, nothing to flush
+		}
+		if _, err := m.client.ExecOutput(container, script, datastore.CredentialEnv(vol.Credentials)...); err != nil {
+			return fmt.Errorf("failed to quiesce volume %s: %w", vol.Name, err)
+		}
+	}
+	return nil
+}
+
+// stopContainers stops the containers using the volumes, or with quiesce:
+// project every running service of the compose project, and returns a func
+// that starts what it stopped again. Running containers outside the project
+// that mount a volume are stopped too and recorded in its Foreign, unless
+// foreign_containers: refuse, which fails instead. Volumes whose container
+// had to be killed after its stop timeout are marked Killed.
+func (m *Manager) stopContainers(volumes []models.Volume) (func(), error) {
+	for i, vol := range volumes {
+		_, foreign, _ := m.client.VolumeUsers(vol)
+		if len(foreign) > 0 && m.cfg.ForeignContainers == models.ForeignRefuse {
+			return nil, fmt.Errorf("volume %s is in use by container(s) outside the compose project: %s; stop them first, or set foreign_containers: stop",
+				vol.Name, strings.Join(foreign, ", "))
+		}
+		volumes[i].Foreign = foreign
+	}
+
+	if m.cfg.Quiesce == models.QuiesceProject {
+		var timeout time.Duration
+		for _, v := range volumes {
+			timeout = max(timeout, m.stopTimeout(v))
+		}
+		services, killed, err := m.client.StopProject(m.cfg, timeout)
+		if err != nil {
+			return nil, err
+		}
+		for i := range volumes {
+			volumes[i].Killed = volumes[i].Service != "" && slices.Contains(killed, volumes[i].Service)
+		}
+		// compose stop leaves containers outside the project running
+		stopped, _ := m.client.StopContainers(volumes, m.stopTimeout)
+		return func() {
+			m.client.StartServices(m.cfg, services)
+			m.client.StartContainers(stopped)
+		}, nil
+	}
+	stopped, killed := m.client.StopContainers(volumes, m.stopTimeout)
+	for i := range volumes {
+		volumes[i].Killed = slices.Contains(killed, volumes[i].Name)
+	}
+	return func() { m.client.StartContainers(stopped) }, nil
+}
+
+// stopTimeout is how long a volume's container gets to shut down: the
+// stop_timeouts entry for its datastore type, else the datastore's default
+func (m *Manager) stopTimeout(vol models.Volume) time.Duration {
+	if timeout, ok := m.cfg.StopTimeouts[vol.DatastoreType]; ok {
+		return timeout
+	}
+	return datastore.For(vol.DatastoreType).StopTimeout
+}
+
+// Hold flushes and stops what a snapshot of the volumes would, and returns a
+// func that starts it again. Snapshots taken meanwhile find the containers
+// stopped and leave them so, which lets snapshots of several projects share
+// one point in time.
+func (m *Manager) Hold(volumes []models.Volume) (func(), error) {
+	if err := m.quiesce(volumes); err != nil {
+		return nil, err
+	}
+	return m.stopContainers(volumes)
+}
+
+// healthTimeout bounds waits for a datastore to come back up
+func (m *Manager) healthTimeout() time.Duration {
+	if m.cfg.HealthTimeout <= 0 {
+		return defaultHealthTimeout
+	}
+	return m.cfg.HealthTimeout
+}
+
+// waitHealthy blocks until every volume's datastore passes its health
+// command, then runs restore hooks when afterRestore is set
+func (m *Manager) waitHealthy(volumes []models.Volume, afterRestore bool) error {
+	for _, vol := range volumes {
+		strategy := datastore.For(vol.DatastoreType)
+		if strategy.Health == "" && (!afterRestore || strategy.Restore == "") {
+			continue
+		}
+		container, err := m.client.ResolveContainer(vol)
+		if err != nil || !m.client.IsRunning(container) {
+			continue // no running container to gate on
+		}
+		env := datastore.CredentialEnv(vol.Credentials)
+
+		if strategy.Health != "" {
+			if err := m.pollHealth(context.Background(), container, strategy.Health, env); err != nil {
+				return fmt.Errorf("volume %s: %w", vol.Name, err)
+			}
+		}
+
+		if afterRestore && strategy.Restore != "" {
+			if _, err := m.client.ExecOutput(container, strategy.Restore, env...); err != nil {
+				return fmt.Errorf("restore hook failed for volume %s: %w", vol.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// postRestoreExec runs each service's post_restore_exec command in its
+// container, in service order, once Docker reports the container healthy
+func (m *Manager) postRestoreExec() error {
+	for _, service := range slices.Sorted(maps.Keys(m.cfg.PostRestoreExec)) {
+		container, err := m.client.ResolveContainer(models.Volume{Service: service})
+		if err != nil {
+			return fmt.Errorf("post_restore_exec for %s: %w", service, err)
+		}
+		if err := m.awaitContainerHealthy(container); err != nil {
+			return fmt.Errorf("post_restore_exec for %s: %w", service, err)
+		}
+		if _, err := m.client.ExecOutput(container, m.cfg.PostRestoreExec[service]); err != nil {
+			return fmt.Errorf("post_restore_exec for %s failed: %w", service, err)
+		}
+	}
+	return nil
+}
+
+// awaitContainerHealthy waits, up to the health timeout, for a container's
+// healthcheck to pass. Containers without one are taken as healthy.
+func (m *Manager) awaitContainerHealthy(container string) error {
+	timeout := m.healthTimeout()
+	deadline := time.Now().Add(timeout)
+	for {
+		health, err := m.client.ContainerHealth(container)
+		if err != nil {
+			return err
+		}
+		if health == "" || health == "healthy" {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("container %s not healthy after %s (%s)", container, timeout, health)
+		}
+		time.Sleep(healthPollInterval)
+	}
+}
+
+// pollHealth runs a health command in a container until it succeeds, the
+// health timeout passes, or ctx is cancelled
+func (m *Manager) pollHealth(ctx context.Context, container, health string, env []string) error {
+	timeout := m.healthTimeout()
+	deadline := time.Now().Add(timeout)
+	for {
+		_, err := m.client.ExecOutput(container, health, env...)
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("datastore not healthy after %s: %w", timeout, err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(healthPollInterval):
+		}
+	}
+}
@@ -1,19 +1,27 @@
 package snapshot
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"time"
 
 	"gopkg.in/yaml.v3"
 
+	"github.com/stackgen-cli/dataclean/internal/datastore"
 	"github.com/stackgen-cli/dataclean/internal/docker"
+	"github.com/stackgen-cli/dataclean/internal/events"
 	"github.com/stackgen-cli/dataclean/internal/models"
+	"github.com/stackgen-cli/dataclean/internal/plugin"
 )
 
+// Version is the dataclean release recorded in the metadata of new snapshots
+var Version string
+
 // Manager handles snapshot operations
 type Manager struct {
 	client *docker.Client
@@ -25,18 +33,34 @@ type CreateOptions struct {
 	Tags        []string
 	Description string
 	Metadata    map[string]string
-	Incremental bool    // Create incremental snapshot
-	ParentName  string  // Name of parent snapshot for incremental
+	Incremental bool          // Create incremental snapshot
+	ParentName  string        // Name of parent snapshot for incremental
+	Logical     bool          // Also store SQL dumps for Postgres/MySQL volumes
+	Tables      bool          // Record table/collection row counts in metadata
+	ExportAll   bool          // Export every volume, even ones whose fingerprint is unchanged
+	Runtime     bool          // Also record docker inspect output and server settings under runtime/
+	QuietWait   time.Duration // How long to wait for writes to volumes that stay running (default: quiet_wait)
+	ExpiresAt   time.Time     // When cleanup may delete the snapshot, overriding retention_days
 }
 
-// NewManager creates a new snapshot manager
+// NewManager creates a new snapshot manager. The client retries docker
+// commands as the config's docker_retry says.
 func NewManager(client *docker.Client, cfg *models.Config) *Manager {
+	if client != nil {
+		client.SetRetryPolicy(cfg.DockerRetry)
+	}
 	return &Manager{
 		client: client,
 		cfg:    cfg,
 	}
 }
 
+// publish announces an event in this manager's project on the event bus
+func (m *Manager) publish(e events.Event) {
+	e.Config = m.cfg
+	events.Publish(e)
+}
+
 // Create creates a new snapshot of the specified volumes
 func (m *Manager) Create(name string, volumes []models.Volume) (*models.Snapshot, error) {
 	return m.CreateWithOptions(name, volumes, CreateOptions{})
@@ -44,34 +68,134 @@ func (m *Manager) Create(name string, volumes []models.Volume) (*models.Snapshot
 
 // CreateWithOptions creates a new snapshot with additional options
 func (m *Manager) CreateWithOptions(name string, volumes []models.Volume, opts CreateOptions) (*models.Snapshot, error) {
+	if err := m.ValidateName(name); err != nil {
+		return nil, err
+	}
+	return m.create(name, volumes, opts)
+}
+
+// create is CreateWithOptions without name validation, for backups under a
+// reserved prefix
+func (m *Manager) create(name string, volumes []models.Volume, opts CreateOptions) (*models.Snapshot, error) {
+	if err := m.cfg.Writable(); err != nil {
+		return nil, err
+	}
+	began := time.Now()
 	snapshotDir := filepath.Join(m.cfg.SnapshotDir, name)
 
+	// Incremental snapshots only keep archives that differ from the parent chain
+	var parent *models.Snapshot
+	if opts.ParentName != "" {
+		p, err := m.Get(opts.ParentName)
+		if err != nil {
+			return nil, fmt.Errorf("parent snapshot %s: %w", opts.ParentName, err)
+		}
+		parent = p
+		opts.Incremental = true
+	}
+
 	// Create snapshot directory
 	if err := os.MkdirAll(snapshotDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create snapshot directory: %w", err)
 	}
 
-	// Stop containers for consistent snapshot
-	m.client.StopContainers(volumes)
-	defer m.client.StartContainers(volumes)
+	// Dumps, settings, and table summaries need the database running, so take
+	// them before stopping
+	volumes = append([]models.Volume(nil), volumes...)
+	for i := range volumes {
+		if err := m.captureLiveState(&volumes[i], snapshotDir, opts); err != nil {
+			return nil, err
+		}
+	}
 
-	// Export each volume
+	// Pin the exact server images so restore --with-images can bring them back
+	for i := range volumes {
+		if volumes[i].ImageName != "" {
+			volumes[i].ImageDigest, _ = m.client.ImageDigest(volumes[i].ImageName) // not pulled yet: nothing to pin
+		}
+	}
+
+	// Archived WAL belongs in the WAL directory, not in the snapshot
+	m.syncPITR(volumes)
+
+	// Let datastores flush to disk, then stop containers for a consistent snapshot
+	if err := m.quiesce(volumes); err != nil {
+		return nil, err
+	}
+	start, err := m.stopContainers(volumes)
+	if err != nil {
+		return nil, err
+	}
+	defer start()
+
+	quietWait := opts.QuietWait
+	if quietWait == 0 {
+		quietWait = m.cfg.QuietWait
+	}
+	m.awaitQuiet(volumes, quietWait)
+
+	// Export each volume, or hard-link the previous archive when the volume
+	// hasn't changed since
+	previous := m.previousVolumes(name, parent)
 	var totalSize int64
 	var snapshotVolumes []models.Volume
 
 	for _, vol := range volumes {
-		tarPath := filepath.Join(snapshotDir, fmt.Sprintf("%s.tar.gz", sanitizeName(vol.Name)))
+		vol.ArchiveDir = m.archiveDir(name, vol)
+		vol.LinkedFrom = ""
+		vol.ExportTime, vol.ExportRate = 0, 0
+		tarPath := archivePath(snapshotDir, vol)
+		if err := os.MkdirAll(filepath.Dir(tarPath), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create archive directory for %s: %w", vol.Name, err)
+		}
 
-		if err := m.client.ExportVolume(vol, tarPath); err != nil {
-			return nil, fmt.Errorf("failed to export volume %s: %w", vol.Name, err)
+		vol.Fingerprint, _ = m.client.Fingerprint(vol) // without one the volume is simply exported
+		linked := false
+		if prev, ok := previous[vol.Name]; ok && !opts.ExportAll {
+			linked = m.linkUnchanged(prev, &vol, tarPath)
+		}
+		if !linked {
+			// An archive left by an overwritten snapshot may be hard-linked
+			// from an earlier one; exporters must not write through it
+			os.Remove(tarPath)
+			exportStart := time.Now()
+			if err := m.exportVolume(&vol, tarPath); err != nil {
+				return nil, fmt.Errorf("failed to export volume %s: %w", vol.Name, err)
+			}
+			vol.ExportTime = time.Since(exportStart).Round(time.Millisecond)
+
+			// Read the archive back so a broken export fails now, not at restore time
+			checksum, err := verifyArchive(tarPath, "")
+			if err != nil {
+				return nil, fmt.Errorf("failed to verify volume %s: %w", vol.Name, err)
+			}
+			vol.Checksum = checksum
+			if vol.Exporter == "" {
+				vol.ArchiveFormat = models.ArchiveFormatPAX
+			}
 		}
 
 		// Get file size
 		info, err := os.Stat(tarPath)
 		if err == nil {
-			totalSize += info.Size()
 			vol.SizeBytes = info.Size()
 			vol.SizeHuman = models.FormatSize(info.Size())
+			if vol.ExportTime > 0 {
+				vol.ExportRate = int64(float64(info.Size()) / vol.ExportTime.Seconds())
+			}
+			if parent != nil && m.unchangedFromParent(parent, vol) {
+				os.Remove(tarPath)
+				vol.LinkedFrom = "" // inherited through the chain instead
+			} else {
+				totalSize += info.Size()
+			}
+		}
+
+		// Passwords stay out of metadata unless explicitly allowed
+		if m.cfg.StoreCredentials {
+			vol.Credentials = vol.Credentials.Stored()
+		} else {
+			vol.Credentials = vol.Credentials.Redacted()
 		}
 
 		snapshotVolumes = append(snapshotVolumes, vol)
@@ -82,17 +206,33 @@ func (m *Manager) CreateWithOptions(name string, volumes []models.Volume, opts C
 
 	// Create snapshot metadata
 	snapshot := &models.Snapshot{
-		Name:        name,
-		Timestamp:   time.Now(),
-		Volumes:     snapshotVolumes,
-		SizeBytes:   totalSize,
-		SizeHuman:   models.FormatSize(totalSize),
-		Path:        snapshotDir,
-		Tags:        allTags,
-		Description: opts.Description,
-		Metadata:    opts.Metadata,
-		Incremental: opts.Incremental,
-		ParentName:  opts.ParentName,
+		Name:          name,
+		Timestamp:     time.Now(),
+		Volumes:       snapshotVolumes,
+		SizeBytes:     totalSize,
+		SizeHuman:     models.FormatSize(totalSize),
+		Path:          snapshotDir,
+		Tags:          allTags,
+		Description:   opts.Description,
+		Metadata:      opts.Metadata,
+		Incremental:   opts.Incremental,
+		ParentName:    opts.ParentName,
+		FormatVersion: models.SnapshotFormatVersion,
+		SchemaVersion: models.SnapshotSchemaVersion,
+		Duration:      time.Since(began).Round(time.Millisecond),
+		WrittenBy:     Version,
+		MinReader:     models.SnapshotMinReader,
+	}
+	if !opts.ExpiresAt.IsZero() {
+		snapshot.ExpiresAt = &opts.ExpiresAt
+	}
+
+	if snapshot.ComposeFile, err = m.writeCompose(snapshotDir); err != nil {
+		return nil, err
+	}
+
+	if err := writeStoredCredentials(snapshotDir, snapshot.Volumes); err != nil {
+		return nil, fmt.Errorf("failed to write credentials: %w", err)
 	}
 
 	// Save metadata
@@ -108,58 +248,319 @@ func (m *Manager) CreateWithOptions(name string, volumes []models.Volume, opts C
 	return snapshot, nil
 }
 
-// Restore restores volumes from a named snapshot
-func (m *Manager) Restore(name string) error {
+// RestoreOptions controls restores
+type RestoreOptions struct {
+	WithImages   bool      // Also bring back the exact images the snapshot was taken with
+	RecoverTo    time.Time // Replay archived Postgres WAL up to this time (see EnablePITR)
+	LeaveStopped bool      // Don't restart the containers stopped for the restore
+	SkipBackup   bool      // Don't take the pre-restore backup, e.g. when restoring into copies
+
+	// Into restores the snapshot volume with a key's Docker or compose name
+	// into another volume, created if missing, instead of its own
+	Into map[string]models.Volume
+
+	// Context cancels the restore (e.g. on Ctrl-C): no more volumes are
+	// imported, and the ones already touched are rolled back from the
+	// pre-restore backup, as they are when an import fails
+	Context context.Context
+}
+
+// Restore restores volumes from a named snapshot. The result records what
+// happened to each volume and is returned even when the restore fails.
+func (m *Manager) Restore(name string) (*models.RestoreResult, error) {
+	return m.RestoreWithOptions(name, RestoreOptions{})
+}
+
+// RestoreWithOptions restores a snapshot with options. Every attempt is
+// logged to the history log, as is the verdict of the validation queries.
+func (m *Manager) RestoreWithOptions(name string, opts RestoreOptions) (*models.RestoreResult, error) {
+	if err := m.cfg.Writable(); err != nil {
+		return &models.RestoreResult{Snapshot: name}, err
+	}
+	result, err := m.restore(name, opts)
+	m.logRestore(result, err) // advisory, like the state file
+	return result, err
+}
+
+// selectRestored narrows a snapshot's volumes to those include_volumes and
+// exclude_volumes pick, as they pick what is snapshotted
+func (m *Manager) selectRestored(snapshot *models.Snapshot) error {
+	selected := m.cfg.SelectVolumes(snapshot.Volumes)
+	if len(selected) == 0 && len(snapshot.Volumes) > 0 {
+		return fmt.Errorf("no volume of snapshot %s is included (see include_volumes, exclude_volumes, and include_services)", snapshot.Name)
+	}
+	snapshot.Volumes = selected
+	return nil
+}
+
+func (m *Manager) restore(name string, opts RestoreOptions) (*models.RestoreResult, error) {
 	snapshotDir := filepath.Join(m.cfg.SnapshotDir, name)
+	result := &models.RestoreResult{Snapshot: name}
+	if err := checkName(name); err != nil {
+		return result, err
+	}
+	if opts.LeaveStopped && (opts.WithImages || !opts.RecoverTo.IsZero()) {
+		return result, fmt.Errorf("leaving containers stopped can't be combined with restoring images or point-in-time recovery")
+	}
+	if len(opts.Into) > 0 && !opts.RecoverTo.IsZero() {
+		return result, fmt.Errorf("restoring into other volumes can't be combined with point-in-time recovery")
+	}
 
 	// Load metadata
 	snapshot, err := m.loadMetadata(snapshotDir)
 	if err != nil {
-		return err
+		return result, err
+	}
+	if err := checkReadable(snapshot); err != nil {
+		return result, err
+	}
+	if err := m.selectRestored(snapshot); err != nil {
+		return result, err
+	}
+	if opts.WithImages && len(pinnedImages(snapshot.Volumes)) == 0 {
+		return result, fmt.Errorf("snapshot %s has no recorded image digests", name)
 	}
 
-	// Create pre-restore backup if configured
-	if m.cfg.BackupBeforeRestore {
+	// Nothing is touched unless every archive reads back cleanly
+	if err := m.VerifySnapshot(snapshot); err != nil {
+		return result, fmt.Errorf("snapshot %s failed verification, existing data left untouched: %w", name, err)
+	}
+
+	// Archives are found by the snapshot's own volume names, so look them up
+	// before pointing volumes elsewhere
+	archives := make([]string, len(snapshot.Volumes))
+	for i, vol := range snapshot.Volumes {
+		if archives[i], err = m.resolveArchive(snapshot, vol); err != nil {
+			return result, err
+		}
+	}
+	if snapshot.Volumes, err = retarget(snapshot.Volumes, opts.Into); err != nil {
+		return result, err
+	}
+	if err := m.resolveAliases(snapshot.Volumes); err != nil {
+		return result, err
+	}
+
+	// Rolling forward needs the WAL and AOF written up to now, so collect them first
+	var pitr *recovery
+	if !opts.RecoverTo.IsZero() {
+		if pitr, err = m.preparePITR(snapshot, opts.RecoverTo); err != nil {
+			return result, fmt.Errorf("%w, existing data left untouched", err)
+		}
+	}
+
+	// Create pre-restore backup if configured; without it there's no way back
+	if m.cfg.BackupBeforeRestore && !opts.SkipBackup {
 		backupName := fmt.Sprintf("_pre-restore-%s", time.Now().Format("20060102-150405"))
-		m.Create(backupName, snapshot.Volumes)
+		if _, err := m.create(backupName, snapshot.Volumes, CreateOptions{}); err != nil {
+			return result, fmt.Errorf("pre-restore backup failed, existing data left untouched: %w", err)
+		}
+		result.Backup = backupName
 	}
 
-	// Stop containers
-	m.client.StopContainers(snapshot.Volumes)
-	defer m.client.StartContainers(snapshot.Volumes)
+	// Only containers that were running come back, and none with LeaveStopped
+	start, err := m.stopContainers(snapshot.Volumes)
+	if err != nil {
+		return result, err
+	}
+	restart := func() {
+		if !opts.LeaveStopped {
+			start()
+		}
+	}
+	m.publish(events.Event{Kind: events.RestoreStarted, Operation: "restore", Target: name, Snapshot: snapshot})
+
+	if opts.WithImages {
+		for image, digest := range pinnedImages(snapshot.Volumes) {
+			if err := m.client.PinImage(image, digest); err != nil {
+				restart()
+				return result, fmt.Errorf("failed to restore image %s, existing data left untouched: %w", image, err)
+			}
+		}
+	}
 
-	// Import each volume
+	// Clear and import the volumes, several at a time in restore_after order,
+	// starting no more after the first failure
 	for _, vol := range snapshot.Volumes {
-		tarPath := filepath.Join(snapshotDir, fmt.Sprintf("%s.tar.gz", sanitizeName(vol.Name)))
+		result.Volumes = append(result.Volumes, models.VolumeResult{Volume: vol.Name})
+	}
+	parallelism := m.cfg.RestoreParallelism
+	if parallelism == 0 {
+		parallelism = defaultRestoreParallelism
+	}
+	deps := restoreDependencies(snapshot.Volumes, m.cfg.RestoreAfter)
+	err = runOrdered(len(snapshot.Volumes), deps, parallelism, func(i int) error {
+		if opts.cancelled() {
+			return errRestoreCancelled
+		}
+		vol, vr := snapshot.Volumes[i], &result.Volumes[i]
+		err := m.prepareVolume(vol, name, vr)
+		if err == nil {
+			err = m.importVolume(archives[i], vol)
+		}
+		if err != nil {
+			vr.Error = err.Error()
+			return fmt.Errorf("failed to restore volume %s: %w", vol.Name, err)
+		}
+		vr.Imported = true
+		m.publish(events.Event{Kind: events.VolumeRestored, Operation: "restore", Target: name, Volume: vol.Name})
+		return nil
+	})
+	if err != nil {
+		err = m.abandon(result, snapshot.Volumes, err)
+		restart()
+		return result, err
+	}
+	if err := m.stageRecovery(pitr); err != nil {
+		restart()
+		return result, err
+	}
+
+	// Stopped datastores can't be health checked, validated, or rolled forward
+	if opts.LeaveStopped {
+		m.recordRestore(name, snapshot.Volumes)
+		return result, nil
+	}
 
-		if err := m.client.ImportVolume(tarPath, vol); err != nil {
-			return fmt.Errorf("failed to import volume %s: %w", vol.Name, err)
+	// Don't report success until the datastores are back up. Re-tagged images
+	// only take effect in recreated containers.
+	start()
+	if opts.WithImages {
+		if err := m.client.RecreateServices(m.cfg, services(snapshot.Volumes)); err != nil {
+			return result, err
+		}
+	}
+	if err := m.waitHealthy(snapshot.Volumes, true); err != nil {
+		return result, err
+	}
+	if pitr != nil {
+		if err := m.finishRecovery(pitr.wal); err != nil {
+			return result, err
 		}
+		result.RecoveredTo = &opts.RecoverTo
 	}
+	if err := m.postRestoreExec(); err != nil {
+		return result, err
+	}
+
+	// Drift is measured from here, after startup writes have settled. The
+	// state file is advisory, so failing to write it doesn't fail the restore.
+	m.recordRestore(name, snapshot.Volumes)
+	result.ConfigDrift = m.runtimeDrift(snapshot)
+	err = m.validate(snapshot.Volumes)
+	if invalid, ok := err.(*ValidationError); ok {
+		result.Suspect = invalid.Problems
+	}
+	return result, err
+}
+
+// prepareVolume makes sure a volume exists and holds no data before an
+// import. Volumes it creates, and existing ones that are empty, aren't
+// cleared, which saves a helper container and a destructive step.
+func (m *Manager) prepareVolume(vol models.Volume, source string, vr *models.VolumeResult) error {
+	created, err := m.client.EnsureVolume(vol, models.ProvenanceLabels(source, time.Now())...)
+	if err != nil || created {
+		vr.Created = created
+		return err
+	}
+	cleared, err := m.client.ClearNonEmptyVolume(vol)
+	if err != nil {
+		return err
+	}
+	vr.Cleared, vr.Empty = cleared, !cleared
+	if cleared {
+		m.publish(events.Event{Kind: events.VolumeCleared, Operation: "restore", Target: source, Volume: vol.Name})
+	}
+	return nil
+}
+
+// retarget returns the volumes a restore writes to: the snapshot's, with
+// each one into names (by Docker or compose name) swapped for its target
+func retarget(volumes []models.Volume, into map[string]models.Volume) ([]models.Volume, error) {
+	out := slices.Clone(volumes)
+	for key, target := range into {
+		i := slices.IndexFunc(volumes, func(v models.Volume) bool {
+			return v.Name == key || (v.ComposeName != "" && v.ComposeName == key)
+		})
+		if i < 0 {
+			return nil, fmt.Errorf("snapshot has no volume %s to restore into %s", key, target.Name)
+		}
+		vol := &out[i]
+		vol.Name = target.Name
+		vol.ComposeName = target.ComposeName
+		vol.Service = target.Service
+		vol.ContainerName = target.ContainerName
+		vol.MountPath = target.MountPath
+		vol.Credentials = target.Credentials
+		vol.Alias = target.Alias
+	}
+	seen := make(map[string]bool)
+	for _, vol := range out {
+		if seen[vol.Name] {
+			return nil, fmt.Errorf("volume %s would be restored twice", vol.Name)
+		}
+		seen[vol.Name] = true
+	}
+	return out, nil
+}
 
+// resolveAliases points volumes adopted under an alias at the anonymous
+// volume their service's container mounts now, which is another one than
+// at snapshot time once the container was recreated
+func (m *Manager) resolveAliases(volumes []models.Volume) error {
+	if !slices.ContainsFunc(volumes, func(v models.Volume) bool { return v.Alias != "" }) {
+		return nil
+	}
+	mounts, err := m.client.AnonymousMounts()
+	if err != nil {
+		return err
+	}
+	for i := range volumes {
+		vol := &volumes[i]
+		if vol.Alias == "" {
+			continue
+		}
+		mount, ok := docker.FindAnonymous(mounts, vol.Service, vol.MountPath)
+		if !ok {
+			return fmt.Errorf("volume %s has no container to restore into; create it with 'docker compose up --no-start %s'", vol.Alias, vol.Service)
+		}
+		vol.Name = mount.Volume
+	}
 	return nil
 }
 
 // Reset clears all data from the specified volumes
 func (m *Manager) Reset(volumes []models.Volume) error {
+	if err := m.cfg.Writable(); err != nil {
+		return err
+	}
 	// Create pre-reset backup if configured
 	if m.cfg.BackupBeforeRestore {
 		backupName := fmt.Sprintf("_pre-reset-%s", time.Now().Format("20060102-150405"))
-		m.Create(backupName, volumes)
+		if _, err := m.create(backupName, volumes, CreateOptions{}); err != nil {
+			return fmt.Errorf("pre-reset backup failed, existing data left untouched: %w", err)
+		}
 	}
 
 	// Stop containers
-	m.client.StopContainers(volumes)
-	defer m.client.StartContainers(volumes)
+	start, err := m.stopContainers(volumes)
+	if err != nil {
+		return err
+	}
+	m.publish(events.Event{Kind: events.ResetStarted, Operation: "reset"})
 
 	// Clear each volume
 	for _, vol := range volumes {
 		if err := m.client.ClearVolume(vol); err != nil {
+			start()
 			return fmt.Errorf("failed to clear volume %s: %w", vol.Name, err)
 		}
+		m.publish(events.Event{Kind: events.VolumeCleared, Operation: "reset", Volume: vol.Name})
 	}
 
-	return nil
+	start()
+	m.forgetRestore(volumes) // advisory, like recordRestore
+	return m.waitHealthy(volumes, false)
 }
 
 // List returns all available snapshots
@@ -200,13 +601,34 @@ func (m *Manager) List() ([]models.Snapshot, error) {
 
 // Get returns a specific snapshot by name
 func (m *Manager) Get(name string) (*models.Snapshot, error) {
+	if err := checkName(name); err != nil {
+		return nil, err
+	}
 	snapshotDir := filepath.Join(m.cfg.SnapshotDir, name)
 	return m.loadMetadata(snapshotDir)
 }
 
-// Delete removes a snapshot
+// Delete removes a snapshot, including archives that storage rules placed
+// outside the snapshot directory
 func (m *Manager) Delete(name string) error {
+	if err := m.cfg.Writable(); err != nil {
+		return err
+	}
+	if err := checkName(name); err != nil {
+		return err
+	}
 	snapshotDir := filepath.Join(m.cfg.SnapshotDir, name)
+	if snapshot, err := m.loadMetadata(snapshotDir); err == nil {
+		for _, vol := range snapshot.Volumes {
+			if vol.ArchiveDir == "" {
+				continue
+			}
+			if err := os.Remove(archivePath(snapshotDir, vol)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			os.Remove(vol.ArchiveDir) // shared by the snapshot's routed volumes; goes once empty
+		}
+	}
 	return os.RemoveAll(snapshotDir)
 }
 
@@ -222,13 +644,282 @@ func (m *Manager) loadMetadata(snapshotDir string) (*models.Snapshot, error) {
 	if err := yaml.Unmarshal(data, &snapshot); err != nil {
 		return nil, fmt.Errorf("invalid snapshot metadata: %w", err)
 	}
+	if err := snapshot.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid snapshot metadata in %s: %w", metadataPath, err)
+	}
+
+	if snapshot.FormatVersion > models.SnapshotFormatVersion {
+		return nil, fmt.Errorf("snapshot %s was written by a newer dataclean (format %d, this release reads up to %d)",
+			snapshot.Name, snapshot.FormatVersion, models.SnapshotFormatVersion)
+	}
 
 	// Update path in case directory was moved
 	snapshot.Path = snapshotDir
+	readStoredCredentials(&snapshot)
 
 	return &snapshot, nil
 }
 
+// credentialsFileName holds the passwords of a snapshot taken with
+// store_credentials, by volume, readable by its owner only. metadata.yaml
+// never holds one, and bundles leave the file out.
+const credentialsFileName = "credentials.yaml"
+
+// writeStoredCredentials writes the clear text passwords of volumes, if any
+func writeStoredCredentials(snapshotDir string, volumes []models.Volume) error {
+	passwords := map[string]string{}
+	for _, v := range volumes {
+		if v.Credentials != nil && v.Credentials.StoredPassword != "" {
+			passwords[v.Name] = v.Credentials.StoredPassword
+		}
+	}
+	if len(passwords) == 0 {
+		return nil
+	}
+	data, err := yaml.Marshal(passwords)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(snapshotDir, credentialsFileName), data, 0600)
+}
+
+// readStoredCredentials fills in the passwords of a snapshot's credentials
+// file. Without a readable one, volumes connect with what the running
+// containers' environment says.
+func readStoredCredentials(snapshot *models.Snapshot) {
+	data, err := os.ReadFile(filepath.Join(snapshot.Path, credentialsFileName))
+	if err != nil {
+		return
+	}
+	var passwords map[string]string
+	if yaml.Unmarshal(data, &passwords) != nil {
+		return
+	}
+	for i, v := range snapshot.Volumes {
+		if password := passwords[v.Name]; password != "" && v.Credentials != nil {
+			creds := *v.Credentials
+			creds.StoredPassword = password
+			snapshot.Volumes[i].Credentials = &creds
+		}
+	}
+}
+
+// captureLiveState records logical dumps, runtime settings, and table
+// summaries from a running datastore
+func (m *Manager) captureLiveState(vol *models.Volume, snapshotDir string, opts CreateOptions) error {
+	if opts.Logical && datastore.SupportsLogicalDump(vol.DatastoreType) {
+		dumpFile, err := m.dumpVolume(*vol, snapshotDir)
+		if err != nil {
+			return fmt.Errorf("failed to dump volume %s: %w", vol.Name, err)
+		}
+		vol.LogicalDump = dumpFile
+	}
+
+	if opts.Runtime {
+		if err := m.captureRuntime(vol, snapshotDir); err != nil {
+			return err
+		}
+	}
+
+	if opts.Tables {
+		if script, ok := datastore.SummaryCommand(vol.DatastoreType); ok {
+			container, err := m.client.ResolveContainer(*vol)
+			if err != nil {
+				return fmt.Errorf("failed to summarize volume %s: %w", vol.Name, err)
+			}
+			output, err := m.client.ExecOutput(container, script, datastore.CredentialEnv(vol.Credentials)...)
+			if err != nil {
+				return fmt.Errorf("failed to summarize volume %s: %w", vol.Name, err)
+			}
+			vol.Tables = datastore.ParseSummary(output)
+		}
+	}
+
+	return nil
+}
+
+// dumpVolume writes a logical SQL dump of a running datastore into the snapshot directory
+func (m *Manager) dumpVolume(vol models.Volume, snapshotDir string) (string, error) {
+	script, _ := datastore.DumpCommand(vol.DatastoreType)
+	container, err := m.client.ResolveContainer(vol)
+	if err != nil {
+		return "", err
+	}
+
+	dumpFile := fmt.Sprintf("%s.sql", sanitizeName(vol.Name))
+	if err := m.client.ExecToFile(container, script, filepath.Join(snapshotDir, dumpFile), datastore.CredentialEnv(vol.Credentials)...); err != nil {
+		return "", err
+	}
+	return dumpFile, nil
+}
+
+// unchangedFromParent reports whether a freshly exported volume has the same
+// contents (checksum) as the parent chain's archive for it
+func (m *Manager) unchangedFromParent(parent *models.Snapshot, vol models.Volume) bool {
+	previous, err := FindVolume(parent, vol.Name)
+	if err != nil {
+		return false
+	}
+	checksum := previous.Checksum
+	if checksum == "" {
+		archive, err := m.resolveArchive(parent, *previous)
+		if err != nil {
+			return false
+		}
+		if checksum, err = verifyArchive(archive, ""); err != nil {
+			return false
+		}
+	}
+	return checksum == vol.Checksum
+}
+
+// previousArchive is the most recent snapshot of a volume to link against
+type previousArchive struct {
+	snapshot *models.Snapshot
+	volume   models.Volume
+}
+
+// previousVolumes returns, per volume, the snapshot a new snapshot can link
+// unchanged archives from: the parent for incremental snapshots, otherwise
+// the newest snapshot containing the volume
+func (m *Manager) previousVolumes(name string, parent *models.Snapshot) map[string]previousArchive {
+	previous := map[string]previousArchive{}
+	if parent != nil {
+		for _, v := range parent.Volumes {
+			previous[v.Name] = previousArchive{parent, v}
+		}
+		return previous
+	}
+
+	snapshots, err := m.List()
+	if err != nil {
+		return previous
+	}
+	for volName, s := range latestSnapshots(snapshots) {
+		if s.Name == name {
+			continue // being overwritten
+		}
+		if v, err := FindVolume(&s, volName); err == nil {
+			previous[volName] = previousArchive{&s, *v}
+		}
+	}
+	return previous
+}
+
+// linkUnchanged hard-links the previous archive of a volume into place when
+// its fingerprint hasn't changed. It reports false, leaving nothing behind,
+// whenever the volume has to be exported instead (changed, no fingerprint,
+// or the archive is on another filesystem).
+func (m *Manager) linkUnchanged(prev previousArchive, vol *models.Volume, tarPath string) bool {
+	if vol.Fingerprint == "" || prev.volume.Fingerprint != vol.Fingerprint || prev.volume.Checksum == "" {
+		return false
+	}
+	src, err := m.resolveArchive(prev.snapshot, prev.volume)
+	if err != nil {
+		return false
+	}
+	os.Remove(tarPath) // left over from an overwritten snapshot
+	if err := os.Link(src, tarPath); err != nil {
+		return false
+	}
+
+	vol.Checksum = prev.volume.Checksum
+	vol.ArchiveFormat = prev.volume.ArchiveFormat
+	vol.Exporter = prev.volume.Exporter
+	vol.LinkedFrom = prev.snapshot.Name
+	return true
+}
+
+// exportVolume exports a volume with the exporter plugin a rule assigns it,
+// or by copying its files, and records which
+func (m *Manager) exportVolume(vol *models.Volume, tarPath string) error {
+	vol.Exporter = m.cfg.ExporterFor(*vol)
+	if vol.Exporter == "" {
+		return m.client.ExportVolume(*vol, tarPath)
+	}
+	p, err := plugin.Find(m.cfg.PluginsDir, vol.Exporter)
+	if err != nil {
+		return err
+	}
+	return p.Export(*vol, tarPath)
+}
+
+// importVolume imports an archive with the exporter plugin that wrote it, or
+// by extracting its files
+func (m *Manager) importVolume(tarPath string, vol models.Volume) error {
+	if vol.Exporter == "" {
+		return m.client.ImportVolume(tarPath, vol)
+	}
+	p, err := plugin.Find(m.cfg.PluginsDir, vol.Exporter)
+	if err != nil {
+		return fmt.Errorf("archive was written by an exporter plugin: %w", err)
+	}
+	return p.Import(tarPath, vol)
+}
+
+// composeFileName is the resolved compose config stored in each snapshot
+const composeFileName = "compose.yaml"
+
+// writeCompose keeps the compose config the data was written under and
+// returns its file name. Snapshots of volumes outside a compose project simply
+// go without it. Secrets are redacted even with store_credentials: the file is
+// readable by all and bundled, and passwords live in the credentials file.
+func (m *Manager) writeCompose(snapshotDir string) (string, error) {
+	compose, err := m.client.ResolvedCompose(m.cfg)
+	if err != nil {
+		return "", nil
+	}
+	if err := os.WriteFile(filepath.Join(snapshotDir, composeFileName), compose, 0644); err != nil {
+		return "", fmt.Errorf("failed to write compose config: %w", err)
+	}
+	return composeFileName, nil
+}
+
+// pinnedImages maps image names to the digests recorded for them
+func pinnedImages(volumes []models.Volume) map[string]string {
+	images := map[string]string{}
+	for _, v := range volumes {
+		if v.ImageName != "" && v.ImageDigest != "" {
+			images[v.ImageName] = v.ImageDigest
+		}
+	}
+	return images
+}
+
+// services returns the distinct compose services of volumes
+func services(volumes []models.Volume) []string {
+	var names []string
+	for _, v := range volumes {
+		if v.Service != "" && !slices.Contains(names, v.Service) {
+			names = append(names, v.Service)
+		}
+	}
+	return names
+}
+
+// archivePath returns the path of a volume's archive: inside the snapshot
+// directory, or wherever a storage rule sent it
+func archivePath(snapshotDir string, vol models.Volume) string {
+	if vol.ArchiveDir != "" {
+		snapshotDir = vol.ArchiveDir
+	}
+	name := vol.Name
+	if vol.Alias != "" {
+		name = vol.Alias // the Docker name of an anonymous volume changes
+	}
+	return filepath.Join(snapshotDir, fmt.Sprintf("%s.tar.gz", sanitizeName(name)))
+}
+
+// archiveDir returns the directory a storage rule sends a volume's archive
+// to for the named snapshot, or "" to keep it in the snapshot directory
+func (m *Manager) archiveDir(name string, vol models.Volume) string {
+	dir := m.cfg.StorageDir(vol)
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, name)
+}
+
 // sanitizeName converts a volume name to a safe filename
 func sanitizeName(name string) string {
 	// Replace characters that might be problematic in filenames
@@ -325,6 +1016,9 @@ func (m *Manager) UpdateMetadata(name string, metadata map[string]string) error
 
 // saveMetadata saves snapshot metadata
 func (m *Manager) saveMetadata(snapshot *models.Snapshot) error {
+	if err := m.cfg.Writable(); err != nil {
+		return err
+	}
 	metadataPath := filepath.Join(snapshot.Path, "metadata.yaml")
 	metadataBytes, err := yaml.Marshal(snapshot)
 	if err != nil {
@@ -377,31 +1071,58 @@ func (m *Manager) GetSizeReport(volumes []models.Volume) (*models.SizeReport, er
 	return report, nil
 }
 
-// CleanupOldSnapshots removes snapshots older than retention days
+// CleanupOldSnapshots removes snapshots past their expiry date or older
+// than retention days
 func (m *Manager) CleanupOldSnapshots() ([]string, error) {
-	if m.cfg.RetentionDays <= 0 {
-		return nil, nil
+	expired, err := m.expiredSnapshots()
+	if err != nil {
+		return nil, err
+	}
+
+	var deleted []string
+	for _, s := range expired {
+		if err := m.Delete(s.Name); err == nil {
+			deleted = append(deleted, s.Name)
+		}
 	}
 
-	cutoff := time.Now().AddDate(0, 0, -m.cfg.RetentionDays)
+	return deleted, nil
+}
+
+// expiredSnapshots returns snapshots past the expiry date they were created
+// with, and those without one that are older than retention days
+func (m *Manager) expiredSnapshots() ([]models.Snapshot, error) {
+	now := time.Now()
+	cutoff := now.AddDate(0, 0, -m.cfg.RetentionDays)
 	snapshots, err := m.List()
 	if err != nil {
 		return nil, err
 	}
 
-	var deleted []string
+	kids := children(snapshots)
+
+	var expired []models.Snapshot
 	for _, s := range snapshots {
 		// Skip system backups (prefixed with _)
 		if strings.HasPrefix(s.Name, "_") {
 			continue
 		}
 
-		if s.Timestamp.Before(cutoff) {
-			if err := m.Delete(s.Name); err == nil {
-				deleted = append(deleted, s.Name)
+		// Incremental snapshots still need their parents (see Compact)
+		if len(kids[s.Name]) > 0 {
+			continue
+		}
+
+		if s.ExpiresAt != nil {
+			if s.ExpiresAt.Before(now) {
+				expired = append(expired, s)
 			}
+			continue
+		}
+		if m.cfg.RetentionDays > 0 && s.Timestamp.Before(cutoff) {
+			expired = append(expired, s)
 		}
 	}
 
-	return deleted, nil
+	return expired, nil
 }
@@ -4,9 +4,12 @@ package snapshot
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"gopkg.in/yaml.v3"
+
 	"github.com/stackgen-cli/dataclean/internal/models"
 )
 
@@ -228,6 +231,33 @@ func TestDelete_NotFound(t *testing.T) {
 	}
 }
 
+func TestReadOnly(t *testing.T) {
+	tmpDir := t.TempDir()
+	snapshotDir := filepath.Join(tmpDir, "kept")
+	os.MkdirAll(snapshotDir, 0755)
+	os.WriteFile(filepath.Join(snapshotDir, "metadata.yaml"), []byte(`name: kept`), 0644)
+
+	m := &Manager{cfg: &models.Config{SnapshotDir: tmpDir, ReadOnly: true}}
+	if err := m.Delete("kept"); err == nil {
+		t.Error("expected Delete to be refused in read-only mode")
+	}
+	if err := m.AddTag("kept", "golden"); err == nil {
+		t.Error("expected AddTag to be refused in read-only mode")
+	}
+	if _, err := m.RestoreWithOptions("kept", RestoreOptions{}); err == nil {
+		t.Error("expected restore to be refused in read-only mode")
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, historyFile)); err == nil {
+		t.Error("a refused restore was written to the history log")
+	}
+	if _, err := m.Get("kept"); err != nil {
+		t.Errorf("Get() failed in read-only mode: %v", err)
+	}
+	if _, err := os.Stat(snapshotDir); err != nil {
+		t.Error("read-only mode deleted the snapshot")
+	}
+}
+
 func TestSnapshotTimestampSorting(t *testing.T) {
 	// Test that snapshots are properly sortable by timestamp
 	snapshots := []models.Snapshot{
@@ -252,3 +282,180 @@ func TestSnapshotTimestampSorting(t *testing.T) {
 		t.Error("expected 'first' to be last after sorting")
 	}
 }
+
+func TestSaveMetadata_NeverWritesPasswords(t *testing.T) {
+	tmpDir := t.TempDir()
+	m := &Manager{cfg: &models.Config{SnapshotDir: tmpDir}}
+
+	snap := &models.Snapshot{
+		Name: "with-creds",
+		Path: filepath.Join(tmpDir, "with-creds"),
+		Volumes: []models.Volume{{
+			Name:          "project_pgdata",
+			DatastoreType: models.DatastorePostgres,
+			// Not passed through Redacted(): the Secret type alone must keep it out
+			Credentials: &models.Credentials{User: "app", Password: models.NewSecret("hunter2")},
+		}},
+	}
+	os.MkdirAll(snap.Path, 0755)
+	if err := m.saveMetadata(snap); err != nil {
+		t.Fatalf("saveMetadata failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(snap.Path, "metadata.yaml"))
+	if err != nil {
+		t.Fatalf("failed to read metadata: %v", err)
+	}
+	if strings.Contains(string(data), "hunter2") {
+		t.Errorf("metadata.yaml contains the password:\n%s", data)
+	}
+
+	loaded, err := m.loadMetadata(snap.Path)
+	if err != nil {
+		t.Fatalf("loadMetadata failed: %v", err)
+	}
+	if creds := loaded.Volumes[0].Credentials; creds == nil || creds.User != "app" || creds.Password.Reveal() != "" {
+		t.Errorf("loaded credentials = %#v, want user only", creds)
+	}
+}
+
+func TestSaveMetadata_KeepsUnknownFields(t *testing.T) {
+	tmpDir := t.TempDir()
+	m := &Manager{cfg: &models.Config{SnapshotDir: tmpDir}}
+
+	// Written by a newer release, with fields this one doesn't know
+	dir := filepath.Join(tmpDir, "newer")
+	os.MkdirAll(dir, 0755)
+	metadata := `name: newer
+timestamp: 2024-01-15T10:30:00Z
+schema_version: 7
+encryption:
+  key_id: team-key
+volumes:
+  - name: project_pgdata
+    datastore_type: postgres
+    compression: zstd
+size_bytes: 100
+`
+	os.WriteFile(filepath.Join(dir, "metadata.yaml"), []byte(metadata), 0644)
+
+	if err := m.AddTag("newer", "kept"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "metadata.yaml"))
+	if err != nil {
+		t.Fatalf("failed to read metadata: %v", err)
+	}
+	for _, want := range []string{"key_id: team-key", "compression: zstd", "schema_version: 7", "- kept"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("metadata.yaml lost %q:\n%s", want, data)
+		}
+	}
+}
+
+func TestLoadMetadata_Invalid(t *testing.T) {
+	tmpDir := t.TempDir()
+	m := &Manager{cfg: &models.Config{SnapshotDir: tmpDir}}
+
+	for name, metadata := range map[string]string{
+		"no-name":     "volumes:\n  - name: project_pgdata\n",
+		"no-volume":   "name: no-volume\nvolumes:\n  - datastore_type: postgres\n",
+		"duplicate":   "name: duplicate\nvolumes:\n  - name: project_pgdata\n  - name: project_pgdata\n",
+		"negative":    "name: negative\nsize_bytes: -1\n",
+		"wrong-types": "name: wrong-types\nvolumes: many\n",
+	} {
+		dir := filepath.Join(tmpDir, name)
+		os.MkdirAll(dir, 0755)
+		os.WriteFile(filepath.Join(dir, "metadata.yaml"), []byte(metadata), 0644)
+		if _, err := m.loadMetadata(dir); err == nil {
+			t.Errorf("%s: expected an error for metadata:\n%s", name, metadata)
+		}
+	}
+}
+
+func TestExporterPlugin(t *testing.T) {
+	pluginsDir := t.TempDir()
+	marker := filepath.Join(pluginsDir, "called")
+	script := "#!/bin/sh\ncat > " + marker + "\necho '{}'\n"
+	os.WriteFile(filepath.Join(pluginsDir, "dataclean-exporter-vault"), []byte(script), 0755)
+	m := NewManager(nil, &models.Config{
+		PluginsDir: pluginsDir,
+		Exporters:  []models.ExporterRule{{Type: models.DatastorePostgres, Plugin: "vault"}},
+	})
+
+	vol := models.Volume{Name: "shop_pgdata", DatastoreType: models.DatastorePostgres}
+	if err := m.exportVolume(&vol, filepath.Join(t.TempDir(), "pgdata.tar.gz")); err != nil {
+		t.Fatalf("exportVolume() failed: %v", err)
+	}
+	if vol.Exporter != "vault" {
+		t.Errorf("Exporter = %q, want vault", vol.Exporter)
+	}
+	if data, _ := os.ReadFile(marker); !strings.Contains(string(data), `"method":"export"`) {
+		t.Errorf("plugin got %q, want an export request", data)
+	}
+
+	// Restores use the plugin that wrote the archive, whatever the rules say now
+	m.cfg.Exporters = nil
+	if err := m.importVolume("pgdata.tar.gz", vol); err != nil {
+		t.Fatalf("importVolume() failed: %v", err)
+	}
+	if data, _ := os.ReadFile(marker); !strings.Contains(string(data), `"method":"import"`) {
+		t.Errorf("plugin got %q, want an import request", data)
+	}
+	vol.Exporter = "missing"
+	if err := m.importVolume("pgdata.tar.gz", vol); err == nil {
+		t.Error("expected error importing with a plugin that isn't installed")
+	}
+}
+
+func TestStoredCredentials(t *testing.T) {
+	tmpDir := t.TempDir()
+	m := &Manager{cfg: &models.Config{SnapshotDir: tmpDir}}
+	snapshotDir := filepath.Join(tmpDir, "seeded")
+	os.MkdirAll(snapshotDir, 0755)
+
+	creds := (&models.Credentials{User: "app", Password: models.NewSecret("hunter2")}).Stored()
+	volumes := []models.Volume{{Name: "shop_pgdata", Credentials: creds}, {Name: "shop_redis"}}
+	if err := writeStoredCredentials(snapshotDir, volumes); err != nil {
+		t.Fatalf("writeStoredCredentials() failed: %v", err)
+	}
+	metadata, _ := yaml.Marshal(&models.Snapshot{Name: "seeded", Volumes: volumes})
+	if strings.Contains(string(metadata), "hunter2") {
+		t.Fatalf("metadata holds the password:\n%s", metadata)
+	}
+	os.WriteFile(filepath.Join(snapshotDir, "metadata.yaml"), metadata, 0644)
+
+	info, err := os.Stat(filepath.Join(snapshotDir, credentialsFileName))
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("credentials file = %v, %v; want mode 0600", info, err)
+	}
+
+	snap, err := m.Get("seeded")
+	if err != nil {
+		t.Fatalf("Get() failed: %v", err)
+	}
+	if got := snap.Volumes[0].Credentials; got == nil || got.StoredPassword != "hunter2" || got.User != "app" {
+		t.Errorf("loaded credentials = %+v, want the stored password", got)
+	}
+	if snap.Volumes[1].Credentials != nil {
+		t.Errorf("volume without credentials got %+v", snap.Volumes[1].Credentials)
+	}
+}
+
+func TestRestore_WithImagesRefusedBeforeBackup(t *testing.T) {
+	tmpDir := t.TempDir()
+	m := NewManager(nil, &models.Config{SnapshotDir: tmpDir, BackupBeforeRestore: true})
+	os.MkdirAll(filepath.Join(tmpDir, "seeded"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, "seeded", "metadata.yaml"), []byte("name: seeded\nvolumes:\n  - name: shop_pgdata\n"), 0644)
+
+	_, err := m.RestoreWithOptions("seeded", RestoreOptions{WithImages: true})
+	if err == nil || !strings.Contains(err.Error(), "no recorded image digests") {
+		t.Fatalf("RestoreWithOptions() = %v, want the missing digests refused", err)
+	}
+	entries, _ := os.ReadDir(tmpDir)
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), "_pre-restore") {
+			t.Errorf("refused restore left a backup behind: %s", e.Name())
+		}
+	}
+}
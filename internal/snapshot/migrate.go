@@ -0,0 +1,149 @@
+package snapshot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+// MigrateStore upgrades every snapshot in the snapshot directory to
+// models.SnapshotFormatVersion in place: directories holding only volume
+// archives (from before metadata was written) get generated metadata, and
+// archives without a checksum are read back and checksummed. With apply
+// unset nothing is written, and the steps are only reported. A snapshot that
+// fails to migrate is reported with its error and left as it was.
+func (m *Manager) MigrateStore(apply bool) ([]models.StoreMigration, error) {
+	if apply {
+		if err := m.cfg.Writable(); err != nil {
+			return nil, err
+		}
+	}
+	entries, err := os.ReadDir(m.cfg.SnapshotDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var migrations []models.StoreMigration
+	for _, e := range entries {
+		// _wal, _aof, and other internal directories hold no snapshots
+		if !e.IsDir() || strings.HasPrefix(e.Name(), "_") && !m.hasMetadata(e.Name()) {
+			continue
+		}
+		mig, ok := m.migrateSnapshot(e.Name(), apply)
+		if ok {
+			migrations = append(migrations, mig)
+		}
+	}
+	return migrations, nil
+}
+
+// hasMetadata reports whether a snapshot directory has a metadata file
+func (m *Manager) hasMetadata(name string) bool {
+	_, err := os.Stat(filepath.Join(m.cfg.SnapshotDir, name, "metadata.yaml"))
+	return err == nil
+}
+
+// migrateSnapshot upgrades one snapshot directory. ok is false when there is
+// nothing to migrate.
+func (m *Manager) migrateSnapshot(name string, apply bool) (models.StoreMigration, bool) {
+	mig := models.StoreMigration{Snapshot: name}
+	dir := filepath.Join(m.cfg.SnapshotDir, name)
+
+	var snapshot *models.Snapshot
+	if m.hasMetadata(name) {
+		s, err := m.loadMetadata(dir)
+		if err != nil {
+			mig.Error = err.Error()
+			return mig, true
+		}
+		if s.FormatVersion == models.SnapshotFormatVersion {
+			return mig, false
+		}
+		snapshot = s
+	} else {
+		s, err := flatSnapshot(name, dir)
+		if err != nil || s == nil {
+			return mig, false // not a snapshot
+		}
+		snapshot = s
+		mig.Steps = append(mig.Steps, fmt.Sprintf("generate metadata for %d archive(s)", len(s.Volumes)))
+		mig.Notes = append(mig.Notes, "volume types are unknown, so they are restored as plain files")
+	}
+	mig.From = snapshot.FormatVersion
+
+	for i, vol := range snapshot.Volumes {
+		if vol.ArchiveFormat == models.ArchiveFormatLegacy && vol.Exporter == "" {
+			mig.Notes = append(mig.Notes, fmt.Sprintf("%s is a %s archive: take a new snapshot to keep sparse files, extended attributes, and ACLs", vol.Name, vol.ArchiveFormat))
+		}
+		if vol.Checksum != "" {
+			continue
+		}
+		mig.Steps = append(mig.Steps, "record checksum of "+vol.Name)
+		if !apply {
+			continue
+		}
+		archive, err := m.resolveArchive(snapshot, vol)
+		if err == nil {
+			snapshot.Volumes[i].Checksum, err = verifyArchive(archive, "")
+		}
+		if err != nil {
+			mig.Error = fmt.Sprintf("volume %s: %v", vol.Name, err)
+			return mig, true
+		}
+	}
+
+	mig.Steps = append(mig.Steps, fmt.Sprintf("set format_version %d", models.SnapshotFormatVersion))
+	if apply {
+		snapshot.FormatVersion = models.SnapshotFormatVersion
+		if err := m.saveMetadata(snapshot); err != nil {
+			mig.Error = err.Error()
+		}
+	}
+	return mig, true
+}
+
+// flatSnapshot builds metadata for a directory that holds volume archives
+// but no metadata, or returns nil if it holds none. The snapshot is dated
+// by its oldest archive.
+func flatSnapshot(name, dir string) (*models.Snapshot, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &models.Snapshot{Name: name, Path: dir, Timestamp: time.Now()}
+	for _, e := range entries {
+		volume, ok := strings.CutSuffix(e.Name(), ".tar.gz")
+		if !ok || !e.Type().IsRegular() {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			return nil, err
+		}
+		snapshot.Volumes = append(snapshot.Volumes, models.Volume{
+			Name:          volume,
+			DatastoreType: models.DatastoreGeneric,
+			SizeBytes:     info.Size(),
+			SizeHuman:     models.FormatSize(info.Size()),
+		})
+		snapshot.SizeBytes += info.Size()
+		if info.ModTime().Before(snapshot.Timestamp) {
+			snapshot.Timestamp = info.ModTime()
+		}
+	}
+	if len(snapshot.Volumes) == 0 {
+		return nil, nil
+	}
+	sort.Slice(snapshot.Volumes, func(i, j int) bool { return snapshot.Volumes[i].Name < snapshot.Volumes[j].Name })
+	snapshot.SizeHuman = models.FormatSize(snapshot.SizeBytes)
+	return snapshot, nil
+}
@@ -0,0 +1,80 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+func TestMigrateStore(t *testing.T) {
+	tmpDir := t.TempDir()
+	m := NewManager(nil, &models.Config{SnapshotDir: tmpDir})
+
+	// Metadata from before format versions, without checksums
+	writeChainSnapshot(t, m, "old", "", time.Now(), map[string]string{"project_pgdata": "pg", "project_uploads": "files"})
+	// Archives without metadata
+	os.MkdirAll(filepath.Join(tmpDir, "flat"), 0755)
+	writeTestArchive(t, filepath.Join(tmpDir, "flat", "project_cache.tar.gz"), map[string]string{"data": "cache"})
+	// Internal directories aren't snapshots
+	os.MkdirAll(filepath.Join(tmpDir, "_wal", "project_pgdata"), 0755)
+
+	planned, err := m.MigrateStore(false)
+	if err != nil {
+		t.Fatalf("MigrateStore(false) failed: %v", err)
+	}
+	if len(planned) != 2 {
+		t.Fatalf("planned = %+v, want old and flat", planned)
+	}
+	if _, err := m.Get("flat"); err == nil {
+		t.Error("dry run wrote metadata for flat")
+	}
+
+	migrated, err := m.MigrateStore(true)
+	if err != nil {
+		t.Fatalf("MigrateStore(true) failed: %v", err)
+	}
+	for _, mig := range migrated {
+		if mig.Error != "" {
+			t.Errorf("%s: %s", mig.Snapshot, mig.Error)
+		}
+	}
+
+	for _, name := range []string{"old", "flat"} {
+		snap, err := m.Get(name)
+		if err != nil {
+			t.Fatalf("Get(%s) failed: %v", name, err)
+		}
+		if snap.FormatVersion != models.SnapshotFormatVersion {
+			t.Errorf("%s: FormatVersion = %d", name, snap.FormatVersion)
+		}
+		for _, v := range snap.Volumes {
+			if v.Checksum == "" {
+				t.Errorf("%s: volume %s has no checksum", name, v.Name)
+			}
+		}
+		if err := m.VerifySnapshot(snap); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+
+	again, _ := m.MigrateStore(false)
+	if len(again) != 0 {
+		t.Errorf("second run = %+v, want nothing to migrate", again)
+	}
+}
+
+func TestLoadMetadata_NewerFormat(t *testing.T) {
+	tmpDir := t.TempDir()
+	m := NewManager(nil, &models.Config{SnapshotDir: tmpDir})
+	snap := &models.Snapshot{Name: "future", Path: filepath.Join(tmpDir, "future"), FormatVersion: models.SnapshotFormatVersion + 1}
+	os.MkdirAll(snap.Path, 0755)
+	if err := m.saveMetadata(snap); err != nil {
+		t.Fatalf("failed to save metadata: %v", err)
+	}
+	if _, err := m.Get("future"); err == nil {
+		t.Error("expected an error for a newer snapshot format")
+	}
+}
@@ -0,0 +1,84 @@
+package snapshot
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// NameRules are the rules new snapshot names must follow. Names become
+// directory names under the snapshot directory, so the built-in rules keep
+// them to one safe path component.
+type NameRules struct {
+	Charset          string   `json:"charset"` // Every character must be in this class
+	MaxLength        int      `json:"max_length"`
+	FirstChar        string   `json:"first_char"`        // The first character must be in this class
+	ReservedPrefixes []string `json:"reserved_prefixes"` // Kept for dataclean's own snapshots
+	Pattern          string   `json:"pattern,omitempty"` // name_pattern from the config
+}
+
+const maxNameLength = 100
+
+var (
+	nameCharset   = regexp.MustCompile(`^[A-Za-z0-9._-]*$`)
+	nameFirstChar = regexp.MustCompile(`^[A-Za-z0-9]`)
+
+	// reservedPrefixes start the names of pre-restore and pre-reset backups
+	reservedPrefixes = []string{"_"}
+)
+
+// NameRules returns the rules ValidateName applies
+func (m *Manager) NameRules() NameRules {
+	return NameRules{
+		Charset:          "[A-Za-z0-9._-]",
+		MaxLength:        maxNameLength,
+		FirstChar:        "[A-Za-z0-9]",
+		ReservedPrefixes: reservedPrefixes,
+		Pattern:          m.cfg.NamePattern,
+	}
+}
+
+// ValidateName checks a name for a new snapshot against the built-in rules
+// and the config's name_pattern
+func (m *Manager) ValidateName(name string) error {
+	for _, prefix := range reservedPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return fmt.Errorf("invalid snapshot name %q: names starting with %q are reserved for dataclean's own backups", name, prefix)
+		}
+	}
+	if err := checkName(name); err != nil {
+		return err
+	}
+	if !nameCharset.MatchString(name) {
+		return fmt.Errorf("invalid snapshot name %q: use only letters, digits, '.', '_' and '-'", name)
+	}
+	if !nameFirstChar.MatchString(name) {
+		return fmt.Errorf("invalid snapshot name %q: must start with a letter or digit", name)
+	}
+	if len(name) > maxNameLength {
+		return fmt.Errorf("invalid snapshot name %q: longer than %d characters", name, maxNameLength)
+	}
+	if m.cfg.NamePattern != "" {
+		pattern, err := regexp.Compile(m.cfg.NamePattern)
+		if err != nil {
+			return fmt.Errorf("name_pattern: %w", err)
+		}
+		if !pattern.MatchString(name) {
+			return fmt.Errorf("invalid snapshot name %q: doesn't match name_pattern %s", name, m.cfg.NamePattern)
+		}
+	}
+	return nil
+}
+
+// checkName rejects names that aren't a single path component. It guards
+// every lookup by name, so snapshots named before ValidateName existed still
+// resolve but "../elsewhere" never leaves the snapshot directory.
+func checkName(name string) error {
+	switch {
+	case name == "":
+		return fmt.Errorf("snapshot name is empty")
+	case name == "." || name == "..", strings.ContainsAny(name, "/\\\x00"):
+		return fmt.Errorf("invalid snapshot name %q: must not be a path", name)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+func TestValidateName(t *testing.T) {
+	m := NewManager(nil, &models.Config{SnapshotDir: t.TempDir()})
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{"before-migration", true},
+		{"v1.2.3", true},
+		{"snapshot-2024-01-15-143052", true},
+		{"", false},
+		{"../../etc", false},
+		{"a/b", false},
+		{`a\b`, false},
+		{"..", false},
+		{".hidden", false},
+		{"-flag", false},
+		{"_pre-restore-20240101-000000", false},
+		{"with space", false},
+		{"name@20240101T000000Z", false},
+		{strings.Repeat("a", maxNameLength), true},
+		{strings.Repeat("a", maxNameLength+1), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := m.ValidateName(tt.name)
+			if (err == nil) != tt.valid {
+				t.Errorf("ValidateName(%q) = %v, want valid=%v", tt.name, err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestValidateName_Pattern(t *testing.T) {
+	m := NewManager(nil, &models.Config{SnapshotDir: t.TempDir(), NamePattern: `^(feature|release)-`})
+	if err := m.ValidateName("feature-login"); err != nil {
+		t.Errorf("ValidateName(feature-login) = %v", err)
+	}
+	if err := m.ValidateName("scratch"); err == nil {
+		t.Error("expected scratch to be rejected by name_pattern")
+	}
+}
+
+func TestLookupRejectsPaths(t *testing.T) {
+	root := t.TempDir()
+	outside := filepath.Join(root, "outside")
+	os.MkdirAll(outside, 0755)
+	os.WriteFile(filepath.Join(outside, "metadata.yaml"), []byte("name: outside\n"), 0644)
+
+	m := NewManager(nil, &models.Config{SnapshotDir: filepath.Join(root, "snapshots")})
+	if _, err := m.Get("../outside"); err == nil {
+		t.Error("expected Get to reject a path")
+	}
+	if err := m.Delete("../outside"); err == nil {
+		t.Error("expected Delete to reject a path")
+	}
+	if _, err := os.Stat(outside); err != nil {
+		t.Errorf("Delete removed a directory outside the snapshot directory: %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package snapshot
+
+import (
+	"slices"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+// defaultRestoreParallelism is how many volumes a restore imports at once
+// unless restore_parallelism says otherwise
+const defaultRestoreParallelism = 4
+
+// restoreDependencies returns, for each volume, the indexes of the volumes
+// restore_after says must be restored before it
+func restoreDependencies(volumes []models.Volume, after map[models.DatastoreType][]models.DatastoreType) [][]int {
+	deps := make([][]int, len(volumes))
+	for i, vol := range volumes {
+		for j, other := range volumes {
+			if i != j && slices.Contains(after[vol.DatastoreType], other.DatastoreType) {
+				deps[i] = append(deps[i], j)
+			}
+		}
+	}
+	return deps
+}
+
+// runOrdered calls run for every index, up to limit at a time, each only
+// once run has returned nil for all of its deps. After the first error no
+// more are started; it waits for those in flight and returns that error.
+// deps must not have cycles (config validation rejects them).
+func runOrdered(n int, deps [][]int, limit int, run func(i int) error) error {
+	if limit <= 0 {
+		limit = 1
+	}
+	type outcome struct {
+		i   int
+		err error
+	}
+	finished := make(chan outcome)
+	started := make([]bool, n)
+	done := make([]bool, n)
+	running := 0
+	var firstErr error
+
+	ready := func(i int) bool {
+		for _, d := range deps[i] {
+			if !done[d] {
+				return false
+			}
+		}
+		return true
+	}
+	for {
+		for i := 0; i < n && firstErr == nil && running < limit; i++ {
+			if started[i] || !ready(i) {
+				continue
+			}
+			started[i] = true
+			running++
+			go func(i int) { finished <- outcome{i, run(i)} }(i)
+		}
+		if running == 0 {
+			return firstErr
+		}
+
+		o := <-finished
+		running--
+		if o.err != nil && firstErr == nil {
+			firstErr = o.err
+		}
+		done[o.i] = o.err == nil
+	}
+}
@@ -0,0 +1,75 @@
+package snapshot
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+func TestRestoreDependencies(t *testing.T) {
+	volumes := []models.Volume{
+		{Name: "search", DatastoreType: models.DatastoreElastic},
+		{Name: "pgdata", DatastoreType: models.DatastorePostgres},
+		{Name: "cache", DatastoreType: models.DatastoreRedis},
+		{Name: "analytics", DatastoreType: models.DatastorePostgres},
+	}
+	after := map[models.DatastoreType][]models.DatastoreType{
+		models.DatastoreElastic: {models.DatastorePostgres},
+	}
+
+	got := restoreDependencies(volumes, after)
+	want := [][]int{{1, 3}, nil, nil, nil}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("restoreDependencies() = %v, want %v", got, want)
+	}
+}
+
+func TestRunOrdered(t *testing.T) {
+	// 2 waits for 0 and 1; 3 waits for 2
+	deps := [][]int{nil, nil, {0, 1}, {2}}
+
+	var mu sync.Mutex
+	var order []int
+	running, peak := 0, 0
+	err := runOrdered(len(deps), deps, 2, func(i int) error {
+		mu.Lock()
+		running++
+		peak = max(peak, running)
+		mu.Unlock()
+
+		mu.Lock()
+		running--
+		order = append(order, i)
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("runOrdered() failed: %v", err)
+	}
+	if len(order) != 4 || order[2] != 2 || order[3] != 3 {
+		t.Errorf("order = %v, want 0 and 1 (any order), then 2, then 3", order)
+	}
+	if peak > 2 {
+		t.Errorf("%d ran at once, limit is 2", peak)
+	}
+
+	// A failure stops everything that depends on it, and anything not yet started
+	var ran []int
+	failed := errors.New("import failed")
+	err = runOrdered(len(deps), deps, 1, func(i int) error {
+		ran = append(ran, i)
+		if i == 1 {
+			return failed
+		}
+		return nil
+	})
+	if err != failed {
+		t.Errorf("runOrdered() = %v, want %v", err, failed)
+	}
+	if !reflect.DeepEqual(ran, []int{0, 1}) {
+		t.Errorf("ran %v, want only 0 and 1", ran)
+	}
+}
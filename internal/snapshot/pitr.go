@@ -0,0 +1,342 @@
+package snapshot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/stackgen-cli/dataclean/internal/datastore"
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+// walDirName holds archived Postgres WAL, one directory per volume. List
+// skips it because it has no metadata.
+const walDirName = "_wal"
+
+// WALDir returns where a volume's archived WAL is kept
+func (m *Manager) WALDir(vol models.Volume) string {
+	return filepath.Join(m.cfg.SnapshotDir, walDirName, sanitizeName(vol.Name))
+}
+
+// EnablePITR turns on WAL archiving for a Postgres volume and restarts its
+// container so the setting takes effect. Restores can reach any point in time
+// after the next snapshot.
+func (m *Manager) EnablePITR(vol models.Volume) error {
+	if err := m.cfg.Writable(); err != nil {
+		return err
+	}
+	if vol.DatastoreType != models.DatastorePostgres {
+		return fmt.Errorf("point-in-time recovery needs a Postgres volume, %s is %s", vol.Name, vol.DatastoreType)
+	}
+	if err := m.setWALArchive(vol, datastore.PostgresEnableWALArchive); err != nil {
+		return fmt.Errorf("failed to enable WAL archiving for %s: %w", vol.Name, err)
+	}
+	if err := os.MkdirAll(m.WALDir(vol), 0755); err != nil {
+		return err
+	}
+
+	state, err := m.LoadState()
+	if err != nil {
+		return err
+	}
+	state.PITR[vol.Name] = time.Now()
+	return m.saveState(state)
+}
+
+// DisablePITR turns WAL archiving off again. WAL archived so far stays in
+// WALDir until deleted by hand.
+func (m *Manager) DisablePITR(vol models.Volume) error {
+	if err := m.cfg.Writable(); err != nil {
+		return err
+	}
+	if err := m.setWALArchive(vol, datastore.PostgresDisableWALArchive); err != nil {
+		return fmt.Errorf("failed to disable WAL archiving for %s: %w", vol.Name, err)
+	}
+
+	state, err := m.LoadState()
+	if err != nil {
+		return err
+	}
+	delete(state.PITR, vol.Name)
+	return m.saveState(state)
+}
+
+// setWALArchive applies archive settings and restarts the server, which
+// archive_mode requires
+func (m *Manager) setWALArchive(vol models.Volume, script string) error {
+	container, err := m.client.ResolveContainer(vol)
+	if err != nil {
+		return err
+	}
+	if _, err := m.client.ExecOutput(container, script, datastore.CredentialEnv(vol.Credentials)...); err != nil {
+		return err
+	}
+	if err := m.client.RestartContainer(container); err != nil {
+		return err
+	}
+	return m.waitHealthy([]models.Volume{vol}, false)
+}
+
+// SyncWAL moves archived WAL segments out of a volume's running Postgres
+// container into WALDir, returning how many were moved
+func (m *Manager) SyncWAL(vol models.Volume) (int, error) {
+	container, err := m.client.ResolveContainer(vol)
+	if err != nil {
+		return 0, err
+	}
+	env := datastore.CredentialEnv(vol.Credentials)
+
+	listing, err := m.client.ExecOutput(container, datastore.PostgresListWAL, env...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list archived WAL of %s: %w", vol.Name, err)
+	}
+	dir := m.WALDir(vol)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return 0, err
+	}
+
+	// Segments are only removed from the container once they're safely here;
+	// anything copied before a failure is picked up again next time
+	var moved []string
+	for _, name := range strings.Fields(listing) {
+		if !datastore.ValidWALName(name) {
+			continue // an in-progress copy
+		}
+		dest := filepath.Join(dir, name)
+		if _, err := os.Stat(dest); err != nil {
+			tmp := tempPath(dest)
+			if err := m.client.ExecToFile(container, datastore.PostgresFetchWAL(name), tmp, env...); err != nil {
+				os.Remove(tmp)
+				return 0, fmt.Errorf("failed to copy WAL segment %s of %s: %w", name, vol.Name, err)
+			}
+			if err := os.Rename(tmp, dest); err != nil {
+				return 0, err
+			}
+		}
+		moved = append(moved, name)
+	}
+
+	if len(moved) > 0 {
+		if _, err := m.client.ExecOutput(container, datastore.PostgresRemoveWAL(moved), env...); err != nil {
+			return len(moved), fmt.Errorf("failed to remove copied WAL of %s: %w", vol.Name, err)
+		}
+	}
+	return len(moved), nil
+}
+
+// flushWAL archives the segment being written and waits until it, and
+// everything before it, has been moved to WALDir, so a restore can reach the
+// present
+func (m *Manager) flushWAL(vol models.Volume, container string) error {
+	env := datastore.CredentialEnv(vol.Credentials)
+	out, err := m.client.ExecOutput(container, datastore.PostgresSwitchWAL, env...)
+	if err != nil {
+		return fmt.Errorf("failed to switch WAL of %s: %w", vol.Name, err)
+	}
+	last := strings.TrimSpace(out)
+
+	timeout := m.healthTimeout()
+	deadline := time.Now().Add(timeout)
+	for {
+		if _, err := m.SyncWAL(vol); err != nil {
+			return err
+		}
+		if _, err := os.Stat(filepath.Join(m.WALDir(vol), last)); err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("WAL segment %s of %s was not archived within %s", last, vol.Name, timeout)
+		}
+		time.Sleep(healthPollInterval)
+	}
+}
+
+// syncPITR moves archived WAL out of every volume that archives it, so it
+// isn't copied into snapshots. Failures leave the WAL where it is for the
+// next sync.
+func (m *Manager) syncPITR(volumes []models.Volume) {
+	state, err := m.LoadState()
+	if err != nil {
+		return
+	}
+	for _, vol := range volumes {
+		if _, ok := state.PITR[vol.Name]; ok {
+			m.SyncWAL(vol)
+		}
+	}
+}
+
+// pitrVolumes returns the volumes of a snapshot that can be rolled forward:
+// Postgres volumes whose WAL was being archived when the snapshot was taken
+func pitrVolumes(snapshot *models.Snapshot, state *models.State) []models.Volume {
+	var volumes []models.Volume
+	for _, vol := range snapshot.Volumes {
+		since, ok := state.PITR[vol.Name]
+		if ok && vol.DatastoreType == models.DatastorePostgres && !snapshot.Timestamp.Before(since) {
+			volumes = append(volumes, vol)
+		}
+	}
+	return volumes
+}
+
+// recovery is what a point-in-time restore applies on top of a snapshot
+type recovery struct {
+	target time.Time
+	wal    []models.Volume // Postgres volumes replaying archived WAL
+	aof    []aofRestore    // Redis volumes loading a later AOF capture
+}
+
+// aofRestore pairs a Redis volume with the capture it gets
+type aofRestore struct {
+	vol     models.Volume
+	capture aofCapture
+}
+
+// rollForward reports what a snapshot's volumes can be rolled forward with
+// up to target, without capturing anything new
+func (m *Manager) rollForward(snapshot *models.Snapshot, state *models.State, target time.Time) *recovery {
+	r := &recovery{target: target, wal: pitrVolumes(snapshot, state)}
+	for _, vol := range snapshot.Volumes {
+		if !m.cfg.AOFCapture.Enabled(vol) {
+			continue
+		}
+		if c, ok := m.aofCaptureBetween(vol, snapshot.Timestamp, target); ok {
+			r.aof = append(r.aof, aofRestore{vol, c})
+		}
+	}
+	return r
+}
+
+func (r *recovery) empty() bool {
+	return len(r.wal) == 0 && len(r.aof) == 0
+}
+
+// PITRBase returns the newest snapshot to roll forward to target: taken
+// before target, with Postgres WAL archived or a Redis AOF captured since
+func (m *Manager) PITRBase(target time.Time) (string, error) {
+	state, err := m.LoadState()
+	if err != nil {
+		return "", err
+	}
+	if len(state.PITR) == 0 && len(m.cfg.AOFCapture.Volumes) == 0 {
+		return "", fmt.Errorf("point-in-time recovery is not enabled (see 'dataclean pitr enable' and aof_capture)")
+	}
+
+	snapshots, err := m.List()
+	if err != nil {
+		return "", err
+	}
+	for _, s := range snapshots { // newest first
+		if !s.Timestamp.After(target) && (len(pitrVolumes(&s, state)) > 0 || m.capturesAOF(&s)) {
+			return s.Name, nil
+		}
+	}
+	return "", fmt.Errorf("no snapshot was taken between enabling point-in-time recovery and %s", target.Format("2006-01-02 15:04:05"))
+}
+
+// capturesAOF reports whether any of a snapshot's volumes has AOF capture on
+func (m *Manager) capturesAOF(snapshot *models.Snapshot) bool {
+	for _, vol := range snapshot.Volumes {
+		if m.cfg.AOFCapture.Enabled(vol) {
+			return true
+		}
+	}
+	return false
+}
+
+// preparePITR checks that a snapshot can be rolled forward to target and
+// brings the WAL archive and AOF captures up to date while the servers are
+// still running
+func (m *Manager) preparePITR(snapshot *models.Snapshot, target time.Time) (*recovery, error) {
+	if snapshot.Timestamp.After(target) {
+		return nil, fmt.Errorf("snapshot %s was taken after %s", snapshot.Name, target.Format("2006-01-02 15:04:05"))
+	}
+	state, err := m.LoadState()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, vol := range pitrVolumes(snapshot, state) {
+		// A stopped server has nothing left to archive
+		if container, err := m.client.ResolveContainer(vol); err == nil {
+			if err := m.flushWAL(vol, container); err != nil {
+				return nil, err
+			}
+		}
+		if entries, err := os.ReadDir(m.WALDir(vol)); err != nil || len(entries) == 0 {
+			return nil, fmt.Errorf("no archived WAL for %s in %s", vol.Name, m.WALDir(vol))
+		}
+	}
+
+	// Capture the present when the target is past the last capture
+	for _, vol := range snapshot.Volumes {
+		if !m.cfg.AOFCapture.Enabled(vol) {
+			continue
+		}
+		captures := m.aofCaptures(vol)
+		if len(captures) == 0 || captures[len(captures)-1].At.Before(target) {
+			if _, err := m.client.ResolveContainer(vol); err == nil {
+				if _, err := m.CaptureAOF(vol); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	r := m.rollForward(snapshot, state, target)
+	if r.empty() {
+		return nil, fmt.Errorf("nothing to roll snapshot %s forward with: no Postgres volume archiving WAL since before it, and no Redis AOF captured after it", snapshot.Name)
+	}
+	return r, nil
+}
+
+// stageRecovery sets up restored volumes to reach the target when they next
+// start: Postgres replays WAL, Redis loads the chosen AOF capture
+func (m *Manager) stageRecovery(r *recovery) error {
+	if r == nil {
+		return nil
+	}
+	for _, vol := range r.wal {
+		if err := m.client.VolumeScript(vol, m.WALDir(vol), datastore.PostgresStageRecovery(r.target)); err != nil {
+			return fmt.Errorf("failed to stage WAL replay for %s: %w", vol.Name, err)
+		}
+	}
+	for _, a := range r.aof {
+		dir, file := filepath.Split(a.capture.Path)
+		if err := m.client.VolumeScript(a.vol, dir, datastore.RedisStageAOF(file)); err != nil {
+			return fmt.Errorf("failed to restore AOF capture for %s: %w", a.vol.Name, err)
+		}
+	}
+	return nil
+}
+
+// finishRecovery waits for WAL replay to end and removes the recovery settings
+func (m *Manager) finishRecovery(volumes []models.Volume) error {
+	timeout := m.healthTimeout()
+	for _, vol := range volumes {
+		container, err := m.client.ResolveContainer(vol)
+		if err != nil {
+			return fmt.Errorf("volume %s: %w", vol.Name, err)
+		}
+		env := datastore.CredentialEnv(vol.Credentials)
+
+		deadline := time.Now().Add(timeout)
+		for {
+			out, err := m.client.ExecOutput(container, datastore.PostgresInRecovery, env...)
+			if err == nil && strings.TrimSpace(out) == "f" {
+				break
+			}
+			if time.Now().After(deadline) {
+				return fmt.Errorf("volume %s: WAL replay not finished after %s (see the container logs)", vol.Name, timeout)
+			}
+			time.Sleep(healthPollInterval)
+		}
+
+		if _, err := m.client.ExecOutput(container, datastore.PostgresFinishRecovery, env...); err != nil {
+			return fmt.Errorf("failed to clear recovery settings of %s: %w", vol.Name, err)
+		}
+	}
+	return nil
+}
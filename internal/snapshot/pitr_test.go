@@ -0,0 +1,50 @@
+package snapshot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+func TestPITRBase(t *testing.T) {
+	tmpDir := t.TempDir()
+	m := &Manager{cfg: &models.Config{SnapshotDir: tmpDir}}
+
+	enabled := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	target := time.Date(2024, 5, 1, 14, 30, 0, 0, time.UTC)
+
+	if _, err := m.PITRBase(target); err == nil {
+		t.Error("expected error before point-in-time recovery is enabled")
+	}
+
+	state, _ := m.LoadState()
+	state.PITR["project_pgdata"] = enabled
+	if err := m.saveState(state); err != nil {
+		t.Fatalf("saveState failed: %v", err)
+	}
+
+	// Taken before archiving started: no WAL to roll it forward
+	writeChainSnapshot(t, m, "early", "", enabled.Add(-time.Hour), nil)
+	if _, err := m.PITRBase(target); err == nil {
+		t.Error("expected error when no snapshot follows enabling")
+	}
+
+	writeChainSnapshot(t, m, "morning", "", enabled.Add(time.Hour), nil)
+	writeChainSnapshot(t, m, "noon", "", enabled.Add(3*time.Hour), nil)
+	writeChainSnapshot(t, m, "evening", "", target.Add(time.Hour), nil)
+
+	base, err := m.PITRBase(target)
+	if err != nil {
+		t.Fatalf("PITRBase() failed: %v", err)
+	}
+	if base != "noon" {
+		t.Errorf("PITRBase() = %s, want noon (newest before target)", base)
+	}
+
+	snap, _ := m.Get("noon")
+	vols := pitrVolumes(snap, state)
+	if len(vols) != 1 || vols[0].Name != "project_pgdata" {
+		t.Errorf("pitrVolumes() = %+v, want only the Postgres volume", vols)
+	}
+}
@@ -0,0 +1,245 @@
+package snapshot
+
+import (
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+// PlanCreate simulates CreateWithOptions and returns the steps it would perform
+func (m *Manager) PlanCreate(name string, volumes []models.Volume) (*models.Plan, error) {
+	if err := m.ValidateName(name); err != nil {
+		return nil, err
+	}
+	plan := &models.Plan{Operation: "snapshot", Snapshot: name}
+	snapshotDir := filepath.Join(m.cfg.SnapshotDir, name)
+
+	m.planExports(plan, volumes, snapshotDir)
+	return plan, nil
+}
+
+// PlanPrune simulates CleanupOldSnapshots and returns the snapshots it would delete
+func (m *Manager) PlanPrune() (*models.Plan, error) {
+	plan := &models.Plan{Operation: "prune"}
+	if err := m.planRetention(plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+func (m *Manager) planRetention(plan *models.Plan) error {
+	expired, err := m.expiredSnapshots()
+	if err != nil {
+		return err
+	}
+	for _, s := range expired {
+		plan.Add(models.PlanAction{
+			Kind:      models.ActionDeleteSnapshot,
+			Target:    s.Name,
+			Path:      s.Path,
+			SizeBytes: s.SizeBytes,
+			Note:      expiryNote(s, m.cfg.RetentionDays),
+		})
+	}
+	return nil
+}
+
+// expiryNote explains why retention deletes a snapshot
+func expiryNote(s models.Snapshot, retentionDays int) string {
+	if s.ExpiresAt != nil {
+		return "expired " + s.ExpiresAt.Format("2006-01-02 15:04")
+	}
+	return fmt.Sprintf("older than retention (%d days)", retentionDays)
+}
+
+// PlanRestore simulates Restore and returns the steps it would perform
+func (m *Manager) PlanRestore(name string) (*models.Plan, error) {
+	return m.PlanRestoreWithOptions(name, RestoreOptions{})
+}
+
+// PlanRestoreWithOptions simulates RestoreWithOptions; only Into, SkipBackup,
+// and LeaveStopped change the plan
+func (m *Manager) PlanRestoreWithOptions(name string, opts RestoreOptions) (*models.Plan, error) {
+	if err := checkName(name); err != nil {
+		return nil, err
+	}
+	snapshotDir := filepath.Join(m.cfg.SnapshotDir, name)
+	snapshot, err := m.loadMetadata(snapshotDir)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkReadable(snapshot); err != nil {
+		return nil, err
+	}
+	if err := m.selectRestored(snapshot); err != nil {
+		return nil, err
+	}
+
+	plan := &models.Plan{Operation: "restore", Snapshot: name}
+
+	archives := make([]string, len(snapshot.Volumes))
+	for i, vol := range snapshot.Volumes {
+		archive, err := m.resolveArchive(snapshot, vol)
+		if err != nil {
+			archive = archivePath(snapshotDir, vol)
+		}
+		archives[i] = archive
+		plan.Add(models.PlanAction{
+			Kind:   models.ActionVerifyArchive,
+			Target: vol.Name,
+			Path:   archive,
+			Note:   "abort before any change if unreadable or checksum differs",
+		})
+	}
+	if snapshot.Volumes, err = retarget(snapshot.Volumes, opts.Into); err != nil {
+		return nil, err
+	}
+
+	if m.cfg.BackupBeforeRestore && !opts.SkipBackup {
+		backupName := fmt.Sprintf("_pre-restore-%s", time.Now().Format("20060102-150405"))
+		m.planBackup(plan, backupName, snapshot.Volumes)
+	}
+
+	m.planStops(plan, snapshot.Volumes)
+	for i, vol := range snapshot.Volumes {
+		action := models.PlanAction{
+			Kind:      models.ActionImportVolume,
+			Target:    vol.Name,
+			Path:      archives[i],
+			SizeBytes: vol.SizeBytes,
+		}
+		// A volume the restore creates has nothing to clear
+		if m.volumeExists(vol) {
+			plan.Add(models.PlanAction{
+				Kind:   models.ActionClearVolume,
+				Target: vol.Name,
+				Note:   "skipped if already empty",
+			})
+		} else {
+			action.Note = "into a new volume"
+		}
+		if vol.Exporter != "" {
+			action.Note = strings.TrimPrefix(action.Note+", with exporter plugin "+vol.Exporter, ", ")
+		}
+		plan.Add(action)
+	}
+	m.planStarts(plan, snapshot.Volumes)
+	if !opts.LeaveStopped {
+		for _, service := range slices.Sorted(maps.Keys(m.cfg.PostRestoreExec)) {
+			plan.Add(models.PlanAction{
+				Kind:   models.ActionExec,
+				Target: service,
+				Note:   m.cfg.PostRestoreExec[service] + " (post_restore_exec, once healthy)",
+			})
+		}
+	}
+
+	return plan, nil
+}
+
+// PlanReset simulates Reset and returns the steps it would perform
+func (m *Manager) PlanReset(volumes []models.Volume) (*models.Plan, error) {
+	plan := &models.Plan{Operation: "reset"}
+
+	if m.cfg.BackupBeforeRestore {
+		backupName := fmt.Sprintf("_pre-reset-%s", time.Now().Format("20060102-150405"))
+		m.planBackup(plan, backupName, volumes)
+	}
+
+	m.planStops(plan, volumes)
+	for _, vol := range volumes {
+		plan.Add(models.PlanAction{
+			Kind:      models.ActionClearVolume,
+			Target:    vol.Name,
+			SizeBytes: m.volumeSize(vol),
+			Note:      "all data deleted",
+		})
+	}
+	m.planStarts(plan, volumes)
+
+	return plan, nil
+}
+
+// planBackup adds the automatic backup snapshot taken before restore/reset
+func (m *Manager) planBackup(plan *models.Plan, backupName string, volumes []models.Volume) {
+	plan.Add(models.PlanAction{
+		Kind:   models.ActionCreateBackup,
+		Target: backupName,
+		Path:   filepath.Join(m.cfg.SnapshotDir, backupName),
+	})
+	m.planExports(plan, volumes, filepath.Join(m.cfg.SnapshotDir, backupName))
+}
+
+// planExports adds the stop/export/start sequence used when creating a snapshot
+func (m *Manager) planExports(plan *models.Plan, volumes []models.Volume, snapshotDir string) {
+	m.planStops(plan, volumes)
+	for _, vol := range volumes {
+		vol.ArchiveDir = m.archiveDir(filepath.Base(snapshotDir), vol)
+		note := "uncompressed size"
+		if exporter := m.cfg.ExporterFor(vol); exporter != "" {
+			note += ", with exporter plugin " + exporter
+		}
+		plan.Add(models.PlanAction{
+			Kind:      models.ActionExportVolume,
+			Target:    vol.Name,
+			Path:      archivePath(snapshotDir, vol),
+			SizeBytes: m.volumeSize(vol),
+			Note:      note,
+		})
+	}
+	m.planStarts(plan, volumes)
+}
+
+func (m *Manager) planStops(plan *models.Plan, volumes []models.Volume) {
+	for _, name := range containerNames(volumes) {
+		plan.Add(models.PlanAction{Kind: models.ActionStopContainer, Target: name})
+	}
+}
+
+func (m *Manager) planStarts(plan *models.Plan, volumes []models.Volume) {
+	for _, name := range containerNames(volumes) {
+		plan.Add(models.PlanAction{Kind: models.ActionStartContainer, Target: name})
+	}
+}
+
+// volumeSize returns the live size of a volume, or 0 when it can't be measured
+func (m *Manager) volumeSize(vol models.Volume) int64 {
+	// Managers built without a Docker client (tests, metadata-only use) can't measure
+	if m.client == nil {
+		return 0
+	}
+	size, err := m.client.GetVolumeSize(vol)
+	if err != nil {
+		return 0
+	}
+	return size
+}
+
+// volumeExists reports whether a volume exists, assuming it does when that
+// can't be checked
+func (m *Manager) volumeExists(vol models.Volume) bool {
+	if m.client == nil {
+		return true
+	}
+	_, exists, err := m.client.VolumeLabels(vol.Name)
+	return exists || err != nil
+}
+
+// containerNames returns the unique container names attached to the volumes
+func containerNames(volumes []models.Volume) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, v := range volumes {
+		if v.ContainerName == "" || seen[v.ContainerName] {
+			continue
+		}
+		seen[v.ContainerName] = true
+		names = append(names, v.ContainerName)
+	}
+	return names
+}
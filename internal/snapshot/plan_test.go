@@ -0,0 +1,208 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+func TestPlanRestore(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "dataclean-plan-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	snapshotDir := filepath.Join(tmpDir, "baseline")
+	os.MkdirAll(snapshotDir, 0755)
+	metadata := `
+name: baseline
+timestamp: 2024-01-15T10:30:00Z
+volumes:
+  - name: project_pgdata
+    datastore_type: postgres
+    container_name: project-db
+    size_bytes: 2048
+  - name: project_redis
+    datastore_type: redis
+    container_name: project-db
+    size_bytes: 1024
+`
+	os.WriteFile(filepath.Join(snapshotDir, "metadata.yaml"), []byte(metadata), 0644)
+
+	cfg := &models.Config{SnapshotDir: tmpDir}
+	m := &Manager{cfg: cfg}
+
+	plan, err := m.PlanRestore("baseline")
+	if err != nil {
+		t.Fatalf("PlanRestore() failed: %v", err)
+	}
+
+	expected := []models.PlanActionKind{
+		models.ActionVerifyArchive,
+		models.ActionVerifyArchive,
+		models.ActionStopContainer,
+		models.ActionClearVolume,
+		models.ActionImportVolume,
+		models.ActionClearVolume,
+		models.ActionImportVolume,
+		models.ActionStartContainer,
+	}
+	if len(plan.Actions) != len(expected) {
+		t.Fatalf("expected %d actions, got %d: %+v", len(expected), len(plan.Actions), plan.Actions)
+	}
+	for i, kind := range expected {
+		if plan.Actions[i].Kind != kind {
+			t.Errorf("action %d = %s, want %s", i, plan.Actions[i].Kind, kind)
+		}
+	}
+	if plan.EstimatedBytes != 3072 {
+		t.Errorf("EstimatedBytes = %d, want 3072", plan.EstimatedBytes)
+	}
+}
+
+func TestPlanRestore_WithBackup(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "dataclean-plan-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	snapshotDir := filepath.Join(tmpDir, "baseline")
+	os.MkdirAll(snapshotDir, 0755)
+	os.WriteFile(filepath.Join(snapshotDir, "metadata.yaml"), []byte(`
+name: baseline
+volumes:
+  - name: project_pgdata
+    datastore_type: postgres
+`), 0644)
+
+	cfg := &models.Config{SnapshotDir: tmpDir, BackupBeforeRestore: true}
+	m := &Manager{cfg: cfg}
+
+	plan, err := m.PlanRestore("baseline")
+	if err != nil {
+		t.Fatalf("PlanRestore() failed: %v", err)
+	}
+
+	// Archives are verified before the backup is taken
+	if plan.Actions[0].Kind != models.ActionVerifyArchive {
+		t.Errorf("first action = %s, want %s", plan.Actions[0].Kind, models.ActionVerifyArchive)
+	}
+	if plan.Actions[1].Kind != models.ActionCreateBackup {
+		t.Errorf("second action = %s, want %s", plan.Actions[1].Kind, models.ActionCreateBackup)
+	}
+	if plan.Actions[2].Kind != models.ActionExportVolume {
+		t.Errorf("third action = %s, want %s", plan.Actions[2].Kind, models.ActionExportVolume)
+	}
+}
+
+func TestPlanRestore_PostRestoreExec(t *testing.T) {
+	cfg := &models.Config{SnapshotDir: t.TempDir(), PostRestoreExec: map[string]string{
+		"worker": "bin/replay",
+		"api":    "php artisan cache:clear",
+	}}
+	m := NewManager(nil, cfg)
+	writeChainSnapshot(t, m, "baseline", "", time.Now(), map[string]string{"project_pgdata": "pg"})
+
+	plan, err := m.PlanRestore("baseline")
+	if err != nil {
+		t.Fatalf("PlanRestore() failed: %v", err)
+	}
+	var services []string
+	for _, a := range plan.Actions {
+		if a.Kind == models.ActionExec {
+			services = append(services, a.Target)
+		}
+	}
+	if !slices.Equal(services, []string{"api", "worker"}) {
+		t.Errorf("exec actions for %v, want [api worker]", services)
+	}
+
+	plan, err = m.PlanRestoreWithOptions("baseline", RestoreOptions{LeaveStopped: true})
+	if err != nil {
+		t.Fatalf("PlanRestoreWithOptions() failed: %v", err)
+	}
+	if slices.ContainsFunc(plan.Actions, func(a models.PlanAction) bool { return a.Kind == models.ActionExec }) {
+		t.Error("--leave-stopped plan runs post_restore_exec")
+	}
+}
+
+func TestPlanRestore_Into(t *testing.T) {
+	m := NewManager(nil, &models.Config{SnapshotDir: t.TempDir()})
+	writeChainSnapshot(t, m, "baseline", "", time.Now(), map[string]string{"project_pgdata": "pg", "project_uploads": "files"})
+
+	into := map[string]models.Volume{"pgdata": {Name: "copy_pgdata"}}
+	plan, err := m.PlanRestoreWithOptions("baseline", RestoreOptions{Into: into})
+	if err != nil {
+		t.Fatalf("PlanRestoreWithOptions() failed: %v", err)
+	}
+	var imports []string
+	for _, a := range plan.Actions {
+		if a.Kind == models.ActionImportVolume {
+			imports = append(imports, a.Target+" <- "+filepath.Base(a.Path))
+		}
+	}
+	want := []string{"copy_pgdata <- project_pgdata.tar.gz", "project_uploads <- project_uploads.tar.gz"}
+	if !slices.Equal(imports, want) {
+		t.Errorf("imports = %v, want %v", imports, want)
+	}
+
+	if _, err := m.PlanRestoreWithOptions("baseline", RestoreOptions{Into: map[string]models.Volume{"missing": {Name: "x"}}}); err == nil {
+		t.Error("expected error restoring a volume the snapshot doesn't have")
+	}
+	twice := map[string]models.Volume{"pgdata": {Name: "project_uploads"}}
+	if _, err := m.PlanRestoreWithOptions("baseline", RestoreOptions{Into: twice}); err == nil {
+		t.Error("expected error restoring two volumes into one")
+	}
+}
+
+func TestPlanPrune_OnlyPruneDeletes(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "dataclean-plan-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	old := time.Now().AddDate(0, 0, -30).Format(time.RFC3339)
+	recent := time.Now().Format(time.RFC3339)
+	for name, ts := range map[string]string{"old": old, "recent": recent, "_pre-restore-old": old} {
+		dir := filepath.Join(tmpDir, name)
+		os.MkdirAll(dir, 0755)
+		os.WriteFile(filepath.Join(dir, "metadata.yaml"), []byte("name: "+name+"\ntimestamp: "+ts+"\n"), 0644)
+	}
+
+	cfg := &models.Config{SnapshotDir: tmpDir, RetentionDays: 7}
+	m := &Manager{cfg: cfg}
+
+	deletes := func(plan *models.Plan) []string {
+		var targets []string
+		for _, a := range plan.Actions {
+			if a.Kind == models.ActionDeleteSnapshot {
+				targets = append(targets, a.Target)
+			}
+		}
+		return targets
+	}
+
+	plan, err := m.PlanPrune()
+	if err != nil {
+		t.Fatalf("PlanPrune() failed: %v", err)
+	}
+	if got := deletes(plan); len(got) != 1 || got[0] != "old" {
+		t.Errorf("expected only 'old' to be deleted, got %v", got)
+	}
+
+	// Taking a snapshot leaves expired snapshots to 'prune'
+	plan, err = m.PlanCreate("new", []models.Volume{{Name: "project_pgdata"}})
+	if err != nil {
+		t.Fatalf("PlanCreate() failed: %v", err)
+	}
+	if got := deletes(plan); len(got) != 0 {
+		t.Errorf("expected a snapshot to delete nothing, got %v", got)
+	}
+}
@@ -0,0 +1,98 @@
+package snapshot
+
+import (
+	"path/filepath"
+	"sort"
+
+	"github.com/stackgen-cli/dataclean/internal/datastore"
+	"github.com/stackgen-cli/dataclean/internal/models"
+	"github.com/stackgen-cli/dataclean/internal/sqldiff"
+)
+
+// PreviewRestore compares each volume's live data with what restoring the
+// snapshot would put in its place: sizes, drift since the last restore, and,
+// for snapshots with table counts or logical dumps, row counts per table.
+// Volumes whose containers aren't running get no row counts.
+func (m *Manager) PreviewRestore(snapshot *models.Snapshot) ([]models.RestorePreview, error) {
+	statuses, err := m.VolumeStatus(snapshot.Volumes)
+	if err != nil {
+		return nil, err
+	}
+
+	previews := make([]models.RestorePreview, len(statuses))
+	for i, st := range statuses {
+		vol := snapshot.Volumes[i]
+		previews[i] = models.RestorePreview{Status: st, SnapshotBytes: vol.SizeBytes}
+
+		stored := snapshotTables(snapshot, vol)
+		if stored == nil {
+			continue
+		}
+		if live, ok := m.liveTables(vol); ok {
+			previews[i].Tables = tableDeltas(live, stored)
+		}
+	}
+	return previews, nil
+}
+
+// snapshotTables returns the row counts recorded with --tables, or counted
+// from the logical dump
+func snapshotTables(snapshot *models.Snapshot, vol models.Volume) []models.TableSummary {
+	if len(vol.Tables) > 0 {
+		return vol.Tables
+	}
+	if vol.LogicalDump == "" {
+		return nil
+	}
+	dump, err := sqldiff.ParseFile(filepath.Join(snapshot.Path, vol.LogicalDump), 0)
+	if err != nil {
+		return nil
+	}
+	tables := make([]models.TableSummary, 0, len(dump.Tables))
+	for _, t := range dump.Tables {
+		tables = append(tables, models.TableSummary{Name: t.Name, Rows: int64(t.RowCount)})
+	}
+	return tables
+}
+
+// liveTables counts the rows of a volume's running datastore
+func (m *Manager) liveTables(vol models.Volume) ([]models.TableSummary, bool) {
+	script, ok := datastore.SummaryCommand(vol.DatastoreType)
+	if !ok {
+		return nil, false
+	}
+	container, err := m.client.ResolveContainer(vol)
+	if err != nil || !m.client.IsRunning(container) {
+		return nil, false
+	}
+	output, err := m.client.ExecOutput(container, script, datastore.CredentialEnv(vol.Credentials)...)
+	if err != nil {
+		return nil, false
+	}
+	return datastore.ParseSummary(output), true
+}
+
+// tableDeltas lists the tables whose row counts differ, by name
+func tableDeltas(live, stored []models.TableSummary) []models.TableDelta {
+	deltas := map[string]*models.TableDelta{}
+	for _, t := range live {
+		deltas[t.Name] = &models.TableDelta{Name: t.Name, Live: t.Rows, Dropped: true}
+	}
+	for _, t := range stored {
+		if d, ok := deltas[t.Name]; ok {
+			d.Snapshot = t.Rows
+			d.Dropped = false
+		} else {
+			deltas[t.Name] = &models.TableDelta{Name: t.Name, Snapshot: t.Rows, Restored: true}
+		}
+	}
+
+	var changed []models.TableDelta
+	for _, d := range deltas {
+		if d.Live != d.Snapshot || d.Dropped || d.Restored {
+			changed = append(changed, *d)
+		}
+	}
+	sort.Slice(changed, func(i, j int) bool { return changed[i].Name < changed[j].Name })
+	return changed
+}
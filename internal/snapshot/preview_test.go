@@ -0,0 +1,35 @@
+package snapshot
+
+import (
+	"testing"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+func TestTableDeltas(t *testing.T) {
+	live := []models.TableSummary{
+		{Name: "public.users", Rows: 120},
+		{Name: "public.orders", Rows: 40},
+		{Name: "public.scratch", Rows: 3},
+	}
+	stored := []models.TableSummary{
+		{Name: "public.users", Rows: 100},
+		{Name: "public.orders", Rows: 40},
+		{Name: "public.audit", Rows: 0},
+	}
+
+	deltas := tableDeltas(live, stored)
+	want := []models.TableDelta{
+		{Name: "public.audit", Snapshot: 0, Restored: true},
+		{Name: "public.scratch", Live: 3, Dropped: true},
+		{Name: "public.users", Live: 120, Snapshot: 100},
+	}
+	if len(deltas) != len(want) {
+		t.Fatalf("deltas = %+v, want %+v", deltas, want)
+	}
+	for i := range want {
+		if deltas[i] != want[i] {
+			t.Errorf("deltas[%d] = %+v, want %+v", i, deltas[i], want[i])
+		}
+	}
+}
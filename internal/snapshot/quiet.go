@@ -0,0 +1,68 @@
+package snapshot
+
+import (
+	"strings"
+	"time"
+
+	"github.com/stackgen-cli/dataclean/internal/datastore"
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+const (
+	// activityProbe is how long a volume's file listing must stay unchanged
+	// for it to count as quiet
+	activityProbe = 2 * time.Second
+
+	// quietPoll is the pause between checks while waiting for a quiet window
+	quietPoll = 3 * time.Second
+)
+
+// awaitQuiet checks volumes whose containers are still running (nothing to
+// stop them by, or they refused) for writes in flight, and waits up to wait
+// for those to finish. A hot copy of a busy Postgres data directory doesn't
+// restore, so volumes still busy when the wait is over are flagged with
+// HotCopy; they are copied anyway.
+func (m *Manager) awaitQuiet(volumes []models.Volume, wait time.Duration) {
+	hot := make(map[int]string) // volume index -> running container
+	for i, vol := range volumes {
+		if container, err := m.client.ResolveContainer(vol); err == nil && m.client.IsRunning(container) {
+			hot[i] = container
+		}
+	}
+
+	deadline := time.Now().Add(wait)
+	for len(hot) > 0 {
+		for i, container := range hot {
+			volumes[i].HotCopy = m.writeActivity(volumes[i], container)
+			if volumes[i].HotCopy == "" {
+				delete(hot, i)
+			}
+		}
+		if len(hot) == 0 || !time.Now().Add(quietPoll).Before(deadline) {
+			return
+		}
+		time.Sleep(quietPoll)
+	}
+}
+
+// writeActivity describes what is writing to a volume, or returns "" when
+// it looks quiet: the datastore reports nothing in flight and no file
+// changes during a short probe
+func (m *Manager) writeActivity(vol models.Volume, container string) string {
+	if script := datastore.For(vol.DatastoreType).Activity; script != "" {
+		output, err := m.client.ExecOutput(container, script, datastore.CredentialEnv(vol.Credentials)...)
+		if activity := strings.Join(strings.Fields(output), " "); err == nil && activity != "" {
+			return activity
+		}
+	}
+
+	before, err := m.client.Fingerprint(vol)
+	if err != nil {
+		return "" // can't tell; the export will fail the same way if it's broken
+	}
+	time.Sleep(activityProbe)
+	if after, err := m.client.Fingerprint(vol); err == nil && after != before {
+		return "files changed while checking"
+	}
+	return ""
+}
@@ -0,0 +1,86 @@
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/stackgen-cli/dataclean/internal/events"
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+// errRestoreCancelled stops a restore whose context was cancelled before
+// every volume was imported
+var errRestoreCancelled = errors.New("restore cancelled")
+
+// cancelled reports whether the restore's context was cancelled
+func (o RestoreOptions) cancelled() bool {
+	return o.Context != nil && o.Context.Err() != nil
+}
+
+// abandon rolls back a restore that failed or was cancelled while importing,
+// so no volume is left holding part of the snapshot next to the others' old
+// data, and returns cause with what became of the volumes
+func (m *Manager) abandon(result *models.RestoreResult, volumes []models.Volume, cause error) error {
+	if !slices.ContainsFunc(result.Volumes, touched) {
+		return fmt.Errorf("%w, existing data left untouched", cause)
+	}
+	if result.Backup == "" {
+		return fmt.Errorf("%w; without a pre-restore backup (backup_before_restore) the volumes can't be rolled back and hold a mix of old and restored data", cause)
+	}
+	if err := m.rollback(result, volumes); err != nil {
+		return fmt.Errorf("%w; rolling back from %s failed: %v", cause, result.Backup, err)
+	}
+	return fmt.Errorf("%w; the volumes it touched were rolled back from %s", cause, result.Backup)
+}
+
+// touched reports whether a restore changed a volume, or may have
+func touched(vr models.VolumeResult) bool {
+	return vr.Cleared || vr.Created || vr.Imported || vr.Error != ""
+}
+
+// rollback puts each volume the restore touched back as the pre-restore
+// backup recorded it. Volumes the backup doesn't have, which the restore
+// created, are emptied again.
+func (m *Manager) rollback(result *models.RestoreResult, volumes []models.Volume) error {
+	backup, err := m.loadMetadata(filepath.Join(m.cfg.SnapshotDir, result.Backup))
+	if err != nil {
+		return err
+	}
+
+	var failed []string
+	for i := range result.Volumes {
+		vr := &result.Volumes[i]
+		if !touched(*vr) {
+			continue
+		}
+		vol := volumes[i]
+		if err := m.rollbackVolume(backup, vol); err != nil {
+			failed = append(failed, fmt.Sprintf("%s (%v)", vol.Name, err))
+			continue
+		}
+		vr.RolledBack = true
+		m.publish(events.Event{Kind: events.VolumeRestored, Operation: "rollback", Target: backup.Name, Volume: vol.Name})
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+func (m *Manager) rollbackVolume(backup *models.Snapshot, vol models.Volume) error {
+	if err := m.client.ClearVolume(vol); err != nil {
+		return err
+	}
+	i := slices.IndexFunc(backup.Volumes, func(b models.Volume) bool { return b.Name == vol.Name })
+	if i < 0 {
+		return nil
+	}
+	archive, err := m.resolveArchive(backup, backup.Volumes[i])
+	if err != nil {
+		return err
+	}
+	return m.importVolume(archive, backup.Volumes[i])
+}
@@ -0,0 +1,42 @@
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+func TestAbandon(t *testing.T) {
+	m := NewManager(nil, &models.Config{SnapshotDir: t.TempDir()})
+	volumes := []models.Volume{{Name: "shop_pgdata"}, {Name: "shop_redis"}}
+	cause := errors.New("failed to restore volume shop_pgdata: tar: short read")
+
+	// Nothing was cleared yet, so there's nothing to roll back
+	result := &models.RestoreResult{Backup: "_pre-restore-1", Volumes: []models.VolumeResult{{Volume: "shop_pgdata"}, {Volume: "shop_redis"}}}
+	err := m.abandon(result, volumes, cause)
+	if !errors.Is(err, cause) || !strings.Contains(err.Error(), "left untouched") {
+		t.Errorf("abandon() = %v, want the cause with the data left untouched", err)
+	}
+
+	// Without a backup, a half-done restore is reported as such
+	result = &models.RestoreResult{Volumes: []models.VolumeResult{{Volume: "shop_pgdata", Cleared: true, Error: "short read"}, {Volume: "shop_redis"}}}
+	err = m.abandon(result, volumes, cause)
+	if !errors.Is(err, cause) || !strings.Contains(err.Error(), "can't be rolled back") {
+		t.Errorf("abandon() = %v, want the cause and that nothing could be rolled back", err)
+	}
+}
+
+func TestRestoreOptionsCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	opts := RestoreOptions{Context: ctx}
+	if opts.cancelled() || (RestoreOptions{}).cancelled() {
+		t.Fatal("cancelled() before cancel")
+	}
+	cancel()
+	if !opts.cancelled() {
+		t.Error("cancelled() = false after cancel")
+	}
+}
@@ -0,0 +1,128 @@
+package snapshot
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/stackgen-cli/dataclean/internal/datastore"
+	"github.com/stackgen-cli/dataclean/internal/docker"
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+// runtimeDirName holds what snapshot --runtime captures for each volume:
+// <volume>.json (docker inspect) and <volume>.settings (name=value lines)
+const runtimeDirName = "runtime"
+
+// captureRuntime records the container and server settings of a running
+// volume, so restores can report what has changed since
+func (m *Manager) captureRuntime(vol *models.Volume, snapshotDir string) error {
+	settings, inspect, err := m.runtimeSettings(*vol)
+	if err != nil {
+		return fmt.Errorf("failed to capture runtime settings of %s: %w", vol.Name, err)
+	}
+	if err := os.MkdirAll(filepath.Join(snapshotDir, runtimeDirName), 0755); err != nil {
+		return err
+	}
+
+	base := filepath.Join(runtimeDirName, sanitizeName(vol.Name))
+	if err := os.WriteFile(filepath.Join(snapshotDir, base+".json"), inspect, 0644); err != nil {
+		return err
+	}
+	var lines strings.Builder
+	for _, name := range slices.Sorted(maps.Keys(settings)) {
+		fmt.Fprintf(&lines, "%s=%s\n", name, settings[name])
+	}
+	if err := os.WriteFile(filepath.Join(snapshotDir, base+".settings"), []byte(lines.String()), 0644); err != nil {
+		return err
+	}
+	vol.Runtime = base + ".settings"
+	return nil
+}
+
+// runtimeSettings returns a volume's container settings merged with its
+// server's, and the inspect output they came from
+func (m *Manager) runtimeSettings(vol models.Volume) (map[string]string, []byte, error) {
+	container, err := m.client.ResolveContainer(vol)
+	if err != nil {
+		return nil, nil, err
+	}
+	inspect, err := m.client.InspectContainer(container)
+	if err != nil {
+		return nil, nil, err
+	}
+	settings, err := docker.ContainerSettings(inspect)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if script := datastore.For(vol.DatastoreType).Settings; script != "" {
+		output, err := m.client.ExecOutput(container, script, datastore.CredentialEnv(vol.Credentials)...)
+		if err != nil {
+			return nil, nil, err
+		}
+		for name, value := range datastore.ParseSettings(output) {
+			settings[name] = value
+		}
+	}
+	return settings, inspect, nil
+}
+
+// runtimeDrift compares the settings captured with a snapshot to those of
+// the running containers. It is advisory, so volumes that can't be compared
+// are noted rather than failing the restore.
+func (m *Manager) runtimeDrift(snapshot *models.Snapshot) []string {
+	var drift []string
+	for _, vol := range snapshot.Volumes {
+		if vol.Runtime == "" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(snapshot.Path, vol.Runtime))
+		if err != nil {
+			continue // bundles don't carry runtime/
+		}
+		container, err := m.client.ResolveContainer(vol)
+		if err != nil || !m.client.IsRunning(container) {
+			continue
+		}
+		now, _, err := m.runtimeSettings(vol)
+		if err != nil {
+			drift = append(drift, fmt.Sprintf("%s: settings not compared: %v", vol.Name, err))
+			continue
+		}
+		for _, d := range diffSettings(datastore.ParseSettings(string(data)), now) {
+			drift = append(drift, vol.Name+": "+d)
+		}
+	}
+	return drift
+}
+
+// diffSettings describes each setting that differs between then and now,
+// sorted by name
+func diffSettings(then, now map[string]string) []string {
+	names := make(map[string]bool)
+	for name := range then {
+		names[name] = true
+	}
+	for name := range now {
+		names[name] = true
+	}
+
+	var diffs []string
+	for _, name := range slices.Sorted(maps.Keys(names)) {
+		was, hadIt := then[name]
+		is, hasIt := now[name]
+		switch {
+		case !hadIt:
+			diffs = append(diffs, fmt.Sprintf("%s is now %q (not set before)", name, is))
+		case !hasIt:
+			diffs = append(diffs, fmt.Sprintf("%s was %q, now not set", name, was))
+		case was != is:
+			diffs = append(diffs, fmt.Sprintf("%s was %q, now %q", name, was, is))
+		}
+	}
+	return diffs
+}
@@ -0,0 +1,32 @@
+package snapshot
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffSettings(t *testing.T) {
+	then := map[string]string{
+		"container.image": "postgres:15",
+		"shared_buffers":  "16384",
+		"work_mem":        "4096",
+	}
+	now := map[string]string{
+		"container.image": "postgres:16",
+		"shared_buffers":  "16384",
+		"max_connections": "200",
+	}
+
+	got := diffSettings(then, now)
+	want := []string{
+		`container.image was "postgres:15", now "postgres:16"`,
+		`max_connections is now "200" (not set before)`,
+		`work_mem was "4096", now not set`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("diffSettings() = %q, want %q", got, want)
+	}
+	if got := diffSettings(then, then); len(got) != 0 {
+		t.Errorf("diffSettings(same) = %q, want nothing", got)
+	}
+}
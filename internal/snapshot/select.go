@@ -0,0 +1,62 @@
+package snapshot
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+// Selector picks snapshots by name, glob pattern, and tag
+type Selector struct {
+	Patterns []string // Names or globs such as 'tmp-*'; a plain name must exist
+	Tags     []string // Only snapshots with at least one of these tags
+	KeepLast int      // Leave the newest KeepLast matches out
+}
+
+// Select returns the snapshots matching sel, newest first. A snapshot must
+// match a pattern (when any are given) and a tag (when any are given).
+func (m *Manager) Select(sel Selector) ([]models.Snapshot, error) {
+	for _, p := range sel.Patterns {
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", p, err)
+		}
+	}
+	all, err := m.List()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]bool, len(all))
+	for _, s := range all {
+		names[s.Name] = true
+	}
+	for _, p := range sel.Patterns {
+		if !strings.ContainsAny(p, "*?[") && !names[p] {
+			return nil, fmt.Errorf("snapshot '%s' not found", p)
+		}
+	}
+
+	var matched []models.Snapshot
+	for _, s := range all {
+		if len(sel.Patterns) > 0 && !slices.ContainsFunc(sel.Patterns, func(p string) bool {
+			ok, _ := path.Match(p, s.Name)
+			return ok
+		}) {
+			continue
+		}
+		if len(sel.Tags) > 0 && !slices.ContainsFunc(sel.Tags, func(t string) bool {
+			return slices.Contains(s.Tags, t)
+		}) {
+			continue
+		}
+		matched = append(matched, s)
+	}
+
+	if sel.KeepLast >= len(matched) {
+		return nil, nil
+	}
+	return matched[max(sel.KeepLast, 0):], nil
+}
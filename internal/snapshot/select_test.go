@@ -0,0 +1,72 @@
+package snapshot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+func TestSelect(t *testing.T) {
+	m := NewManager(nil, &models.Config{SnapshotDir: t.TempDir()})
+	now := time.Now()
+	for i, s := range []struct {
+		name string
+		tags []string
+	}{
+		{"tmp-1", []string{"scratch"}},
+		{"tmp-2", nil},
+		{"tmp-3", []string{"scratch"}},
+		{"release", []string{"scratch", "keep"}},
+	} {
+		snap := &models.Snapshot{
+			Name:      s.name,
+			Timestamp: now.Add(time.Duration(i) * time.Minute),
+			Path:      filepath.Join(m.cfg.SnapshotDir, s.name),
+			Tags:      s.tags,
+		}
+		os.MkdirAll(snap.Path, 0755)
+		if err := m.saveMetadata(snap); err != nil {
+			t.Fatalf("failed to save metadata: %v", err)
+		}
+	}
+
+	names := func(sel Selector) []string {
+		t.Helper()
+		snaps, err := m.Select(sel)
+		if err != nil {
+			t.Fatalf("Select(%+v) failed: %v", sel, err)
+		}
+		var n []string
+		for _, s := range snaps {
+			n = append(n, s.Name)
+		}
+		return n
+	}
+	for _, tc := range []struct {
+		sel  Selector
+		want string
+	}{
+		{Selector{Patterns: []string{"tmp-*"}}, "[tmp-3 tmp-2 tmp-1]"},
+		{Selector{Patterns: []string{"tmp-*"}, KeepLast: 2}, "[tmp-1]"},
+		{Selector{Patterns: []string{"tmp-*"}, KeepLast: 5}, "[]"},
+		{Selector{Tags: []string{"scratch"}}, "[release tmp-3 tmp-1]"},
+		{Selector{Patterns: []string{"tmp-*"}, Tags: []string{"scratch"}}, "[tmp-3 tmp-1]"},
+		{Selector{Patterns: []string{"release", "tmp-2"}}, "[release tmp-2]"},
+		{Selector{Patterns: []string{"nope-*"}}, "[]"},
+	} {
+		if got := fmt.Sprint(names(tc.sel)); got != tc.want {
+			t.Errorf("Select(%+v) = %s, want %s", tc.sel, got, tc.want)
+		}
+	}
+
+	if _, err := m.Select(Selector{Patterns: []string{"missing"}}); err == nil {
+		t.Error("expected error for a name that doesn't exist")
+	}
+	if _, err := m.Select(Selector{Patterns: []string{"tmp-["}}); err == nil {
+		t.Error("expected error for an invalid pattern")
+	}
+}
@@ -0,0 +1,117 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/stackgen-cli/dataclean/internal/datastore"
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+// ShellOptions controls a shell session on a snapshot volume
+type ShellOptions struct {
+	Image   string // Image to run (default: the volume's service image)
+	Command string // Shell command to run instead of the datastore's client
+}
+
+// Shell starts a temporary container on a copy of a snapshot volume's data
+// and attaches the terminal to the datastore's client. The snapshot and the
+// live volumes are never touched: the data is unpacked into a scratch volume,
+// which is removed with the container when the session ends, changes and all.
+func (m *Manager) Shell(ctx context.Context, name, volume string, opts ShellOptions) error {
+	return m.onScratchCopy(ctx, name, volume, opts.Image, func(container string, vol models.Volume, env []string) error {
+		script := opts.Command
+		if script == "" {
+			script = datastore.For(vol.DatastoreType).Client
+		}
+		if script == "" {
+			script = datastore.ShellFallback
+		}
+		return m.client.ExecInteractive(container, script, env...)
+	})
+}
+
+// Query runs one query (SQL, or a redis-cli or mongosh command) against a
+// temporary container on a copy of a snapshot volume's data, the way Shell
+// does, and returns what the datastore's client printed. image overrides the
+// volume's service image.
+func (m *Manager) Query(ctx context.Context, name, volume, query, image string) (string, error) {
+	var output string
+	err := m.onScratchCopy(ctx, name, volume, image, func(container string, vol models.Volume, env []string) error {
+		script := datastore.For(vol.DatastoreType).Query
+		if script == "" {
+			return fmt.Errorf("queries aren't supported for %s volumes; use 'dataclean shell' instead", vol.DatastoreType)
+		}
+		var err error
+		output, err = m.client.ExecOutput(container, script, append(env, datastore.EnvQuery+"="+query)...)
+		return err
+	})
+	return output, err
+}
+
+// onScratchCopy unpacks a snapshot volume into a scratch volume, starts the
+// volume's image on it, and calls fn with the container once the datastore
+// is healthy. The container and the scratch volume are removed afterwards.
+func (m *Manager) onScratchCopy(ctx context.Context, name, volume, image string, fn func(container string, vol models.Volume, env []string) error) error {
+	snapshot, err := m.Get(name)
+	if err != nil {
+		return err
+	}
+	vol, err := FindVolume(snapshot, volume)
+	if err != nil {
+		return err
+	}
+	image, dataPath, err := bakeTarget(*vol, BakeOptions{Base: image})
+	if err != nil {
+		return err
+	}
+	archive, err := m.resolveArchive(snapshot, *vol)
+	if err != nil {
+		return err
+	}
+
+	// The container and its scratch volume share a name
+	scratch := temporaryName("dataclean-shell")
+	if err := m.client.CreateVolume(scratch, "dataclean.snapshot="+snapshot.Name, "dataclean.temporary=true"); err != nil {
+		return err
+	}
+	defer m.client.RemoveVolume(scratch)
+	if err := m.client.ImportVolume(archive, models.Volume{Name: scratch}); err != nil {
+		return fmt.Errorf("failed to unpack %s: %w", vol.Name, err)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := m.client.RunDetached(scratch, image, scratch, dataPath); err != nil {
+		return err
+	}
+	defer m.client.RemoveContainer(scratch)
+
+	env := datastore.CredentialEnv(m.loginCredentials(*vol))
+	if health := datastore.For(vol.DatastoreType).Health; health != "" {
+		if err := m.pollHealth(ctx, scratch, health, env); err != nil {
+			return fmt.Errorf("%s didn't start on the snapshot data: %w", image, err)
+		}
+	}
+	return fn(scratch, *vol, env)
+}
+
+// loginCredentials returns the credentials to log in with: the snapshot's own
+// when it kept a password, otherwise those of the live service, since
+// snapshots usually leave passwords out
+func (m *Manager) loginCredentials(vol models.Volume) *models.Credentials {
+	if c := vol.Credentials; c != nil && (c.Password.Reveal() != "" || c.StoredPassword != "") {
+		return c
+	}
+	live, err := m.client.DetectComposeVolumes(m.cfg)
+	if err != nil {
+		return vol.Credentials
+	}
+	for _, v := range live {
+		if v.Name == vol.Name && v.Credentials != nil {
+			return v.Credentials
+		}
+	}
+	return vol.Credentials
+}
@@ -0,0 +1,51 @@
+package snapshot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+// stacksDirName holds one <name>.yaml per snapshot taken across stacks; the
+// reserved prefix keeps it apart from snapshots
+const stacksDirName = "_stacks"
+
+// SaveStackSet records a snapshot taken across several stacks
+func (m *Manager) SaveStackSet(set *models.StackSet) error {
+	if err := checkName(set.Name); err != nil {
+		return err
+	}
+	dir := filepath.Join(m.cfg.SnapshotDir, stacksDirName)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(set)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, set.Name+".yaml"), data, 0644)
+}
+
+// StackSet returns the record of a snapshot taken across stacks, or nil if
+// there is none by that name
+func (m *Manager) StackSet(name string) (*models.StackSet, error) {
+	if err := checkName(name); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(m.cfg.SnapshotDir, stacksDirName, name+".yaml"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var set models.StackSet
+	if err := yaml.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid stack record %s: %w", name, err)
+	}
+	return &set, nil
+}
@@ -0,0 +1,35 @@
+package snapshot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+func TestStackSet(t *testing.T) {
+	m := NewManager(nil, &models.Config{SnapshotDir: t.TempDir()})
+
+	set := &models.StackSet{Name: "release-1", Timestamp: time.Now().UTC().Truncate(time.Second), Consistent: true, Stacks: []models.StackMember{
+		{Stack: models.Stack{Name: "api", Path: "services/api", Project: "api"}, Volumes: []string{"api_pgdata"}, SizeBytes: 2048},
+		{Stack: models.Stack{Name: "worker", Path: "services/worker"}, Volumes: []string{"worker_redisdata"}},
+	}}
+	if err := m.SaveStackSet(set); err != nil {
+		t.Fatalf("SaveStackSet() failed: %v", err)
+	}
+	got, err := m.StackSet("release-1")
+	if err != nil || got == nil {
+		t.Fatalf("StackSet() = %v, %v", got, err)
+	}
+	if len(got.Stacks) != 2 || got.Stacks[0].Path != "services/api" || got.Stacks[0].SizeBytes != 2048 || !got.Timestamp.Equal(set.Timestamp) || !got.Consistent {
+		t.Errorf("StackSet() = %+v, want %+v", got, set)
+	}
+
+	// The record isn't a snapshot
+	if snapshots, err := m.List(); err != nil || len(snapshots) != 0 {
+		t.Errorf("List() = %v, %v; want no snapshots", snapshots, err)
+	}
+	if got, err := m.StackSet("missing"); got != nil || err != nil {
+		t.Errorf("StackSet(missing) = %v, %v; want nil", got, err)
+	}
+}
@@ -0,0 +1,90 @@
+package snapshot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+// stateFile lives next to the snapshot directories; List skips plain files
+const stateFile = "state.yaml"
+
+// LoadState reads the project state, returning an empty state if none was saved yet
+func (m *Manager) LoadState() (*models.State, error) {
+	state := &models.State{Restored: map[string]models.RestoreRecord{}, PITR: map[string]time.Time{}}
+
+	data, err := os.ReadFile(filepath.Join(m.cfg.SnapshotDir, stateFile))
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", stateFile, err)
+	}
+	if state.Restored == nil {
+		state.Restored = map[string]models.RestoreRecord{}
+	}
+	if state.PITR == nil {
+		state.PITR = map[string]time.Time{}
+	}
+	return state, nil
+}
+
+// saveState writes the project state
+func (m *Manager) saveState(state *models.State) error {
+	data, err := yaml.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(m.cfg.SnapshotDir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(m.cfg.SnapshotDir, stateFile), data, 0644)
+}
+
+// recordRestore remembers that volumes now hold the named snapshot's data
+func (m *Manager) recordRestore(snapshot string, volumes []models.Volume) error {
+	state, err := m.LoadState()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, vol := range volumes {
+		size, _ := m.client.GetVolumeSize(vol)
+		state.Restored[vol.Name] = models.RestoreRecord{Snapshot: snapshot, RestoredAt: now, SizeBytes: size}
+	}
+	return m.saveState(state)
+}
+
+// forgetRestore drops the restore records of volumes that were reset
+func (m *Manager) forgetRestore(volumes []models.Volume) error {
+	state, err := m.LoadState()
+	if err != nil {
+		return err
+	}
+	for _, vol := range volumes {
+		delete(state.Restored, vol.Name)
+	}
+	return m.saveState(state)
+}
+
+// RestoredVolumes maps each snapshot to the volumes last restored from it
+func (m *Manager) RestoredVolumes() (map[string][]string, error) {
+	state, err := m.LoadState()
+	if err != nil {
+		return nil, err
+	}
+	bySnapshot := map[string][]string{}
+	for vol, rec := range state.Restored {
+		bySnapshot[rec.Snapshot] = append(bySnapshot[rec.Snapshot], vol)
+	}
+	return bySnapshot, nil
+}
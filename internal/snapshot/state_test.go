@@ -0,0 +1,46 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+func TestState_RoundTrip(t *testing.T) {
+	tmpDir := t.TempDir()
+	m := NewManager(nil, &models.Config{SnapshotDir: tmpDir})
+
+	state, err := m.LoadState()
+	if err != nil {
+		t.Fatalf("LoadState() without a file failed: %v", err)
+	}
+	if len(state.Restored) != 0 {
+		t.Errorf("expected empty state, got %v", state.Restored)
+	}
+
+	state.Restored["pgdata"] = models.RestoreRecord{Snapshot: "baseline", RestoredAt: time.Now(), SizeBytes: 1024}
+	state.Restored["redis"] = models.RestoreRecord{Snapshot: "baseline", RestoredAt: time.Now()}
+	if err := m.saveState(state); err != nil {
+		t.Fatalf("saveState() failed: %v", err)
+	}
+
+	byVolume, err := m.RestoredVolumes()
+	if err != nil {
+		t.Fatalf("RestoredVolumes() failed: %v", err)
+	}
+	if got := len(byVolume["baseline"]); got != 2 {
+		t.Errorf("RestoredVolumes()[baseline] has %d volumes, want 2", got)
+	}
+
+	// The state file must not show up as a snapshot
+	os.MkdirAll(filepath.Join(tmpDir, "baseline"), 0755)
+	snapshots, _ := m.List()
+	for _, s := range snapshots {
+		if s.Name == stateFile {
+			t.Error("state file listed as a snapshot")
+		}
+	}
+}
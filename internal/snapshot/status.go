@@ -0,0 +1,107 @@
+package snapshot
+
+import (
+	"slices"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+// VolumeStatus reports each volume's live size, the containers that mount it,
+// and whether it has been written to since its most recent snapshot. Docker
+// errors for one volume leave its fields empty rather than failing the report.
+func (m *Manager) VolumeStatus(volumes []models.Volume) ([]models.VolumeStatus, error) {
+	snapshots, err := m.List()
+	if err != nil {
+		return nil, err
+	}
+	latest := latestSnapshots(snapshots)
+	state, err := m.LoadState()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]models.VolumeStatus, 0, len(volumes))
+	for _, vol := range volumes {
+		st := models.VolumeStatus{Volume: vol}
+
+		if size, err := m.client.GetVolumeSize(vol); err == nil {
+			st.SizeBytes = size
+			st.SizeHuman = models.FormatSize(size)
+		}
+		st.Containers, _ = m.client.VolumeContainers(vol)
+
+		if snap, ok := latest[vol.Name]; ok {
+			st.LastSnapshot = snap.Name
+			st.SnapshotTime = snap.Timestamp
+		}
+		rec, recorded := state.Restored[vol.Name]
+		if recorded {
+			st.Restored = &rec
+			if st.SizeHuman != "" {
+				st.SizeDelta = st.SizeBytes - rec.SizeBytes
+			}
+		} else if labels, _, err := m.client.VolumeLabels(vol.Name); err == nil {
+			// Restored elsewhere, or the state file is gone: the volume's
+			// labels still tell where it came from, but the label time
+			// predates the import, so drift can't be judged
+			st.Restored = models.ProvenanceFromLabels(labels)
+		}
+		if modified, err := m.client.LastModified(vol); err == nil {
+			st.LastModified = modified
+			if st.LastSnapshot != "" {
+				clean := !modified.After(st.SnapshotTime)
+				st.Clean = &clean
+			}
+			if recorded {
+				drifted := modified.After(st.Restored.RestoredAt) || st.SizeDelta != 0
+				st.Drifted = &drifted
+			}
+		}
+
+		statuses = append(statuses, st)
+	}
+
+	return statuses, nil
+}
+
+// latestSnapshots maps each volume name to the newest snapshot containing it
+func latestSnapshots(snapshots []models.Snapshot) map[string]models.Snapshot {
+	latest := map[string]models.Snapshot{}
+	for _, s := range snapshots {
+		for _, v := range s.Volumes {
+			if prev, ok := latest[v.Name]; !ok || s.Timestamp.After(prev.Timestamp) {
+				latest[v.Name] = s
+			}
+		}
+	}
+	return latest
+}
+
+// LastSnapshots maps the name of each volume that has been snapshotted to the
+// newest snapshot containing it. Aliased volumes are matched by alias, since
+// their Docker name changes.
+func (m *Manager) LastSnapshots(volumes []models.Volume) (map[string]models.Snapshot, error) {
+	snapshots, err := m.List()
+	if err != nil {
+		return nil, err
+	}
+	latest := latestSnapshots(snapshots)
+	last := make(map[string]models.Snapshot)
+	for _, vol := range volumes {
+		if s, ok := latest[vol.Name]; ok {
+			last[vol.Name] = s
+			continue
+		}
+		if vol.Alias == "" {
+			continue
+		}
+		for _, s := range snapshots {
+			prev, seen := last[vol.Name]
+			aliased := slices.ContainsFunc(s.Volumes, func(v models.Volume) bool { return v.Alias == vol.Alias })
+			if aliased && (!seen || s.Timestamp.After(prev.Timestamp)) {
+				last[vol.Name] = s
+			}
+		}
+	}
+	return last, nil
+}
@@ -0,0 +1,27 @@
+package snapshot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+func TestLatestSnapshots(t *testing.T) {
+	now := time.Now()
+	snapshots := []models.Snapshot{
+		{Name: "old", Timestamp: now.Add(-2 * time.Hour), Volumes: []models.Volume{{Name: "pgdata"}, {Name: "redis"}}},
+		{Name: "new", Timestamp: now, Volumes: []models.Volume{{Name: "pgdata"}}},
+	}
+
+	latest := latestSnapshots(snapshots)
+	if got := latest["pgdata"].Name; got != "new" {
+		t.Errorf("latest[pgdata] = %q, want new", got)
+	}
+	if got := latest["redis"].Name; got != "old" {
+		t.Errorf("latest[redis] = %q, want old", got)
+	}
+	if _, ok := latest["mongo"]; ok {
+		t.Error("expected no entry for a volume without snapshots")
+	}
+}
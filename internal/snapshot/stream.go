@@ -0,0 +1,240 @@
+package snapshot
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path/filepath"
+	"time"
+
+	"github.com/stackgen-cli/dataclean/internal/events"
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+// BundleStream is a bundle being read, typically straight from a remote, with
+// its metadata read and its archives still to come
+type BundleStream struct {
+	Snapshot *models.Snapshot
+	tr       *tar.Reader
+}
+
+// ReadBundle reads the metadata at the start of a bundle, leaving the rest of
+// r for RestoreBundle
+func ReadBundle(r io.Reader) (*BundleStream, error) {
+	tr := tar.NewReader(r)
+	snapshot, err := readBundleMetadata(tr)
+	if err != nil {
+		return nil, err
+	}
+	return &BundleStream{Snapshot: snapshot, tr: tr}, nil
+}
+
+// RestoreBundle restores a bundle as it is read, importing each volume archive
+// as it arrives instead of saving the snapshot first, so no local disk space
+// is needed for it. source names it in the history log and state file.
+//
+// Archives can only be checked as they are imported: a corrupt or altered
+// archive fails the restore with its volume already cleared. Volumes are
+// imported one at a time in bundle order, so a restore_after order the bundle
+// doesn't follow is refused, as is point-in-time recovery.
+func (m *Manager) RestoreBundle(source string, b *BundleStream, opts RestoreOptions) (*models.RestoreResult, error) {
+	if err := m.cfg.Writable(); err != nil {
+		return &models.RestoreResult{Snapshot: source}, err
+	}
+	result, err := m.restoreBundle(source, b, opts)
+	m.logRestore(result, err) // advisory, like the state file
+	return result, err
+}
+
+func (m *Manager) restoreBundle(source string, b *BundleStream, opts RestoreOptions) (*models.RestoreResult, error) {
+	snapshot := b.Snapshot
+	result := &models.RestoreResult{Snapshot: source}
+	if err := checkReadable(snapshot); err != nil {
+		return result, err
+	}
+	if !opts.RecoverTo.IsZero() {
+		return result, fmt.Errorf("point-in-time recovery needs a local snapshot (pull it first)")
+	}
+	if opts.LeaveStopped && opts.WithImages {
+		return result, fmt.Errorf("leaving containers stopped can't be combined with restoring images")
+	}
+	if len(m.cfg.SelectVolumes(snapshot.Volumes)) != len(snapshot.Volumes) {
+		return result, fmt.Errorf("a snapshot streamed from the remote is restored whole; pull it first to restore only some of its volumes")
+	}
+	if err := checkStreamOrder(snapshot.Volumes, m.cfg.RestoreAfter); err != nil {
+		return result, err
+	}
+	if err := m.resolveAliases(snapshot.Volumes); err != nil {
+		return result, err
+	}
+	for _, vol := range snapshot.Volumes {
+		if vol.Exporter != "" {
+			return result, fmt.Errorf("volume %s was exported by plugin %s, which imports from a file; pull the snapshot and restore it instead", vol.Name, vol.Exporter)
+		}
+	}
+	if opts.WithImages && len(pinnedImages(snapshot.Volumes)) == 0 {
+		return result, fmt.Errorf("snapshot %s has no recorded image digests", source)
+	}
+
+	// The backup is local, but only as large as the data being replaced
+	if m.cfg.BackupBeforeRestore && !opts.SkipBackup {
+		backupName := fmt.Sprintf("_pre-restore-%s", time.Now().Format("20060102-150405"))
+		if _, err := m.create(backupName, snapshot.Volumes, CreateOptions{}); err != nil {
+			return result, fmt.Errorf("pre-restore backup failed, existing data left untouched: %w", err)
+		}
+		result.Backup = backupName
+	}
+
+	start, err := m.stopContainers(snapshot.Volumes)
+	if err != nil {
+		return result, err
+	}
+	restart := func() {
+		if !opts.LeaveStopped {
+			start()
+		}
+	}
+	m.publish(events.Event{Kind: events.RestoreStarted, Operation: "restore", Target: source, Snapshot: snapshot})
+
+	if opts.WithImages {
+		for image, digest := range pinnedImages(snapshot.Volumes) {
+			if err := m.client.PinImage(image, digest); err != nil {
+				restart()
+				return result, fmt.Errorf("failed to restore image %s, existing data left untouched: %w", image, err)
+			}
+		}
+	}
+
+	archives := make(map[string]int)
+	for i, vol := range snapshot.Volumes {
+		result.Volumes = append(result.Volumes, models.VolumeResult{Volume: vol.Name})
+		archives[filepath.Base(archivePath("", vol))] = i
+	}
+	if err := m.importBundle(source, b.tr, snapshot.Volumes, archives, result, opts); err != nil {
+		err = m.abandon(result, snapshot.Volumes, err)
+		restart()
+		return result, err
+	}
+
+	if opts.LeaveStopped {
+		m.recordRestore(source, snapshot.Volumes)
+		return result, nil
+	}
+	start()
+	if opts.WithImages {
+		if err := m.client.RecreateServices(m.cfg, services(snapshot.Volumes)); err != nil {
+			return result, err
+		}
+	}
+	if err := m.waitHealthy(snapshot.Volumes, true); err != nil {
+		return result, err
+	}
+	if err := m.postRestoreExec(); err != nil {
+		return result, err
+	}
+	m.recordRestore(source, snapshot.Volumes)
+	err = m.validate(snapshot.Volumes)
+	if invalid, ok := err.(*ValidationError); ok {
+		result.Suspect = invalid.Problems
+	}
+	return result, err
+}
+
+// importBundle imports each volume archive in tr as it is read. Dumps and
+// other files in the bundle are skipped.
+func (m *Manager) importBundle(source string, tr *tar.Reader, volumes []models.Volume, archives map[string]int, result *models.RestoreResult, opts RestoreOptions) error {
+	for {
+		if opts.cancelled() {
+			return errRestoreCancelled
+		}
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("bundle is corrupt: %w", err)
+		}
+		i, ok := archives[hdr.Name]
+		if !ok {
+			continue
+		}
+		vol, vr := volumes[i], &result.Volumes[i]
+		if vr.Imported {
+			return fmt.Errorf("bundle holds volume %s twice", vol.Name)
+		}
+
+		err = m.prepareVolume(vol, source, vr)
+		if err == nil {
+			err = m.importArchiveStream(tr, vol)
+		}
+		if err != nil {
+			vr.Error = err.Error()
+			return fmt.Errorf("failed to restore volume %s: %w", vol.Name, err)
+		}
+		vr.Imported = true
+		m.publish(events.Event{Kind: events.VolumeRestored, Operation: "restore", Target: source, Volume: vol.Name})
+	}
+
+	for i := range result.Volumes {
+		if !result.Volumes[i].Imported {
+			return fmt.Errorf("bundle ended without the archive of volume %s", volumes[i].Name)
+		}
+	}
+	return nil
+}
+
+// importArchiveStream imports a compressed volume archive read from src,
+// checking it against the volume's recorded checksum on the way
+func (m *Manager) importArchiveStream(src io.Reader, vol models.Volume) error {
+	stream, finish, err := verifyingStream(src, vol.Checksum)
+	if err != nil {
+		return err
+	}
+	if err := m.client.ImportStream(stream, vol); err != nil {
+		return err
+	}
+	if err := finish(); err != nil {
+		return fmt.Errorf("%w; the volume holds a partial import", err)
+	}
+	return nil
+}
+
+// verifyingStream decompresses an archive read from src. Once the returned
+// stream has been consumed, finish reads whatever is left and, like
+// verifyArchive, checks the uncompressed tar against expected when set.
+func verifyingStream(src io.Reader, expected string) (stream io.Reader, finish func() error, err error) {
+	gz, err := gzip.NewReader(src)
+	if err != nil {
+		return nil, nil, fmt.Errorf("archive is not readable: %w", err)
+	}
+	h := sha256.New()
+	stream = io.TeeReader(gz, h)
+	finish = func() error {
+		// tar stops reading at the end-of-archive marker; hash the padding too
+		if _, err := io.Copy(io.Discard, stream); err != nil {
+			return fmt.Errorf("archive is corrupt: %w", err)
+		}
+		if digest := hex.EncodeToString(h.Sum(nil)); expected != "" && digest != expected {
+			return fmt.Errorf("archive checksum mismatch (expected %s, got %s)", expected, digest)
+		}
+		return nil
+	}
+	return stream, finish, nil
+}
+
+// checkStreamOrder makes sure importing volumes in bundle order satisfies
+// restore_after: every volume a volume waits for must come before it
+func checkStreamOrder(volumes []models.Volume, after map[models.DatastoreType][]models.DatastoreType) error {
+	for i, deps := range restoreDependencies(volumes, after) {
+		for _, j := range deps {
+			if j > i {
+				return fmt.Errorf("restore_after needs %s imported before %s, which the bundle has the other way round; pull the snapshot and restore it instead",
+					volumes[j].Name, volumes[i].Name)
+			}
+		}
+	}
+	return nil
+}
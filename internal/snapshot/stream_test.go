@@ -0,0 +1,91 @@
+package snapshot
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+func TestReadBundle(t *testing.T) {
+	src := NewManager(nil, &models.Config{SnapshotDir: t.TempDir()})
+	writeChainSnapshot(t, src, "nightly", "", time.Now(), map[string]string{"project_pgdata": "pg v1", "project_uploads": "files v1"})
+	var bundle bytes.Buffer
+	if err := src.Bundle("nightly", &bundle); err != nil {
+		t.Fatalf("Bundle() failed: %v", err)
+	}
+
+	b, err := ReadBundle(&bundle)
+	if err != nil {
+		t.Fatalf("ReadBundle() failed: %v", err)
+	}
+	if len(b.Snapshot.Volumes) != 2 || b.Snapshot.Volumes[0].Name != "project_pgdata" {
+		t.Errorf("unexpected volumes: %+v", b.Snapshot.Volumes)
+	}
+	hdr, err := b.tr.Next()
+	if err != nil || hdr.Name != "project_pgdata.tar.gz" {
+		t.Errorf("next entry = %v, %v; want the volume archive", hdr, err)
+	}
+
+	if _, err := ReadBundle(strings.NewReader("not a tar")); err == nil {
+		t.Error("expected error for something that isn't a bundle")
+	}
+}
+
+func TestVerifyingStream(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "pgdata.tar.gz")
+	writeTestArchive(t, archive, map[string]string{"data": "pg v1"})
+	digest, err := verifyArchive(archive, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(archive)
+
+	// Consuming only part of the stream, as tar does, still hashes it all
+	for _, expected := range []string{digest, ""} {
+		stream, finish, err := verifyingStream(bytes.NewReader(data), expected)
+		if err != nil {
+			t.Fatalf("verifyingStream() failed: %v", err)
+		}
+		io.CopyN(io.Discard, stream, 512)
+		if err := finish(); err != nil {
+			t.Errorf("finish() with expected %q failed: %v", expected, err)
+		}
+	}
+
+	stream, finish, err := verifyingStream(bytes.NewReader(data), strings.Repeat("0", 64))
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.Copy(io.Discard, stream)
+	if err := finish(); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("expected checksum mismatch, got %v", err)
+	}
+
+	if _, _, err := verifyingStream(strings.NewReader("not gzip"), ""); err == nil {
+		t.Error("expected error for an archive that isn't gzip")
+	}
+}
+
+func TestCheckStreamOrder(t *testing.T) {
+	after := map[models.DatastoreType][]models.DatastoreType{
+		models.DatastoreElastic: {models.DatastorePostgres},
+	}
+	pg := models.Volume{Name: "pgdata", DatastoreType: models.DatastorePostgres}
+	es := models.Volume{Name: "esdata", DatastoreType: models.DatastoreElastic}
+
+	if err := checkStreamOrder([]models.Volume{pg, es}, after); err != nil {
+		t.Errorf("bundle order satisfies restore_after, got %v", err)
+	}
+	if err := checkStreamOrder([]models.Volume{es, pg}, after); err == nil {
+		t.Error("expected error when the bundle has esdata before pgdata")
+	}
+	if err := checkStreamOrder([]models.Volume{es, pg}, nil); err != nil {
+		t.Errorf("no restore_after, got %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/stackgen-cli/dataclean/internal/datastore"
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+// EphemeralLabel marks containers started by Up. Its value is the project,
+// so down only removes the project's own.
+const EphemeralLabel = "dataclean.ephemeral"
+
+// UpOptions controls starting a throwaway datastore from a snapshot
+type UpOptions struct {
+	Project string // Compose project the container belongs to
+	Image   string // Image to run (default: the volume's service image)
+	Port    int    // Container port to publish (default: the datastore's)
+}
+
+// Ephemeral is a throwaway datastore started by Up
+type Ephemeral struct {
+	Container string
+	Volume    models.Volume
+	Address   string // Published host address, e.g. 127.0.0.1:49153
+	URL       string // Connection string
+}
+
+// FindServiceVolume returns the volume of a snapshot that belongs to a compose service
+func FindServiceVolume(snapshot *models.Snapshot, service string) (*models.Volume, error) {
+	var found *models.Volume
+	for i, v := range snapshot.Volumes {
+		if v.Service != service {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("service %s has several volumes in snapshot %s (%s, %s); choose one by name",
+				service, snapshot.Name, found.Name, v.Name)
+		}
+		found = &snapshot.Volumes[i]
+	}
+	if found == nil {
+		return nil, fmt.Errorf("service %s has no volume in snapshot %s", service, snapshot.Name)
+	}
+	return found, nil
+}
+
+// Up starts a throwaway container of a snapshot volume's image with the
+// volume's data unpacked into a fresh anonymous volume, and publishes its
+// port on a random localhost port. The live volumes are never touched.
+// Removing the container (see RemoveEphemeral) removes its data.
+func (m *Manager) Up(ctx context.Context, name, volume string, opts UpOptions) (*Ephemeral, error) {
+	snapshot, err := m.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	vol, err := FindVolume(snapshot, volume)
+	if err != nil {
+		return nil, err
+	}
+	image, dataPath, err := bakeTarget(*vol, BakeOptions{Base: opts.Image})
+	if err != nil {
+		return nil, err
+	}
+	port := opts.Port
+	if port == 0 {
+		port = datastore.For(vol.DatastoreType).Port
+	}
+	if port == 0 {
+		return nil, fmt.Errorf("no known port for %s volume %s; pass one explicitly", vol.DatastoreType, vol.Name)
+	}
+	archive, err := m.resolveArchive(snapshot, *vol)
+	if err != nil {
+		return nil, err
+	}
+
+	container := temporaryName("dataclean-up")
+	err = m.client.CreateContainer(container, image, dataPath, port,
+		EphemeralLabel+"="+opts.Project, "dataclean.snapshot="+snapshot.Name)
+	if err != nil {
+		return nil, err
+	}
+	e, err := m.startEphemeral(ctx, container, archive, dataPath, port, *vol)
+	if err != nil {
+		m.client.RemoveContainer(container)
+		return nil, err
+	}
+	return e, nil
+}
+
+// startEphemeral fills a created container's volume and starts it
+func (m *Manager) startEphemeral(ctx context.Context, container, archive, dataPath string, port int, vol models.Volume) (*Ephemeral, error) {
+	if err := m.client.ImportInto(archive, container, dataPath); err != nil {
+		return nil, fmt.Errorf("failed to unpack %s: %w", vol.Name, err)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := m.client.StartContainer(container); err != nil {
+		return nil, err
+	}
+
+	creds := m.loginCredentials(vol)
+	if health := datastore.For(vol.DatastoreType).Health; health != "" {
+		if err := m.pollHealth(ctx, container, health, datastore.CredentialEnv(creds)); err != nil {
+			return nil, fmt.Errorf("%s didn't start on the snapshot data: %w", container, err)
+		}
+	}
+
+	addr, err := m.client.HostAddress(container, port)
+	if err != nil {
+		return nil, err
+	}
+	return &Ephemeral{
+		Container: container,
+		Volume:    vol,
+		Address:   addr,
+		URL:       datastore.ConnectionURL(vol.DatastoreType, addr, creds),
+	}, nil
+}
+
+// RemoveEphemeral removes a container started by Up, along with its data
+func (m *Manager) RemoveEphemeral(container string) error {
+	return m.client.RemoveContainer(container)
+}
+
+// EphemeralContainers lists the containers Up started for a project
+func (m *Manager) EphemeralContainers(project string) ([]string, error) {
+	return m.client.ContainersWithLabel(EphemeralLabel + "=" + project)
+}
+
+// temporaryName returns a unique container or volume name
+func temporaryName(prefix string) string {
+	return prefix + "-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+}
@@ -0,0 +1,70 @@
+package snapshot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/stackgen-cli/dataclean/internal/datastore"
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+// ValidationError reports failed restore validation queries. The data was
+// restored, but can't be trusted.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("restore is suspect, validation failed: %s", strings.Join(e.Problems, "; "))
+}
+
+// validate runs the configured validation queries against restored volumes
+func (m *Manager) validate(volumes []models.Volume) error {
+	var problems []string
+	for _, vol := range volumes {
+		queries := m.cfg.ValidationQueries(vol)
+		if len(queries) == 0 || (vol.ContainerName == "" && vol.Service == "") {
+			continue // nothing to query, or no service runs on it (a clone, say)
+		}
+		script := datastore.For(vol.DatastoreType).Query
+		if script == "" {
+			problems = append(problems, fmt.Sprintf("%s: validation queries aren't supported for %s", vol.Name, vol.DatastoreType))
+			continue
+		}
+		container, err := m.client.ResolveContainer(vol)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", vol.Name, err))
+			continue
+		}
+		if !m.client.IsRunning(container) {
+			continue // stopped before the restore, and left that way
+		}
+
+		env := datastore.CredentialEnv(vol.Credentials)
+		for _, q := range queries {
+			out, err := m.client.ExecOutput(container, script, append(env, datastore.EnvQuery+"="+q)...)
+			if problem := checkValidation(out, err); problem != "" {
+				problems = append(problems, fmt.Sprintf("%s: %q %s", vol.Name, q, problem))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// checkValidation judges a validation query's output: it must run and print
+// something other than 0 or false. It returns "" when the check passed.
+func checkValidation(output string, err error) string {
+	if err != nil {
+		return fmt.Sprintf("failed: %v", err)
+	}
+	switch v := strings.TrimSpace(output); strings.ToLower(v) {
+	case "":
+		return "returned nothing"
+	case "0", "f", "false":
+		return "returned " + v
+	}
+	return ""
+}
@@ -0,0 +1,27 @@
+package snapshot
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCheckValidation(t *testing.T) {
+	tests := []struct {
+		output string
+		err    error
+		pass   bool
+	}{
+		{"42\n", nil, true},
+		{"t\n", nil, true},
+		{"0\n", nil, false},
+		{"f", nil, false},
+		{"FALSE", nil, false},
+		{"", nil, false},
+		{"42", fmt.Errorf("relation \"users\" does not exist"), false},
+	}
+	for _, tt := range tests {
+		if got := checkValidation(tt.output, tt.err); (got == "") != tt.pass {
+			t.Errorf("checkValidation(%q, %v) = %q, want pass=%v", tt.output, tt.err, got, tt.pass)
+		}
+	}
+}
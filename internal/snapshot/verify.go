@@ -0,0 +1,72 @@
+package snapshot
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+// verifyArchive reads an archive end to end (gzip stream and every tar entry)
+// and returns the SHA-256 of its uncompressed tar stream. Hashing the tar
+// rather than the .tar.gz keeps checksums stable across gzip settings. When
+// expected is set, a different checksum is an error.
+func verifyArchive(archive, expected string) (string, error) {
+	f, err := os.Open(archive)
+	if err != nil {
+		return "", fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return "", fmt.Errorf("archive %s is not readable: %w", archive, err)
+	}
+	defer gz.Close()
+
+	h := sha256.New()
+	stream := io.TeeReader(gz, h)
+	tr := tar.NewReader(stream)
+	for {
+		_, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("archive %s is corrupt: %w", archive, err)
+		}
+		if _, err := io.Copy(io.Discard, tr); err != nil {
+			return "", fmt.Errorf("archive %s is corrupt: %w", archive, err)
+		}
+	}
+	// Hash the end-of-archive padding too
+	if _, err := io.Copy(io.Discard, stream); err != nil {
+		return "", fmt.Errorf("archive %s is corrupt: %w", archive, err)
+	}
+
+	digest := hex.EncodeToString(h.Sum(nil))
+	if expected != "" && digest != expected {
+		return "", fmt.Errorf("archive %s checksum mismatch (expected %s, got %s)", archive, expected, digest)
+	}
+	return digest, nil
+}
+
+// VerifySnapshot checks that every volume archive of a snapshot is fully
+// readable and matches its recorded checksum
+func (m *Manager) VerifySnapshot(snapshot *models.Snapshot) error {
+	for _, vol := range snapshot.Volumes {
+		archive, err := m.resolveArchive(snapshot, vol)
+		if err != nil {
+			return err
+		}
+		if _, err := verifyArchive(archive, vol.Checksum); err != nil {
+			return fmt.Errorf("volume %s: %w", vol.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package snapshot
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+func TestVerifyArchive(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "dataclean-verify-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	archive := filepath.Join(tmpDir, "pgdata.tar.gz")
+	writeTestArchive(t, archive, map[string]string{"PG_VERSION": "16\n", "base/1/1259": "table data"})
+
+	checksum, err := verifyArchive(archive, "")
+	if err != nil {
+		t.Fatalf("verifyArchive() failed: %v", err)
+	}
+	if len(checksum) != 64 {
+		t.Errorf("checksum = %q, want a SHA-256 hex digest", checksum)
+	}
+	if _, err := verifyArchive(archive, checksum); err != nil {
+		t.Errorf("verifyArchive() with matching checksum failed: %v", err)
+	}
+	if _, err := verifyArchive(archive, "deadbeef"); err == nil {
+		t.Error("expected checksum mismatch")
+	}
+
+	// A truncated download must be caught before any volume is cleared
+	data, _ := os.ReadFile(archive)
+	truncated := filepath.Join(tmpDir, "truncated.tar.gz")
+	os.WriteFile(truncated, data[:len(data)/2], 0644)
+	if _, err := verifyArchive(truncated, ""); err == nil {
+		t.Error("expected error for truncated archive")
+	}
+}
+
+func TestVerifyArchivePAXAttributes(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "dataclean-verify-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// GNU tar --format=posix --xattrs stores attributes as PAX records
+	archive := filepath.Join(tmpDir, "mongo.tar.gz")
+	f, err := os.Create(archive)
+	if err != nil {
+		t.Fatalf("failed to create archive: %v", err)
+	}
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	body := "wiredtiger"
+	tw.WriteHeader(&tar.Header{
+		Name:       "./WiredTiger.wt",
+		Mode:       0600,
+		Size:       int64(len(body)),
+		Format:     tar.FormatPAX,
+		PAXRecords: map[string]string{"SCHILY.xattr.user.checksum": "abc"},
+	})
+	tw.Write([]byte(body))
+	tw.Close()
+	gz.Close()
+	f.Close()
+
+	if _, err := verifyArchive(archive, ""); err != nil {
+		t.Errorf("verifyArchive() failed on PAX archive: %v", err)
+	}
+}
+
+func TestVerifySnapshot(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "dataclean-verify-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	m := NewManager(nil, &models.Config{SnapshotDir: tmpDir})
+	snap := &models.Snapshot{
+		Name:    "baseline",
+		Path:    filepath.Join(tmpDir, "baseline"),
+		Volumes: []models.Volume{{Name: "project_pgdata"}},
+	}
+	os.MkdirAll(snap.Path, 0755)
+	writeTestArchive(t, filepath.Join(snap.Path, "project_pgdata.tar.gz"), map[string]string{"PG_VERSION": "16\n"})
+
+	if err := m.VerifySnapshot(snap); err != nil {
+		t.Errorf("VerifySnapshot() failed: %v", err)
+	}
+
+	snap.Volumes[0].Checksum = "0000"
+	if err := m.VerifySnapshot(snap); err == nil {
+		t.Error("expected error for checksum mismatch")
+	}
+
+	snap.Volumes = append(snap.Volumes, models.Volume{Name: "project_missing"})
+	snap.Volumes[0].Checksum = ""
+	if err := m.VerifySnapshot(snap); err == nil {
+		t.Error("expected error for missing archive")
+	}
+}
@@ -0,0 +1,160 @@
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// runsDirName holds a workspace per dataclean process for its temporary
+// files, so processes sharing a snapshot directory never write to the same
+// temporary path. The reserved prefix keeps it apart from snapshots.
+const runsDirName = "_runs"
+
+// runOwnerFile in a workspace names the host and process it belongs to
+const runOwnerFile = "owner"
+
+// staleRunAge is how old a workspace of another host's process must be to
+// count as abandoned, since whether that process still runs can't be checked
+const staleRunAge = 24 * time.Hour
+
+// runID names this process's workspace
+var runID = time.Now().UTC().Format("20060102T150405Z") + "-" + strconv.Itoa(os.Getpid())
+
+var (
+	runsMu sync.Mutex
+	runs   = make(map[string]bool) // Workspaces this process created
+)
+
+// workspace returns this process's workspace in the snapshot directory. The
+// first call creates it, after removing the workspaces of runs that ended
+// without cleaning up after themselves.
+func (m *Manager) workspace() (string, error) {
+	dir := filepath.Join(m.cfg.SnapshotDir, runsDirName, runID)
+	runsMu.Lock()
+	defer runsMu.Unlock()
+	if runs[dir] {
+		return dir, nil
+	}
+	m.cleanStaleRuns(time.Now())
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create workspace: %w", err)
+	}
+	host, _ := os.Hostname()
+	owner := fmt.Sprintf("%s %d\n", host, os.Getpid())
+	if err := os.WriteFile(filepath.Join(dir, runOwnerFile), []byte(owner), 0644); err != nil {
+		os.RemoveAll(dir)
+		return "", fmt.Errorf("failed to create workspace: %w", err)
+	}
+	runs[dir] = true
+	return dir, nil
+}
+
+// tempDir creates a directory for temporary files in this process's workspace
+func (m *Manager) tempDir(pattern string) (string, error) {
+	dir, err := m.workspace()
+	if err != nil {
+		return "", err
+	}
+	return os.MkdirTemp(dir, pattern)
+}
+
+// tempPath returns where to write a file before renaming it to dest. It sits
+// next to dest, so the rename stays on one filesystem, and carries the run's
+// ID, so two processes writing the same file don't share it.
+func tempPath(dest string) string {
+	return dest + "." + runID + ".tmp"
+}
+
+// EndRun removes the workspaces this process created. Call it on exit.
+func EndRun() {
+	runsMu.Lock()
+	defer runsMu.Unlock()
+	for dir := range runs {
+		os.RemoveAll(dir)
+		delete(runs, dir)
+	}
+}
+
+// CleanStaleRuns removes the workspaces of runs that have ended and returns
+// their paths
+func (m *Manager) CleanStaleRuns(now time.Time) []string {
+	runsMu.Lock()
+	defer runsMu.Unlock()
+	return m.cleanStaleRuns(now)
+}
+
+func (m *Manager) cleanStaleRuns(now time.Time) []string {
+	var removed []string
+	for _, dir := range m.staleRuns(now) {
+		if os.RemoveAll(dir) == nil {
+			removed = append(removed, dir)
+		}
+	}
+	return removed
+}
+
+// staleRuns lists the workspaces whose process has exited: on this host, when
+// no process has its ID any more; on others, once they're staleRunAge old.
+// runsMu must be held.
+func (m *Manager) staleRuns(now time.Time) []string {
+	root := filepath.Join(m.cfg.SnapshotDir, runsDirName)
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil
+	}
+	host, _ := os.Hostname()
+
+	var stale []string
+	for _, e := range entries {
+		dir := filepath.Join(root, e.Name())
+		if !e.IsDir() || runs[dir] {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		owner, _ := os.ReadFile(filepath.Join(dir, runOwnerFile))
+		ownerHost, pid, ok := parseRunOwner(string(owner))
+		switch {
+		case ok && ownerHost == host:
+			if !processAlive(pid) {
+				stale = append(stale, dir)
+			}
+		case now.Sub(info.ModTime()) > staleRunAge:
+			stale = append(stale, dir)
+		}
+	}
+	return stale
+}
+
+// parseRunOwner reads a workspace's owner file: "<host> <pid>"
+func parseRunOwner(owner string) (host string, pid int, ok bool) {
+	fields := strings.Fields(owner)
+	if len(fields) != 2 {
+		return "", 0, false
+	}
+	pid, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return "", 0, false
+	}
+	return fields[0], pid, true
+}
+
+// processAlive reports whether a process with this ID runs on this host.
+// Where signals can't tell, it is taken to be alive.
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return !errors.Is(p.Signal(syscall.Signal(0)), os.ErrProcessDone)
+}
@@ -0,0 +1,67 @@
+package snapshot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+func TestCleanStaleRuns(t *testing.T) {
+	tmpDir := t.TempDir()
+	m := NewManager(nil, &models.Config{SnapshotDir: tmpDir})
+	host, _ := os.Hostname()
+	now := time.Now()
+
+	write := func(id, owner string, age time.Duration) string {
+		dir := filepath.Join(tmpDir, runsDirName, id)
+		os.MkdirAll(dir, 0755)
+		os.WriteFile(filepath.Join(dir, runOwnerFile), []byte(owner), 0644)
+		os.Chtimes(dir, now.Add(-age), now.Add(-age))
+		return dir
+	}
+	// A pid far above any pid_max belongs to no process
+	ended := write("ended", fmt.Sprintf("%s %d\n", host, 1<<30), time.Minute)
+	live := write("live", fmt.Sprintf("%s %d\n", host, os.Getpid()), 48*time.Hour)
+	remote := write("remote", "elsewhere 1\n", time.Hour)
+	remoteOld := write("remote-old", "elsewhere 1\n", 2*staleRunAge)
+	starting := write("starting", "", time.Minute)
+
+	removed := m.CleanStaleRuns(now)
+	if len(removed) != 2 || removed[0] != ended || removed[1] != remoteOld {
+		t.Errorf("CleanStaleRuns() = %v, want [%s %s]", removed, ended, remoteOld)
+	}
+	for _, dir := range []string{live, remote, starting} {
+		if _, err := os.Stat(dir); err != nil {
+			t.Errorf("%s was removed: %v", filepath.Base(dir), err)
+		}
+	}
+}
+
+func TestWorkspace(t *testing.T) {
+	tmpDir := t.TempDir()
+	m := NewManager(nil, &models.Config{SnapshotDir: tmpDir})
+
+	dir, err := m.tempDir("bake-")
+	if err != nil {
+		t.Fatalf("tempDir() failed: %v", err)
+	}
+	workspace := filepath.Join(tmpDir, runsDirName, runID)
+	if filepath.Dir(dir) != workspace {
+		t.Errorf("tempDir() = %s, want a directory in %s", dir, workspace)
+	}
+	if snapshots, _ := m.List(); len(snapshots) != 0 {
+		t.Errorf("List() = %v, want the workspace left out", snapshots)
+	}
+	if report, _ := m.Fsck(false, time.Now()); len(report.Problems) != 0 {
+		t.Errorf("Fsck() reported a live workspace: %+v", report.Problems)
+	}
+
+	EndRun()
+	if _, err := os.Stat(workspace); !os.IsNotExist(err) {
+		t.Errorf("EndRun() left %s behind", workspace)
+	}
+}
@@ -0,0 +1,244 @@
+// Package sqldiff compares plain-text pg_dump/mysqldump output table by table
+package sqldiff
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	createTableRe = regexp.MustCompile(`^CREATE (?:UNLOGGED )?TABLE (?:IF NOT EXISTS )?(\S+) \($`)
+	copyRe        = regexp.MustCompile(`^COPY (\S+) (?:\(.*\) )?FROM stdin;$`)
+	insertRe      = regexp.MustCompile(`^INSERT INTO (\S+) (?:\(.*?\) )?VALUES (.*);$`)
+)
+
+// Table is a table parsed from a logical dump
+type Table struct {
+	Name     string
+	Columns  []string
+	RowCount int
+	Rows     []string // Raw rows, kept only for small tables
+	Partial  bool     // Rows were dropped because the table exceeded the keep limit
+}
+
+// Dump is the set of tables found in a logical dump
+type Dump struct {
+	Tables map[string]*Table
+}
+
+// ParseFile parses a dump file (see Parse)
+func ParseFile(path string, keepRows int) (*Dump, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f, keepRows)
+}
+
+// Parse reads a plain-text pg_dump or mysqldump. Row data is kept for tables
+// with at most keepRows rows so small tables can be compared row by row.
+func Parse(r io.Reader, keepRows int) (*Dump, error) {
+	dump := &Dump{Tables: make(map[string]*Table)}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 1024*1024), 256*1024*1024) // extended inserts can be huge
+
+	var createTable, copyTable *Table
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case createTable != nil:
+			trimmed := strings.TrimSpace(line)
+			if strings.HasPrefix(trimmed, ")") {
+				createTable = nil
+				continue
+			}
+			createTable.Columns = append(createTable.Columns, strings.TrimSuffix(trimmed, ","))
+
+		case copyTable != nil:
+			if line == `\.` {
+				copyTable = nil
+				continue
+			}
+			copyTable.addRow(line, keepRows)
+
+		default:
+			if m := createTableRe.FindStringSubmatch(line); m != nil {
+				createTable = dump.table(m[1])
+				createTable.Columns = nil
+			} else if m := copyRe.FindStringSubmatch(line); m != nil {
+				copyTable = dump.table(m[1])
+			} else if m := insertRe.FindStringSubmatch(line); m != nil {
+				t := dump.table(m[1])
+				for _, row := range splitTuples(m[2]) {
+					t.addRow(row, keepRows)
+				}
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to parse dump: %w", err)
+	}
+	return dump, nil
+}
+
+// table returns the named table, creating it on first use
+func (d *Dump) table(name string) *Table {
+	name = normalizeName(name)
+	t, ok := d.Tables[name]
+	if !ok {
+		t = &Table{Name: name}
+		d.Tables[name] = t
+	}
+	return t
+}
+
+func (t *Table) addRow(row string, keepRows int) {
+	t.RowCount++
+	if t.Partial {
+		return
+	}
+	if t.RowCount > keepRows {
+		t.Rows = nil
+		t.Partial = true
+		return
+	}
+	t.Rows = append(t.Rows, row)
+}
+
+// normalizeName strips identifier quoting (`users`, "users")
+func normalizeName(name string) string {
+	return strings.NewReplacer("`", "", `"`, "").Replace(name)
+}
+
+// splitTuples splits "(1,'a'),(2,'b')" into "1,'a'" and "2,'b'"
+func splitTuples(values string) []string {
+	var tuples []string
+	var current strings.Builder
+	depth := 0
+	inQuote, escaped := false, false
+
+	for _, ch := range values {
+		switch {
+		case escaped:
+			escaped = false
+		case inQuote && ch == '\\':
+			escaped = true
+		case ch == '\'':
+			inQuote = !inQuote
+		case !inQuote && ch == '(':
+			depth++
+			if depth == 1 {
+				current.Reset()
+				continue
+			}
+		case !inQuote && ch == ')':
+			depth--
+			if depth == 0 {
+				tuples = append(tuples, current.String())
+				continue
+			}
+		}
+		if depth > 0 {
+			current.WriteRune(ch)
+		}
+	}
+
+	return tuples
+}
+
+// Table statuses in a comparison
+const (
+	StatusAdded     = "added"
+	StatusRemoved   = "removed"
+	StatusChanged   = "changed"
+	StatusUnchanged = "unchanged"
+)
+
+// TableDiff describes how one table differs between two dumps
+type TableDiff struct {
+	Name           string   `json:"name"`
+	Status         string   `json:"status"`
+	AddedColumns   []string `json:"added_columns,omitempty"`
+	RemovedColumns []string `json:"removed_columns,omitempty"`
+	RowsBefore     int      `json:"rows_before"`
+	RowsAfter      int      `json:"rows_after"`
+	DataCompared   bool     `json:"data_compared"`
+	AddedRows      []string `json:"added_rows,omitempty"`
+	RemovedRows    []string `json:"removed_rows,omitempty"`
+}
+
+// Compare reports table-level differences from a to b. When compareData is set,
+// tables small enough to have kept their rows are also compared row by row.
+func Compare(a, b *Dump, compareData bool) []TableDiff {
+	names := make(map[string]bool)
+	for n := range a.Tables {
+		names[n] = true
+	}
+	for n := range b.Tables {
+		names[n] = true
+	}
+
+	var diffs []TableDiff
+	for name := range names {
+		before, after := a.Tables[name], b.Tables[name]
+		d := TableDiff{Name: name}
+
+		switch {
+		case before == nil:
+			d.Status = StatusAdded
+			d.RowsAfter = after.RowCount
+			d.AddedColumns = after.Columns
+		case after == nil:
+			d.Status = StatusRemoved
+			d.RowsBefore = before.RowCount
+			d.RemovedColumns = before.Columns
+		default:
+			d.RowsBefore, d.RowsAfter = before.RowCount, after.RowCount
+			d.AddedColumns = difference(after.Columns, before.Columns)
+			d.RemovedColumns = difference(before.Columns, after.Columns)
+			if compareData && !before.Partial && !after.Partial {
+				d.DataCompared = true
+				d.AddedRows = difference(after.Rows, before.Rows)
+				d.RemovedRows = difference(before.Rows, after.Rows)
+			}
+
+			d.Status = StatusUnchanged
+			if d.RowsBefore != d.RowsAfter || len(d.AddedColumns) > 0 || len(d.RemovedColumns) > 0 ||
+				len(d.AddedRows) > 0 || len(d.RemovedRows) > 0 {
+				d.Status = StatusChanged
+			}
+		}
+
+		diffs = append(diffs, d)
+	}
+
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Name < diffs[j].Name })
+	return diffs
+}
+
+// difference returns items in a that aren't in b, respecting duplicates
+func difference(a, b []string) []string {
+	counts := make(map[string]int)
+	for _, s := range b {
+		counts[s]++
+	}
+
+	var out []string
+	for _, s := range a {
+		if counts[s] > 0 {
+			counts[s]--
+			continue
+		}
+		out = append(out, s)
+	}
+	return out
+}
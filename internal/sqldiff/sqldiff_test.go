@@ -0,0 +1,146 @@
+package sqldiff
+
+import (
+	"strings"
+	"testing"
+)
+
+const pgDumpBefore = `--
+-- PostgreSQL database dump
+--
+
+CREATE TABLE public.users (
+    id integer NOT NULL,
+    email text NOT NULL
+);
+
+CREATE TABLE public.sessions (
+    id integer NOT NULL
+);
+
+COPY public.users (id, email) FROM stdin;
+1	alice@example.com
+2	bob@example.com
+\.
+
+COPY public.sessions (id) FROM stdin;
+\.
+`
+
+const pgDumpAfter = `CREATE TABLE public.users (
+    id integer NOT NULL,
+    email text NOT NULL,
+    name text
+);
+
+CREATE TABLE public.orders (
+    id integer NOT NULL
+);
+
+COPY public.users (id, email, name) FROM stdin;
+1	alice@example.com	\N
+3	carol@example.com	Carol
+4	dave@example.com	Dave
+\.
+
+COPY public.orders (id) FROM stdin;
+10
+\.
+`
+
+func TestParse_Postgres(t *testing.T) {
+	dump, err := Parse(strings.NewReader(pgDumpBefore), 100)
+	if err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+
+	users := dump.Tables["public.users"]
+	if users == nil {
+		t.Fatal("expected public.users table")
+	}
+	if users.RowCount != 2 {
+		t.Errorf("users RowCount = %d, want 2", users.RowCount)
+	}
+	if len(users.Columns) != 2 || users.Columns[1] != "email text NOT NULL" {
+		t.Errorf("users Columns = %v", users.Columns)
+	}
+	if dump.Tables["public.sessions"].RowCount != 0 {
+		t.Error("sessions should have no rows")
+	}
+}
+
+func TestParse_MySQL(t *testing.T) {
+	dump := `CREATE TABLE ` + "`users`" + ` (
+  ` + "`id`" + ` int NOT NULL,
+  ` + "`name`" + ` varchar(255) DEFAULT NULL,
+  PRIMARY KEY (` + "`id`" + `)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+INSERT INTO ` + "`users`" + ` VALUES (1,'O\'Brien'),(2,'a (b), c');
+INSERT INTO ` + "`users`" + ` VALUES (3,'Zed');
+`
+	parsed, err := Parse(strings.NewReader(dump), 100)
+	if err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+
+	users := parsed.Tables["users"]
+	if users == nil {
+		t.Fatal("expected users table")
+	}
+	if users.RowCount != 3 {
+		t.Errorf("users RowCount = %d, want 3", users.RowCount)
+	}
+	if len(users.Columns) != 3 {
+		t.Errorf("users Columns = %v, want 3 entries", users.Columns)
+	}
+	if users.Rows[1] != "2,'a (b), c'" {
+		t.Errorf("second row = %q", users.Rows[1])
+	}
+}
+
+func TestParse_PartialRows(t *testing.T) {
+	dump, err := Parse(strings.NewReader(pgDumpAfter), 2)
+	if err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+
+	users := dump.Tables["public.users"]
+	if !users.Partial || users.Rows != nil {
+		t.Error("users rows should be dropped beyond the keep limit")
+	}
+	if users.RowCount != 3 {
+		t.Errorf("users RowCount = %d, want 3", users.RowCount)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	before, _ := Parse(strings.NewReader(pgDumpBefore), 100)
+	after, _ := Parse(strings.NewReader(pgDumpAfter), 100)
+
+	diffs := Compare(before, after, true)
+	byName := make(map[string]TableDiff)
+	for _, d := range diffs {
+		byName[d.Name] = d
+	}
+
+	if byName["public.orders"].Status != StatusAdded {
+		t.Errorf("orders status = %s, want added", byName["public.orders"].Status)
+	}
+	if byName["public.sessions"].Status != StatusRemoved {
+		t.Errorf("sessions status = %s, want removed", byName["public.sessions"].Status)
+	}
+
+	users := byName["public.users"]
+	if users.Status != StatusChanged {
+		t.Errorf("users status = %s, want changed", users.Status)
+	}
+	if users.RowsBefore != 2 || users.RowsAfter != 3 {
+		t.Errorf("users rows = %d -> %d, want 2 -> 3", users.RowsBefore, users.RowsAfter)
+	}
+	if len(users.AddedColumns) != 1 || len(users.RemovedColumns) != 0 {
+		t.Errorf("users columns added=%v removed=%v", users.AddedColumns, users.RemovedColumns)
+	}
+	if !users.DataCompared || len(users.AddedRows) != 3 || len(users.RemovedRows) != 2 {
+		t.Errorf("users rows added=%v removed=%v", users.AddedRows, users.RemovedRows)
+	}
+}
@@ -0,0 +1,169 @@
+// Package tasks renders dataclean workflows as targets for the task runners
+// teams already use (make, Task, just), so everyone runs the same commands
+// with the same flags.
+package tasks
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+// Formats lists the task runners Render supports
+var Formats = []string{"make", "task", "just"}
+
+// Target is one named workflow: shell commands run in order
+type Target struct {
+	Name        string
+	Description string
+	Commands    []string
+}
+
+// Command joins a program and its arguments into a shell command line,
+// quoting the arguments that need it
+func Command(args ...string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = shellQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+func shellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./=:@,+", r))
+	}) < 0 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// Render writes targets in a task runner's format: a Makefile, a
+// Taskfile.yml, or a justfile
+func Render(format string, targets []Target) (string, error) {
+	switch format {
+	case "make":
+		return renderMake(targets), nil
+	case "task":
+		return renderTask(targets)
+	case "just":
+		return renderJust(targets), nil
+	}
+	return "", fmt.Errorf("unknown format %q (one of %s)", format, strings.Join(Formats, ", "))
+}
+
+const header = "Generated by 'dataclean generate tasks'"
+
+func renderMake(targets []Target) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", header)
+	names := make([]string, len(targets))
+	for i, t := range targets {
+		names[i] = t.Name
+	}
+	fmt.Fprintf(&b, ".PHONY: %s\n", strings.Join(names, " "))
+	for _, t := range targets {
+		fmt.Fprintf(&b, "\n# %s\n%s:\n", t.Description, t.Name)
+		for _, c := range t.Commands {
+			fmt.Fprintf(&b, "\t%s\n", strings.ReplaceAll(c, "$", "$$"))
+		}
+	}
+	return b.String()
+}
+
+func renderTask(targets []Target) (string, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\nversion: '3'\n\ntasks:\n", header)
+	for _, t := range targets {
+		desc, err := yamlScalar(t.Description)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "  %s:\n    desc: %s\n    cmds:\n", t.Name, desc)
+		for _, c := range t.Commands {
+			cmd, err := yamlScalar(c)
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(&b, "      - %s\n", cmd)
+		}
+	}
+	return b.String(), nil
+}
+
+// yamlScalar quotes a string as YAML would, on one line
+func yamlScalar(s string) (string, error) {
+	out, err := yaml.Marshal(s)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+func renderJust(targets []Target) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n", header)
+	for _, t := range targets {
+		fmt.Fprintf(&b, "\n# %s\n%s:\n", t.Description, t.Name)
+		for _, c := range t.Commands {
+			fmt.Fprintf(&b, "    %s\n", strings.ReplaceAll(c, "{{", "{{{{"))
+		}
+	}
+	return b.String()
+}
+
+// Workflows returns the standard targets for a project's volumes: taking and
+// restoring a baseline snapshot, and emptying cache volumes. configFile, when
+// set, is passed to every command.
+func Workflows(volumes []models.Volume, baseline, configFile string) []Target {
+	dataclean := func(args ...string) string {
+		if configFile != "" {
+			args = append(args, "--config", configFile)
+		}
+		return Command(append([]string{"dataclean"}, args...)...)
+	}
+
+	snapshotArgs := []string{"snapshot", baseline, "--tag", "baseline"}
+	var caches []string
+	logical := false
+	for _, v := range volumes {
+		switch v.DatastoreType {
+		case models.DatastorePostgres, models.DatastoreMySQL:
+			logical = true
+		case models.DatastoreRedis:
+			name := v.ComposeName
+			if name == "" {
+				name = v.Name
+			}
+			caches = append(caches, name)
+		}
+	}
+	if logical {
+		// A logical dump restores across server versions when files don't
+		snapshotArgs = append(snapshotArgs, "--logical")
+	}
+
+	targets := []Target{
+		{
+			Name:        "snapshot-baseline",
+			Description: fmt.Sprintf("Save the current data as the %s snapshot", baseline),
+			Commands:    []string{dataclean(snapshotArgs...)},
+		},
+		{
+			Name:        "refresh-dev",
+			Description: fmt.Sprintf("Replace the dev data with the %s snapshot", baseline),
+			Commands:    []string{dataclean("restore", baseline, "--force")},
+		},
+	}
+	if len(caches) > 0 {
+		targets = append(targets, Target{
+			Name:        "reset-cache",
+			Description: "Empty the cache volumes (" + strings.Join(caches, ", ") + ")",
+			Commands:    []string{dataclean("reset", "--force", "--include", strings.Join(caches, ","))},
+		})
+	}
+	return targets
+}
@@ -0,0 +1,51 @@
+package tasks
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+func TestWorkflows(t *testing.T) {
+	volumes := []models.Volume{
+		{Name: "shop_pgdata", ComposeName: "pgdata", DatastoreType: models.DatastorePostgres},
+		{Name: "shop_redisdata", ComposeName: "redisdata", DatastoreType: models.DatastoreRedis},
+	}
+	targets := Workflows(volumes, "seeded", "configs/data clean.yaml")
+	if len(targets) != 3 {
+		t.Fatalf("got %d targets, want 3: %+v", len(targets), targets)
+	}
+	if got, want := targets[0].Commands[0], "dataclean snapshot seeded --tag baseline --logical --config 'configs/data clean.yaml'"; got != want {
+		t.Errorf("snapshot-baseline = %q, want %q", got, want)
+	}
+	if got := targets[2].Commands[0]; !strings.Contains(got, "reset --force --include redisdata") {
+		t.Errorf("reset-cache = %q", got)
+	}
+
+	// No Redis, no Postgres: no reset-cache and no logical dumps
+	targets = Workflows([]models.Volume{{Name: "shop_uploads", DatastoreType: models.DatastoreGeneric}}, "baseline", "")
+	if len(targets) != 2 || strings.Contains(targets[0].Commands[0], "--logical") {
+		t.Errorf("unexpected targets: %+v", targets)
+	}
+}
+
+func TestRender(t *testing.T) {
+	targets := []Target{{Name: "refresh-dev", Description: "Restore: baseline", Commands: []string{"echo $HOME {{x}}"}}}
+
+	out, err := Render("make", targets)
+	if err != nil || !strings.Contains(out, ".PHONY: refresh-dev") || !strings.Contains(out, "\techo $$HOME {{x}}\n") {
+		t.Errorf("make output:\n%s (%v)", out, err)
+	}
+	out, err = Render("task", targets)
+	if err != nil || !strings.Contains(out, "desc: 'Restore: baseline'") || !strings.Contains(out, "      - echo $HOME {{x}}\n") {
+		t.Errorf("task output:\n%s (%v)", out, err)
+	}
+	out, err = Render("just", targets)
+	if err != nil || !strings.Contains(out, "refresh-dev:\n    echo $HOME {{{{x}}\n") {
+		t.Errorf("just output:\n%s (%v)", out, err)
+	}
+	if _, err := Render("ant", targets); err == nil {
+		t.Error("expected error for an unknown format")
+	}
+}
@@ -0,0 +1,105 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+)
+
+// KeyMap holds the key bindings shared by the selectors and the tree browser
+type KeyMap struct {
+	Up       key.Binding
+	Down     key.Binding
+	Toggle   key.Binding
+	Confirm  key.Binding
+	Expand   key.Binding
+	Collapse key.Binding
+	Filter   key.Binding
+	Sort     key.Binding
+	Tag      key.Binding
+	Help     key.Binding
+	Quit     key.Binding
+}
+
+// DefaultKeyMap returns the built-in bindings
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		Up:       key.NewBinding(key.WithKeys("up", "k"), key.WithHelp("↑/k", "up")),
+		Down:     key.NewBinding(key.WithKeys("down", "j"), key.WithHelp("↓/j", "down")),
+		Toggle:   key.NewBinding(key.WithKeys(" "), key.WithHelp("space", "toggle")),
+		Confirm:  key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "confirm")),
+		Expand:   key.NewBinding(key.WithKeys("enter", " ", "right", "l"), key.WithHelp("enter/→", "expand")),
+		Collapse: key.NewBinding(key.WithKeys("left", "h"), key.WithHelp("←/h", "collapse")),
+		Filter:   key.NewBinding(key.WithKeys("/"), key.WithHelp("/", "filter")),
+		Sort:     key.NewBinding(key.WithKeys("s"), key.WithHelp("s", "sort")),
+		Tag:      key.NewBinding(key.WithKeys("t"), key.WithHelp("t", "tag")),
+		Help:     key.NewBinding(key.WithKeys("?"), key.WithHelp("?", "help")),
+		Quit:     key.NewBinding(key.WithKeys("q", "esc", "ctrl+c"), key.WithHelp("q", "quit")),
+	}
+}
+
+// NewKeyMap returns the default bindings with actions remapped from the
+// config's keymap section (action name to keys, e.g. up: [w, up])
+func NewKeyMap(overrides map[string][]string) (KeyMap, error) {
+	km := DefaultKeyMap()
+	for action, keys := range overrides {
+		b := km.binding(action)
+		if b == nil {
+			return km, fmt.Errorf("unknown keymap action %q (valid: %s)", action, strings.Join(keyActions(), ", "))
+		}
+		if len(keys) == 0 {
+			return km, fmt.Errorf("keymap action %q has no keys", action)
+		}
+
+		bound := make([]string, len(keys))
+		names := make([]string, len(keys))
+		for i, k := range keys {
+			if k == "space" {
+				k = " " // what bubbletea reports for the space bar
+			}
+			bound[i] = k
+			names[i] = strings.ReplaceAll(k, " ", "space")
+		}
+		b.SetKeys(bound...)
+		b.SetHelp(strings.Join(names, "/"), b.Help().Desc)
+	}
+	return km, nil
+}
+
+// binding returns the binding for a config action name
+func (k *KeyMap) binding(action string) *key.Binding {
+	switch action {
+	case "up":
+		return &k.Up
+	case "down":
+		return &k.Down
+	case "toggle":
+		return &k.Toggle
+	case "confirm":
+		return &k.Confirm
+	case "expand":
+		return &k.Expand
+	case "collapse":
+		return &k.Collapse
+	case "filter":
+		return &k.Filter
+	case "sort":
+		return &k.Sort
+	case "tag":
+		return &k.Tag
+	case "help":
+		return &k.Help
+	case "quit":
+		return &k.Quit
+	}
+	return nil
+}
+
+// keyActions lists the action names accepted in the keymap config
+func keyActions() []string {
+	actions := []string{"up", "down", "toggle", "confirm", "expand", "collapse", "filter", "sort", "tag", "help", "quit"}
+	sort.Strings(actions)
+	return actions
+}
@@ -0,0 +1,45 @@
+package tui
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestNewKeyMap(t *testing.T) {
+	km, err := NewKeyMap(map[string][]string{
+		"up":     {"w"},
+		"toggle": {"x", "space"},
+	})
+	if err != nil {
+		t.Fatalf("NewKeyMap() failed: %v", err)
+	}
+
+	if !key.Matches(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("w")}, km.Up) {
+		t.Error("expected w to move up")
+	}
+	if key.Matches(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("k")}, km.Up) {
+		t.Error("expected k to be unbound after remapping up")
+	}
+	if !key.Matches(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")}, km.Toggle) {
+		t.Error("expected space to toggle")
+	}
+	if got := km.Toggle.Help().Key; got != "x/space" {
+		t.Errorf("toggle help = %q, want %q", got, "x/space")
+	}
+
+	// Untouched actions keep their defaults
+	if !key.Matches(tea.KeyMsg{Type: tea.KeyEnter}, km.Confirm) {
+		t.Error("expected enter to confirm")
+	}
+}
+
+func TestNewKeyMap_Invalid(t *testing.T) {
+	if _, err := NewKeyMap(map[string][]string{"jump": {"g"}}); err == nil {
+		t.Error("expected error for unknown action")
+	}
+	if _, err := NewKeyMap(map[string][]string{"quit": {}}); err == nil {
+		t.Error("expected error for action without keys")
+	}
+}
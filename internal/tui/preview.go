@@ -0,0 +1,93 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+// renderPreview describes a snapshot for the selector's preview pane
+func renderPreview(snap models.Snapshot, all []models.Snapshot, now time.Time) string {
+	var b strings.Builder
+	b.WriteString(titleStyle.UnsetMarginLeft().Render(snap.Name))
+	b.WriteString("\n\n")
+
+	fmt.Fprintf(&b, "%s %s (%s)\n", metaStyle.Render("Created:"),
+		formatAge(now.Sub(snap.Timestamp)), snap.Timestamp.Format("2006-01-02 15:04"))
+	fmt.Fprintf(&b, "%s %s\n", metaStyle.Render("Size:   "), snap.SizeHuman)
+	if len(snap.Tags) > 0 {
+		fmt.Fprintf(&b, "%s %s\n", metaStyle.Render("Tags:   "), strings.Join(snap.Tags, ", "))
+	}
+	if chain := parentChain(snap, all); len(chain) > 0 {
+		fmt.Fprintf(&b, "%s %s\n", metaStyle.Render("Parents:"), strings.Join(chain, " → "))
+	}
+	if snap.Description != "" {
+		b.WriteString("\n")
+		b.WriteString(snap.Description)
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(metaStyle.Render(fmt.Sprintf("Volumes (%d):", len(snap.Volumes))))
+	b.WriteString("\n")
+	for _, v := range snap.Volumes {
+		_, icon := models.GetDatastoreInfo(v.DatastoreType)
+		size := v.SizeHuman
+		if size == "" {
+			size = "-"
+		}
+		fmt.Fprintf(&b, "  %s %s  %s  %s\n", icon, v.Name, metaStyle.Render(string(v.DatastoreType)), size)
+	}
+
+	return b.String()
+}
+
+// parentChain lists a snapshot's ancestors, nearest first. A missing parent
+// ends the chain with a marker rather than failing the preview.
+func parentChain(snap models.Snapshot, all []models.Snapshot) []string {
+	byName := make(map[string]models.Snapshot, len(all))
+	for _, s := range all {
+		byName[s.Name] = s
+	}
+
+	var chain []string
+	seen := map[string]bool{snap.Name: true}
+	for parent := snap.ParentName; parent != ""; {
+		if seen[parent] {
+			break // corrupt metadata with a cycle
+		}
+		seen[parent] = true
+
+		p, ok := byName[parent]
+		if !ok {
+			chain = append(chain, parent+" (missing)")
+			break
+		}
+		chain = append(chain, parent)
+		parent = p.ParentName
+	}
+	return chain
+}
+
+// formatAge renders a duration as a rough "3 days ago"
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return plural(int(d.Minutes()), "minute") + " ago"
+	case d < 24*time.Hour:
+		return plural(int(d.Hours()), "hour") + " ago"
+	default:
+		return plural(int(d.Hours()/24), "day") + " ago"
+	}
+}
+
+func plural(n int, unit string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", unit)
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
@@ -0,0 +1,147 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+// palette holds the colors every TUI style is built from
+type palette struct {
+	Accent    lipgloss.TerminalColor // Titles, directories
+	Highlight lipgloss.TerminalColor // Selected item
+	Muted     lipgloss.TerminalColor // Secondary text
+	Subtle    lipgloss.TerminalColor // Help text, borders
+	Warning   lipgloss.TerminalColor
+	Error     lipgloss.TerminalColor
+	Success   lipgloss.TerminalColor
+}
+
+var (
+	darkPalette = palette{
+		Accent: lipgloss.Color("39"), Highlight: lipgloss.Color("170"),
+		Muted: lipgloss.Color("245"), Subtle: lipgloss.Color("241"),
+		Warning: lipgloss.Color("208"), Error: lipgloss.Color("196"), Success: lipgloss.Color("40"),
+	}
+	lightPalette = palette{
+		Accent: lipgloss.Color("25"), Highlight: lipgloss.Color("127"),
+		Muted: lipgloss.Color("240"), Subtle: lipgloss.Color("244"),
+		Warning: lipgloss.Color("166"), Error: lipgloss.Color("160"), Success: lipgloss.Color("28"),
+	}
+
+	// Theme presets selectable with theme.preset; "auto" follows the terminal background
+	presets = map[string]palette{
+		"auto":  adaptive(lightPalette, darkPalette),
+		"dark":  darkPalette,
+		"light": lightPalette,
+	}
+)
+
+var (
+	titleStyle        lipgloss.Style
+	itemStyle         lipgloss.Style
+	selectedItemStyle lipgloss.Style
+	warningStyle      lipgloss.Style
+	errorStyle        lipgloss.Style
+	successStyle      lipgloss.Style
+	helpBoxStyle      lipgloss.Style
+	dirStyle          lipgloss.Style
+	metaStyle         lipgloss.Style
+	helpStyle         lipgloss.Style
+	previewStyle      lipgloss.Style
+
+	current palette
+)
+
+func init() {
+	setStyles(presets["auto"])
+}
+
+// SetTheme applies the config's theme: a preset plus per-color overrides
+func SetTheme(t models.Theme) error {
+	name := t.Preset
+	if name == "" {
+		name = "auto"
+	}
+	p, ok := presets[name]
+	if !ok {
+		names := make([]string, 0, len(presets))
+		for n := range presets {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unknown theme preset %q (valid: %v)", t.Preset, names)
+	}
+
+	for _, o := range []struct {
+		value string
+		color *lipgloss.TerminalColor
+	}{
+		{t.Accent, &p.Accent},
+		{t.Highlight, &p.Highlight},
+		{t.Muted, &p.Muted},
+		{t.Warning, &p.Warning},
+		{t.Error, &p.Error},
+		{t.Success, &p.Success},
+	} {
+		if o.value != "" {
+			*o.color = lipgloss.Color(o.value)
+		}
+	}
+
+	setStyles(p)
+	return nil
+}
+
+// DisableColor renders the TUI without colors (NO_COLOR, TERM=dumb, --no-color)
+func DisableColor() {
+	lipgloss.SetColorProfile(termenv.Ascii)
+}
+
+// setStyles rebuilds every style from a palette
+func setStyles(p palette) {
+	current = p
+	titleStyle = lipgloss.NewStyle().MarginLeft(2).Bold(true).Foreground(p.Accent)
+	itemStyle = lipgloss.NewStyle().PaddingLeft(4)
+	selectedItemStyle = lipgloss.NewStyle().PaddingLeft(2).Foreground(p.Highlight)
+	warningStyle = lipgloss.NewStyle().Foreground(p.Warning)
+	errorStyle = lipgloss.NewStyle().Foreground(p.Error)
+	successStyle = lipgloss.NewStyle().Foreground(p.Success)
+	helpBoxStyle = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(p.Subtle).Padding(1, 2)
+	dirStyle = lipgloss.NewStyle().Foreground(p.Accent)
+	metaStyle = lipgloss.NewStyle().Foreground(p.Muted)
+	helpStyle = lipgloss.NewStyle().Foreground(p.Subtle)
+	previewStyle = lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder(), false, false, false, true).
+		BorderForeground(p.Subtle).
+		PaddingLeft(2)
+}
+
+// themedDelegate is the list delegate with the selection drawn in the theme's colors
+func themedDelegate() list.DefaultDelegate {
+	d := list.NewDefaultDelegate()
+	d.Styles.SelectedTitle = d.Styles.SelectedTitle.Foreground(current.Highlight).BorderForeground(current.Highlight)
+	d.Styles.SelectedDesc = d.Styles.SelectedDesc.Foreground(current.Highlight).BorderForeground(current.Highlight)
+	return d
+}
+
+// adaptive combines two palettes into one that follows the terminal background
+func adaptive(light, dark palette) palette {
+	pick := func(l, d lipgloss.TerminalColor) lipgloss.TerminalColor {
+		return lipgloss.AdaptiveColor{Light: string(l.(lipgloss.Color)), Dark: string(d.(lipgloss.Color))}
+	}
+	return palette{
+		Accent:    pick(light.Accent, dark.Accent),
+		Highlight: pick(light.Highlight, dark.Highlight),
+		Muted:     pick(light.Muted, dark.Muted),
+		Subtle:    pick(light.Subtle, dark.Subtle),
+		Warning:   pick(light.Warning, dark.Warning),
+		Error:     pick(light.Error, dark.Error),
+		Success:   pick(light.Success, dark.Success),
+	}
+}
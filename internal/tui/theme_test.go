@@ -0,0 +1,27 @@
+package tui
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+func TestSetTheme(t *testing.T) {
+	defer setStyles(presets["auto"])
+
+	if err := SetTheme(models.Theme{Preset: "light", Accent: "#0000aa"}); err != nil {
+		t.Fatalf("SetTheme() failed: %v", err)
+	}
+	if current.Accent != lipgloss.Color("#0000aa") {
+		t.Errorf("Accent = %v, want override", current.Accent)
+	}
+	if current.Highlight != lightPalette.Highlight {
+		t.Errorf("Highlight = %v, want light preset", current.Highlight)
+	}
+
+	if err := SetTheme(models.Theme{Preset: "solarized"}); err == nil {
+		t.Error("expected error for unknown preset")
+	}
+}
@@ -0,0 +1,255 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbletea"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+// TreeNode is a file or directory in the browse tree
+type TreeNode struct {
+	Name     string
+	Entry    models.ArchiveEntry
+	Children []*TreeNode
+	Expanded bool
+}
+
+// BuildTree groups archive entries into a directory tree under a named root
+func BuildTree(root string, entries []models.ArchiveEntry) *TreeNode {
+	rootNode := &TreeNode{Name: root, Entry: models.ArchiveEntry{IsDir: true}, Expanded: true}
+	index := map[string]*TreeNode{"": rootNode}
+
+	// Parents sort before children, so intermediate directories exist when needed
+	sorted := append([]models.ArchiveEntry(nil), entries...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Path < sorted[j].Path })
+
+	for _, e := range sorted {
+		parent := rootNode
+		parts := strings.Split(e.Path, "/")
+		for i := range parts[:len(parts)-1] {
+			dir := strings.Join(parts[:i+1], "/")
+			node, ok := index[dir]
+			if !ok {
+				node = &TreeNode{Name: parts[i], Entry: models.ArchiveEntry{Path: dir, IsDir: true}}
+				index[dir] = node
+				parent.Children = append(parent.Children, node)
+			}
+			parent = node
+		}
+
+		if node, ok := index[e.Path]; ok {
+			node.Entry = e // directory created implicitly before its own header
+			continue
+		}
+		node := &TreeNode{Name: parts[len(parts)-1], Entry: e}
+		index[e.Path] = node
+		parent.Children = append(parent.Children, node)
+	}
+
+	rootNode.finalize()
+	return rootNode
+}
+
+// finalize sorts children (directories first) and rolls file sizes up into directories
+func (n *TreeNode) finalize() int64 {
+	if !n.Entry.IsDir {
+		return n.Entry.SizeBytes
+	}
+	sort.Slice(n.Children, func(i, j int) bool {
+		a, b := n.Children[i], n.Children[j]
+		if a.Entry.IsDir != b.Entry.IsDir {
+			return a.Entry.IsDir
+		}
+		return a.Name < b.Name
+	})
+	var total int64
+	for _, c := range n.Children {
+		total += c.finalize()
+	}
+	n.Entry.SizeBytes = total
+	return total
+}
+
+// treeRow is a visible node with its indentation depth
+type treeRow struct {
+	node  *TreeNode
+	depth int
+}
+
+// TreeModel is a bubbletea model for browsing archive trees
+type TreeModel struct {
+	title    string
+	roots    []*TreeNode
+	keys     KeyMap
+	help     help.Model
+	showHelp bool
+	cursor   int
+	offset   int
+	width    int
+	height   int
+	quitting bool
+}
+
+// NewTreeBrowser creates a TUI for browsing one or more archive trees
+func NewTreeBrowser(title string, roots []*TreeNode, keys KeyMap) TreeModel {
+	return TreeModel{title: title, roots: roots, keys: keys, help: help.New(), height: 20}
+}
+
+// rows flattens the expanded part of the tree
+func (m TreeModel) rows() []treeRow {
+	var rows []treeRow
+	var walk func(n *TreeNode, depth int)
+	walk = func(n *TreeNode, depth int) {
+		rows = append(rows, treeRow{node: n, depth: depth})
+		if n.Expanded {
+			for _, c := range n.Children {
+				walk(c, depth+1)
+			}
+		}
+	}
+	for _, r := range m.roots {
+		walk(r, 0)
+	}
+	return rows
+}
+
+// Init implements bubbletea.Model
+func (m TreeModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update implements bubbletea.Model
+func (m TreeModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	rows := m.rows()
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if m.showHelp {
+			// Any key closes the overlay
+			m.showHelp = false
+			return m, nil
+		}
+
+		switch {
+		case key.Matches(msg, m.keys.Quit):
+			m.quitting = true
+			return m, tea.Quit
+		case key.Matches(msg, m.keys.Help):
+			m.showHelp = true
+		case key.Matches(msg, m.keys.Up):
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case key.Matches(msg, m.keys.Down):
+			if m.cursor < len(rows)-1 {
+				m.cursor++
+			}
+		case key.Matches(msg, m.keys.Expand):
+			if node := rows[m.cursor].node; node.Entry.IsDir {
+				node.Expanded = !node.Expanded
+			}
+		case key.Matches(msg, m.keys.Collapse):
+			row := rows[m.cursor]
+			if row.node.Entry.IsDir && row.node.Expanded {
+				row.node.Expanded = false
+				break
+			}
+			// Jump to parent directory
+			for i := m.cursor - 1; i >= 0; i-- {
+				if rows[i].depth < row.depth {
+					m.cursor = i
+					break
+				}
+			}
+		}
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height - 4
+		m.help.Width = msg.Width
+	}
+
+	// Keep the cursor visible
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.height > 0 && m.cursor >= m.offset+m.height {
+		m.offset = m.cursor - m.height + 1
+	}
+
+	return m, nil
+}
+
+// View implements bubbletea.Model
+func (m TreeModel) View() string {
+	if m.quitting {
+		return ""
+	}
+	if m.showHelp {
+		return helpOverlay(m.help, [][]key.Binding{
+			{m.keys.Up, m.keys.Down},
+			{m.keys.Expand, m.keys.Collapse},
+			{m.keys.Help, m.keys.Quit},
+		}, m.width, m.height+4)
+	}
+
+	var b strings.Builder
+	b.WriteString(titleStyle.Render(m.title))
+	b.WriteString("\n\n")
+
+	rows := m.rows()
+	end := m.offset + m.height
+	if end > len(rows) {
+		end = len(rows)
+	}
+	for i := m.offset; i < end; i++ {
+		b.WriteString(m.renderRow(rows[i], i == m.cursor))
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(m.help.ShortHelpView([]key.Binding{
+		m.keys.Up, m.keys.Down, m.keys.Expand, m.keys.Collapse, m.keys.Help, m.keys.Quit,
+	}))
+	return b.String()
+}
+
+func (m TreeModel) renderRow(row treeRow, selected bool) string {
+	n := row.node
+	indent := strings.Repeat("  ", row.depth)
+
+	icon := "  "
+	name := n.Name
+	if n.Entry.IsDir {
+		icon = "▸ "
+		if n.Expanded {
+			icon = "▾ "
+		}
+		name = dirStyle.Render(name + "/")
+	} else if n.Entry.LinkTarget != "" {
+		name = fmt.Sprintf("%s -> %s", name, n.Entry.LinkTarget)
+	}
+
+	meta := models.FormatSize(n.Entry.SizeBytes)
+	if !n.Entry.ModTime.IsZero() {
+		meta += "  " + n.Entry.ModTime.Format("2006-01-02 15:04")
+	}
+
+	line := fmt.Sprintf("%s%s%s  %s", indent, icon, name, metaStyle.Render(meta))
+	if selected {
+		return selectedItemStyle.Render("> " + line)
+	}
+	return itemStyle.Render(line)
+}
+
+// RunTreeBrowser runs the archive tree browsing TUI
+func RunTreeBrowser(title string, roots []*TreeNode, keys KeyMap) error {
+	p := tea.NewProgram(NewTreeBrowser(title, roots, keys), tea.WithAltScreen())
+	_, err := p.Run()
+	return err
+}
@@ -2,7 +2,12 @@ package tui
 
 import (
 	"fmt"
+	"slices"
+	"sort"
+	"time"
 
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -10,15 +15,6 @@ import (
 	"github.com/stackgen-cli/dataclean/internal/models"
 )
 
-var (
-	titleStyle        = lipgloss.NewStyle().MarginLeft(2).Bold(true).Foreground(lipgloss.Color("39"))
-	itemStyle         = lipgloss.NewStyle().PaddingLeft(4)
-	selectedItemStyle = lipgloss.NewStyle().PaddingLeft(2).Foreground(lipgloss.Color("170"))
-	warningStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("208"))
-	errorStyle        = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
-	successStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("40"))
-)
-
 // VolumeItem represents a volume in the TUI list
 type VolumeItem struct {
 	Volume   models.Volume
@@ -26,7 +22,12 @@ type VolumeItem struct {
 }
 
 func (i VolumeItem) FilterValue() string { return i.Volume.Name }
-func (i VolumeItem) Title() string       { return i.Volume.Name }
+func (i VolumeItem) Title() string {
+	if i.Selected {
+		return "[x] " + i.Volume.Name
+	}
+	return "[ ] " + i.Volume.Name
+}
 func (i VolumeItem) Description() string {
 	_, icon := models.GetDatastoreInfo(i.Volume.DatastoreType)
 	return fmt.Sprintf("%s %s", icon, i.Volume.DatastoreType)
@@ -48,16 +49,22 @@ func (i SnapshotItem) Description() string {
 
 // Model represents the TUI application state
 type Model struct {
-	list       list.Model
-	mode       Mode
-	volumes    []models.Volume
-	snapshots  []models.Snapshot
-	selected   []models.Volume
-	err        error
-	quitting   bool
-	confirmed  bool
-	width      int
-	height     int
+	list      list.Model
+	keys      KeyMap
+	help      help.Model
+	showHelp  bool
+	mode      Mode
+	volumes   []models.Volume
+	snapshots []models.Snapshot
+	sortBy    SnapshotSort
+	tag       string // Only show snapshots with this tag ("" = all)
+	selected  []models.Volume
+	preselect bool // Volumes start selected, so confirming with none means none
+	err       error
+	quitting  bool
+	confirmed bool
+	width     int
+	height    int
 }
 
 // Mode represents the current TUI mode
@@ -69,42 +76,134 @@ const (
 	ModeConfirmDestructive
 )
 
+// SnapshotSort is the order of the snapshot selector
+type SnapshotSort int
+
+const (
+	SortByDate SnapshotSort = iota // Newest first
+	SortBySize                     // Largest first
+	SortByName
+)
+
+func (s SnapshotSort) String() string {
+	switch s {
+	case SortBySize:
+		return "size"
+	case SortByName:
+		return "name"
+	}
+	return "date"
+}
+
 // NewVolumeSelector creates a TUI for selecting volumes
-func NewVolumeSelector(volumes []models.Volume) Model {
+func NewVolumeSelector(volumes []models.Volume, preselected bool, keys KeyMap) Model {
 	items := make([]list.Item, len(volumes))
 	for i, v := range volumes {
-		items[i] = VolumeItem{Volume: v}
+		items[i] = VolumeItem{Volume: v, Selected: preselected}
 	}
 
-	l := list.New(items, list.NewDefaultDelegate(), 0, 0)
-	l.Title = "Select Volumes"
-	l.SetShowStatusBar(true)
-	l.SetFilteringEnabled(true)
-
+	l := newList(items, "Select Volumes", keys)
 	return Model{
-		list:    l,
-		mode:    ModeSelectVolumes,
-		volumes: volumes,
+		list:      l,
+		keys:      keys,
+		help:      help.New(),
+		mode:      ModeSelectVolumes,
+		volumes:   volumes,
+		preselect: preselected,
 	}
 }
 
 // NewSnapshotSelector creates a TUI for selecting a snapshot
-func NewSnapshotSelector(snapshots []models.Snapshot) Model {
-	items := make([]list.Item, len(snapshots))
-	for i, s := range snapshots {
+func NewSnapshotSelector(snapshots []models.Snapshot, keys KeyMap) Model {
+	m := Model{
+		list:      newList(nil, "Select Snapshot", keys),
+		keys:      keys,
+		help:      help.New(),
+		mode:      ModeSelectSnapshot,
+		snapshots: snapshots,
+	}
+	m.refreshSnapshots()
+	return m
+}
+
+// refreshSnapshots rebuilds the snapshot list for the current sort and tag
+// filter, and shows both in the title
+func (m *Model) refreshSnapshots() {
+	var shown []models.Snapshot
+	for _, s := range m.snapshots {
+		if m.tag == "" || slices.Contains(s.Tags, m.tag) {
+			shown = append(shown, s)
+		}
+	}
+
+	sort.SliceStable(shown, func(i, j int) bool {
+		a, b := shown[i], shown[j]
+		switch m.sortBy {
+		case SortBySize:
+			return a.SizeBytes > b.SizeBytes
+		case SortByName:
+			return a.Name < b.Name
+		}
+		return a.Timestamp.After(b.Timestamp)
+	})
+
+	items := make([]list.Item, len(shown))
+	for i, s := range shown {
 		items[i] = SnapshotItem{Snapshot: s}
 	}
+	m.list.SetItems(items)
+	m.list.ResetSelected()
+
+	title := fmt.Sprintf("Select Snapshot · sort: %s", m.sortBy)
+	if m.tag != "" {
+		title += fmt.Sprintf(" · tag: %s", m.tag)
+	}
+	m.list.Title = title
+}
+
+// snapshotTags returns every tag used by the snapshots, sorted
+func (m Model) snapshotTags() []string {
+	var tags []string
+	for _, s := range m.snapshots {
+		for _, t := range s.Tags {
+			if !slices.Contains(tags, t) {
+				tags = append(tags, t)
+			}
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// nextTag cycles the tag filter through all tags and back to none
+func (m Model) nextTag() string {
+	tags := m.snapshotTags()
+	i := slices.Index(tags, m.tag)
+	if m.tag == "" {
+		i = -1
+	}
+	if i+1 >= len(tags) {
+		return ""
+	}
+	return tags[i+1]
+}
 
-	l := list.New(items, list.NewDefaultDelegate(), 0, 0)
-	l.Title = "Select Snapshot"
+// newList creates a filterable list that navigates with keys. The list's own
+// help is hidden; Model renders a contextual footer instead.
+func newList(items []list.Item, title string, keys KeyMap) list.Model {
+	l := list.New(items, themedDelegate(), 0, 0)
+	l.Title = title
 	l.SetShowStatusBar(true)
 	l.SetFilteringEnabled(true)
+	l.SetShowHelp(false)
 
-	return Model{
-		list:      l,
-		mode:      ModeSelectSnapshot,
-		snapshots: snapshots,
-	}
+	l.KeyMap.CursorUp = keys.Up
+	l.KeyMap.CursorDown = keys.Down
+	l.KeyMap.Filter = keys.Filter
+	l.KeyMap.Quit = keys.Quit
+	l.KeyMap.ShowFullHelp.SetEnabled(false)
+	l.KeyMap.CloseFullHelp.SetEnabled(false)
+	return l
 }
 
 // Init implements bubbletea.Model
@@ -116,42 +215,62 @@ func (m Model) Init() tea.Cmd {
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "q", "ctrl+c":
+		if m.showHelp {
+			// Any key closes the overlay
+			m.showHelp = false
+			return m, nil
+		}
+		if m.handledByFilter(msg) {
+			break
+		}
+
+		switch {
+		case key.Matches(msg, m.keys.Quit):
 			m.quitting = true
 			return m, tea.Quit
-		case "enter":
+		case key.Matches(msg, m.keys.Help):
+			m.showHelp = true
+			return m, nil
+		case key.Matches(msg, m.keys.Confirm):
 			if m.mode == ModeSelectVolumes {
-				// Get selected item
-				if item, ok := m.list.SelectedItem().(VolumeItem); ok {
-					m.selected = append(m.selected, item.Volume)
+				// Collect toggled items, falling back to the highlighted one
+				// unless every volume started selected and was deselected
+				for _, li := range m.list.Items() {
+					if item, ok := li.(VolumeItem); ok && item.Selected {
+						m.selected = append(m.selected, item.Volume)
+					}
+				}
+				if len(m.selected) == 0 && !m.preselect {
+					if item, ok := m.list.SelectedItem().(VolumeItem); ok {
+						m.selected = append(m.selected, item.Volume)
+					}
 				}
-				m.quitting = true
-				m.confirmed = true
-				return m, tea.Quit
-			}
-			if m.mode == ModeSelectSnapshot {
-				m.quitting = true
-				m.confirmed = true
-				return m, tea.Quit
 			}
-		case " ":
+			m.quitting = true
+			m.confirmed = true
+			return m, tea.Quit
+		case key.Matches(msg, m.keys.Sort) && m.mode == ModeSelectSnapshot:
+			m.sortBy = (m.sortBy + 1) % 3
+			m.refreshSnapshots()
+			return m, nil
+		case key.Matches(msg, m.keys.Tag) && m.mode == ModeSelectSnapshot:
+			m.tag = m.nextTag()
+			m.refreshSnapshots()
+			return m, nil
+		case key.Matches(msg, m.keys.Toggle) && m.mode == ModeSelectVolumes:
 			// Toggle selection for multi-select
-			if m.mode == ModeSelectVolumes {
-				if item, ok := m.list.SelectedItem().(VolumeItem); ok {
-					item.Selected = !item.Selected
-					// Update item in list
-					items := m.list.Items()
-					items[m.list.Index()] = item
-					m.list.SetItems(items)
-				}
+			if item, ok := m.list.SelectedItem().(VolumeItem); ok {
+				item.Selected = !item.Selected
+				m.list.SetItem(m.list.GlobalIndex(), item)
 			}
+			return m, nil
 		}
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
-		m.list.SetWidth(msg.Width)
-		m.list.SetHeight(msg.Height - 4)
+		m.help.Width = msg.Width
+		m.list.SetWidth(m.listWidth())
+		m.list.SetHeight(msg.Height - 2)
 	}
 
 	var cmd tea.Cmd
@@ -159,12 +278,95 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+// handledByFilter reports whether a key belongs to the list's filter: typing
+// into the filter, or esc clearing an applied one
+func (m Model) handledByFilter(msg tea.KeyMsg) bool {
+	switch m.list.FilterState() {
+	case list.Filtering:
+		return true
+	case list.FilterApplied:
+		return key.Matches(msg, m.list.KeyMap.ClearFilter)
+	}
+	return false
+}
+
+// shortHelp returns the bindings shown in the footer for the current state
+func (m Model) shortHelp() []key.Binding {
+	if m.list.FilterState() == list.Filtering {
+		return []key.Binding{m.list.KeyMap.AcceptWhileFiltering, m.list.KeyMap.CancelWhileFiltering}
+	}
+	bindings := []key.Binding{m.keys.Up, m.keys.Down}
+	if m.mode == ModeSelectVolumes {
+		bindings = append(bindings, m.keys.Toggle)
+	}
+	bindings = append(bindings, m.keys.Confirm, m.keys.Filter)
+	if m.mode == ModeSelectSnapshot {
+		bindings = append(bindings, m.keys.Sort, m.keys.Tag)
+	}
+	if m.list.FilterState() == list.FilterApplied {
+		bindings = append(bindings, m.list.KeyMap.ClearFilter)
+	}
+	return append(bindings, m.keys.Help, m.keys.Quit)
+}
+
+// fullHelp returns every binding, grouped into columns for the overlay
+func (m Model) fullHelp() [][]key.Binding {
+	selection := []key.Binding{m.keys.Confirm}
+	if m.mode == ModeSelectVolumes {
+		selection = append([]key.Binding{m.keys.Toggle}, selection...)
+	}
+	if m.mode == ModeSelectSnapshot {
+		selection = append(selection, m.keys.Sort, m.keys.Tag)
+	}
+	return [][]key.Binding{
+		{m.keys.Up, m.keys.Down, m.list.KeyMap.PrevPage, m.list.KeyMap.NextPage},
+		selection,
+		{m.keys.Filter, m.list.KeyMap.ClearFilter, m.keys.Help, m.keys.Quit},
+	}
+}
+
 // View implements bubbletea.Model
 func (m Model) View() string {
 	if m.quitting {
 		return ""
 	}
-	return m.list.View()
+	if m.showHelp {
+		return helpOverlay(m.help, m.fullHelp(), m.width, m.height)
+	}
+	body := m.list.View()
+	if m.mode == ModeSelectSnapshot {
+		body = lipgloss.JoinHorizontal(lipgloss.Top, body, m.preview())
+	}
+	return body + "\n" + m.help.ShortHelpView(m.shortHelp())
+}
+
+// listWidth leaves the right half of the screen for the snapshot preview
+func (m Model) listWidth() int {
+	if m.mode == ModeSelectSnapshot {
+		return m.width / 2
+	}
+	return m.width
+}
+
+// preview renders the highlighted snapshot's details
+func (m Model) preview() string {
+	item, ok := m.list.SelectedItem().(SnapshotItem)
+	if !ok {
+		return ""
+	}
+	style := previewStyle.Width(m.width - m.listWidth() - 3).MaxHeight(m.height - 2)
+	return style.Render(renderPreview(item.Snapshot, m.snapshots, time.Now()))
+}
+
+// helpOverlay renders the full key reference centered on screen
+func helpOverlay(h help.Model, groups [][]key.Binding, width, height int) string {
+	h.ShowAll = true
+	box := helpBoxStyle.Render(titleStyle.Render("Keys") + "\n\n" + h.FullHelpView(groups) +
+		"\n\n" + helpStyle.Render("press any key to close"))
+	if width == 0 || height == 0 {
+		return box
+	}
+	return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, box)
 }
 
 // SelectedVolumes returns the selected volumes
@@ -186,10 +388,10 @@ func (m Model) Confirmed() bool {
 }
 
 // RunVolumeSelector runs the volume selection TUI
-func RunVolumeSelector(volumes []models.Volume) ([]models.Volume, error) {
-	m := NewVolumeSelector(volumes)
+func RunVolumeSelector(volumes []models.Volume, preselected bool, keys KeyMap) ([]models.Volume, error) {
+	m := NewVolumeSelector(volumes, preselected, keys)
 	p := tea.NewProgram(m, tea.WithAltScreen())
-	
+
 	finalModel, err := p.Run()
 	if err != nil {
 		return nil, err
@@ -204,10 +406,10 @@ func RunVolumeSelector(volumes []models.Volume) ([]models.Volume, error) {
 }
 
 // RunSnapshotSelector runs the snapshot selection TUI
-func RunSnapshotSelector(snapshots []models.Snapshot) (*models.Snapshot, error) {
-	m := NewSnapshotSelector(snapshots)
+func RunSnapshotSelector(snapshots []models.Snapshot, keys KeyMap) (*models.Snapshot, error) {
+	m := NewSnapshotSelector(snapshots, keys)
 	p := tea.NewProgram(m, tea.WithAltScreen())
-	
+
 	finalModel, err := p.Run()
 	if err != nil {
 		return nil, err
@@ -225,12 +427,12 @@ func RunSnapshotSelector(snapshots []models.Snapshot) (*models.Snapshot, error)
 func ConfirmDestructive(message string) (bool, error) {
 	fmt.Println(warningStyle.Render("⚠️  " + message))
 	fmt.Print("Type 'yes' to confirm: ")
-	
+
 	var response string
 	_, err := fmt.Scanln(&response)
 	if err != nil {
 		return false, err
 	}
-	
+
 	return response == "yes", nil
 }
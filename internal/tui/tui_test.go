@@ -0,0 +1,148 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/stackgen-cli/dataclean/internal/models"
+)
+
+func TestVolumeSelector_RemappedKeys(t *testing.T) {
+	keys, err := NewKeyMap(map[string][]string{"toggle": {"x"}, "confirm": {"y"}})
+	if err != nil {
+		t.Fatalf("NewKeyMap() failed: %v", err)
+	}
+	volumes := []models.Volume{{Name: "pgdata"}, {Name: "redis"}}
+
+	var m tea.Model = NewVolumeSelector(volumes, false, keys)
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+
+	fm := m.(Model)
+	if !fm.Confirmed() {
+		t.Fatal("expected selection to be confirmed")
+	}
+	if got := fm.SelectedVolumes(); len(got) != 1 || got[0].Name != "redis" {
+		t.Errorf("SelectedVolumes() = %v, want [redis]", got)
+	}
+}
+
+func TestModel_HelpOverlay(t *testing.T) {
+	var m tea.Model = NewSnapshotSelector([]models.Snapshot{{Name: "baseline"}}, DefaultKeyMap())
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("?")})
+	if !m.(Model).showHelp {
+		t.Fatal("expected ? to open the help overlay")
+	}
+
+	// The next key only closes the overlay
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+	if m.(Model).showHelp || m.(Model).quitting {
+		t.Error("expected q to close the overlay without quitting")
+	}
+}
+
+func TestRenderPreview(t *testing.T) {
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	all := []models.Snapshot{
+		{Name: "baseline", Timestamp: now.Add(-72 * time.Hour)},
+		{Name: "seeded", ParentName: "baseline"},
+	}
+	snap := models.Snapshot{
+		Name:        "after-migration",
+		Timestamp:   now.Add(-2 * time.Hour),
+		SizeHuman:   "45.2 MB",
+		Tags:        []string{"migration"},
+		Description: "Before schema v2",
+		ParentName:  "seeded",
+		Volumes:     []models.Volume{{Name: "pgdata", DatastoreType: models.DatastorePostgres, SizeHuman: "40 MB"}},
+	}
+
+	out := renderPreview(snap, all, now)
+	for _, want := range []string{"2 hours ago", "migration", "seeded → baseline", "Before schema v2", "pgdata", "40 MB"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("preview missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestParentChain_Missing(t *testing.T) {
+	chain := parentChain(models.Snapshot{Name: "b", ParentName: "a"}, nil)
+	if len(chain) != 1 || chain[0] != "a (missing)" {
+		t.Errorf("parentChain() = %v", chain)
+	}
+}
+
+func TestSnapshotSelector_SortAndTag(t *testing.T) {
+	now := time.Now()
+	snapshots := []models.Snapshot{
+		{Name: "b-small", Timestamp: now, SizeBytes: 10, Tags: []string{"ci"}},
+		{Name: "a-large", Timestamp: now.Add(-time.Hour), SizeBytes: 100, Tags: []string{"prod"}},
+		{Name: "c-mid", Timestamp: now.Add(-2 * time.Hour), SizeBytes: 50, Tags: []string{"ci"}},
+	}
+	names := func(m tea.Model) []string {
+		var out []string
+		for _, li := range m.(Model).list.Items() {
+			out = append(out, li.(SnapshotItem).Snapshot.Name)
+		}
+		return out
+	}
+	press := func(m tea.Model, k string) tea.Model {
+		m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+		return m
+	}
+
+	var m tea.Model = NewSnapshotSelector(snapshots, DefaultKeyMap())
+	if got := strings.Join(names(m), ","); got != "b-small,a-large,c-mid" {
+		t.Errorf("date order = %s", got)
+	}
+
+	m = press(m, "s")
+	if got := strings.Join(names(m), ","); got != "a-large,c-mid,b-small" {
+		t.Errorf("size order = %s", got)
+	}
+	m = press(m, "s")
+	if got := strings.Join(names(m), ","); got != "a-large,b-small,c-mid" {
+		t.Errorf("name order = %s", got)
+	}
+	if title := m.(Model).list.Title; !strings.Contains(title, "sort: name") {
+		t.Errorf("title = %q, want active sort shown", title)
+	}
+
+	m = press(m, "t")
+	if got := strings.Join(names(m), ","); got != "b-small,c-mid" {
+		t.Errorf("tag ci = %s", got)
+	}
+	if title := m.(Model).list.Title; !strings.Contains(title, "tag: ci") {
+		t.Errorf("title = %q, want active tag shown", title)
+	}
+	m = press(m, "t") // prod
+	m = press(m, "t") // back to all
+	if got := len(names(m)); got != 3 {
+		t.Errorf("expected tag filter to cycle back to all, got %d snapshots", got)
+	}
+}
+
+func TestVolumeSelector_DeselectAll(t *testing.T) {
+	volumes := []models.Volume{{Name: "pgdata"}, {Name: "redis"}}
+
+	var m tea.Model = NewVolumeSelector(volumes, true, DefaultKeyMap())
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeySpace})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeySpace})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	fm := m.(Model)
+	if !fm.Confirmed() {
+		t.Fatal("expected selection to be confirmed")
+	}
+	if got := fm.SelectedVolumes(); len(got) != 0 {
+		t.Errorf("SelectedVolumes() = %v, want none after deselecting every volume", got)
+	}
+}
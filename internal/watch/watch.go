@@ -0,0 +1,123 @@
+// Package watch detects file changes under a set of paths by polling
+// modification times, so it works on any filesystem (bind mounts, network
+// shares) without platform-specific notification APIs.
+package watch
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Watcher polls paths and reports changes once they settle
+type Watcher struct {
+	Paths    []string
+	Interval time.Duration // How often paths are scanned
+	Debounce time.Duration // Quiet period before a batch of changes is reported
+}
+
+// fileState is what a scan records per file
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// New creates a watcher with the given polling interval and debounce period
+func New(paths []string, interval, debounce time.Duration) *Watcher {
+	return &Watcher{Paths: paths, Interval: interval, Debounce: debounce}
+}
+
+// Run polls until ctx is cancelled. onChange receives the sorted list of files
+// that were created, modified, or removed since the last report. Changes that
+// keep arriving (an editor saving several files, a generator writing a batch)
+// are reported together once nothing has changed for the debounce period.
+func (w *Watcher) Run(ctx context.Context, onChange func(changed []string)) error {
+	last, err := scan(w.Paths)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+
+	pending := map[string]bool{}
+	var lastChange time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			current, err := scan(w.Paths)
+			if err != nil {
+				return err
+			}
+			if changed := diff(last, current); len(changed) > 0 {
+				for _, p := range changed {
+					pending[p] = true
+				}
+				lastChange = now
+			}
+			last = current
+
+			if len(pending) > 0 && now.Sub(lastChange) >= w.Debounce {
+				batch := make([]string, 0, len(pending))
+				for p := range pending {
+					batch = append(batch, p)
+				}
+				sort.Strings(batch)
+				pending = map[string]bool{}
+				onChange(batch)
+			}
+		}
+	}
+}
+
+// scan records the size and modification time of every file under paths.
+// Paths that don't exist yet are skipped so they can be created later.
+func scan(paths []string) (map[string]fileState, error) {
+	states := map[string]fileState{}
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil // removed between listing and stat
+			}
+			states[path] = fileState{size: info.Size(), modTime: info.ModTime()}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return states, nil
+}
+
+// diff returns the files that differ between two scans
+func diff(before, after map[string]fileState) []string {
+	var changed []string
+	for path, a := range after {
+		if b, ok := before[path]; !ok || b != a {
+			changed = append(changed, path)
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
@@ -0,0 +1,70 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDiff(t *testing.T) {
+	now := time.Now()
+	before := map[string]fileState{
+		"a.sql": {size: 10, modTime: now},
+		"b.sql": {size: 10, modTime: now},
+		"c.sql": {size: 10, modTime: now},
+	}
+	after := map[string]fileState{
+		"a.sql": {size: 10, modTime: now},
+		"b.sql": {size: 12, modTime: now.Add(time.Second)},
+		"d.sql": {size: 1, modTime: now},
+	}
+
+	got := diff(before, after)
+	want := []string{"b.sql", "c.sql", "d.sql"}
+	if len(got) != len(want) {
+		t.Fatalf("diff() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("diff()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestScan_MissingPath(t *testing.T) {
+	states, err := scan([]string{filepath.Join(t.TempDir(), "migrations")})
+	if err != nil {
+		t.Fatalf("scan() failed: %v", err)
+	}
+	if len(states) != 0 {
+		t.Errorf("scan() = %v, want empty", states)
+	}
+}
+
+func TestRun_Debounces(t *testing.T) {
+	dir := t.TempDir()
+	w := New([]string{dir}, 10*time.Millisecond, 50*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	batches := make(chan []string, 4)
+	go w.Run(ctx, func(changed []string) { batches <- changed })
+
+	// Let the initial scan happen, then write two files in quick succession
+	time.Sleep(30 * time.Millisecond)
+	os.WriteFile(filepath.Join(dir, "001_init.sql"), []byte("create table a();"), 0644)
+	time.Sleep(15 * time.Millisecond)
+	os.WriteFile(filepath.Join(dir, "002_users.sql"), []byte("create table b();"), 0644)
+
+	select {
+	case got := <-batches:
+		if len(got) != 2 {
+			t.Errorf("batch = %v, want both files in one batch", got)
+		}
+	case <-ctx.Done():
+		t.Fatal("no change reported")
+	}
+}